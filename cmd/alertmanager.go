@@ -0,0 +1,166 @@
+//go:build !minimal || monitoring || alertmanager
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+var alertmanagerOptions = vendors.AlertmanagerOptions{
+	Timeout:  envGet("ALERTMANAGER_TIMEOUT", 30).(int),
+	Insecure: envGet("ALERTMANAGER_INSECURE", false).(bool),
+	URL:      envGet("ALERTMANAGER_URL", "").(string),
+	User:     envGet("ALERTMANAGER_USER", "").(string),
+	Password: envGet("ALERTMANAGER_PASSWORD", "").(string),
+}
+
+var alertmanagerSilenceOptions = vendors.AlertmanagerSilenceOptions{
+	Matchers:  strings.Split(envGet("ALERTMANAGER_SILENCE_MATCHERS", "").(string), ";"),
+	StartsAt:  envGet("ALERTMANAGER_SILENCE_STARTS_AT", "").(string),
+	Duration:  envGet("ALERTMANAGER_SILENCE_DURATION", "1h").(string),
+	CreatedBy: envGet("ALERTMANAGER_SILENCE_CREATED_BY", "tools").(string),
+	Comment:   envGet("ALERTMANAGER_SILENCE_COMMENT", "").(string),
+}
+
+var alertmanagerExpireSilenceOptions = vendors.AlertmanagerExpireSilenceOptions{
+	ID: envGet("ALERTMANAGER_SILENCE_ID", "").(string),
+}
+
+var alertmanagerAlertsOptions = vendors.AlertmanagerAlertsOptions{
+	Filter:    strings.Split(envGet("ALERTMANAGER_ALERTS_FILTER", "").(string), ";"),
+	Receiver:  envGet("ALERTMANAGER_ALERTS_RECEIVER", "").(string),
+	Silenced:  envGet("ALERTMANAGER_ALERTS_SILENCED", false).(bool),
+	Inhibited: envGet("ALERTMANAGER_ALERTS_INHIBITED", false).(bool),
+}
+
+var alertmanagerOutput = common.OutputOptions{
+	Output: envGet("ALERTMANAGER_OUTPUT", "").(string),
+	Query:  envGet("ALERTMANAGER_OUTPUT_QUERY", "").(string),
+}
+
+func alertmanagerNew(stdout *common.Stdout) *vendors.Alertmanager {
+
+	common.Debug("Alertmanager", alertmanagerOptions, stdout)
+	common.Debug("Alertmanager", alertmanagerOutput, stdout)
+
+	return vendors.NewAlertmanager(alertmanagerOptions)
+}
+
+func NewAlertmanagerCommand() *cobra.Command {
+
+	alertmanagerCmd := &cobra.Command{
+		Use:   "alertmanager",
+		Short: "Alertmanager tools",
+	}
+	flags := alertmanagerCmd.PersistentFlags()
+	flags.IntVar(&alertmanagerOptions.Timeout, "alertmanager-timeout", alertmanagerOptions.Timeout, "Alertmanager timeout in seconds")
+	flags.BoolVar(&alertmanagerOptions.Insecure, "alertmanager-insecure", alertmanagerOptions.Insecure, "Alertmanager insecure")
+	flags.StringVar(&alertmanagerOptions.URL, "alertmanager-url", alertmanagerOptions.URL, "Alertmanager URL")
+	flags.StringVar(&alertmanagerOptions.User, "alertmanager-user", alertmanagerOptions.User, "Alertmanager user")
+	flags.StringVar(&alertmanagerOptions.Password, "alertmanager-password", alertmanagerOptions.Password, "Alertmanager password")
+	flags.StringVar(&alertmanagerOutput.Output, "alertmanager-output", alertmanagerOutput.Output, "Alertmanager output")
+	flags.StringVar(&alertmanagerOutput.Query, "alertmanager-output-query", alertmanagerOutput.Query, "Alertmanager output query")
+
+	silenceCmd := &cobra.Command{
+		Use:   "silence",
+		Short: "Silence methods",
+	}
+	alertmanagerCmd.AddCommand(silenceCmd)
+
+	silenceCreateCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create silence",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Alertmanager creating silence...")
+			common.Debug("Alertmanager", alertmanagerSilenceOptions, stdout)
+
+			bytes, err := idempotent("", alertmanagerSilenceOptions, func() ([]byte, error) {
+				return alertmanagerNew(stdout).CreateSilence(alertmanagerSilenceOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(alertmanagerOutput, "Alertmanager", []interface{}{alertmanagerOptions, alertmanagerSilenceOptions}, bytes, stdout)
+		},
+	}
+	flags = silenceCreateCmd.PersistentFlags()
+	flags.StringArrayVar(&alertmanagerSilenceOptions.Matchers, "alertmanager-silence-matchers", alertmanagerSilenceOptions.Matchers, "Alertmanager silence matcher, repeatable: name=value, name!=value, name=~regex, name!~regex")
+	flags.StringVar(&alertmanagerSilenceOptions.StartsAt, "alertmanager-silence-starts-at", alertmanagerSilenceOptions.StartsAt, "Alertmanager silence starts at (RFC3339), now if empty")
+	flags.StringVar(&alertmanagerSilenceOptions.Duration, "alertmanager-silence-duration", alertmanagerSilenceOptions.Duration, "Alertmanager silence duration: 30m, 2h")
+	flags.StringVar(&alertmanagerSilenceOptions.CreatedBy, "alertmanager-silence-created-by", alertmanagerSilenceOptions.CreatedBy, "Alertmanager silence created by")
+	flags.StringVar(&alertmanagerSilenceOptions.Comment, "alertmanager-silence-comment", alertmanagerSilenceOptions.Comment, "Alertmanager silence comment")
+	silenceCmd.AddCommand(idempotentCommand(silenceCreateCmd))
+
+	silenceExpireCmd := &cobra.Command{
+		Use:   "expire",
+		Short: "Expire silence",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Alertmanager expiring silence...")
+			common.Debug("Alertmanager", alertmanagerExpireSilenceOptions, stdout)
+
+			bytes, err := idempotent("", alertmanagerExpireSilenceOptions, func() ([]byte, error) {
+				return alertmanagerNew(stdout).ExpireSilence(alertmanagerExpireSilenceOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputRaw(alertmanagerOutput.Output, bytes, stdout)
+		},
+	}
+	flags = silenceExpireCmd.PersistentFlags()
+	flags.StringVar(&alertmanagerExpireSilenceOptions.ID, "alertmanager-silence-id", alertmanagerExpireSilenceOptions.ID, "Alertmanager silence ID")
+	silenceCmd.AddCommand(idempotentCommand(silenceExpireCmd))
+
+	silenceCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List silences",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Alertmanager listing silences...")
+
+			bytes, err := alertmanagerNew(stdout).ListSilences()
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(alertmanagerOutput, "Alertmanager", []interface{}{alertmanagerOptions}, bytes, stdout)
+		},
+	})
+
+	alertsCmd := &cobra.Command{
+		Use:   "alerts",
+		Short: "List firing alerts",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Alertmanager listing alerts...")
+			common.Debug("Alertmanager", alertmanagerAlertsOptions, stdout)
+
+			bytes, err := alertmanagerNew(stdout).ListAlerts(alertmanagerAlertsOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(alertmanagerOutput, "Alertmanager", []interface{}{alertmanagerOptions, alertmanagerAlertsOptions}, bytes, stdout)
+		},
+	}
+	flags = alertsCmd.PersistentFlags()
+	flags.StringArrayVar(&alertmanagerAlertsOptions.Filter, "alertmanager-alerts-filter", alertmanagerAlertsOptions.Filter, "Alertmanager alerts filter matcher, repeatable")
+	flags.StringVar(&alertmanagerAlertsOptions.Receiver, "alertmanager-alerts-receiver", alertmanagerAlertsOptions.Receiver, "Alertmanager alerts receiver regex")
+	flags.BoolVar(&alertmanagerAlertsOptions.Silenced, "alertmanager-alerts-silenced", alertmanagerAlertsOptions.Silenced, "Alertmanager alerts include silenced")
+	flags.BoolVar(&alertmanagerAlertsOptions.Inhibited, "alertmanager-alerts-inhibited", alertmanagerAlertsOptions.Inhibited, "Alertmanager alerts include inhibited")
+	alertmanagerCmd.AddCommand(alertsCmd)
+
+	return alertmanagerCmd
+}
+
+func init() {
+	registerVendor("alertmanager", vendorGroupMonitoring, NewAlertmanagerCommand, alertmanagerNew)
+}
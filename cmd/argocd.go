@@ -0,0 +1,171 @@
+//go:build !minimal || ci || argocd
+
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+var argoCDOptions = vendors.ArgoCDOptions{
+	Timeout:  envGet("ARGOCD_TIMEOUT", 30).(int),
+	Insecure: envGet("ARGOCD_INSECURE", false).(bool),
+	URL:      envGet("ARGOCD_URL", "").(string),
+	Token:    envGet("ARGOCD_TOKEN", "").(string),
+}
+
+var argoCDApplicationOptions = vendors.ArgoCDApplicationOptions{
+	Name:      envGet("ARGOCD_APPLICATION", "").(string),
+	Namespace: envGet("ARGOCD_APPLICATION_NAMESPACE", "").(string),
+}
+
+var argoCDWaitOptions = vendors.ArgoCDWaitOptions{
+	Wait:         envGet("ARGOCD_WAIT", false).(bool),
+	WaitTimeout:  envGet("ARGOCD_WAIT_TIMEOUT", 600).(int),
+	PollInterval: envGet("ARGOCD_POLL_INTERVAL", 5).(int),
+}
+
+var argoCDSyncOptions = vendors.ArgoCDSyncOptions{
+	Revision: envGet("ARGOCD_SYNC_REVISION", "").(string),
+	Prune:    envGet("ARGOCD_SYNC_PRUNE", false).(bool),
+	DryRun:   envGet("ARGOCD_SYNC_DRY_RUN", false).(bool),
+}
+
+var argoCDRollbackOptions = vendors.ArgoCDRollbackOptions{
+	ID:     int64(envGet("ARGOCD_ROLLBACK_ID", 0).(int)),
+	Prune:  envGet("ARGOCD_ROLLBACK_PRUNE", false).(bool),
+	DryRun: envGet("ARGOCD_ROLLBACK_DRY_RUN", false).(bool),
+}
+
+var argoCDOutput = common.OutputOptions{
+	Output: envGet("ARGOCD_OUTPUT", "").(string),
+	Query:  envGet("ARGOCD_OUTPUT_QUERY", "").(string),
+}
+
+func argoCDNew(stdout *common.Stdout) *vendors.ArgoCD {
+
+	common.Debug("ArgoCD", argoCDOptions, stdout)
+	common.Debug("ArgoCD", argoCDOutput, stdout)
+
+	return vendors.NewArgoCD(argoCDOptions, stdout.Module("argocd"))
+}
+
+func NewArgoCDCommand() *cobra.Command {
+
+	argoCDCmd := &cobra.Command{
+		Use:   "argocd",
+		Short: "ArgoCD tools",
+	}
+	flags := argoCDCmd.PersistentFlags()
+	flags.IntVar(&argoCDOptions.Timeout, "argocd-timeout", argoCDOptions.Timeout, "ArgoCD timeout in seconds")
+	flags.BoolVar(&argoCDOptions.Insecure, "argocd-insecure", argoCDOptions.Insecure, "ArgoCD insecure")
+	flags.StringVar(&argoCDOptions.URL, "argocd-url", argoCDOptions.URL, "ArgoCD URL")
+	flags.StringVar(&argoCDOptions.Token, "argocd-token", argoCDOptions.Token, "ArgoCD token")
+	flags.StringVar(&argoCDApplicationOptions.Name, "argocd-application", argoCDApplicationOptions.Name, "ArgoCD application name")
+	flags.StringVar(&argoCDApplicationOptions.Namespace, "argocd-application-namespace", argoCDApplicationOptions.Namespace, "ArgoCD application namespace, if applications are in any namespace")
+	flags.IntVar(&argoCDWaitOptions.WaitTimeout, "argocd-wait-timeout", argoCDWaitOptions.WaitTimeout, "ArgoCD wait timeout in seconds")
+	flags.IntVar(&argoCDWaitOptions.PollInterval, "argocd-poll-interval", argoCDWaitOptions.PollInterval, "ArgoCD application poll interval in seconds")
+	flags.StringVar(&argoCDOutput.Output, "argocd-output", argoCDOutput.Output, "ArgoCD output")
+	flags.StringVar(&argoCDOutput.Query, "argocd-output-query", argoCDOutput.Query, "ArgoCD output query")
+
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Sync application",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("ArgoCD syncing application %s...", argoCDApplicationOptions.Name)
+			common.Debug("ArgoCD", argoCDSyncOptions, stdout)
+
+			if !hooksPreSend(stdout, "argocd", &argoCDSyncOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", []interface{}{argoCDApplicationOptions, argoCDSyncOptions, argoCDWaitOptions}, func() ([]byte, error) {
+				return argoCDNew(stdout).SyncApplication(argoCDApplicationOptions, argoCDSyncOptions, argoCDWaitOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "argocd", bytes)
+			common.OutputJson(argoCDOutput, "ArgoCD", []interface{}{argoCDOptions, argoCDApplicationOptions, argoCDSyncOptions}, bytes, stdout)
+		},
+	}
+	flags = syncCmd.PersistentFlags()
+	flags.StringVar(&argoCDSyncOptions.Revision, "argocd-sync-revision", argoCDSyncOptions.Revision, "ArgoCD sync revision, target revision of application if empty")
+	flags.BoolVar(&argoCDSyncOptions.Prune, "argocd-sync-prune", argoCDSyncOptions.Prune, "ArgoCD sync prune")
+	flags.BoolVar(&argoCDSyncOptions.DryRun, "argocd-sync-dry-run", argoCDSyncOptions.DryRun, "ArgoCD sync dry run")
+	flags.BoolVar(&argoCDWaitOptions.Wait, "argocd-wait", argoCDWaitOptions.Wait, "ArgoCD wait for application to be synced and healthy")
+	argoCDCmd.AddCommand(idempotentCommand(syncCmd))
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Get application sync and health status",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("ArgoCD getting application %s...", argoCDApplicationOptions.Name)
+
+			bytes, err := argoCDNew(stdout).GetApplicationStatus(argoCDApplicationOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(argoCDOutput, "ArgoCD", []interface{}{argoCDOptions, argoCDApplicationOptions}, bytes, stdout)
+		},
+	}
+	argoCDCmd.AddCommand(statusCmd)
+
+	waitCmd := &cobra.Command{
+		Use:   "wait",
+		Short: "Wait for application to be synced and healthy",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("ArgoCD waiting for application %s...", argoCDApplicationOptions.Name)
+
+			bytes, err := argoCDNew(stdout).WaitHealthy(argoCDApplicationOptions, argoCDWaitOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(argoCDOutput, "ArgoCD", []interface{}{argoCDOptions, argoCDApplicationOptions}, bytes, stdout)
+		},
+	}
+	argoCDCmd.AddCommand(waitCmd)
+
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Rollback application to deployment of history",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("ArgoCD rolling back application %s...", argoCDApplicationOptions.Name)
+			common.Debug("ArgoCD", argoCDRollbackOptions, stdout)
+
+			if !hooksPreSend(stdout, "argocd", &argoCDRollbackOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", []interface{}{argoCDApplicationOptions, argoCDRollbackOptions, argoCDWaitOptions}, func() ([]byte, error) {
+				return argoCDNew(stdout).Rollback(argoCDApplicationOptions, argoCDRollbackOptions, argoCDWaitOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "argocd", bytes)
+			common.OutputJson(argoCDOutput, "ArgoCD", []interface{}{argoCDOptions, argoCDApplicationOptions, argoCDRollbackOptions}, bytes, stdout)
+		},
+	}
+	flags = rollbackCmd.PersistentFlags()
+	flags.Int64Var(&argoCDRollbackOptions.ID, "argocd-rollback-id", argoCDRollbackOptions.ID, "ArgoCD history ID to rollback to, previous deployment if empty")
+	flags.BoolVar(&argoCDRollbackOptions.Prune, "argocd-rollback-prune", argoCDRollbackOptions.Prune, "ArgoCD rollback prune")
+	flags.BoolVar(&argoCDRollbackOptions.DryRun, "argocd-rollback-dry-run", argoCDRollbackOptions.DryRun, "ArgoCD rollback dry run")
+	flags.BoolVar(&argoCDWaitOptions.Wait, "argocd-wait", argoCDWaitOptions.Wait, "ArgoCD wait for application to be healthy")
+	argoCDCmd.AddCommand(idempotentCommand(rollbackCmd))
+
+	return argoCDCmd
+}
+
+func init() {
+	registerVendor("argocd", vendorGroupCI, NewArgoCDCommand, argoCDNew)
+}
@@ -0,0 +1,198 @@
+//go:build !minimal || ci || artifactory || nexus
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+// ArtifactOptions are options of artifact commands, which are the same for Artifactory and Nexus
+type ArtifactOptions struct {
+	Repository string
+	Path       string
+	File       string
+	Properties []string
+	Recursive  bool
+	Tag        string
+	Checksum   string
+	Group      string
+	Artifact   string
+	Version    string
+	Classifier string
+}
+
+// newArtifactOptions returns options from environment variables having prefix, e.g. ARTIFACTORY
+func newArtifactOptions(prefix string) ArtifactOptions {
+
+	return ArtifactOptions{
+		Repository: envGet(prefix+"_REPOSITORY", "").(string),
+		Path:       envGet(prefix+"_PATH", "").(string),
+		File:       envGet(prefix+"_FILE", "").(string),
+		Properties: strings.Split(envGet(prefix+"_PROPERTIES", "").(string), ","),
+		Recursive:  envGet(prefix+"_RECURSIVE", false).(bool),
+		Tag:        envGet(prefix+"_TAG", "").(string),
+		Checksum:   envGet(prefix+"_CHECKSUM", "").(string),
+		Group:      envGet(prefix+"_GROUP", "").(string),
+		Artifact:   envGet(prefix+"_ARTIFACT", "").(string),
+		Version:    envGet(prefix+"_VERSION", "").(string),
+		Classifier: envGet(prefix+"_CLASSIFIER", "").(string),
+	}
+}
+
+// artifactCommands adds upload, download, search and set-properties commands of artifact repository,
+// name is vendor name, e.g. Artifactory, flags are prefixed by its lower case
+func artifactCommands(parent *cobra.Command, name string, options *ArtifactOptions, output *common.OutputOptions,
+	vendorOptions interface{}, repository func(stdout *common.Stdout) vendors.ArtifactRepository) {
+
+	prefix := strings.ToLower(name)
+	vendor := prefix
+	flag := func(s string) string {
+		return fmt.Sprintf("%s-%s", prefix, s)
+	}
+	flags := parent.PersistentFlags()
+	flags.StringVar(&options.Repository, flag("repository"), options.Repository, fmt.Sprintf("%s repository", name))
+
+	uploadCmd := &cobra.Command{
+		Use:   "upload",
+		Short: "Upload file to repository",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("%s uploading %s to %s...", name, options.File, options.Repository)
+			common.Debug(name, options, stdout)
+
+			uploadOptions := vendors.ArtifactUploadOptions{
+				Repository: options.Repository,
+				Path:       options.Path,
+				File:       options.File,
+				Properties: options.Properties,
+			}
+			if !hooksPreSend(stdout, vendor, &uploadOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", uploadOptions, func() ([]byte, error) {
+				return repository(stdout).Upload(uploadOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, vendor, bytes)
+			common.OutputJson(*output, name, []interface{}{vendorOptions, uploadOptions}, bytes, stdout)
+		},
+	}
+	flags = uploadCmd.PersistentFlags()
+	flags.StringVar(&options.File, flag("file"), options.File, fmt.Sprintf("%s file to upload", name))
+	flags.StringVar(&options.Path, flag("path"), options.Path, fmt.Sprintf("%s path in repository, file name if empty", name))
+	if vendor != "nexus" {
+		flags.StringSliceVar(&options.Properties, flag("properties"), options.Properties, fmt.Sprintf("%s properties as key=value", name))
+	}
+	parent.AddCommand(idempotentCommand(uploadCmd))
+
+	downloadCmd := &cobra.Command{
+		Use:   "download",
+		Short: "Download file from repository",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("%s downloading %s from %s...", name, options.Path, options.Repository)
+			common.Debug(name, options, stdout)
+
+			downloadOptions := vendors.ArtifactDownloadOptions{
+				Repository: options.Repository,
+				Path:       options.Path,
+				File:       options.File,
+			}
+			bytes, err := repository(stdout).Download(downloadOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(*output, name, []interface{}{vendorOptions, downloadOptions}, bytes, stdout)
+		},
+	}
+	flags = downloadCmd.PersistentFlags()
+	flags.StringVar(&options.Path, flag("path"), options.Path, fmt.Sprintf("%s path in repository", name))
+	flags.StringVar(&options.File, flag("file"), options.File, fmt.Sprintf("%s file to write, base name of path if empty", name))
+	parent.AddCommand(downloadCmd)
+
+	searchCmd := &cobra.Command{
+		Use:   "search",
+		Short: "Search artifacts by checksum or group, artifact, version and classifier",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("%s searching artifacts...", name)
+			common.Debug(name, options, stdout)
+
+			searchOptions := vendors.ArtifactSearchOptions{
+				Repository: options.Repository,
+				Checksum:   options.Checksum,
+				Group:      options.Group,
+				Artifact:   options.Artifact,
+				Version:    options.Version,
+				Classifier: options.Classifier,
+			}
+			bytes, err := repository(stdout).Search(searchOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(*output, name, []interface{}{vendorOptions, searchOptions}, bytes, stdout)
+		},
+	}
+	flags = searchCmd.PersistentFlags()
+	flags.StringVar(&options.Checksum, flag("checksum"), options.Checksum, fmt.Sprintf("%s checksum: MD5, SHA-1 or SHA-256", name))
+	flags.StringVar(&options.Group, flag("group"), options.Group, fmt.Sprintf("%s group ID", name))
+	flags.StringVar(&options.Artifact, flag("artifact"), options.Artifact, fmt.Sprintf("%s artifact ID", name))
+	flags.StringVar(&options.Version, flag("version"), options.Version, fmt.Sprintf("%s version", name))
+	flags.StringVar(&options.Classifier, flag("classifier"), options.Classifier, fmt.Sprintf("%s classifier", name))
+	parent.AddCommand(searchCmd)
+
+	short := "Set properties of artifact"
+	if vendor == "nexus" {
+		short = "Set properties of artifact as attributes of tag, which is associated with its component"
+	}
+	setPropertiesCmd := &cobra.Command{
+		Use:   "set-properties",
+		Short: short,
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("%s setting properties of %s in %s...", name, options.Path, options.Repository)
+			common.Debug(name, options, stdout)
+
+			propertiesOptions := vendors.ArtifactPropertiesOptions{
+				Repository: options.Repository,
+				Path:       options.Path,
+				Properties: options.Properties,
+				Recursive:  options.Recursive,
+				Tag:        options.Tag,
+			}
+			if !hooksPreSend(stdout, vendor, &propertiesOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", propertiesOptions, func() ([]byte, error) {
+				return repository(stdout).SetProperties(propertiesOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, vendor, bytes)
+			common.OutputJson(*output, name, []interface{}{vendorOptions, propertiesOptions}, bytes, stdout)
+		},
+	}
+	flags = setPropertiesCmd.PersistentFlags()
+	flags.StringVar(&options.Path, flag("path"), options.Path, fmt.Sprintf("%s path in repository", name))
+	flags.StringSliceVar(&options.Properties, flag("properties"), options.Properties, fmt.Sprintf("%s properties as key=value", name))
+	if vendor == "nexus" {
+		flags.StringVar(&options.Tag, flag("tag"), options.Tag, fmt.Sprintf("%s tag having properties as attributes", name))
+	} else {
+		flags.BoolVar(&options.Recursive, flag("recursive"), options.Recursive, fmt.Sprintf("%s sets properties of folder children", name))
+	}
+	parent.AddCommand(idempotentCommand(setPropertiesCmd))
+}
@@ -0,0 +1,57 @@
+//go:build !minimal || ci || artifactory
+
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+var artifactoryOptions = vendors.ArtifactoryOptions{
+	Timeout:  envGet("ARTIFACTORY_TIMEOUT", 300).(int),
+	Insecure: envGet("ARTIFACTORY_INSECURE", false).(bool),
+	URL:      envGet("ARTIFACTORY_URL", "").(string),
+	Token:    envGet("ARTIFACTORY_TOKEN", "").(string),
+	User:     envGet("ARTIFACTORY_USER", "").(string),
+	Password: envGet("ARTIFACTORY_PASSWORD", "").(string),
+}
+
+var artifactoryArtifactOptions = newArtifactOptions("ARTIFACTORY")
+
+var artifactoryOutput = common.OutputOptions{
+	Output: envGet("ARTIFACTORY_OUTPUT", "").(string),
+	Query:  envGet("ARTIFACTORY_OUTPUT_QUERY", "").(string),
+}
+
+func artifactoryNew(stdout *common.Stdout) vendors.ArtifactRepository {
+
+	common.Debug("Artifactory", artifactoryOptions, stdout)
+	common.Debug("Artifactory", artifactoryOutput, stdout)
+
+	return vendors.NewArtifactory(artifactoryOptions)
+}
+
+func NewArtifactoryCommand() *cobra.Command {
+
+	artifactoryCmd := &cobra.Command{
+		Use:   "artifactory",
+		Short: "Artifactory tools",
+	}
+	flags := artifactoryCmd.PersistentFlags()
+	flags.IntVar(&artifactoryOptions.Timeout, "artifactory-timeout", artifactoryOptions.Timeout, "Artifactory timeout in seconds")
+	flags.BoolVar(&artifactoryOptions.Insecure, "artifactory-insecure", artifactoryOptions.Insecure, "Artifactory insecure")
+	flags.StringVar(&artifactoryOptions.URL, "artifactory-url", artifactoryOptions.URL, "Artifactory URL, e.g. https://example.jfrog.io/artifactory")
+	flags.StringVar(&artifactoryOptions.Token, "artifactory-token", artifactoryOptions.Token, "Artifactory access token")
+	flags.StringVar(&artifactoryOptions.User, "artifactory-user", artifactoryOptions.User, "Artifactory user, it's used if token is empty")
+	flags.StringVar(&artifactoryOptions.Password, "artifactory-password", artifactoryOptions.Password, "Artifactory password or API key")
+	flags.StringVar(&artifactoryOutput.Output, "artifactory-output", artifactoryOutput.Output, "Artifactory output")
+	flags.StringVar(&artifactoryOutput.Query, "artifactory-output-query", artifactoryOutput.Query, "Artifactory output query")
+
+	artifactCommands(artifactoryCmd, "Artifactory", &artifactoryArtifactOptions, &artifactoryOutput, &artifactoryOptions, artifactoryNew)
+	return artifactoryCmd
+}
+
+func init() {
+	registerVendor("artifactory", vendorGroupCI, NewArtifactoryCommand, artifactoryNew)
+}
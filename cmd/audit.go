@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var auditOptions = common.AuditOptions{
+	Log:           envGet("AUDIT_LOG", "").(string),
+	Actor:         envGet("AUDIT_ACTOR", "").(string),
+	CorrelationID: envGet("AUDIT_CORRELATION_ID", "").(string),
+}
+
+// flags of targets of commands, e.g. channel or calendar, which are written to audit records
+var auditTargetFlags = []string{
+	"slack-channel", "telegram-chat-id", "discord-channel", "rocketchat-channel", "webex-room", "mattermost-channel",
+	"google-calendar-id", "email-to", "twilio-to", "ssh-host", "kubernetes-namespace", "github-repo", "bitbucket-repo",
+	"gitlab-variable-project-id", "gitlab-pipeline-project-id", "gitlab-tag-project-id", "gitlab-merge-request-project-id",
+	"jira-issue-project-key", "pagerduty-incident-service-id", "incident-channel",
+}
+
+// auditTarget returns values of target flags of command, e.g. slack-channel=C123
+func auditTarget(cmd *cobra.Command) string {
+
+	targets := []string{}
+	for _, name := range auditTargetFlags {
+		flag := cmd.Flag(name)
+		if flag == nil || utils.IsEmpty(flag.Value.String()) || flag.Value.String() == "[]" {
+			continue
+		}
+		targets = append(targets, fmt.Sprintf("%s=%s", name, strings.Trim(flag.Value.String(), "[]")))
+	}
+	return strings.Join(targets, " ")
+}
+
+// auditStart starts audit log of mutating vendor requests of command, e.g. sending messages or creating events
+func auditStart(cmd *cobra.Command) error {
+	return common.SetAudit(auditOptions, strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "), auditTarget(cmd))
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestAuditTarget(t *testing.T) {
+
+	var channel, text string
+	var to []string
+	parent := &cobra.Command{Use: "slack"}
+	parent.PersistentFlags().StringVar(&channel, "slack-channel", "", "")
+	child := &cobra.Command{Use: "send-message", Run: func(cmd *cobra.Command, args []string) {}}
+	child.Flags().StringVar(&text, "slack-message", "", "")
+	child.Flags().StringSliceVar(&to, "email-to", nil, "")
+	parent.AddCommand(child)
+
+	// inherited flags are targets, flags which aren't targets or are empty are skipped
+	parent.SetArgs([]string{"send-message", "--slack-channel", "C123", "--slack-message", "Deployed"})
+	if err := parent.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if target := auditTarget(child); target != "slack-channel=C123" {
+		t.Fatalf("unexpected target %q", target)
+	}
+
+	parent.SetArgs([]string{"send-message", "--email-to", "a@example.com,b@example.com"})
+	if err := parent.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if target := auditTarget(child); target != "slack-channel=C123 email-to=a@example.com,b@example.com" {
+		t.Fatalf("unexpected target %q", target)
+	}
+}
@@ -1,10 +1,14 @@
+//go:build !minimal || cloud || aws
+
 package cmd
 
 import (
 	"encoding/json"
 
 	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/server"
 	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -23,6 +27,86 @@ var EC2Output = common.OutputOptions{
 	Query:  envGet("AWS_EC2_OUTPUT_QUERY", "").(string),
 }
 
+var awsMessagingOptions = vendors.AWSMessagingOptions{
+	Timeout:  envGet("AWS_TIMEOUT", 30).(int),
+	Insecure: envGet("AWS_INSECURE", false).(bool),
+	Region:   envGet("AWS_REGION", "").(string),
+	Endpoint: envGet("AWS_ENDPOINT", "").(string),
+	AWSKeys: vendors.AWSKeys{
+		AccessKey:    envGet("AWS_ACCESSKEY", "").(string),
+		SecretKey:    envGet("AWS_SECRETKEY", "").(string),
+		SessionToken: envGet("AWS_SESSION_TOKEN", "").(string),
+	},
+}
+
+var awsSNSPublishOptions = vendors.AWSSNSPublishOptions{
+	TopicARN:        envGet("AWS_SNS_TOPIC_ARN", "").(string),
+	Message:         envGet("AWS_SNS_MESSAGE", "").(string),
+	Subject:         envGet("AWS_SNS_SUBJECT", "").(string),
+	Attributes:      envGet("AWS_SNS_ATTRIBUTES", "").(string),
+	GroupID:         envGet("AWS_SNS_GROUP_ID", "").(string),
+	DeduplicationID: envGet("AWS_SNS_DEDUPLICATION_ID", "").(string),
+}
+
+var awsSQSSendOptions = vendors.AWSSQSSendOptions{
+	QueueURL:        envGet("AWS_SQS_QUEUE_URL", "").(string),
+	Body:            envGet("AWS_SQS_BODY", "").(string),
+	DelaySeconds:    envGet("AWS_SQS_DELAY_SECONDS", 0).(int),
+	Attributes:      envGet("AWS_SQS_ATTRIBUTES", "").(string),
+	GroupID:         envGet("AWS_SQS_GROUP_ID", "").(string),
+	DeduplicationID: envGet("AWS_SQS_DEDUPLICATION_ID", "").(string),
+}
+
+var awsSQSReceiveOptions = vendors.AWSSQSReceiveOptions{
+	QueueURL:          envGet("AWS_SQS_QUEUE_URL", "").(string),
+	MaxMessages:       envGet("AWS_SQS_MAX_MESSAGES", 1).(int),
+	WaitSeconds:       envGet("AWS_SQS_WAIT_SECONDS", 0).(int),
+	VisibilityTimeout: envGet("AWS_SQS_VISIBILITY_TIMEOUT", 0).(int),
+	Delete:            envGet("AWS_SQS_DELETE", false).(bool),
+}
+
+var awsS3Options = vendors.AWSS3Options{
+	Timeout:  envGet("AWS_S3_TIMEOUT", 300).(int),
+	Insecure: envGet("AWS_INSECURE", false).(bool),
+	Region:   envGet("AWS_REGION", "").(string),
+	Endpoint: envGet("AWS_ENDPOINT", "").(string),
+	Bucket:   envGet("AWS_S3_BUCKET", "").(string),
+	PartSize: envGet("AWS_S3_PART_SIZE", 16).(int),
+	AWSKeys: vendors.AWSKeys{
+		AccessKey:    envGet("AWS_ACCESSKEY", "").(string),
+		SecretKey:    envGet("AWS_SECRETKEY", "").(string),
+		SessionToken: envGet("AWS_SESSION_TOKEN", "").(string),
+	},
+}
+
+var awsS3UploadOptions = vendors.AWSS3UploadOptions{
+	Key:         envGet("AWS_S3_KEY", "").(string),
+	File:        envGet("AWS_S3_FILE", "").(string),
+	ContentType: envGet("AWS_S3_CONTENT_TYPE", "").(string),
+	Presign:     envGet("AWS_S3_PRESIGN", 0).(int),
+}
+
+var awsS3DownloadOptions = vendors.AWSS3DownloadOptions{
+	Key:  envGet("AWS_S3_KEY", "").(string),
+	File: envGet("AWS_S3_FILE", "").(string),
+}
+
+var awsS3ListOptions = vendors.AWSS3ListOptions{
+	Prefix:  envGet("AWS_S3_PREFIX", "").(string),
+	MaxKeys: envGet("AWS_S3_MAX_KEYS", 1000).(int),
+}
+
+var awsS3PresignOptions = vendors.AWSS3PresignOptions{
+	Key:     envGet("AWS_S3_KEY", "").(string),
+	Method:  envGet("AWS_S3_PRESIGN_METHOD", "GET").(string),
+	Expires: envGet("AWS_S3_PRESIGN_EXPIRES", 3600).(int),
+}
+
+var awsMessagingOutput = common.OutputOptions{
+	Output: envGet("AWS_OUTPUT", "").(string),
+	Query:  envGet("AWS_OUTPUT_QUERY", "").(string),
+}
+
 func EC2New(stdout *common.Stdout) *vendors.AWSEC2 {
 	common.Debug("EC2", EC2Options, stdout)
 	common.Debug("EC2", EC2Output, stdout)
@@ -34,6 +118,36 @@ func EC2New(stdout *common.Stdout) *vendors.AWSEC2 {
 	return ec2
 }
 
+func awsMessagingNew(stdout *common.Stdout) *vendors.AWSMessaging {
+
+	common.Debug("AWS", awsMessagingOptions, stdout)
+	common.Debug("AWS", awsMessagingOutput, stdout)
+
+	return vendors.NewAWSMessaging(awsMessagingOptions)
+}
+
+func awsS3New(stdout *common.Stdout) *vendors.AWSS3 {
+
+	common.Debug("AWS", awsS3Options, stdout)
+	common.Debug("AWS", awsMessagingOutput, stdout)
+
+	return vendors.NewAWSS3(awsS3Options)
+}
+
+func awsMessagingFlags(cmd *cobra.Command) {
+
+	flags := cmd.PersistentFlags()
+	flags.IntVar(&awsMessagingOptions.Timeout, "aws-timeout", awsMessagingOptions.Timeout, "AWS timeout in seconds")
+	flags.BoolVar(&awsMessagingOptions.Insecure, "aws-insecure", awsMessagingOptions.Insecure, "AWS insecure")
+	flags.StringVar(&awsMessagingOptions.Region, "aws-region", awsMessagingOptions.Region, "AWS region")
+	flags.StringVar(&awsMessagingOptions.Endpoint, "aws-endpoint", awsMessagingOptions.Endpoint, "AWS endpoint URL instead of regional one")
+	flags.StringVar(&awsMessagingOptions.AccessKey, "aws-accesskey", awsMessagingOptions.AccessKey, "Access key for AWS, otherwise environment or web identity is used")
+	flags.StringVar(&awsMessagingOptions.SecretKey, "aws-secretkey", awsMessagingOptions.SecretKey, "Secret key for AWS")
+	flags.StringVar(&awsMessagingOptions.SessionToken, "aws-session-token", awsMessagingOptions.SessionToken, "Session token for AWS")
+	flags.StringVar(&awsMessagingOutput.Output, "aws-output", awsMessagingOutput.Output, "AWS output")
+	flags.StringVar(&awsMessagingOutput.Query, "aws-output-query", awsMessagingOutput.Query, "AWS output query")
+}
+
 func NewAWSCommand() *cobra.Command {
 	awsCmd := &cobra.Command{
 		Use:   "aws",
@@ -41,6 +155,9 @@ func NewAWSCommand() *cobra.Command {
 	}
 
 	awsCmd.AddCommand(NewEC2Subcommand())
+	awsCmd.AddCommand(NewSNSSubcommand())
+	awsCmd.AddCommand(NewSQSSubcommand())
+	awsCmd.AddCommand(NewS3Subcommand())
 
 	return awsCmd
 }
@@ -82,3 +199,268 @@ func NewEC2Subcommand() *cobra.Command {
 
 	return EC2Cmd
 }
+
+func NewSNSSubcommand() *cobra.Command {
+	snsCmd := &cobra.Command{
+		Use:   "sns",
+		Short: "SNS tools",
+	}
+	awsMessagingFlags(snsCmd)
+
+	publishCmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Publish message to SNS topic",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("AWS publishing message to %s...", awsSNSPublishOptions.TopicARN)
+			common.Debug("AWS", awsSNSPublishOptions, stdout)
+
+			messageBytes, err := utils.Content(awsSNSPublishOptions.Message)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			awsSNSPublishOptions.Message = string(messageBytes)
+
+			if !hooksPreSend(stdout, "sns", &awsSNSPublishOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", awsSNSPublishOptions, func() ([]byte, error) {
+				return awsMessagingNew(stdout).PublishSNS(awsSNSPublishOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "sns", bytes)
+			common.OutputJson(awsMessagingOutput, "AWS", []interface{}{awsMessagingOptions, awsSNSPublishOptions}, bytes, stdout)
+		},
+	}
+	flags := publishCmd.PersistentFlags()
+	flags.StringVar(&awsSNSPublishOptions.TopicARN, "aws-sns-topic-arn", awsSNSPublishOptions.TopicARN, "AWS SNS topic ARN")
+	flags.StringVar(&awsSNSPublishOptions.Message, "aws-sns-message", awsSNSPublishOptions.Message, "AWS SNS message")
+	flags.StringVar(&awsSNSPublishOptions.Subject, "aws-sns-subject", awsSNSPublishOptions.Subject, "AWS SNS subject for email subscriptions")
+	flags.StringVar(&awsSNSPublishOptions.Attributes, "aws-sns-attributes", awsSNSPublishOptions.Attributes, "AWS SNS message attributes, key=value comma-separated")
+	flags.StringVar(&awsSNSPublishOptions.GroupID, "aws-sns-group-id", awsSNSPublishOptions.GroupID, "AWS SNS message group ID for FIFO topics")
+	flags.StringVar(&awsSNSPublishOptions.DeduplicationID, "aws-sns-deduplication-id", awsSNSPublishOptions.DeduplicationID, "AWS SNS message deduplication ID for FIFO topics")
+	snsCmd.AddCommand(idempotentCommand(publishCmd))
+
+	return snsCmd
+}
+
+func NewSQSSubcommand() *cobra.Command {
+	sqsCmd := &cobra.Command{
+		Use:   "sqs",
+		Short: "SQS tools",
+	}
+	awsMessagingFlags(sqsCmd)
+
+	sendCmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send message to SQS queue",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("AWS sending message to %s...", awsSQSSendOptions.QueueURL)
+			common.Debug("AWS", awsSQSSendOptions, stdout)
+
+			bodyBytes, err := utils.Content(awsSQSSendOptions.Body)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			awsSQSSendOptions.Body = string(bodyBytes)
+
+			if !hooksPreSend(stdout, "sqs", &awsSQSSendOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", awsSQSSendOptions, func() ([]byte, error) {
+				return awsMessagingNew(stdout).SendSQS(awsSQSSendOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "sqs", bytes)
+			common.OutputJson(awsMessagingOutput, "AWS", []interface{}{awsMessagingOptions, awsSQSSendOptions}, bytes, stdout)
+		},
+	}
+	flags := sendCmd.PersistentFlags()
+	flags.StringVar(&awsSQSSendOptions.QueueURL, "aws-sqs-queue-url", awsSQSSendOptions.QueueURL, "AWS SQS queue URL")
+	flags.StringVar(&awsSQSSendOptions.Body, "aws-sqs-body", awsSQSSendOptions.Body, "AWS SQS message body")
+	flags.IntVar(&awsSQSSendOptions.DelaySeconds, "aws-sqs-delay-seconds", awsSQSSendOptions.DelaySeconds, "AWS SQS message delay in seconds")
+	flags.StringVar(&awsSQSSendOptions.Attributes, "aws-sqs-attributes", awsSQSSendOptions.Attributes, "AWS SQS message attributes, key=value comma-separated")
+	flags.StringVar(&awsSQSSendOptions.GroupID, "aws-sqs-group-id", awsSQSSendOptions.GroupID, "AWS SQS message group ID for FIFO queues")
+	flags.StringVar(&awsSQSSendOptions.DeduplicationID, "aws-sqs-deduplication-id", awsSQSSendOptions.DeduplicationID, "AWS SQS message deduplication ID for FIFO queues")
+	sqsCmd.AddCommand(idempotentCommand(sendCmd))
+
+	receiveCmd := &cobra.Command{
+		Use:   "receive",
+		Short: "Receive messages from SQS queue",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("AWS receiving messages from %s...", awsSQSReceiveOptions.QueueURL)
+			common.Debug("AWS", awsSQSReceiveOptions, stdout)
+
+			bytes, err := awsMessagingNew(stdout).ReceiveSQS(awsSQSReceiveOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(awsMessagingOutput, "AWS", []interface{}{awsMessagingOptions, awsSQSReceiveOptions}, bytes, stdout)
+		},
+	}
+	flags = receiveCmd.PersistentFlags()
+	flags.StringVar(&awsSQSReceiveOptions.QueueURL, "aws-sqs-queue-url", awsSQSReceiveOptions.QueueURL, "AWS SQS queue URL")
+	flags.IntVar(&awsSQSReceiveOptions.MaxMessages, "aws-sqs-max-messages", awsSQSReceiveOptions.MaxMessages, "AWS SQS max messages to receive, up to 10")
+	flags.IntVar(&awsSQSReceiveOptions.WaitSeconds, "aws-sqs-wait-seconds", awsSQSReceiveOptions.WaitSeconds, "AWS SQS long polling wait in seconds, up to 20")
+	flags.IntVar(&awsSQSReceiveOptions.VisibilityTimeout, "aws-sqs-visibility-timeout", awsSQSReceiveOptions.VisibilityTimeout, "AWS SQS visibility timeout in seconds")
+	flags.BoolVar(&awsSQSReceiveOptions.Delete, "aws-sqs-delete", awsSQSReceiveOptions.Delete, "AWS SQS delete received messages")
+	sqsCmd.AddCommand(receiveCmd)
+
+	return sqsCmd
+}
+
+func NewS3Subcommand() *cobra.Command {
+	s3Cmd := &cobra.Command{
+		Use:   "s3",
+		Short: "S3 tools",
+	}
+	flags := s3Cmd.PersistentFlags()
+	flags.IntVar(&awsS3Options.Timeout, "aws-timeout", awsS3Options.Timeout, "AWS timeout in seconds, whole transfer should fit")
+	flags.BoolVar(&awsS3Options.Insecure, "aws-insecure", awsS3Options.Insecure, "AWS insecure")
+	flags.StringVar(&awsS3Options.Region, "aws-region", awsS3Options.Region, "AWS region")
+	flags.StringVar(&awsS3Options.Endpoint, "aws-endpoint", awsS3Options.Endpoint, "AWS endpoint URL instead of regional one, path style is used")
+	flags.StringVar(&awsS3Options.AccessKey, "aws-accesskey", awsS3Options.AccessKey, "Access key for AWS, otherwise environment or web identity is used")
+	flags.StringVar(&awsS3Options.SecretKey, "aws-secretkey", awsS3Options.SecretKey, "Secret key for AWS")
+	flags.StringVar(&awsS3Options.SessionToken, "aws-session-token", awsS3Options.SessionToken, "Session token for AWS")
+	flags.StringVar(&awsS3Options.Bucket, "aws-s3-bucket", awsS3Options.Bucket, "AWS S3 bucket")
+	flags.IntVar(&awsS3Options.PartSize, "aws-s3-part-size", awsS3Options.PartSize, "AWS S3 multipart upload part size in MB, larger files are uploaded in parts")
+	flags.StringVar(&awsMessagingOutput.Output, "aws-output", awsMessagingOutput.Output, "AWS output")
+	flags.StringVar(&awsMessagingOutput.Query, "aws-output-query", awsMessagingOutput.Query, "AWS output query")
+
+	uploadCmd := &cobra.Command{
+		Use:   "upload",
+		Short: "Upload file to S3",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("AWS uploading %s to %s...", awsS3UploadOptions.File, awsS3Options.Bucket)
+			common.Debug("AWS", awsS3UploadOptions, stdout)
+
+			bytes, err := idempotent("", awsS3UploadOptions, func() ([]byte, error) {
+				return awsS3New(stdout).Upload(awsS3UploadOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(awsMessagingOutput, "AWS", []interface{}{awsS3Options, awsS3UploadOptions}, bytes, stdout)
+		},
+	}
+	flags = uploadCmd.PersistentFlags()
+	flags.StringVar(&awsS3UploadOptions.Key, "aws-s3-key", awsS3UploadOptions.Key, "AWS S3 object key, file name if empty")
+	flags.StringVar(&awsS3UploadOptions.File, "aws-s3-file", awsS3UploadOptions.File, "AWS S3 file to upload")
+	flags.StringVar(&awsS3UploadOptions.ContentType, "aws-s3-content-type", awsS3UploadOptions.ContentType, "AWS S3 content type, by file extension if empty")
+	flags.IntVar(&awsS3UploadOptions.Presign, "aws-s3-presign", awsS3UploadOptions.Presign, "AWS S3 presigned download URL expiry in seconds, none if zero")
+	s3Cmd.AddCommand(idempotentCommand(uploadCmd))
+
+	downloadCmd := &cobra.Command{
+		Use:   "download",
+		Short: "Download file from S3",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("AWS downloading %s from %s...", awsS3DownloadOptions.Key, awsS3Options.Bucket)
+			common.Debug("AWS", awsS3DownloadOptions, stdout)
+
+			bytes, err := awsS3New(stdout).Download(awsS3DownloadOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(awsMessagingOutput, "AWS", []interface{}{awsS3Options, awsS3DownloadOptions}, bytes, stdout)
+		},
+	}
+	flags = downloadCmd.PersistentFlags()
+	flags.StringVar(&awsS3DownloadOptions.Key, "aws-s3-key", awsS3DownloadOptions.Key, "AWS S3 object key")
+	flags.StringVar(&awsS3DownloadOptions.File, "aws-s3-file", awsS3DownloadOptions.File, "AWS S3 file to write, key name if empty")
+	s3Cmd.AddCommand(downloadCmd)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List S3 objects",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("AWS listing %s...", awsS3Options.Bucket)
+			common.Debug("AWS", awsS3ListOptions, stdout)
+
+			bytes, err := awsS3New(stdout).List(awsS3ListOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(awsMessagingOutput, "AWS", []interface{}{awsS3Options, awsS3ListOptions}, bytes, stdout)
+		},
+	}
+	flags = listCmd.PersistentFlags()
+	flags.StringVar(&awsS3ListOptions.Prefix, "aws-s3-prefix", awsS3ListOptions.Prefix, "AWS S3 key prefix")
+	flags.IntVar(&awsS3ListOptions.MaxKeys, "aws-s3-max-keys", awsS3ListOptions.MaxKeys, "AWS S3 max objects, all if zero")
+	s3Cmd.AddCommand(listCmd)
+
+	presignCmd := &cobra.Command{
+		Use:   "presign",
+		Short: "Get S3 presigned URL",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("AWS presigning %s in %s...", awsS3PresignOptions.Key, awsS3Options.Bucket)
+			common.Debug("AWS", awsS3PresignOptions, stdout)
+
+			bytes, err := awsS3New(stdout).Presign(awsS3PresignOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(awsMessagingOutput, "AWS", []interface{}{awsS3Options, awsS3PresignOptions}, bytes, stdout)
+		},
+	}
+	flags = presignCmd.PersistentFlags()
+	flags.StringVar(&awsS3PresignOptions.Key, "aws-s3-key", awsS3PresignOptions.Key, "AWS S3 object key")
+	flags.StringVar(&awsS3PresignOptions.Method, "aws-s3-presign-method", awsS3PresignOptions.Method, "AWS S3 presigned method: GET, PUT")
+	flags.IntVar(&awsS3PresignOptions.Expires, "aws-s3-presign-expires", awsS3PresignOptions.Expires, "AWS S3 presigned URL expiry in seconds, up to 7 days")
+	s3Cmd.AddCommand(presignCmd)
+
+	return s3Cmd
+}
+
+func init() {
+	registerVendor("aws", vendorGroupCloud, NewAWSCommand, EC2New, awsMessagingNew, awsS3New)
+	registerVendorTargets(func(targets map[string]server.Target) {
+
+		targets["sns"] = secretsTarget("aws", func() server.Target {
+			awsMessaging := vendors.NewAWSMessaging(awsMessagingOptions)
+			return func(params map[string]string, message string) ([]byte, error) {
+				opts := awsSNSPublishOptions
+				opts.Message = message
+				if !utils.IsEmpty(params["topic"]) {
+					opts.TopicARN = params["topic"]
+				}
+				if !utils.IsEmpty(params["subject"]) {
+					opts.Subject = params["subject"]
+				} else if !utils.IsEmpty(params["title"]) {
+					opts.Subject = params["title"]
+				}
+				return awsMessaging.PublishSNS(opts)
+			}
+		})
+
+		targets["sqs"] = secretsTarget("aws", func() server.Target {
+			awsMessaging := vendors.NewAWSMessaging(awsMessagingOptions)
+			return func(params map[string]string, message string) ([]byte, error) {
+				opts := awsSQSSendOptions
+				opts.Body = message
+				if !utils.IsEmpty(params["queue"]) {
+					opts.QueueURL = params["queue"]
+				}
+				return awsMessaging.SendSQS(opts)
+			}
+		})
+	})
+}
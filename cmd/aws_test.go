@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devopsext/tools/vendors"
+)
+
+func TestAWSWebIdentityRefresh(t *testing.T) {
+
+	tokens := []string{}
+	keys := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.Form.Get("Action") {
+		case "AssumeRoleWithWebIdentity":
+			tokens = append(tokens, r.Form.Get("WebIdentityToken"))
+			// session expires within refresh window, so that it's assumed again by the next request
+			fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>AKIA%d</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken>
+<Expiration>%s</Expiration></Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`,
+				len(tokens), time.Now().Add(time.Minute).UTC().Format(time.RFC3339))
+		case "Publish":
+			keys = append(keys, strings.TrimPrefix(strings.SplitN(r.Header.Get("Authorization"), "/", 2)[0], "AWS4-HMAC-SHA256 Credential="))
+			w.Write([]byte(`<PublishResponse><PublishResult><MessageId>m1</MessageId></PublishResult></PublishResponse>`))
+		}
+	}))
+	defer srv.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/tools")
+	t.Setenv("AWS_ENDPOINT_URL_STS", srv.URL)
+
+	// one client is shared by targets of server, so that its keys are refreshed rather than created
+	messaging := vendors.NewAWSMessaging(vendors.AWSMessagingOptions{Timeout: 5, Region: "us-east-1", Endpoint: srv.URL})
+	for i, token := range []string{"t1", "t2"} {
+		if err := os.WriteFile(tokenFile, []byte(token), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := messaging.PublishSNS(vendors.AWSSNSPublishOptions{TopicARN: "arn:aws:sns:us-east-1:123456789012:t", Message: "Deployed"}); err != nil {
+			t.Fatal(err)
+		}
+		if len(tokens) != i+1 || tokens[i] != token || keys[i] != fmt.Sprintf("AKIA%d", i+1) {
+			t.Fatalf("expected keys refreshed by token %s, got tokens %v and keys %v", token, tokens, keys)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+//go:build !minimal || ci || awx
+
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var awxOptions = vendors.AWXOptions{
+	Timeout:  envGet("AWX_TIMEOUT", 30).(int),
+	Insecure: envGet("AWX_INSECURE", false).(bool),
+	URL:      envGet("AWX_URL", "").(string),
+	Token:    envGet("AWX_TOKEN", "").(string),
+	User:     envGet("AWX_USER", "").(string),
+	Password: envGet("AWX_PASSWORD", "").(string),
+}
+
+var awxLaunchOptions = vendors.AWXLaunchOptions{
+	JobTemplateID: envGet("AWX_JOB_TEMPLATE_ID", "").(string),
+	ExtraVars:     envGet("AWX_EXTRA_VARS", "").(string),
+	Limit:         envGet("AWX_LIMIT", "").(string),
+	Inventory:     envGet("AWX_INVENTORY", "").(string),
+	Wait:          envGet("AWX_WAIT", false).(bool),
+	WaitTimeout:   envGet("AWX_WAIT_TIMEOUT", 3600).(int),
+	PollInterval:  envGet("AWX_POLL_INTERVAL", 5).(int),
+}
+
+var awxJobOptions = vendors.AWXJobOptions{
+	ID: envGet("AWX_JOB_ID", "").(string),
+}
+
+var awxOutput = common.OutputOptions{
+	Output: envGet("AWX_OUTPUT", "").(string),
+	Query:  envGet("AWX_OUTPUT_QUERY", "").(string),
+}
+
+func awxNew(stdout *common.Stdout) *vendors.AWX {
+
+	common.Debug("AWX", awxOptions, stdout)
+	common.Debug("AWX", awxOutput, stdout)
+
+	return vendors.NewAWX(awxOptions, stdout.Module("awx"))
+}
+
+func NewAWXCommand() *cobra.Command {
+
+	awxCmd := &cobra.Command{
+		Use:   "awx",
+		Short: "AWX / Ansible Tower tools",
+	}
+	flags := awxCmd.PersistentFlags()
+	flags.IntVar(&awxOptions.Timeout, "awx-timeout", awxOptions.Timeout, "AWX timeout in seconds")
+	flags.BoolVar(&awxOptions.Insecure, "awx-insecure", awxOptions.Insecure, "AWX insecure")
+	flags.StringVar(&awxOptions.URL, "awx-url", awxOptions.URL, "AWX URL")
+	flags.StringVar(&awxOptions.Token, "awx-token", awxOptions.Token, "AWX token")
+	flags.StringVar(&awxOptions.User, "awx-user", awxOptions.User, "AWX user")
+	flags.StringVar(&awxOptions.Password, "awx-password", awxOptions.Password, "AWX password")
+	flags.StringVar(&awxOutput.Output, "awx-output", awxOutput.Output, "AWX output")
+	flags.StringVar(&awxOutput.Query, "awx-output-query", awxOutput.Query, "AWX output query")
+
+	launchCmd := &cobra.Command{
+		Use:   "launch",
+		Short: "Launch job template",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("AWX launching job template %s...", awxLaunchOptions.JobTemplateID)
+			common.Debug("AWX", awxLaunchOptions, stdout)
+
+			varsBytes, err := utils.Content(awxLaunchOptions.ExtraVars)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			awxLaunchOptions.ExtraVars = string(varsBytes)
+
+			bytes, err := idempotent("", awxLaunchOptions, func() ([]byte, error) {
+				return awxNew(stdout).LaunchJobTemplate(awxLaunchOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(awxOutput, "AWX", []interface{}{awxOptions, awxLaunchOptions}, bytes, stdout)
+		},
+	}
+	flags = launchCmd.PersistentFlags()
+	flags.StringVar(&awxLaunchOptions.JobTemplateID, "awx-job-template-id", awxLaunchOptions.JobTemplateID, "AWX job template ID")
+	flags.StringVar(&awxLaunchOptions.ExtraVars, "awx-extra-vars", awxLaunchOptions.ExtraVars, "AWX extra vars: json or yaml")
+	flags.StringVar(&awxLaunchOptions.Limit, "awx-limit", awxLaunchOptions.Limit, "AWX host limit")
+	flags.StringVar(&awxLaunchOptions.Inventory, "awx-inventory", awxLaunchOptions.Inventory, "AWX inventory ID")
+	flags.BoolVar(&awxLaunchOptions.Wait, "awx-wait", awxLaunchOptions.Wait, "AWX wait for job completion")
+	flags.IntVar(&awxLaunchOptions.WaitTimeout, "awx-wait-timeout", awxLaunchOptions.WaitTimeout, "AWX wait timeout in seconds")
+	flags.IntVar(&awxLaunchOptions.PollInterval, "awx-poll-interval", awxLaunchOptions.PollInterval, "AWX job poll interval in seconds")
+	awxCmd.AddCommand(idempotentCommand(launchCmd))
+
+	jobCmd := &cobra.Command{
+		Use:   "job",
+		Short: "Job methods",
+	}
+	flags = jobCmd.PersistentFlags()
+	flags.StringVar(&awxJobOptions.ID, "awx-job-id", awxJobOptions.ID, "AWX job ID")
+	awxCmd.AddCommand(jobCmd)
+
+	jobCmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Get job status",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("AWX getting job %s...", awxJobOptions.ID)
+
+			bytes, err := awxNew(stdout).GetJob(awxJobOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(awxOutput, "AWX", []interface{}{awxOptions, awxJobOptions}, bytes, stdout)
+		},
+	})
+
+	jobCmd.AddCommand(&cobra.Command{
+		Use:   "stdout",
+		Short: "Get job stdout",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("AWX getting job %s stdout...", awxJobOptions.ID)
+
+			bytes, err := awxNew(stdout).GetJobStdout(awxJobOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputRaw(awxOutput.Output, bytes, stdout)
+		},
+	})
+
+	return awxCmd
+}
+
+func init() {
+	registerVendor("awx", vendorGroupCI, NewAWXCommand, awxNew)
+}
@@ -0,0 +1,430 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	BatchJobOk      = "ok"
+	BatchJobFailed  = "failed"
+	BatchJobSkipped = "skipped"
+)
+
+type BatchOptions struct {
+	File            string
+	Parallel        bool
+	Concurrency     int
+	ContinueOnError bool
+	Vars            []string
+}
+
+// BatchJob is command of tools with its options, which are flag names as in config file,
+// values are templates of vars and results of jobs done, e.g. {{ .Jobs.ticket.Output.key }},
+// needs are jobs which must be done ok before, parallel jobs wait for them
+type BatchJob struct {
+	Name    string                 `yaml:"name"`
+	Command string                 `yaml:"command"`
+	Options map[string]interface{} `yaml:"options"`
+	Needs   []string               `yaml:"needs"`
+}
+
+// BatchFile has jobs, options of all jobs and vars of templates, parallel and continue on error are defaults of flags
+type BatchFile struct {
+	Parallel        bool                   `yaml:"parallel"`
+	ContinueOnError bool                   `yaml:"continue_on_error"`
+	Vars            map[string]string      `yaml:"vars"`
+	Options         map[string]interface{} `yaml:"options"`
+	Jobs            []*BatchJob            `yaml:"jobs"`
+}
+
+// BatchJobResult has output of job parsed as JSON if it's JSON
+type BatchJobResult struct {
+	Name     string      `json:"name"`
+	Command  string      `json:"command"`
+	Status   string      `json:"status"`
+	ExitCode int         `json:"exit_code,omitempty"`
+	Duration float64     `json:"duration"`
+	Output   interface{} `json:"output,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+type BatchReport struct {
+	Status   string            `json:"status"`
+	Duration float64           `json:"duration"`
+	Jobs     []*BatchJobResult `json:"jobs"`
+}
+
+type batchRun struct {
+	file    *BatchFile
+	results map[string]*BatchJobResult
+	mutex   sync.Mutex
+	exe     string
+}
+
+var batchOptions = BatchOptions{
+	File:            envGet("BATCH_FILE", "").(string),
+	Parallel:        envGet("BATCH_PARALLEL", false).(bool),
+	Concurrency:     envGet("BATCH_CONCURRENCY", 5).(int),
+	ContinueOnError: envGet("BATCH_CONTINUE_ON_ERROR", false).(bool),
+	Vars:            strings.Split(envGet("BATCH_VARS", "").(string), ","),
+}
+
+var batchOutput = common.OutputOptions{
+	Output: envGet("BATCH_OUTPUT", "").(string),
+	Query:  envGet("BATCH_OUTPUT_QUERY", "").(string),
+}
+
+// batchLoad reads jobs file, names are unique and needs are jobs before, so that there are no cycles
+func batchLoad(file string) (*BatchFile, error) {
+
+	b, err := utils.Content(file)
+	if err != nil {
+		return nil, err
+	}
+	var bf BatchFile
+	if err := yaml.Unmarshal(b, &bf); err != nil {
+		return nil, err
+	}
+	if len(bf.Jobs) == 0 {
+		return nil, errors.New("no batch jobs")
+	}
+
+	names := make(map[string]bool)
+	for i, job := range bf.Jobs {
+		if job == nil {
+			return nil, fmt.Errorf("batch job %d is empty", i)
+		}
+		if utils.IsEmpty(job.Name) {
+			job.Name = fmt.Sprintf("job%d", i)
+		}
+		if names[job.Name] {
+			return nil, fmt.Errorf("batch job %s is duplicated", job.Name)
+		}
+		if utils.IsEmpty(job.Command) {
+			return nil, fmt.Errorf("batch job %s has no command", job.Name)
+		}
+		for _, need := range job.Needs {
+			if !names[need] {
+				return nil, fmt.Errorf("batch job %s needs %s, which isn't before it", job.Name, need)
+			}
+		}
+		names[job.Name] = true
+	}
+	if bf.Vars == nil {
+		bf.Vars = make(map[string]string)
+	}
+	return &bf, nil
+}
+
+// batchRender renders template of option, results are jobs done by now
+func (r *batchRun) render(name, value string) (string, error) {
+
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+	t, err := template.New(name).Funcs(sprig.TxtFuncMap()).Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	r.mutex.Lock()
+	jobs := make(map[string]*BatchJobResult)
+	for k, v := range r.results {
+		jobs[k] = v
+	}
+	r.mutex.Unlock()
+
+	var b bytes.Buffer
+	err = t.Execute(&b, map[string]interface{}{"Vars": r.file.Vars, "Jobs": jobs})
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// args returns command with flags of options of file and job, output is plain and logs are JSON,
+// so that output is parsed and errors are found in logs
+func (r *batchRun) args(job *BatchJob) ([]string, error) {
+
+	values := make(map[string][]string)
+	configFlatten("", r.file.Options, values)
+	configFlatten("", job.Options, values)
+
+	names := []string{}
+	for k := range values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	args := strings.Fields(job.Command)
+	for _, k := range names {
+		for _, v := range values[k] {
+			s, err := r.render(k, v)
+			if err != nil {
+				return nil, fmt.Errorf("option %s: %s", k, err)
+			}
+			args = append(args, fmt.Sprintf("--%s=%s", k, s))
+		}
+	}
+	return append(args, "--output-plain", "--stdout-format=json"), nil
+}
+
+// batchErrors returns messages of error logs, other logs are debug of batch
+func batchErrors(job string, stderr []byte) []string {
+
+	r := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(stderr))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if utils.IsEmpty(line) {
+			continue
+		}
+		var entry struct {
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil || utils.IsEmpty(entry.Level) {
+			stdout.Debug("Batch job %s: %s", job, line)
+			continue
+		}
+		switch entry.Level {
+		case "error", "fatal", "panic":
+			r = append(r, entry.Msg)
+		default:
+			stdout.Debug("Batch job %s: %s", job, entry.Msg)
+		}
+	}
+	return r
+}
+
+// runJob runs job as process of the same binary, so that options of jobs don't share globals
+func (r *batchRun) runJob(ctx context.Context, job *BatchJob) *BatchJobResult {
+
+	result := &BatchJobResult{Name: job.Name, Command: job.Command, Status: BatchJobFailed}
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start).Seconds()
+	}()
+
+	args, err := r.args(job)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	stdout.Debug("Batch job %s running %s...", job.Name, job.Command)
+	var out, errOut bytes.Buffer
+	c := exec.CommandContext(ctx, r.exe, args...)
+	// job has key of its own, so that jobs of the same command don't share operations
+	if !utils.IsEmpty(idempotencyOptions.Key) {
+		c.Env = append(os.Environ(), fmt.Sprintf("%s_IDEMPOTENCY_KEY=%s:%s", APPNAME, idempotencyOptions.Key, job.Name))
+	}
+	c.Stdout = &out
+	c.Stderr = &errOut
+	err = c.Run()
+
+	b := bytes.TrimSpace(out.Bytes())
+	if len(b) > 0 {
+		var v interface{}
+		if json.Unmarshal(b, &v) == nil {
+			result.Output = v
+		} else {
+			result.Output = string(b)
+		}
+	}
+	errs := batchErrors(job.Name, errOut.Bytes())
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+	}
+	switch {
+	case len(errs) > 0:
+		result.Error = strings.Join(errs, "; ")
+	case err != nil:
+		result.Error = err.Error()
+	default:
+		result.Status = BatchJobOk
+	}
+	return result
+}
+
+func (r *batchRun) done(result *BatchJobResult) {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.results[result.Name] = result
+}
+
+// skipped returns result of job which isn't run as batch is stopped or its needs have failed
+func (r *batchRun) skipped(ctx context.Context, job *BatchJob) *BatchJobResult {
+
+	if ctx.Err() != nil {
+		return &BatchJobResult{Name: job.Name, Command: job.Command, Status: BatchJobSkipped, Error: "batch is stopped"}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, need := range job.Needs {
+		if res, ok := r.results[need]; !ok || res.Status != BatchJobOk {
+			return &BatchJobResult{Name: job.Name, Command: job.Command, Status: BatchJobSkipped, Error: fmt.Sprintf("job %s isn't done", need)}
+		}
+	}
+	return nil
+}
+
+// run runs jobs in order or in parallel, batch is stopped on the first failure unless it continues on error
+func (r *batchRun) run(ctx context.Context, options BatchOptions) *BatchReport {
+
+	start := time.Now()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	finish := func(job *BatchJob) {
+		result := r.skipped(ctx, job)
+		if result == nil {
+			result = r.runJob(ctx, job)
+		}
+		if result.Status == BatchJobFailed {
+			stdout.Warn("Batch job %s failed: %s", job.Name, result.Error)
+			if !options.ContinueOnError {
+				cancel()
+			}
+		}
+		r.done(result)
+	}
+
+	if !options.Parallel {
+		for _, job := range r.file.Jobs {
+			finish(job)
+		}
+	} else {
+		concurrency := options.Concurrency
+		if concurrency <= 0 {
+			concurrency = 1
+		}
+		slots := make(chan struct{}, concurrency)
+		dones := make(map[string]chan struct{})
+		for _, job := range r.file.Jobs {
+			dones[job.Name] = make(chan struct{})
+		}
+
+		var wg sync.WaitGroup
+		for _, job := range r.file.Jobs {
+			wg.Add(1)
+			go func(job *BatchJob) {
+				defer wg.Done()
+				defer close(dones[job.Name])
+				for _, need := range job.Needs {
+					<-dones[need]
+				}
+				slots <- struct{}{}
+				defer func() { <-slots }()
+				finish(job)
+			}(job)
+		}
+		wg.Wait()
+	}
+
+	report := &BatchReport{Status: BatchJobOk, Duration: time.Since(start).Seconds()}
+	for _, job := range r.file.Jobs {
+		result := r.results[job.Name]
+		if result.Status != BatchJobOk {
+			report.Status = BatchJobFailed
+		}
+		report.Jobs = append(report.Jobs, result)
+	}
+	return report
+}
+
+func NewBatchCommand() *cobra.Command {
+
+	batchCmd := &cobra.Command{
+		Use:   "batch",
+		Short: "Run commands of jobs file in order or in parallel, and report their results",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Batch running...")
+			common.Debug("Batch", batchOptions, stdout)
+
+			file, err := batchLoad(batchOptions.File)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			// flags which are set are over defaults of file
+			if !cmd.Flags().Changed("batch-parallel") {
+				batchOptions.Parallel = batchOptions.Parallel || file.Parallel
+			}
+			if !cmd.Flags().Changed("batch-continue-on-error") {
+				batchOptions.ContinueOnError = batchOptions.ContinueOnError || file.ContinueOnError
+			}
+			for _, kv := range common.RemoveEmptyStrings(batchOptions.Vars) {
+				k, v, _ := strings.Cut(kv, "=")
+				file.Vars[strings.TrimSpace(k)] = v
+			}
+
+			exe, err := os.Executable()
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			r := &batchRun{file: file, results: make(map[string]*BatchJobResult), exe: exe}
+
+			ctx, cancel := serviceContext()
+			defer cancel()
+
+			report := r.run(ctx, batchOptions)
+			bytes, err := json.Marshal(report)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(batchOutput, "Batch", []interface{}{batchOptions}, bytes, stdout)
+			if report.Status != BatchJobOk {
+				// batch of some jobs done has failed in part
+				e := &common.PoolError{Total: len(report.Jobs)}
+				for _, j := range report.Jobs {
+					switch j.Status {
+					case BatchJobFailed:
+						e.Failed++
+					case BatchJobSkipped:
+						e.Skipped++
+					}
+				}
+				stdout.Error(fmt.Errorf("Batch has failed jobs: %w", e))
+			}
+		},
+	}
+	flags := batchCmd.PersistentFlags()
+	flags.StringVar(&batchOptions.File, "batch-file", batchOptions.File, "Batch jobs YAML file: content or path")
+	flags.BoolVar(&batchOptions.Parallel, "batch-parallel", batchOptions.Parallel, "Batch runs jobs in parallel, jobs wait for their needs")
+	flags.IntVar(&batchOptions.Concurrency, "batch-concurrency", batchOptions.Concurrency, "Batch jobs running at once in parallel")
+	flags.BoolVar(&batchOptions.ContinueOnError, "batch-continue-on-error", batchOptions.ContinueOnError, "Batch runs the rest of jobs if job fails, jobs which need it are skipped")
+	flags.StringSliceVar(&batchOptions.Vars, "batch-vars", batchOptions.Vars, "Batch vars of templates: key=value")
+	flags.StringVar(&batchOutput.Output, "batch-output", batchOutput.Output, "Batch output")
+	flags.StringVar(&batchOutput.Query, "batch-output-query", batchOutput.Query, "Batch output query")
+
+	return batchCmd
+}
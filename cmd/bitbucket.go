@@ -0,0 +1,285 @@
+//go:build !minimal || ci || bitbucket
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var bitbucketOptions = vendors.BitbucketOptions{
+	Timeout:  envGet("BITBUCKET_TIMEOUT", 30).(int),
+	Insecure: envGet("BITBUCKET_INSECURE", false).(bool),
+	URL:      envGet("BITBUCKET_URL", "https://api.bitbucket.org/2.0").(string),
+	Token:    envGet("BITBUCKET_TOKEN", "").(string),
+	User:     envGet("BITBUCKET_USER", "").(string),
+	Password: envGet("BITBUCKET_PASSWORD", "").(string),
+}
+
+var bitbucketRepoOptions = vendors.BitbucketRepoOptions{
+	Workspace: envGet("BITBUCKET_WORKSPACE", "").(string),
+	Repo:      envGet("BITBUCKET_REPO", "").(string),
+}
+
+var bitbucketPullRequestOptions = vendors.BitbucketPullRequestOptions{
+	Title:             envGet("BITBUCKET_PULL_REQUEST_TITLE", "").(string),
+	Description:       envGet("BITBUCKET_PULL_REQUEST_DESCRIPTION", "").(string),
+	Source:            envGet("BITBUCKET_PULL_REQUEST_SOURCE", "").(string),
+	Destination:       envGet("BITBUCKET_PULL_REQUEST_DESTINATION", "").(string),
+	Reviewers:         strings.Split(envGet("BITBUCKET_PULL_REQUEST_REVIEWERS", "").(string), ","),
+	CloseSourceBranch: envGet("BITBUCKET_PULL_REQUEST_CLOSE_SOURCE_BRANCH", false).(bool),
+}
+
+var bitbucketMergeOptions = vendors.BitbucketMergeOptions{
+	ID:                envGet("BITBUCKET_MERGE_ID", 0).(int),
+	Strategy:          envGet("BITBUCKET_MERGE_STRATEGY", "").(string),
+	Message:           envGet("BITBUCKET_MERGE_MESSAGE", "").(string),
+	CloseSourceBranch: envGet("BITBUCKET_MERGE_CLOSE_SOURCE_BRANCH", false).(bool),
+}
+
+var bitbucketBuildStatusOptions = vendors.BitbucketBuildStatusOptions{
+	Commit:      envGet("BITBUCKET_BUILD_COMMIT", "").(string),
+	Key:         envGet("BITBUCKET_BUILD_KEY", "").(string),
+	State:       envGet("BITBUCKET_BUILD_STATE", "INPROGRESS").(string),
+	Name:        envGet("BITBUCKET_BUILD_NAME", "").(string),
+	URL:         envGet("BITBUCKET_BUILD_URL", "").(string),
+	Description: envGet("BITBUCKET_BUILD_DESCRIPTION", "").(string),
+}
+
+var bitbucketVariableOptions = vendors.BitbucketVariableOptions{
+	Key:     envGet("BITBUCKET_VARIABLE_KEY", "").(string),
+	Value:   envGet("BITBUCKET_VARIABLE_VALUE", "").(string),
+	Secured: envGet("BITBUCKET_VARIABLE_SECURED", false).(bool),
+}
+
+var bitbucketOutput = common.OutputOptions{
+	Output: envGet("BITBUCKET_OUTPUT", "").(string),
+	Query:  envGet("BITBUCKET_OUTPUT_QUERY", "").(string),
+}
+
+func bitbucketNew(stdout *common.Stdout) *vendors.Bitbucket {
+
+	common.Debug("Bitbucket", bitbucketOptions, stdout)
+	common.Debug("Bitbucket", bitbucketOutput, stdout)
+
+	return vendors.NewBitbucket(bitbucketOptions)
+}
+
+func NewBitbucketCommand() *cobra.Command {
+
+	bitbucketCmd := &cobra.Command{
+		Use:   "bitbucket",
+		Short: "Bitbucket tools",
+	}
+	flags := bitbucketCmd.PersistentFlags()
+	flags.IntVar(&bitbucketOptions.Timeout, "bitbucket-timeout", bitbucketOptions.Timeout, "Bitbucket timeout in seconds")
+	flags.BoolVar(&bitbucketOptions.Insecure, "bitbucket-insecure", bitbucketOptions.Insecure, "Bitbucket insecure")
+	flags.StringVar(&bitbucketOptions.URL, "bitbucket-url", bitbucketOptions.URL, "Bitbucket API URL of Cloud, or Server and Data Center URL")
+	flags.StringVar(&bitbucketOptions.Token, "bitbucket-token", bitbucketOptions.Token, "Bitbucket access token")
+	flags.StringVar(&bitbucketOptions.User, "bitbucket-user", bitbucketOptions.User, "Bitbucket user")
+	flags.StringVar(&bitbucketOptions.Password, "bitbucket-password", bitbucketOptions.Password, "Bitbucket password or app password")
+	flags.StringVar(&bitbucketRepoOptions.Workspace, "bitbucket-workspace", bitbucketRepoOptions.Workspace, "Bitbucket workspace, or project key of Server")
+	flags.StringVar(&bitbucketRepoOptions.Repo, "bitbucket-repo", bitbucketRepoOptions.Repo, "Bitbucket repository slug")
+	flags.StringVar(&bitbucketOutput.Output, "bitbucket-output", bitbucketOutput.Output, "Bitbucket output")
+	flags.StringVar(&bitbucketOutput.Query, "bitbucket-output-query", bitbucketOutput.Query, "Bitbucket output query")
+
+	// tools bitbucket create-pull-request
+	createPullRequestCmd := &cobra.Command{
+		Use:   "create-pull-request",
+		Short: "Create pull request",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Bitbucket creating pull request...")
+			common.Debug("Bitbucket", bitbucketRepoOptions, stdout)
+			common.Debug("Bitbucket", bitbucketPullRequestOptions, stdout)
+
+			descriptionBytes, err := utils.Content(bitbucketPullRequestOptions.Description)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			bitbucketPullRequestOptions.Description = string(descriptionBytes)
+
+			if !hooksPreSend(stdout, "bitbucket", &bitbucketPullRequestOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", []interface{}{bitbucketRepoOptions, bitbucketPullRequestOptions}, func() ([]byte, error) {
+				return bitbucketNew(stdout).CreatePullRequest(bitbucketRepoOptions, bitbucketPullRequestOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "bitbucket", bytes)
+			common.OutputJson(bitbucketOutput, "Bitbucket", []interface{}{bitbucketOptions, bitbucketRepoOptions, bitbucketPullRequestOptions}, bytes, stdout)
+		},
+	}
+	flags = createPullRequestCmd.PersistentFlags()
+	flags.StringVar(&bitbucketPullRequestOptions.Title, "bitbucket-pull-request-title", bitbucketPullRequestOptions.Title, "Bitbucket pull request title")
+	flags.StringVar(&bitbucketPullRequestOptions.Description, "bitbucket-pull-request-description", bitbucketPullRequestOptions.Description, "Bitbucket pull request description content or file")
+	flags.StringVar(&bitbucketPullRequestOptions.Source, "bitbucket-pull-request-source", bitbucketPullRequestOptions.Source, "Bitbucket pull request source branch")
+	flags.StringVar(&bitbucketPullRequestOptions.Destination, "bitbucket-pull-request-destination", bitbucketPullRequestOptions.Destination, "Bitbucket pull request destination branch, main branch of Cloud repository if empty")
+	flags.StringSliceVar(&bitbucketPullRequestOptions.Reviewers, "bitbucket-pull-request-reviewers", bitbucketPullRequestOptions.Reviewers, "Bitbucket pull request reviewers, account IDs or {uuid} of Cloud, user names of Server")
+	flags.BoolVar(&bitbucketPullRequestOptions.CloseSourceBranch, "bitbucket-pull-request-close-source-branch", bitbucketPullRequestOptions.CloseSourceBranch, "Bitbucket pull request closes source branch on merge, Cloud only")
+	bitbucketCmd.AddCommand(idempotentCommand(createPullRequestCmd))
+
+	// tools bitbucket merge-pull-request
+	mergePullRequestCmd := &cobra.Command{
+		Use:   "merge-pull-request",
+		Short: "Merge pull request",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Bitbucket merging pull request %d...", bitbucketMergeOptions.ID)
+			common.Debug("Bitbucket", bitbucketRepoOptions, stdout)
+			common.Debug("Bitbucket", bitbucketMergeOptions, stdout)
+
+			if !hooksPreSend(stdout, "bitbucket", &bitbucketMergeOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", []interface{}{bitbucketRepoOptions, bitbucketMergeOptions}, func() ([]byte, error) {
+				return bitbucketNew(stdout).MergePullRequest(bitbucketRepoOptions, bitbucketMergeOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "bitbucket", bytes)
+			common.OutputJson(bitbucketOutput, "Bitbucket", []interface{}{bitbucketOptions, bitbucketRepoOptions, bitbucketMergeOptions}, bytes, stdout)
+		},
+	}
+	flags = mergePullRequestCmd.PersistentFlags()
+	flags.IntVar(&bitbucketMergeOptions.ID, "bitbucket-merge-id", bitbucketMergeOptions.ID, "Bitbucket pull request ID")
+	flags.StringVar(&bitbucketMergeOptions.Strategy, "bitbucket-merge-strategy", bitbucketMergeOptions.Strategy, "Bitbucket merge strategy: merge_commit, squash, fast_forward of Cloud, no-ff, squash, ff-only of Server, default of repository if empty")
+	flags.StringVar(&bitbucketMergeOptions.Message, "bitbucket-merge-message", bitbucketMergeOptions.Message, "Bitbucket merge commit message")
+	flags.BoolVar(&bitbucketMergeOptions.CloseSourceBranch, "bitbucket-merge-close-source-branch", bitbucketMergeOptions.CloseSourceBranch, "Bitbucket merge deletes source branch")
+	bitbucketCmd.AddCommand(idempotentCommand(mergePullRequestCmd))
+
+	// tools bitbucket build-status
+	buildStatusCmd := &cobra.Command{
+		Use:   "build-status",
+		Short: "Publish build status of commit",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Bitbucket publishing build status of %s...", bitbucketBuildStatusOptions.Commit)
+			common.Debug("Bitbucket", bitbucketRepoOptions, stdout)
+			common.Debug("Bitbucket", bitbucketBuildStatusOptions, stdout)
+
+			if !hooksPreSend(stdout, "bitbucket", &bitbucketBuildStatusOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", []interface{}{bitbucketRepoOptions, bitbucketBuildStatusOptions}, func() ([]byte, error) {
+				return bitbucketNew(stdout).SetBuildStatus(bitbucketRepoOptions, bitbucketBuildStatusOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "bitbucket", bytes)
+			common.OutputJson(bitbucketOutput, "Bitbucket", []interface{}{bitbucketOptions, bitbucketRepoOptions, bitbucketBuildStatusOptions}, bytes, stdout)
+		},
+	}
+	flags = buildStatusCmd.PersistentFlags()
+	flags.StringVar(&bitbucketBuildStatusOptions.Commit, "bitbucket-build-commit", bitbucketBuildStatusOptions.Commit, "Bitbucket build commit hash")
+	flags.StringVar(&bitbucketBuildStatusOptions.Key, "bitbucket-build-key", bitbucketBuildStatusOptions.Key, "Bitbucket build key, statuses of the same key are replaced")
+	flags.StringVar(&bitbucketBuildStatusOptions.State, "bitbucket-build-state", bitbucketBuildStatusOptions.State, "Bitbucket build state: INPROGRESS, SUCCESSFUL, FAILED, STOPPED")
+	flags.StringVar(&bitbucketBuildStatusOptions.Name, "bitbucket-build-name", bitbucketBuildStatusOptions.Name, "Bitbucket build name")
+	flags.StringVar(&bitbucketBuildStatusOptions.URL, "bitbucket-build-url", bitbucketBuildStatusOptions.URL, "Bitbucket build URL")
+	flags.StringVar(&bitbucketBuildStatusOptions.Description, "bitbucket-build-description", bitbucketBuildStatusOptions.Description, "Bitbucket build description")
+	bitbucketCmd.AddCommand(idempotentCommand(buildStatusCmd))
+
+	// tools bitbucket variables list|set|delete
+	variablesCmd := &cobra.Command{
+		Use:   "variables",
+		Short: "Manage repository pipelines variables of Cloud",
+	}
+	flags = variablesCmd.PersistentFlags()
+	flags.StringVar(&bitbucketVariableOptions.Key, "bitbucket-variable-key", bitbucketVariableOptions.Key, "Bitbucket variable key")
+	bitbucketCmd.AddCommand(variablesCmd)
+
+	variablesCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List repository variables",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Bitbucket getting variables...")
+			common.Debug("Bitbucket", bitbucketRepoOptions, stdout)
+
+			bytes, err := bitbucketNew(stdout).GetVariables(bitbucketRepoOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(bitbucketOutput, "Bitbucket", []interface{}{bitbucketOptions, bitbucketRepoOptions}, bytes, stdout)
+		},
+	})
+
+	setVariableCmd := &cobra.Command{
+		Use:   "set",
+		Short: "Create or update repository variable",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Bitbucket setting variable %s...", bitbucketVariableOptions.Key)
+			common.Debug("Bitbucket", bitbucketRepoOptions, stdout)
+
+			valueBytes, err := utils.Content(bitbucketVariableOptions.Value)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			bitbucketVariableOptions.Value = string(valueBytes)
+
+			if !hooksPreSend(stdout, "bitbucket", &bitbucketVariableOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", []interface{}{bitbucketRepoOptions, bitbucketVariableOptions}, func() ([]byte, error) {
+				return bitbucketNew(stdout).SetVariable(bitbucketRepoOptions, bitbucketVariableOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "bitbucket", bytes)
+			common.OutputJson(bitbucketOutput, "Bitbucket", []interface{}{bitbucketOptions, bitbucketRepoOptions}, bytes, stdout)
+		},
+	}
+	flags = setVariableCmd.PersistentFlags()
+	flags.StringVar(&bitbucketVariableOptions.Value, "bitbucket-variable-value", bitbucketVariableOptions.Value, "Bitbucket variable value content or file")
+	flags.BoolVar(&bitbucketVariableOptions.Secured, "bitbucket-variable-secured", bitbucketVariableOptions.Secured, "Bitbucket variable is secured")
+	variablesCmd.AddCommand(idempotentCommand(setVariableCmd))
+
+	variablesCmd.AddCommand(&cobra.Command{
+		Use:   "delete",
+		Short: "Delete repository variable",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Bitbucket deleting variable %s...", bitbucketVariableOptions.Key)
+			common.Debug("Bitbucket", bitbucketRepoOptions, stdout)
+
+			if !hooksPreSend(stdout, "bitbucket", &bitbucketVariableOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", []interface{}{bitbucketRepoOptions, bitbucketVariableOptions}, func() ([]byte, error) {
+				return bitbucketNew(stdout).DeleteVariable(bitbucketRepoOptions, bitbucketVariableOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "bitbucket", bytes)
+			common.OutputJson(bitbucketOutput, "Bitbucket", []interface{}{bitbucketOptions, bitbucketRepoOptions}, bytes, stdout)
+		},
+	})
+
+	return bitbucketCmd
+}
+
+func init() {
+	registerVendor("bitbucket", vendorGroupCI, NewBitbucketCommand, bitbucketNew)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/spf13/pflag"
+)
+
+// bulkOptions are of commands sending to many channels, chats or users, e.g. slack broadcast
+var bulkOptions = common.PoolOptions{
+	Concurrency: envGet("BULK_CONCURRENCY", 5).(int),
+	Rate:        envGet("BULK_RATE", 0.0).(float64),
+	StopOnError: envGet("BULK_STOP_ON_ERROR", false).(bool),
+}
+
+func bulkFlags(flags *pflag.FlagSet) {
+
+	flags.IntVar(&bulkOptions.Concurrency, "bulk-concurrency", bulkOptions.Concurrency, "Bulk items sent in parallel")
+	flags.Float64Var(&bulkOptions.Rate, "bulk-rate", bulkOptions.Rate, "Bulk items started per second, e.g. of API rate limits, unlimited if 0")
+	flags.BoolVar(&bulkOptions.StopOnError, "bulk-stop-on-error", bulkOptions.StopOnError, "Bulk items aren't started after the first failure, they are skipped")
+}
+
+// bulkItems returns items without empty and duplicated ones, items of flags are comma or space separated
+func bulkItems(values []string) []string {
+
+	items := []string{}
+	seen := make(map[string]bool)
+	for _, v := range values {
+		for _, item := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if seen[item] {
+				continue
+			}
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// bulkRun runs fn of items by worker pool, report of results is output and command fails
+// with partial failure if some of items have failed, options are of operation of items, e.g. message
+func bulkRun(output common.OutputOptions, name string, obj []interface{}, options interface{}, items []string, fn func(ctx context.Context, item string) ([]byte, error)) {
+
+	if len(items) == 0 {
+		stdout.Error("%s has no items", name)
+		return
+	}
+	stdout.Debug("%s running %d items by %d workers...", name, len(items), bulkOptions.Concurrency)
+
+	// items are done once by idempotency key, so that retried command sends failed items only
+	report := common.PoolRun(deadlineContext, bulkOptions, items, func(ctx context.Context, item string) ([]byte, error) {
+		return idempotent(item, options, func() ([]byte, error) {
+			return fn(ctx, item)
+		})
+	})
+	bytes, err := json.Marshal(report)
+	if err != nil {
+		stdout.Error(err)
+		return
+	}
+	common.OutputJson(output, name, append(obj, bulkOptions), bytes, stdout)
+
+	if err := report.Err(); err != nil {
+		stdout.Error(bulkError(name, report, err))
+	}
+}
+
+// bulkError returns error of report, failed items are listed, so that they can be sent again
+func bulkError(name string, report *common.PoolReport, err error) error {
+
+	failed := []string{}
+	for _, r := range report.Results {
+		if r.Status != common.PoolStatusOK {
+			failed = append(failed, r.Item)
+		}
+	}
+	return fmt.Errorf("%s failed for %s: %w", name, strings.Join(failed, ", "), err)
+}
@@ -1,3 +1,5 @@
+//go:build !minimal || monitoring || catchpoint
+
 package cmd
 
 import (
@@ -40,7 +42,7 @@ func catchpointNew(stdout *common.Stdout) *vendors.Catchpoint {
 	common.Debug("Catchpoint", catchpointOptions, stdout)
 	common.Debug("Catchpoint", catchpointOutput, stdout)
 
-	catchpoint := vendors.NewCatchpoint(catchpointOptions, stdout)
+	catchpoint := vendors.NewCatchpoint(catchpointOptions, stdout.Module("catchpoint"))
 	if catchpoint == nil {
 		stdout.Panic("No site24x7")
 	}
@@ -69,7 +71,9 @@ func NewCatchpointCommand() *cobra.Command {
 			stdout.Debug("Catchpoint instant test...")
 			common.Debug("Catchpoint", catchpointInstantTestOptions, stdout)
 
-			bytes, err := catchpointNew(stdout).InstantTest(catchpointInstantTestOptions)
+			bytes, err := idempotent("", catchpointInstantTestOptions, func() ([]byte, error) {
+				return catchpointNew(stdout).InstantTest(catchpointInstantTestOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -84,7 +88,7 @@ func NewCatchpointCommand() *cobra.Command {
 	flags.IntVar(&catchpointInstantTestOptions.InstantTestType, "test-type-id", catchpointInstantTestOptions.InstantTestType, "Test type ID")
 	flags.IntVar(&catchpointInstantTestOptions.HTTPMethodType, "http-method-type-id", catchpointInstantTestOptions.HTTPMethodType, "HTTP method type ID")
 	flags.IntVar(&catchpointInstantTestOptions.MonitorType, "monitor-type-id", catchpointInstantTestOptions.MonitorType, "Monitor type ID")
-	catchpointCmd.AddCommand(instantTest)
+	catchpointCmd.AddCommand(idempotentCommand(instantTest))
 
 	instantTestWithNodeGroup := &cobra.Command{
 		Use:   "instant-test-with-node-group",
@@ -94,7 +98,9 @@ func NewCatchpointCommand() *cobra.Command {
 			stdout.Debug("Catchpoint instant test with node group...")
 			common.Debug("Catchpoint", catchpointInstantTestWithNodeGroupOptions, stdout)
 
-			bytes, err := catchpointNew(stdout).InstantTestWithNodeGroup(catchpointInstantTestWithNodeGroupOptions)
+			bytes, err := idempotent("", catchpointInstantTestWithNodeGroupOptions, func() ([]byte, error) {
+				return catchpointNew(stdout).InstantTestWithNodeGroup(catchpointInstantTestWithNodeGroupOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -109,7 +115,11 @@ func NewCatchpointCommand() *cobra.Command {
 	flags.IntVar(&catchpointInstantTestWithNodeGroupOptions.InstantTestType, "test-type-id", catchpointInstantTestWithNodeGroupOptions.InstantTestType, "Test type ID")
 	flags.IntVar(&catchpointInstantTestWithNodeGroupOptions.HTTPMethodType, "http-method-type-id", catchpointInstantTestWithNodeGroupOptions.HTTPMethodType, "HTTP method type ID")
 	flags.IntVar(&catchpointInstantTestWithNodeGroupOptions.MonitorType, "monitor-type-id", catchpointInstantTestWithNodeGroupOptions.MonitorType, "Monitor type ID")
-	catchpointCmd.AddCommand(instantTestWithNodeGroup)
+	catchpointCmd.AddCommand(idempotentCommand(instantTestWithNodeGroup))
 
 	return catchpointCmd
 }
+
+func init() {
+	registerVendor("catchpoint", vendorGroupMonitoring, NewCatchpointCommand, catchpointNew)
+}
@@ -0,0 +1,209 @@
+//go:build !minimal || cloud || cloudflare
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+var cloudflareOptions = vendors.CloudflareOptions{
+	Timeout:  envGet("CLOUDFLARE_TIMEOUT", 30).(int),
+	Insecure: envGet("CLOUDFLARE_INSECURE", false).(bool),
+	URL:      envGet("CLOUDFLARE_URL", "").(string),
+	Token:    envGet("CLOUDFLARE_TOKEN", "").(string),
+	Email:    envGet("CLOUDFLARE_EMAIL", "").(string),
+	Key:      envGet("CLOUDFLARE_KEY", "").(string),
+	Zone:     envGet("CLOUDFLARE_ZONE", "").(string),
+}
+
+var cloudflareDNSRecordOptions = vendors.CloudflareDNSRecordOptions{
+	ID:      envGet("CLOUDFLARE_DNS_ID", "").(string),
+	Type:    envGet("CLOUDFLARE_DNS_TYPE", "").(string),
+	Name:    envGet("CLOUDFLARE_DNS_NAME", "").(string),
+	Content: envGet("CLOUDFLARE_DNS_CONTENT", "").(string),
+	TTL:     envGet("CLOUDFLARE_DNS_TTL", 0).(int),
+	Proxied: envGet("CLOUDFLARE_DNS_PROXIED", "").(string),
+	Comment: envGet("CLOUDFLARE_DNS_COMMENT", "").(string),
+}
+
+var cloudflarePurgeOptions = vendors.CloudflarePurgeOptions{
+	Everything: envGet("CLOUDFLARE_PURGE_EVERYTHING", false).(bool),
+	Files:      strings.Split(envGet("CLOUDFLARE_PURGE_FILES", "").(string), ","),
+	Tags:       strings.Split(envGet("CLOUDFLARE_PURGE_TAGS", "").(string), ","),
+	Hosts:      strings.Split(envGet("CLOUDFLARE_PURGE_HOSTS", "").(string), ","),
+	Prefixes:   strings.Split(envGet("CLOUDFLARE_PURGE_PREFIXES", "").(string), ","),
+}
+
+var cloudflareFirewallRuleOptions = vendors.CloudflareFirewallRuleOptions{
+	Rule: envGet("CLOUDFLARE_FIREWALL_RULE", "").(string),
+}
+
+var cloudflareOutput = common.OutputOptions{
+	Output: envGet("CLOUDFLARE_OUTPUT", "").(string),
+	Query:  envGet("CLOUDFLARE_OUTPUT_QUERY", "").(string),
+}
+
+func cloudflareNew(stdout *common.Stdout) *vendors.Cloudflare {
+
+	common.Debug("Cloudflare", cloudflareOptions, stdout)
+	common.Debug("Cloudflare", cloudflareOutput, stdout)
+
+	return vendors.NewCloudflare(cloudflareOptions)
+}
+
+// cloudflareDNSCommand is command which changes DNS record by action of vendor
+func cloudflareDNSCommand(use, short, debug string, action func(*vendors.Cloudflare, vendors.CloudflareDNSRecordOptions) ([]byte, error)) *cobra.Command {
+
+	dnsCmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Cloudflare %s %s %s...", debug, cloudflareDNSRecordOptions.Type, cloudflareDNSRecordOptions.Name)
+			common.Debug("Cloudflare", cloudflareDNSRecordOptions, stdout)
+
+			recordOptions := cloudflareDNSRecordOptions
+			if !hooksPreSend(stdout, "cloudflare", &recordOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", recordOptions, func() ([]byte, error) {
+				return action(cloudflareNew(stdout), recordOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "cloudflare", bytes)
+			common.OutputJson(cloudflareOutput, "Cloudflare", []interface{}{cloudflareOptions, recordOptions}, bytes, stdout)
+		},
+	}
+	flags := dnsCmd.PersistentFlags()
+	flags.StringVar(&cloudflareDNSRecordOptions.ID, "cloudflare-dns-id", cloudflareDNSRecordOptions.ID, "Cloudflare DNS record ID, record is found by name and type if empty")
+	if use != "delete-dns-record" {
+		flags.StringVar(&cloudflareDNSRecordOptions.Content, "cloudflare-dns-content", cloudflareDNSRecordOptions.Content, "Cloudflare DNS record content, e.g. IP address")
+		flags.IntVar(&cloudflareDNSRecordOptions.TTL, "cloudflare-dns-ttl", cloudflareDNSRecordOptions.TTL, "Cloudflare DNS record TTL in seconds, automatic if 0")
+		flags.StringVar(&cloudflareDNSRecordOptions.Proxied, "cloudflare-dns-proxied", cloudflareDNSRecordOptions.Proxied, "Cloudflare DNS record is proxied: true, false, kept on update if empty")
+		flags.StringVar(&cloudflareDNSRecordOptions.Comment, "cloudflare-dns-comment", cloudflareDNSRecordOptions.Comment, "Cloudflare DNS record comment")
+	}
+	return idempotentCommand(dnsCmd)
+}
+
+// cloudflareFirewallRuleCommand enables or disables firewall rule
+func cloudflareFirewallRuleCommand(use, short string, enabled bool) *cobra.Command {
+
+	ruleCmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Cloudflare setting firewall rule %s enabled %v...", cloudflareFirewallRuleOptions.Rule, enabled)
+			common.Debug("Cloudflare", cloudflareFirewallRuleOptions, stdout)
+
+			ruleOptions := cloudflareFirewallRuleOptions
+			ruleOptions.Enabled = enabled
+			if !hooksPreSend(stdout, "cloudflare", &ruleOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", ruleOptions, func() ([]byte, error) {
+				return cloudflareNew(stdout).SetFirewallRule(ruleOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "cloudflare", bytes)
+			common.OutputJson(cloudflareOutput, "Cloudflare", []interface{}{cloudflareOptions, ruleOptions}, bytes, stdout)
+		},
+	}
+	flags := ruleCmd.PersistentFlags()
+	flags.StringVar(&cloudflareFirewallRuleOptions.Rule, "cloudflare-firewall-rule", cloudflareFirewallRuleOptions.Rule, "Cloudflare firewall custom rule ID or description")
+	return idempotentCommand(ruleCmd)
+}
+
+func NewCloudflareCommand() *cobra.Command {
+
+	cloudflareCmd := &cobra.Command{
+		Use:   "cloudflare",
+		Short: "Cloudflare tools",
+	}
+	flags := cloudflareCmd.PersistentFlags()
+	flags.IntVar(&cloudflareOptions.Timeout, "cloudflare-timeout", cloudflareOptions.Timeout, "Cloudflare timeout in seconds")
+	flags.BoolVar(&cloudflareOptions.Insecure, "cloudflare-insecure", cloudflareOptions.Insecure, "Cloudflare insecure")
+	flags.StringVar(&cloudflareOptions.URL, "cloudflare-url", cloudflareOptions.URL, "Cloudflare API URL, https://api.cloudflare.com/client/v4 if empty")
+	flags.StringVar(&cloudflareOptions.Token, "cloudflare-token", cloudflareOptions.Token, "Cloudflare API token")
+	flags.StringVar(&cloudflareOptions.Email, "cloudflare-email", cloudflareOptions.Email, "Cloudflare account email, it's used with key if token is empty")
+	flags.StringVar(&cloudflareOptions.Key, "cloudflare-key", cloudflareOptions.Key, "Cloudflare global API key")
+	flags.StringVar(&cloudflareOptions.Zone, "cloudflare-zone", cloudflareOptions.Zone, "Cloudflare zone ID or name")
+	flags.StringVar(&cloudflareDNSRecordOptions.Type, "cloudflare-dns-type", cloudflareDNSRecordOptions.Type, "Cloudflare DNS record type: A, AAAA, CNAME, TXT...")
+	flags.StringVar(&cloudflareDNSRecordOptions.Name, "cloudflare-dns-name", cloudflareDNSRecordOptions.Name, "Cloudflare DNS record name")
+	flags.StringVar(&cloudflareOutput.Output, "cloudflare-output", cloudflareOutput.Output, "Cloudflare output")
+	flags.StringVar(&cloudflareOutput.Query, "cloudflare-output-query", cloudflareOutput.Query, "Cloudflare output query")
+
+	cloudflareCmd.AddCommand(&cobra.Command{
+		Use:   "get-dns-records",
+		Short: "Get DNS records of zone by name and type",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Cloudflare getting DNS records...")
+			common.Debug("Cloudflare", cloudflareDNSRecordOptions, stdout)
+
+			bytes, err := cloudflareNew(stdout).GetDNSRecords(cloudflareDNSRecordOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(cloudflareOutput, "Cloudflare", []interface{}{cloudflareOptions, cloudflareDNSRecordOptions}, bytes, stdout)
+		},
+	})
+
+	cloudflareCmd.AddCommand(cloudflareDNSCommand("create-dns-record", "Create DNS record", "creating DNS record", (*vendors.Cloudflare).CreateDNSRecord))
+	cloudflareCmd.AddCommand(cloudflareDNSCommand("update-dns-record", "Update DNS record by ID or name and type", "updating DNS record", (*vendors.Cloudflare).UpdateDNSRecord))
+	cloudflareCmd.AddCommand(cloudflareDNSCommand("delete-dns-record", "Delete DNS record by ID or name and type", "deleting DNS record", (*vendors.Cloudflare).DeleteDNSRecord))
+
+	purgeCacheCmd := &cobra.Command{
+		Use:   "purge-cache",
+		Short: "Purge cache of zone, everything or by files, tags, hosts and prefixes",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Cloudflare purging cache of %s...", cloudflareOptions.Zone)
+			common.Debug("Cloudflare", cloudflarePurgeOptions, stdout)
+
+			purgeOptions := cloudflarePurgeOptions
+			if !hooksPreSend(stdout, "cloudflare", &purgeOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", purgeOptions, func() ([]byte, error) {
+				return cloudflareNew(stdout).PurgeCache(purgeOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "cloudflare", bytes)
+			common.OutputJson(cloudflareOutput, "Cloudflare", []interface{}{cloudflareOptions, purgeOptions}, bytes, stdout)
+		},
+	}
+	flags = purgeCacheCmd.PersistentFlags()
+	flags.BoolVar(&cloudflarePurgeOptions.Everything, "cloudflare-purge-everything", cloudflarePurgeOptions.Everything, "Cloudflare purges everything")
+	flags.StringSliceVar(&cloudflarePurgeOptions.Files, "cloudflare-purge-files", cloudflarePurgeOptions.Files, "Cloudflare URLs to purge")
+	flags.StringSliceVar(&cloudflarePurgeOptions.Tags, "cloudflare-purge-tags", cloudflarePurgeOptions.Tags, "Cloudflare cache tags to purge")
+	flags.StringSliceVar(&cloudflarePurgeOptions.Hosts, "cloudflare-purge-hosts", cloudflarePurgeOptions.Hosts, "Cloudflare hosts to purge")
+	flags.StringSliceVar(&cloudflarePurgeOptions.Prefixes, "cloudflare-purge-prefixes", cloudflarePurgeOptions.Prefixes, "Cloudflare URL prefixes to purge")
+	cloudflareCmd.AddCommand(idempotentCommand(purgeCacheCmd))
+
+	cloudflareCmd.AddCommand(cloudflareFirewallRuleCommand("enable-firewall-rule", "Enable firewall custom rule", true))
+	cloudflareCmd.AddCommand(cloudflareFirewallRuleCommand("disable-firewall-rule", "Disable firewall custom rule", false))
+
+	return cloudflareCmd
+}
+
+func init() {
+	registerVendor("cloudflare", vendorGroupCloud, NewCloudflareCommand, cloudflareNew)
+}
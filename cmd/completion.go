@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/spf13/cobra"
+)
+
+// completionCacheTTL is seconds of responses of dynamic completions being cached if HTTP cache is disabled,
+// so that channels and calendars aren't requested on each tab
+var completionCacheTTL = envGet("COMPLETION_CACHE_TTL", 3600).(int)
+
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// completionCommand returns true if command generates completion script or is called by it,
+// output of such commands is read by shell, so that logs, config errors and telemetry are skipped
+func completionCommand(cmd *cobra.Command) bool {
+
+	switch cmd.Name() {
+	case "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return cmd.Parent() == cmd.Root()
+	}
+	return false
+}
+
+// completionStdout is stdout of completion commands, only panics are written to stderr
+func completionStdout() *common.Stdout {
+
+	options := stdoutOptions
+	options.Level = "panic"
+	options.Levels = nil
+	options.Stderr = true
+	options.File = ""
+	return common.NewStdout(options)
+}
+
+// completionStart applies config, secrets and HTTP options before vendors are requested by dynamic completion,
+// flags are parsed by then, so that --config and --profile of command line are used as well,
+// secrets of flags of completed command are resolved only
+func completionStart(cmd *cobra.Command) bool {
+
+	if _, err := configApply(cmd); err != nil {
+		return false
+	}
+	if err := secretsResolve(cmd); err != nil {
+		return false
+	}
+	options := httpClientOptions
+	if options.CacheTTL <= 0 {
+		options.CacheTTL = completionCacheTTL
+	}
+	if err := common.HttpValidateOptions(options); err != nil {
+		return false
+	}
+	common.SetHttpClientOptions(options)
+	common.SetHttpStdout(stdout.Module("http"))
+	return true
+}
+
+// completionFilter returns items having prefix of last item of comma separated list being completed,
+// items are prefixed by items before it, e.g. of --slack-channels
+func completionFilter(items []string, toComplete string) []string {
+
+	prefix := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		prefix = toComplete[:i+1]
+		toComplete = toComplete[i+1:]
+	}
+	r := []string{}
+	for _, item := range items {
+		if strings.HasPrefix(item, toComplete) {
+			r = append(r, prefix+item)
+		}
+	}
+	return r
+}
+
+// completionValues completes flag by static values, e.g. of formats
+func completionValues(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completionFilter(values, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func completionProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+
+	profiles, err := configProfiles()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return completionFilter(profiles, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func NewCompletionCommand() *cobra.Command {
+
+	completionCmd := &cobra.Command{
+		Use:   fmt.Sprintf("completion [%s]", strings.Join(completionShells, "|")),
+		Short: "Generate shell completion script",
+		Long: `Generate shell completion script of commands and flags, values of some flags are completed dynamically,
+e.g. Slack channels, Google calendar ids and config profiles.
+
+  bash:       source <(tools completion bash)
+  zsh:        tools completion zsh > "${fpath[1]}/_tools"
+  fish:       tools completion fish > ~/.config/fish/completions/tools.fish
+  powershell: tools completion powershell | Out-String | Invoke-Expression`,
+		ValidArgs:             completionShells,
+		Args:                  cobra.ExactValidArgs(1),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+
+			var err error
+			root := cmd.Root()
+			switch args[0] {
+			case "bash":
+				err = root.GenBashCompletionV2(os.Stdout, true)
+			case "zsh":
+				err = root.GenZshCompletion(os.Stdout)
+			case "fish":
+				err = root.GenFishCompletion(os.Stdout, true)
+			case "powershell":
+				err = root.GenPowerShellCompletionWithDesc(os.Stdout)
+			}
+			if err != nil {
+				exitConfig(err)
+			}
+		},
+	}
+	return completionCmd
+}
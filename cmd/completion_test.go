@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/devopsext/tools/common"
+)
+
+func TestCompletionFilter(t *testing.T) {
+
+	items := []string{"alerts", "alerts-prod", "deploys"}
+	tests := []struct {
+		toComplete string
+		expected   []string
+	}{
+		{"", []string{"alerts", "alerts-prod", "deploys"}},
+		{"alerts-", []string{"alerts-prod"}},
+		{"deploys,al", []string{"deploys,alerts", "deploys,alerts-prod"}},
+		{"x", []string{}},
+	}
+	for _, tt := range tests {
+		r := completionFilter(items, tt.toComplete)
+		if !reflect.DeepEqual(r, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.toComplete, tt.expected, r)
+		}
+	}
+}
+
+func TestCompletionSecrets(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-1" {
+			w.Write([]byte(`{"ok":false,"error":"invalid_auth"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"channels":[{"id":"C1","name":"alerts"},{"id":"C2","name":"deploys"}]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("COMPLETION_TEST_SLACK", "xoxb-1")
+
+	slack, cache, out := slackOptions, httpClientOptions, stdout
+	defer func() {
+		slackOptions, httpClientOptions, stdout = slack, cache, out
+		common.SetHttpClientOptions(cache)
+		secretsRoot = nil
+	}()
+	slackOptions.URL = srv.URL
+	slackOptions.Token = "env://COMPLETION_TEST_SLACK"
+	httpClientOptions.CacheDir = t.TempDir()
+	stdout = completionStdout()
+
+	cmd, _, err := NewSlackCommand().Find([]string{"send-message"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.ParseFlags(nil); err != nil {
+		t.Fatal(err)
+	}
+	// token is reference, it's resolved before channels are requested
+	r, _ := slackCompleteChannels(cmd, nil, "al")
+	if !reflect.DeepEqual(r, []string{"alerts"}) {
+		t.Errorf("expected alerts, got %v", r)
+	}
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+type ConfigOptions struct {
+	File    string
+	Profile string
+}
+
+var configOptions = ConfigOptions{
+	File:    envGet("CONFIG", "").(string),
+	Profile: envGet("PROFILE", "").(string),
+}
+
+// configFile returns file of options, default one is optional
+func configFile() (string, bool) {
+
+	if !utils.IsEmpty(configOptions.File) {
+		return configOptions.File, true
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(home, ".config", "tools", "config.yaml"), false
+}
+
+// configFlatten returns values of nested keys joined as flag names, e.g. slack: {message: {channel: C1}} => slack-message-channel
+func configFlatten(prefix string, m map[string]interface{}, values map[string][]string) {
+
+	for k, v := range m {
+		name := strings.ToLower(strings.ReplaceAll(k, "_", "-"))
+		if !utils.IsEmpty(prefix) {
+			name = fmt.Sprintf("%s-%s", prefix, name)
+		}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			configFlatten(name, v, values)
+		case []interface{}:
+			items := []string{}
+			for _, item := range v {
+				items = append(items, fmt.Sprintf("%v", item))
+			}
+			values[name] = items
+		case nil:
+		default:
+			values[name] = []string{fmt.Sprintf("%v", v)}
+		}
+	}
+}
+
+// configValues returns values of config file with values of profile over them,
+// profile is set by flag, env var or profile key of file
+func configValues() (map[string][]string, error) {
+
+	file, required := configFile()
+	if utils.IsEmpty(file) {
+		return nil, nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		if !required && errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	profiles, _ := config["profiles"].(map[string]interface{})
+	delete(config, "profiles")
+
+	profile := configOptions.Profile
+	if p, ok := config["profile"].(string); ok && utils.IsEmpty(profile) {
+		profile = p
+	}
+	delete(config, "profile")
+
+	values := make(map[string][]string)
+	configFlatten("", config, values)
+	if utils.IsEmpty(profile) {
+		return values, nil
+	}
+	p, ok := profiles[profile].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: no profile %s", file, profile)
+	}
+	configFlatten("", p, values)
+	return values, nil
+}
+
+// configProfiles returns sorted names of profiles of config file, e.g. of completion of --profile
+func configProfiles() ([]string, error) {
+
+	file, required := configFile()
+	if utils.IsEmpty(file) {
+		return nil, nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		if !required && errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var config struct {
+		Profiles map[string]interface{} `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	profiles := []string{}
+	for name := range config.Profiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// configApply sets flags of all commands from config file, flags which are set and flags of env vars which are set
+// are kept, so that precedence is flags, env vars, profile, config and defaults, keys without flags are returned
+func configApply(cmd *cobra.Command) ([]string, error) {
+
+	values, err := configValues()
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+
+	flags := make(map[string][]*pflag.Flag)
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		for _, fs := range []*pflag.FlagSet{c.PersistentFlags(), c.LocalNonPersistentFlags()} {
+			fs.VisitAll(func(f *pflag.Flag) {
+				flags[f.Name] = append(flags[f.Name], f)
+			})
+		}
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(cmd.Root())
+
+	unknown := []string{}
+	for name, items := range values {
+
+		fs, ok := flags[name]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		if env := describeEnv(name); !utils.IsEmpty(env) {
+			if _, ok := os.LookupEnv(env); ok {
+				continue
+			}
+		}
+		for _, f := range fs {
+			if f.Changed {
+				continue
+			}
+			for _, item := range items {
+				if err := f.Value.Set(item); err != nil {
+					return nil, fmt.Errorf("config %s: %s", name, err)
+				}
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown, nil
+}
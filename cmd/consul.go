@@ -0,0 +1,210 @@
+//go:build !minimal || config || consul
+
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var consulOptions = vendors.ConsulOptions{
+	Timeout:    envGet("CONSUL_TIMEOUT", 30).(int),
+	Insecure:   envGet("CONSUL_INSECURE", false).(bool),
+	URL:        envGet("CONSUL_URL", "").(string),
+	Token:      envGet("CONSUL_TOKEN", "").(string),
+	Datacenter: envGet("CONSUL_DATACENTER", "").(string),
+}
+
+var consulKVOptions = vendors.ConsulKVOptions{
+	Key:     envGet("CONSUL_KV_KEY", "").(string),
+	Value:   envGet("CONSUL_KV_VALUE", "").(string),
+	Recurse: envGet("CONSUL_KV_RECURSE", false).(bool),
+}
+
+var consulCatalogOptions = vendors.ConsulCatalogOptions{
+	Service: envGet("CONSUL_CATALOG_SERVICE", "").(string),
+	Tag:     envGet("CONSUL_CATALOG_TAG", "").(string),
+	Passing: envGet("CONSUL_CATALOG_PASSING", false).(bool),
+}
+
+var consulMaintenanceOptions = vendors.ConsulMaintenanceOptions{
+	ServiceID: envGet("CONSUL_MAINTENANCE_SERVICE_ID", "").(string),
+	Enable:    envGet("CONSUL_MAINTENANCE_ENABLE", true).(bool),
+	Reason:    envGet("CONSUL_MAINTENANCE_REASON", "").(string),
+}
+
+var consulOutput = common.OutputOptions{
+	Output: envGet("CONSUL_OUTPUT", "").(string),
+	Query:  envGet("CONSUL_OUTPUT_QUERY", "").(string),
+}
+
+func consulNew(stdout *common.Stdout) *vendors.Consul {
+
+	common.Debug("Consul", consulOptions, stdout)
+	common.Debug("Consul", consulOutput, stdout)
+
+	return vendors.NewConsul(consulOptions)
+}
+
+func NewConsulCommand() *cobra.Command {
+
+	consulCmd := &cobra.Command{
+		Use:   "consul",
+		Short: "Consul tools",
+	}
+	flags := consulCmd.PersistentFlags()
+	flags.IntVar(&consulOptions.Timeout, "consul-timeout", consulOptions.Timeout, "Consul timeout in seconds")
+	flags.BoolVar(&consulOptions.Insecure, "consul-insecure", consulOptions.Insecure, "Consul insecure")
+	flags.StringVar(&consulOptions.URL, "consul-url", consulOptions.URL, "Consul URL")
+	flags.StringVar(&consulOptions.Token, "consul-token", consulOptions.Token, "Consul ACL token")
+	flags.StringVar(&consulOptions.Datacenter, "consul-datacenter", consulOptions.Datacenter, "Consul datacenter, datacenter of agent if empty")
+	flags.StringVar(&consulOutput.Output, "consul-output", consulOutput.Output, "Consul output")
+	flags.StringVar(&consulOutput.Query, "consul-output-query", consulOutput.Query, "Consul output query")
+
+	kvCmd := &cobra.Command{
+		Use:   "kv",
+		Short: "KV store",
+	}
+	flags = kvCmd.PersistentFlags()
+	flags.StringVar(&consulKVOptions.Key, "consul-kv-key", consulKVOptions.Key, "Consul KV key or prefix")
+	consulCmd.AddCommand(kvCmd)
+
+	kvGetCmd := &cobra.Command{
+		Use:   "get",
+		Short: "Get key value or values of prefix",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Consul getting key %s...", consulKVOptions.Key)
+
+			bytes, err := consulNew(stdout).GetKV(consulKVOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			if !consulKVOptions.Recurse {
+				common.OutputRaw(consulOutput.Output, bytes, stdout)
+				return
+			}
+			common.OutputJson(consulOutput, "Consul", []interface{}{consulOptions, consulKVOptions}, bytes, stdout)
+		},
+	}
+	flags = kvGetCmd.PersistentFlags()
+	flags.BoolVar(&consulKVOptions.Recurse, "consul-kv-recurse", consulKVOptions.Recurse, "Consul KV get keys of prefix")
+	kvCmd.AddCommand(kvGetCmd)
+
+	kvPutCmd := &cobra.Command{
+		Use:   "put",
+		Short: "Put key value",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Consul putting key %s...", consulKVOptions.Key)
+
+			valueBytes, err := utils.Content(consulKVOptions.Value)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			consulKVOptions.Value = string(valueBytes)
+
+			if !hooksPreSend(stdout, "consul", &consulKVOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", consulKVOptions, func() ([]byte, error) {
+				return consulNew(stdout).PutKV(consulKVOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "consul", bytes)
+			common.OutputJson(consulOutput, "Consul", []interface{}{consulOptions, consulKVOptions}, bytes, stdout)
+		},
+	}
+	flags = kvPutCmd.PersistentFlags()
+	flags.StringVar(&consulKVOptions.Value, "consul-kv-value", consulKVOptions.Value, "Consul KV value, file or content")
+	kvCmd.AddCommand(idempotentCommand(kvPutCmd))
+
+	kvDeleteCmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete key or keys of prefix",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Consul deleting key %s...", consulKVOptions.Key)
+
+			if !hooksPreSend(stdout, "consul", &consulKVOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", consulKVOptions, func() ([]byte, error) {
+				return consulNew(stdout).DeleteKV(consulKVOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "consul", bytes)
+			common.OutputJson(consulOutput, "Consul", []interface{}{consulOptions, consulKVOptions}, bytes, stdout)
+		},
+	}
+	flags = kvDeleteCmd.PersistentFlags()
+	flags.BoolVar(&consulKVOptions.Recurse, "consul-kv-recurse", consulKVOptions.Recurse, "Consul KV delete keys of prefix")
+	kvCmd.AddCommand(idempotentCommand(kvDeleteCmd))
+
+	catalogCmd := &cobra.Command{
+		Use:   "catalog",
+		Short: "Get services or instances of service",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Consul getting catalog %s...", consulCatalogOptions.Service)
+
+			bytes, err := consulNew(stdout).GetCatalog(consulCatalogOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(consulOutput, "Consul", []interface{}{consulOptions, consulCatalogOptions}, bytes, stdout)
+		},
+	}
+	flags = catalogCmd.PersistentFlags()
+	flags.StringVar(&consulCatalogOptions.Service, "consul-catalog-service", consulCatalogOptions.Service, "Consul catalog service, all services if empty")
+	flags.StringVar(&consulCatalogOptions.Tag, "consul-catalog-tag", consulCatalogOptions.Tag, "Consul catalog service tag")
+	flags.BoolVar(&consulCatalogOptions.Passing, "consul-catalog-passing", consulCatalogOptions.Passing, "Consul catalog instances with passing checks only")
+	consulCmd.AddCommand(catalogCmd)
+
+	maintenanceCmd := &cobra.Command{
+		Use:   "maintenance",
+		Short: "Enable or disable maintenance mode of node or service",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Consul setting maintenance of %s...", consulMaintenanceOptions.ServiceID)
+
+			if !hooksPreSend(stdout, "consul", &consulMaintenanceOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", consulMaintenanceOptions, func() ([]byte, error) {
+				return consulNew(stdout).SetMaintenance(consulMaintenanceOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "consul", bytes)
+			common.OutputJson(consulOutput, "Consul", []interface{}{consulOptions, consulMaintenanceOptions}, bytes, stdout)
+		},
+	}
+	flags = maintenanceCmd.PersistentFlags()
+	flags.StringVar(&consulMaintenanceOptions.ServiceID, "consul-maintenance-service-id", consulMaintenanceOptions.ServiceID, "Consul maintenance service ID, node of agent if empty")
+	flags.BoolVar(&consulMaintenanceOptions.Enable, "consul-maintenance-enable", consulMaintenanceOptions.Enable, "Consul maintenance enable, false to disable")
+	flags.StringVar(&consulMaintenanceOptions.Reason, "consul-maintenance-reason", consulMaintenanceOptions.Reason, "Consul maintenance reason")
+	consulCmd.AddCommand(idempotentCommand(maintenanceCmd))
+
+	return consulCmd
+}
+
+func init() {
+	registerVendor("consul", vendorGroupConfig, NewConsulCommand, consulNew)
+}
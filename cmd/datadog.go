@@ -0,0 +1,221 @@
+//go:build !minimal || monitoring || datadog
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/server"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var datadogOptions = vendors.DatadogOptions{
+	Timeout:  envGet("DATADOG_TIMEOUT", 30).(int),
+	Insecure: envGet("DATADOG_INSECURE", false).(bool),
+	URL:      envGet("DATADOG_URL", "https://api.datadoghq.com").(string),
+	APIKey:   envGet("DATADOG_API_KEY", "").(string),
+	AppKey:   envGet("DATADOG_APP_KEY", "").(string),
+}
+
+var datadogEventOptions = vendors.DatadogEventOptions{
+	Title:          envGet("DATADOG_EVENT_TITLE", "").(string),
+	Text:           envGet("DATADOG_EVENT_TEXT", "").(string),
+	Tags:           envGet("DATADOG_EVENT_TAGS", "").(string),
+	AlertType:      envGet("DATADOG_EVENT_ALERT_TYPE", "info").(string),
+	Priority:       envGet("DATADOG_EVENT_PRIORITY", "normal").(string),
+	AggregationKey: envGet("DATADOG_EVENT_AGGREGATION_KEY", "").(string),
+	SourceType:     envGet("DATADOG_EVENT_SOURCE_TYPE", "").(string),
+	Host:           envGet("DATADOG_EVENT_HOST", "").(string),
+}
+
+var datadogMetricOptions = vendors.DatadogMetricOptions{
+	Metric:   envGet("DATADOG_METRIC", "").(string),
+	Type:     envGet("DATADOG_METRIC_TYPE", "gauge").(string),
+	Value:    envGet("DATADOG_METRIC_VALUE", 0.0).(float64),
+	Tags:     envGet("DATADOG_METRIC_TAGS", "").(string),
+	Host:     envGet("DATADOG_METRIC_HOST", "").(string),
+	Interval: envGet("DATADOG_METRIC_INTERVAL", 0).(int),
+}
+
+var datadogMonitorOptions = vendors.DatadogMonitorOptions{
+	ID:       envGet("DATADOG_MONITOR_ID", "").(string),
+	Scope:    envGet("DATADOG_MONITOR_SCOPE", "").(string),
+	Duration: envGet("DATADOG_MONITOR_DURATION", 0).(int),
+}
+
+var datadogOutput = common.OutputOptions{
+	Output: envGet("DATADOG_OUTPUT", "").(string),
+	Query:  envGet("DATADOG_OUTPUT_QUERY", "").(string),
+}
+
+func datadogNew(stdout *common.Stdout) *vendors.Datadog {
+
+	common.Debug("Datadog", datadogOptions, stdout)
+	common.Debug("Datadog", datadogOutput, stdout)
+
+	return vendors.NewDatadog(datadogOptions)
+}
+
+func NewDatadogCommand() *cobra.Command {
+
+	datadogCmd := &cobra.Command{
+		Use:   "datadog",
+		Short: "Datadog tools",
+	}
+	flags := datadogCmd.PersistentFlags()
+	flags.IntVar(&datadogOptions.Timeout, "datadog-timeout", datadogOptions.Timeout, "Datadog timeout in seconds")
+	flags.BoolVar(&datadogOptions.Insecure, "datadog-insecure", datadogOptions.Insecure, "Datadog insecure")
+	flags.StringVar(&datadogOptions.URL, "datadog-url", datadogOptions.URL, "Datadog API URL of site, e.g. https://api.datadoghq.eu")
+	flags.StringVar(&datadogOptions.APIKey, "datadog-api-key", datadogOptions.APIKey, "Datadog API key")
+	flags.StringVar(&datadogOptions.AppKey, "datadog-app-key", datadogOptions.AppKey, "Datadog application key, needed for monitors")
+	flags.StringVar(&datadogOutput.Output, "datadog-output", datadogOutput.Output, "Datadog output")
+	flags.StringVar(&datadogOutput.Query, "datadog-output-query", datadogOutput.Query, "Datadog output query")
+
+	postEventCmd := &cobra.Command{
+		Use:   "post-event",
+		Short: "Post event, e.g. deploy marker",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Datadog posting event...")
+			common.Debug("Datadog", datadogEventOptions, stdout)
+
+			textBytes, err := utils.Content(datadogEventOptions.Text)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			datadogEventOptions.Text = string(textBytes)
+
+			if !hooksPreSend(stdout, "datadog", &datadogEventOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", datadogEventOptions, func() ([]byte, error) {
+				return datadogNew(stdout).PostEvent(datadogEventOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "datadog", bytes)
+			common.OutputJson(datadogOutput, "Datadog", []interface{}{datadogOptions, datadogEventOptions}, bytes, stdout)
+		},
+	}
+	flags = postEventCmd.PersistentFlags()
+	flags.StringVar(&datadogEventOptions.Title, "datadog-event-title", datadogEventOptions.Title, "Datadog event title")
+	flags.StringVar(&datadogEventOptions.Text, "datadog-event-text", datadogEventOptions.Text, "Datadog event text, markdown with %%% prefix and suffix")
+	flags.StringVar(&datadogEventOptions.Tags, "datadog-event-tags", datadogEventOptions.Tags, "Datadog event tags, comma-separated, e.g. env:prod,service:api")
+	flags.StringVar(&datadogEventOptions.AlertType, "datadog-event-alert-type", datadogEventOptions.AlertType, "Datadog event alert type: info, success, warning, error")
+	flags.StringVar(&datadogEventOptions.Priority, "datadog-event-priority", datadogEventOptions.Priority, "Datadog event priority: normal, low")
+	flags.StringVar(&datadogEventOptions.AggregationKey, "datadog-event-aggregation-key", datadogEventOptions.AggregationKey, "Datadog event aggregation key")
+	flags.StringVar(&datadogEventOptions.SourceType, "datadog-event-source-type", datadogEventOptions.SourceType, "Datadog event source type name, e.g. jenkins")
+	flags.StringVar(&datadogEventOptions.Host, "datadog-event-host", datadogEventOptions.Host, "Datadog event host")
+	datadogCmd.AddCommand(idempotentCommand(postEventCmd))
+
+	submitMetricCmd := &cobra.Command{
+		Use:   "submit-metric",
+		Short: "Submit custom metric point",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Datadog submitting metric %s...", datadogMetricOptions.Metric)
+			common.Debug("Datadog", datadogMetricOptions, stdout)
+
+			bytes, err := idempotent("", datadogMetricOptions, func() ([]byte, error) {
+				return datadogNew(stdout).SubmitMetric(datadogMetricOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(datadogOutput, "Datadog", []interface{}{datadogOptions, datadogMetricOptions}, bytes, stdout)
+		},
+	}
+	flags = submitMetricCmd.PersistentFlags()
+	flags.StringVar(&datadogMetricOptions.Metric, "datadog-metric", datadogMetricOptions.Metric, "Datadog metric name")
+	flags.StringVar(&datadogMetricOptions.Type, "datadog-metric-type", datadogMetricOptions.Type, "Datadog metric type: gauge, count, rate")
+	flags.Float64Var(&datadogMetricOptions.Value, "datadog-metric-value", datadogMetricOptions.Value, "Datadog metric value")
+	flags.StringVar(&datadogMetricOptions.Tags, "datadog-metric-tags", datadogMetricOptions.Tags, "Datadog metric tags, comma-separated")
+	flags.StringVar(&datadogMetricOptions.Host, "datadog-metric-host", datadogMetricOptions.Host, "Datadog metric host")
+	flags.IntVar(&datadogMetricOptions.Interval, "datadog-metric-interval", datadogMetricOptions.Interval, "Datadog metric interval in seconds for count and rate")
+	datadogCmd.AddCommand(idempotentCommand(submitMetricCmd))
+
+	monitorFlags := func(cmd *cobra.Command) {
+		flags := cmd.PersistentFlags()
+		flags.StringVar(&datadogMonitorOptions.ID, "datadog-monitor-id", datadogMonitorOptions.ID, "Datadog monitor ID")
+		flags.StringVar(&datadogMonitorOptions.Scope, "datadog-monitor-scope", datadogMonitorOptions.Scope, "Datadog monitor scope, e.g. host:web-1, all if empty")
+	}
+
+	muteMonitorCmd := &cobra.Command{
+		Use:   "mute-monitor",
+		Short: "Mute monitor for time window",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Datadog muting monitor %s...", datadogMonitorOptions.ID)
+			common.Debug("Datadog", datadogMonitorOptions, stdout)
+
+			bytes, err := idempotent("", datadogMonitorOptions, func() ([]byte, error) {
+				return datadogNew(stdout).MuteMonitor(datadogMonitorOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(datadogOutput, "Datadog", []interface{}{datadogOptions, datadogMonitorOptions}, bytes, stdout)
+		},
+	}
+	monitorFlags(muteMonitorCmd)
+	muteMonitorCmd.PersistentFlags().IntVar(&datadogMonitorOptions.Duration, "datadog-monitor-duration", datadogMonitorOptions.Duration, "Datadog monitor mute duration in seconds, until unmuted if zero")
+	datadogCmd.AddCommand(idempotentCommand(muteMonitorCmd))
+
+	unmuteMonitorCmd := &cobra.Command{
+		Use:   "unmute-monitor",
+		Short: "Unmute monitor",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Datadog unmuting monitor %s...", datadogMonitorOptions.ID)
+			common.Debug("Datadog", datadogMonitorOptions, stdout)
+
+			bytes, err := idempotent("", datadogMonitorOptions, func() ([]byte, error) {
+				return datadogNew(stdout).UnmuteMonitor(datadogMonitorOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(datadogOutput, "Datadog", []interface{}{datadogOptions, datadogMonitorOptions}, bytes, stdout)
+		},
+	}
+	monitorFlags(unmuteMonitorCmd)
+	datadogCmd.AddCommand(idempotentCommand(unmuteMonitorCmd))
+
+	return datadogCmd
+}
+
+func init() {
+	registerVendor("datadog", vendorGroupMonitoring, NewDatadogCommand, datadogNew)
+	registerVendorTargets(func(targets map[string]server.Target) {
+
+		targets["datadog"] = secretsTarget("datadog", func() server.Target {
+			datadog := vendors.NewDatadog(datadogOptions)
+			return func(params map[string]string, message string) ([]byte, error) {
+				opts := datadogEventOptions
+				opts.Text = message
+				if !utils.IsEmpty(params["title"]) {
+					opts.Title = params["title"]
+				}
+				if utils.IsEmpty(opts.Title) {
+					opts.Title = common.TruncateString(strings.SplitN(message, "\n", 2)[0], 100)
+				}
+				if !utils.IsEmpty(params["tags"]) {
+					opts.Tags = params["tags"]
+				}
+				if !utils.IsEmpty(params["alert_type"]) {
+					opts.AlertType = params["alert_type"]
+				}
+				return datadog.PostEvent(opts)
+			}
+		})
+	})
+}
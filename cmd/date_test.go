@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func FuzzDateCalculate(f *testing.F) {
+
+	f.Add("2024-01-02T03:04:05Z", "-1h30m", time.RFC3339Nano)
+	f.Add("2024-01-02", "24h", "2006-01-02")
+	f.Add("Jan 2 03:04", "", time.Stamp)
+	f.Add("2024-01-02T03:04:05Z", "9223372036854775807ns", time.RFC3339)
+	f.Add("", ".5", "")
+
+	f.Fuzz(func(t *testing.T, value, offset, format string) {
+		dateCalculate(DateOptions{Value: value, Offset: offset, Format: format})
+	})
+}
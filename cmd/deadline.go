@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/spf13/cobra"
+)
+
+// deadline of command in seconds, it bounds requests, retries and pagination of vendors
+var deadline = envGet("DEADLINE", 0).(int)
+
+// deadlineContext is done at deadline, contexts of commands are of it, e.g. of batch jobs
+var deadlineContext = context.Background()
+var deadlineCancel = func() {}
+
+// deadlineGrace is time of command to fail by requests cancelled at deadline, before it's stopped
+const deadlineGrace = 2 * time.Second
+
+// deadlineStart cancels context of command and requests of vendors at deadline, command which doesn't stop by then,
+// e.g. waiting for something else than vendors, is stopped with timeout exit code
+func deadlineStart(cmd *cobra.Command) {
+
+	if deadline <= 0 {
+		return
+	}
+	d := time.Duration(deadline) * time.Second
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	deadlineContext, deadlineCancel = context.WithTimeout(ctx, d)
+	common.SetHttpContext(deadlineContext)
+
+	time.AfterFunc(d+deadlineGrace, func() {
+		exitTimeout(fmt.Errorf("command deadline of %d seconds exceeded: %w", deadline, context.DeadlineExceeded))
+	})
+}
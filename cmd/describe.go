@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type DescribeOptions struct {
+	JSON bool
+}
+
+type DescribeFlag struct {
+	Name       string `json:"name"`
+	Shorthand  string `json:"shorthand,omitempty"`
+	Type       string `json:"type"`
+	Usage      string `json:"usage"`
+	Default    string `json:"default"`
+	Env        string `json:"env,omitempty"`
+	Persistent bool   `json:"persistent"`
+	Required   bool   `json:"required,omitempty"`
+}
+
+type DescribeEnv struct {
+	Name    string `json:"name"`
+	Default string `json:"default"`
+}
+
+// DescribeCommand is command with its own flags, inherited flags are described by parents
+type DescribeCommand struct {
+	Name     string             `json:"name"`
+	Path     string             `json:"path"`
+	Use      string             `json:"use"`
+	Short    string             `json:"short,omitempty"`
+	Long     string             `json:"long,omitempty"`
+	Aliases  []string           `json:"aliases,omitempty"`
+	Version  string             `json:"version,omitempty"`
+	Flags    []*DescribeFlag    `json:"flags,omitempty"`
+	Env      []*DescribeEnv     `json:"env,omitempty"`
+	Commands []*DescribeCommand `json:"commands,omitempty"`
+}
+
+var describeOptions = DescribeOptions{
+	JSON: envGet("DESCRIBE_JSON", false).(bool),
+}
+
+var describeOutput = common.OutputOptions{
+	Output: envGet("DESCRIBE_OUTPUT", "").(string),
+	Query:  envGet("DESCRIBE_OUTPUT_QUERY", "").(string),
+}
+
+// describeEnv returns env var of flag, if it's read by envGet with the same name, e.g. --slack-token => TOOLS_SLACK_TOKEN
+func describeEnv(name string) string {
+
+	key := fmt.Sprintf("%s_%s", APPNAME, strings.ToUpper(strings.ReplaceAll(name, "-", "_")))
+	if _, ok := envDefaults[key]; ok {
+		return key
+	}
+	return ""
+}
+
+// describeDefault formats env default as pflag does, flag defaults have values of env vars if they are set
+func describeDefault(def interface{}) string {
+
+	switch v := def.(type) {
+	case []string:
+		return fmt.Sprintf("[%s]", strings.Join(v, ","))
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func describeFlags(flags *pflag.FlagSet, persistent bool, envs map[string]bool) []*DescribeFlag {
+
+	r := []*DescribeFlag{}
+	flags.VisitAll(func(f *pflag.Flag) {
+
+		if f.Hidden {
+			return
+		}
+		df := &DescribeFlag{
+			Name:       f.Name,
+			Shorthand:  f.Shorthand,
+			Type:       f.Value.Type(),
+			Usage:      f.Usage,
+			Default:    f.DefValue,
+			Env:        describeEnv(f.Name),
+			Persistent: persistent,
+			Required:   flagRequired(f),
+		}
+		if df.Env != "" {
+			df.Default = describeDefault(envDefaults[df.Env])
+			envs[df.Env] = true
+		}
+		r = append(r, df)
+	})
+	return r
+}
+
+func describeCommand(cmd *cobra.Command, envs map[string]bool) *DescribeCommand {
+
+	dc := &DescribeCommand{
+		Name:    cmd.Name(),
+		Path:    cmd.CommandPath(),
+		Use:     cmd.Use,
+		Short:   cmd.Short,
+		Long:    cmd.Long,
+		Aliases: cmd.Aliases,
+	}
+	dc.Flags = append(dc.Flags, describeFlags(cmd.PersistentFlags(), true, envs)...)
+	dc.Flags = append(dc.Flags, describeFlags(cmd.LocalNonPersistentFlags(), false, envs)...)
+
+	for _, c := range cmd.Commands() {
+		if !c.IsAvailableCommand() {
+			continue
+		}
+		dc.Commands = append(dc.Commands, describeCommand(c, envs))
+	}
+	return dc
+}
+
+// describe returns command tree from root, env vars without flags are added to root
+func describe(root *cobra.Command) *DescribeCommand {
+
+	envs := make(map[string]bool)
+	dc := describeCommand(root, envs)
+	dc.Version = version
+
+	keys := []string{}
+	for k := range envDefaults {
+		if !envs[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		dc.Env = append(dc.Env, &DescribeEnv{
+			Name:    k,
+			Default: describeDefault(envDefaults[k]),
+		})
+	}
+	return dc
+}
+
+// describeText prints command tree with short descriptions
+func describeText(dc *DescribeCommand, indent string, sb *strings.Builder) {
+
+	sb.WriteString(fmt.Sprintf("%s%s\t%s\n", indent, dc.Name, dc.Short))
+	for _, c := range dc.Commands {
+		describeText(c, indent+"  ", sb)
+	}
+}
+
+func NewDescribeCommand() *cobra.Command {
+
+	describeCmd := &cobra.Command{
+		Use:   "describe",
+		Short: "Describe commands, flags, env vars and defaults",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Describing commands...")
+
+			dc := describe(cmd.Root())
+			if !describeOptions.JSON {
+				sb := &strings.Builder{}
+				describeText(dc, "", sb)
+				common.OutputRaw(describeOutput.Output, []byte(sb.String()), stdout)
+				return
+			}
+
+			bytes, err := json.Marshal(dc)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(describeOutput, "Describe", []interface{}{describeOptions}, bytes, stdout)
+		},
+	}
+	flags := describeCmd.PersistentFlags()
+	flags.BoolVar(&describeOptions.JSON, "json", describeOptions.JSON, "Describe as JSON")
+	flags.StringVar(&describeOutput.Output, "describe-output", describeOutput.Output, "Describe output")
+	flags.StringVar(&describeOutput.Query, "describe-output-query", describeOutput.Query, "Describe output query")
+
+	return describeCmd
+}
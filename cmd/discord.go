@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var discordOptions = vendors.DiscordOptions{
+	Timeout:    envGet("DISCORD_TIMEOUT", 30).(int),
+	Insecure:   envGet("DISCORD_INSECURE", false).(bool),
+	URL:        envGet("DISCORD_URL", "https://discord.com/api/v10").(string),
+	WebhookURL: envGet("DISCORD_WEBHOOK_URL", "").(string),
+	BotToken:   envGet("DISCORD_BOT_TOKEN", "").(string),
+}
+
+var discordMessageOptions = vendors.DiscordMessageOptions{
+	Channel:    envGet("DISCORD_CHANNEL", "").(string),
+	Thread:     envGet("DISCORD_THREAD", "").(string),
+	ThreadName: envGet("DISCORD_THREAD_NAME", "").(string),
+	Content:    envGet("DISCORD_MESSAGE_CONTENT", "").(string),
+	Username:   envGet("DISCORD_USERNAME", "").(string),
+	AvatarURL:  envGet("DISCORD_AVATAR_URL", "").(string),
+	Embeds:     envGet("DISCORD_EMBEDS", "").(string),
+}
+
+var discordFileOptions = vendors.DiscordFileOptions{
+	DiscordMessageOptions: discordMessageOptions,
+	Name:                  envGet("DISCORD_FILE_NAME", "").(string),
+	File:                  envGet("DISCORD_FILE", "").(string),
+}
+
+var discordThreadOptions = vendors.DiscordThreadOptions{
+	Channel:             envGet("DISCORD_CHANNEL", "").(string),
+	MessageID:           envGet("DISCORD_MESSAGE_ID", "").(string),
+	Name:                envGet("DISCORD_THREAD_NAME", "").(string),
+	AutoArchiveDuration: envGet("DISCORD_THREAD_AUTO_ARCHIVE", 1440).(int),
+}
+
+var discordOutput = common.OutputOptions{
+	Output: envGet("DISCORD_OUTPUT", "").(string),
+	Query:  envGet("DISCORD_OUTPUT_QUERY", "").(string),
+}
+
+func discordNew(stdout *common.Stdout) *vendors.Discord {
+
+	common.Debug("Discord", discordOptions, stdout)
+	common.Debug("Discord", discordOutput, stdout)
+
+	discordMessageOptions.Channel = serviceChannel(stdout, "discord", discordMessageOptions.Channel)
+	discordFileOptions.Channel = serviceChannel(stdout, "discord", discordFileOptions.Channel)
+
+	return vendors.NewDiscord(discordOptions)
+}
+
+func discordMessageFlags(cmd *cobra.Command, opts *vendors.DiscordMessageOptions) {
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&opts.Channel, "discord-channel", opts.Channel, "Discord channel ID (bot API only)")
+	flags.StringVar(&opts.Thread, "discord-thread", opts.Thread, "Discord thread ID")
+	flags.StringVar(&opts.ThreadName, "discord-thread-name", opts.ThreadName, "Discord forum thread name to create (webhook only)")
+	flags.StringVar(&opts.Content, "discord-message-content", opts.Content, "Discord message content")
+	flags.StringVar(&opts.Username, "discord-username", opts.Username, "Discord username override (webhook only)")
+	flags.StringVar(&opts.AvatarURL, "discord-avatar-url", opts.AvatarURL, "Discord avatar URL override (webhook only)")
+	flags.StringVar(&opts.Embeds, "discord-embeds", opts.Embeds, "Discord embeds json")
+}
+
+func NewDiscordCommand() *cobra.Command {
+
+	discordCmd := &cobra.Command{
+		Use:   "discord",
+		Short: "Discord tools",
+	}
+
+	flags := discordCmd.PersistentFlags()
+	flags.IntVar(&discordOptions.Timeout, "discord-timeout", discordOptions.Timeout, "Discord timeout")
+	flags.BoolVar(&discordOptions.Insecure, "discord-insecure", discordOptions.Insecure, "Discord insecure")
+	flags.StringVar(&discordOptions.URL, "discord-url", discordOptions.URL, "Discord API URL")
+	flags.StringVar(&discordOptions.WebhookURL, "discord-webhook-url", discordOptions.WebhookURL, "Discord webhook URL")
+	flags.StringVar(&discordOptions.BotToken, "discord-bot-token", discordOptions.BotToken, "Discord bot token")
+	flags.StringVar(&discordOutput.Output, "discord-output", discordOutput.Output, "Discord output")
+	flags.StringVar(&discordOutput.Query, "discord-output-query", discordOutput.Query, "Discord output query")
+
+	sendMessage := &cobra.Command{
+		Use:   "send-message",
+		Short: "Send message",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Discord sending message...")
+			common.Debug("Discord", discordMessageOptions, stdout)
+
+			contentBytes, err := utils.Content(discordMessageOptions.Content)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			discordMessageOptions.Content = string(contentBytes)
+
+			embedsBytes, err := utils.Content(discordMessageOptions.Embeds)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			discordMessageOptions.Embeds = string(embedsBytes)
+
+			if !hooksPreSend(stdout, "discord", &discordMessageOptions) {
+				return
+			}
+
+			discord := discordNew(stdout)
+			bytes, err := idempotent("", discordMessageOptions, func() ([]byte, error) {
+				return discord.SendMessage(discordMessageOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "discord", bytes)
+			common.OutputJson(discordOutput, "Discord", []interface{}{discordOptions, discordMessageOptions}, bytes, stdout)
+		},
+	}
+	discordMessageFlags(sendMessage, &discordMessageOptions)
+	discordCmd.AddCommand(idempotentCommand(sendMessage))
+
+	sendFile := &cobra.Command{
+		Use:   "send-file",
+		Short: "Send file",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Discord sending file...")
+			common.Debug("Discord", discordFileOptions, stdout)
+
+			contentBytes, err := utils.Content(discordFileOptions.Content)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			discordFileOptions.Content = string(contentBytes)
+
+			embedsBytes, err := utils.Content(discordFileOptions.Embeds)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			discordFileOptions.Embeds = string(embedsBytes)
+
+			if utils.IsEmpty(discordFileOptions.Name) && utils.FileExists(discordFileOptions.File) {
+				discordFileOptions.Name = filepath.Base(discordFileOptions.File)
+			}
+
+			fileBytes, err := utils.Content(discordFileOptions.File)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			discordFileOptions.File = string(fileBytes)
+
+			if !hooksPreSend(stdout, "discord", &discordFileOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", discordFileOptions, func() ([]byte, error) {
+				return discordNew(stdout).SendFile(discordFileOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "discord", bytes)
+			common.OutputJson(discordOutput, "Discord", []interface{}{discordOptions, discordFileOptions}, bytes, stdout)
+		},
+	}
+	discordMessageFlags(sendFile, &discordFileOptions.DiscordMessageOptions)
+	flags = sendFile.PersistentFlags()
+	flags.StringVar(&discordFileOptions.Name, "discord-file-name", discordFileOptions.Name, "Discord file name, base name of file path if empty")
+	flags.StringVar(&discordFileOptions.File, "discord-file", discordFileOptions.File, "Discord file content or path")
+	discordCmd.AddCommand(idempotentCommand(sendFile))
+
+	createThread := &cobra.Command{
+		Use:   "create-thread",
+		Short: "Create thread",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Discord creating thread...")
+			common.Debug("Discord", discordThreadOptions, stdout)
+
+			bytes, err := idempotent("", discordThreadOptions, func() ([]byte, error) {
+				return discordNew(stdout).CreateThread(discordThreadOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(discordOutput, "Discord", []interface{}{discordOptions, discordThreadOptions}, bytes, stdout)
+		},
+	}
+	flags = createThread.PersistentFlags()
+	flags.StringVar(&discordThreadOptions.Channel, "discord-channel", discordThreadOptions.Channel, "Discord channel ID")
+	flags.StringVar(&discordThreadOptions.MessageID, "discord-message-id", discordThreadOptions.MessageID, "Discord message ID to start thread from")
+	flags.StringVar(&discordThreadOptions.Name, "discord-thread-name", discordThreadOptions.Name, "Discord thread name")
+	flags.IntVar(&discordThreadOptions.AutoArchiveDuration, "discord-thread-auto-archive", discordThreadOptions.AutoArchiveDuration, "Discord thread auto archive duration in minutes: 60, 1440, 4320, 10080")
+	discordCmd.AddCommand(idempotentCommand(createThread))
+
+	return discordCmd
+}
+
+func init() {
+	registerVendor("discord", vendorGroupChat, NewDiscordCommand, discordNew)
+}
@@ -0,0 +1,272 @@
+//go:build !minimal || logs || elasticsearch
+
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var elasticsearchOptions = vendors.ElasticsearchOptions{
+	Timeout:  envGet("ELASTICSEARCH_TIMEOUT", 30).(int),
+	Insecure: envGet("ELASTICSEARCH_INSECURE", false).(bool),
+	URL:      envGet("ELASTICSEARCH_URL", "").(string),
+	User:     envGet("ELASTICSEARCH_USER", "").(string),
+	Password: envGet("ELASTICSEARCH_PASSWORD", "").(string),
+	APIKey:   envGet("ELASTICSEARCH_API_KEY", "").(string),
+}
+
+var elasticsearchIndexOptions = vendors.ElasticsearchIndexOptions{
+	Index:    envGet("ELASTICSEARCH_INDEX", "").(string),
+	ID:       envGet("ELASTICSEARCH_ID", "").(string),
+	Document: envGet("ELASTICSEARCH_DOCUMENT", "").(string),
+	Refresh:  envGet("ELASTICSEARCH_REFRESH", "").(string),
+}
+
+var elasticsearchBulkOptions = vendors.ElasticsearchBulkOptions{
+	Index:   envGet("ELASTICSEARCH_INDEX", "").(string),
+	File:    envGet("ELASTICSEARCH_BULK_FILE", "").(string),
+	IDField: envGet("ELASTICSEARCH_BULK_ID_FIELD", "").(string),
+	Batch:   envGet("ELASTICSEARCH_BULK_BATCH", 500).(int),
+	Refresh: envGet("ELASTICSEARCH_REFRESH", "").(string),
+}
+
+var elasticsearchSearchOptions = vendors.ElasticsearchSearchOptions{
+	Index:       envGet("ELASTICSEARCH_INDEX", "").(string),
+	Query:       envGet("ELASTICSEARCH_SEARCH_QUERY", "").(string),
+	QueryString: envGet("ELASTICSEARCH_SEARCH_QUERY_STRING", "").(string),
+	Size:        envGet("ELASTICSEARCH_SEARCH_SIZE", 10).(int),
+	Sort:        envGet("ELASTICSEARCH_SEARCH_SORT", "").(string),
+}
+
+var elasticsearchIndicesOptions = vendors.ElasticsearchIndicesOptions{
+	Index:     envGet("ELASTICSEARCH_INDEX", "").(string),
+	Pattern:   envGet("ELASTICSEARCH_INDICES_PATTERN", "").(string),
+	Body:      envGet("ELASTICSEARCH_INDICES_BODY", "").(string),
+	OlderThan: envGet("ELASTICSEARCH_INDICES_OLDER_THAN", 0).(int),
+	DryRun:    envGet("ELASTICSEARCH_INDICES_DRY_RUN", false).(bool),
+}
+
+var elasticsearchOutput = common.OutputOptions{
+	Output: envGet("ELASTICSEARCH_OUTPUT", "").(string),
+	Query:  envGet("ELASTICSEARCH_OUTPUT_QUERY", "").(string),
+}
+
+func elasticsearchNew(stdout *common.Stdout) *vendors.Elasticsearch {
+
+	common.Debug("Elasticsearch", elasticsearchOptions, stdout)
+	common.Debug("Elasticsearch", elasticsearchOutput, stdout)
+
+	return vendors.NewElasticsearch(elasticsearchOptions)
+}
+
+func NewElasticsearchCommand() *cobra.Command {
+
+	elasticsearchCmd := &cobra.Command{
+		Use:     "elasticsearch",
+		Aliases: []string{"opensearch"},
+		Short:   "Elasticsearch and OpenSearch tools",
+	}
+	flags := elasticsearchCmd.PersistentFlags()
+	flags.IntVar(&elasticsearchOptions.Timeout, "elasticsearch-timeout", elasticsearchOptions.Timeout, "Elasticsearch timeout in seconds")
+	flags.BoolVar(&elasticsearchOptions.Insecure, "elasticsearch-insecure", elasticsearchOptions.Insecure, "Elasticsearch insecure")
+	flags.StringVar(&elasticsearchOptions.URL, "elasticsearch-url", elasticsearchOptions.URL, "Elasticsearch URL")
+	flags.StringVar(&elasticsearchOptions.User, "elasticsearch-user", elasticsearchOptions.User, "Elasticsearch user")
+	flags.StringVar(&elasticsearchOptions.Password, "elasticsearch-password", elasticsearchOptions.Password, "Elasticsearch password")
+	flags.StringVar(&elasticsearchOptions.APIKey, "elasticsearch-api-key", elasticsearchOptions.APIKey, "Elasticsearch API key, base64 encoded id:key")
+	flags.StringVar(&elasticsearchOutput.Output, "elasticsearch-output", elasticsearchOutput.Output, "Elasticsearch output")
+	flags.StringVar(&elasticsearchOutput.Query, "elasticsearch-output-query", elasticsearchOutput.Query, "Elasticsearch output query")
+
+	indexCmd := &cobra.Command{
+		Use:   "index",
+		Short: "Index document",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Elasticsearch indexing document into %s...", elasticsearchIndexOptions.Index)
+			common.Debug("Elasticsearch", elasticsearchIndexOptions, stdout)
+
+			documentBytes, err := utils.Content(elasticsearchIndexOptions.Document)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			elasticsearchIndexOptions.Document = string(documentBytes)
+
+			if !hooksPreSend(stdout, "elasticsearch", &elasticsearchIndexOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", elasticsearchIndexOptions, func() ([]byte, error) {
+				return elasticsearchNew(stdout).IndexDocument(elasticsearchIndexOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "elasticsearch", bytes)
+			common.OutputJson(elasticsearchOutput, "Elasticsearch", []interface{}{elasticsearchOptions, elasticsearchIndexOptions}, bytes, stdout)
+		},
+	}
+	flags = indexCmd.PersistentFlags()
+	flags.StringVar(&elasticsearchIndexOptions.Index, "elasticsearch-index", elasticsearchIndexOptions.Index, "Elasticsearch index or data stream")
+	flags.StringVar(&elasticsearchIndexOptions.ID, "elasticsearch-id", elasticsearchIndexOptions.ID, "Elasticsearch document ID, generated if empty")
+	flags.StringVar(&elasticsearchIndexOptions.Document, "elasticsearch-document", elasticsearchIndexOptions.Document, "Elasticsearch document JSON")
+	flags.StringVar(&elasticsearchIndexOptions.Refresh, "elasticsearch-refresh", elasticsearchIndexOptions.Refresh, "Elasticsearch refresh: true, false, wait_for")
+	elasticsearchCmd.AddCommand(idempotentCommand(indexCmd))
+
+	bulkCmd := &cobra.Command{
+		Use:   "bulk",
+		Short: "Bulk index documents from NDJSON file",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Elasticsearch bulk indexing %s into %s...", elasticsearchBulkOptions.File, elasticsearchBulkOptions.Index)
+			common.Debug("Elasticsearch", elasticsearchBulkOptions, stdout)
+
+			bytes, err := idempotent("", elasticsearchBulkOptions, func() ([]byte, error) {
+				return elasticsearchNew(stdout).BulkIndex(elasticsearchBulkOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(elasticsearchOutput, "Elasticsearch", []interface{}{elasticsearchOptions, elasticsearchBulkOptions}, bytes, stdout)
+		},
+	}
+	flags = bulkCmd.PersistentFlags()
+	flags.StringVar(&elasticsearchBulkOptions.Index, "elasticsearch-index", elasticsearchBulkOptions.Index, "Elasticsearch index or data stream")
+	flags.StringVar(&elasticsearchBulkOptions.File, "elasticsearch-bulk-file", elasticsearchBulkOptions.File, "Elasticsearch NDJSON file with document per line")
+	flags.StringVar(&elasticsearchBulkOptions.IDField, "elasticsearch-bulk-id-field", elasticsearchBulkOptions.IDField, "Elasticsearch document field used as ID")
+	flags.IntVar(&elasticsearchBulkOptions.Batch, "elasticsearch-bulk-batch", elasticsearchBulkOptions.Batch, "Elasticsearch documents per bulk request")
+	flags.StringVar(&elasticsearchBulkOptions.Refresh, "elasticsearch-refresh", elasticsearchBulkOptions.Refresh, "Elasticsearch refresh: true, false, wait_for")
+	elasticsearchCmd.AddCommand(idempotentCommand(bulkCmd))
+
+	searchCmd := &cobra.Command{
+		Use:   "search",
+		Short: "Search with query DSL or query string",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Elasticsearch searching %s...", elasticsearchSearchOptions.Index)
+			common.Debug("Elasticsearch", elasticsearchSearchOptions, stdout)
+
+			queryBytes, err := utils.Content(elasticsearchSearchOptions.Query)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			elasticsearchSearchOptions.Query = string(queryBytes)
+
+			bytes, err := elasticsearchNew(stdout).Search(elasticsearchSearchOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(elasticsearchOutput, "Elasticsearch", []interface{}{elasticsearchOptions, elasticsearchSearchOptions}, bytes, stdout)
+		},
+	}
+	flags = searchCmd.PersistentFlags()
+	flags.StringVar(&elasticsearchSearchOptions.Index, "elasticsearch-index", elasticsearchSearchOptions.Index, "Elasticsearch index pattern, all if empty")
+	flags.StringVar(&elasticsearchSearchOptions.Query, "elasticsearch-search-query", elasticsearchSearchOptions.Query, "Elasticsearch query DSL JSON")
+	flags.StringVar(&elasticsearchSearchOptions.QueryString, "elasticsearch-search-query-string", elasticsearchSearchOptions.QueryString, "Elasticsearch simple query string, e.g. user:bob +action:delete")
+	flags.IntVar(&elasticsearchSearchOptions.Size, "elasticsearch-search-size", elasticsearchSearchOptions.Size, "Elasticsearch search size")
+	flags.StringVar(&elasticsearchSearchOptions.Sort, "elasticsearch-search-sort", elasticsearchSearchOptions.Sort, "Elasticsearch search sort, e.g. @timestamp:desc")
+	elasticsearchCmd.AddCommand(searchCmd)
+
+	createIndexCmd := &cobra.Command{
+		Use:   "create-index",
+		Short: "Create index with settings and mappings",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Elasticsearch creating index %s...", elasticsearchIndicesOptions.Index)
+			common.Debug("Elasticsearch", elasticsearchIndicesOptions, stdout)
+
+			bodyBytes, err := utils.Content(elasticsearchIndicesOptions.Body)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			elasticsearchIndicesOptions.Body = string(bodyBytes)
+
+			bytes, err := idempotent("", elasticsearchIndicesOptions, func() ([]byte, error) {
+				return elasticsearchNew(stdout).CreateIndex(elasticsearchIndicesOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(elasticsearchOutput, "Elasticsearch", []interface{}{elasticsearchOptions, elasticsearchIndicesOptions}, bytes, stdout)
+		},
+	}
+	flags = createIndexCmd.PersistentFlags()
+	flags.StringVar(&elasticsearchIndicesOptions.Index, "elasticsearch-index", elasticsearchIndicesOptions.Index, "Elasticsearch index")
+	flags.StringVar(&elasticsearchIndicesOptions.Body, "elasticsearch-indices-body", elasticsearchIndicesOptions.Body, "Elasticsearch index settings, mappings and aliases JSON")
+	elasticsearchCmd.AddCommand(idempotentCommand(createIndexCmd))
+
+	deleteIndexCmd := &cobra.Command{
+		Use:   "delete-index",
+		Short: "Delete index",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Elasticsearch deleting index %s...", elasticsearchIndicesOptions.Index)
+			common.Debug("Elasticsearch", elasticsearchIndicesOptions, stdout)
+
+			bytes, err := idempotent("", elasticsearchIndicesOptions, func() ([]byte, error) {
+				return elasticsearchNew(stdout).DeleteIndex(elasticsearchIndicesOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(elasticsearchOutput, "Elasticsearch", []interface{}{elasticsearchOptions, elasticsearchIndicesOptions}, bytes, stdout)
+		},
+	}
+	deleteIndexCmd.PersistentFlags().StringVar(&elasticsearchIndicesOptions.Index, "elasticsearch-index", elasticsearchIndicesOptions.Index, "Elasticsearch index")
+	elasticsearchCmd.AddCommand(idempotentCommand(deleteIndexCmd))
+
+	listIndicesCmd := &cobra.Command{
+		Use:   "list-indices",
+		Short: "List indices with health, docs count, size and creation date",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Elasticsearch listing indices %s...", elasticsearchIndicesOptions.Pattern)
+			common.Debug("Elasticsearch", elasticsearchIndicesOptions, stdout)
+
+			bytes, err := elasticsearchNew(stdout).ListIndices(elasticsearchIndicesOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(elasticsearchOutput, "Elasticsearch", []interface{}{elasticsearchOptions, elasticsearchIndicesOptions}, bytes, stdout)
+		},
+	}
+	listIndicesCmd.PersistentFlags().StringVar(&elasticsearchIndicesOptions.Pattern, "elasticsearch-indices-pattern", elasticsearchIndicesOptions.Pattern, "Elasticsearch indices pattern, e.g. audit-*")
+	elasticsearchCmd.AddCommand(listIndicesCmd)
+
+	pruneIndicesCmd := &cobra.Command{
+		Use:   "prune-indices",
+		Short: "Delete indices matching pattern older than days",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Elasticsearch pruning indices %s...", elasticsearchIndicesOptions.Pattern)
+			common.Debug("Elasticsearch", elasticsearchIndicesOptions, stdout)
+
+			bytes, err := idempotent("", elasticsearchIndicesOptions, func() ([]byte, error) {
+				return elasticsearchNew(stdout).PruneIndices(elasticsearchIndicesOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(elasticsearchOutput, "Elasticsearch", []interface{}{elasticsearchOptions, elasticsearchIndicesOptions}, bytes, stdout)
+		},
+	}
+	flags = pruneIndicesCmd.PersistentFlags()
+	flags.StringVar(&elasticsearchIndicesOptions.Pattern, "elasticsearch-indices-pattern", elasticsearchIndicesOptions.Pattern, "Elasticsearch indices pattern, e.g. audit-*")
+	flags.IntVar(&elasticsearchIndicesOptions.OlderThan, "elasticsearch-indices-older-than", elasticsearchIndicesOptions.OlderThan, "Elasticsearch indices age in days by creation date")
+	flags.BoolVar(&elasticsearchIndicesOptions.DryRun, "elasticsearch-indices-dry-run", elasticsearchIndicesOptions.DryRun, "Elasticsearch list indices to delete only")
+	elasticsearchCmd.AddCommand(idempotentCommand(pruneIndicesCmd))
+
+	return elasticsearchCmd
+}
+
+func init() {
+	registerVendor("elasticsearch", vendorGroupLogs, NewElasticsearchCommand, elasticsearchNew)
+}
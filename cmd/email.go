@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var emailOptions = vendors.EmailOptions{
+	Timeout:  envGet("EMAIL_TIMEOUT", 30).(int),
+	Insecure: envGet("EMAIL_INSECURE", false).(bool),
+	Server:   envGet("EMAIL_SERVER", "").(string),
+	User:     envGet("EMAIL_USER", "").(string),
+	Password: envGet("EMAIL_PASSWORD", "").(string),
+	TLS:      envGet("EMAIL_TLS", false).(bool),
+	StartTLS: envGet("EMAIL_STARTTLS", false).(bool),
+	DSN:      envGet("EMAIL_DSN", false).(bool),
+}
+
+var emailMessageOptions = vendors.EmailMessageOptions{
+	From:        envGet("EMAIL_FROM", "").(string),
+	To:          strings.Split(envGet("EMAIL_TO", "").(string), ","),
+	Cc:          strings.Split(envGet("EMAIL_CC", "").(string), ","),
+	Bcc:         strings.Split(envGet("EMAIL_BCC", "").(string), ","),
+	Subject:     envGet("EMAIL_SUBJECT", "").(string),
+	Text:        envGet("EMAIL_TEXT", "").(string),
+	HTML:        envGet("EMAIL_HTML", "").(string),
+	Attachments: strings.Split(envGet("EMAIL_ATTACHMENTS", "").(string), ","),
+}
+
+var emailOutput = common.OutputOptions{
+	Output: envGet("EMAIL_OUTPUT", "").(string),
+	Query:  envGet("EMAIL_OUTPUT_QUERY", "").(string),
+}
+
+func emailNew(stdout *common.Stdout) *vendors.Email {
+
+	common.Debug("Email", emailOptions, stdout)
+	common.Debug("Email", emailOutput, stdout)
+
+	return vendors.NewEmail(emailOptions)
+}
+
+func NewEmailCommand() *cobra.Command {
+
+	emailCmd := &cobra.Command{
+		Use:   "email",
+		Short: "Email tools",
+	}
+	flags := emailCmd.PersistentFlags()
+	flags.IntVar(&emailOptions.Timeout, "email-timeout", emailOptions.Timeout, "Email timeout in seconds")
+	flags.BoolVar(&emailOptions.Insecure, "email-insecure", emailOptions.Insecure, "Email insecure")
+	flags.StringVar(&emailOptions.Server, "email-server", emailOptions.Server, "Email SMTP server host:port")
+	flags.StringVar(&emailOptions.User, "email-user", emailOptions.User, "Email SMTP user")
+	flags.StringVar(&emailOptions.Password, "email-password", emailOptions.Password, "Email SMTP password")
+	flags.BoolVar(&emailOptions.TLS, "email-tls", emailOptions.TLS, "Email SMTP implicit TLS, usually port 465")
+	flags.BoolVar(&emailOptions.StartTLS, "email-starttls", emailOptions.StartTLS, "Email SMTP STARTTLS")
+	flags.BoolVar(&emailOptions.DSN, "email-dsn", emailOptions.DSN, "Email requests delivery status notifications if SMTP server supports DSN")
+	flags.StringVar(&emailOutput.Output, "email-output", emailOutput.Output, "Email output")
+	flags.StringVar(&emailOutput.Query, "email-output-query", emailOutput.Query, "Email output query")
+
+	sendCmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send email",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Email sending...")
+			common.Debug("Email", emailMessageOptions, stdout)
+
+			textBytes, err := utils.Content(emailMessageOptions.Text)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			emailMessageOptions.Text = string(textBytes)
+
+			htmlBytes, err := utils.Content(emailMessageOptions.HTML)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			emailMessageOptions.HTML = string(htmlBytes)
+
+			if !hooksPreSend(stdout, "email", &emailMessageOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", emailMessageOptions, func() ([]byte, error) {
+				return emailNew(stdout).Send(emailMessageOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "email", bytes)
+			common.OutputJson(emailOutput, "Email", []interface{}{emailOptions, emailMessageOptions}, bytes, stdout)
+		},
+	}
+	flags = sendCmd.PersistentFlags()
+	flags.StringVar(&emailMessageOptions.From, "email-from", emailMessageOptions.From, "Email sender")
+	flags.StringSliceVar(&emailMessageOptions.To, "email-to", emailMessageOptions.To, "Email recipients")
+	flags.StringSliceVar(&emailMessageOptions.Cc, "email-cc", emailMessageOptions.Cc, "Email CC recipients")
+	flags.StringSliceVar(&emailMessageOptions.Bcc, "email-bcc", emailMessageOptions.Bcc, "Email BCC recipients")
+	flags.StringVar(&emailMessageOptions.Subject, "email-subject", emailMessageOptions.Subject, "Email subject")
+	flags.StringVar(&emailMessageOptions.Text, "email-text", emailMessageOptions.Text, "Email plain text body")
+	flags.StringVar(&emailMessageOptions.HTML, "email-html", emailMessageOptions.HTML, "Email HTML body")
+	flags.StringSliceVar(&emailMessageOptions.Attachments, "email-attachments", emailMessageOptions.Attachments, "Email attachment file paths")
+	emailCmd.AddCommand(idempotentCommand(sendCmd))
+
+	return emailCmd
+}
+
+func init() {
+	registerVendor("email", vendorGroupChat, NewEmailCommand, emailNew)
+}
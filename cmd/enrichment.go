@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var enrichmentOptions = common.EnrichmentOptions{
+	File: envGet("ENRICHMENT_FILE", "").(string),
+}
+
+var enrichmentObject = envGet("ENRICHMENT_OBJECT", "").(string)
+
+var enrichmentOutput = common.OutputOptions{
+	Output: envGet("ENRICHMENT_OUTPUT", "").(string),
+	Query:  envGet("ENRICHMENT_OUTPUT_QUERY", "").(string),
+}
+
+func enrichmentJson(data []byte, err error) (interface{}, error) {
+
+	if err != nil {
+		return nil, err
+	}
+	var r interface{}
+	err = json.Unmarshal(data, &r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func enrichmentExec(stdout *common.Stdout) common.Enricher {
+
+	return func(params map[string]string) (interface{}, error) {
+
+		timeout := 60
+		if !utils.IsEmpty(params["timeout"]) {
+			t, err := strconv.Atoi(params["timeout"])
+			if err != nil {
+				return nil, err
+			}
+			timeout = t
+		}
+		opts := vendors.ExecOptions{
+			Command: params["command"],
+			Args:    strings.Fields(params["args"]),
+			Shell:   params["shell"] == "true",
+			Dir:     params["dir"],
+			Stdin:   params["stdin"],
+			Timeout: timeout,
+			Redact:  strings.Split(params["redact"], ","),
+		}
+		return enrichmentJson(vendors.NewExec(opts, stdout.Module("exec")).Run())
+	}
+}
+
+func enrichmentNew(stdout *common.Stdout) *common.Enrichment {
+
+	common.Debug("Enrichment", enrichmentOptions, stdout)
+
+	enrichment, err := common.NewEnrichment(enrichmentOptions, stdout)
+	if err != nil {
+		stdout.Panic(err)
+	}
+
+	// enrichers of vendors excluded by build tags are absent
+	for name, enricher := range vendorsEnrichers {
+		enrichment.Register(name, enricher)
+	}
+	enrichment.Register("exec", enrichmentExec(stdout))
+	enrichment.Register("service", func(params map[string]string) (interface{}, error) {
+		name := params["name"]
+		if utils.IsEmpty(name) {
+			name = servicesOptions.Service
+		}
+		return servicesNew(stdout).Get(name)
+	})
+	return enrichment
+}
+
+// enrichObject injects enrichment results into json object, object is returned as is if there is no enrichment file
+func enrichObject(stdout *common.Stdout, object string) string {
+
+	if utils.IsEmpty(enrichmentOptions.File) {
+		return object
+	}
+
+	m := make(map[string]interface{})
+	if !utils.IsEmpty(object) {
+		err := json.Unmarshal([]byte(object), &m)
+		if err != nil {
+			stdout.Panic(err)
+		}
+	}
+
+	bytes, err := json.Marshal(enrichmentNew(stdout).Enrich(m))
+	if err != nil {
+		stdout.Panic(err)
+	}
+	return string(bytes)
+}
+
+func NewEnrichmentCommand() *cobra.Command {
+
+	enrichmentCmd := &cobra.Command{
+		Use:   "enrichment",
+		Short: "Enrichment tools",
+	}
+	flags := enrichmentCmd.PersistentFlags()
+	flags.StringVar(&enrichmentOutput.Output, "enrichment-output", enrichmentOutput.Output, "Enrichment output")
+	flags.StringVar(&enrichmentOutput.Query, "enrichment-output-query", enrichmentOutput.Query, "Enrichment output query")
+
+	runCmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run enrichment steps on object",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Enrichment running...")
+
+			objectBytes, err := utils.Content(enrichmentObject)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			common.OutputJson(enrichmentOutput, "Enrichment", []interface{}{enrichmentOptions}, []byte(enrichObject(stdout, string(objectBytes))), stdout)
+		},
+	}
+	flags = runCmd.PersistentFlags()
+	flags.StringVar(&enrichmentObject, "enrichment-object", enrichmentObject, "Enrichment object: json")
+	enrichmentCmd.AddCommand(runCmd)
+
+	return enrichmentCmd
+}
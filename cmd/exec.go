@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+var execOptions = vendors.ExecOptions{
+	Command: envGet("EXEC_COMMAND", "").(string),
+	Args:    strings.Fields(envGet("EXEC_ARGS", "").(string)),
+	Shell:   envGet("EXEC_SHELL", false).(bool),
+	Dir:     envGet("EXEC_DIR", "").(string),
+	Env:     strings.Split(envGet("EXEC_ENV", "").(string), ","),
+	Stdin:   envGet("EXEC_STDIN", "").(string),
+	Timeout: envGet("EXEC_TIMEOUT", 60).(int),
+	Redact:  strings.Split(envGet("EXEC_REDACT", "").(string), ","),
+}
+
+var execOutput = common.OutputOptions{
+	Output: envGet("EXEC_OUTPUT", "").(string),
+	Query:  envGet("EXEC_OUTPUT_QUERY", "").(string),
+}
+
+func execNew(stdout *common.Stdout) *vendors.Exec {
+
+	common.Debug("Exec", execOutput, stdout)
+
+	execOptions.Env = common.RemoveEmptyStrings(execOptions.Env)
+	return vendors.NewExec(execOptions, stdout.Module("exec"))
+}
+
+func NewExecCommand() *cobra.Command {
+
+	execCmd := &cobra.Command{
+		Use:   "exec",
+		Short: "Exec tools",
+	}
+	flags := execCmd.PersistentFlags()
+	flags.StringVar(&execOutput.Output, "exec-output", execOutput.Output, "Exec output")
+	flags.StringVar(&execOutput.Query, "exec-output-query", execOutput.Query, "Exec output query")
+
+	runCmd := &cobra.Command{
+		Use:   "run [-- args]",
+		Short: "Run local command",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Exec running...")
+			if len(args) > 0 {
+				execOptions.Args = args
+			}
+
+			bytes, err := idempotent("", execOptions, func() ([]byte, error) {
+				return execNew(stdout).Run()
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(execOutput, "Exec", []interface{}{}, bytes, stdout)
+		},
+	}
+	flags = runCmd.PersistentFlags()
+	flags.StringVar(&execOptions.Command, "exec-command", execOptions.Command, "Exec command")
+	flags.BoolVar(&execOptions.Shell, "exec-shell", execOptions.Shell, "Exec command in shell")
+	flags.StringVar(&execOptions.Dir, "exec-dir", execOptions.Dir, "Exec working dir")
+	flags.StringSliceVar(&execOptions.Env, "exec-env", execOptions.Env, "Exec env: KEY=value")
+	flags.StringVar(&execOptions.Stdin, "exec-stdin", execOptions.Stdin, "Exec stdin")
+	flags.IntVar(&execOptions.Timeout, "exec-timeout", execOptions.Timeout, "Exec timeout in seconds")
+	flags.StringSliceVar(&execOptions.Redact, "exec-redact", execOptions.Redact, "Exec secrets redacted in logs")
+	execCmd.AddCommand(idempotentCommand(runCmd))
+
+	return execCmd
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/devopsext/tools/common"
+	"github.com/spf13/cobra"
+)
+
+// exit codes of failure classes, so that CI steps can tell config errors and auth failures
+// from missing objects, outages, timeouts and bulk items failed in part
+const (
+	exitCodeError        = 1
+	exitCodeConfig       = 2
+	exitCodeAPIClient    = 3
+	exitCodeAPIAuth      = 4
+	exitCodeAPINotFound  = 5
+	exitCodeAPIRateLimit = 6
+	exitCodeAPIServer    = 7
+	exitCodeTimeout      = 8
+	exitCodePartial      = 9
+)
+
+const (
+	exitClassConfig  = "config"
+	exitClassPartial = "partial"
+)
+
+var exitCodes = map[string]int{
+	common.TelemetryErrorClient:    exitCodeAPIClient,
+	common.TelemetryErrorAuth:      exitCodeAPIAuth,
+	common.TelemetryErrorNotFound:  exitCodeAPINotFound,
+	common.TelemetryErrorRateLimit: exitCodeAPIRateLimit,
+	common.TelemetryErrorServer:    exitCodeAPIServer,
+	common.TelemetryErrorTimeout:   exitCodeTimeout,
+}
+
+// errorFormat of error which command has failed by, it's written to stderr as JSON object if it's json
+var errorFormat = envGet("ERROR_FORMAT", "text").(string)
+
+// ExitError is error which command has failed by, class is config, partial or category of telemetry,
+// e.g. auth, not_found or other, code is exit code of class
+type ExitError struct {
+	Class   string           `json:"class"`
+	Code    int              `json:"code"`
+	Message string           `json:"message"`
+	Command string           `json:"command,omitempty"`
+	API     *common.APIError `json:"api,omitempty"`
+}
+
+var exitCode = struct {
+	mutex   sync.Mutex
+	code    int
+	err     *ExitError
+	command string
+}{}
+
+// exitClassify returns exit code and class of error, vendors' errors which aren't API errors,
+// e.g. of network, are general errors, config errors are validation errors of options and errors of exitConfig only
+func exitClassify(obj interface{}) (int, string) {
+
+	err, ok := obj.(error)
+	if !ok {
+		return exitCodeError, common.TelemetryErrorOther
+	}
+	var poolErr *common.PoolError
+	if errors.As(err, &poolErr) {
+		if poolErr.Partial() {
+			return exitCodePartial, exitClassPartial
+		}
+		return exitCodeError, common.TelemetryErrorOther
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return exitCodeTimeout, common.TelemetryErrorTimeout
+	}
+	category := common.TelemetryErrorCategory(err)
+	if code, ok := exitCodes[category]; ok {
+		return code, category
+	}
+	if category == common.TelemetryErrorValidation {
+		return exitCodeConfig, exitClassConfig
+	}
+	return exitCodeError, category
+}
+
+func exitRecord(code int, class string, obj interface{}, force bool) {
+
+	e := &ExitError{Class: class, Code: code}
+	switch v := obj.(type) {
+	case error:
+		e.Message = v.Error()
+		var apiErr *common.APIError
+		if errors.As(v, &apiErr) {
+			e.API = apiErr
+		}
+	default:
+		e.Message = fmt.Sprintf("%v", v)
+	}
+
+	exitCode.mutex.Lock()
+	defer exitCode.mutex.Unlock()
+
+	// the first error of class is kept, general errors are replaced by it
+	if force || exitCode.code == 0 || (exitCode.code == exitCodeError && code != exitCodeError) {
+		exitCode.code = code
+		exitCode.err = e
+	}
+}
+
+// exitError is error handler of stdout, any error fails command with code of its class,
+// errors are counted by telemetry as well
+func exitError(obj interface{}) {
+
+	telemetry.Error(obj)
+
+	code, class := exitClassify(obj)
+	exitRecord(code, class, obj, false)
+}
+
+// exitConfig fails command by error of flags, config or options before it's run
+func exitConfig(obj interface{}, args ...interface{}) {
+
+	if s, ok := obj.(string); ok && len(args) > 0 {
+		obj = fmt.Sprintf(s, args...)
+	}
+	stdout.Error(obj)
+	exitRecord(exitCodeConfig, exitClassConfig, obj, true)
+	exit()
+}
+
+// exitTimeout fails command which hasn't stopped by deadline
+func exitTimeout(err error) {
+
+	stdout.Error(err)
+	exitRecord(exitCodeTimeout, common.TelemetryErrorTimeout, err, true)
+	exit()
+}
+
+// exitReset is called by services which have stopped, errors of requests being served aren't errors of service
+func exitReset() {
+
+	exitCode.mutex.Lock()
+	defer exitCode.mutex.Unlock()
+
+	exitCode.code = 0
+	exitCode.err = nil
+}
+
+func exitCommand(cmd *cobra.Command) {
+
+	exitCode.mutex.Lock()
+	defer exitCode.mutex.Unlock()
+
+	exitCode.command = strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
+// exitJson returns true if error format is json, args are looked for it if flags aren't parsed, e.g. of unknown flag
+func exitJson() bool {
+
+	format := errorFormat
+	for i, arg := range os.Args {
+		switch {
+		case arg == "--error-format" && i+1 < len(os.Args):
+			format = os.Args[i+1]
+		case strings.HasPrefix(arg, "--error-format="):
+			format = strings.TrimPrefix(arg, "--error-format=")
+		}
+	}
+	return strings.ToLower(format) == "json"
+}
+
+// exit exits with code of error which command has failed by, error is written as JSON object to stderr
+// of json error format
+func exit() {
+
+	exitCode.mutex.Lock()
+	code := exitCode.code
+	e := exitCode.err
+	if e != nil {
+		e.Command = exitCode.command
+	}
+	exitCode.mutex.Unlock()
+
+	if code == 0 {
+		return
+	}
+	if exitJson() && e != nil {
+		b, err := json.Marshal(map[string]interface{}{"error": e})
+		if err == nil {
+			fmt.Fprintln(os.Stderr, string(b))
+		}
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/devopsext/tools/common"
+)
+
+func TestExitClassify(t *testing.T) {
+
+	tests := []struct {
+		obj   interface{}
+		code  int
+		class string
+	}{
+		{common.NewAPIError(401, nil), exitCodeAPIAuth, common.TelemetryErrorAuth},
+		{fmt.Errorf("lookup: %w", common.NewAPIError(404, nil)), exitCodeAPINotFound, common.TelemetryErrorNotFound},
+		{common.NewAPIError(503, nil), exitCodeAPIServer, common.TelemetryErrorServer},
+		{fmt.Errorf("request: %w", context.DeadlineExceeded), exitCodeTimeout, common.TelemetryErrorTimeout},
+		{&common.PoolError{Total: 3, Failed: 1}, exitCodePartial, exitClassPartial},
+		{&common.PoolError{Total: 3, Failed: 3}, exitCodeError, common.TelemetryErrorOther},
+		{&common.ValidationError{Name: "Slack", Issues: []string{"channel is required"}}, exitCodeConfig, exitClassConfig},
+		{fmt.Errorf("send: %w", &common.ValidationError{Name: "Slack"}), exitCodeConfig, exitClassConfig},
+		// errors of vendors aren't config errors by their messages
+		{errors.New("no channel name"), exitCodeError, common.TelemetryErrorOther},
+		{errors.New("invalid character 'x' looking for beginning of value"), exitCodeError, common.TelemetryErrorOther},
+		{errors.New("something"), exitCodeError, common.TelemetryErrorOther},
+		{"Notify failed", exitCodeError, common.TelemetryErrorOther},
+	}
+	for _, tt := range tests {
+		code, class := exitClassify(tt.obj)
+		if code != tt.code || class != tt.class {
+			t.Errorf("%v: expected %d %s, got %d %s", tt.obj, tt.code, tt.class, code, class)
+		}
+	}
+}
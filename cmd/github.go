@@ -0,0 +1,278 @@
+//go:build !minimal || ci || github
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var githubOptions = vendors.GithubOptions{
+	Timeout:           envGet("GITHUB_TIMEOUT", 30).(int),
+	Insecure:          envGet("GITHUB_INSECURE", false).(bool),
+	URL:               envGet("GITHUB_URL", "https://api.github.com").(string),
+	Token:             envGet("GITHUB_TOKEN", "").(string),
+	AppID:             envGet("GITHUB_APP_ID", "").(string),
+	AppInstallationID: envGet("GITHUB_APP_INSTALLATION_ID", "").(string),
+	AppPrivateKey:     envGet("GITHUB_APP_PRIVATE_KEY", "").(string),
+}
+
+var githubRepoOptions = vendors.GithubRepoOptions{
+	Owner: envGet("GITHUB_OWNER", "").(string),
+	Repo:  envGet("GITHUB_REPO", "").(string),
+}
+
+var githubIssueOptions = vendors.GithubIssueOptions{
+	Title:     envGet("GITHUB_ISSUE_TITLE", "").(string),
+	Body:      envGet("GITHUB_ISSUE_BODY", "").(string),
+	Labels:    strings.Split(envGet("GITHUB_ISSUE_LABELS", "").(string), ","),
+	Assignees: strings.Split(envGet("GITHUB_ISSUE_ASSIGNEES", "").(string), ","),
+}
+
+var githubCommentOptions = vendors.GithubCommentOptions{
+	Number: envGet("GITHUB_COMMENT_NUMBER", 0).(int),
+	Body:   envGet("GITHUB_COMMENT_BODY", "").(string),
+}
+
+var githubReleaseOptions = vendors.GithubReleaseOptions{
+	Tag:        envGet("GITHUB_RELEASE_TAG", "").(string),
+	Target:     envGet("GITHUB_RELEASE_TARGET", "").(string),
+	Name:       envGet("GITHUB_RELEASE_NAME", "").(string),
+	Body:       envGet("GITHUB_RELEASE_BODY", "").(string),
+	Draft:      envGet("GITHUB_RELEASE_DRAFT", false).(bool),
+	Prerelease: envGet("GITHUB_RELEASE_PRERELEASE", false).(bool),
+	Assets:     strings.Split(envGet("GITHUB_RELEASE_ASSETS", "").(string), ","),
+}
+
+var githubWorkflowDispatchOptions = vendors.GithubWorkflowDispatchOptions{
+	Workflow: envGet("GITHUB_WORKFLOW", "").(string),
+	Ref:      envGet("GITHUB_WORKFLOW_REF", "main").(string),
+	Inputs:   envGet("GITHUB_WORKFLOW_INPUTS", "").(string),
+}
+
+var githubRepositoryDispatchOptions = vendors.GithubRepositoryDispatchOptions{
+	EventType:     envGet("GITHUB_DISPATCH_EVENT_TYPE", "").(string),
+	ClientPayload: envGet("GITHUB_DISPATCH_CLIENT_PAYLOAD", "").(string),
+}
+
+var githubPullRequestOptions = vendors.GithubPullRequestOptions{
+	Number: envGet("GITHUB_PULL_REQUEST_NUMBER", 0).(int),
+}
+
+var githubOutput = common.OutputOptions{
+	Output: envGet("GITHUB_OUTPUT", "").(string),
+	Query:  envGet("GITHUB_OUTPUT_QUERY", "").(string),
+}
+
+func githubNew(stdout *common.Stdout) *vendors.Github {
+
+	common.Debug("Github", githubOptions, stdout)
+	common.Debug("Github", githubOutput, stdout)
+
+	return vendors.NewGithub(githubOptions)
+}
+
+func NewGithubCommand() *cobra.Command {
+
+	githubCmd := &cobra.Command{
+		Use:   "github",
+		Short: "Github tools",
+	}
+	flags := githubCmd.PersistentFlags()
+	flags.IntVar(&githubOptions.Timeout, "github-timeout", githubOptions.Timeout, "Github timeout in seconds")
+	flags.BoolVar(&githubOptions.Insecure, "github-insecure", githubOptions.Insecure, "Github insecure")
+	flags.StringVar(&githubOptions.URL, "github-url", githubOptions.URL, "Github API URL")
+	flags.StringVar(&githubOptions.Token, "github-token", githubOptions.Token, "Github token")
+	flags.StringVar(&githubOptions.AppID, "github-app-id", githubOptions.AppID, "Github app ID")
+	flags.StringVar(&githubOptions.AppInstallationID, "github-app-installation-id", githubOptions.AppInstallationID, "Github app installation ID")
+	flags.StringVar(&githubOptions.AppPrivateKey, "github-app-private-key", githubOptions.AppPrivateKey, "Github app private key content or file")
+	flags.StringVar(&githubRepoOptions.Owner, "github-owner", githubRepoOptions.Owner, "Github repository owner")
+	flags.StringVar(&githubRepoOptions.Repo, "github-repo", githubRepoOptions.Repo, "Github repository name")
+	flags.StringVar(&githubOutput.Output, "github-output", githubOutput.Output, "Github output")
+	flags.StringVar(&githubOutput.Query, "github-output-query", githubOutput.Query, "Github output query")
+
+	// tools github create-issue
+	createIssueCmd := &cobra.Command{
+		Use:   "create-issue",
+		Short: "Create issue",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Github creating issue...")
+			common.Debug("Github", githubRepoOptions, stdout)
+			common.Debug("Github", githubIssueOptions, stdout)
+
+			bodyBytes, err := utils.Content(githubIssueOptions.Body)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			githubIssueOptions.Body = string(bodyBytes)
+			githubIssueOptions.Labels = common.RemoveEmptyStrings(githubIssueOptions.Labels)
+			githubIssueOptions.Assignees = common.RemoveEmptyStrings(githubIssueOptions.Assignees)
+
+			bytes, err := idempotent("", []interface{}{githubRepoOptions, githubIssueOptions}, func() ([]byte, error) {
+				return githubNew(stdout).CreateIssue(githubRepoOptions, githubIssueOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(githubOutput, "Github", []interface{}{githubOptions, githubRepoOptions, githubIssueOptions}, bytes, stdout)
+		},
+	}
+	flags = createIssueCmd.PersistentFlags()
+	flags.StringVar(&githubIssueOptions.Title, "github-issue-title", githubIssueOptions.Title, "Github issue title")
+	flags.StringVar(&githubIssueOptions.Body, "github-issue-body", githubIssueOptions.Body, "Github issue body")
+	flags.StringSliceVar(&githubIssueOptions.Labels, "github-issue-labels", githubIssueOptions.Labels, "Github issue labels")
+	flags.StringSliceVar(&githubIssueOptions.Assignees, "github-issue-assignees", githubIssueOptions.Assignees, "Github issue assignees")
+	githubCmd.AddCommand(idempotentCommand(createIssueCmd))
+
+	// tools github comment, works for issues and pull requests
+	commentCmd := &cobra.Command{
+		Use:   "comment",
+		Short: "Comment on issue or pull request",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Github commenting...")
+			common.Debug("Github", githubRepoOptions, stdout)
+			common.Debug("Github", githubCommentOptions, stdout)
+
+			bodyBytes, err := utils.Content(githubCommentOptions.Body)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			githubCommentOptions.Body = string(bodyBytes)
+
+			bytes, err := idempotent("", []interface{}{githubRepoOptions, githubCommentOptions}, func() ([]byte, error) {
+				return githubNew(stdout).Comment(githubRepoOptions, githubCommentOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(githubOutput, "Github", []interface{}{githubOptions, githubRepoOptions, githubCommentOptions}, bytes, stdout)
+		},
+	}
+	flags = commentCmd.PersistentFlags()
+	flags.IntVar(&githubCommentOptions.Number, "github-comment-number", githubCommentOptions.Number, "Github issue or pull request number")
+	flags.StringVar(&githubCommentOptions.Body, "github-comment-body", githubCommentOptions.Body, "Github comment body")
+	githubCmd.AddCommand(idempotentCommand(commentCmd))
+
+	// tools github create-release
+	createReleaseCmd := &cobra.Command{
+		Use:   "create-release",
+		Short: "Create release with assets",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Github creating release...")
+			common.Debug("Github", githubRepoOptions, stdout)
+			common.Debug("Github", githubReleaseOptions, stdout)
+
+			bodyBytes, err := utils.Content(githubReleaseOptions.Body)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			githubReleaseOptions.Body = string(bodyBytes)
+
+			bytes, err := idempotent("", []interface{}{githubRepoOptions, githubReleaseOptions}, func() ([]byte, error) {
+				return githubNew(stdout).CreateRelease(githubRepoOptions, githubReleaseOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(githubOutput, "Github", []interface{}{githubOptions, githubRepoOptions, githubReleaseOptions}, bytes, stdout)
+		},
+	}
+	flags = createReleaseCmd.PersistentFlags()
+	flags.StringVar(&githubReleaseOptions.Tag, "github-release-tag", githubReleaseOptions.Tag, "Github release tag")
+	flags.StringVar(&githubReleaseOptions.Target, "github-release-target", githubReleaseOptions.Target, "Github release target commitish")
+	flags.StringVar(&githubReleaseOptions.Name, "github-release-name", githubReleaseOptions.Name, "Github release name")
+	flags.StringVar(&githubReleaseOptions.Body, "github-release-body", githubReleaseOptions.Body, "Github release body")
+	flags.BoolVar(&githubReleaseOptions.Draft, "github-release-draft", githubReleaseOptions.Draft, "Github release draft")
+	flags.BoolVar(&githubReleaseOptions.Prerelease, "github-release-prerelease", githubReleaseOptions.Prerelease, "Github release prerelease")
+	flags.StringSliceVar(&githubReleaseOptions.Assets, "github-release-assets", githubReleaseOptions.Assets, "Github release asset files")
+	githubCmd.AddCommand(idempotentCommand(createReleaseCmd))
+
+	// tools github dispatch-workflow
+	dispatchWorkflowCmd := &cobra.Command{
+		Use:   "dispatch-workflow",
+		Short: "Dispatch workflow",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Github dispatching workflow...")
+			common.Debug("Github", githubRepoOptions, stdout)
+			common.Debug("Github", githubWorkflowDispatchOptions, stdout)
+
+			bytes, err := idempotent("", []interface{}{githubRepoOptions, githubWorkflowDispatchOptions}, func() ([]byte, error) {
+				return githubNew(stdout).DispatchWorkflow(githubRepoOptions, githubWorkflowDispatchOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputRaw(githubOutput.Output, bytes, stdout)
+		},
+	}
+	flags = dispatchWorkflowCmd.PersistentFlags()
+	flags.StringVar(&githubWorkflowDispatchOptions.Workflow, "github-workflow", githubWorkflowDispatchOptions.Workflow, "Github workflow ID or file name")
+	flags.StringVar(&githubWorkflowDispatchOptions.Ref, "github-workflow-ref", githubWorkflowDispatchOptions.Ref, "Github workflow ref")
+	flags.StringVar(&githubWorkflowDispatchOptions.Inputs, "github-workflow-inputs", githubWorkflowDispatchOptions.Inputs, "Github workflow inputs json")
+	githubCmd.AddCommand(idempotentCommand(dispatchWorkflowCmd))
+
+	// tools github dispatch-repository
+	dispatchRepositoryCmd := &cobra.Command{
+		Use:   "dispatch-repository",
+		Short: "Dispatch repository event",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Github dispatching repository event...")
+			common.Debug("Github", githubRepoOptions, stdout)
+			common.Debug("Github", githubRepositoryDispatchOptions, stdout)
+
+			bytes, err := idempotent("", []interface{}{githubRepoOptions, githubRepositoryDispatchOptions}, func() ([]byte, error) {
+				return githubNew(stdout).DispatchRepository(githubRepoOptions, githubRepositoryDispatchOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputRaw(githubOutput.Output, bytes, stdout)
+		},
+	}
+	flags = dispatchRepositoryCmd.PersistentFlags()
+	flags.StringVar(&githubRepositoryDispatchOptions.EventType, "github-dispatch-event-type", githubRepositoryDispatchOptions.EventType, "Github repository dispatch event type")
+	flags.StringVar(&githubRepositoryDispatchOptions.ClientPayload, "github-dispatch-client-payload", githubRepositoryDispatchOptions.ClientPayload, "Github repository dispatch client payload json")
+	githubCmd.AddCommand(idempotentCommand(dispatchRepositoryCmd))
+
+	// tools github pull-request-status
+	pullRequestStatusCmd := &cobra.Command{
+		Use:   "pull-request-status",
+		Short: "Get pull request status with checks",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Github getting pull request status...")
+			common.Debug("Github", githubRepoOptions, stdout)
+			common.Debug("Github", githubPullRequestOptions, stdout)
+
+			bytes, err := githubNew(stdout).GetPullRequestStatus(githubRepoOptions, githubPullRequestOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(githubOutput, "Github", []interface{}{githubOptions, githubRepoOptions, githubPullRequestOptions}, bytes, stdout)
+		},
+	}
+	flags = pullRequestStatusCmd.PersistentFlags()
+	flags.IntVar(&githubPullRequestOptions.Number, "github-pull-request-number", githubPullRequestOptions.Number, "Github pull request number")
+	githubCmd.AddCommand(pullRequestStatusCmd)
+
+	return githubCmd
+}
+
+func init() {
+	registerVendor("github", vendorGroupCI, NewGithubCommand, githubNew)
+}
@@ -1,10 +1,14 @@
+//go:build !minimal || ci || gitlab
+
 package cmd
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/devopsext/tools/common"
 	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -35,6 +39,48 @@ var pipelineGetVariablesOptions = vendors.GitlabGetPipelineVariablesOptions{
 	Query: strings.Split(envGet("GITLAB_PIPELINE_VARIABLE_QUERY", "").(string), ","),
 }
 
+var gitlabTriggerPipelineOptions = vendors.GitlabTriggerPipelineOptions{
+	TriggerToken: envGet("GITLAB_PIPELINE_TRIGGER_TOKEN", "").(string),
+	Variables:    envGet("GITLAB_PIPELINE_VARIABLES", "").(string),
+}
+
+var gitlabPipelineStatusOptions = vendors.GitlabPipelineStatusOptions{
+	PipelineID: envGet("GITLAB_PIPELINE_ID", 0).(int),
+}
+
+var gitlabMergeRequestOptions = vendors.GitlabMergeRequestOptions{
+	ProjectID:          envGet("GITLAB_MERGE_REQUEST_PROJECT_ID", 0).(int),
+	SourceBranch:       envGet("GITLAB_MERGE_REQUEST_SOURCE_BRANCH", "").(string),
+	TargetBranch:       envGet("GITLAB_MERGE_REQUEST_TARGET_BRANCH", "main").(string),
+	Title:              envGet("GITLAB_MERGE_REQUEST_TITLE", "").(string),
+	Description:        envGet("GITLAB_MERGE_REQUEST_DESCRIPTION", "").(string),
+	Labels:             strings.Split(envGet("GITLAB_MERGE_REQUEST_LABELS", "").(string), ","),
+	RemoveSourceBranch: envGet("GITLAB_MERGE_REQUEST_REMOVE_SOURCE_BRANCH", false).(bool),
+}
+
+var gitlabMergeRequestNoteOptions = vendors.GitlabMergeRequestNoteOptions{
+	IID:  envGet("GITLAB_MERGE_REQUEST_IID", 0).(int),
+	Body: envGet("GITLAB_MERGE_REQUEST_NOTE_BODY", "").(string),
+}
+
+var gitlabTagOptions = vendors.GitlabTagOptions{
+	ProjectID: envGet("GITLAB_TAG_PROJECT_ID", 0).(int),
+	Name:      envGet("GITLAB_TAG_NAME", "").(string),
+	Ref:       envGet("GITLAB_TAG_REF", "").(string),
+	Message:   envGet("GITLAB_TAG_MESSAGE", "").(string),
+}
+
+var gitlabVariableOptions = vendors.GitlabVariableOptions{
+	ProjectID:        envGet("GITLAB_VARIABLE_PROJECT_ID", 0).(int),
+	Key:              envGet("GITLAB_VARIABLE_KEY", "").(string),
+	Value:            envGet("GITLAB_VARIABLE_VALUE", "").(string),
+	Type:             envGet("GITLAB_VARIABLE_TYPE", "env_var").(string),
+	Protected:        envGet("GITLAB_VARIABLE_PROTECTED", false).(bool),
+	Masked:           envGet("GITLAB_VARIABLE_MASKED", false).(bool),
+	Raw:              envGet("GITLAB_VARIABLE_RAW", false).(bool),
+	EnvironmentScope: envGet("GITLAB_VARIABLE_ENVIRONMENT_SCOPE", "*").(string),
+}
+
 func gitlabNew(stdout *common.Stdout) *vendors.Gitlab {
 
 	common.Debug("Gitlab", gitlabOptions, stdout)
@@ -125,5 +171,275 @@ func NewGitlabCommand() *cobra.Command {
 	flags.StringSliceVar(&pipelineGetVariablesOptions.Query, "gitlab-pipeline-variable-query", pipelineGetVariablesOptions.Query, "Gitlab pipeline variable query")
 	pipelineCmd.AddCommand(pipelineGetVariablesCmd)
 
+	pipelineTriggerCmd := &cobra.Command{
+		Use:   "trigger",
+		Short: "Trigger gitlab pipeline",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Gitlab triggering pipeline...")
+			gitlabTriggerPipelineOptions.ProjectID = pipelineOptions.ProjectID
+			gitlabTriggerPipelineOptions.Ref = pipelineOptions.Ref
+			common.Debug("Gitlab", gitlabTriggerPipelineOptions, stdout)
+
+			bytes, err := idempotent("", gitlabTriggerPipelineOptions, func() ([]byte, error) {
+				return gitlabNew(stdout).TriggerPipeline(gitlabTriggerPipelineOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(gitlabOutput, "Gitlab", []interface{}{gitlabOptions, pipelineOptions, gitlabTriggerPipelineOptions}, bytes, stdout)
+		},
+	}
+	flags = pipelineTriggerCmd.PersistentFlags()
+	flags.StringVar(&gitlabTriggerPipelineOptions.TriggerToken, "gitlab-pipeline-trigger-token", gitlabTriggerPipelineOptions.TriggerToken, "Gitlab pipeline trigger token")
+	flags.StringVar(&gitlabTriggerPipelineOptions.Variables, "gitlab-pipeline-variables", gitlabTriggerPipelineOptions.Variables, "Gitlab pipeline variables: KEY1=value1,KEY2=value2")
+	pipelineCmd.AddCommand(idempotentCommand(pipelineTriggerCmd))
+
+	pipelineStatusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Get gitlab pipeline status",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Gitlab getting pipeline status...")
+			gitlabPipelineStatusOptions.ProjectID = pipelineOptions.ProjectID
+			common.Debug("Gitlab", gitlabPipelineStatusOptions, stdout)
+
+			bytes, err := gitlabNew(stdout).GetPipelineStatus(gitlabPipelineStatusOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(gitlabOutput, "Gitlab", []interface{}{gitlabOptions, pipelineOptions, gitlabPipelineStatusOptions}, bytes, stdout)
+		},
+	}
+	flags = pipelineStatusCmd.PersistentFlags()
+	flags.IntVar(&gitlabPipelineStatusOptions.PipelineID, "gitlab-pipeline-id", gitlabPipelineStatusOptions.PipelineID, "Gitlab pipeline ID")
+	pipelineCmd.AddCommand(pipelineStatusCmd)
+
+	mergeRequestCmd := &cobra.Command{
+		Use:   "merge-request",
+		Short: "Merge request methods",
+	}
+	flags = mergeRequestCmd.PersistentFlags()
+	flags.IntVar(&gitlabMergeRequestOptions.ProjectID, "gitlab-merge-request-project-id", gitlabMergeRequestOptions.ProjectID, "Gitlab merge request project ID")
+	gitlabCmd.AddCommand(mergeRequestCmd)
+
+	mergeRequestCreateCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create merge request",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Gitlab creating merge request...")
+			common.Debug("Gitlab", gitlabMergeRequestOptions, stdout)
+
+			descriptionBytes, err := utils.Content(gitlabMergeRequestOptions.Description)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			gitlabMergeRequestOptions.Description = string(descriptionBytes)
+
+			bytes, err := idempotent("", gitlabMergeRequestOptions, func() ([]byte, error) {
+				return gitlabNew(stdout).CreateMergeRequest(gitlabMergeRequestOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(gitlabOutput, "Gitlab", []interface{}{gitlabOptions, gitlabMergeRequestOptions}, bytes, stdout)
+		},
+	}
+	flags = mergeRequestCreateCmd.PersistentFlags()
+	flags.StringVar(&gitlabMergeRequestOptions.SourceBranch, "gitlab-merge-request-source-branch", gitlabMergeRequestOptions.SourceBranch, "Gitlab merge request source branch")
+	flags.StringVar(&gitlabMergeRequestOptions.TargetBranch, "gitlab-merge-request-target-branch", gitlabMergeRequestOptions.TargetBranch, "Gitlab merge request target branch")
+	flags.StringVar(&gitlabMergeRequestOptions.Title, "gitlab-merge-request-title", gitlabMergeRequestOptions.Title, "Gitlab merge request title")
+	flags.StringVar(&gitlabMergeRequestOptions.Description, "gitlab-merge-request-description", gitlabMergeRequestOptions.Description, "Gitlab merge request description")
+	flags.StringSliceVar(&gitlabMergeRequestOptions.Labels, "gitlab-merge-request-labels", gitlabMergeRequestOptions.Labels, "Gitlab merge request labels")
+	flags.BoolVar(&gitlabMergeRequestOptions.RemoveSourceBranch, "gitlab-merge-request-remove-source-branch", gitlabMergeRequestOptions.RemoveSourceBranch, "Gitlab merge request remove source branch")
+	mergeRequestCmd.AddCommand(idempotentCommand(mergeRequestCreateCmd))
+
+	mergeRequestCommentCmd := &cobra.Command{
+		Use:   "comment",
+		Short: "Comment on merge request",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Gitlab commenting merge request...")
+			gitlabMergeRequestNoteOptions.ProjectID = gitlabMergeRequestOptions.ProjectID
+			common.Debug("Gitlab", gitlabMergeRequestNoteOptions, stdout)
+
+			bodyBytes, err := utils.Content(gitlabMergeRequestNoteOptions.Body)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			gitlabMergeRequestNoteOptions.Body = string(bodyBytes)
+
+			bytes, err := idempotent("", gitlabMergeRequestNoteOptions, func() ([]byte, error) {
+				return gitlabNew(stdout).CommentOnMergeRequest(gitlabMergeRequestNoteOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(gitlabOutput, "Gitlab", []interface{}{gitlabOptions, gitlabMergeRequestNoteOptions}, bytes, stdout)
+		},
+	}
+	flags = mergeRequestCommentCmd.PersistentFlags()
+	flags.IntVar(&gitlabMergeRequestNoteOptions.IID, "gitlab-merge-request-iid", gitlabMergeRequestNoteOptions.IID, "Gitlab merge request IID")
+	flags.StringVar(&gitlabMergeRequestNoteOptions.Body, "gitlab-merge-request-note-body", gitlabMergeRequestNoteOptions.Body, "Gitlab merge request note body")
+	mergeRequestCmd.AddCommand(idempotentCommand(mergeRequestCommentCmd))
+
+	tagCmd := &cobra.Command{
+		Use:   "tag",
+		Short: "Tag methods",
+	}
+	flags = tagCmd.PersistentFlags()
+	flags.IntVar(&gitlabTagOptions.ProjectID, "gitlab-tag-project-id", gitlabTagOptions.ProjectID, "Gitlab tag project ID")
+	gitlabCmd.AddCommand(tagCmd)
+
+	tagCreateCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create tag",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Gitlab creating tag...")
+			common.Debug("Gitlab", gitlabTagOptions, stdout)
+
+			bytes, err := idempotent("", gitlabTagOptions, func() ([]byte, error) {
+				return gitlabNew(stdout).CreateTag(gitlabTagOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(gitlabOutput, "Gitlab", []interface{}{gitlabOptions, gitlabTagOptions}, bytes, stdout)
+		},
+	}
+	flags = tagCreateCmd.PersistentFlags()
+	flags.StringVar(&gitlabTagOptions.Name, "gitlab-tag-name", gitlabTagOptions.Name, "Gitlab tag name")
+	flags.StringVar(&gitlabTagOptions.Ref, "gitlab-tag-ref", gitlabTagOptions.Ref, "Gitlab tag ref")
+	flags.StringVar(&gitlabTagOptions.Message, "gitlab-tag-message", gitlabTagOptions.Message, "Gitlab tag message")
+	tagCmd.AddCommand(idempotentCommand(tagCreateCmd))
+
+	variableCmd := &cobra.Command{
+		Use:   "variable",
+		Short: "Project variable methods",
+	}
+	flags = variableCmd.PersistentFlags()
+	flags.IntVar(&gitlabVariableOptions.ProjectID, "gitlab-variable-project-id", gitlabVariableOptions.ProjectID, "Gitlab variable project ID")
+	flags.StringVar(&gitlabVariableOptions.Key, "gitlab-variable-key", gitlabVariableOptions.Key, "Gitlab variable key")
+	flags.StringVar(&gitlabVariableOptions.EnvironmentScope, "gitlab-variable-environment-scope", gitlabVariableOptions.EnvironmentScope, "Gitlab variable environment scope")
+	gitlabCmd.AddCommand(variableCmd)
+
+	variableCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List project variables",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Gitlab listing variables...")
+			common.Debug("Gitlab", gitlabVariableOptions, stdout)
+
+			bytes, err := gitlabNew(stdout).ListVariables(gitlabVariableOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(gitlabOutput, "Gitlab", []interface{}{gitlabOptions, gitlabVariableOptions}, bytes, stdout)
+		},
+	})
+
+	variableCmd.AddCommand(&cobra.Command{
+		Use:   "get",
+		Short: "Get project variable",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Gitlab getting variable...")
+			common.Debug("Gitlab", gitlabVariableOptions, stdout)
+
+			bytes, err := gitlabNew(stdout).GetVariable(gitlabVariableOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(gitlabOutput, "Gitlab", []interface{}{gitlabOptions, gitlabVariableOptions}, bytes, stdout)
+		},
+	})
+
+	variableSetCmd := &cobra.Command{
+		Use:   "set",
+		Short: "Create or update project variable",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Gitlab setting variable...")
+			common.Debug("Gitlab", gitlabVariableOptions, stdout)
+
+			valueBytes, err := utils.Content(gitlabVariableOptions.Value)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			gitlabVariableOptions.Value = string(valueBytes)
+
+			bytes, err := idempotent("", gitlabVariableOptions, func() ([]byte, error) {
+				return gitlabNew(stdout).SetVariable(gitlabVariableOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(gitlabOutput, "Gitlab", []interface{}{gitlabOptions, gitlabVariableOptions}, bytes, stdout)
+		},
+	}
+	flags = variableSetCmd.PersistentFlags()
+	flags.StringVar(&gitlabVariableOptions.Value, "gitlab-variable-value", gitlabVariableOptions.Value, "Gitlab variable value")
+	flags.StringVar(&gitlabVariableOptions.Type, "gitlab-variable-type", gitlabVariableOptions.Type, "Gitlab variable type: env_var, file")
+	flags.BoolVar(&gitlabVariableOptions.Protected, "gitlab-variable-protected", gitlabVariableOptions.Protected, "Gitlab variable protected")
+	flags.BoolVar(&gitlabVariableOptions.Masked, "gitlab-variable-masked", gitlabVariableOptions.Masked, "Gitlab variable masked")
+	flags.BoolVar(&gitlabVariableOptions.Raw, "gitlab-variable-raw", gitlabVariableOptions.Raw, "Gitlab variable raw")
+	variableCmd.AddCommand(idempotentCommand(variableSetCmd))
+
+	variableCmd.AddCommand(&cobra.Command{
+		Use:   "delete",
+		Short: "Delete project variable",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Gitlab deleting variable...")
+			common.Debug("Gitlab", gitlabVariableOptions, stdout)
+
+			bytes, err := idempotent("", gitlabVariableOptions, func() ([]byte, error) {
+				return gitlabNew(stdout).DeleteVariable(gitlabVariableOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputRaw(gitlabOutput.Output, bytes, stdout)
+		},
+	})
+
 	return gitlabCmd
 }
+
+func enrichmentGitlabDeployments(params map[string]string) (interface{}, error) {
+
+	projectID, err := strconv.Atoi(params["project"])
+	if err != nil {
+		return nil, err
+	}
+	limit := 5
+	if !utils.IsEmpty(params["limit"]) {
+		limit, err = strconv.Atoi(params["limit"])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return enrichmentJson(vendors.NewGitlab(gitlabOptions).ListDeployments(vendors.GitlabDeploymentsOptions{
+		ProjectID:   projectID,
+		Environment: params["environment"],
+		Status:      params["status"],
+		Limit:       limit,
+	}))
+}
+
+func init() {
+	registerVendor("gitlab", vendorGroupCI, NewGitlabCommand, gitlabNew)
+	registerVendorEnricher("gitlab-deployments", enrichmentGitlabDeployments)
+}
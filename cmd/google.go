@@ -1,8 +1,16 @@
+//go:build !minimal || cloud || google
+
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
 	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/server"
 	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -39,6 +47,7 @@ var googleCalendarInsertEventOptions = vendors.GoogleCalendarInsertEventOptions{
 	SourceTitle:         envGet("GOOGLE_CALENDAR_EVENT_SOURCE_TITLE", "").(string),
 	SourceURL:           envGet("GOOGLE_CALENDAR_EVENT_SOURCE_URL", "").(string),
 	ConferenceID:        envGet("GOOGLE_CALENDAR_EVENT_CONFERENCE_ID", "").(string),
+	Attendees:           strings.Split(envGet("GOOGLE_CALENDAR_EVENT_ATTENDEES", "").(string), ","),
 }
 
 var googleCalendarDeleteEventOptions = vendors.GoogleCalendarDeleteEventOptions{
@@ -61,7 +70,30 @@ func googleNew(stdout *common.Stdout) *vendors.Google {
 	common.Debug("Google", googleOptions, stdout)
 	common.Debug("Google", googleOutput, stdout)
 
-	return vendors.NewGoogle(googleOptions, stdout)
+	return vendors.NewGoogle(googleOptions, stdout.Module("google"))
+}
+
+// googleCompleteCalendars completes calendar id by ids of calendars of user with summaries as descriptions
+func googleCompleteCalendars(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+
+	if !completionStart(cmd) || utils.IsEmpty(googleOptions.RefreshToken) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	b, err := vendors.NewGoogle(googleOptions, stdout.Module("google")).CalendarList()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var list vendors.GoogleCalendarList
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	ids := []string{}
+	for _, c := range list.Items {
+		if strings.HasPrefix(c.ID, toComplete) {
+			ids = append(ids, fmt.Sprintf("%s\t%s", c.ID, c.Summary))
+		}
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
 }
 
 func NewGoogleCommand() *cobra.Command {
@@ -78,6 +110,7 @@ func NewGoogleCommand() *cobra.Command {
 	flags.StringVar(&googleOptions.RefreshToken, "google-refresh-token", googleOptions.RefreshToken, "Google refresh token")
 	flags.StringVar(&googleOutput.Output, "google-output", googleOutput.Output, "Google output")
 	flags.StringVar(&googleOutput.Query, "google-output-query", googleOutput.Query, "Google output query")
+	requiredFlags(flags, "google-oauth-client-id", "google-oauth-client-secret", "google-refresh-token")
 
 	calendarCmd := &cobra.Command{
 		Use:   "calendar",
@@ -85,6 +118,7 @@ func NewGoogleCommand() *cobra.Command {
 	}
 	flags = calendarCmd.PersistentFlags()
 	flags.StringVar(&googleCalendarOptions.ID, "google-calendar-id", googleCalendarOptions.ID, "Google calendar id")
+	calendarCmd.RegisterFlagCompletionFunc("google-calendar-id", googleCompleteCalendars)
 	googleCmd.AddCommand(calendarCmd)
 
 	calendarGetEventsCmd := &cobra.Command{
@@ -98,7 +132,7 @@ func NewGoogleCommand() *cobra.Command {
 
 			bytes, err := googleNew(stdout).CalendarGetEvents(googleCalendarOptions, googleCalendarGetEventsOptions)
 			if err != nil {
-				stdout.Error("CalendarGetEvents error: %s", err)
+				stdout.Error(fmt.Errorf("CalendarGetEvents error: %w", err))
 			}
 			common.OutputJson(googleOutput, "Google", []interface{}{googleOptions, googleCalendarOptions, googleCalendarGetEventsOptions}, bytes, stdout)
 		},
@@ -121,9 +155,12 @@ func NewGoogleCommand() *cobra.Command {
 			common.Debug("Google", googleCalendarOptions, stdout)
 			common.Debug("Google", googleCalendarInsertEventOptions, stdout)
 
-			bytes, err := googleNew(stdout).CalendarInsertEvent(googleCalendarOptions, googleCalendarInsertEventOptions)
+			google := googleNew(stdout)
+			bytes, err := idempotent("", []interface{}{googleCalendarOptions, googleCalendarInsertEventOptions}, func() ([]byte, error) {
+				return google.CalendarInsertEvent(googleCalendarOptions, googleCalendarInsertEventOptions)
+			})
 			if err != nil {
-				stdout.Error("CalendarInsertEvent error: %s", err)
+				stdout.Error(fmt.Errorf("CalendarInsertEvent error: %w", err))
 			}
 			common.OutputJson(googleOutput, "Google", []interface{}{googleOptions, googleCalendarOptions, googleCalendarInsertEventOptions}, bytes, stdout)
 		},
@@ -140,7 +177,8 @@ func NewGoogleCommand() *cobra.Command {
 	flags.StringVar(&googleCalendarInsertEventOptions.SourceTitle, "google-calendar-event-source-title", googleCalendarInsertEventOptions.SourceTitle, "Google calendar event source title")
 	flags.StringVar(&googleCalendarInsertEventOptions.SourceURL, "google-calendar-event-source-url", googleCalendarInsertEventOptions.SourceURL, "Google calendar event source URL")
 	flags.StringVar(&googleCalendarInsertEventOptions.ConferenceID, "google-calendar-event-conference-id", googleCalendarInsertEventOptions.ConferenceID, "Google calendar conference ID")
-	calendarCmd.AddCommand(calendarInsertEventCmd)
+	flags.StringSliceVar(&googleCalendarInsertEventOptions.Attendees, "google-calendar-event-attendees", googleCalendarInsertEventOptions.Attendees, "Google calendar event attendee emails, comma separated")
+	calendarCmd.AddCommand(idempotentCommand(calendarInsertEventCmd))
 
 	calendarDeleteEventCmd := &cobra.Command{
 		Use:   "delete-event",
@@ -151,16 +189,18 @@ func NewGoogleCommand() *cobra.Command {
 			common.Debug("Google", googleCalendarOptions, stdout)
 			common.Debug("Google", googleCalendarDeleteEventOptions, stdout)
 
-			bytes, err := googleNew(stdout).CalendarDeleteEvent(googleCalendarOptions, googleCalendarDeleteEventOptions)
+			bytes, err := idempotent("", []interface{}{googleCalendarOptions, googleCalendarDeleteEventOptions}, func() ([]byte, error) {
+				return googleNew(stdout).CalendarDeleteEvent(googleCalendarOptions, googleCalendarDeleteEventOptions)
+			})
 			if err != nil {
-				stdout.Error("CalendarDeleteEvent error: %s", err)
+				stdout.Error(fmt.Errorf("CalendarDeleteEvent error: %w", err))
 			}
 			common.OutputJson(googleOutput, "Google", []interface{}{googleOptions, googleCalendarOptions, googleCalendarDeleteEventOptions}, bytes, stdout)
 		},
 	}
 	flags = calendarDeleteEventCmd.PersistentFlags()
 	flags.StringVar(&googleCalendarDeleteEventOptions.ID, "google-calendar-event-id", googleCalendarDeleteEventOptions.ID, "Google calendar event ID")
-	calendarCmd.AddCommand(calendarDeleteEventCmd)
+	calendarCmd.AddCommand(idempotentCommand(calendarDeleteEventCmd))
 
 	calendarDeleteEventsCmd := &cobra.Command{
 		Use:   "delete-events",
@@ -171,9 +211,11 @@ func NewGoogleCommand() *cobra.Command {
 			common.Debug("Google", googleCalendarOptions, stdout)
 			common.Debug("Google", googleCalendarGetEventsOptions, stdout)
 
-			bytes, err := googleNew(stdout).CalendarDeleteEvents(googleCalendarOptions, googleCalendarGetEventsOptions)
+			bytes, err := idempotent("", []interface{}{googleCalendarOptions, googleCalendarGetEventsOptions}, func() ([]byte, error) {
+				return googleNew(stdout).CalendarDeleteEvents(googleCalendarOptions, googleCalendarGetEventsOptions)
+			})
 			if err != nil {
-				stdout.Error("CalendarDeleteEvent error: %s", err)
+				stdout.Error(fmt.Errorf("CalendarDeleteEvent error: %w", err))
 			}
 			common.OutputJson(googleOutput, "Google", []interface{}{googleOptions, googleCalendarOptions, googleCalendarGetEventsOptions}, bytes, stdout)
 		},
@@ -185,7 +227,65 @@ func NewGoogleCommand() *cobra.Command {
 	flags.StringVar(&googleCalendarGetEventsOptions.OrderBy, "google-calendar-order-by", googleCalendarGetEventsOptions.OrderBy, "Google calendar order by")
 	flags.StringVar(&googleCalendarGetEventsOptions.Q, "google-calendar-q", googleCalendarGetEventsOptions.Q, "Google calendar q")
 	flags.BoolVar(&googleCalendarGetEventsOptions.SingleEvents, "google-calendar-single-events", googleCalendarGetEventsOptions.SingleEvents, "Google calendar single events")
-	calendarCmd.AddCommand(calendarDeleteEventsCmd)
+	calendarCmd.AddCommand(idempotentCommand(calendarDeleteEventsCmd))
+
+	calendarListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "Calendar list of user",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Google calendar listing calendars...")
+
+			bytes, err := googleNew(stdout).CalendarList()
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(googleOutput, "Google", []interface{}{googleOptions}, bytes, stdout)
+		},
+	}
+	calendarCmd.AddCommand(calendarListCmd)
 
 	return &googleCmd
 }
+
+func init() {
+	registerVendor("google", vendorGroupCloud, NewGoogleCommand, googleNew)
+	registerVendorTargets(func(targets map[string]server.Target) {
+
+		// calendar event of message as description, e.g. of maintenance windows posted by other services
+		targets["google-calendar"] = secretsTarget("google", func() server.Target {
+			google := vendors.NewGoogle(googleOptions, stdout.Module("google"))
+			return func(params map[string]string, message string) ([]byte, error) {
+				calendarOpts := googleCalendarOptions
+				if !utils.IsEmpty(params["calendar"]) {
+					calendarOpts.ID = params["calendar"]
+				}
+				opts := googleCalendarInsertEventOptions
+				opts.Description = message
+				if !utils.IsEmpty(params["title"]) {
+					opts.Summary = params["title"]
+				}
+				if !utils.IsEmpty(params["summary"]) {
+					opts.Summary = params["summary"]
+				}
+				if !utils.IsEmpty(params["start"]) {
+					opts.Start = params["start"]
+				}
+				if !utils.IsEmpty(params["end"]) {
+					opts.End = params["end"]
+				}
+				if !utils.IsEmpty(params["timezone"]) {
+					opts.TimeZone = params["timezone"]
+				}
+				if !utils.IsEmpty(params["conference"]) {
+					opts.ConferenceID = params["conference"]
+				}
+				if !utils.IsEmpty(params["attendees"]) {
+					opts.Attendees = strings.Split(params["attendees"], ",")
+				}
+				return google.CalendarInsertEvent(calendarOpts, opts)
+			}
+		})
+	})
+}
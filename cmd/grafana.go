@@ -1,3 +1,5 @@
+//go:build !minimal || monitoring || grafana
+
 package cmd
 
 import (
@@ -111,7 +113,9 @@ func NewGrafanaCommand() *cobra.Command {
 				return
 			}
 
-			bytes, err := grafanaNew(stdout).CreateDashboard(grafanaCreateDashboardOptions)
+			bytes, err := idempotent("", grafanaCreateDashboardOptions, func() ([]byte, error) {
+				return grafanaNew(stdout).CreateDashboard(grafanaCreateDashboardOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -215,7 +219,9 @@ func NewGrafanaCommand() *cobra.Command {
 				return
 			}
 
-			bytes, err := grafanaNew(stdout).CreateAnnotation(grafanaCreateAnnotationOptions)
+			bytes, err := idempotent("", grafanaCreateAnnotationOptions, func() ([]byte, error) {
+				return grafanaNew(stdout).CreateAnnotation(grafanaCreateAnnotationOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -232,3 +238,7 @@ func NewGrafanaCommand() *cobra.Command {
 
 	return &grafanaCmd
 }
+
+func init() {
+	registerVendor("grafana", vendorGroupMonitoring, NewGrafanaCommand, grafanaNew)
+}
@@ -0,0 +1,189 @@
+//go:build !minimal || incident || grafanaoncall
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var grafanaOnCallOptions = vendors.GrafanaOnCallOptions{
+	Timeout:    envGet("GRAFANA_ONCALL_TIMEOUT", 30).(int),
+	Insecure:   envGet("GRAFANA_ONCALL_INSECURE", false).(bool),
+	URL:        envGet("GRAFANA_ONCALL_URL", "").(string),
+	Token:      envGet("GRAFANA_ONCALL_TOKEN", "").(string),
+	GrafanaURL: envGet("GRAFANA_ONCALL_GRAFANA_URL", "").(string),
+}
+
+var grafanaOnCallEscalationOptions = vendors.GrafanaOnCallEscalationOptions{
+	Title:      envGet("GRAFANA_ONCALL_ESCALATION_TITLE", "").(string),
+	Message:    envGet("GRAFANA_ONCALL_ESCALATION_MESSAGE", "").(string),
+	SourceURL:  envGet("GRAFANA_ONCALL_ESCALATION_SOURCE_URL", "").(string),
+	Important:  envGet("GRAFANA_ONCALL_ESCALATION_IMPORTANT", false).(bool),
+	Team:       envGet("GRAFANA_ONCALL_ESCALATION_TEAM", "").(string),
+	Users:      strings.Split(envGet("GRAFANA_ONCALL_ESCALATION_USERS", "").(string), ","),
+	AlertGroup: envGet("GRAFANA_ONCALL_ALERT_GROUP", "").(string),
+}
+
+var grafanaOnCallAlertGroupOptions = vendors.GrafanaOnCallAlertGroupOptions{
+	ID: envGet("GRAFANA_ONCALL_ALERT_GROUP", "").(string),
+}
+
+var grafanaOnCallScheduleOptions = vendors.GrafanaOnCallScheduleOptions{
+	Schedule: envGet("GRAFANA_ONCALL_SCHEDULE", "").(string),
+}
+
+var grafanaOnCallOutput = common.OutputOptions{
+	Output: envGet("GRAFANA_ONCALL_OUTPUT", "").(string),
+	Query:  envGet("GRAFANA_ONCALL_OUTPUT_QUERY", "").(string),
+}
+
+func grafanaOnCallNew(stdout *common.Stdout) *vendors.GrafanaOnCall {
+
+	common.Debug("GrafanaOnCall", grafanaOnCallOptions, stdout)
+	common.Debug("GrafanaOnCall", grafanaOnCallOutput, stdout)
+
+	return vendors.NewGrafanaOnCall(grafanaOnCallOptions)
+}
+
+// enrichmentGrafanaOnCall gets users on-call now of schedule, e.g. to mention them in alert message
+func enrichmentGrafanaOnCall(params map[string]string) (interface{}, error) {
+
+	opts := grafanaOnCallOptions
+	if !utils.IsEmpty(params["url"]) {
+		opts.URL = params["url"]
+	}
+	scheduleOptions := vendors.GrafanaOnCallScheduleOptions{
+		Schedule: params["schedule"],
+	}
+	return enrichmentJson(vendors.NewGrafanaOnCall(opts).GetOnCall(scheduleOptions))
+}
+
+func NewGrafanaOnCallCommand() *cobra.Command {
+
+	grafanaOnCallCmd := &cobra.Command{
+		Use:   "grafanaoncall",
+		Short: "Grafana OnCall tools",
+	}
+	flags := grafanaOnCallCmd.PersistentFlags()
+	flags.IntVar(&grafanaOnCallOptions.Timeout, "grafana-oncall-timeout", grafanaOnCallOptions.Timeout, "Grafana OnCall timeout in seconds")
+	flags.BoolVar(&grafanaOnCallOptions.Insecure, "grafana-oncall-insecure", grafanaOnCallOptions.Insecure, "Grafana OnCall insecure")
+	flags.StringVar(&grafanaOnCallOptions.URL, "grafana-oncall-url", grafanaOnCallOptions.URL, "Grafana OnCall API URL, e.g. https://oncall-prod-us-central-0.grafana.net/oncall")
+	flags.StringVar(&grafanaOnCallOptions.Token, "grafana-oncall-token", grafanaOnCallOptions.Token, "Grafana OnCall API token or Grafana service account token")
+	flags.StringVar(&grafanaOnCallOptions.GrafanaURL, "grafana-oncall-grafana-url", grafanaOnCallOptions.GrafanaURL, "Grafana stack URL, required by service account token")
+	flags.StringVar(&grafanaOnCallOutput.Output, "grafana-oncall-output", grafanaOnCallOutput.Output, "Grafana OnCall output")
+	flags.StringVar(&grafanaOnCallOutput.Query, "grafana-oncall-output-query", grafanaOnCallOutput.Query, "Grafana OnCall output query")
+
+	escalateCmd := &cobra.Command{
+		Use:   "escalate",
+		Short: "Page team or users directly",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("GrafanaOnCall escalating %s...", grafanaOnCallEscalationOptions.Title)
+			common.Debug("GrafanaOnCall", grafanaOnCallEscalationOptions, stdout)
+
+			messageBytes, err := utils.Content(grafanaOnCallEscalationOptions.Message)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			grafanaOnCallEscalationOptions.Message = string(messageBytes)
+
+			if !hooksPreSend(stdout, "grafanaoncall", &grafanaOnCallEscalationOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", grafanaOnCallEscalationOptions, func() ([]byte, error) {
+				return grafanaOnCallNew(stdout).Escalate(grafanaOnCallEscalationOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "grafanaoncall", bytes)
+			common.OutputJson(grafanaOnCallOutput, "GrafanaOnCall", []interface{}{grafanaOnCallOptions, grafanaOnCallEscalationOptions}, bytes, stdout)
+		},
+	}
+	flags = escalateCmd.PersistentFlags()
+	flags.StringVar(&grafanaOnCallEscalationOptions.Title, "grafana-oncall-escalation-title", grafanaOnCallEscalationOptions.Title, "Grafana OnCall escalation title")
+	flags.StringVar(&grafanaOnCallEscalationOptions.Message, "grafana-oncall-escalation-message", grafanaOnCallEscalationOptions.Message, "Grafana OnCall escalation message content or path")
+	flags.StringVar(&grafanaOnCallEscalationOptions.SourceURL, "grafana-oncall-escalation-source-url", grafanaOnCallEscalationOptions.SourceURL, "Grafana OnCall escalation source URL")
+	flags.BoolVar(&grafanaOnCallEscalationOptions.Important, "grafana-oncall-escalation-important", grafanaOnCallEscalationOptions.Important, "Grafana OnCall escalation uses important notification policy")
+	flags.StringVar(&grafanaOnCallEscalationOptions.Team, "grafana-oncall-escalation-team", grafanaOnCallEscalationOptions.Team, "Grafana OnCall escalation team ID")
+	flags.StringSliceVar(&grafanaOnCallEscalationOptions.Users, "grafana-oncall-escalation-users", grafanaOnCallEscalationOptions.Users, "Grafana OnCall escalation user IDs")
+	flags.StringVar(&grafanaOnCallEscalationOptions.AlertGroup, "grafana-oncall-alert-group", grafanaOnCallEscalationOptions.AlertGroup, "Grafana OnCall alert group ID to add users to")
+	grafanaOnCallCmd.AddCommand(idempotentCommand(escalateCmd))
+
+	alertGroupCmd := &cobra.Command{
+		Use:   "alert-group",
+		Short: "Alert group methods",
+	}
+	flags = alertGroupCmd.PersistentFlags()
+	flags.StringVar(&grafanaOnCallAlertGroupOptions.ID, "grafana-oncall-alert-group", grafanaOnCallAlertGroupOptions.ID, "Grafana OnCall alert group ID")
+	grafanaOnCallCmd.AddCommand(idempotentCommand(alertGroupCmd))
+
+	alertGroupCmd.AddCommand(&cobra.Command{
+		Use:   "acknowledge",
+		Short: "Acknowledge alert group",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("GrafanaOnCall acknowledging alert group %s...", grafanaOnCallAlertGroupOptions.ID)
+
+			bytes, err := idempotent("", grafanaOnCallAlertGroupOptions, func() ([]byte, error) {
+				return grafanaOnCallNew(stdout).AcknowledgeAlertGroup(grafanaOnCallAlertGroupOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(grafanaOnCallOutput, "GrafanaOnCall", []interface{}{grafanaOnCallOptions, grafanaOnCallAlertGroupOptions}, bytes, stdout)
+		},
+	})
+
+	alertGroupCmd.AddCommand(&cobra.Command{
+		Use:   "resolve",
+		Short: "Resolve alert group",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("GrafanaOnCall resolving alert group %s...", grafanaOnCallAlertGroupOptions.ID)
+
+			bytes, err := idempotent("", grafanaOnCallAlertGroupOptions, func() ([]byte, error) {
+				return grafanaOnCallNew(stdout).ResolveAlertGroup(grafanaOnCallAlertGroupOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(grafanaOnCallOutput, "GrafanaOnCall", []interface{}{grafanaOnCallOptions, grafanaOnCallAlertGroupOptions}, bytes, stdout)
+		},
+	})
+
+	onCallCmd := &cobra.Command{
+		Use:   "get-oncall",
+		Short: "Get users on-call now of schedule",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("GrafanaOnCall getting on-call of %s...", grafanaOnCallScheduleOptions.Schedule)
+
+			bytes, err := grafanaOnCallNew(stdout).GetOnCall(grafanaOnCallScheduleOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(grafanaOnCallOutput, "GrafanaOnCall", []interface{}{grafanaOnCallOptions, grafanaOnCallScheduleOptions}, bytes, stdout)
+		},
+	}
+	flags = onCallCmd.PersistentFlags()
+	flags.StringVar(&grafanaOnCallScheduleOptions.Schedule, "grafana-oncall-schedule", grafanaOnCallScheduleOptions.Schedule, "Grafana OnCall schedule name or ID")
+	grafanaOnCallCmd.AddCommand(onCallCmd)
+
+	return grafanaOnCallCmd
+}
+
+func init() {
+	registerVendor("grafanaoncall", vendorGroupIncident, NewGrafanaOnCallCommand, grafanaOnCallNew)
+	registerVendorEnricher("grafanaoncall", enrichmentGrafanaOnCall)
+}
@@ -1,6 +1,11 @@
+//go:build !minimal || logs || graylog
+
 package cmd
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/devopsext/tools/common"
 	"github.com/devopsext/tools/vendors"
 	"github.com/devopsext/utils"
@@ -23,6 +28,19 @@ var graylogOptions = vendors.GraylogOptions{
 	Range:     envGet("GRAYLOG_RANGE", "").(string),
 }
 
+var graylogSavedSearchOptions = vendors.GraylogSavedSearchOptions{
+	ID: envGet("GRAYLOG_SAVED_SEARCH_ID", "").(string),
+}
+
+var graylogGELFOptions = vendors.GraylogGELFOptions{
+	URL:          envGet("GRAYLOG_GELF_URL", "").(string),
+	Host:         envGet("GRAYLOG_GELF_HOST", "").(string),
+	ShortMessage: envGet("GRAYLOG_GELF_SHORT_MESSAGE", "").(string),
+	FullMessage:  envGet("GRAYLOG_GELF_FULL_MESSAGE", "").(string),
+	Level:        envGet("GRAYLOG_GELF_LEVEL", 6).(int),
+	Fields:       strings.Split(envGet("GRAYLOG_GELF_FIELDS", "").(string), ","),
+}
+
 var graylogOutput = common.OutputOptions{
 	Output: envGet("GRAYLOG_OUTPUT", "").(string),
 	Query:  envGet("GRAYLOG_OUTPUT_QUERY", "").(string),
@@ -57,7 +75,8 @@ func NewGraylogCommand() *cobra.Command {
 	flags.StringVar(&graylogOptions.Password, "graylog-password", graylogOptions.Password, "Graylog password")
 	flags.StringVar(&graylogOptions.Streams, "graylog-streams", graylogOptions.Streams, "Graylog streams")
 	flags.StringVar(&graylogOptions.Query, "graylog-query", graylogOptions.Query, "Graylog query")
-	flags.StringVar(&graylogOptions.RangeType, "graylog-range-type", graylogOptions.RangeType, "Graylog range type")
+	flags.StringVar(&graylogOptions.RangeType, "graylog-range-type", graylogOptions.RangeType, "Graylog range type: relative, absolute")
+	flags.StringVar(&graylogOptions.Range, "graylog-range", graylogOptions.Range, "Graylog relative range in seconds")
 	flags.StringVar(&graylogOptions.Sort, "graylog-sort", graylogOptions.Sort, "Graylog sort")
 	flags.IntVar(&graylogOptions.Limit, "graylog-limit", graylogOptions.Limit, "Graylog limit")
 	flags.StringVar(&graylogOptions.From, "graylog-from", graylogOptions.From, "Graylog from time")
@@ -80,5 +99,100 @@ func NewGraylogCommand() *cobra.Command {
 		},
 	})
 
+	searchSavedCmd := &cobra.Command{
+		Use:   "search-saved",
+		Short: "Run saved search, its time range is overridden by range or from and to",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Graylog running saved search %s...", graylogSavedSearchOptions.ID)
+			common.Debug("Graylog", graylogSavedSearchOptions, stdout)
+
+			bytes, err := graylogNew(stdout).SearchSaved(graylogSavedSearchOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(graylogOutput, "Graylog", []interface{}{graylogOptions, graylogSavedSearchOptions}, bytes, stdout)
+		},
+	}
+	flags = searchSavedCmd.PersistentFlags()
+	flags.StringVar(&graylogSavedSearchOptions.ID, "graylog-saved-search-id", graylogSavedSearchOptions.ID, "Graylog saved search ID")
+	graylogCmd.AddCommand(searchSavedCmd)
+
+	sendGELFCmd := &cobra.Command{
+		Use:   "send-gelf",
+		Short: "Send GELF message",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Graylog sending GELF message to %s...", graylogGELFOptions.URL)
+			common.Debug("Graylog", graylogGELFOptions, stdout)
+
+			fullBytes, err := utils.Content(graylogGELFOptions.FullMessage)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			graylogGELFOptions.FullMessage = string(fullBytes)
+
+			if !hooksPreSend(stdout, "graylog", &graylogGELFOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", graylogGELFOptions, func() ([]byte, error) {
+				return graylogNew(stdout).SendGELF(graylogGELFOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "graylog", bytes)
+			common.OutputJson(graylogOutput, "Graylog", []interface{}{graylogOptions, graylogGELFOptions}, bytes, stdout)
+		},
+	}
+	flags = sendGELFCmd.PersistentFlags()
+	flags.StringVar(&graylogGELFOptions.URL, "graylog-gelf-url", graylogGELFOptions.URL, "Graylog GELF input URL: http://host:12201/gelf, udp://host:12201 or tcp://host:12201")
+	flags.StringVar(&graylogGELFOptions.Host, "graylog-gelf-host", graylogGELFOptions.Host, "Graylog GELF host, hostname if empty")
+	flags.StringVar(&graylogGELFOptions.ShortMessage, "graylog-gelf-short-message", graylogGELFOptions.ShortMessage, "Graylog GELF short message")
+	flags.StringVar(&graylogGELFOptions.FullMessage, "graylog-gelf-full-message", graylogGELFOptions.FullMessage, "Graylog GELF full message, content or file")
+	flags.IntVar(&graylogGELFOptions.Level, "graylog-gelf-level", graylogGELFOptions.Level, "Graylog GELF syslog level: 0 emergency ... 7 debug")
+	flags.StringSliceVar(&graylogGELFOptions.Fields, "graylog-gelf-fields", graylogGELFOptions.Fields, "Graylog GELF additional fields as key=value")
+	graylogCmd.AddCommand(idempotentCommand(sendGELFCmd))
+
 	return &graylogCmd
 }
+
+// enrichmentGraylog gets logs by query or saved search, relative range is in seconds, e.g. logs around alert
+func enrichmentGraylog(params map[string]string) (interface{}, error) {
+
+	opts := graylogOptions
+	if !utils.IsEmpty(params["url"]) {
+		opts.URL = params["url"]
+	}
+	for k, v := range map[string]*string{"streams": &opts.Streams, "query": &opts.Query, "sort": &opts.Sort,
+		"range": &opts.Range, "from": &opts.From, "to": &opts.To} {
+		if !utils.IsEmpty(params[k]) {
+			*v = params[k]
+		}
+	}
+	if !utils.IsEmpty(params["range"]) {
+		opts.RangeType = "relative"
+	} else if !utils.IsEmpty(params["from"]) {
+		opts.RangeType = "absolute"
+	}
+	if !utils.IsEmpty(params["limit"]) {
+		limit, err := strconv.Atoi(params["limit"])
+		if err != nil {
+			return nil, err
+		}
+		opts.Limit = limit
+	}
+	graylog := vendors.NewGraylog(opts)
+	if !utils.IsEmpty(params["saved"]) {
+		return enrichmentJson(graylog.SearchSaved(vendors.GraylogSavedSearchOptions{ID: params["saved"]}))
+	}
+	return enrichmentJson(graylog.GetLogs())
+}
+
+func init() {
+	registerVendor("graylog", vendorGroupLogs, NewGraylogCommand, graylogNew)
+	registerVendorEnricher("graylog", enrichmentGraylog)
+}
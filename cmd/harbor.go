@@ -0,0 +1,118 @@
+//go:build !minimal || ci || harbor
+
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+var harborOptions = vendors.HarborOptions{
+	Timeout:  envGet("HARBOR_TIMEOUT", 30).(int),
+	Insecure: envGet("HARBOR_INSECURE", false).(bool),
+	URL:      envGet("HARBOR_URL", "").(string),
+	User:     envGet("HARBOR_USER", "").(string),
+	Password: envGet("HARBOR_PASSWORD", "").(string),
+}
+
+var harborReplicationOptions = vendors.HarborReplicationOptions{
+	PolicyID: envGet("HARBOR_REPLICATION_POLICY_ID", 0).(int),
+}
+
+var harborRetentionOptions = vendors.HarborRetentionOptions{
+	Project:     envGet("HARBOR_RETENTION_PROJECT", "").(string),
+	RetentionID: envGet("HARBOR_RETENTION_ID", 0).(int),
+	DryRun:      envGet("HARBOR_RETENTION_DRY_RUN", false).(bool),
+}
+
+var harborOutput = common.OutputOptions{
+	Output: envGet("HARBOR_OUTPUT", "").(string),
+	Query:  envGet("HARBOR_OUTPUT_QUERY", "").(string),
+}
+
+func harborNew(stdout *common.Stdout) *vendors.Harbor {
+
+	common.Debug("Harbor", harborOptions, stdout)
+	common.Debug("Harbor", harborOutput, stdout)
+
+	return vendors.NewHarbor(harborOptions)
+}
+
+func NewHarborCommand() *cobra.Command {
+
+	harborCmd := &cobra.Command{
+		Use:   "harbor",
+		Short: "Harbor tools",
+	}
+	flags := harborCmd.PersistentFlags()
+	flags.IntVar(&harborOptions.Timeout, "harbor-timeout", harborOptions.Timeout, "Harbor timeout in seconds")
+	flags.BoolVar(&harborOptions.Insecure, "harbor-insecure", harborOptions.Insecure, "Harbor insecure")
+	flags.StringVar(&harborOptions.URL, "harbor-url", harborOptions.URL, "Harbor URL")
+	flags.StringVar(&harborOptions.User, "harbor-user", harborOptions.User, "Harbor user or robot account")
+	flags.StringVar(&harborOptions.Password, "harbor-password", harborOptions.Password, "Harbor password or robot secret")
+	flags.StringVar(&harborOutput.Output, "harbor-output", harborOutput.Output, "Harbor output")
+	flags.StringVar(&harborOutput.Query, "harbor-output-query", harborOutput.Query, "Harbor output query")
+
+	replicationCmd := &cobra.Command{
+		Use:   "start-replication",
+		Short: "Start replication of policy",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Harbor starting replication...")
+			common.Debug("Harbor", harborReplicationOptions, stdout)
+
+			if !hooksPreSend(stdout, "harbor", &harborReplicationOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", harborReplicationOptions, func() ([]byte, error) {
+				return harborNew(stdout).StartReplication(harborReplicationOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "harbor", bytes)
+			common.OutputJson(harborOutput, "Harbor", []interface{}{harborOptions, harborReplicationOptions}, bytes, stdout)
+		},
+	}
+	flags = replicationCmd.PersistentFlags()
+	flags.IntVar(&harborReplicationOptions.PolicyID, "harbor-replication-policy-id", harborReplicationOptions.PolicyID, "Harbor replication policy ID")
+	harborCmd.AddCommand(idempotentCommand(replicationCmd))
+
+	retentionCmd := &cobra.Command{
+		Use:   "start-retention",
+		Short: "Start tag retention of project",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Harbor starting retention...")
+			common.Debug("Harbor", harborRetentionOptions, stdout)
+
+			if !hooksPreSend(stdout, "harbor", &harborRetentionOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", harborRetentionOptions, func() ([]byte, error) {
+				return harborNew(stdout).StartRetention(harborRetentionOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "harbor", bytes)
+			common.OutputJson(harborOutput, "Harbor", []interface{}{harborOptions, harborRetentionOptions}, bytes, stdout)
+		},
+	}
+	flags = retentionCmd.PersistentFlags()
+	flags.StringVar(&harborRetentionOptions.Project, "harbor-retention-project", harborRetentionOptions.Project, "Harbor project name to take retention policy from")
+	flags.IntVar(&harborRetentionOptions.RetentionID, "harbor-retention-id", harborRetentionOptions.RetentionID, "Harbor retention policy ID, project is used if empty")
+	flags.BoolVar(&harborRetentionOptions.DryRun, "harbor-retention-dry-run", harborRetentionOptions.DryRun, "Harbor retention dry run")
+	harborCmd.AddCommand(idempotentCommand(retentionCmd))
+
+	return harborCmd
+}
+
+func init() {
+	registerVendor("harbor", vendorGroupCI, NewHarborCommand, harborNew)
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+var hooksOptions = common.HooksOptions{
+	File: envGet("HOOKS_FILE", "").(string),
+}
+
+var hooks *common.Hooks
+
+func hooksNew(stdout *common.Stdout) *common.Hooks {
+
+	if hooks != nil || utils.IsEmpty(hooksOptions.File) {
+		return hooks
+	}
+	common.Debug("Hooks", hooksOptions, stdout)
+
+	h, err := common.NewHooks(hooksOptions, stdout)
+	if err != nil {
+		stdout.Panic(err)
+	}
+	hooks = h
+	return hooks
+}
+
+// hooksPreSend runs pre_send hook on message options, false means sending is skipped
+func hooksPreSend(stdout *common.Stdout, vendor string, message interface{}) bool {
+
+	h := hooksNew(stdout)
+	if h == nil {
+		return true
+	}
+
+	send, err := h.PreSend(vendor, message)
+	if err != nil {
+		stdout.Panic(err)
+	}
+	if !send {
+		stdout.Debug("Hooks skipped %s sending", vendor)
+	}
+	return send
+}
+
+func hooksPostResponse(stdout *common.Stdout, vendor string, bytes []byte) []byte {
+
+	h := hooksNew(stdout)
+	if h == nil {
+		return bytes
+	}
+
+	r, err := h.PostResponse(vendor, bytes)
+	if err != nil {
+		stdout.Panic(err)
+	}
+	return r
+}
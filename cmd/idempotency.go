@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var idempotencyOptions = common.IdempotencyOptions{
+	Key:     envGet("IDEMPOTENCY_KEY", "").(string),
+	Store:   envGet("IDEMPOTENCY_STORE", "").(string),
+	TTL:     envGet("IDEMPOTENCY_TTL", 86400).(int),
+	Timeout: envGet("IDEMPOTENCY_TIMEOUT", 5).(int),
+}
+
+// idempotencyCommand is path of command, keys are of commands, so that the same key can be used by steps of job
+var idempotencyCommand string
+
+// idempotencyAnnotation marks commands which operations are idempotent by key
+const idempotencyAnnotation = "idempotent"
+
+// idempotentCommand marks command of operations wrapped by idempotent
+func idempotentCommand(cmd *cobra.Command) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[idempotencyAnnotation] = "true"
+	return cmd
+}
+
+func idempotencyStart(cmd *cobra.Command) {
+	idempotencyCommand = strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	if !utils.IsEmpty(idempotencyOptions.Key) && cmd.Annotations[idempotencyAnnotation] != "true" {
+		stdout.Warn("Idempotency key is ignored by %s, its operations are run again on retry", idempotencyCommand)
+	}
+}
+
+// idempotencyPendingTTL limits reservation of operation which isn't done, e.g. of killed command,
+// so that its key isn't blocked for TTL of done operations
+const idempotencyPendingTTL = 10 * time.Minute
+
+// idempotent runs operation of key once, response of operation which is done is returned again without running it,
+// e.g. by retried CI job, operation is run as is if there is no key or of dry run, which doesn't do it,
+// key is reserved before operation, so that concurrent commands don't run it twice, and it fails if key is of
+// other options, e.g. of other step having the same pipeline id, failures of store are warnings only
+func idempotent(item string, options interface{}, fn func() ([]byte, error)) ([]byte, error) {
+
+	if utils.IsEmpty(idempotencyOptions.Key) || httpClientOptions.DryRun {
+		return fn()
+	}
+	key := fmt.Sprintf("%s:%s", idempotencyCommand, idempotencyOptions.Key)
+	if !utils.IsEmpty(item) {
+		key = fmt.Sprintf("%s:%s", key, item)
+	}
+	hash, err := common.IdempotencyOptionsHash(options)
+	if err != nil {
+		return nil, err
+	}
+	store, err := common.NewIdempotencyStore(idempotencyOptions)
+	if err != nil {
+		return nil, err
+	}
+	ttl := time.Duration(idempotencyOptions.TTL) * time.Second
+	pendingTTL := idempotencyPendingTTL
+	if ttl < pendingTTL {
+		pendingTTL = ttl
+	}
+
+	reserved, err := store.Reserve(&common.IdempotencyEntry{Time: time.Now(), Key: key, Hash: hash, Pending: true}, pendingTTL)
+	if err != nil {
+		stdout.Warn("Idempotency store reserve of %s failed: %s", key, err)
+		return fn()
+	}
+	if !reserved {
+		entry, err := store.Get(key)
+		if err != nil {
+			return nil, fmt.Errorf("idempotency store get of %s failed: %s", key, err)
+		}
+		switch {
+		case entry == nil:
+			return nil, fmt.Errorf("operation %s is reserved by other command, it should be retried", key)
+		case entry.Hash != hash:
+			return nil, fmt.Errorf("idempotency key %s is of operation of other options, key should be unique of operation", key)
+		case entry.Pending:
+			return nil, fmt.Errorf("operation %s is in progress since %s, it should be retried", key, entry.Time.Format(time.RFC3339))
+		}
+		stdout.Info("Operation %s is done at %s, it's skipped", key, entry.Time.Format(time.RFC3339))
+		return entry.Response, nil
+	}
+
+	b, err := fn()
+	if err != nil {
+		if derr := store.Delete(key); derr != nil {
+			stdout.Warn("Idempotency store delete of %s failed: %s", key, derr)
+		}
+		return b, err
+	}
+	entry := &common.IdempotencyEntry{Time: time.Now(), Key: key, Hash: hash}
+	if json.Valid(b) {
+		entry.Response = b
+	} else if len(b) > 0 {
+		entry.Response, _ = json.Marshal(string(b))
+	}
+	if err := store.Put(entry, ttl); err != nil {
+		stdout.Warn("Idempotency store put of %s failed: %s", key, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devopsext/tools/common"
+)
+
+func TestIdempotent(t *testing.T) {
+
+	prevOptions, prevCommand, prevStdout := idempotencyOptions, idempotencyCommand, stdout
+	t.Cleanup(func() {
+		idempotencyOptions, idempotencyCommand, stdout = prevOptions, prevCommand, prevStdout
+	})
+	idempotencyOptions = common.IdempotencyOptions{Key: "42", Store: t.TempDir(), TTL: 60}
+	idempotencyCommand = "slack send-message"
+	stdout = common.NewStdout(common.StdoutOptions{Level: "panic"})
+
+	calls := 0
+	send := func(text string) ([]byte, error) {
+		return idempotent("", map[string]string{"text": text}, func() ([]byte, error) {
+			calls++
+			return []byte(`{"ok":true}`), nil
+		})
+	}
+
+	// failed operation isn't recorded, so that it's retried
+	if _, err := idempotent("", map[string]string{"text": "a"}, func() ([]byte, error) {
+		return nil, errors.New("failed")
+	}); err == nil {
+		t.Fatal("expected error of operation")
+	}
+	if b, err := send("a"); err != nil || string(b) != `{"ok":true}` || calls != 1 {
+		t.Fatalf("expected operation, got %s %v of %d calls", b, err, calls)
+	}
+	if b, err := send("a"); err != nil || string(b) != `{"ok":true}` || calls != 1 {
+		t.Fatalf("expected skipped operation, got %s %v of %d calls", b, err, calls)
+	}
+
+	// the same key of other options fails instead of skipping
+	if _, err := send("b"); err == nil || calls != 1 {
+		t.Fatalf("expected error of other options, got %v of %d calls", err, calls)
+	}
+}
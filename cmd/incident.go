@@ -0,0 +1,498 @@
+//go:build !minimal || ((incident || (jira && pagerduty)) && (cloud || google))
+
+package cmd
+
+import (
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/render"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+type IncidentOptions struct {
+	Key                 string
+	Title               string
+	Summary             string
+	Severity            string
+	Channel             string
+	Private             bool
+	Template            string
+	Duration            int
+	TimeZone            string
+	JiraProject         string
+	JiraType            string
+	JiraPriority        string
+	PagerDutyRoutingKey string
+	PagerDutySource     string
+	DryRun              bool
+}
+
+// IncidentStep is resource of vendor which is created, found by idempotency key or planned on dry run
+type IncidentStep struct {
+	Vendor string `json:"vendor"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type IncidentReport struct {
+	Key      string                   `json:"key"`
+	Title    string                   `json:"title"`
+	Summary  string                   `json:"summary,omitempty"`
+	Severity string                   `json:"severity"`
+	DryRun   bool                     `json:"dry_run"`
+	Failed   int                      `json:"failed"`
+	Steps    map[string]*IncidentStep `json:"steps"`
+}
+
+const (
+	incidentStepChannel   = "channel"
+	incidentStepBridge    = "bridge"
+	incidentStepTicket    = "ticket"
+	incidentStepPage      = "page"
+	incidentStepKickoff   = "kickoff"
+	incidentStatusCreated = "created"
+	incidentStatusExists  = "existing"
+	incidentStatusPlanned = "planned"
+	incidentStatusSkipped = "skipped"
+	incidentStatusFailed  = "failed"
+)
+
+const incidentKickoffTemplate = `:rotating_light: *{{ .Title }}* ({{ .Severity }})
+{{ if .Summary }}{{ .Summary }}
+{{ end }}{{ with .Steps.bridge }}{{ if .URL }}Bridge: {{ .URL }}
+{{ end }}{{ end }}{{ with .Steps.ticket }}{{ if .URL }}Ticket: {{ .URL }}
+{{ end }}{{ end }}{{ with .Steps.page }}{{ if .ID }}Page: {{ .ID }}{{ end }}{{ end }}`
+
+var incidentOptions = IncidentOptions{
+	Key:                 envGet("INCIDENT_KEY", "").(string),
+	Title:               envGet("INCIDENT_TITLE", "").(string),
+	Summary:             envGet("INCIDENT_SUMMARY", "").(string),
+	Severity:            envGet("INCIDENT_SEVERITY", "critical").(string),
+	Channel:             envGet("INCIDENT_CHANNEL", "").(string),
+	Private:             envGet("INCIDENT_PRIVATE", false).(bool),
+	Template:            envGet("INCIDENT_TEMPLATE", "").(string),
+	Duration:            envGet("INCIDENT_DURATION", 60).(int),
+	TimeZone:            envGet("INCIDENT_TIMEZONE", "UTC").(string),
+	JiraProject:         envGet("INCIDENT_JIRA_PROJECT", "").(string),
+	JiraType:            envGet("INCIDENT_JIRA_TYPE", "Task").(string),
+	JiraPriority:        envGet("INCIDENT_JIRA_PRIORITY", "").(string),
+	PagerDutyRoutingKey: envGet("INCIDENT_PAGERDUTY_ROUTING_KEY", pagerDutyEventOptions.RoutingKey).(string),
+	PagerDutySource:     envGet("INCIDENT_PAGERDUTY_SOURCE", "tools").(string),
+	DryRun:              envGet("INCIDENT_DRY_RUN", false).(bool),
+}
+
+var incidentOutput = common.OutputOptions{
+	Output: envGet("INCIDENT_OUTPUT", "").(string),
+	Query:  envGet("INCIDENT_OUTPUT_QUERY", "").(string),
+}
+
+var incidentKeyReplacer = regexp.MustCompile(`[^a-z0-9]+`)
+
+// incidentKey is idempotency key, it's title if key isn't set, so that open of the same incident again finds resources
+func incidentKey() (string, error) {
+
+	key := incidentOptions.Key
+	if utils.IsEmpty(key) {
+		key = incidentOptions.Title
+	}
+	key = strings.Trim(incidentKeyReplacer.ReplaceAllString(strings.ToLower(key), "-"), "-")
+	if len(key) > 60 {
+		key = strings.Trim(key[:60], "-")
+	}
+	if utils.IsEmpty(key) {
+		return "", errors.New("no incident key or title")
+	}
+	return key, nil
+}
+
+// incidentEventID is Google Calendar event ID of key, it allows base32hex chars only
+func incidentEventID(key string) string {
+
+	sum := sha1.Sum([]byte("incident/" + key))
+	return strings.ToLower(base32.HexEncoding.WithPadding(base32.NoPadding).EncodeToString(sum[:]))
+}
+
+func incidentChannelName(key string) string {
+
+	name := incidentOptions.Channel
+	if utils.IsEmpty(name) {
+		name = "inc-" + key
+	}
+	// Slack channel names are limited by 80 chars
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	return name
+}
+
+func incidentFail(step *IncidentStep, err error) *IncidentStep {
+
+	step.Status = incidentStatusFailed
+	step.Error = err.Error()
+	return step
+}
+
+func incidentChannel(key string) *IncidentStep {
+
+	step := &IncidentStep{Vendor: "slack", Name: incidentChannelName(key)}
+	if utils.IsEmpty(slackOptions.Token) {
+		step.Status = incidentStatusSkipped
+		return step
+	}
+	if incidentOptions.DryRun {
+		step.Status = incidentStatusPlanned
+		return step
+	}
+
+	if err := secretsVendor("slack"); err != nil {
+		return incidentFail(step, err)
+	}
+	slack := vendors.NewSlack(slackOptions)
+	channelOptions := vendors.SlackChannelOptions{
+		Name:    step.Name,
+		Private: incidentOptions.Private,
+	}
+	step.Status = incidentStatusExists
+	b, err := slack.GetChannel(channelOptions)
+	if err != nil {
+		return incidentFail(step, err)
+	}
+	if b == nil {
+		step.Status = incidentStatusCreated
+		b, err = slack.CreateChannel(channelOptions)
+		if err != nil {
+			return incidentFail(step, err)
+		}
+	}
+	var c vendors.SlackChannel
+	if err := json.Unmarshal(b, &c); err != nil {
+		return incidentFail(step, err)
+	}
+	step.ID = c.ID
+	return step
+}
+
+func incidentBridge(stdout *common.Stdout, key string) *IncidentStep {
+
+	step := &IncidentStep{Vendor: "google", ID: incidentEventID(key), Name: incidentOptions.Title}
+	if utils.IsEmpty(googleOptions.RefreshToken) || utils.IsEmpty(googleCalendarOptions.ID) {
+		step.Status = incidentStatusSkipped
+		return step
+	}
+	if incidentOptions.DryRun {
+		step.Status = incidentStatusPlanned
+		return step
+	}
+
+	if err := secretsVendor("google"); err != nil {
+		return incidentFail(step, err)
+	}
+	google := vendors.NewGoogle(googleOptions, stdout.Module("google"))
+	step.Status = incidentStatusExists
+	b, err := google.CalendarGetEvent(googleCalendarOptions, vendors.GoogleCalendarGetEventOptions{ID: step.ID})
+	if err != nil {
+		return incidentFail(step, err)
+	}
+	if b == nil {
+		step.Status = incidentStatusCreated
+		start := time.Now().UTC()
+		b, err = google.CalendarInsertEvent(googleCalendarOptions, vendors.GoogleCalendarInsertEventOptions{
+			ID:          step.ID,
+			Summary:     incidentOptions.Title,
+			Description: incidentOptions.Summary,
+			Start:       start.Format(time.RFC3339),
+			End:         start.Add(time.Duration(incidentOptions.Duration) * time.Minute).Format(time.RFC3339),
+			TimeZone:    incidentOptions.TimeZone,
+			Visibility:  "default",
+			SendUpdates: "all",
+		})
+		if err != nil {
+			return incidentFail(step, err)
+		}
+	}
+	var e vendors.GoogleCalendarEvent
+	if err := json.Unmarshal(b, &e); err != nil {
+		return incidentFail(step, err)
+	}
+	step.URL = e.HangoutLink
+	return step
+}
+
+func incidentTicket(key string) *IncidentStep {
+
+	label := "incident-" + key
+	step := &IncidentStep{Vendor: "jira", Name: incidentOptions.Title}
+	if utils.IsEmpty(jiraOptions.URL) || utils.IsEmpty(incidentOptions.JiraProject) {
+		step.Status = incidentStatusSkipped
+		return step
+	}
+	if incidentOptions.DryRun {
+		step.Status = incidentStatusPlanned
+		return step
+	}
+
+	if err := secretsVendor("jira"); err != nil {
+		return incidentFail(step, err)
+	}
+	jira := vendors.NewJira(jiraOptions)
+	step.Status = incidentStatusExists
+	b, err := jira.SearchIssue(vendors.JiraSearchIssueOptions{
+		SearchPattern: fmt.Sprintf("project = \"%s\" AND labels = \"%s\"", incidentOptions.JiraProject, label),
+		MaxResults:    1,
+		Fields:        []string{"key"},
+	})
+	if err != nil {
+		return incidentFail(step, err)
+	}
+	var search struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	if err := json.Unmarshal(b, &search); err != nil {
+		return incidentFail(step, err)
+	}
+
+	if len(search.Issues) > 0 {
+		step.ID = search.Issues[0].Key
+	} else {
+		step.Status = incidentStatusCreated
+		b, err = jira.CreateIssue(vendors.JiraIssueOptions{
+			ProjectKey:  incidentOptions.JiraProject,
+			Type:        incidentOptions.JiraType,
+			Priority:    incidentOptions.JiraPriority,
+			Summary:     incidentOptions.Title,
+			Description: incidentOptions.Summary,
+			Labels:      []string{label},
+		})
+		if err != nil {
+			return incidentFail(step, err)
+		}
+		var issue struct {
+			Key string `json:"key"`
+		}
+		if err := json.Unmarshal(b, &issue); err != nil {
+			return incidentFail(step, err)
+		}
+		step.ID = issue.Key
+	}
+	step.URL = strings.TrimRight(jiraOptions.URL, "/") + "/browse/" + step.ID
+	return step
+}
+
+// incidentPage triggers event with key as dedup key, PagerDuty adds the same event to open incident
+func incidentPage(stdout *common.Stdout, key string) *IncidentStep {
+
+	step := &IncidentStep{Vendor: "pagerduty", ID: key, Name: incidentOptions.Title}
+	if utils.IsEmpty(incidentOptions.PagerDutyRoutingKey) {
+		step.Status = incidentStatusSkipped
+		return step
+	}
+	if incidentOptions.DryRun {
+		step.Status = incidentStatusPlanned
+		return step
+	}
+
+	details := ""
+	if !utils.IsEmpty(incidentOptions.Summary) {
+		b, err := json.Marshal(map[string]string{"summary": incidentOptions.Summary})
+		if err != nil {
+			return incidentFail(step, err)
+		}
+		details = string(b)
+	}
+	if err := secretsVendor("pagerduty"); err != nil {
+		return incidentFail(step, err)
+	}
+	step.Status = incidentStatusCreated
+	_, err := vendors.NewPagerDuty(pagerDutyOptions, stdout.Module("pagerduty")).SendEvent(vendors.PagerDutyEventOptions{
+		RoutingKey:    incidentOptions.PagerDutyRoutingKey,
+		Action:        "trigger",
+		DedupKey:      key,
+		Summary:       incidentOptions.Title,
+		Source:        incidentOptions.PagerDutySource,
+		Severity:      incidentOptions.Severity,
+		CustomDetails: details,
+	})
+	if err != nil {
+		return incidentFail(step, err)
+	}
+	return step
+}
+
+// incidentKickoff posts message to channel once it's created, message of existing channel is already there
+func incidentKickoff(stdout *common.Stdout, report *IncidentReport) *IncidentStep {
+
+	channel := report.Steps[incidentStepChannel]
+	step := &IncidentStep{Vendor: "slack", Name: channel.Name}
+	switch channel.Status {
+	case incidentStatusPlanned:
+		step.Status = incidentStatusPlanned
+		return step
+	case incidentStatusCreated:
+	default:
+		step.Status = incidentStatusSkipped
+		return step
+	}
+
+	content := incidentKickoffTemplate
+	if !utils.IsEmpty(incidentOptions.Template) {
+		b, err := utils.Content(incidentOptions.Template)
+		if err != nil {
+			return incidentFail(step, err)
+		}
+		content = string(b)
+	}
+	tpl, err := render.NewTextTemplate(render.TemplateOptions{
+		Name:       "incident",
+		Content:    content,
+		TimeFormat: time.RFC3339,
+	}, stdout)
+	if err != nil {
+		return incidentFail(step, err)
+	}
+	text, err := tpl.RenderObject(report)
+	if err != nil {
+		return incidentFail(step, err)
+	}
+
+	if err := secretsVendor("slack"); err != nil {
+		return incidentFail(step, err)
+	}
+	step.Status = incidentStatusCreated
+	b, err := vendors.NewSlack(slackOptions).SendMessage(vendors.SlackMessageOptions{
+		Channel: channel.ID,
+		Text:    string(text),
+	})
+	if err != nil {
+		return incidentFail(step, err)
+	}
+	var r vendors.SlackMessageResponse
+	if err := json.Unmarshal(b, &r); err != nil {
+		return incidentFail(step, err)
+	}
+	step.ID = r.TS
+	return step
+}
+
+// incidentOpen creates resources of incident by vendors which are configured, each of them is found by key first,
+// steps don't stop on failure of others, kickoff message is the last one to have links of others
+func incidentOpen(stdout *common.Stdout) (*IncidentReport, error) {
+
+	if utils.IsEmpty(incidentOptions.Title) {
+		return nil, errors.New("no incident title")
+	}
+	key, err := incidentKey()
+	if err != nil {
+		return nil, err
+	}
+	summary, err := utils.Content(incidentOptions.Summary)
+	if err != nil {
+		return nil, err
+	}
+	incidentOptions.Summary = string(summary)
+
+	report := &IncidentReport{
+		Key:      key,
+		Title:    incidentOptions.Title,
+		Summary:  incidentOptions.Summary,
+		Severity: incidentOptions.Severity,
+		DryRun:   incidentOptions.DryRun,
+		Steps:    make(map[string]*IncidentStep),
+	}
+	report.Steps[incidentStepChannel] = incidentChannel(key)
+	report.Steps[incidentStepBridge] = incidentBridge(stdout, key)
+	report.Steps[incidentStepTicket] = incidentTicket(key)
+	report.Steps[incidentStepPage] = incidentPage(stdout, key)
+	report.Steps[incidentStepKickoff] = incidentKickoff(stdout, report)
+
+	for _, s := range report.Steps {
+		if s.Status == incidentStatusFailed {
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+func NewIncidentCommand() *cobra.Command {
+
+	incidentCmd := &cobra.Command{
+		Use:   "incident",
+		Short: "Incident tools across vendors",
+	}
+	flags := incidentCmd.PersistentFlags()
+	flags.StringVar(&incidentOutput.Output, "incident-output", incidentOutput.Output, "Incident output")
+	flags.StringVar(&incidentOutput.Query, "incident-output-query", incidentOutput.Query, "Incident output query")
+
+	openCmd := &cobra.Command{
+		Use:   "open",
+		Short: "Open incident: Slack channel with kickoff message, Google Calendar bridge, Jira ticket and PagerDuty page",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			// steps of global dry run are planned as well, as their requests depend on each other
+			incidentOptions.DryRun = incidentOptions.DryRun || httpClientOptions.DryRun
+			stdout.Debug("Incident opening %s...", incidentOptions.Title)
+			common.Debug("Incident", incidentOptions, stdout)
+			common.Debug("Incident", incidentOutput, stdout)
+
+			report, err := incidentOpen(stdout)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes, err := json.Marshal(report)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(incidentOutput, "Incident", []interface{}{incidentOptions}, bytes, stdout)
+
+			if report.Failed > 0 {
+				failed := []string{}
+				for name, s := range report.Steps {
+					if s.Status == incidentStatusFailed {
+						failed = append(failed, name)
+					}
+				}
+				stdout.Error("Incident failed for %s", strings.Join(failed, ", "))
+			}
+		},
+	}
+	flags = openCmd.PersistentFlags()
+	flags.StringVar(&incidentOptions.Key, "incident-key", incidentOptions.Key, "Incident idempotency key, title if empty, resources of key are reused by next runs")
+	flags.StringVar(&incidentOptions.Title, "incident-title", incidentOptions.Title, "Incident title")
+	flags.StringVar(&incidentOptions.Summary, "incident-summary", incidentOptions.Summary, "Incident summary content or path")
+	flags.StringVar(&incidentOptions.Severity, "incident-severity", incidentOptions.Severity, "Incident severity: critical, error, warning, info")
+	flags.StringVar(&incidentOptions.Channel, "incident-channel", incidentOptions.Channel, "Incident Slack channel name, inc-<key> if empty")
+	flags.BoolVar(&incidentOptions.Private, "incident-private", incidentOptions.Private, "Incident Slack channel is private")
+	flags.StringVar(&incidentOptions.Template, "incident-template", incidentOptions.Template, "Incident kickoff message template content or path, rendered with report")
+	flags.IntVar(&incidentOptions.Duration, "incident-duration", incidentOptions.Duration, "Incident bridge event duration in minutes")
+	flags.StringVar(&incidentOptions.TimeZone, "incident-timezone", incidentOptions.TimeZone, "Incident bridge event timezone")
+	flags.StringVar(&incidentOptions.JiraProject, "incident-jira-project", incidentOptions.JiraProject, "Incident Jira project key, no ticket if empty")
+	flags.StringVar(&incidentOptions.JiraType, "incident-jira-type", incidentOptions.JiraType, "Incident Jira issue type")
+	flags.StringVar(&incidentOptions.JiraPriority, "incident-jira-priority", incidentOptions.JiraPriority, "Incident Jira issue priority")
+	flags.StringVar(&incidentOptions.PagerDutyRoutingKey, "incident-pagerduty-routing-key", incidentOptions.PagerDutyRoutingKey, "Incident PagerDuty routing key, no page if empty")
+	flags.StringVar(&incidentOptions.PagerDutySource, "incident-pagerduty-source", incidentOptions.PagerDutySource, "Incident PagerDuty event source")
+	flags.BoolVar(&incidentOptions.DryRun, "incident-dry-run", incidentOptions.DryRun, "Incident steps are planned only, nothing is created")
+	// steps of incident are found by its key before they're created, so that it's idempotent itself
+	incidentCmd.AddCommand(idempotentCommand(openCmd))
+
+	return incidentCmd
+}
+
+func init() {
+	registerVendorCommand(NewIncidentCommand)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+// interactive mode prompts for required options which aren't set by flags, env vars or config
+var interactive = envGet("INTERACTIVE", false).(bool)
+
+// interactiveRequired is annotation of flags which are required by vendor, it's not cobra's one,
+// as options of env vars and config don't mark flags as changed
+const interactiveRequired = "tools_required"
+
+// requiredFlags marks flags of vendor options which vendor fails without, e.g. token or channel
+func requiredFlags(flags *pflag.FlagSet, names ...string) {
+
+	for _, name := range names {
+		flags.SetAnnotation(name, interactiveRequired, []string{"true"})
+	}
+}
+
+func flagRequired(f *pflag.Flag) bool {
+	_, ok := f.Annotations[interactiveRequired]
+	return ok
+}
+
+// flagEmpty returns true if string or slice flag has no value
+func flagEmpty(f *pflag.Flag) bool {
+
+	switch f.Value.Type() {
+	case "string":
+		return strings.TrimSpace(f.Value.String()) == ""
+	case "stringSlice", "stringArray":
+		return strings.Trim(f.Value.String(), "[], ") == ""
+	}
+	return false
+}
+
+// interactivePrompt reads value of flag from terminal, input of secrets, e.g. tokens, isn't echoed
+func interactivePrompt(reader *bufio.Reader, f *pflag.Flag) (string, error) {
+
+	secret := common.HttpSecretName(f.Name)
+	if secret {
+		fmt.Fprintf(os.Stderr, "%s (--%s, hidden): ", f.Usage, f.Name)
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	fmt.Fprintf(os.Stderr, "%s (--%s): ", f.Usage, f.Name)
+	s, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// interactiveResolve prompts for required flags of command which are empty, empty input keeps flag empty,
+// e.g. channel which is set by service catalog later
+func interactiveResolve(cmd *cobra.Command) error {
+
+	if !interactive {
+		return nil
+	}
+	flags := []*pflag.Flag{}
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if flagRequired(f) && flagEmpty(f) {
+			flags = append(flags, f)
+		}
+	})
+	if len(flags) == 0 {
+		return nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("interactive mode needs terminal, flags aren't set: --%s", flagNames(flags))
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for _, f := range flags {
+		value, err := interactivePrompt(reader, f)
+		if err != nil {
+			return fmt.Errorf("flag %s: %s", f.Name, err)
+		}
+		if value == "" {
+			continue
+		}
+		if err := cmd.Flags().Set(f.Name, value); err != nil {
+			return fmt.Errorf("flag %s: %s", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func flagNames(flags []*pflag.Flag) string {
+
+	names := []string{}
+	for _, f := range flags {
+		names = append(names, f.Name)
+	}
+	return strings.Join(names, ", --")
+}
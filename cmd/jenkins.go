@@ -0,0 +1,174 @@
+//go:build !minimal || ci || jenkins
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var jenkinsOptions = vendors.JenkinsOptions{
+	Timeout:  envGet("JENKINS_TIMEOUT", 30).(int),
+	Insecure: envGet("JENKINS_INSECURE", false).(bool),
+	URL:      envGet("JENKINS_URL", "").(string),
+	User:     envGet("JENKINS_USER", "").(string),
+	Token:    envGet("JENKINS_TOKEN", "").(string),
+}
+
+var jenkinsJobOptions = vendors.JenkinsJobOptions{
+	Job: envGet("JENKINS_JOB", "").(string),
+}
+
+var jenkinsBuildOptions = vendors.JenkinsBuildOptions{
+	Params:       jenkinsParams(envGet("JENKINS_BUILD_PARAMS", "").(string)),
+	Wait:         envGet("JENKINS_BUILD_WAIT", false).(bool),
+	WaitTimeout:  envGet("JENKINS_BUILD_WAIT_TIMEOUT", 3600).(int),
+	PollInterval: envGet("JENKINS_BUILD_POLL_INTERVAL", 5).(int),
+}
+
+var jenkinsBuildStatusOptions = vendors.JenkinsBuildStatusOptions{
+	Number: envGet("JENKINS_BUILD_NUMBER", 0).(int),
+}
+
+var jenkinsOutput = common.OutputOptions{
+	Output: envGet("JENKINS_OUTPUT", "").(string),
+	Query:  envGet("JENKINS_OUTPUT_QUERY", "").(string),
+}
+
+// jenkinsParams splits env parameters by new line, so that values may have commas
+func jenkinsParams(s string) []string {
+
+	r := []string{}
+	for _, p := range strings.Split(s, "\n") {
+		if !utils.IsEmpty(strings.TrimSpace(p)) {
+			r = append(r, strings.TrimSpace(p))
+		}
+	}
+	return r
+}
+
+func jenkinsNew(stdout *common.Stdout) *vendors.Jenkins {
+
+	common.Debug("Jenkins", jenkinsOptions, stdout)
+	common.Debug("Jenkins", jenkinsOutput, stdout)
+
+	return vendors.NewJenkins(jenkinsOptions, stdout.Module("jenkins"))
+}
+
+func NewJenkinsCommand() *cobra.Command {
+
+	jenkinsCmd := &cobra.Command{
+		Use:   "jenkins",
+		Short: "Jenkins tools",
+	}
+	flags := jenkinsCmd.PersistentFlags()
+	flags.IntVar(&jenkinsOptions.Timeout, "jenkins-timeout", jenkinsOptions.Timeout, "Jenkins timeout in seconds")
+	flags.BoolVar(&jenkinsOptions.Insecure, "jenkins-insecure", jenkinsOptions.Insecure, "Jenkins insecure")
+	flags.StringVar(&jenkinsOptions.URL, "jenkins-url", jenkinsOptions.URL, "Jenkins URL")
+	flags.StringVar(&jenkinsOptions.User, "jenkins-user", jenkinsOptions.User, "Jenkins user")
+	flags.StringVar(&jenkinsOptions.Token, "jenkins-token", jenkinsOptions.Token, "Jenkins API token or password")
+	flags.StringVar(&jenkinsJobOptions.Job, "jenkins-job", jenkinsJobOptions.Job, "Jenkins job, folders are separated by slash, e.g. team/deploy")
+	flags.StringVar(&jenkinsOutput.Output, "jenkins-output", jenkinsOutput.Output, "Jenkins output")
+	flags.StringVar(&jenkinsOutput.Query, "jenkins-output-query", jenkinsOutput.Query, "Jenkins output query")
+
+	buildCmd := &cobra.Command{
+		Use:   "build",
+		Short: "Trigger build with parameters",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Jenkins triggering build of %s...", jenkinsJobOptions.Job)
+			common.Debug("Jenkins", jenkinsBuildOptions, stdout)
+
+			if !hooksPreSend(stdout, "jenkins", &jenkinsBuildOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", []interface{}{jenkinsJobOptions, jenkinsBuildOptions}, func() ([]byte, error) {
+				return jenkinsNew(stdout).TriggerBuild(jenkinsJobOptions, jenkinsBuildOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "jenkins", bytes)
+			common.OutputJson(jenkinsOutput, "Jenkins", []interface{}{jenkinsOptions, jenkinsJobOptions, jenkinsBuildOptions}, bytes, stdout)
+		},
+	}
+	flags = buildCmd.PersistentFlags()
+	flags.StringArrayVar(&jenkinsBuildOptions.Params, "jenkins-build-param", jenkinsBuildOptions.Params, "Jenkins build parameter key=value, can be repeated")
+	flags.BoolVar(&jenkinsBuildOptions.Wait, "jenkins-build-wait", jenkinsBuildOptions.Wait, "Jenkins wait for build completion")
+	flags.IntVar(&jenkinsBuildOptions.WaitTimeout, "jenkins-build-wait-timeout", jenkinsBuildOptions.WaitTimeout, "Jenkins wait timeout in seconds")
+	flags.IntVar(&jenkinsBuildOptions.PollInterval, "jenkins-build-poll-interval", jenkinsBuildOptions.PollInterval, "Jenkins build poll interval in seconds")
+	jenkinsCmd.AddCommand(idempotentCommand(buildCmd))
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Get build status",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Jenkins getting build status of %s...", jenkinsJobOptions.Job)
+
+			bytes, err := jenkinsNew(stdout).GetBuildStatus(jenkinsJobOptions, jenkinsBuildStatusOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(jenkinsOutput, "Jenkins", []interface{}{jenkinsOptions, jenkinsJobOptions, jenkinsBuildStatusOptions}, bytes, stdout)
+		},
+	}
+	flags = statusCmd.PersistentFlags()
+	flags.IntVar(&jenkinsBuildStatusOptions.Number, "jenkins-build-number", jenkinsBuildStatusOptions.Number, "Jenkins build number, the last build if empty")
+	jenkinsCmd.AddCommand(statusCmd)
+
+	waitCmd := &cobra.Command{
+		Use:   "wait",
+		Short: "Wait for build completion",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Jenkins waiting for build of %s...", jenkinsJobOptions.Job)
+
+			bytes, err := idempotent("", []interface{}{jenkinsJobOptions, jenkinsBuildStatusOptions, jenkinsBuildOptions}, func() ([]byte, error) {
+				return jenkinsNew(stdout).WaitForBuild(jenkinsJobOptions, jenkinsBuildStatusOptions, jenkinsBuildOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(jenkinsOutput, "Jenkins", []interface{}{jenkinsOptions, jenkinsJobOptions, jenkinsBuildStatusOptions}, bytes, stdout)
+		},
+	}
+	flags = waitCmd.PersistentFlags()
+	flags.IntVar(&jenkinsBuildStatusOptions.Number, "jenkins-build-number", jenkinsBuildStatusOptions.Number, "Jenkins build number, the last build if empty")
+	flags.IntVar(&jenkinsBuildOptions.WaitTimeout, "jenkins-build-wait-timeout", jenkinsBuildOptions.WaitTimeout, "Jenkins wait timeout in seconds")
+	flags.IntVar(&jenkinsBuildOptions.PollInterval, "jenkins-build-poll-interval", jenkinsBuildOptions.PollInterval, "Jenkins build poll interval in seconds")
+	jenkinsCmd.AddCommand(idempotentCommand(waitCmd))
+
+	logCmd := &cobra.Command{
+		Use:   "log",
+		Short: "Get build console log",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Jenkins getting console log of %s...", jenkinsJobOptions.Job)
+
+			bytes, err := jenkinsNew(stdout).GetConsoleLog(jenkinsJobOptions, jenkinsBuildStatusOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputRaw(jenkinsOutput.Output, bytes, stdout)
+		},
+	}
+	flags = logCmd.PersistentFlags()
+	flags.IntVar(&jenkinsBuildStatusOptions.Number, "jenkins-build-number", jenkinsBuildStatusOptions.Number, "Jenkins build number, the last build if empty")
+	jenkinsCmd.AddCommand(logCmd)
+
+	return jenkinsCmd
+}
+
+func init() {
+	registerVendor("jenkins", vendorGroupCI, NewJenkinsCommand, jenkinsNew)
+}
@@ -1,3 +1,5 @@
+//go:build !minimal || incident || jira
+
 package cmd
 
 import (
@@ -119,7 +121,9 @@ func NewJiraCommand() *cobra.Command {
 			}
 			JiraIssueOptions.Description = string(descriptionBytes)
 
-			bytes, err := jiraNew(stdout).CreateIssue(JiraIssueOptions)
+			bytes, err := idempotent("", JiraIssueOptions, func() ([]byte, error) {
+				return jiraNew(stdout).CreateIssue(JiraIssueOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -133,7 +137,7 @@ func NewJiraCommand() *cobra.Command {
 	flags.StringVar(&JiraIssueOptions.Priority, "jira-issue-priority", JiraIssueOptions.Priority, "Jira issue priority")
 	flags.StringVar(&JiraIssueOptions.Assignee, "jira-issue-assignee", JiraIssueOptions.Assignee, "Jira issue assignee")
 	flags.StringVar(&JiraIssueOptions.Reporter, "jira-issue-reporter", JiraIssueOptions.Reporter, "Jira issue reporter")
-	issueCmd.AddCommand(issueCreateCmd)
+	issueCmd.AddCommand(idempotentCommand(issueCreateCmd))
 
 	// tools jira issue add-comment --jira-params --issue-params --add-comment-params
 	issueAddCommentCmd := &cobra.Command{
@@ -151,7 +155,9 @@ func NewJiraCommand() *cobra.Command {
 			}
 			jiraIssueAddCommentOptions.Body = string(bodyBytes)
 
-			bytes, err := jiraNew(stdout).IssueAddComment(JiraIssueOptions, jiraIssueAddCommentOptions)
+			bytes, err := idempotent("", []interface{}{JiraIssueOptions, jiraIssueAddCommentOptions}, func() ([]byte, error) {
+				return jiraNew(stdout).IssueAddComment(JiraIssueOptions, jiraIssueAddCommentOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -161,7 +167,7 @@ func NewJiraCommand() *cobra.Command {
 	}
 	flags = issueAddCommentCmd.PersistentFlags()
 	flags.StringVar(&jiraIssueAddCommentOptions.Body, "jira-issue-comment-body", jiraIssueAddCommentOptions.Body, "Jira issue comment body")
-	issueCmd.AddCommand(issueAddCommentCmd)
+	issueCmd.AddCommand(idempotentCommand(issueAddCommentCmd))
 
 	// tools jira issue add-attachment --jira-params --issue-params --add-attachment-params
 	issueAddAttachmentCmd := &cobra.Command{
@@ -183,7 +189,9 @@ func NewJiraCommand() *cobra.Command {
 			}
 			jiraIssueAddAttachmentOptions.File = string(fileBytes)
 
-			bytes, err := jiraNew(stdout).AddIssueAttachment(JiraIssueOptions, jiraIssueAddAttachmentOptions)
+			bytes, err := idempotent("", []interface{}{JiraIssueOptions, jiraIssueAddAttachmentOptions}, func() ([]byte, error) {
+				return jiraNew(stdout).AddIssueAttachment(JiraIssueOptions, jiraIssueAddAttachmentOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -194,7 +202,7 @@ func NewJiraCommand() *cobra.Command {
 	flags = issueAddAttachmentCmd.PersistentFlags()
 	flags.StringVar(&jiraIssueAddAttachmentOptions.File, "jira-issue-attachment-file", jiraIssueAddAttachmentOptions.File, "Jira issue attachment file")
 	flags.StringVar(&jiraIssueAddAttachmentOptions.Name, "jira-issue-attachment-name", jiraIssueAddAttachmentOptions.Name, "Jira issue attachment name")
-	issueCmd.AddCommand(issueAddAttachmentCmd)
+	issueCmd.AddCommand(idempotentCommand(issueAddAttachmentCmd))
 
 	// tools jira issue update --jira-params --issue-params
 	issueUpdateCmd := &cobra.Command{
@@ -211,7 +219,9 @@ func NewJiraCommand() *cobra.Command {
 			}
 			JiraIssueOptions.Description = string(descriptionBytes)
 
-			bytes, err := jiraNew(stdout).UpdateIssue(JiraIssueOptions)
+			bytes, err := idempotent("", JiraIssueOptions, func() ([]byte, error) {
+				return jiraNew(stdout).UpdateIssue(JiraIssueOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -219,7 +229,7 @@ func NewJiraCommand() *cobra.Command {
 			common.OutputJson(jiraOutput, "Jira", []interface{}{jiraOptions, JiraIssueOptions}, bytes, stdout)
 		},
 	}
-	issueCmd.AddCommand(issueUpdateCmd)
+	issueCmd.AddCommand(idempotentCommand(issueUpdateCmd))
 
 	// tools jira issue change-transitions --jira-params --issue-params
 	issueChangeTransitionsCmd := &cobra.Command{
@@ -235,7 +245,9 @@ func NewJiraCommand() *cobra.Command {
 			}
 			JiraIssueOptions.TransitionID = string(statusBytes)
 
-			bytes, err := jiraNew(stdout).ChangeIssueTransitions(JiraIssueOptions)
+			bytes, err := idempotent("", JiraIssueOptions, func() ([]byte, error) {
+				return jiraNew(stdout).ChangeIssueTransitions(JiraIssueOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -245,7 +257,7 @@ func NewJiraCommand() *cobra.Command {
 	}
 	flags = issueChangeTransitionsCmd.PersistentFlags()
 	flags.StringVar(&JiraIssueOptions.TransitionID, "jira-issue-status", JiraIssueOptions.TransitionID, "Jira issue status")
-	issueCmd.AddCommand(issueChangeTransitionsCmd)
+	issueCmd.AddCommand(idempotentCommand(issueChangeTransitionsCmd))
 
 	issueSearchCmd := &cobra.Command{
 		Use:   "search",
@@ -313,7 +325,9 @@ func NewJiraCommand() *cobra.Command {
 			stdout.Debug("Jira asset creating...")
 			common.Debug("Jira", jiraAssetCreateOptions, stdout)
 
-			bytes, err := jiraNew(stdout).CreateAsset(jiraAssetCreateOptions)
+			bytes, err := idempotent("", jiraAssetCreateOptions, func() ([]byte, error) {
+				return jiraNew(stdout).CreateAsset(jiraAssetCreateOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -325,7 +339,7 @@ func NewJiraCommand() *cobra.Command {
 	flags.StringVar(&jiraAssetCreateOptions.Name, "jira-asset-create-name", jiraAssetCreateOptions.Name, "Jira asset name")
 	flags.StringVar(&jiraAssetCreateOptions.Description, "jira-asset-create-rdescription", jiraAssetCreateOptions.Description, "Jira asset description")
 	// ... all options should be added, like value, etc.
-	assetCmd.AddCommand(assetCreateCmd)
+	assetCmd.AddCommand(idempotentCommand(assetCreateCmd))
 
 	assetUpdateCmd := &cobra.Command{
 		Use:   "update",
@@ -340,7 +354,9 @@ func NewJiraCommand() *cobra.Command {
 			}
 			jiraAssetUpdateOptions.Json = string(jsonBytes)
 
-			bytes, err := jiraNew(stdout).UpdateAsset(jiraAssetUpdateOptions)
+			bytes, err := idempotent("", jiraAssetUpdateOptions, func() ([]byte, error) {
+				return jiraNew(stdout).UpdateAsset(jiraAssetUpdateOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -351,7 +367,11 @@ func NewJiraCommand() *cobra.Command {
 	flags = assetUpdateCmd.PersistentFlags()
 	flags.StringVar(&jiraAssetUpdateOptions.ObjectId, "jira-asset-update-object-id", jiraAssetUpdateOptions.ObjectId, "Jira asset object id")
 	flags.StringVar(&jiraAssetUpdateOptions.Json, "jira-asset-update-json", jiraAssetUpdateOptions.Json, "Jira asset json")
-	assetCmd.AddCommand(assetUpdateCmd)
+	assetCmd.AddCommand(idempotentCommand(assetUpdateCmd))
 
 	return &jiraCmd
 }
+
+func init() {
+	registerVendor("jira", vendorGroupIncident, NewJiraCommand, jiraNew)
+}
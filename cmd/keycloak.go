@@ -0,0 +1,201 @@
+//go:build !minimal || identity || keycloak
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+var keycloakOptions = vendors.KeycloakOptions{
+	Timeout:      envGet("KEYCLOAK_TIMEOUT", 30).(int),
+	Insecure:     envGet("KEYCLOAK_INSECURE", false).(bool),
+	URL:          envGet("KEYCLOAK_URL", "").(string),
+	Realm:        envGet("KEYCLOAK_REALM", "").(string),
+	AuthRealm:    envGet("KEYCLOAK_AUTH_REALM", "master").(string),
+	ClientID:     envGet("KEYCLOAK_CLIENT_ID", "admin-cli").(string),
+	ClientSecret: envGet("KEYCLOAK_CLIENT_SECRET", "").(string),
+	User:         envGet("KEYCLOAK_USER", "").(string),
+	Password:     envGet("KEYCLOAK_PASSWORD", "").(string),
+	Token:        envGet("KEYCLOAK_TOKEN", "").(string),
+}
+
+var keycloakUserOptions = vendors.KeycloakUserOptions{
+	Username:      envGet("KEYCLOAK_USER_USERNAME", "").(string),
+	Email:         envGet("KEYCLOAK_USER_EMAIL", "").(string),
+	FirstName:     envGet("KEYCLOAK_USER_FIRST_NAME", "").(string),
+	LastName:      envGet("KEYCLOAK_USER_LAST_NAME", "").(string),
+	Enabled:       envGet("KEYCLOAK_USER_ENABLED", true).(bool),
+	EmailVerified: envGet("KEYCLOAK_USER_EMAIL_VERIFIED", false).(bool),
+	Password:      envGet("KEYCLOAK_USER_PASSWORD", "").(string),
+	Temporary:     envGet("KEYCLOAK_USER_TEMPORARY", true).(bool),
+	Attributes:    envGet("KEYCLOAK_USER_ATTRIBUTES", "").(string),
+	Groups:        strings.Split(envGet("KEYCLOAK_USER_GROUPS", "").(string), ","),
+}
+
+var keycloakRoleOptions = vendors.KeycloakRoleOptions{
+	User:   envGet("KEYCLOAK_ROLE_USER", "").(string),
+	Client: envGet("KEYCLOAK_ROLE_CLIENT", "").(string),
+	Roles:  strings.Split(envGet("KEYCLOAK_ROLE_ROLES", "").(string), ","),
+}
+
+var keycloakClientOptions = vendors.KeycloakClientOptions{
+	Client: envGet("KEYCLOAK_CLIENT", "").(string),
+}
+
+var keycloakExportOptions = vendors.KeycloakExportOptions{
+	Clients:        envGet("KEYCLOAK_EXPORT_CLIENTS", true).(bool),
+	GroupsAndRoles: envGet("KEYCLOAK_EXPORT_GROUPS_AND_ROLES", true).(bool),
+}
+
+var keycloakOutput = common.OutputOptions{
+	Output: envGet("KEYCLOAK_OUTPUT", "").(string),
+	Query:  envGet("KEYCLOAK_OUTPUT_QUERY", "").(string),
+}
+
+func keycloakNew(stdout *common.Stdout) *vendors.Keycloak {
+
+	common.Debug("Keycloak", keycloakOptions, stdout)
+	common.Debug("Keycloak", keycloakOutput, stdout)
+
+	return vendors.NewKeycloak(keycloakOptions)
+}
+
+func NewKeycloakCommand() *cobra.Command {
+
+	keycloakCmd := &cobra.Command{
+		Use:   "keycloak",
+		Short: "Keycloak tools",
+	}
+	flags := keycloakCmd.PersistentFlags()
+	flags.IntVar(&keycloakOptions.Timeout, "keycloak-timeout", keycloakOptions.Timeout, "Keycloak timeout in seconds")
+	flags.BoolVar(&keycloakOptions.Insecure, "keycloak-insecure", keycloakOptions.Insecure, "Keycloak insecure")
+	flags.StringVar(&keycloakOptions.URL, "keycloak-url", keycloakOptions.URL, "Keycloak URL, with /auth for legacy versions")
+	flags.StringVar(&keycloakOptions.Realm, "keycloak-realm", keycloakOptions.Realm, "Keycloak realm to administer")
+	flags.StringVar(&keycloakOptions.AuthRealm, "keycloak-auth-realm", keycloakOptions.AuthRealm, "Keycloak realm to login")
+	flags.StringVar(&keycloakOptions.ClientID, "keycloak-client-id", keycloakOptions.ClientID, "Keycloak client ID to login")
+	flags.StringVar(&keycloakOptions.ClientSecret, "keycloak-client-secret", keycloakOptions.ClientSecret, "Keycloak client secret, client credentials are used if there is no user")
+	flags.StringVar(&keycloakOptions.User, "keycloak-user", keycloakOptions.User, "Keycloak admin user")
+	flags.StringVar(&keycloakOptions.Password, "keycloak-password", keycloakOptions.Password, "Keycloak admin password")
+	flags.StringVar(&keycloakOptions.Token, "keycloak-token", keycloakOptions.Token, "Keycloak access token, login is skipped if set")
+	flags.StringVar(&keycloakOutput.Output, "keycloak-output", keycloakOutput.Output, "Keycloak output")
+	flags.StringVar(&keycloakOutput.Query, "keycloak-output-query", keycloakOutput.Query, "Keycloak output query")
+
+	createUserCmd := &cobra.Command{
+		Use:   "create-user",
+		Short: "Create user",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Keycloak creating user %s...", keycloakUserOptions.Username)
+			common.Debug("Keycloak", keycloakUserOptions, stdout)
+
+			if !hooksPreSend(stdout, "keycloak", &keycloakUserOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", keycloakUserOptions, func() ([]byte, error) {
+				return keycloakNew(stdout).CreateUser(keycloakUserOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "keycloak", bytes)
+			common.OutputJson(keycloakOutput, "Keycloak", []interface{}{keycloakOptions, keycloakUserOptions}, bytes, stdout)
+		},
+	}
+	flags = createUserCmd.PersistentFlags()
+	flags.StringVar(&keycloakUserOptions.Username, "keycloak-user-username", keycloakUserOptions.Username, "Keycloak username")
+	flags.StringVar(&keycloakUserOptions.Email, "keycloak-user-email", keycloakUserOptions.Email, "Keycloak user email")
+	flags.StringVar(&keycloakUserOptions.FirstName, "keycloak-user-first-name", keycloakUserOptions.FirstName, "Keycloak user first name")
+	flags.StringVar(&keycloakUserOptions.LastName, "keycloak-user-last-name", keycloakUserOptions.LastName, "Keycloak user last name")
+	flags.BoolVar(&keycloakUserOptions.Enabled, "keycloak-user-enabled", keycloakUserOptions.Enabled, "Keycloak user is enabled")
+	flags.BoolVar(&keycloakUserOptions.EmailVerified, "keycloak-user-email-verified", keycloakUserOptions.EmailVerified, "Keycloak user email is verified")
+	flags.StringVar(&keycloakUserOptions.Password, "keycloak-user-password", keycloakUserOptions.Password, "Keycloak user password")
+	flags.BoolVar(&keycloakUserOptions.Temporary, "keycloak-user-temporary", keycloakUserOptions.Temporary, "Keycloak user password is changed on first login")
+	flags.StringVar(&keycloakUserOptions.Attributes, "keycloak-user-attributes", keycloakUserOptions.Attributes, "Keycloak user attributes, key=value pairs")
+	flags.StringSliceVar(&keycloakUserOptions.Groups, "keycloak-user-groups", keycloakUserOptions.Groups, "Keycloak user group paths")
+	keycloakCmd.AddCommand(idempotentCommand(createUserCmd))
+
+	assignRolesCmd := &cobra.Command{
+		Use:   "assign-roles",
+		Short: "Assign realm or client roles to user",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Keycloak assigning roles %v to %s...", keycloakRoleOptions.Roles, keycloakRoleOptions.User)
+			common.Debug("Keycloak", keycloakRoleOptions, stdout)
+
+			if !hooksPreSend(stdout, "keycloak", &keycloakRoleOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", keycloakRoleOptions, func() ([]byte, error) {
+				return keycloakNew(stdout).AssignRoles(keycloakRoleOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "keycloak", bytes)
+			common.OutputJson(keycloakOutput, "Keycloak", []interface{}{keycloakOptions, keycloakRoleOptions}, bytes, stdout)
+		},
+	}
+	flags = assignRolesCmd.PersistentFlags()
+	flags.StringVar(&keycloakRoleOptions.User, "keycloak-role-user", keycloakRoleOptions.User, "Keycloak username or user ID")
+	flags.StringVar(&keycloakRoleOptions.Client, "keycloak-role-client", keycloakRoleOptions.Client, "Keycloak client ID of client roles, realm roles if empty")
+	flags.StringSliceVar(&keycloakRoleOptions.Roles, "keycloak-role-roles", keycloakRoleOptions.Roles, "Keycloak role names")
+	keycloakCmd.AddCommand(idempotentCommand(assignRolesCmd))
+
+	rotateSecretCmd := &cobra.Command{
+		Use:   "rotate-client-secret",
+		Short: "Rotate secret of confidential client",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Keycloak rotating secret of client %s...", keycloakClientOptions.Client)
+
+			if !hooksPreSend(stdout, "keycloak", &keycloakClientOptions) {
+				return
+			}
+
+			bytes, err := keycloakNew(stdout).RotateClientSecret(keycloakClientOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "keycloak", bytes)
+			common.OutputJson(keycloakOutput, "Keycloak", []interface{}{keycloakOptions, keycloakClientOptions}, bytes, stdout)
+		},
+	}
+	flags = rotateSecretCmd.PersistentFlags()
+	flags.StringVar(&keycloakClientOptions.Client, "keycloak-client", keycloakClientOptions.Client, "Keycloak client ID or internal ID")
+	keycloakCmd.AddCommand(rotateSecretCmd)
+
+	exportRealmCmd := &cobra.Command{
+		Use:   "export-realm",
+		Short: "Export realm with clients, groups and roles",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Keycloak exporting realm %s...", keycloakOptions.Realm)
+
+			bytes, err := keycloakNew(stdout).ExportRealm(keycloakExportOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(keycloakOutput, "Keycloak", []interface{}{keycloakOptions, keycloakExportOptions}, bytes, stdout)
+		},
+	}
+	flags = exportRealmCmd.PersistentFlags()
+	flags.BoolVar(&keycloakExportOptions.Clients, "keycloak-export-clients", keycloakExportOptions.Clients, "Keycloak exports clients")
+	flags.BoolVar(&keycloakExportOptions.GroupsAndRoles, "keycloak-export-groups-and-roles", keycloakExportOptions.GroupsAndRoles, "Keycloak exports groups and roles")
+	keycloakCmd.AddCommand(exportRealmCmd)
+
+	return keycloakCmd
+}
+
+func init() {
+	registerVendor("keycloak", vendorGroupIdentity, NewKeycloakCommand, keycloakNew)
+}
@@ -0,0 +1,163 @@
+//go:build !minimal || cloud || kubernetes
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+var kubernetesOptions = vendors.KubernetesOptions{
+	Timeout:    envGet("KUBERNETES_TIMEOUT", 30).(int),
+	Insecure:   envGet("KUBERNETES_INSECURE", false).(bool),
+	URL:        envGet("KUBERNETES_URL", "").(string),
+	Token:      envGet("KUBERNETES_TOKEN", "").(string),
+	Kubeconfig: envGet("KUBERNETES_KUBECONFIG", os.Getenv("KUBECONFIG")).(string),
+	Context:    envGet("KUBERNETES_CONTEXT", "").(string),
+}
+
+var kubernetesResourceOptions = vendors.KubernetesResourceOptions{
+	Namespace:     envGet("KUBERNETES_NAMESPACE", "").(string),
+	Name:          envGet("KUBERNETES_NAME", "").(string),
+	LabelSelector: envGet("KUBERNETES_LABEL_SELECTOR", "").(string),
+	FieldSelector: envGet("KUBERNETES_FIELD_SELECTOR", "").(string),
+}
+
+var kubernetesWaitOptions = vendors.KubernetesWaitOptions{
+	Wait:         envGet("KUBERNETES_WAIT", false).(bool),
+	WaitTimeout:  envGet("KUBERNETES_WAIT_TIMEOUT", 600).(int),
+	PollInterval: envGet("KUBERNETES_POLL_INTERVAL", 5).(int),
+}
+
+var kubernetesOutput = common.OutputOptions{
+	Output: envGet("KUBERNETES_OUTPUT", "").(string),
+	Query:  envGet("KUBERNETES_OUTPUT_QUERY", "").(string),
+}
+
+func kubernetesNew(stdout *common.Stdout) *vendors.Kubernetes {
+
+	common.Debug("Kubernetes", kubernetesOptions, stdout)
+	common.Debug("Kubernetes", kubernetesOutput, stdout)
+
+	return vendors.NewKubernetes(kubernetesOptions, stdout.Module("kubernetes"))
+}
+
+func NewKubernetesCommand() *cobra.Command {
+
+	kubernetesCmd := &cobra.Command{
+		Use:   "kubernetes",
+		Short: "Kubernetes tools",
+	}
+	flags := kubernetesCmd.PersistentFlags()
+	flags.IntVar(&kubernetesOptions.Timeout, "kubernetes-timeout", kubernetesOptions.Timeout, "Kubernetes timeout in seconds")
+	flags.BoolVar(&kubernetesOptions.Insecure, "kubernetes-insecure", kubernetesOptions.Insecure, "Kubernetes insecure")
+	flags.StringVar(&kubernetesOptions.URL, "kubernetes-url", kubernetesOptions.URL, "Kubernetes API URL, kubeconfig or in cluster config if empty")
+	flags.StringVar(&kubernetesOptions.Token, "kubernetes-token", kubernetesOptions.Token, "Kubernetes token")
+	flags.StringVar(&kubernetesOptions.Kubeconfig, "kubernetes-kubeconfig", kubernetesOptions.Kubeconfig, "Kubernetes kubeconfig file, ~/.kube/config if empty and not in cluster")
+	flags.StringVar(&kubernetesOptions.Context, "kubernetes-context", kubernetesOptions.Context, "Kubernetes kubeconfig context, current context if empty")
+	flags.StringVar(&kubernetesResourceOptions.Namespace, "kubernetes-namespace", kubernetesResourceOptions.Namespace, "Kubernetes namespace, namespace of context if empty")
+	flags.StringVar(&kubernetesOutput.Output, "kubernetes-output", kubernetesOutput.Output, "Kubernetes output")
+	flags.StringVar(&kubernetesOutput.Query, "kubernetes-output-query", kubernetesOutput.Query, "Kubernetes output query")
+
+	podsCmd := &cobra.Command{
+		Use:   "pods",
+		Short: "List pods of namespace",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Kubernetes getting pods...")
+			common.Debug("Kubernetes", kubernetesResourceOptions, stdout)
+
+			bytes, err := kubernetesNew(stdout).GetPods(kubernetesResourceOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(kubernetesOutput, "Kubernetes", []interface{}{kubernetesOptions, kubernetesResourceOptions}, bytes, stdout)
+		},
+	}
+	flags = podsCmd.PersistentFlags()
+	flags.StringVar(&kubernetesResourceOptions.LabelSelector, "kubernetes-label-selector", kubernetesResourceOptions.LabelSelector, "Kubernetes label selector, e.g. app=api")
+	flags.StringVar(&kubernetesResourceOptions.FieldSelector, "kubernetes-field-selector", kubernetesResourceOptions.FieldSelector, "Kubernetes field selector, e.g. status.phase!=Running")
+	kubernetesCmd.AddCommand(podsCmd)
+
+	eventsCmd := &cobra.Command{
+		Use:   "events",
+		Short: "List events of namespace or object",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Kubernetes getting events...")
+			common.Debug("Kubernetes", kubernetesResourceOptions, stdout)
+
+			bytes, err := kubernetesNew(stdout).GetEvents(kubernetesResourceOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(kubernetesOutput, "Kubernetes", []interface{}{kubernetesOptions, kubernetesResourceOptions}, bytes, stdout)
+		},
+	}
+	flags = eventsCmd.PersistentFlags()
+	flags.StringVar(&kubernetesResourceOptions.Name, "kubernetes-name", kubernetesResourceOptions.Name, "Kubernetes name of involved object")
+	flags.StringVar(&kubernetesResourceOptions.FieldSelector, "kubernetes-field-selector", kubernetesResourceOptions.FieldSelector, "Kubernetes field selector, e.g. type=Warning")
+	kubernetesCmd.AddCommand(eventsCmd)
+
+	rolloutStatusCmd := &cobra.Command{
+		Use:   "rollout-status",
+		Short: "Get deployment rollout status",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Kubernetes getting deployment %s rollout status...", kubernetesResourceOptions.Name)
+
+			bytes, err := kubernetesNew(stdout).GetRolloutStatus(kubernetesResourceOptions, kubernetesWaitOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(kubernetesOutput, "Kubernetes", []interface{}{kubernetesOptions, kubernetesResourceOptions}, bytes, stdout)
+		},
+	}
+	flags = rolloutStatusCmd.PersistentFlags()
+	flags.StringVar(&kubernetesResourceOptions.Name, "kubernetes-name", kubernetesResourceOptions.Name, "Kubernetes deployment name")
+	flags.BoolVar(&kubernetesWaitOptions.Wait, "kubernetes-wait", kubernetesWaitOptions.Wait, "Kubernetes wait for rollout to be complete")
+	flags.IntVar(&kubernetesWaitOptions.WaitTimeout, "kubernetes-wait-timeout", kubernetesWaitOptions.WaitTimeout, "Kubernetes wait timeout in seconds")
+	flags.IntVar(&kubernetesWaitOptions.PollInterval, "kubernetes-poll-interval", kubernetesWaitOptions.PollInterval, "Kubernetes deployment poll interval in seconds")
+	kubernetesCmd.AddCommand(rolloutStatusCmd)
+
+	restartCmd := &cobra.Command{
+		Use:   "restart",
+		Short: "Restart deployment",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Kubernetes restarting deployment %s...", kubernetesResourceOptions.Name)
+
+			if !hooksPreSend(stdout, "kubernetes", &kubernetesResourceOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", []interface{}{kubernetesResourceOptions, kubernetesWaitOptions}, func() ([]byte, error) {
+				return kubernetesNew(stdout).RestartDeployment(kubernetesResourceOptions, kubernetesWaitOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "kubernetes", bytes)
+			common.OutputJson(kubernetesOutput, "Kubernetes", []interface{}{kubernetesOptions, kubernetesResourceOptions}, bytes, stdout)
+		},
+	}
+	flags = restartCmd.PersistentFlags()
+	flags.StringVar(&kubernetesResourceOptions.Name, "kubernetes-name", kubernetesResourceOptions.Name, "Kubernetes deployment name")
+	flags.BoolVar(&kubernetesWaitOptions.Wait, "kubernetes-wait", kubernetesWaitOptions.Wait, "Kubernetes wait for rollout to be complete")
+	flags.IntVar(&kubernetesWaitOptions.WaitTimeout, "kubernetes-wait-timeout", kubernetesWaitOptions.WaitTimeout, "Kubernetes wait timeout in seconds")
+	flags.IntVar(&kubernetesWaitOptions.PollInterval, "kubernetes-poll-interval", kubernetesWaitOptions.PollInterval, "Kubernetes deployment poll interval in seconds")
+	kubernetesCmd.AddCommand(idempotentCommand(restartCmd))
+
+	return kubernetesCmd
+}
+
+func init() {
+	registerVendor("kubernetes", vendorGroupCloud, NewKubernetesCommand, kubernetesNew)
+}
@@ -0,0 +1,120 @@
+//go:build !minimal || config || launchdarkly
+
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+var launchDarklyOptions = vendors.LaunchDarklyOptions{
+	Timeout:  envGet("LAUNCHDARKLY_TIMEOUT", 30).(int),
+	Insecure: envGet("LAUNCHDARKLY_INSECURE", false).(bool),
+	URL:      envGet("LAUNCHDARKLY_URL", "https://app.launchdarkly.com").(string),
+	Token:    envGet("LAUNCHDARKLY_TOKEN", "").(string),
+}
+
+var launchDarklyFlagOptions = vendors.LaunchDarklyFlagOptions{
+	Project:     envGet("LAUNCHDARKLY_PROJECT", "default").(string),
+	Key:         envGet("LAUNCHDARKLY_FLAG", "").(string),
+	Environment: envGet("LAUNCHDARKLY_ENVIRONMENT", "").(string),
+	Comment:     envGet("LAUNCHDARKLY_COMMENT", "").(string),
+}
+
+var launchDarklyOutput = common.OutputOptions{
+	Output: envGet("LAUNCHDARKLY_OUTPUT", "").(string),
+	Query:  envGet("LAUNCHDARKLY_OUTPUT_QUERY", "").(string),
+}
+
+func launchDarklyNew(stdout *common.Stdout) *vendors.LaunchDarkly {
+
+	common.Debug("LaunchDarkly", launchDarklyOptions, stdout)
+	common.Debug("LaunchDarkly", launchDarklyOutput, stdout)
+
+	return vendors.NewLaunchDarkly(launchDarklyOptions, stdout.Module("launchdarkly"))
+}
+
+func NewLaunchDarklyCommand() *cobra.Command {
+
+	launchDarklyCmd := &cobra.Command{
+		Use:   "launchdarkly",
+		Short: "LaunchDarkly tools",
+	}
+	flags := launchDarklyCmd.PersistentFlags()
+	flags.IntVar(&launchDarklyOptions.Timeout, "launchdarkly-timeout", launchDarklyOptions.Timeout, "LaunchDarkly timeout in seconds")
+	flags.BoolVar(&launchDarklyOptions.Insecure, "launchdarkly-insecure", launchDarklyOptions.Insecure, "LaunchDarkly insecure")
+	flags.StringVar(&launchDarklyOptions.URL, "launchdarkly-url", launchDarklyOptions.URL, "LaunchDarkly URL")
+	flags.StringVar(&launchDarklyOptions.Token, "launchdarkly-token", launchDarklyOptions.Token, "LaunchDarkly API access token")
+	flags.StringVar(&launchDarklyOutput.Output, "launchdarkly-output", launchDarklyOutput.Output, "LaunchDarkly output")
+	flags.StringVar(&launchDarklyOutput.Query, "launchdarkly-output-query", launchDarklyOutput.Query, "LaunchDarkly output query")
+
+	flagCmd := &cobra.Command{
+		Use:   "flag",
+		Short: "Feature flag methods",
+	}
+	flags = flagCmd.PersistentFlags()
+	flags.StringVar(&launchDarklyFlagOptions.Project, "launchdarkly-project", launchDarklyFlagOptions.Project, "LaunchDarkly project key")
+	flags.StringVar(&launchDarklyFlagOptions.Key, "launchdarkly-flag", launchDarklyFlagOptions.Key, "LaunchDarkly flag key")
+	flags.StringVar(&launchDarklyFlagOptions.Environment, "launchdarkly-environment", launchDarklyFlagOptions.Environment, "LaunchDarkly environment key")
+	flags.StringVar(&launchDarklyFlagOptions.Comment, "launchdarkly-comment", launchDarklyFlagOptions.Comment, "LaunchDarkly change comment for audit log")
+	launchDarklyCmd.AddCommand(idempotentCommand(flagCmd))
+
+	flagCmd.AddCommand(&cobra.Command{
+		Use:   "get",
+		Short: "Get flag",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("LaunchDarkly getting flag %s...", launchDarklyFlagOptions.Key)
+
+			bytes, err := launchDarklyNew(stdout).GetFlag(launchDarklyFlagOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(launchDarklyOutput, "LaunchDarkly", []interface{}{launchDarklyOptions, launchDarklyFlagOptions}, bytes, stdout)
+		},
+	})
+
+	flagCmd.AddCommand(&cobra.Command{
+		Use:   "on",
+		Short: "Turn flag on",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("LaunchDarkly turning flag %s on...", launchDarklyFlagOptions.Key)
+
+			bytes, err := idempotent("", launchDarklyFlagOptions, func() ([]byte, error) {
+				return launchDarklyNew(stdout).TurnFlagOn(launchDarklyFlagOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(launchDarklyOutput, "LaunchDarkly", []interface{}{launchDarklyOptions, launchDarklyFlagOptions}, bytes, stdout)
+		},
+	})
+
+	flagCmd.AddCommand(&cobra.Command{
+		Use:   "off",
+		Short: "Turn flag off",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("LaunchDarkly turning flag %s off...", launchDarklyFlagOptions.Key)
+
+			bytes, err := idempotent("", launchDarklyFlagOptions, func() ([]byte, error) {
+				return launchDarklyNew(stdout).TurnFlagOff(launchDarklyFlagOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(launchDarklyOutput, "LaunchDarkly", []interface{}{launchDarklyOptions, launchDarklyFlagOptions}, bytes, stdout)
+		},
+	})
+
+	return launchDarklyCmd
+}
+
+func init() {
+	registerVendor("launchdarkly", vendorGroupConfig, NewLaunchDarklyCommand, launchDarklyNew)
+}
@@ -0,0 +1,192 @@
+//go:build !minimal || identity || ldap
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var ldapOptions = vendors.LDAPOptions{
+	Timeout:      envGet("LDAP_TIMEOUT", 30).(int),
+	Insecure:     envGet("LDAP_INSECURE", false).(bool),
+	URL:          envGet("LDAP_URL", "").(string),
+	StartTLS:     envGet("LDAP_START_TLS", false).(bool),
+	BindDN:       envGet("LDAP_BIND_DN", "").(string),
+	BindPassword: envGet("LDAP_BIND_PASSWORD", "").(string),
+	BaseDN:       envGet("LDAP_BASE_DN", "").(string),
+}
+
+var ldapSearchOptions = vendors.LDAPSearchOptions{
+	BaseDN:     envGet("LDAP_SEARCH_BASE_DN", "").(string),
+	Filter:     envGet("LDAP_SEARCH_FILTER", "").(string),
+	Scope:      envGet("LDAP_SEARCH_SCOPE", "sub").(string),
+	Attributes: strings.Split(envGet("LDAP_SEARCH_ATTRIBUTES", "").(string), ","),
+	SizeLimit:  envGet("LDAP_SEARCH_SIZE_LIMIT", 0).(int),
+}
+
+var ldapUserOptions = vendors.LDAPUserOptions{
+	User:       envGet("LDAP_USER", "").(string),
+	Filter:     envGet("LDAP_USER_FILTER", "").(string),
+	Attributes: strings.Split(envGet("LDAP_USER_ATTRIBUTES", "").(string), ","),
+}
+
+var ldapGroupsOptions = vendors.LDAPGroupsOptions{
+	Filter: envGet("LDAP_GROUPS_FILTER", "").(string),
+	Nested: envGet("LDAP_GROUPS_NESTED", false).(bool),
+}
+
+var ldapOutput = common.OutputOptions{
+	Output: envGet("LDAP_OUTPUT", "").(string),
+	Query:  envGet("LDAP_OUTPUT_QUERY", "").(string),
+}
+
+func ldapNew(stdout *common.Stdout) *vendors.LDAP {
+
+	common.Debug("LDAP", ldapOptions, stdout)
+	common.Debug("LDAP", ldapOutput, stdout)
+
+	return vendors.NewLDAP(ldapOptions)
+}
+
+// enrichmentLDAP gets user by email or account name, or groups of user if groups is true, e.g. to map alert owner to chat user
+func enrichmentLDAP(params map[string]string) (interface{}, error) {
+
+	opts := ldapOptions
+	if !utils.IsEmpty(params["url"]) {
+		opts.URL = params["url"]
+	}
+	if !utils.IsEmpty(params["base_dn"]) {
+		opts.BaseDN = params["base_dn"]
+	}
+	userOptions := vendors.LDAPUserOptions{
+		User:   params["user"],
+		Filter: params["filter"],
+	}
+	if !utils.IsEmpty(params["attributes"]) {
+		userOptions.Attributes = strings.Split(params["attributes"], ",")
+	}
+
+	ldap := vendors.NewLDAP(opts)
+	if params["groups"] == "true" {
+		groupsOptions := vendors.LDAPGroupsOptions{Nested: params["nested"] == "true"}
+		return enrichmentJson(ldap.GetGroups(userOptions, groupsOptions))
+	}
+	return enrichmentJson(ldap.GetUser(userOptions))
+}
+
+func NewLDAPCommand() *cobra.Command {
+
+	ldapCmd := &cobra.Command{
+		Use:   "ldap",
+		Short: "LDAP tools",
+	}
+	flags := ldapCmd.PersistentFlags()
+	flags.IntVar(&ldapOptions.Timeout, "ldap-timeout", ldapOptions.Timeout, "LDAP timeout in seconds")
+	flags.BoolVar(&ldapOptions.Insecure, "ldap-insecure", ldapOptions.Insecure, "LDAP insecure")
+	flags.StringVar(&ldapOptions.URL, "ldap-url", ldapOptions.URL, "LDAP URL, e.g. ldaps://dc.example.com:636")
+	flags.BoolVar(&ldapOptions.StartTLS, "ldap-start-tls", ldapOptions.StartTLS, "LDAP StartTLS for ldap:// URL")
+	flags.StringVar(&ldapOptions.BindDN, "ldap-bind-dn", ldapOptions.BindDN, "LDAP bind DN or user principal name, anonymous bind if empty")
+	flags.StringVar(&ldapOptions.BindPassword, "ldap-bind-password", ldapOptions.BindPassword, "LDAP bind password")
+	flags.StringVar(&ldapOptions.BaseDN, "ldap-base-dn", ldapOptions.BaseDN, "LDAP base DN")
+	flags.StringVar(&ldapOutput.Output, "ldap-output", ldapOutput.Output, "LDAP output")
+	flags.StringVar(&ldapOutput.Query, "ldap-output-query", ldapOutput.Query, "LDAP output query")
+
+	searchCmd := &cobra.Command{
+		Use:   "search",
+		Short: "Search entries",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("LDAP searching %s...", ldapSearchOptions.Filter)
+			common.Debug("LDAP", ldapSearchOptions, stdout)
+
+			bytes, err := ldapNew(stdout).Search(ldapSearchOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(ldapOutput, "LDAP", []interface{}{ldapOptions, ldapSearchOptions}, bytes, stdout)
+		},
+	}
+	flags = searchCmd.PersistentFlags()
+	flags.StringVar(&ldapSearchOptions.BaseDN, "ldap-search-base-dn", ldapSearchOptions.BaseDN, "LDAP search base DN, LDAP base DN if empty")
+	flags.StringVar(&ldapSearchOptions.Filter, "ldap-search-filter", ldapSearchOptions.Filter, "LDAP search filter, e.g. (objectClass=person)")
+	flags.StringVar(&ldapSearchOptions.Scope, "ldap-search-scope", ldapSearchOptions.Scope, "LDAP search scope: base, one, sub")
+	flags.StringSliceVar(&ldapSearchOptions.Attributes, "ldap-search-attributes", ldapSearchOptions.Attributes, "LDAP search attributes, all if empty")
+	flags.IntVar(&ldapSearchOptions.SizeLimit, "ldap-search-size-limit", ldapSearchOptions.SizeLimit, "LDAP search size limit, all pages if 0")
+	ldapCmd.AddCommand(searchCmd)
+
+	ldapCmd.AddCommand(&cobra.Command{
+		Use:   "whoami",
+		Short: "Get authorization identity of bind",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("LDAP getting identity of %s...", ldapOptions.BindDN)
+
+			bytes, err := ldapNew(stdout).WhoAmI()
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(ldapOutput, "LDAP", []interface{}{ldapOptions}, bytes, stdout)
+		},
+	})
+
+	userCmd := &cobra.Command{
+		Use:   "user",
+		Short: "Get user by email, user principal name, account name or uid",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("LDAP getting user %s...", ldapUserOptions.User)
+			common.Debug("LDAP", ldapUserOptions, stdout)
+
+			bytes, err := ldapNew(stdout).GetUser(ldapUserOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(ldapOutput, "LDAP", []interface{}{ldapOptions, ldapUserOptions}, bytes, stdout)
+		},
+	}
+	flags = userCmd.PersistentFlags()
+	flags.StringSliceVar(&ldapUserOptions.Attributes, "ldap-user-attributes", ldapUserOptions.Attributes, "LDAP user attributes, all if empty")
+	ldapCmd.AddCommand(userCmd)
+
+	groupsCmd := &cobra.Command{
+		Use:   "groups",
+		Short: "Get groups of user",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("LDAP getting groups of %s...", ldapUserOptions.User)
+			common.Debug("LDAP", ldapGroupsOptions, stdout)
+
+			bytes, err := ldapNew(stdout).GetGroups(ldapUserOptions, ldapGroupsOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(ldapOutput, "LDAP", []interface{}{ldapOptions, ldapUserOptions, ldapGroupsOptions}, bytes, stdout)
+		},
+	}
+	flags = groupsCmd.PersistentFlags()
+	flags.StringVar(&ldapGroupsOptions.Filter, "ldap-groups-filter", ldapGroupsOptions.Filter, "LDAP groups filter, %s is replaced by user DN")
+	flags.BoolVar(&ldapGroupsOptions.Nested, "ldap-groups-nested", ldapGroupsOptions.Nested, "LDAP groups are nested, Active Directory only")
+	ldapCmd.AddCommand(groupsCmd)
+
+	for _, c := range []*cobra.Command{userCmd, groupsCmd} {
+		flags = c.PersistentFlags()
+		flags.StringVar(&ldapUserOptions.User, "ldap-user", ldapUserOptions.User, "LDAP user email, user principal name, account name or uid")
+		flags.StringVar(&ldapUserOptions.Filter, "ldap-user-filter", ldapUserOptions.Filter, "LDAP user filter, %s is replaced by user")
+	}
+
+	return ldapCmd
+}
+
+func init() {
+	registerVendor("ldap", vendorGroupIdentity, NewLDAPCommand, ldapNew)
+	registerVendorEnricher("ldap", enrichmentLDAP)
+}
@@ -0,0 +1,142 @@
+//go:build !minimal || logs || loki
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var lokiOptions = vendors.LokiOptions{
+	Timeout:  envGet("LOKI_TIMEOUT", 30).(int),
+	Insecure: envGet("LOKI_INSECURE", false).(bool),
+	URL:      envGet("LOKI_URL", "").(string),
+	User:     envGet("LOKI_USER", "").(string),
+	Password: envGet("LOKI_PASSWORD", "").(string),
+	TenantID: envGet("LOKI_TENANT_ID", "").(string),
+}
+
+var lokiPushOptions = vendors.LokiPushOptions{
+	Labels: envGet("LOKI_PUSH_LABELS", "").(string),
+	Text:   envGet("LOKI_PUSH_TEXT", "").(string),
+}
+
+var lokiQueryOptions = vendors.LokiQueryOptions{
+	Query:     envGet("LOKI_QUERY", "").(string),
+	Since:     envGet("LOKI_QUERY_SINCE", "1h").(string),
+	From:      envGet("LOKI_QUERY_FROM", "").(string),
+	To:        envGet("LOKI_QUERY_TO", "").(string),
+	Limit:     envGet("LOKI_QUERY_LIMIT", 100).(int),
+	Direction: envGet("LOKI_QUERY_DIRECTION", "backward").(string),
+	Format:    envGet("LOKI_QUERY_FORMAT", "json").(string),
+}
+
+var lokiOutput = common.OutputOptions{
+	Output: envGet("LOKI_OUTPUT", "").(string),
+	Query:  envGet("LOKI_OUTPUT_QUERY", "").(string),
+}
+
+func lokiNew(stdout *common.Stdout) *vendors.Loki {
+
+	common.Debug("Loki", lokiOptions, stdout)
+	common.Debug("Loki", lokiOutput, stdout)
+
+	return vendors.NewLoki(lokiOptions)
+}
+
+func NewLokiCommand() *cobra.Command {
+
+	lokiCmd := &cobra.Command{
+		Use:   "loki",
+		Short: "Loki tools",
+	}
+	flags := lokiCmd.PersistentFlags()
+	flags.IntVar(&lokiOptions.Timeout, "loki-timeout", lokiOptions.Timeout, "Loki timeout in seconds")
+	flags.BoolVar(&lokiOptions.Insecure, "loki-insecure", lokiOptions.Insecure, "Loki insecure")
+	flags.StringVar(&lokiOptions.URL, "loki-url", lokiOptions.URL, "Loki URL")
+	flags.StringVar(&lokiOptions.User, "loki-user", lokiOptions.User, "Loki user")
+	flags.StringVar(&lokiOptions.Password, "loki-password", lokiOptions.Password, "Loki password")
+	flags.StringVar(&lokiOptions.TenantID, "loki-tenant-id", lokiOptions.TenantID, "Loki tenant ID for multi-tenant setup")
+	flags.StringVar(&lokiOutput.Output, "loki-output", lokiOutput.Output, "Loki output")
+	flags.StringVar(&lokiOutput.Query, "loki-output-query", lokiOutput.Query, "Loki output query")
+
+	pushCmd := &cobra.Command{
+		Use:   "push",
+		Short: "Push log lines with labels",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Loki pushing lines...")
+			common.Debug("Loki", lokiPushOptions, stdout)
+
+			textBytes, err := utils.Content(lokiPushOptions.Text)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			lokiPushOptions.Text = string(textBytes)
+
+			if !hooksPreSend(stdout, "loki", &lokiPushOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", lokiPushOptions, func() ([]byte, error) {
+				return lokiNew(stdout).Push(lokiPushOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "loki", bytes)
+			common.OutputJson(lokiOutput, "Loki", []interface{}{lokiOptions, lokiPushOptions}, bytes, stdout)
+		},
+	}
+	flags = pushCmd.PersistentFlags()
+	flags.StringVar(&lokiPushOptions.Labels, "loki-push-labels", lokiPushOptions.Labels, "Loki stream labels, e.g. job=diagnostics,host=web-1")
+	flags.StringVar(&lokiPushOptions.Text, "loki-push-text", lokiPushOptions.Text, "Loki text or file, each line is entry")
+	lokiCmd.AddCommand(idempotentCommand(pushCmd))
+
+	queryRangeCmd := &cobra.Command{
+		Use:   "query-range",
+		Short: "Query logs or metrics with LogQL within time range",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Loki querying...")
+			common.Debug("Loki", lokiQueryOptions, stdout)
+
+			queryBytes, err := utils.Content(lokiQueryOptions.Query)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			lokiQueryOptions.Query = string(queryBytes)
+
+			bytes, err := lokiNew(stdout).QueryRange(lokiQueryOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			if strings.EqualFold(lokiQueryOptions.Format, "lines") {
+				common.OutputRaw(lokiOutput.Output, bytes, stdout)
+				return
+			}
+			common.OutputJson(lokiOutput, "Loki", []interface{}{lokiOptions, lokiQueryOptions}, bytes, stdout)
+		},
+	}
+	flags = queryRangeCmd.PersistentFlags()
+	flags.StringVar(&lokiQueryOptions.Query, "loki-query", lokiQueryOptions.Query, "Loki LogQL query, e.g. {app=\"api\"} |= \"error\"")
+	flags.StringVar(&lokiQueryOptions.Since, "loki-query-since", lokiQueryOptions.Since, "Loki query duration before end, e.g. 15m, used if from is empty")
+	flags.StringVar(&lokiQueryOptions.From, "loki-query-from", lokiQueryOptions.From, "Loki query start, RFC3339 or unix nanoseconds")
+	flags.StringVar(&lokiQueryOptions.To, "loki-query-to", lokiQueryOptions.To, "Loki query end, RFC3339 or unix nanoseconds, now if empty")
+	flags.IntVar(&lokiQueryOptions.Limit, "loki-query-limit", lokiQueryOptions.Limit, "Loki query limit of entries")
+	flags.StringVar(&lokiQueryOptions.Direction, "loki-query-direction", lokiQueryOptions.Direction, "Loki query direction: backward, forward")
+	flags.StringVar(&lokiQueryOptions.Format, "loki-query-format", lokiQueryOptions.Format, "Loki query result format: json, lines")
+	lokiCmd.AddCommand(queryRangeCmd)
+
+	return lokiCmd
+}
+
+func init() {
+	registerVendor("loki", vendorGroupLogs, NewLokiCommand, lokiNew)
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var mattermostOptions = vendors.MattermostOptions{
+	Timeout:    envGet("MATTERMOST_TIMEOUT", 30).(int),
+	Insecure:   envGet("MATTERMOST_INSECURE", false).(bool),
+	URL:        envGet("MATTERMOST_URL", "").(string),
+	Token:      envGet("MATTERMOST_TOKEN", "").(string),
+	WebhookURL: envGet("MATTERMOST_WEBHOOK_URL", "").(string),
+}
+
+var mattermostMessageOptions = vendors.MattermostMessageOptions{
+	Channel:     envGet("MATTERMOST_CHANNEL", "").(string),
+	Thread:      envGet("MATTERMOST_THREAD", "").(string),
+	Text:        envGet("MATTERMOST_TEXT", "").(string),
+	Username:    envGet("MATTERMOST_USERNAME", "").(string),
+	IconURL:     envGet("MATTERMOST_ICON_URL", "").(string),
+	Attachments: envGet("MATTERMOST_ATTACHMENTS", "").(string),
+}
+
+var mattermostFileOptions = vendors.MattermostFileOptions{
+	Channel: envGet("MATTERMOST_CHANNEL", "").(string),
+	Thread:  envGet("MATTERMOST_THREAD", "").(string),
+	Text:    envGet("MATTERMOST_TEXT", "").(string),
+	Name:    envGet("MATTERMOST_FILE_NAME", "").(string),
+	Content: envGet("MATTERMOST_FILE_CONTENT", "").(string),
+}
+
+var mattermostOutput = common.OutputOptions{
+	Output: envGet("MATTERMOST_OUTPUT", "").(string),
+	Query:  envGet("MATTERMOST_OUTPUT_QUERY", "").(string),
+}
+
+func mattermostNew(stdout *common.Stdout) *vendors.Mattermost {
+
+	common.Debug("Mattermost", mattermostOptions, stdout)
+	common.Debug("Mattermost", mattermostOutput, stdout)
+
+	mattermostMessageOptions.Channel = serviceChannel(stdout, "mattermost", mattermostMessageOptions.Channel)
+	mattermostFileOptions.Channel = serviceChannel(stdout, "mattermost", mattermostFileOptions.Channel)
+
+	return vendors.NewMattermost(mattermostOptions)
+}
+
+func NewMattermostCommand() *cobra.Command {
+
+	mattermostCmd := &cobra.Command{
+		Use:   "mattermost",
+		Short: "Mattermost tools",
+	}
+
+	flags := mattermostCmd.PersistentFlags()
+	flags.IntVar(&mattermostOptions.Timeout, "mattermost-timeout", mattermostOptions.Timeout, "Mattermost timeout")
+	flags.BoolVar(&mattermostOptions.Insecure, "mattermost-insecure", mattermostOptions.Insecure, "Mattermost insecure")
+	flags.StringVar(&mattermostOptions.URL, "mattermost-url", mattermostOptions.URL, "Mattermost URL")
+	flags.StringVar(&mattermostOptions.Token, "mattermost-token", mattermostOptions.Token, "Mattermost bot or personal access token")
+	flags.StringVar(&mattermostOptions.WebhookURL, "mattermost-webhook-url", mattermostOptions.WebhookURL, "Mattermost incoming webhook URL, it's used if there is no token")
+	flags.StringVar(&mattermostOutput.Output, "mattermost-output", mattermostOutput.Output, "Mattermost output")
+	flags.StringVar(&mattermostOutput.Query, "mattermost-output-query", mattermostOutput.Query, "Mattermost output query")
+
+	sendMessage := &cobra.Command{
+		Use:   "send-message",
+		Short: "Send message",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Mattermost sending message...")
+			common.Debug("Mattermost", mattermostMessageOptions, stdout)
+
+			for _, s := range []*string{&mattermostMessageOptions.Text, &mattermostMessageOptions.Attachments} {
+				b, err := utils.Content(*s)
+				if err != nil {
+					stdout.Panic(err)
+				}
+				*s = string(b)
+			}
+
+			if !hooksPreSend(stdout, "mattermost", &mattermostMessageOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", mattermostMessageOptions, func() ([]byte, error) {
+				return mattermostNew(stdout).SendMessage(mattermostMessageOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "mattermost", bytes)
+			common.OutputJson(mattermostOutput, "Mattermost", []interface{}{mattermostOptions, mattermostMessageOptions}, bytes, stdout)
+		},
+	}
+	flags = sendMessage.PersistentFlags()
+	flags.StringVar(&mattermostMessageOptions.Channel, "mattermost-channel", mattermostMessageOptions.Channel, "Mattermost channel ID, or channel name of webhook")
+	flags.StringVar(&mattermostMessageOptions.Thread, "mattermost-thread", mattermostMessageOptions.Thread, "Mattermost root post ID to reply in")
+	flags.StringVar(&mattermostMessageOptions.Text, "mattermost-text", mattermostMessageOptions.Text, "Mattermost text, content or path")
+	flags.StringVar(&mattermostMessageOptions.Username, "mattermost-username", mattermostMessageOptions.Username, "Mattermost username of webhook")
+	flags.StringVar(&mattermostMessageOptions.IconURL, "mattermost-icon-url", mattermostMessageOptions.IconURL, "Mattermost icon URL of webhook")
+	flags.StringVar(&mattermostMessageOptions.Attachments, "mattermost-attachments", mattermostMessageOptions.Attachments, "Mattermost attachments json, content or path")
+	mattermostCmd.AddCommand(idempotentCommand(sendMessage))
+
+	sendFile := &cobra.Command{
+		Use:   "send-file",
+		Short: "Upload file to channel",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Mattermost sending file...")
+			common.Debug("Mattermost", mattermostFileOptions, stdout)
+
+			textBytes, err := utils.Content(mattermostFileOptions.Text)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			mattermostFileOptions.Text = string(textBytes)
+
+			contentBytes, err := utils.Content(mattermostFileOptions.Content)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			mattermostFileOptions.Content = string(contentBytes)
+
+			if !hooksPreSend(stdout, "mattermost", &mattermostFileOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", mattermostFileOptions, func() ([]byte, error) {
+				return mattermostNew(stdout).SendFile(mattermostFileOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "mattermost", bytes)
+			common.OutputJson(mattermostOutput, "Mattermost", []interface{}{mattermostOptions, mattermostFileOptions}, bytes, stdout)
+		},
+	}
+	flags = sendFile.PersistentFlags()
+	flags.StringVar(&mattermostFileOptions.Channel, "mattermost-channel", mattermostFileOptions.Channel, "Mattermost channel ID")
+	flags.StringVar(&mattermostFileOptions.Thread, "mattermost-thread", mattermostFileOptions.Thread, "Mattermost root post ID to reply in")
+	flags.StringVar(&mattermostFileOptions.Text, "mattermost-text", mattermostFileOptions.Text, "Mattermost text, content or path")
+	flags.StringVar(&mattermostFileOptions.Name, "mattermost-file-name", mattermostFileOptions.Name, "Mattermost file name")
+	flags.StringVar(&mattermostFileOptions.Content, "mattermost-file-content", mattermostFileOptions.Content, "Mattermost file content or path")
+	mattermostCmd.AddCommand(idempotentCommand(sendFile))
+
+	return mattermostCmd
+}
+
+func init() {
+	registerVendor("mattermost", vendorGroupChat, NewMattermostCommand, mattermostNew)
+}
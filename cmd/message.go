@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// MessageOptions of templates of messages, vars are key=value of .Vars, env allows env vars and files
+type MessageOptions struct {
+	Templates bool
+	Env       bool
+	Vars      []string
+}
+
+var messageOptions = MessageOptions{
+	Templates: envGet("MESSAGE_TEMPLATES", false).(bool),
+	Env:       envGet("MESSAGE_ENV", false).(bool),
+	Vars:      strings.Split(envGet("VARS", "").(string), ","),
+}
+
+// messageFlags are messages of send commands, which are rendered as templates if they have template actions
+var messageFlags = map[string]bool{
+	"slack-text":                        true,
+	"slack-title":                       true,
+	"telegram-message-text":             true,
+	"telegram-document-caption":         true,
+	"telegram-photo-caption":            true,
+	"discord-message-content":           true,
+	"rocketchat-text":                   true,
+	"webex-message-text":                true,
+	"webex-message-markdown":            true,
+	"teams-title":                       true,
+	"teams-text":                        true,
+	"mattermost-text":                   true,
+	"email-subject":                     true,
+	"email-text":                        true,
+	"email-html":                        true,
+	"twilio-sms-body":                   true,
+	"twilio-call-text":                  true,
+	"aws-sns-message":                   true,
+	"aws-sns-subject":                   true,
+	"aws-sqs-body":                      true,
+	"notify-text":                       true,
+	"notify-title":                      true,
+	"opsgenie-alert-message":            true,
+	"opsgenie-alert-description":        true,
+	"pagerduty-event-summary":           true,
+	"pagerduty-incident-title":          true,
+	"pagerduty-incident-body":           true,
+	"pagerduty-incident-note":           true,
+	"google-calendar-event-summary":     true,
+	"google-calendar-event-description": true,
+	"jira-issue-summary":                true,
+	"jira-issue-description":            true,
+	"jira-issue-comment-body":           true,
+	"github-comment-body":               true,
+	"gitlab-merge-request-note-body":    true,
+	"datadog-event-title":               true,
+	"datadog-event-text":                true,
+	"grafana-annotation-text":           true,
+	"grafana-oncall-escalation-title":   true,
+	"grafana-oncall-escalation-message": true,
+	"zabbix-acknowledge-message":        true,
+	"incident-title":                    true,
+}
+
+// messageVars returns vars of key=value, value may have "=" and ","s are separators of vars
+func messageVars(items []string) (map[string]string, error) {
+
+	vars := make(map[string]string)
+	for _, item := range common.RemoveEmptyStrings(items) {
+		k, v, ok := strings.Cut(item, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("var %s isn't key=value", item)
+		}
+		vars[k] = v
+	}
+	return vars, nil
+}
+
+// messageResolve renders message flags of command, so that messages of all vendors have the same templates,
+// it's before secrets and stdin, so that only messages of command line, env vars and config are rendered,
+// messages of stdin and files are sent as is, as they could be of untrusted input, e.g. of alerts
+func messageResolve(cmd *cobra.Command) error {
+
+	if !messageOptions.Templates {
+		return nil
+	}
+	vars, err := messageVars(messageOptions.Vars)
+	if err != nil {
+		return err
+	}
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+
+		value := f.Value.String()
+		if err != nil || !messageFlags[f.Name] || !common.IsMessageTemplate(value) || common.SecretScheme(value) != "" {
+			return
+		}
+		s, rerr := common.MessageTemplate(f.Name, value, vars, messageOptions.Env)
+		if rerr != nil {
+			err = fmt.Errorf("flag %s: %s", f.Name, rerr)
+			return
+		}
+		err = f.Value.Set(s)
+	})
+	return err
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+)
+
+var metricsOptions = common.MetricsOptions{
+	PushURL:  envGet("METRICS_PUSH_URL", "").(string),
+	PushJob:  envGet("METRICS_PUSH_JOB", "tools").(string),
+	Timeout:  envGet("METRICS_PUSH_TIMEOUT", 5).(int),
+	Insecure: envGet("METRICS_PUSH_INSECURE", false).(bool),
+}
+
+// metricsPush pushes metrics of command to Pushgateway, failures are not errors of command
+func metricsPush() {
+
+	if err := common.MetricsPush(metricsOptions); err != nil {
+		stdout.Debug("Metrics pushing failed: %s", err)
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/server"
+	"github.com/spf13/cobra"
+)
+
+var monitorOptions = server.MonitorSourceOptions{
+	SuiteFile:  envGet("MONITOR_SUITE_FILE", "").(string),
+	TLS:        strings.Split(envGet("MONITOR_TLS", "").(string), ","),
+	Whois:      strings.Split(envGet("MONITOR_WHOIS", "").(string), ","),
+	DNS:        strings.Split(envGet("MONITOR_DNS", "").(string), ","),
+	DNSRecord:  envGet("MONITOR_DNS_RECORD", "A").(string),
+	DNSServer:  envGet("MONITOR_DNS_SERVER", "").(string),
+	Warning:    envGet("MONITOR_WARNING", 30).(int),
+	Critical:   envGet("MONITOR_CRITICAL", 7).(int),
+	Interval:   envGet("MONITOR_INTERVAL", 3600).(int),
+	Timeout:    envGet("MONITOR_TIMEOUT", 30).(int),
+	Insecure:   envGet("MONITOR_INSECURE", false).(bool),
+	Concurrent: envGet("MONITOR_CONCURRENT", 5).(int),
+}
+
+var monitorServerOptions = server.Options{
+	RoutesFile: envGet("MONITOR_ROUTES_FILE", "").(string),
+	Workers:    1,
+	Watch:      envGet("MONITOR_WATCH", false).(bool),
+	Listen:     envGet("MONITOR_LISTEN", "").(string),
+}
+
+var monitorOutput = common.OutputOptions{
+	Output: envGet("MONITOR_OUTPUT", "").(string),
+	Query:  envGet("MONITOR_OUTPUT_QUERY", "").(string),
+}
+
+func monitorNew(stdout *common.Stdout) *server.MonitorSource {
+
+	common.Debug("Monitor", monitorOptions, stdout)
+	common.Debug("Monitor", monitorOutput, stdout)
+
+	source, err := server.NewMonitorSource(monitorOptions, stdout.Module("monitor"))
+	if err != nil {
+		stdout.Panic(err)
+	}
+	return source
+}
+
+func NewMonitorCommand() *cobra.Command {
+
+	monitorCmd := &cobra.Command{
+		Use:   "monitor",
+		Short: "Monitor TLS certificates, domains and DNS",
+	}
+	flags := monitorCmd.PersistentFlags()
+	flags.StringVar(&monitorOptions.SuiteFile, "monitor-suite-file", monitorOptions.SuiteFile, "Monitor suite YAML file with checks")
+	flags.StringSliceVar(&monitorOptions.TLS, "monitor-tls", monitorOptions.TLS, "Monitor TLS certificate expiry of host[:port]")
+	flags.StringSliceVar(&monitorOptions.Whois, "monitor-whois", monitorOptions.Whois, "Monitor domain expiry via WHOIS")
+	flags.StringSliceVar(&monitorOptions.DNS, "monitor-dns", monitorOptions.DNS, "Monitor DNS names resolve")
+	flags.StringVar(&monitorOptions.DNSRecord, "monitor-dns-record", monitorOptions.DNSRecord, "Monitor DNS record: A, AAAA, CNAME, MX, NS, TXT")
+	flags.StringVar(&monitorOptions.DNSServer, "monitor-dns-server", monitorOptions.DNSServer, "Monitor DNS server, system resolver if empty")
+	flags.IntVar(&monitorOptions.Warning, "monitor-warning", monitorOptions.Warning, "Monitor warning threshold in days left")
+	flags.IntVar(&monitorOptions.Critical, "monitor-critical", monitorOptions.Critical, "Monitor critical threshold in days left")
+	flags.IntVar(&monitorOptions.Timeout, "monitor-timeout", monitorOptions.Timeout, "Monitor check timeout in seconds")
+	flags.BoolVar(&monitorOptions.Insecure, "monitor-insecure", monitorOptions.Insecure, "Monitor TLS without certificate verification")
+	flags.IntVar(&monitorOptions.Concurrent, "monitor-concurrent", monitorOptions.Concurrent, "Monitor concurrent checks")
+	flags.StringVar(&monitorOutput.Output, "monitor-output", monitorOutput.Output, "Monitor output")
+	flags.StringVar(&monitorOutput.Query, "monitor-output-query", monitorOutput.Query, "Monitor output query")
+
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Run checks once",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Monitor checking...")
+
+			results := monitorNew(stdout).CheckAll()
+			bytes, err := json.Marshal(results)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(monitorOutput, "Monitor", []interface{}{monitorOptions}, bytes, stdout)
+		},
+	}
+	monitorCmd.AddCommand(checkCmd)
+
+	runCmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run checks on schedule and route status changes to targets",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Monitor starting...")
+			common.Debug("Monitor", monitorServerOptions, stdout)
+
+			s, err := server.NewServer(monitorServerOptions, stdout)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			serverTargets(s)
+			s.AddSource(monitorNew(stdout))
+
+			ctx, cancel := serviceContext()
+			defer cancel()
+
+			err = s.Run(ctx)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			exitReset()
+			stdout.Info("Monitor stopped")
+		},
+	}
+	flags = runCmd.PersistentFlags()
+	flags.StringVar(&monitorServerOptions.RoutesFile, "monitor-routes-file", monitorServerOptions.RoutesFile, "Monitor routes YAML file, see server routes")
+	flags.BoolVar(&monitorServerOptions.Watch, "monitor-watch", monitorServerOptions.Watch, "Monitor reloads routes file on its changes, SIGHUP reloads it anyway")
+	flags.StringVar(&monitorServerOptions.Listen, "monitor-listen", monitorServerOptions.Listen, "Monitor listen address of /healthz, /readyz and /metrics endpoints, e.g. :8081")
+	flags.IntVar(&monitorOptions.Interval, "monitor-interval", monitorOptions.Interval, "Monitor interval in seconds")
+	monitorCmd.AddCommand(runCmd)
+
+	return monitorCmd
+}
@@ -0,0 +1,169 @@
+//go:build !minimal || config || netbox
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var netboxOptions = vendors.NetBoxOptions{
+	Timeout:  envGet("NETBOX_TIMEOUT", 30).(int),
+	Insecure: envGet("NETBOX_INSECURE", false).(bool),
+	URL:      envGet("NETBOX_URL", "").(string),
+	Token:    envGet("NETBOX_TOKEN", "").(string),
+}
+
+var netboxDeviceOptions = vendors.NetBoxDeviceOptions{
+	Name: envGet("NETBOX_DEVICE", "").(string),
+	Site: envGet("NETBOX_SITE", "").(string),
+}
+
+var netboxPrefixesOptions = vendors.NetBoxPrefixesOptions{
+	Within: envGet("NETBOX_PREFIXES_WITHIN", "").(string),
+	Site:   envGet("NETBOX_SITE", "").(string),
+	VRF:    envGet("NETBOX_PREFIXES_VRF", "").(string),
+	Status: envGet("NETBOX_PREFIXES_STATUS", "").(string),
+	Tag:    envGet("NETBOX_PREFIXES_TAG", "").(string),
+	Limit:  envGet("NETBOX_PREFIXES_LIMIT", 50).(int),
+}
+
+var netboxIPOptions = vendors.NetBoxIPOptions{
+	Prefix:      envGet("NETBOX_IP_PREFIX", "").(string),
+	Address:     envGet("NETBOX_IP_ADDRESS", "").(string),
+	Status:      envGet("NETBOX_IP_STATUS", "reserved").(string),
+	DNSName:     envGet("NETBOX_IP_DNS_NAME", "").(string),
+	Description: envGet("NETBOX_IP_DESCRIPTION", "").(string),
+	Tags:        strings.Split(envGet("NETBOX_IP_TAGS", "").(string), ","),
+}
+
+var netboxOutput = common.OutputOptions{
+	Output: envGet("NETBOX_OUTPUT", "").(string),
+	Query:  envGet("NETBOX_OUTPUT_QUERY", "").(string),
+}
+
+func netboxNew(stdout *common.Stdout) *vendors.NetBox {
+
+	common.Debug("NetBox", netboxOptions, stdout)
+	common.Debug("NetBox", netboxOutput, stdout)
+
+	return vendors.NewNetBox(netboxOptions)
+}
+
+// enrichmentNetBox gets device by name, e.g. to add site, rack and role of alerting host
+func enrichmentNetBox(params map[string]string) (interface{}, error) {
+
+	opts := netboxOptions
+	if !utils.IsEmpty(params["url"]) {
+		opts.URL = params["url"]
+	}
+	deviceOptions := vendors.NetBoxDeviceOptions{
+		Name: params["device"],
+		Site: params["site"],
+	}
+	return enrichmentJson(vendors.NewNetBox(opts).GetDevice(deviceOptions))
+}
+
+func NewNetBoxCommand() *cobra.Command {
+
+	netboxCmd := &cobra.Command{
+		Use:   "netbox",
+		Short: "NetBox tools",
+	}
+	flags := netboxCmd.PersistentFlags()
+	flags.IntVar(&netboxOptions.Timeout, "netbox-timeout", netboxOptions.Timeout, "NetBox timeout in seconds")
+	flags.BoolVar(&netboxOptions.Insecure, "netbox-insecure", netboxOptions.Insecure, "NetBox insecure")
+	flags.StringVar(&netboxOptions.URL, "netbox-url", netboxOptions.URL, "NetBox URL")
+	flags.StringVar(&netboxOptions.Token, "netbox-token", netboxOptions.Token, "NetBox API token")
+	flags.StringVar(&netboxOutput.Output, "netbox-output", netboxOutput.Output, "NetBox output")
+	flags.StringVar(&netboxOutput.Query, "netbox-output-query", netboxOutput.Query, "NetBox output query")
+
+	deviceCmd := &cobra.Command{
+		Use:   "device",
+		Short: "Get device by name",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("NetBox getting device %s...", netboxDeviceOptions.Name)
+			common.Debug("NetBox", netboxDeviceOptions, stdout)
+
+			bytes, err := netboxNew(stdout).GetDevice(netboxDeviceOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(netboxOutput, "NetBox", []interface{}{netboxOptions, netboxDeviceOptions}, bytes, stdout)
+		},
+	}
+	flags = deviceCmd.PersistentFlags()
+	flags.StringVar(&netboxDeviceOptions.Name, "netbox-device", netboxDeviceOptions.Name, "NetBox device name")
+	flags.StringVar(&netboxDeviceOptions.Site, "netbox-site", netboxDeviceOptions.Site, "NetBox site slug")
+	netboxCmd.AddCommand(deviceCmd)
+
+	prefixesCmd := &cobra.Command{
+		Use:   "prefixes",
+		Short: "List prefixes",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("NetBox getting prefixes...")
+			common.Debug("NetBox", netboxPrefixesOptions, stdout)
+
+			bytes, err := netboxNew(stdout).GetPrefixes(netboxPrefixesOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(netboxOutput, "NetBox", []interface{}{netboxOptions, netboxPrefixesOptions}, bytes, stdout)
+		},
+	}
+	flags = prefixesCmd.PersistentFlags()
+	flags.StringVar(&netboxPrefixesOptions.Within, "netbox-prefixes-within", netboxPrefixesOptions.Within, "NetBox prefixes within prefix, including it")
+	flags.StringVar(&netboxPrefixesOptions.Site, "netbox-site", netboxPrefixesOptions.Site, "NetBox site slug")
+	flags.StringVar(&netboxPrefixesOptions.VRF, "netbox-prefixes-vrf", netboxPrefixesOptions.VRF, "NetBox prefixes VRF")
+	flags.StringVar(&netboxPrefixesOptions.Status, "netbox-prefixes-status", netboxPrefixesOptions.Status, "NetBox prefixes status: container, active, reserved, deprecated")
+	flags.StringVar(&netboxPrefixesOptions.Tag, "netbox-prefixes-tag", netboxPrefixesOptions.Tag, "NetBox prefixes tag slug")
+	flags.IntVar(&netboxPrefixesOptions.Limit, "netbox-prefixes-limit", netboxPrefixesOptions.Limit, "NetBox prefixes limit")
+	netboxCmd.AddCommand(prefixesCmd)
+
+	reserveIPCmd := &cobra.Command{
+		Use:   "reserve-ip",
+		Short: "Reserve IP address, the next available of prefix if there is no address",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("NetBox reserving IP in %s%s...", netboxIPOptions.Prefix, netboxIPOptions.Address)
+			common.Debug("NetBox", netboxIPOptions, stdout)
+
+			if !hooksPreSend(stdout, "netbox", &netboxIPOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", netboxIPOptions, func() ([]byte, error) {
+				return netboxNew(stdout).ReserveIP(netboxIPOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "netbox", bytes)
+			common.OutputJson(netboxOutput, "NetBox", []interface{}{netboxOptions, netboxIPOptions}, bytes, stdout)
+		},
+	}
+	flags = reserveIPCmd.PersistentFlags()
+	flags.StringVar(&netboxIPOptions.Prefix, "netbox-ip-prefix", netboxIPOptions.Prefix, "NetBox prefix to reserve the next available IP, e.g. 10.0.0.0/24")
+	flags.StringVar(&netboxIPOptions.Address, "netbox-ip-address", netboxIPOptions.Address, "NetBox IP address with mask to reserve, e.g. 10.0.0.10/24")
+	flags.StringVar(&netboxIPOptions.Status, "netbox-ip-status", netboxIPOptions.Status, "NetBox IP status: active, reserved, deprecated, dhcp, slaac")
+	flags.StringVar(&netboxIPOptions.DNSName, "netbox-ip-dns-name", netboxIPOptions.DNSName, "NetBox IP DNS name")
+	flags.StringVar(&netboxIPOptions.Description, "netbox-ip-description", netboxIPOptions.Description, "NetBox IP description")
+	flags.StringSliceVar(&netboxIPOptions.Tags, "netbox-ip-tags", netboxIPOptions.Tags, "NetBox IP tags, they should exist")
+	netboxCmd.AddCommand(idempotentCommand(reserveIPCmd))
+
+	return netboxCmd
+}
+
+func init() {
+	registerVendor("netbox", vendorGroupConfig, NewNetBoxCommand, netboxNew)
+	registerVendorEnricher("netbox", enrichmentNetBox)
+}
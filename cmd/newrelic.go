@@ -0,0 +1,146 @@
+//go:build !minimal || monitoring || newrelic
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var newRelicOptions = vendors.NewRelicOptions{
+	Timeout:   envGet("NEWRELIC_TIMEOUT", 30).(int),
+	Insecure:  envGet("NEWRELIC_INSECURE", false).(bool),
+	URL:       envGet("NEWRELIC_URL", "https://api.newrelic.com/graphql").(string),
+	APIKey:    envGet("NEWRELIC_API_KEY", "").(string),
+	AccountID: envGet("NEWRELIC_ACCOUNT_ID", 0).(int),
+}
+
+var newRelicDeploymentOptions = vendors.NewRelicDeploymentOptions{
+	EntityGUID:  envGet("NEWRELIC_DEPLOYMENT_ENTITY_GUID", "").(string),
+	Version:     envGet("NEWRELIC_DEPLOYMENT_VERSION", "").(string),
+	Changelog:   envGet("NEWRELIC_DEPLOYMENT_CHANGELOG", "").(string),
+	Commit:      envGet("NEWRELIC_DEPLOYMENT_COMMIT", "").(string),
+	Description: envGet("NEWRELIC_DEPLOYMENT_DESCRIPTION", "").(string),
+	User:        envGet("NEWRELIC_DEPLOYMENT_USER", "").(string),
+	DeepLink:    envGet("NEWRELIC_DEPLOYMENT_DEEP_LINK", "").(string),
+	Type:        envGet("NEWRELIC_DEPLOYMENT_TYPE", "").(string),
+	GroupID:     envGet("NEWRELIC_DEPLOYMENT_GROUP_ID", "").(string),
+}
+
+var newRelicNRQLOptions = vendors.NewRelicNRQLOptions{
+	Query:   envGet("NEWRELIC_NRQL_QUERY", "").(string),
+	Format:  envGet("NEWRELIC_NRQL_FORMAT", "json").(string),
+	Timeout: envGet("NEWRELIC_NRQL_TIMEOUT", 0).(int),
+}
+
+var newRelicOutput = common.OutputOptions{
+	Output: envGet("NEWRELIC_OUTPUT", "").(string),
+	Query:  envGet("NEWRELIC_OUTPUT_QUERY", "").(string),
+}
+
+func newRelicNew(stdout *common.Stdout) *vendors.NewRelic {
+
+	common.Debug("NewRelic", newRelicOptions, stdout)
+	common.Debug("NewRelic", newRelicOutput, stdout)
+
+	return vendors.NewNewRelic(newRelicOptions)
+}
+
+func NewNewRelicCommand() *cobra.Command {
+
+	newRelicCmd := &cobra.Command{
+		Use:   "newrelic",
+		Short: "New Relic tools",
+	}
+	flags := newRelicCmd.PersistentFlags()
+	flags.IntVar(&newRelicOptions.Timeout, "newrelic-timeout", newRelicOptions.Timeout, "New Relic timeout in seconds")
+	flags.BoolVar(&newRelicOptions.Insecure, "newrelic-insecure", newRelicOptions.Insecure, "New Relic insecure")
+	flags.StringVar(&newRelicOptions.URL, "newrelic-url", newRelicOptions.URL, "New Relic NerdGraph URL, e.g. https://api.eu.newrelic.com/graphql")
+	flags.StringVar(&newRelicOptions.APIKey, "newrelic-api-key", newRelicOptions.APIKey, "New Relic user API key")
+	flags.IntVar(&newRelicOptions.AccountID, "newrelic-account-id", newRelicOptions.AccountID, "New Relic account ID")
+	flags.StringVar(&newRelicOutput.Output, "newrelic-output", newRelicOutput.Output, "New Relic output")
+	flags.StringVar(&newRelicOutput.Query, "newrelic-output-query", newRelicOutput.Query, "New Relic output query")
+
+	createDeploymentCmd := &cobra.Command{
+		Use:   "create-deployment",
+		Short: "Create deployment marker via change tracking",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("NewRelic creating deployment %s...", newRelicDeploymentOptions.Version)
+			common.Debug("NewRelic", newRelicDeploymentOptions, stdout)
+
+			changelogBytes, err := utils.Content(newRelicDeploymentOptions.Changelog)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			newRelicDeploymentOptions.Changelog = string(changelogBytes)
+
+			if !hooksPreSend(stdout, "newrelic", &newRelicDeploymentOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", newRelicDeploymentOptions, func() ([]byte, error) {
+				return newRelicNew(stdout).CreateDeployment(newRelicDeploymentOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "newrelic", bytes)
+			common.OutputJson(newRelicOutput, "NewRelic", []interface{}{newRelicOptions, newRelicDeploymentOptions}, bytes, stdout)
+		},
+	}
+	flags = createDeploymentCmd.PersistentFlags()
+	flags.StringVar(&newRelicDeploymentOptions.EntityGUID, "newrelic-deployment-entity-guid", newRelicDeploymentOptions.EntityGUID, "New Relic deployment entity GUID")
+	flags.StringVar(&newRelicDeploymentOptions.Version, "newrelic-deployment-version", newRelicDeploymentOptions.Version, "New Relic deployment version")
+	flags.StringVar(&newRelicDeploymentOptions.Changelog, "newrelic-deployment-changelog", newRelicDeploymentOptions.Changelog, "New Relic deployment changelog")
+	flags.StringVar(&newRelicDeploymentOptions.Commit, "newrelic-deployment-commit", newRelicDeploymentOptions.Commit, "New Relic deployment commit")
+	flags.StringVar(&newRelicDeploymentOptions.Description, "newrelic-deployment-description", newRelicDeploymentOptions.Description, "New Relic deployment description")
+	flags.StringVar(&newRelicDeploymentOptions.User, "newrelic-deployment-user", newRelicDeploymentOptions.User, "New Relic deployment user")
+	flags.StringVar(&newRelicDeploymentOptions.DeepLink, "newrelic-deployment-deep-link", newRelicDeploymentOptions.DeepLink, "New Relic deployment deep link, e.g. pipeline URL")
+	flags.StringVar(&newRelicDeploymentOptions.Type, "newrelic-deployment-type", newRelicDeploymentOptions.Type, "New Relic deployment type: basic, blue_green, canary, rolling, shadow, other")
+	flags.StringVar(&newRelicDeploymentOptions.GroupID, "newrelic-deployment-group-id", newRelicDeploymentOptions.GroupID, "New Relic deployment group ID")
+	newRelicCmd.AddCommand(idempotentCommand(createDeploymentCmd))
+
+	queryCmd := &cobra.Command{
+		Use:   "query",
+		Short: "Run NRQL query",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("NewRelic querying...")
+			common.Debug("NewRelic", newRelicNRQLOptions, stdout)
+
+			queryBytes, err := utils.Content(newRelicNRQLOptions.Query)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			newRelicNRQLOptions.Query = string(queryBytes)
+
+			bytes, err := newRelicNew(stdout).QueryNRQL(newRelicNRQLOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			if strings.EqualFold(newRelicNRQLOptions.Format, "csv") {
+				common.OutputRaw(newRelicOutput.Output, bytes, stdout)
+				return
+			}
+			common.OutputJson(newRelicOutput, "NewRelic", []interface{}{newRelicOptions, newRelicNRQLOptions}, bytes, stdout)
+		},
+	}
+	flags = queryCmd.PersistentFlags()
+	flags.StringVar(&newRelicNRQLOptions.Query, "newrelic-nrql-query", newRelicNRQLOptions.Query, "New Relic NRQL query")
+	flags.StringVar(&newRelicNRQLOptions.Format, "newrelic-nrql-format", newRelicNRQLOptions.Format, "New Relic NRQL result format: json, csv")
+	flags.IntVar(&newRelicNRQLOptions.Timeout, "newrelic-nrql-timeout", newRelicNRQLOptions.Timeout, "New Relic NRQL query timeout in seconds")
+	newRelicCmd.AddCommand(queryCmd)
+
+	return newRelicCmd
+}
+
+func init() {
+	registerVendor("newrelic", vendorGroupMonitoring, NewNewRelicCommand, newRelicNew)
+}
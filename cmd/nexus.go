@@ -0,0 +1,55 @@
+//go:build !minimal || ci || nexus
+
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+var nexusOptions = vendors.NexusOptions{
+	Timeout:  envGet("NEXUS_TIMEOUT", 300).(int),
+	Insecure: envGet("NEXUS_INSECURE", false).(bool),
+	URL:      envGet("NEXUS_URL", "").(string),
+	User:     envGet("NEXUS_USER", "").(string),
+	Password: envGet("NEXUS_PASSWORD", "").(string),
+}
+
+var nexusArtifactOptions = newArtifactOptions("NEXUS")
+
+var nexusOutput = common.OutputOptions{
+	Output: envGet("NEXUS_OUTPUT", "").(string),
+	Query:  envGet("NEXUS_OUTPUT_QUERY", "").(string),
+}
+
+func nexusNew(stdout *common.Stdout) vendors.ArtifactRepository {
+
+	common.Debug("Nexus", nexusOptions, stdout)
+	common.Debug("Nexus", nexusOutput, stdout)
+
+	return vendors.NewNexus(nexusOptions)
+}
+
+func NewNexusCommand() *cobra.Command {
+
+	nexusCmd := &cobra.Command{
+		Use:   "nexus",
+		Short: "Nexus tools",
+	}
+	flags := nexusCmd.PersistentFlags()
+	flags.IntVar(&nexusOptions.Timeout, "nexus-timeout", nexusOptions.Timeout, "Nexus timeout in seconds")
+	flags.BoolVar(&nexusOptions.Insecure, "nexus-insecure", nexusOptions.Insecure, "Nexus insecure")
+	flags.StringVar(&nexusOptions.URL, "nexus-url", nexusOptions.URL, "Nexus URL")
+	flags.StringVar(&nexusOptions.User, "nexus-user", nexusOptions.User, "Nexus user or user token name")
+	flags.StringVar(&nexusOptions.Password, "nexus-password", nexusOptions.Password, "Nexus password or user token pass code")
+	flags.StringVar(&nexusOutput.Output, "nexus-output", nexusOutput.Output, "Nexus output")
+	flags.StringVar(&nexusOutput.Query, "nexus-output-query", nexusOutput.Query, "Nexus output query")
+
+	artifactCommands(nexusCmd, "Nexus", &nexusArtifactOptions, &nexusOutput, &nexusOptions, nexusNew)
+	return nexusCmd
+}
+
+func init() {
+	registerVendor("nexus", vendorGroupCI, NewNexusCommand, nexusNew)
+}
@@ -0,0 +1,441 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/render"
+	"github.com/devopsext/tools/server"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+type NotifyOptions struct {
+	Targets    []string
+	Title      string
+	Text       string
+	Severity   string
+	Link       string
+	Labels     string
+	Template   string
+	Params     []string
+	CloudEvent string
+	File       string
+	FileName   string
+}
+
+// NotifyMessage is canonical model which template is rendered with
+type NotifyMessage struct {
+	Title    string             `json:"title"`
+	Text     string             `json:"text"`
+	Severity string             `json:"severity"`
+	Link     string             `json:"link,omitempty"`
+	Labels   map[string]string  `json:"labels,omitempty"`
+	Time     time.Time          `json:"time"`
+	Event    *server.CloudEvent `json:"event,omitempty"`
+}
+
+type NotifyResult struct {
+	Vendor   string          `json:"vendor"`
+	Status   string          `json:"status"`
+	Error    string          `json:"error,omitempty"`
+	Duration int64           `json:"duration"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+type NotifyReport struct {
+	Message string          `json:"message"`
+	Sent    int             `json:"sent"`
+	Failed  int             `json:"failed"`
+	Results []*NotifyResult `json:"results"`
+}
+
+var notifyOptions = NotifyOptions{
+	Targets:    strings.Split(envGet("NOTIFY_TARGETS", envGet("NOTIFY_VENDORS", "")).(string), ","),
+	Title:      envGet("NOTIFY_TITLE", "").(string),
+	Text:       envGet("NOTIFY_TEXT", "").(string),
+	Severity:   envGet("NOTIFY_SEVERITY", "").(string),
+	Link:       envGet("NOTIFY_LINK", "").(string),
+	Labels:     envGet("NOTIFY_LABELS", "").(string),
+	Template:   envGet("NOTIFY_TEMPLATE", "").(string),
+	Params:     strings.Split(envGet("NOTIFY_PARAMS", "").(string), ";"),
+	CloudEvent: envGet("NOTIFY_CLOUDEVENT", "").(string),
+	File:       envGet("NOTIFY_FILE", "").(string),
+	FileName:   envGet("NOTIFY_FILE_NAME", "").(string),
+}
+
+var notifyOutput = common.OutputOptions{
+	Output: envGet("NOTIFY_OUTPUT", "").(string),
+	Query:  envGet("NOTIFY_OUTPUT_QUERY", "").(string),
+}
+
+// notifyCloudEvent reads single cloud event from file or stdin if -
+func notifyCloudEvent() (*server.CloudEvent, error) {
+
+	var r io.Reader = os.Stdin
+	if notifyOptions.CloudEvent != "-" {
+		f, err := os.Open(notifyOptions.CloudEvent)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var event *server.CloudEvent
+	err := server.ReadCloudEvents(r, func(ce *server.CloudEvent, err error) error {
+		if err != nil {
+			return err
+		}
+		if event != nil {
+			return errors.New("more than one cloud event")
+		}
+		event = ce
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, errors.New("no cloud event")
+	}
+	return event, nil
+}
+
+// notifyMessage builds canonical message, flags take precedence over cloud event attributes and data
+func notifyMessage() (*NotifyMessage, error) {
+
+	m := &NotifyMessage{
+		Title:    notifyOptions.Title,
+		Severity: notifyOptions.Severity,
+		Link:     notifyOptions.Link,
+		Labels:   make(map[string]string),
+		Time:     time.Now(),
+	}
+	textBytes, err := utils.Content(notifyOptions.Text)
+	if err != nil {
+		return nil, err
+	}
+	m.Text = string(textBytes)
+
+	if !utils.IsEmpty(notifyOptions.CloudEvent) {
+		ce, err := notifyCloudEvent()
+		if err != nil {
+			return nil, err
+		}
+		m.Event = ce
+		e := ce.Event()
+		for k, v := range e.Labels {
+			m.Labels[k] = v
+		}
+		m.Time = e.Time
+		if utils.IsEmpty(m.Title) {
+			m.Title = ce.DataString("title")
+		}
+		if utils.IsEmpty(m.Title) {
+			m.Title = ce.Type
+		}
+		if utils.IsEmpty(m.Text) {
+			m.Text = e.Message
+		}
+		if utils.IsEmpty(m.Severity) {
+			m.Severity = ce.DataString("severity", "level")
+		}
+		if utils.IsEmpty(m.Severity) {
+			m.Severity = ce.Extensions["severity"]
+		}
+		if utils.IsEmpty(m.Link) {
+			m.Link = ce.DataString("link", "url")
+		}
+	}
+	if utils.IsEmpty(m.Severity) {
+		m.Severity = "info"
+	}
+	for k, v := range utils.MapGetKeyValues(notifyOptions.Labels) {
+		m.Labels[k] = v
+	}
+	return m, nil
+}
+
+// notifyRender renders message once for all vendors, text is used as is without template
+func notifyRender(stdout *common.Stdout, m *NotifyMessage) (string, error) {
+
+	if utils.IsEmpty(notifyOptions.Template) {
+		return m.Text, nil
+	}
+	content, err := utils.Content(notifyOptions.Template)
+	if err != nil {
+		return "", err
+	}
+	tpl, err := render.NewTextTemplate(render.TemplateOptions{
+		Name:       "notify",
+		Content:    string(content),
+		TimeFormat: time.RFC3339,
+	}, stdout)
+	if err != nil {
+		return "", err
+	}
+	b, err := tpl.RenderObject(m)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// notifyParams returns params by vendor from vendor.key=value items
+func notifyParams() (map[string]map[string]string, error) {
+
+	params := make(map[string]map[string]string)
+	for _, p := range common.RemoveEmptyStrings(notifyOptions.Params) {
+		key, value, ok := strings.Cut(strings.TrimSpace(p), "=")
+		vendor, name, ok2 := strings.Cut(key, ".")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid notify param %s, vendor.key=value is expected", p)
+		}
+		if params[vendor] == nil {
+			params[vendor] = make(map[string]string)
+		}
+		params[vendor][name] = value
+	}
+	return params, nil
+}
+
+// notifyMessengers are chat vendors which send the same message, file and reply,
+// they're created when they're used, so that secrets of vendor are resolved by then
+func notifyMessengers() map[string]func() vendors.Messenger {
+
+	return map[string]func() vendors.Messenger{
+		"slack": func() vendors.Messenger {
+			return vendors.NewSlackMessenger(vendors.NewSlack(slackOptions), slackMessageOptions.Channel)
+		},
+		"telegram": func() vendors.Messenger {
+			return vendors.NewTelegramMessenger(vendors.NewTelegram(telegramOptions))
+		},
+		"discord": func() vendors.Messenger {
+			return vendors.NewDiscordMessenger(vendors.NewDiscord(discordOptions), discordMessageOptions.Channel)
+		},
+		"rocketchat": func() vendors.Messenger {
+			return vendors.NewRocketChatMessenger(vendors.NewRocketChat(rocketChatOptions), rocketChatFileOptions.RoomID)
+		},
+		"webex": func() vendors.Messenger {
+			return vendors.NewWebexMessenger(vendors.NewWebex(webexOptions), webexMessageOptions.Room)
+		},
+		"mattermost": func() vendors.Messenger {
+			return vendors.NewMattermostMessenger(vendors.NewMattermost(mattermostOptions), mattermostFileOptions.Channel)
+		},
+	}
+}
+
+// notifySenders send message by vendor targets, or file with message by messengers if file is set,
+// channel param is chat of Telegram and room of Webex as well
+func notifySenders(title, message string) (map[string]func(params map[string]string) ([]byte, error), error) {
+
+	senders := make(map[string]func(params map[string]string) ([]byte, error))
+	if utils.IsEmpty(notifyOptions.File) {
+		for name, target := range vendorTargets() {
+			target := target
+			senders[name] = func(params map[string]string) ([]byte, error) {
+				return target(params, message)
+			}
+		}
+		return senders, nil
+	}
+
+	content, err := utils.Content(notifyOptions.File)
+	if err != nil {
+		return nil, err
+	}
+	name := notifyOptions.FileName
+	if utils.IsEmpty(name) {
+		name = filepath.Base(notifyOptions.File)
+	}
+	for vendor, messenger := range notifyMessengers() {
+		vendor, messenger := vendor, messenger
+		senders[vendor] = func(params map[string]string) ([]byte, error) {
+			if err := secretsVendor(vendor); err != nil {
+				return nil, err
+			}
+			channel := params["channel"]
+			for _, k := range []string{"chat", "room"} {
+				if utils.IsEmpty(channel) {
+					channel = params[k]
+				}
+			}
+			return messenger().SendFile(vendors.MessengerFile{
+				MessengerMessage: vendors.MessengerMessage{
+					Channel: channel,
+					Thread:  params["thread"],
+					Title:   title,
+					Text:    message,
+				},
+				Name:    name,
+				Content: string(content),
+			})
+		}
+	}
+	return senders, nil
+}
+
+func notifySend(stdout *common.Stdout) (*NotifyReport, error) {
+
+	m, err := notifyMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := notifyRender(stdout, m)
+	if err != nil {
+		return nil, err
+	}
+	params, err := notifyParams()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, v := range common.RemoveEmptyStrings(notifyOptions.Targets) {
+		names = append(names, strings.ToLower(strings.TrimSpace(v)))
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no notify targets")
+	}
+
+	senders, err := notifySenders(m.Title, message)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if _, ok := senders[name]; !ok {
+			known := []string{}
+			for k := range senders {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			if !utils.IsEmpty(notifyOptions.File) {
+				return nil, fmt.Errorf("notify target %s can't send files, known are %s", name, strings.Join(known, ", "))
+			}
+			return nil, fmt.Errorf("unknown notify target %s, known are %s", name, strings.Join(known, ", "))
+		}
+	}
+
+	// time of message is of run, so that retried notify has the same options
+	o := *m
+	o.Time = time.Time{}
+
+	report := &NotifyReport{
+		Message: message,
+		Results: make([]*NotifyResult, len(names)),
+	}
+	var wg sync.WaitGroup
+	for i, name := range names {
+
+		p := map[string]string{"title": m.Title}
+		for k, v := range params[name] {
+			p[k] = v
+		}
+
+		wg.Add(1)
+		go func(i int, name string, p map[string]string) {
+			defer wg.Done()
+
+			stdout.Debug("Notify sending to %s...", name)
+			t := time.Now()
+			// notify of each vendor is operation of its own, so that retry sends to failed vendors only
+			b, err := idempotent(name, []interface{}{o, p}, func() ([]byte, error) {
+				return senders[name](p)
+			})
+
+			r := &NotifyResult{
+				Vendor:   name,
+				Status:   "ok",
+				Duration: time.Since(t).Milliseconds(),
+			}
+			if err != nil {
+				r.Status = "error"
+				r.Error = err.Error()
+			}
+			if len(b) > 0 {
+				if json.Valid(b) {
+					r.Response = b
+				} else {
+					r.Response, _ = json.Marshal(string(b))
+				}
+			}
+			report.Results[i] = r
+		}(i, name, p)
+	}
+	wg.Wait()
+
+	for _, r := range report.Results {
+		if r.Status == "ok" {
+			report.Sent++
+		} else {
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+func NewNotifyCommand() *cobra.Command {
+
+	notifyCmd := &cobra.Command{
+		Use:   "notify",
+		Short: "Send one rendered message to many vendors concurrently",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Notify sending to %s...", strings.Join(notifyOptions.Targets, ","))
+			common.Debug("Notify", notifyOptions, stdout)
+			common.Debug("Notify", notifyOutput, stdout)
+
+			report, err := notifySend(stdout)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes, err := json.Marshal(report)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(notifyOutput, "Notify", []interface{}{notifyOptions}, bytes, stdout)
+
+			if report.Failed > 0 {
+				failed := []string{}
+				for _, r := range report.Results {
+					if r.Status != "ok" {
+						failed = append(failed, r.Vendor)
+					}
+				}
+				stdout.Error("Notify failed for %s", strings.Join(failed, ", "))
+			}
+		},
+	}
+	flags := notifyCmd.PersistentFlags()
+	flags.StringSliceVar(&notifyOptions.Targets, "targets", notifyOptions.Targets, "Notify targets: slack, telegram, teams, discord, mattermost, rocketchat, webex, webhook, email, twilio, sns, sqs, datadog")
+	flags.StringSliceVar(&notifyOptions.Targets, "notify-vendors", notifyOptions.Targets, "Notify targets")
+	flags.MarkDeprecated("notify-vendors", "use --targets instead")
+	flags.StringVar(&notifyOptions.Title, "notify-title", notifyOptions.Title, "Notify title")
+	flags.StringVar(&notifyOptions.Text, "notify-text", notifyOptions.Text, "Notify text")
+	flags.StringVar(&notifyOptions.Severity, "notify-severity", notifyOptions.Severity, "Notify severity, info if empty")
+	flags.StringVar(&notifyOptions.Link, "notify-link", notifyOptions.Link, "Notify link")
+	flags.StringVar(&notifyOptions.Labels, "notify-labels", notifyOptions.Labels, "Notify labels, key=value comma-separated")
+	flags.StringVar(&notifyOptions.Template, "notify-template", notifyOptions.Template, "Notify template rendered once with title, text, severity, link, labels, time and cloud event")
+	flags.StringArrayVar(&notifyOptions.Params, "notify-params", notifyOptions.Params, "Notify vendor param, repeatable: vendor.key=value, e.g. slack.channel=C01, email.to=ops@example.com")
+	flags.StringVar(&notifyOptions.CloudEvent, "notify-cloudevent", notifyOptions.CloudEvent, "Notify CloudEvents JSON file as input, - for stdin, flags override its title, text, severity, link and labels")
+	flags.StringVar(&notifyOptions.File, "notify-file", notifyOptions.File, "Notify file content or path, it's sent with message by chat vendors: slack, telegram, discord, mattermost, rocketchat, webex")
+	flags.StringVar(&notifyOptions.FileName, "notify-file-name", notifyOptions.FileName, "Notify file name, base of file path if empty")
+	flags.StringVar(&notifyOutput.Output, "notify-output", notifyOutput.Output, "Notify output")
+	flags.StringVar(&notifyOutput.Query, "notify-output-query", notifyOutput.Query, "Notify output query")
+
+	return idempotentCommand(notifyCmd)
+}
@@ -1,3 +1,5 @@
+//go:build !minimal || monitoring || observium
+
 package cmd
 
 import (
@@ -66,3 +68,7 @@ func NewObserviumCommand() *cobra.Command {
 
 	return observiumCmd
 }
+
+func init() {
+	registerVendor("observium", vendorGroupMonitoring, NewObserviumCommand, observiumNew)
+}
@@ -0,0 +1,200 @@
+//go:build !minimal || incident || opsgenie
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var opsgenieOptions = vendors.OpsgenieOptions{
+	Timeout:  envGet("OPSGENIE_TIMEOUT", 30).(int),
+	Insecure: envGet("OPSGENIE_INSECURE", false).(bool),
+	URL:      envGet("OPSGENIE_URL", "https://api.opsgenie.com").(string),
+	Key:      envGet("OPSGENIE_KEY", "").(string),
+}
+
+var opsgenieAlertOptions = vendors.OpsgenieAlertOptions{
+	Message:     envGet("OPSGENIE_ALERT_MESSAGE", "").(string),
+	Alias:       envGet("OPSGENIE_ALERT_ALIAS", "").(string),
+	Description: envGet("OPSGENIE_ALERT_DESCRIPTION", "").(string),
+	Responders:  strings.Split(envGet("OPSGENIE_ALERT_RESPONDERS", "").(string), ","),
+	Tags:        strings.Split(envGet("OPSGENIE_ALERT_TAGS", "").(string), ","),
+	Priority:    envGet("OPSGENIE_ALERT_PRIORITY", "P3").(string),
+	Entity:      envGet("OPSGENIE_ALERT_ENTITY", "").(string),
+	Source:      envGet("OPSGENIE_ALERT_SOURCE", "tools").(string),
+	Details:     envGet("OPSGENIE_ALERT_DETAILS", "").(string),
+}
+
+var opsgenieAlertActionOptions = vendors.OpsgenieAlertActionOptions{
+	Identifier:     envGet("OPSGENIE_ALERT_IDENTIFIER", "").(string),
+	IdentifierType: envGet("OPSGENIE_ALERT_IDENTIFIER_TYPE", "alias").(string),
+	User:           envGet("OPSGENIE_ALERT_USER", "").(string),
+	Source:         envGet("OPSGENIE_ALERT_SOURCE", "tools").(string),
+	Note:           envGet("OPSGENIE_ALERT_NOTE", "").(string),
+}
+
+var opsgenieOnCallsOptions = vendors.OpsgenieOnCallsOptions{
+	Schedule:     envGet("OPSGENIE_SCHEDULE", "").(string),
+	ScheduleType: envGet("OPSGENIE_SCHEDULE_TYPE", "name").(string),
+	Flat:         envGet("OPSGENIE_ONCALLS_FLAT", true).(bool),
+	Date:         envGet("OPSGENIE_ONCALLS_DATE", "").(string),
+}
+
+var opsgenieOutput = common.OutputOptions{
+	Output: envGet("OPSGENIE_OUTPUT", "").(string),
+	Query:  envGet("OPSGENIE_OUTPUT_QUERY", "").(string),
+}
+
+func opsgenieNew(stdout *common.Stdout) *vendors.Opsgenie {
+
+	common.Debug("Opsgenie", opsgenieOptions, stdout)
+	common.Debug("Opsgenie", opsgenieOutput, stdout)
+
+	return vendors.NewOpsgenie(opsgenieOptions)
+}
+
+func NewOpsgenieCommand() *cobra.Command {
+
+	opsgenieCmd := &cobra.Command{
+		Use:   "opsgenie",
+		Short: "Opsgenie tools",
+	}
+	flags := opsgenieCmd.PersistentFlags()
+	flags.IntVar(&opsgenieOptions.Timeout, "opsgenie-timeout", opsgenieOptions.Timeout, "Opsgenie timeout in seconds")
+	flags.BoolVar(&opsgenieOptions.Insecure, "opsgenie-insecure", opsgenieOptions.Insecure, "Opsgenie insecure")
+	flags.StringVar(&opsgenieOptions.URL, "opsgenie-url", opsgenieOptions.URL, "Opsgenie URL")
+	flags.StringVar(&opsgenieOptions.Key, "opsgenie-key", opsgenieOptions.Key, "Opsgenie API key")
+	flags.StringVar(&opsgenieOutput.Output, "opsgenie-output", opsgenieOutput.Output, "Opsgenie output")
+	flags.StringVar(&opsgenieOutput.Query, "opsgenie-output-query", opsgenieOutput.Query, "Opsgenie output query")
+
+	alertCmd := &cobra.Command{
+		Use:   "alert",
+		Short: "Alert methods",
+	}
+	opsgenieCmd.AddCommand(alertCmd)
+
+	createAlertCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create alert",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Opsgenie creating alert...")
+			common.Debug("Opsgenie", opsgenieAlertOptions, stdout)
+
+			descriptionBytes, err := utils.Content(opsgenieAlertOptions.Description)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			opsgenieAlertOptions.Description = string(descriptionBytes)
+
+			bytes, err := idempotent("", opsgenieAlertOptions, func() ([]byte, error) {
+				return opsgenieNew(stdout).CreateAlert(opsgenieAlertOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(opsgenieOutput, "Opsgenie", []interface{}{opsgenieOptions, opsgenieAlertOptions}, bytes, stdout)
+		},
+	}
+	flags = createAlertCmd.PersistentFlags()
+	flags.StringVar(&opsgenieAlertOptions.Message, "opsgenie-alert-message", opsgenieAlertOptions.Message, "Opsgenie alert message")
+	flags.StringVar(&opsgenieAlertOptions.Alias, "opsgenie-alert-alias", opsgenieAlertOptions.Alias, "Opsgenie alert alias (dedup key)")
+	flags.StringVar(&opsgenieAlertOptions.Description, "opsgenie-alert-description", opsgenieAlertOptions.Description, "Opsgenie alert description")
+	flags.StringSliceVar(&opsgenieAlertOptions.Responders, "opsgenie-alert-responders", opsgenieAlertOptions.Responders, "Opsgenie alert responders: team:name, user:username, escalation:name, schedule:name")
+	flags.StringSliceVar(&opsgenieAlertOptions.Tags, "opsgenie-alert-tags", opsgenieAlertOptions.Tags, "Opsgenie alert tags")
+	flags.StringVar(&opsgenieAlertOptions.Priority, "opsgenie-alert-priority", opsgenieAlertOptions.Priority, "Opsgenie alert priority: P1-P5")
+	flags.StringVar(&opsgenieAlertOptions.Entity, "opsgenie-alert-entity", opsgenieAlertOptions.Entity, "Opsgenie alert entity")
+	flags.StringVar(&opsgenieAlertOptions.Source, "opsgenie-alert-source", opsgenieAlertOptions.Source, "Opsgenie alert source")
+	flags.StringVar(&opsgenieAlertOptions.Details, "opsgenie-alert-details", opsgenieAlertOptions.Details, "Opsgenie alert details: key1=value1,key2=value2")
+	alertCmd.AddCommand(idempotentCommand(createAlertCmd))
+
+	closeAlertCmd := &cobra.Command{
+		Use:   "close",
+		Short: "Close alert",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Opsgenie closing alert...")
+			common.Debug("Opsgenie", opsgenieAlertActionOptions, stdout)
+
+			bytes, err := idempotent("", opsgenieAlertActionOptions, func() ([]byte, error) {
+				return opsgenieNew(stdout).CloseAlert(opsgenieAlertActionOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(opsgenieOutput, "Opsgenie", []interface{}{opsgenieOptions, opsgenieAlertActionOptions}, bytes, stdout)
+		},
+	}
+	alertCmd.AddCommand(idempotentCommand(closeAlertCmd))
+
+	addNoteCmd := &cobra.Command{
+		Use:   "add-note",
+		Short: "Add note to alert",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Opsgenie adding alert note...")
+			common.Debug("Opsgenie", opsgenieAlertActionOptions, stdout)
+
+			noteBytes, err := utils.Content(opsgenieAlertActionOptions.Note)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			opsgenieAlertActionOptions.Note = string(noteBytes)
+
+			bytes, err := idempotent("", opsgenieAlertActionOptions, func() ([]byte, error) {
+				return opsgenieNew(stdout).AddNote(opsgenieAlertActionOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(opsgenieOutput, "Opsgenie", []interface{}{opsgenieOptions, opsgenieAlertActionOptions}, bytes, stdout)
+		},
+	}
+	alertCmd.AddCommand(idempotentCommand(addNoteCmd))
+
+	for _, c := range []*cobra.Command{closeAlertCmd, addNoteCmd} {
+		flags = c.PersistentFlags()
+		flags.StringVar(&opsgenieAlertActionOptions.Identifier, "opsgenie-alert-identifier", opsgenieAlertActionOptions.Identifier, "Opsgenie alert identifier")
+		flags.StringVar(&opsgenieAlertActionOptions.IdentifierType, "opsgenie-alert-identifier-type", opsgenieAlertActionOptions.IdentifierType, "Opsgenie alert identifier type: id, alias, tiny")
+		flags.StringVar(&opsgenieAlertActionOptions.User, "opsgenie-alert-user", opsgenieAlertActionOptions.User, "Opsgenie alert action user")
+		flags.StringVar(&opsgenieAlertActionOptions.Source, "opsgenie-alert-source", opsgenieAlertActionOptions.Source, "Opsgenie alert action source")
+		flags.StringVar(&opsgenieAlertActionOptions.Note, "opsgenie-alert-note", opsgenieAlertActionOptions.Note, "Opsgenie alert note")
+	}
+
+	getOnCallsCmd := &cobra.Command{
+		Use:   "get-oncalls",
+		Short: "Get who is on call for schedule",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Opsgenie getting on-calls...")
+			common.Debug("Opsgenie", opsgenieOnCallsOptions, stdout)
+
+			bytes, err := opsgenieNew(stdout).GetOnCalls(opsgenieOnCallsOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(opsgenieOutput, "Opsgenie", []interface{}{opsgenieOptions, opsgenieOnCallsOptions}, bytes, stdout)
+		},
+	}
+	flags = getOnCallsCmd.PersistentFlags()
+	flags.StringVar(&opsgenieOnCallsOptions.Schedule, "opsgenie-schedule", opsgenieOnCallsOptions.Schedule, "Opsgenie schedule")
+	flags.StringVar(&opsgenieOnCallsOptions.ScheduleType, "opsgenie-schedule-type", opsgenieOnCallsOptions.ScheduleType, "Opsgenie schedule identifier type: id, name")
+	flags.BoolVar(&opsgenieOnCallsOptions.Flat, "opsgenie-oncalls-flat", opsgenieOnCallsOptions.Flat, "Opsgenie on-calls as flat list of usernames")
+	flags.StringVar(&opsgenieOnCallsOptions.Date, "opsgenie-oncalls-date", opsgenieOnCallsOptions.Date, "Opsgenie on-calls date (RFC3339), now if empty")
+	opsgenieCmd.AddCommand(getOnCallsCmd)
+
+	return opsgenieCmd
+}
+
+func init() {
+	registerVendor("opsgenie", vendorGroupIncident, NewOpsgenieCommand, opsgenieNew)
+}
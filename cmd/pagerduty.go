@@ -1,6 +1,11 @@
+//go:build !minimal || incident || pagerduty
+
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/devopsext/tools/common"
 	"github.com/devopsext/tools/vendors"
 	"github.com/devopsext/utils"
@@ -8,10 +13,11 @@ import (
 )
 
 var pagerDutyOptions = vendors.PagerDutyOptions{
-	Timeout:  envGet("PAGERDUTY_TIMEOUT", 30).(int),
-	Insecure: envGet("PAGERDUTY_INSECURE", false).(bool),
-	URL:      envGet("PAGERDUTY_URL", "").(string),
-	Token:    envGet("PAGERDUTY_TOKEN", "").(string),
+	Timeout:   envGet("PAGERDUTY_TIMEOUT", 30).(int),
+	Insecure:  envGet("PAGERDUTY_INSECURE", false).(bool),
+	URL:       envGet("PAGERDUTY_URL", "").(string),
+	Token:     envGet("PAGERDUTY_TOKEN", "").(string),
+	EventsURL: envGet("PAGERDUTY_EVENTS_URL", "https://events.pagerduty.com").(string),
 }
 
 var pagerDutyGetIncidentsOptions = vendors.PagerDutyGetIncidentsOptions{
@@ -31,6 +37,31 @@ var pagerDutyIncidentOptions = vendors.PagerDutyIncidentOptions{
 	PriorityID: envGet("PAGERDUTY_INCIDENT_PRIORITY_ID", "").(string),
 }
 
+var pagerDutyIncidentNoteOptions = vendors.PagerDutyIncidentNoteOptions{
+	IncidentID:  envGet("PAGERDUTY_INCIDENT_ID", "").(string),
+	NoteContent: envGet("PAGERDUTY_INCIDENT_NOTE", "").(string),
+}
+
+var pagerDutyEventOptions = vendors.PagerDutyEventOptions{
+	RoutingKey:    envGet("PAGERDUTY_EVENT_ROUTING_KEY", "").(string),
+	DedupKey:      envGet("PAGERDUTY_EVENT_DEDUP_KEY", "").(string),
+	Summary:       envGet("PAGERDUTY_EVENT_SUMMARY", "").(string),
+	Source:        envGet("PAGERDUTY_EVENT_SOURCE", "tools").(string),
+	Severity:      envGet("PAGERDUTY_EVENT_SEVERITY", "error").(string),
+	Component:     envGet("PAGERDUTY_EVENT_COMPONENT", "").(string),
+	Group:         envGet("PAGERDUTY_EVENT_GROUP", "").(string),
+	Class:         envGet("PAGERDUTY_EVENT_CLASS", "").(string),
+	CustomDetails: envGet("PAGERDUTY_EVENT_CUSTOM_DETAILS", "").(string),
+}
+
+var pagerDutyOnCallsOptions = vendors.PagerDutyOnCallsOptions{
+	EscalationPolicyIDs: strings.Split(envGet("PAGERDUTY_ONCALLS_ESCALATION_POLICY_IDS", "").(string), ","),
+	ScheduleIDs:         strings.Split(envGet("PAGERDUTY_ONCALLS_SCHEDULE_IDS", "").(string), ","),
+	Since:               envGet("PAGERDUTY_ONCALLS_SINCE", "").(string),
+	Until:               envGet("PAGERDUTY_ONCALLS_UNTIL", "").(string),
+	Earliest:            envGet("PAGERDUTY_ONCALLS_EARLIEST", true).(bool),
+}
+
 var pagerDutyOutput = common.OutputOptions{
 	Output: envGet("PAGERDUTY_OUTPUT", "").(string),
 	Query:  envGet("PAGERDUTY_OUTPUT_QUERY", "").(string),
@@ -41,7 +72,7 @@ func pagerDutyNew(stdout *common.Stdout) *vendors.PagerDuty {
 	common.Debug("PagerDuty", pagerDutyOptions, stdout)
 	common.Debug("PagerDuty", pagerDutyOutput, stdout)
 
-	pagerDuty := vendors.NewPagerDuty(pagerDutyOptions, stdout)
+	pagerDuty := vendors.NewPagerDuty(pagerDutyOptions, stdout.Module("pagerduty"))
 	if pagerDuty == nil {
 		stdout.Panic("No PagerDuty")
 	}
@@ -112,7 +143,9 @@ func NewPagerDutyCommand() *cobra.Command {
 			}
 			pagerDutyIncidentOptions.Body = string(bodyBytes)
 
-			bytes, err := pagerDutyNew(stdout).CreateIncident(pagerDutyIncidentOptions, pagerDutyCreateIncidentOptions)
+			bytes, err := idempotent("", []interface{}{pagerDutyIncidentOptions, pagerDutyCreateIncidentOptions}, func() ([]byte, error) {
+				return pagerDutyNew(stdout).CreateIncident(pagerDutyIncidentOptions, pagerDutyCreateIncidentOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -122,7 +155,119 @@ func NewPagerDutyCommand() *cobra.Command {
 	}
 	flags = createIncidentCmd.PersistentFlags()
 	flags.StringVar(&pagerDutyCreateIncidentOptions.From, "pagerduty-incident-from", pagerDutyCreateIncidentOptions.From, "PagerDuty incident from")
-	incidentCmd.AddCommand(createIncidentCmd)
+	incidentCmd.AddCommand(idempotentCommand(createIncidentCmd))
+
+	// tools pagerduty incident note --pagerduty-incident-id --pagerduty-incident-note
+	noteIncidentCmd := &cobra.Command{
+		Use:   "note",
+		Short: "Add note to incident",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("PagerDuty adding incident note...")
+			common.Debug("PagerDuty", pagerDutyIncidentNoteOptions, stdout)
+
+			noteBytes, err := utils.Content(pagerDutyIncidentNoteOptions.NoteContent)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			pagerDutyIncidentNoteOptions.NoteContent = string(noteBytes)
+
+			bytes, err := idempotent("", []interface{}{pagerDutyIncidentNoteOptions, pagerDutyCreateIncidentOptions}, func() ([]byte, error) {
+				return pagerDutyNew(stdout).CreateIncidentNote(pagerDutyIncidentNoteOptions, pagerDutyCreateIncidentOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(pagerDutyOutput, "PagerDuty", []interface{}{pagerDutyOptions, pagerDutyIncidentNoteOptions}, bytes, stdout)
+		},
+	}
+	flags = noteIncidentCmd.PersistentFlags()
+	flags.StringVar(&pagerDutyIncidentNoteOptions.IncidentID, "pagerduty-incident-id", pagerDutyIncidentNoteOptions.IncidentID, "PagerDuty incident ID")
+	flags.StringVar(&pagerDutyIncidentNoteOptions.NoteContent, "pagerduty-incident-note", pagerDutyIncidentNoteOptions.NoteContent, "PagerDuty incident note")
+	flags.StringVar(&pagerDutyCreateIncidentOptions.From, "pagerduty-incident-from", pagerDutyCreateIncidentOptions.From, "PagerDuty incident from")
+	incidentCmd.AddCommand(idempotentCommand(noteIncidentCmd))
+
+	// tools pagerduty event trigger|acknowledge|resolve --pagerduty-event-routing-key --pagerduty-event-dedup-key
+	eventCmd := &cobra.Command{
+		Use:   "event",
+		Short: "Events v2 methods",
+	}
+	flags = eventCmd.PersistentFlags()
+	flags.StringVar(&pagerDutyOptions.EventsURL, "pagerduty-events-url", pagerDutyOptions.EventsURL, "PagerDuty events URL")
+	flags.StringVar(&pagerDutyEventOptions.RoutingKey, "pagerduty-event-routing-key", pagerDutyEventOptions.RoutingKey, "PagerDuty event routing key")
+	flags.StringVar(&pagerDutyEventOptions.DedupKey, "pagerduty-event-dedup-key", pagerDutyEventOptions.DedupKey, "PagerDuty event dedup key")
+	pagerDutyCmd.AddCommand(eventCmd)
+
+	for _, action := range []string{"trigger", "acknowledge", "resolve"} {
+
+		eventAction := action
+		eventActionCmd := &cobra.Command{
+			Use:   eventAction,
+			Short: fmt.Sprintf("Send %s event", eventAction),
+			Run: func(cmd *cobra.Command, args []string) {
+
+				stdout.Debug("PagerDuty sending %s event...", eventAction)
+				pagerDutyEventOptions.Action = eventAction
+				common.Debug("PagerDuty", pagerDutyEventOptions, stdout)
+
+				detailsBytes, err := utils.Content(pagerDutyEventOptions.CustomDetails)
+				if err != nil {
+					stdout.Panic(err)
+				}
+				pagerDutyEventOptions.CustomDetails = string(detailsBytes)
+
+				bytes, err := idempotent("", pagerDutyEventOptions, func() ([]byte, error) {
+					return pagerDutyNew(stdout).SendEvent(pagerDutyEventOptions)
+				})
+				if err != nil {
+					stdout.Error(err)
+					return
+				}
+				common.OutputJson(pagerDutyOutput, "PagerDuty", []interface{}{pagerDutyOptions, pagerDutyEventOptions}, bytes, stdout)
+			},
+		}
+		if eventAction == "trigger" {
+			flags = eventActionCmd.PersistentFlags()
+			flags.StringVar(&pagerDutyEventOptions.Summary, "pagerduty-event-summary", pagerDutyEventOptions.Summary, "PagerDuty event summary")
+			flags.StringVar(&pagerDutyEventOptions.Source, "pagerduty-event-source", pagerDutyEventOptions.Source, "PagerDuty event source")
+			flags.StringVar(&pagerDutyEventOptions.Severity, "pagerduty-event-severity", pagerDutyEventOptions.Severity, "PagerDuty event severity: critical, error, warning, info")
+			flags.StringVar(&pagerDutyEventOptions.Component, "pagerduty-event-component", pagerDutyEventOptions.Component, "PagerDuty event component")
+			flags.StringVar(&pagerDutyEventOptions.Group, "pagerduty-event-group", pagerDutyEventOptions.Group, "PagerDuty event group")
+			flags.StringVar(&pagerDutyEventOptions.Class, "pagerduty-event-class", pagerDutyEventOptions.Class, "PagerDuty event class")
+			flags.StringVar(&pagerDutyEventOptions.CustomDetails, "pagerduty-event-custom-details", pagerDutyEventOptions.CustomDetails, "PagerDuty event custom details json")
+		}
+		eventCmd.AddCommand(idempotentCommand(eventActionCmd))
+	}
+
+	// tools pagerduty get-oncalls --pagerduty-oncalls-escalation-policy-ids
+	getOnCallsCmd := &cobra.Command{
+		Use:   "get-oncalls",
+		Short: "Get on-calls",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("PagerDuty getting on-calls...")
+			common.Debug("PagerDuty", pagerDutyOnCallsOptions, stdout)
+
+			bytes, err := pagerDutyNew(stdout).GetOnCalls(pagerDutyOnCallsOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(pagerDutyOutput, "PagerDuty", []interface{}{pagerDutyOptions, pagerDutyOnCallsOptions}, bytes, stdout)
+		},
+	}
+	flags = getOnCallsCmd.PersistentFlags()
+	flags.StringSliceVar(&pagerDutyOnCallsOptions.EscalationPolicyIDs, "pagerduty-oncalls-escalation-policy-ids", pagerDutyOnCallsOptions.EscalationPolicyIDs, "PagerDuty on-calls escalation policy IDs")
+	flags.StringSliceVar(&pagerDutyOnCallsOptions.ScheduleIDs, "pagerduty-oncalls-schedule-ids", pagerDutyOnCallsOptions.ScheduleIDs, "PagerDuty on-calls schedule IDs")
+	flags.StringVar(&pagerDutyOnCallsOptions.Since, "pagerduty-oncalls-since", pagerDutyOnCallsOptions.Since, "PagerDuty on-calls since")
+	flags.StringVar(&pagerDutyOnCallsOptions.Until, "pagerduty-oncalls-until", pagerDutyOnCallsOptions.Until, "PagerDuty on-calls until")
+	flags.BoolVar(&pagerDutyOnCallsOptions.Earliest, "pagerduty-oncalls-earliest", pagerDutyOnCallsOptions.Earliest, "PagerDuty on-calls earliest per escalation level")
+	pagerDutyCmd.AddCommand(getOnCallsCmd)
 
 	return pagerDutyCmd
 }
+
+func init() {
+	registerVendor("pagerduty", vendorGroupIncident, NewPagerDutyCommand, pagerDutyNew)
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/devopsext/tools/common"
+	"github.com/spf13/cobra"
+)
+
+func pluginsDefaultDir() string {
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".tools", "plugins")
+}
+
+// plugins are discovered before flags are parsed, so dir is set by env only
+var pluginsOptions = common.PluginsOptions{
+	Dir: envGet("PLUGINS_DIR", pluginsDefaultDir()).(string),
+}
+
+var pluginsOutput = common.OutputOptions{
+	Output: envGet("PLUGINS_OUTPUT", "").(string),
+	Query:  envGet("PLUGINS_OUTPUT_QUERY", "").(string),
+}
+
+func pluginRun(plugin *common.Plugin, args []string) {
+
+	stdout.Debug("Plugin %s running %s %v...", plugin.Name, plugin.Path, args)
+
+	c := exec.Command(plugin.Path, args...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(), fmt.Sprintf("%s_PLUGIN_NAME=%s", APPNAME, plugin.Name))
+
+	err := c.Run()
+	if err == nil {
+		return
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	stdout.Error(err)
+	os.Exit(1)
+}
+
+// addPluginCommands adds plugin commands to root, built-in commands can't be overridden
+func addPluginCommands(rootCmd *cobra.Command) {
+
+	plugins, err := common.FindPlugins(pluginsOptions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Plugins error: %s\n", err)
+		return
+	}
+
+	for _, p := range plugins {
+
+		exists := false
+		for _, c := range rootCmd.Commands() {
+			if c.Name() == p.Name {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+
+		plugin := p
+		if plugin.Vendor {
+			rootCmd.AddCommand(pluginVendorCommand(plugin))
+			continue
+		}
+		rootCmd.AddCommand(&cobra.Command{
+			Use:                fmt.Sprintf("%s [args]", plugin.Name),
+			Short:              fmt.Sprintf("Plugin %s", plugin.Path),
+			DisableFlagParsing: true,
+			Run: func(cmd *cobra.Command, args []string) {
+				pluginRun(plugin, args)
+			},
+		})
+	}
+}
+
+// pluginVendorCommand executes methods of vendor plugin, e.g. tools acme send-message --vendors-options '{...}',
+// flags are of vendors command, as options of plugin are JSON object
+func pluginVendorCommand(plugin *common.Plugin) *cobra.Command {
+
+	vendorCmd := &cobra.Command{
+		Use:   fmt.Sprintf("%s [method]", plugin.Name),
+		Short: fmt.Sprintf("Vendor plugin %s", plugin.Path),
+		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+
+			// Go plugins aren't opened by completion, as it's slow
+			if len(args) > 0 || filepath.Ext(plugin.Path) == ".so" {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			methods, err := common.NewVendorExec(plugin.Name, plugin.Path, stdout).Methods()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			names := []string{}
+			for _, m := range methods {
+				names = append(names, m.Name)
+			}
+			return completionFilter(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Vendor plugin %s method %s executing...", plugin.Name, args[0])
+
+			bytes, err := vendorsExecute(plugin.Name, args[0])
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(vendorsOutput, plugin.Name, []interface{}{vendorsOptions}, bytes, stdout)
+		},
+	}
+	flags := vendorCmd.PersistentFlags()
+	flags.StringVar(&vendorsOptions, "vendors-options", vendorsOptions, "Vendors JSON object of options of method, - is stdin")
+	flags.StringVar(&vendorsOutput.Output, "vendors-output", vendorsOutput.Output, "Vendors output")
+	flags.StringVar(&vendorsOutput.Query, "vendors-output-query", vendorsOutput.Query, "Vendors output query")
+	return vendorCmd
+}
+
+func NewPluginsCommand() *cobra.Command {
+
+	pluginsCmd := &cobra.Command{
+		Use:   "plugins",
+		Short: "Plugin tools",
+	}
+	flags := pluginsCmd.PersistentFlags()
+	flags.StringVar(&pluginsOutput.Output, "plugins-output", pluginsOutput.Output, "Plugins output")
+	flags.StringVar(&pluginsOutput.Query, "plugins-output-query", pluginsOutput.Query, "Plugins output query")
+
+	pluginsCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List plugins",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Plugins listing...")
+
+			plugins, err := common.FindPlugins(pluginsOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes, err := json.Marshal(plugins)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(pluginsOutput, "Plugins", []interface{}{pluginsOptions}, bytes, stdout)
+		},
+	})
+
+	return pluginsCmd
+}
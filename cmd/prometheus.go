@@ -1,3 +1,5 @@
+//go:build !minimal || monitoring || prometheus
+
 package cmd
 
 import (
@@ -82,3 +84,23 @@ func NewPrometheusCommand() *cobra.Command {
 
 	return prometheusCmd
 }
+
+func enrichmentPrometheus(params map[string]string) (interface{}, error) {
+
+	opts := prometheusOptions
+	if !utils.IsEmpty(params["url"]) {
+		opts.URL = params["url"]
+	}
+	opts.Query = params["query"]
+	opts.From = params["from"]
+	opts.To = params["to"]
+	if !utils.IsEmpty(params["step"]) {
+		opts.Step = params["step"]
+	}
+	return enrichmentJson(vendors.NewPrometheus(opts).Get())
+}
+
+func init() {
+	registerVendor("prometheus", vendorGroupMonitoring, NewPrometheusCommand, prometheusNew)
+	registerVendorEnricher("prometheus", enrichmentPrometheus)
+}
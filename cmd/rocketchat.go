@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var rocketChatOptions = vendors.RocketChatOptions{
+	Timeout:  envGet("ROCKETCHAT_TIMEOUT", 30).(int),
+	Insecure: envGet("ROCKETCHAT_INSECURE", false).(bool),
+	URL:      envGet("ROCKETCHAT_URL", "").(string),
+	UserID:   envGet("ROCKETCHAT_USER_ID", "").(string),
+	Token:    envGet("ROCKETCHAT_TOKEN", "").(string),
+}
+
+var rocketChatMessageOptions = vendors.RocketChatMessageOptions{
+	Channel:     envGet("ROCKETCHAT_CHANNEL", "").(string),
+	Thread:      envGet("ROCKETCHAT_THREAD", "").(string),
+	Text:        envGet("ROCKETCHAT_TEXT", "").(string),
+	Alias:       envGet("ROCKETCHAT_ALIAS", "").(string),
+	Emoji:       envGet("ROCKETCHAT_EMOJI", "").(string),
+	Avatar:      envGet("ROCKETCHAT_AVATAR", "").(string),
+	Attachments: envGet("ROCKETCHAT_ATTACHMENTS", "").(string),
+}
+
+var rocketChatFileOptions = vendors.RocketChatFileOptions{
+	RoomID:      envGet("ROCKETCHAT_ROOM_ID", "").(string),
+	Thread:      envGet("ROCKETCHAT_THREAD", "").(string),
+	Text:        envGet("ROCKETCHAT_TEXT", "").(string),
+	Description: envGet("ROCKETCHAT_FILE_DESCRIPTION", "").(string),
+	Name:        envGet("ROCKETCHAT_FILE_NAME", "").(string),
+	Content:     envGet("ROCKETCHAT_FILE_CONTENT", "").(string),
+}
+
+var rocketChatChannelOptions = vendors.RocketChatChannelOptions{
+	Name:     envGet("ROCKETCHAT_CHANNEL_NAME", "").(string),
+	Members:  strings.Split(envGet("ROCKETCHAT_CHANNEL_MEMBERS", "").(string), ","),
+	Private:  envGet("ROCKETCHAT_CHANNEL_PRIVATE", false).(bool),
+	ReadOnly: envGet("ROCKETCHAT_CHANNEL_READ_ONLY", false).(bool),
+}
+
+var rocketChatOutput = common.OutputOptions{
+	Output: envGet("ROCKETCHAT_OUTPUT", "").(string),
+	Query:  envGet("ROCKETCHAT_OUTPUT_QUERY", "").(string),
+}
+
+func rocketChatNew(stdout *common.Stdout) *vendors.RocketChat {
+
+	common.Debug("RocketChat", rocketChatOptions, stdout)
+	common.Debug("RocketChat", rocketChatOutput, stdout)
+
+	rocketChatMessageOptions.Channel = serviceChannel(stdout, "rocketchat", rocketChatMessageOptions.Channel)
+
+	return vendors.NewRocketChat(rocketChatOptions)
+}
+
+func NewRocketChatCommand() *cobra.Command {
+
+	rocketChatCmd := &cobra.Command{
+		Use:   "rocketchat",
+		Short: "Rocket.Chat tools",
+	}
+
+	flags := rocketChatCmd.PersistentFlags()
+	flags.IntVar(&rocketChatOptions.Timeout, "rocketchat-timeout", rocketChatOptions.Timeout, "Rocket.Chat timeout")
+	flags.BoolVar(&rocketChatOptions.Insecure, "rocketchat-insecure", rocketChatOptions.Insecure, "Rocket.Chat insecure")
+	flags.StringVar(&rocketChatOptions.URL, "rocketchat-url", rocketChatOptions.URL, "Rocket.Chat URL")
+	flags.StringVar(&rocketChatOptions.UserID, "rocketchat-user-id", rocketChatOptions.UserID, "Rocket.Chat user ID of personal access token")
+	flags.StringVar(&rocketChatOptions.Token, "rocketchat-token", rocketChatOptions.Token, "Rocket.Chat personal access token")
+	flags.StringVar(&rocketChatOutput.Output, "rocketchat-output", rocketChatOutput.Output, "Rocket.Chat output")
+	flags.StringVar(&rocketChatOutput.Query, "rocketchat-output-query", rocketChatOutput.Query, "Rocket.Chat output query")
+
+	sendMessage := &cobra.Command{
+		Use:   "send-message",
+		Short: "Send message",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Rocket.Chat sending message...")
+			common.Debug("RocketChat", rocketChatMessageOptions, stdout)
+
+			textBytes, err := utils.Content(rocketChatMessageOptions.Text)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			rocketChatMessageOptions.Text = string(textBytes)
+
+			if !hooksPreSend(stdout, "rocketchat", &rocketChatMessageOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", rocketChatMessageOptions, func() ([]byte, error) {
+				return rocketChatNew(stdout).SendMessage(rocketChatMessageOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "rocketchat", bytes)
+			common.OutputJson(rocketChatOutput, "RocketChat", []interface{}{rocketChatOptions, rocketChatMessageOptions}, bytes, stdout)
+		},
+	}
+	flags = sendMessage.PersistentFlags()
+	flags.StringVar(&rocketChatMessageOptions.Channel, "rocketchat-channel", rocketChatMessageOptions.Channel, "Rocket.Chat channel (#channel, @user or room ID)")
+	flags.StringVar(&rocketChatMessageOptions.Thread, "rocketchat-thread", rocketChatMessageOptions.Thread, "Rocket.Chat thread message ID to reply in")
+	flags.StringVar(&rocketChatMessageOptions.Text, "rocketchat-text", rocketChatMessageOptions.Text, "Rocket.Chat text")
+	flags.StringVar(&rocketChatMessageOptions.Alias, "rocketchat-alias", rocketChatMessageOptions.Alias, "Rocket.Chat sender alias")
+	flags.StringVar(&rocketChatMessageOptions.Emoji, "rocketchat-emoji", rocketChatMessageOptions.Emoji, "Rocket.Chat sender emoji")
+	flags.StringVar(&rocketChatMessageOptions.Avatar, "rocketchat-avatar", rocketChatMessageOptions.Avatar, "Rocket.Chat sender avatar URL")
+	flags.StringVar(&rocketChatMessageOptions.Attachments, "rocketchat-attachments", rocketChatMessageOptions.Attachments, "Rocket.Chat attachments json")
+	rocketChatCmd.AddCommand(idempotentCommand(sendMessage))
+
+	sendFile := &cobra.Command{
+		Use:   "send-file",
+		Short: "Upload file to room",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Rocket.Chat sending file...")
+			common.Debug("RocketChat", rocketChatFileOptions, stdout)
+
+			textBytes, err := utils.Content(rocketChatFileOptions.Text)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			rocketChatFileOptions.Text = string(textBytes)
+
+			contentBytes, err := utils.Content(rocketChatFileOptions.Content)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			rocketChatFileOptions.Content = string(contentBytes)
+
+			if !hooksPreSend(stdout, "rocketchat", &rocketChatFileOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", rocketChatFileOptions, func() ([]byte, error) {
+				return rocketChatNew(stdout).SendFile(rocketChatFileOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "rocketchat", bytes)
+			common.OutputJson(rocketChatOutput, "RocketChat", []interface{}{rocketChatOptions, rocketChatFileOptions}, bytes, stdout)
+		},
+	}
+	flags = sendFile.PersistentFlags()
+	flags.StringVar(&rocketChatFileOptions.RoomID, "rocketchat-room-id", rocketChatFileOptions.RoomID, "Rocket.Chat room ID")
+	flags.StringVar(&rocketChatFileOptions.Thread, "rocketchat-thread", rocketChatFileOptions.Thread, "Rocket.Chat thread message ID")
+	flags.StringVar(&rocketChatFileOptions.Text, "rocketchat-text", rocketChatFileOptions.Text, "Rocket.Chat text")
+	flags.StringVar(&rocketChatFileOptions.Description, "rocketchat-file-description", rocketChatFileOptions.Description, "Rocket.Chat file description")
+	flags.StringVar(&rocketChatFileOptions.Name, "rocketchat-file-name", rocketChatFileOptions.Name, "Rocket.Chat file name")
+	flags.StringVar(&rocketChatFileOptions.Content, "rocketchat-file-content", rocketChatFileOptions.Content, "Rocket.Chat file content or path")
+	rocketChatCmd.AddCommand(idempotentCommand(sendFile))
+
+	createChannel := &cobra.Command{
+		Use:   "create-channel",
+		Short: "Create channel",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Rocket.Chat creating channel %s...", rocketChatChannelOptions.Name)
+			common.Debug("RocketChat", rocketChatChannelOptions, stdout)
+
+			bytes, err := idempotent("", rocketChatChannelOptions, func() ([]byte, error) {
+				return rocketChatNew(stdout).CreateChannel(rocketChatChannelOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(rocketChatOutput, "RocketChat", []interface{}{rocketChatOptions, rocketChatChannelOptions}, bytes, stdout)
+		},
+	}
+	flags = createChannel.PersistentFlags()
+	flags.StringVar(&rocketChatChannelOptions.Name, "rocketchat-channel-name", rocketChatChannelOptions.Name, "Rocket.Chat channel name")
+	flags.StringSliceVar(&rocketChatChannelOptions.Members, "rocketchat-channel-members", rocketChatChannelOptions.Members, "Rocket.Chat channel member usernames")
+	flags.BoolVar(&rocketChatChannelOptions.Private, "rocketchat-channel-private", rocketChatChannelOptions.Private, "Rocket.Chat private group instead of channel")
+	flags.BoolVar(&rocketChatChannelOptions.ReadOnly, "rocketchat-channel-read-only", rocketChatChannelOptions.ReadOnly, "Rocket.Chat read only channel")
+	rocketChatCmd.AddCommand(idempotentCommand(createChannel))
+
+	return rocketChatCmd
+}
+
+func init() {
+	registerVendor("rocketchat", vendorGroupChat, NewRocketChatCommand, rocketChatNew)
+}
@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/devopsext/tools/common"
@@ -20,6 +24,42 @@ var stdoutOptions = common.StdoutOptions{
 	Template:        envGet("STDOUT_TEMPLATE", "{{.file}} {{.msg}}").(string),
 	TimestampFormat: envGet("STDOUT_TIMESTAMP_FORMAT", time.RFC3339Nano).(string),
 	TextColors:      envGet("STDOUT_TEXT_COLORS", true).(bool),
+	Stderr:          envGet("STDOUT_STDERR", false).(bool),
+	Levels:          strings.Split(envGet("STDOUT_LEVELS", "").(string), ","),
+	Fields:          strings.Split(envGet("STDOUT_FIELDS", "").(string), ","),
+	Sampling:        envGet("STDOUT_SAMPLING", 0).(int),
+	File:            envGet("STDOUT_FILE", "").(string),
+}
+
+var httpClientOptions = common.HttpClientOptions{
+	Retries:         envGet("HTTP_RETRIES", 2).(int),
+	RetryDelay:      envGet("HTTP_RETRY_DELAY", 500).(int),
+	RetryMaxDelay:   envGet("HTTP_RETRY_MAX_DELAY", 10000).(int),
+	RetryPost:       envGet("HTTP_RETRY_POST", false).(bool),
+	BreakerFailures: envGet("HTTP_BREAKER_FAILURES", 5).(int),
+	BreakerTimeout:  envGet("HTTP_BREAKER_TIMEOUT", 30).(int),
+	MaxRequestSize:  int64(envGet("HTTP_MAX_REQUEST_SIZE", 0).(int)),
+	MaxResponseSize: int64(envGet("HTTP_MAX_RESPONSE_SIZE", 0).(int)),
+	Raw:             envGet("HTTP_RAW", false).(bool),
+	DryRun:          envGet("DRY_RUN", false).(bool),
+	Debug:           envGet("HTTP_DEBUG", false).(bool),
+	ConnectTimeout:  envGet("HTTP_CONNECT_TIMEOUT", 0).(int),
+	RequestTimeout:  envGet("HTTP_REQUEST_TIMEOUT", 0).(int),
+	Proxy:           envGet("HTTP_PROXY", "").(string),
+	NoProxy:         strings.Split(envGet("HTTP_NO_PROXY", "").(string), ","),
+	CAFile:          envGet("HTTP_CA_FILE", "").(string),
+	CertFile:        envGet("HTTP_CERT_FILE", "").(string),
+	KeyFile:         envGet("HTTP_KEY_FILE", "").(string),
+	CacheTTL:        envGet("HTTP_CACHE_TTL", 0).(int),
+	CacheDir:        envGet("HTTP_CACHE_DIR", "").(string),
+}
+
+var outputFormatOptions = common.OutputFormatOptions{
+	Format:      envGet("OUTPUT_FORMAT", common.OutputFormatJson).(string),
+	Columns:     strings.Split(envGet("OUTPUT_COLUMNS", "").(string), ","),
+	Template:    envGet("OUTPUT_TEMPLATE", "").(string),
+	QueryEngine: envGet("OUTPUT_QUERY_ENGINE", common.OutputQueryJsonata).(string),
+	Plain:       envGet("OUTPUT_PLAIN", false).(bool),
 }
 
 func getOnlyEnv(key string) string {
@@ -30,8 +70,13 @@ func getOnlyEnv(key string) string {
 	return fmt.Sprintf("$%s", key)
 }
 
+// envDefaults keeps env vars read by envGet with their defaults, so that commands can be described
+var envDefaults = make(map[string]interface{})
+
 func envGet(s string, def interface{}) interface{} {
-	return utils.EnvGet(fmt.Sprintf("%s_%s", APPNAME, s), def)
+	key := fmt.Sprintf("%s_%s", APPNAME, s)
+	envDefaults[key] = def
+	return utils.EnvGet(key, def)
 }
 
 func envStringExpand(s string, def string) string {
@@ -52,19 +97,155 @@ func Execute() {
 	rootCmd := &cobra.Command{
 		Use:   "tools",
 		Short: "Tools",
+		// errors of flags are written by stdout, so that they are of error format as well
+		SilenceErrors: true,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if completionCommand(cmd) {
+				stdout = completionStdout()
+				return
+			}
+			// config is applied first, so that it has stdout options as well
+			unknown, err := configApply(cmd)
+			stdoutOptions.Stderr = stdoutOptions.Stderr || outputFormatOptions.Plain
 			stdout = common.NewStdout(stdoutOptions)
 			stdout.SetCallerOffset(1)
+			stdout.SetErrorHandler(exitError)
+			exitCommand(cmd)
+			idempotencyStart(cmd)
+			if err != nil {
+				exitConfig(err)
+			}
+			if len(unknown) > 0 {
+				stdout.Debug("Config keys without flags: %s", strings.Join(unknown, ", "))
+			}
+			if !utils.Contains([]string{"text", "json"}, strings.ToLower(errorFormat)) {
+				exitConfig("Unknown error format %s, it should be text or json", errorFormat)
+			}
+			if err := common.HttpValidateOptions(httpClientOptions); err != nil {
+				exitConfig(err)
+			}
+			common.SetHttpClientOptions(httpClientOptions)
+			common.SetHttpStdout(stdout.Module("http"))
+			if !utils.Contains(common.OutputFormats, strings.ToLower(outputFormatOptions.Format)) {
+				exitConfig("Unknown output format %s, it should be one of %s", outputFormatOptions.Format, strings.Join(common.OutputFormats, ", "))
+			}
+			if !utils.Contains([]string{common.OutputQueryJsonata, common.OutputQueryJq}, strings.ToLower(outputFormatOptions.QueryEngine)) {
+				exitConfig("Unknown output query engine %s, it should be jsonata or jq", outputFormatOptions.QueryEngine)
+			}
+			common.SetOutputFormatOptions(outputFormatOptions)
+			if err := auditStart(cmd); err != nil {
+				exitConfig(err)
+			}
+			if err := messageResolve(cmd); err != nil {
+				exitConfig(err)
+			}
+			if err := secretsResolve(cmd); err != nil {
+				exitConfig(err)
+			}
+			if err := stdinResolve(cmd); err != nil {
+				exitConfig(err)
+			}
+			if err := interactiveResolve(cmd); err != nil {
+				exitConfig(err)
+			}
+			deadlineStart(cmd)
+			telemetryStart(cmd)
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if completionCommand(cmd) {
+				return
+			}
+			telemetrySend()
+			metricsPush()
+			deadlineCancel()
+			common.CloseAudit()
 		},
 	}
 
+	// completion command is ours, so that it's described and skips config like its requests of dynamic completion
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
 	flags := rootCmd.PersistentFlags()
 
+	flags.StringVar(&configOptions.File, "config", configOptions.File, "Config YAML file of options and profiles, ~/.config/tools/config.yaml is used if it exists")
+	flags.StringVar(&configOptions.Profile, "profile", configOptions.Profile, "Config profile, e.g. prod or staging, options of profile are over options of config")
+
+	flags.BoolVar(&interactive, "interactive", interactive, "Interactive mode prompts for required options which aren't set by flags, env vars or config, input of secrets is hidden")
+	flags.StringVar(&errorFormat, "error-format", errorFormat, "Error format of failed command: text, or json object written to stderr with class, exit code and message")
+
 	flags.StringVar(&stdoutOptions.Format, "stdout-format", stdoutOptions.Format, "Stdout format: json, text, template")
 	flags.StringVar(&stdoutOptions.Level, "stdout-level", stdoutOptions.Level, "Stdout level: info, warn, error, debug, panic")
 	flags.StringVar(&stdoutOptions.Template, "stdout-template", stdoutOptions.Template, "Stdout template")
 	flags.StringVar(&stdoutOptions.TimestampFormat, "stdout-timestamp-format", stdoutOptions.TimestampFormat, "Stdout timestamp format")
 	flags.BoolVar(&stdoutOptions.TextColors, "stdout-text-colors", stdoutOptions.TextColors, "Stdout text colors")
+	flags.BoolVar(&stdoutOptions.Stderr, "stdout-stderr", stdoutOptions.Stderr, "Stdout logs are written to stderr, it's set by plain output as well")
+	flags.StringSliceVar(&stdoutOptions.Levels, "stdout-levels", stdoutOptions.Levels, "Stdout levels of modules over stdout level: module=level, modules are http, vendors and server sources, e.g. http=debug,google=warn")
+	flags.StringSliceVar(&stdoutOptions.Fields, "stdout-fields", stdoutOptions.Fields, "Stdout fields of all logs: key=value, e.g. job=deploy,env=prod")
+	flags.IntVar(&stdoutOptions.Sampling, "stdout-sampling", stdoutOptions.Sampling, "Stdout debug logs of the same caller per second, the rest is dropped and counted by sampled field, unlimited if 0")
+	flags.StringVar(&stdoutOptions.File, "stdout-file", stdoutOptions.File, "Stdout file which logs are appended to as well, e.g. of server")
+
+	flags.IntVar(&httpClientOptions.Retries, "http-retries", httpClientOptions.Retries, "HTTP retries of vendor requests on network errors, 429 and 5xx")
+	flags.IntVar(&httpClientOptions.RetryDelay, "http-retry-delay", httpClientOptions.RetryDelay, "HTTP delay before first retry in milliseconds, it's doubled with jitter for next ones")
+	flags.IntVar(&httpClientOptions.RetryMaxDelay, "http-retry-max-delay", httpClientOptions.RetryMaxDelay, "HTTP max delay between retries in milliseconds")
+	flags.BoolVar(&httpClientOptions.RetryPost, "http-retry-post", httpClientOptions.RetryPost, "HTTP retries of POST and PATCH which aren't idempotent")
+	flags.IntVar(&httpClientOptions.BreakerFailures, "http-breaker-failures", httpClientOptions.BreakerFailures, "HTTP failures of host in a row which open circuit breaker, disabled if 0")
+	flags.IntVar(&httpClientOptions.BreakerTimeout, "http-breaker-timeout", httpClientOptions.BreakerTimeout, "HTTP seconds of open circuit breaker before host is probed")
+	flags.Int64Var(&httpClientOptions.MaxRequestSize, "http-max-request-size", httpClientOptions.MaxRequestSize, "HTTP max request size in bytes, unlimited if 0")
+	flags.Int64Var(&httpClientOptions.MaxResponseSize, "http-max-response-size", httpClientOptions.MaxResponseSize, "HTTP max response size in bytes, unlimited if 0")
+	flags.BoolVar(&httpClientOptions.DryRun, "dry-run", httpClientOptions.DryRun, "Requests of vendors except of GET, HEAD and OPTIONS aren't sent, they are output with secrets redacted")
+	flags.IntVar(&httpClientOptions.ConnectTimeout, "http-connect-timeout", httpClientOptions.ConnectTimeout, "HTTP timeout of connect and TLS handshake in seconds, timeout of vendor if 0")
+	flags.IntVar(&httpClientOptions.RequestTimeout, "http-request-timeout", httpClientOptions.RequestTimeout, "HTTP timeout of each request attempt in seconds, timeout of vendor covers all attempts")
+	flags.IntVar(&deadline, "deadline", deadline, "Deadline of command in seconds including retries and pagination, requests are cancelled at it, disabled if 0")
+	flags.StringVar(&httpClientOptions.Proxy, "http-proxy", httpClientOptions.Proxy, "HTTP proxy URL of vendor requests: http, https or socks5, e.g. socks5://proxy:1080, HTTP_PROXY and HTTPS_PROXY env vars are used if empty")
+	flags.StringSliceVar(&httpClientOptions.NoProxy, "http-no-proxy", httpClientOptions.NoProxy, "HTTP hosts and domains requested without proxy, e.g. vault.internal,.corp")
+	flags.StringVar(&httpClientOptions.CAFile, "http-ca-file", httpClientOptions.CAFile, "HTTP CA bundle PEM file added to system CAs, e.g. of private CA of egress proxy")
+	flags.StringVar(&httpClientOptions.CertFile, "http-cert-file", httpClientOptions.CertFile, "HTTP client certificate PEM file of mTLS")
+	flags.StringVar(&httpClientOptions.KeyFile, "http-key-file", httpClientOptions.KeyFile, "HTTP client key PEM file of mTLS")
+	flags.IntVar(&httpClientOptions.CacheTTL, "http-cache-ttl", httpClientOptions.CacheTTL, "HTTP seconds of responses of GET and HEAD being cached on disk, e.g. of user and channel lookups, disabled if 0")
+	flags.StringVar(&httpClientOptions.CacheDir, "http-cache-dir", httpClientOptions.CacheDir, "HTTP cache dir, user cache dir tools/http if empty, e.g. ~/.cache/tools/http")
+	flags.BoolVar(&httpClientOptions.Debug, "http-debug", httpClientOptions.Debug, "HTTP requests and responses of vendors are logged with timing, headers and bodies, secrets are redacted")
+	flags.BoolVar(&httpClientOptions.Raw, "http-raw", httpClientOptions.Raw, "HTTP raw mode, responses of non-2xx are output as is and don't fail commands")
+
+	flags.StringVar(&outputFormatOptions.Format, "output-format", outputFormatOptions.Format, "Output format of responses: json, yaml, table, tsv, raw, template")
+	flags.StringSliceVar(&outputFormatOptions.Columns, "output-columns", outputFormatOptions.Columns, "Output columns of table and tsv, keys or paths like user.name, all keys if empty")
+	flags.StringVar(&outputFormatOptions.Template, "output-template", outputFormatOptions.Template, "Output Go template file or content over parsed response")
+	flags.StringVar(&outputFormatOptions.QueryEngine, "output-query-engine", outputFormatOptions.QueryEngine, "Output query engine of vendor output queries: jsonata, jq")
+	flags.BoolVar(&outputFormatOptions.Plain, "output-plain", outputFormatOptions.Plain, "Output is written to stdout as is and logs to stderr, e.g. for pipelines or to capture value by $(...)")
+
+	flags.StringVar(&idempotencyOptions.Key, "idempotency-key", idempotencyOptions.Key, "Idempotency key of sending and creating commands, operation of command and key is done once, e.g. by retried CI job of $CI_PIPELINE_ID")
+	flags.StringVar(&idempotencyOptions.Store, "idempotency-store", idempotencyOptions.Store, "Idempotency store of done operations: dir or redis://[:password@]host:port[/db], user cache dir tools/idempotency if empty")
+	flags.IntVar(&idempotencyOptions.TTL, "idempotency-ttl", idempotencyOptions.TTL, "Idempotency seconds of done operations being kept")
+	flags.IntVar(&idempotencyOptions.Timeout, "idempotency-timeout", idempotencyOptions.Timeout, "Idempotency Redis store timeout in seconds")
+
+	flags.StringVar(&auditOptions.Log, "audit-log", auditOptions.Log, "Audit log of mutating vendor requests: JSON lines file appended to, syslog or syslog://host:514, disabled if empty")
+	flags.StringVar(&auditOptions.Actor, "audit-actor", auditOptions.Actor, "Audit actor of records, CI user like $GITHUB_ACTOR or OS user if empty")
+	flags.StringVar(&auditOptions.CorrelationID, "audit-correlation-id", auditOptions.CorrelationID, "Audit correlation id of records of command, e.g. $CI_PIPELINE_ID, generated if empty")
+
+	flags.StringVar(&servicesOptions.Service, "service", servicesOptions.Service, "Service name from service catalog")
+	flags.StringVar(&servicesOptions.File, "services-file", servicesOptions.File, "Service catalog YAML file")
+	flags.StringVar(&servicesBackstageOptions.URL, "services-backstage-url", servicesBackstageOptions.URL, "Service catalog Backstage URL")
+	flags.StringVar(&servicesBackstageOptions.Token, "services-backstage-token", servicesBackstageOptions.Token, "Service catalog Backstage token")
+	flags.StringVar(&servicesBackstageOptions.Namespace, "services-backstage-namespace", servicesBackstageOptions.Namespace, "Service catalog Backstage namespace")
+	flags.StringVar(&enrichmentOptions.File, "enrichment-file", enrichmentOptions.File, "Enrichment steps YAML file")
+	flags.StringVar(&hooksOptions.File, "hooks-file", hooksOptions.File, "Starlark hooks file with pre_send and post_response functions")
+	flags.StringVar(&telemetryOptions.URL, "telemetry-url", telemetryOptions.URL, "Telemetry URL to post usage events to, telemetry is disabled if empty")
+	flags.IntVar(&telemetryOptions.Timeout, "telemetry-timeout", telemetryOptions.Timeout, "Telemetry timeout in seconds")
+	flags.BoolVar(&telemetryOptions.Insecure, "telemetry-insecure", telemetryOptions.Insecure, "Telemetry insecure")
+	flags.StringVar(&telemetryOptions.Source, "telemetry-source", telemetryOptions.Source, "Telemetry source, e.g. team or pipeline name")
+	flags.StringSliceVar(&messageOptions.Vars, "vars", messageOptions.Vars, "Vars of message templates: key=value, e.g. --slack-text 'Deployed {{ .Vars.version }}'")
+	flags.BoolVar(&messageOptions.Templates, "message-templates", messageOptions.Templates, "Messages of send commands having {{ are rendered as templates of vars and helpers, messages of stdin and files are sent as is")
+	flags.BoolVar(&messageOptions.Env, "message-env", messageOptions.Env, "Message templates have env vars of .Env and env, and include of files")
+	flags.StringVar(&metricsOptions.PushURL, "metrics-push-url", metricsOptions.PushURL, "Metrics Pushgateway URL to push metrics of requests to after command, e.g. http://pushgateway:9091")
+	flags.StringVar(&metricsOptions.PushJob, "metrics-push-job", metricsOptions.PushJob, "Metrics Pushgateway job")
+	flags.IntVar(&metricsOptions.Timeout, "metrics-push-timeout", metricsOptions.Timeout, "Metrics Pushgateway timeout in seconds")
+	flags.BoolVar(&metricsOptions.Insecure, "metrics-push-insecure", metricsOptions.Insecure, "Metrics Pushgateway insecure")
+
+	rootCmd.RegisterFlagCompletionFunc("profile", completionProfiles)
+	rootCmd.RegisterFlagCompletionFunc("error-format", completionValues("text", "json"))
+	rootCmd.RegisterFlagCompletionFunc("stdout-format", completionValues("json", "text", "template"))
+	rootCmd.RegisterFlagCompletionFunc("stdout-level", completionValues("info", "warn", "error", "debug", "panic"))
+	rootCmd.RegisterFlagCompletionFunc("output-format", completionValues(common.OutputFormats...))
+	rootCmd.RegisterFlagCompletionFunc("output-query-engine", completionValues(common.OutputQueryJsonata, common.OutputQueryJq))
 
 	rootCmd.AddCommand(&cobra.Command{
 		Use:   "version",
@@ -74,28 +255,46 @@ func Execute() {
 		},
 	})
 
-	rootCmd.AddCommand(NewSlackCommand())
-	rootCmd.AddCommand(NewTelegramCommand())
-	rootCmd.AddCommand(NewGraylogCommand())
-	rootCmd.AddCommand(NewJiraCommand())
-	rootCmd.AddCommand(NewGrafanaCommand())
+	addVendorCommands(rootCmd)
+
 	rootCmd.AddCommand(NewJSONCommand())
-	rootCmd.AddCommand(NewGitlabCommand())
-	rootCmd.AddCommand(NewGoogleCommand())
-	rootCmd.AddCommand(NewPrometheusCommand())
-	rootCmd.AddCommand(NewObserviumCommand())
-	rootCmd.AddCommand(NewZabbixCommand())
-	rootCmd.AddCommand(NewVCenterCommand())
-	rootCmd.AddCommand(NewPagerDutyCommand())
-	rootCmd.AddCommand(NewAWSCommand())
-	rootCmd.AddCommand(NewSite24x7Command())
-	rootCmd.AddCommand(NewCatchpointCommand())
+	rootCmd.AddCommand(NewServicesCommand())
+	rootCmd.AddCommand(NewEnrichmentCommand())
 
+	rootCmd.AddCommand(NewExecCommand())
+	rootCmd.AddCommand(NewSSHCommand())
+	rootCmd.AddCommand(NewServerCommand())
+	rootCmd.AddCommand(NewServeCommand())
+	rootCmd.AddCommand(NewBatchCommand())
+	rootCmd.AddCommand(NewMonitorCommand())
+	rootCmd.AddCommand(NewServiceCommand())
+	rootCmd.AddCommand(NewNotifyCommand())
 	rootCmd.AddCommand(NewTemplateCommand())
 	rootCmd.AddCommand(NewDateCommand())
+	rootCmd.AddCommand(NewPluginsCommand())
+	rootCmd.AddCommand(NewVendorsCommand())
+	rootCmd.AddCommand(NewDescribeCommand())
+	rootCmd.AddCommand(NewCompletionCommand())
+
+	addPluginCommands(rootCmd)
 
-	if err := rootCmd.Execute(); err != nil {
-		stdout.Error(err)
-		os.Exit(1)
+	// requests of vendors are cancelled on interrupt or termination, default handling is back then,
+	// so that the next signal stops command which doesn't wait for vendors
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	common.SetHttpContext(ctx)
+
+	// errors of executing are of flags and args, stdout isn't created by then
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		if stdout == nil {
+			stdout = common.NewStdout(stdoutOptions)
+			stdout.SetErrorHandler(exitError)
+		}
+		exitConfig(err)
 	}
+	exit()
 }
@@ -0,0 +1,154 @@
+//go:build !minimal || ci || rundeck
+
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+var rundeckOptions = vendors.RundeckOptions{
+	Timeout:    envGet("RUNDECK_TIMEOUT", 30).(int),
+	Insecure:   envGet("RUNDECK_INSECURE", false).(bool),
+	URL:        envGet("RUNDECK_URL", "").(string),
+	Token:      envGet("RUNDECK_TOKEN", "").(string),
+	APIVersion: envGet("RUNDECK_API_VERSION", 41).(int),
+}
+
+var rundeckRunOptions = vendors.RundeckRunOptions{
+	JobID:        envGet("RUNDECK_JOB_ID", "").(string),
+	Options:      envGet("RUNDECK_JOB_OPTIONS", "").(string),
+	LogLevel:     envGet("RUNDECK_JOB_LOG_LEVEL", "").(string),
+	AsUser:       envGet("RUNDECK_JOB_AS_USER", "").(string),
+	Filter:       envGet("RUNDECK_JOB_FILTER", "").(string),
+	Follow:       envGet("RUNDECK_FOLLOW", false).(bool),
+	PollInterval: envGet("RUNDECK_POLL_INTERVAL", 2).(int),
+	WaitTimeout:  envGet("RUNDECK_WAIT_TIMEOUT", 3600).(int),
+}
+
+var rundeckExecutionOptions = vendors.RundeckExecutionOptions{
+	ID: envGet("RUNDECK_EXECUTION_ID", "").(string),
+}
+
+var rundeckOutput = common.OutputOptions{
+	Output: envGet("RUNDECK_OUTPUT", "").(string),
+	Query:  envGet("RUNDECK_OUTPUT_QUERY", "").(string),
+}
+
+func rundeckNew(stdout *common.Stdout) *vendors.Rundeck {
+
+	common.Debug("Rundeck", rundeckOptions, stdout)
+	common.Debug("Rundeck", rundeckOutput, stdout)
+
+	return vendors.NewRundeck(rundeckOptions, stdout.Module("rundeck"))
+}
+
+func NewRundeckCommand() *cobra.Command {
+
+	rundeckCmd := &cobra.Command{
+		Use:   "rundeck",
+		Short: "Rundeck tools",
+	}
+	flags := rundeckCmd.PersistentFlags()
+	flags.IntVar(&rundeckOptions.Timeout, "rundeck-timeout", rundeckOptions.Timeout, "Rundeck timeout in seconds")
+	flags.BoolVar(&rundeckOptions.Insecure, "rundeck-insecure", rundeckOptions.Insecure, "Rundeck insecure")
+	flags.StringVar(&rundeckOptions.URL, "rundeck-url", rundeckOptions.URL, "Rundeck URL")
+	flags.StringVar(&rundeckOptions.Token, "rundeck-token", rundeckOptions.Token, "Rundeck token")
+	flags.IntVar(&rundeckOptions.APIVersion, "rundeck-api-version", rundeckOptions.APIVersion, "Rundeck API version")
+	flags.IntVar(&rundeckRunOptions.PollInterval, "rundeck-poll-interval", rundeckRunOptions.PollInterval, "Rundeck output poll interval in seconds")
+	flags.IntVar(&rundeckRunOptions.WaitTimeout, "rundeck-wait-timeout", rundeckRunOptions.WaitTimeout, "Rundeck follow timeout in seconds")
+	flags.StringVar(&rundeckOutput.Output, "rundeck-output", rundeckOutput.Output, "Rundeck output")
+	flags.StringVar(&rundeckOutput.Query, "rundeck-output-query", rundeckOutput.Query, "Rundeck output query")
+
+	runJobCmd := &cobra.Command{
+		Use:   "run-job",
+		Short: "Run job",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Rundeck running job %s...", rundeckRunOptions.JobID)
+			common.Debug("Rundeck", rundeckRunOptions, stdout)
+
+			bytes, err := idempotent("", rundeckRunOptions, func() ([]byte, error) {
+				return rundeckNew(stdout).RunJob(rundeckRunOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(rundeckOutput, "Rundeck", []interface{}{rundeckOptions, rundeckRunOptions}, bytes, stdout)
+		},
+	}
+	flags = runJobCmd.PersistentFlags()
+	flags.StringVar(&rundeckRunOptions.JobID, "rundeck-job-id", rundeckRunOptions.JobID, "Rundeck job ID")
+	flags.StringVar(&rundeckRunOptions.Options, "rundeck-job-options", rundeckRunOptions.Options, "Rundeck job options: opt1=value1,opt2=value2")
+	flags.StringVar(&rundeckRunOptions.LogLevel, "rundeck-job-log-level", rundeckRunOptions.LogLevel, "Rundeck job log level: DEBUG, VERBOSE, INFO, WARN, ERROR")
+	flags.StringVar(&rundeckRunOptions.AsUser, "rundeck-job-as-user", rundeckRunOptions.AsUser, "Rundeck job as user")
+	flags.StringVar(&rundeckRunOptions.Filter, "rundeck-job-filter", rundeckRunOptions.Filter, "Rundeck job node filter")
+	flags.BoolVar(&rundeckRunOptions.Follow, "rundeck-follow", rundeckRunOptions.Follow, "Rundeck follow execution output until completed")
+	rundeckCmd.AddCommand(idempotentCommand(runJobCmd))
+
+	executionCmd := &cobra.Command{
+		Use:   "execution",
+		Short: "Execution methods",
+	}
+	flags = executionCmd.PersistentFlags()
+	flags.StringVar(&rundeckExecutionOptions.ID, "rundeck-execution-id", rundeckExecutionOptions.ID, "Rundeck execution ID")
+	rundeckCmd.AddCommand(idempotentCommand(executionCmd))
+
+	executionCmd.AddCommand(&cobra.Command{
+		Use:   "get",
+		Short: "Get execution",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Rundeck getting execution %s...", rundeckExecutionOptions.ID)
+
+			bytes, err := rundeckNew(stdout).GetExecution(rundeckExecutionOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(rundeckOutput, "Rundeck", []interface{}{rundeckOptions, rundeckExecutionOptions}, bytes, stdout)
+		},
+	})
+
+	executionCmd.AddCommand(&cobra.Command{
+		Use:   "follow",
+		Short: "Follow execution output",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Rundeck following execution %s...", rundeckExecutionOptions.ID)
+
+			bytes, err := rundeckNew(stdout).FollowExecution(rundeckExecutionOptions, rundeckRunOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(rundeckOutput, "Rundeck", []interface{}{rundeckOptions, rundeckExecutionOptions}, bytes, stdout)
+		},
+	})
+
+	executionCmd.AddCommand(&cobra.Command{
+		Use:   "abort",
+		Short: "Abort execution",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Rundeck aborting execution %s...", rundeckExecutionOptions.ID)
+
+			bytes, err := idempotent("", rundeckExecutionOptions, func() ([]byte, error) {
+				return rundeckNew(stdout).AbortExecution(rundeckExecutionOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(rundeckOutput, "Rundeck", []interface{}{rundeckOptions, rundeckExecutionOptions}, bytes, stdout)
+		},
+	})
+
+	return rundeckCmd
+}
+
+func init() {
+	registerVendor("rundeck", vendorGroupCI, NewRundeckCommand, rundeckNew)
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/server"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// secretsRoot is root of command, vendors used by notify and server are its commands
+var secretsRoot *cobra.Command
+
+// secretsVendors are resolvings of flags of vendors by name
+var secretsVendors = make(map[string]*secretsVendorResolving)
+var secretsVendorsMutex sync.Mutex
+
+type secretsVendorResolving struct {
+	once sync.Once
+	err  error
+}
+
+// secretsFlags replaces secret references of string flags with secrets, references of schemes except of skipped are resolved
+func secretsFlags(flags []*pflag.Flag, skip string) error {
+
+	for _, scheme := range common.SecretSchemes() {
+		if scheme == skip {
+			continue
+		}
+		for _, f := range flags {
+
+			value := f.Value.String()
+			if common.SecretScheme(value) != scheme {
+				continue
+			}
+			secret, err := common.ResolveSecret(value)
+			if err == nil {
+				err = f.Value.Set(secret)
+			}
+			if err != nil {
+				return fmt.Errorf("flag %s: %s", f.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func secretsStringFlags(fs *pflag.FlagSet, flags []*pflag.Flag) []*pflag.Flag {
+
+	fs.VisitAll(func(f *pflag.Flag) {
+		if f.Value.Type() == "string" {
+			flags = append(flags, f)
+		}
+	})
+	return flags
+}
+
+// secretsResolve replaces secret references of flags of command and its parents before vendors are created,
+// flags of vendors used by notify, server and others are resolved when they're used by secretsVendor
+func secretsResolve(cmd *cobra.Command) error {
+
+	secretsRoot = cmd.Root()
+	flags := secretsStringFlags(cmd.LocalFlags(), nil)
+	flags = secretsStringFlags(cmd.InheritedFlags(), flags)
+	return secretsFlags(flags, "")
+}
+
+// secretsVendor replaces secret references of flags of vendor command and its commands once, so that options of vendor
+// have secrets by then, references of scheme of vendor itself, e.g. vault:// of Vault options, aren't resolved
+func secretsVendor(name string) error {
+
+	if secretsRoot == nil {
+		return nil
+	}
+	secretsVendorsMutex.Lock()
+	r, ok := secretsVendors[name]
+	if !ok {
+		r = &secretsVendorResolving{}
+		secretsVendors[name] = r
+	}
+	secretsVendorsMutex.Unlock()
+
+	r.once.Do(func() {
+		flags := []*pflag.Flag{}
+		var walk func(c *cobra.Command)
+		walk = func(c *cobra.Command) {
+			flags = secretsStringFlags(c.PersistentFlags(), flags)
+			flags = secretsStringFlags(c.LocalNonPersistentFlags(), flags)
+			for _, sub := range c.Commands() {
+				walk(sub)
+			}
+		}
+		for _, c := range secretsRoot.Commands() {
+			if c.Name() == name {
+				walk(c)
+			}
+		}
+		r.err = secretsFlags(flags, name)
+	})
+	return r.err
+}
+
+// secretsTarget builds target by options of vendor when target is used, after secrets of vendor are resolved
+func secretsTarget(vendor string, build func() server.Target) server.Target {
+
+	var once sync.Once
+	var target server.Target
+	var err error
+	return func(params map[string]string, message string) ([]byte, error) {
+		once.Do(func() {
+			if err = secretsVendor(vendor); err == nil {
+				target = build()
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		return target(params, message)
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestSecretsResolve(t *testing.T) {
+
+	t.Setenv("SECRETS_TEST_SLACK", "xoxb-1")
+	t.Setenv("SECRETS_TEST_TELEGRAM", "bot-1")
+
+	var slackToken, telegramToken string
+	root := &cobra.Command{Use: "tools"}
+	slack := &cobra.Command{Use: "slack"}
+	slack.PersistentFlags().StringVar(&slackToken, "slack-token", "env://SECRETS_TEST_SLACK", "")
+	send := &cobra.Command{Use: "send", Run: func(cmd *cobra.Command, args []string) {}}
+	slack.AddCommand(send)
+	telegram := &cobra.Command{Use: "telegram"}
+	telegram.PersistentFlags().StringVar(&telegramToken, "telegram-id-token", "env://SECRETS_TEST_TELEGRAM", "")
+	telegram.AddCommand(&cobra.Command{Use: "send", Run: func(cmd *cobra.Command, args []string) {}})
+	root.AddCommand(slack, telegram)
+
+	defer func() {
+		secretsRoot = nil
+		secretsVendors = make(map[string]*secretsVendorResolving)
+	}()
+	if err := send.ParseFlags(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := secretsResolve(send); err != nil {
+		t.Fatal(err)
+	}
+	// flags of other vendors are resolved when they're used only
+	if slackToken != "xoxb-1" || telegramToken != "env://SECRETS_TEST_TELEGRAM" {
+		t.Fatalf("expected flags of command resolved only, got %s and %s", slackToken, telegramToken)
+	}
+	if err := secretsVendor("telegram"); err != nil {
+		t.Fatal(err)
+	}
+	if telegramToken != "bot-1" {
+		t.Fatalf("expected flags of vendor resolved, got %s", telegramToken)
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/server"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var serveOptions = server.APIOptions{
+	RoutesFile:  envGet("SERVE_ROUTES_FILE", "").(string),
+	Listen:      envGet("SERVE_LISTEN", ":8080").(string),
+	Concurrency: envGet("SERVE_CONCURRENCY", 10).(int),
+	MaxBody:     int64(envGet("SERVE_MAX_BODY", 1048576).(int)),
+}
+
+// audit records are written to stdout unless file is set
+var serveAuditFile = envGet("SERVE_AUDIT_FILE", "").(string)
+
+func NewServeCommand() *cobra.Command {
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve targets of vendors as REST API with token auth, e.g. POST /slack/message",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Serve starting...")
+			common.Debug("Serve", serveOptions, stdout)
+
+			var audit io.Writer = os.Stdout
+			if !utils.IsEmpty(serveAuditFile) {
+				f, err := os.OpenFile(serveAuditFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+				if err != nil {
+					stdout.Error(err)
+					return
+				}
+				defer f.Close()
+				audit = f
+			}
+
+			api, err := server.NewAPI(serveOptions, audit, stdout)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			for name, target := range vendorTargets() {
+				api.AddTarget(name, target)
+			}
+
+			ctx, cancel := serviceContext()
+			defer cancel()
+
+			err = api.Run(ctx)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			exitReset()
+			stdout.Info("Serve stopped")
+		},
+	}
+	flags := serveCmd.PersistentFlags()
+	flags.StringVar(&serveOptions.RoutesFile, "serve-routes-file", serveOptions.RoutesFile, "Serve routes YAML file of paths, targets, tokens and required params")
+	flags.StringVar(&serveOptions.Listen, "serve-listen", serveOptions.Listen, "Serve listen address of routes, /healthz and /metrics")
+	flags.IntVar(&serveOptions.Concurrency, "serve-concurrency", serveOptions.Concurrency, "Serve requests in flight of route which has no own concurrency, the rest are rejected with 429")
+	flags.Int64Var(&serveOptions.MaxBody, "serve-max-body", serveOptions.MaxBody, "Serve max size of request body in bytes")
+	flags.StringVar(&serveAuditFile, "serve-audit-file", serveAuditFile, "Serve audit file of JSON lines, stdout if empty")
+
+	return serveCmd
+}
@@ -0,0 +1,741 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/server"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var serverOptions = server.Options{
+	RoutesFile:    envGet("SERVER_ROUTES_FILE", "").(string),
+	Workers:       envGet("SERVER_WORKERS", 10).(int),
+	Watch:         envGet("SERVER_WATCH", false).(bool),
+	Listen:        envGet("SERVER_LISTEN", "").(string),
+	Checks:        envGet("SERVER_CHECKS", false).(bool),
+	CheckInterval: envGet("SERVER_CHECK_INTERVAL", 60).(int),
+	AdminToken:    envGet("SERVER_ADMIN_TOKEN", "").(string),
+	Deliveries:    envGet("SERVER_DELIVERIES", 100).(int),
+	TrackInterval: envGet("SERVER_TRACK_INTERVAL", 60).(int),
+}
+
+type ServerAdminOptions struct {
+	URL       string
+	Timeout   int
+	Insecure  bool
+	Limit     int
+	Status    string
+	State     string
+	Recipient string
+}
+
+var serverAdminOptions = ServerAdminOptions{
+	URL:       envGet("SERVER_ADMIN_URL", "http://127.0.0.1:8081").(string),
+	Timeout:   envGet("SERVER_ADMIN_TIMEOUT", 30).(int),
+	Insecure:  envGet("SERVER_ADMIN_INSECURE", false).(bool),
+	Limit:     envGet("SERVER_ADMIN_LIMIT", 20).(int),
+	Status:    envGet("SERVER_ADMIN_STATUS", "").(string),
+	State:     envGet("SERVER_ADMIN_STATE", "").(string),
+	Recipient: envGet("SERVER_ADMIN_RECIPIENT", "").(string),
+}
+
+var serverAdminOutput = common.OutputOptions{
+	Output: envGet("SERVER_ADMIN_OUTPUT", "").(string),
+	Query:  envGet("SERVER_ADMIN_OUTPUT_QUERY", "").(string),
+}
+
+var serverKubernetesOptions = server.KubernetesSourceOptions{
+	URL:           envGet("SERVER_KUBERNETES_URL", "").(string),
+	Token:         envGet("SERVER_KUBERNETES_TOKEN", "").(string),
+	Insecure:      envGet("SERVER_KUBERNETES_INSECURE", false).(bool),
+	Namespace:     envGet("SERVER_KUBERNETES_NAMESPACE", "").(string),
+	FieldSelector: envGet("SERVER_KUBERNETES_FIELD_SELECTOR", "").(string),
+	LabelSelector: envGet("SERVER_KUBERNETES_LABEL_SELECTOR", "").(string),
+	Events:        envGet("SERVER_KUBERNETES_EVENTS", false).(bool),
+	PodRestarts:   envGet("SERVER_KUBERNETES_POD_RESTARTS", false).(bool),
+	Rollouts:      envGet("SERVER_KUBERNETES_ROLLOUTS", false).(bool),
+}
+
+var serverFilesOptions = server.FilesSourceOptions{
+	Paths:    strings.Split(envGet("SERVER_FILES_PATHS", "").(string), ","),
+	Pattern:  envGet("SERVER_FILES_PATTERN", "").(string),
+	Ops:      strings.Split(envGet("SERVER_FILES_OPS", "create,write").(string), ","),
+	Debounce: envGet("SERVER_FILES_DEBOUNCE", 2).(int),
+}
+
+var serverMailOptions = server.MailSourceOptions{
+	Protocol: envGet("SERVER_MAIL_PROTOCOL", "imap").(string),
+	Address:  envGet("SERVER_MAIL_ADDRESS", "").(string),
+	TLS:      envGet("SERVER_MAIL_TLS", true).(bool),
+	Insecure: envGet("SERVER_MAIL_INSECURE", false).(bool),
+	User:     envGet("SERVER_MAIL_USER", "").(string),
+	Password: envGet("SERVER_MAIL_PASSWORD", "").(string),
+	Mailbox:  envGet("SERVER_MAIL_MAILBOX", "INBOX").(string),
+	Interval: envGet("SERVER_MAIL_INTERVAL", 60).(int),
+	Delete:   envGet("SERVER_MAIL_DELETE", false).(bool),
+	Subject:  envGet("SERVER_MAIL_SUBJECT", "").(string),
+	Body:     envGet("SERVER_MAIL_BODY", "").(string),
+}
+
+var serverFeedsOptions = server.FeedsSourceOptions{
+	URLs:      strings.Split(envGet("SERVER_FEEDS_URLS", "").(string), ","),
+	Interval:  envGet("SERVER_FEEDS_INTERVAL", 300).(int),
+	StateFile: envGet("SERVER_FEEDS_STATE_FILE", "").(string),
+	Timeout:   envGet("SERVER_FEEDS_TIMEOUT", 30).(int),
+	Insecure:  envGet("SERVER_FEEDS_INSECURE", false).(bool),
+}
+
+var serverStatusOptions = server.StatusSourceOptions{
+	Providers: strings.Split(envGet("SERVER_STATUS_PROVIDERS", "").(string), ","),
+	Services:  envGet("SERVER_STATUS_SERVICES", "").(string),
+	Interval:  envGet("SERVER_STATUS_INTERVAL", 300).(int),
+	StateFile: envGet("SERVER_STATUS_STATE_FILE", "").(string),
+	Timeout:   envGet("SERVER_STATUS_TIMEOUT", 30).(int),
+	Insecure:  envGet("SERVER_STATUS_INSECURE", false).(bool),
+}
+
+var serverCloudEventsOptions = server.CloudEventsSourceOptions{
+	File: envGet("SERVER_CLOUDEVENTS_FILE", "").(string),
+}
+
+var serverWebhookOptions = server.WebhookSourceOptions{
+	Listen:           envGet("SERVER_WEBHOOK_LISTEN", "").(string),
+	Alertmanager:     envGet("SERVER_WEBHOOK_ALERTMANAGER", "/alertmanager").(string),
+	Grafana:          envGet("SERVER_WEBHOOK_GRAFANA", "/grafana").(string),
+	Sentry:           envGet("SERVER_WEBHOOK_SENTRY", "/sentry").(string),
+	Github:           envGet("SERVER_WEBHOOK_GITHUB", "/github").(string),
+	GithubSecret:     envGet("SERVER_WEBHOOK_GITHUB_SECRET", "").(string),
+	Gitlab:           envGet("SERVER_WEBHOOK_GITLAB", "/gitlab").(string),
+	GitlabToken:      envGet("SERVER_WEBHOOK_GITLAB_TOKEN", "").(string),
+	Harbor:           envGet("SERVER_WEBHOOK_HARBOR", "/harbor").(string),
+	HarborAuth:       envGet("SERVER_WEBHOOK_HARBOR_AUTH", "").(string),
+	HarborProduction: envGet("SERVER_WEBHOOK_HARBOR_PRODUCTION", "").(string),
+	Flux:             envGet("SERVER_WEBHOOK_FLUX", "/flux").(string),
+	FluxSecret:       envGet("SERVER_WEBHOOK_FLUX_SECRET", "").(string),
+}
+
+// vendor targets use vendor options from env and flags, params override destination,
+// vendors are created once when targets are used, so that connections are reused between events
+// and secrets of unused vendors aren't resolved
+func vendorTargets() map[string]server.Target {
+
+	targets := make(map[string]server.Target)
+
+	targets["slack"] = secretsTarget("slack", func() server.Target {
+		slack := vendors.NewSlack(slackOptions)
+		return func(params map[string]string, message string) ([]byte, error) {
+			opts := vendors.SlackMessageOptions{
+				Channel: params["channel"],
+				Thread:  params["thread"],
+				Title:   params["title"],
+				Text:    message,
+			}
+			if utils.IsEmpty(opts.Channel) {
+				opts.Channel = slackMessageOptions.Channel
+			}
+			if !utils.IsEmpty(params["image"]) {
+				b, err := json.Marshal([]map[string]string{{"fallback": params["image"], "image_url": params["image"]}})
+				if err != nil {
+					return nil, err
+				}
+				opts.Attachments = string(b)
+			}
+			if !utils.IsEmpty(params["attachments"]) {
+				opts.Attachments = params["attachments"]
+			}
+			return slack.SendMessage(opts)
+		}
+	})
+
+	targets["telegram"] = secretsTarget("telegram", func() server.Target {
+		telegram := vendors.NewTelegram(telegramOptions)
+		return func(params map[string]string, message string) ([]byte, error) {
+			opts := telegramOptions
+			if !utils.IsEmpty(params["chat"]) {
+				opts.ChatID = params["chat"]
+			}
+			// the first message of thread is edited, so that it has the latest state
+			if !utils.IsEmpty(params["thread"]) {
+				return telegram.CustomEditMessage(opts, vendors.TelegramEditOptions{MessageID: params["thread"], Text: message})
+			}
+			return telegram.CustomSendMessage(opts, vendors.TelegramMessageOptions{Text: message})
+		}
+	})
+
+	// channel of Teams is of webhook, webhook param overrides it
+	targets["teams"] = secretsTarget("teams", func() server.Target {
+		teams := vendors.NewTeams(teamsOptions)
+		return func(params map[string]string, message string) ([]byte, error) {
+			opts := teamsOptions
+			if !utils.IsEmpty(params["webhook"]) {
+				opts.WebhookURL = params["webhook"]
+			}
+			messageOpts := vendors.TeamsMessageOptions{
+				Title: params["title"],
+				Text:  message,
+				Card:  params["card"],
+			}
+			return teams.CustomSendMessage(opts, messageOpts)
+		}
+	})
+
+	targets["mattermost"] = secretsTarget("mattermost", func() server.Target {
+		mattermost := vendors.NewMattermost(mattermostOptions)
+		return func(params map[string]string, message string) ([]byte, error) {
+			opts := vendors.MattermostMessageOptions{
+				Channel:     params["channel"],
+				Thread:      params["thread"],
+				Text:        message,
+				Attachments: params["attachments"],
+			}
+			if utils.IsEmpty(opts.Channel) {
+				opts.Channel = mattermostMessageOptions.Channel
+			}
+			return mattermost.SendMessage(opts)
+		}
+	})
+
+	targets["discord"] = secretsTarget("discord", func() server.Target {
+		discord := vendors.NewDiscord(discordOptions)
+		return func(params map[string]string, message string) ([]byte, error) {
+			opts := discordOptions
+			if !utils.IsEmpty(params["webhook"]) {
+				opts.WebhookURL = params["webhook"]
+			}
+			messageOpts := vendors.DiscordMessageOptions{
+				Channel: params["channel"],
+				Thread:  params["thread"],
+				Content: message,
+			}
+			if !utils.IsEmpty(params["image"]) {
+				b, err := json.Marshal([]interface{}{map[string]interface{}{"image": map[string]string{"url": params["image"]}}})
+				if err != nil {
+					return nil, err
+				}
+				messageOpts.Embeds = string(b)
+			}
+			if !utils.IsEmpty(params["embeds"]) {
+				messageOpts.Embeds = params["embeds"]
+			}
+			return discord.CustomSendMessage(opts, messageOpts)
+		}
+	})
+
+	targets["rocketchat"] = secretsTarget("rocketchat", func() server.Target {
+		rocketChat := vendors.NewRocketChat(rocketChatOptions)
+		return func(params map[string]string, message string) ([]byte, error) {
+			opts := vendors.RocketChatMessageOptions{
+				Channel: params["channel"],
+				Alias:   params["alias"],
+				Text:    message,
+			}
+			if utils.IsEmpty(opts.Channel) {
+				opts.Channel = rocketChatMessageOptions.Channel
+			}
+			return rocketChat.SendMessage(opts)
+		}
+	})
+
+	targets["webex"] = secretsTarget("webex", func() server.Target {
+		webex := vendors.NewWebex(webexOptions)
+		return func(params map[string]string, message string) ([]byte, error) {
+			opts := vendors.WebexMessageOptions{
+				Room:          params["room"],
+				ToPersonEmail: params["to"],
+				Parent:        params["thread"],
+				Markdown:      message,
+				Attachments:   params["attachments"],
+			}
+			if utils.IsEmpty(opts.Room) && utils.IsEmpty(opts.ToPersonEmail) {
+				opts.Room = webexMessageOptions.Room
+			}
+			return webex.SendMessage(opts)
+		}
+	})
+
+	// message is sent as payload if it's JSON, otherwise as text of JSON object
+	targets["webhook"] = secretsTarget("webhook", func() server.Target {
+		webhook := vendors.NewWebhook(webhookOptions)
+		return func(params map[string]string, message string) ([]byte, error) {
+			opts := webhookOptions
+			if !utils.IsEmpty(params["url"]) {
+				opts.URL = params["url"]
+			}
+			if !utils.IsEmpty(params["method"]) {
+				opts.Method = params["method"]
+			}
+			payload := message
+			if !json.Valid([]byte(message)) {
+				b, err := json.Marshal(map[string]string{"title": params["title"], "text": message})
+				if err != nil {
+					return nil, err
+				}
+				payload = string(b)
+			}
+			sendOpts := webhookSendOptions
+			sendOpts.Payload = payload
+			sendOpts.Format = vendors.WebhookFormatJSON
+			return webhook.CustomSend(opts, sendOpts)
+		}
+	})
+
+	targets["email"] = secretsTarget("email", func() server.Target {
+		email := vendors.NewEmail(emailOptions)
+		return func(params map[string]string, message string) ([]byte, error) {
+			opts := emailMessageOptions
+			opts.Text = message
+			opts.HTML = ""
+			opts.Attachments = nil
+			if !utils.IsEmpty(params["to"]) {
+				opts.To = strings.Split(params["to"], ",")
+			}
+			if !utils.IsEmpty(params["subject"]) {
+				opts.Subject = params["subject"]
+			} else if !utils.IsEmpty(params["title"]) {
+				opts.Subject = params["title"]
+			}
+			return email.Send(opts)
+		}
+	})
+
+	targets["twilio"] = secretsTarget("twilio", func() server.Target {
+		twilio := vendors.NewTwilio(twilioOptions)
+		return func(params map[string]string, message string) ([]byte, error) {
+			to := params["to"]
+			if utils.IsEmpty(to) {
+				to = twilioSMSOptions.To
+			}
+			if params["call"] == "true" {
+				opts := twilioCallOptions
+				opts.To = to
+				opts.Text = message
+				opts.TwimlURL = ""
+				return twilio.Call(opts)
+			}
+			return twilio.SendSMS(vendors.TwilioSMSOptions{
+				To:                  to,
+				Body:                message,
+				MessagingServiceSID: twilioSMSOptions.MessagingServiceSID,
+			})
+		}
+	})
+
+	// targets of vendors excluded by build tags are absent
+	for _, add := range vendorsTargets {
+		add(targets)
+	}
+	return targets
+}
+
+// vendorChecks verify tokens of targets, for targets having them configured, vendors are created by checks
+// as secrets of tokens are resolved by then
+func vendorChecks() map[string]server.Check {
+
+	checks := make(map[string]server.Check)
+	if !utils.IsEmpty(slackOptions.Token) {
+		checks["slack"] = func() error {
+			if err := secretsVendor("slack"); err != nil {
+				return err
+			}
+			_, err := vendors.NewSlack(slackOptions).AuthTest()
+			return err
+		}
+	}
+	if !utils.IsEmpty(telegramOptions.IDToken) {
+		checks["telegram"] = func() error {
+			if err := secretsVendor("telegram"); err != nil {
+				return err
+			}
+			_, err := vendors.NewTelegram(telegramOptions).GetMe()
+			return err
+		}
+	}
+	if !utils.IsEmpty(webexOptions.Token) {
+		checks["webex"] = func() error {
+			if err := secretsVendor("webex"); err != nil {
+				return err
+			}
+			_, err := vendors.NewWebex(webexOptions).GetMe()
+			return err
+		}
+	}
+	return checks
+}
+
+// vendorReceipts record recipients of responses, which have message IDs to track them
+func vendorReceipts() map[string]server.Receipts {
+
+	receipts := make(map[string]server.Receipts)
+
+	receipts["slack"] = func(params map[string]string, response []byte) []*server.Receipt {
+		var r vendors.SlackMessageResponse
+		if json.Unmarshal(response, &r) != nil || !r.OK {
+			return nil
+		}
+		return []*server.Receipt{{Recipient: r.Channel, MessageID: r.TS, State: server.ReceiptDelivered}}
+	}
+
+	receipts["telegram"] = func(params map[string]string, response []byte) []*server.Receipt {
+		var r struct {
+			OK     bool `json:"ok"`
+			Result struct {
+				MessageID int64 `json:"message_id"`
+				EditDate  int64 `json:"edit_date"`
+				Chat      struct {
+					ID int64 `json:"id"`
+				} `json:"chat"`
+			} `json:"result"`
+		}
+		if json.Unmarshal(response, &r) != nil || !r.OK {
+			return nil
+		}
+		state := server.ReceiptDelivered
+		if r.Result.EditDate > 0 {
+			state = server.ReceiptEdited
+		}
+		return []*server.Receipt{{
+			Recipient: strconv.FormatInt(r.Result.Chat.ID, 10),
+			MessageID: strconv.FormatInt(r.Result.MessageID, 10),
+			State:     state,
+		}}
+	}
+
+	receipts["webex"] = func(params map[string]string, response []byte) []*server.Receipt {
+		var r struct {
+			ID     string `json:"id"`
+			RoomID string `json:"roomId"`
+		}
+		if json.Unmarshal(response, &r) != nil || utils.IsEmpty(r.ID) {
+			return nil
+		}
+		return []*server.Receipt{{Recipient: r.RoomID, MessageID: r.ID, State: server.ReceiptDelivered}}
+	}
+
+	// recipients are accepted by SMTP server, they are delivered or failed by notifications if DSN is requested
+	receipts["email"] = func(params map[string]string, response []byte) []*server.Receipt {
+		var r vendors.EmailResult
+		if json.Unmarshal(response, &r) != nil {
+			return nil
+		}
+		detail := ""
+		if r.DSN {
+			detail = "DSN requested"
+		}
+		receipts := []*server.Receipt{}
+		for _, rcpt := range r.Recipients {
+			receipts = append(receipts, &server.Receipt{Recipient: rcpt, MessageID: r.MessageID, State: server.ReceiptSent, Detail: detail})
+		}
+		return receipts
+	}
+	return receipts
+}
+
+// vendorTrackers track reads of messages, Slack messages are read if they have reactions
+func vendorTrackers() map[string]server.Tracker {
+
+	trackers := make(map[string]server.Tracker)
+	if !utils.IsEmpty(slackOptions.Token) {
+		trackers["slack"] = func(receipt server.Receipt) (string, string, error) {
+			if err := secretsVendor("slack"); err != nil {
+				return "", "", err
+			}
+			b, err := vendors.NewSlack(slackOptions).GetReactions(vendors.SlackReactionOptions{Channel: receipt.Recipient, Thread: receipt.MessageID})
+			if err != nil {
+				return "", "", err
+			}
+			var r struct {
+				Message struct {
+					Reactions []struct {
+						Name  string   `json:"name"`
+						Users []string `json:"users"`
+					} `json:"reactions"`
+				} `json:"message"`
+			}
+			if err := json.Unmarshal(b, &r); err != nil {
+				return "", "", err
+			}
+			if len(r.Message.Reactions) == 0 {
+				return receipt.State, receipt.Detail, nil
+			}
+			reactions := []string{}
+			for _, reaction := range r.Message.Reactions {
+				reactions = append(reactions, fmt.Sprintf("%s by %s", reaction.Name, strings.Join(reaction.Users, ", ")))
+			}
+			return server.ReceiptRead, strings.Join(reactions, "; "), nil
+		}
+	}
+	return trackers
+}
+
+func serverTargets(s *server.Server) {
+
+	for name, target := range vendorTargets() {
+		s.AddTarget(name, target)
+	}
+	for name, check := range vendorChecks() {
+		s.AddCheck(name, check)
+	}
+	for name, receipts := range vendorReceipts() {
+		s.AddReceipts(name, receipts)
+	}
+	for name, tracker := range vendorTrackers() {
+		s.AddTracker(name, tracker)
+	}
+}
+
+func serverSources(s *server.Server, stdout *common.Stdout) {
+
+	if serverKubernetesOptions.Events || serverKubernetesOptions.PodRestarts || serverKubernetesOptions.Rollouts {
+		common.Debug("Server", serverKubernetesOptions, stdout)
+		source, err := server.NewKubernetesSource(serverKubernetesOptions, stdout.Module("kubernetes"))
+		if err != nil {
+			stdout.Panic(err)
+		}
+		s.AddSource(source)
+	}
+
+	if len(common.RemoveEmptyStrings(serverFilesOptions.Paths)) > 0 {
+		common.Debug("Server", serverFilesOptions, stdout)
+		source, err := server.NewFilesSource(serverFilesOptions, stdout.Module("files"))
+		if err != nil {
+			stdout.Panic(err)
+		}
+		s.AddSource(source)
+	}
+
+	if !utils.IsEmpty(serverMailOptions.Address) {
+		common.Debug("Server", serverMailOptions, stdout)
+		source, err := server.NewMailSource(serverMailOptions, stdout.Module("mail"))
+		if err != nil {
+			stdout.Panic(err)
+		}
+		s.AddSource(source)
+	}
+
+	if len(common.RemoveEmptyStrings(serverFeedsOptions.URLs)) > 0 {
+		common.Debug("Server", serverFeedsOptions, stdout)
+		source, err := server.NewFeedsSource(serverFeedsOptions, stdout.Module("feeds"))
+		if err != nil {
+			stdout.Panic(err)
+		}
+		s.AddSource(source)
+	}
+
+	if len(common.RemoveEmptyStrings(serverStatusOptions.Providers)) > 0 {
+		common.Debug("Server", serverStatusOptions, stdout)
+		source, err := server.NewStatusSource(serverStatusOptions, stdout.Module("status"))
+		if err != nil {
+			stdout.Panic(err)
+		}
+		s.AddSource(source)
+	}
+
+	if !utils.IsEmpty(serverWebhookOptions.Listen) {
+		common.Debug("Server", serverWebhookOptions, stdout)
+		source, err := server.NewWebhookSource(serverWebhookOptions, stdout.Module("webhook"))
+		if err != nil {
+			stdout.Panic(err)
+		}
+		s.AddSource(source)
+	}
+
+	if !utils.IsEmpty(serverCloudEventsOptions.File) {
+		common.Debug("Server", serverCloudEventsOptions, stdout)
+		source, err := server.NewCloudEventsSource(serverCloudEventsOptions, stdout.Module("cloudevents"))
+		if err != nil {
+			stdout.Panic(err)
+		}
+		s.AddSource(source)
+	}
+}
+
+func NewServerCommand() *cobra.Command {
+
+	serverCmd := &cobra.Command{
+		Use:   "server",
+		Short: "Run server which routes events from sources to targets",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Server starting...")
+			common.Debug("Server", serverOptions, stdout)
+
+			s, err := server.NewServer(serverOptions, stdout)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			serverTargets(s)
+			serverSources(s, stdout)
+			if !utils.IsEmpty(servicesOptions.File) || !utils.IsEmpty(servicesBackstageOptions.URL) {
+				s.SetServices(servicesNew(stdout))
+			}
+
+			ctx, cancel := serviceContext()
+			defer cancel()
+
+			err = s.Run(ctx)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			exitReset()
+			stdout.Info("Server stopped")
+		},
+	}
+	flags := serverCmd.PersistentFlags()
+	flags.StringVar(&serverOptions.RoutesFile, "server-routes-file", serverOptions.RoutesFile, "Server routes YAML file")
+	flags.BoolVar(&serverOptions.Watch, "server-watch", serverOptions.Watch, "Server reloads routes file on its changes, SIGHUP reloads it anyway")
+	flags.StringVar(&serverOptions.Listen, "server-listen", serverOptions.Listen, "Server listen address of /healthz, /readyz, /metrics and /admin endpoints, e.g. :8081")
+	flags.StringVar(&serverOptions.AdminToken, "server-admin-token", serverOptions.AdminToken, "Server admin token of /admin endpoints, they are disabled if empty")
+	flags.IntVar(&serverOptions.Deliveries, "server-deliveries", serverOptions.Deliveries, "Server deliveries kept for admin endpoints")
+	flags.IntVar(&serverOptions.TrackInterval, "server-track-interval", serverOptions.TrackInterval, "Server interval in seconds of tracking reads of delivered messages, disabled if zero")
+	flags.BoolVar(&serverOptions.Checks, "server-checks", serverOptions.Checks, "Server readiness checks auth of targets in routes")
+	flags.IntVar(&serverOptions.CheckInterval, "server-check-interval", serverOptions.CheckInterval, "Server readiness checks interval in seconds, results are cached in between")
+	flags.IntVar(&serverOptions.Workers, "server-workers", serverOptions.Workers, "Server workers routing events concurrently, events order isn't kept if more than one")
+	flags.StringVar(&serverKubernetesOptions.URL, "server-kubernetes-url", serverKubernetesOptions.URL, "Server Kubernetes API URL, in cluster config if empty")
+	flags.StringVar(&serverKubernetesOptions.Token, "server-kubernetes-token", serverKubernetesOptions.Token, "Server Kubernetes token")
+	flags.BoolVar(&serverKubernetesOptions.Insecure, "server-kubernetes-insecure", serverKubernetesOptions.Insecure, "Server Kubernetes insecure")
+	flags.StringVar(&serverKubernetesOptions.Namespace, "server-kubernetes-namespace", serverKubernetesOptions.Namespace, "Server Kubernetes namespace, all if empty")
+	flags.StringVar(&serverKubernetesOptions.FieldSelector, "server-kubernetes-field-selector", serverKubernetesOptions.FieldSelector, "Server Kubernetes events field selector, e.g. type=Warning")
+	flags.StringVar(&serverKubernetesOptions.LabelSelector, "server-kubernetes-label-selector", serverKubernetesOptions.LabelSelector, "Server Kubernetes pods and rollouts label selector")
+	flags.BoolVar(&serverKubernetesOptions.Events, "server-kubernetes-events", serverKubernetesOptions.Events, "Server Kubernetes watch events")
+	flags.BoolVar(&serverKubernetesOptions.PodRestarts, "server-kubernetes-pod-restarts", serverKubernetesOptions.PodRestarts, "Server Kubernetes watch pod restarts")
+	flags.BoolVar(&serverKubernetesOptions.Rollouts, "server-kubernetes-rollouts", serverKubernetesOptions.Rollouts, "Server Kubernetes watch Argo Rollouts steps, canary weights and analysis")
+	flags.StringSliceVar(&serverFilesOptions.Paths, "server-files-paths", serverFilesOptions.Paths, "Server files or directories to watch")
+	flags.StringVar(&serverFilesOptions.Pattern, "server-files-pattern", serverFilesOptions.Pattern, "Server files name pattern, e.g. *.csv")
+	flags.StringSliceVar(&serverFilesOptions.Ops, "server-files-ops", serverFilesOptions.Ops, "Server files operations: create, write, remove, rename, chmod")
+	flags.IntVar(&serverFilesOptions.Debounce, "server-files-debounce", serverFilesOptions.Debounce, "Server files debounce in seconds")
+	flags.StringVar(&serverMailOptions.Protocol, "server-mail-protocol", serverMailOptions.Protocol, "Server mail protocol: imap, pop3")
+	flags.StringVar(&serverMailOptions.Address, "server-mail-address", serverMailOptions.Address, "Server mail host:port")
+	flags.BoolVar(&serverMailOptions.TLS, "server-mail-tls", serverMailOptions.TLS, "Server mail TLS")
+	flags.BoolVar(&serverMailOptions.Insecure, "server-mail-insecure", serverMailOptions.Insecure, "Server mail insecure")
+	flags.StringVar(&serverMailOptions.User, "server-mail-user", serverMailOptions.User, "Server mail user")
+	flags.StringVar(&serverMailOptions.Password, "server-mail-password", serverMailOptions.Password, "Server mail password")
+	flags.StringVar(&serverMailOptions.Mailbox, "server-mail-mailbox", serverMailOptions.Mailbox, "Server mail IMAP mailbox")
+	flags.IntVar(&serverMailOptions.Interval, "server-mail-interval", serverMailOptions.Interval, "Server mail poll interval in seconds")
+	flags.BoolVar(&serverMailOptions.Delete, "server-mail-delete", serverMailOptions.Delete, "Server mail delete messages after reading")
+	flags.StringVar(&serverMailOptions.Subject, "server-mail-subject", serverMailOptions.Subject, "Server mail subject regexp, named groups become labels")
+	flags.StringVar(&serverMailOptions.Body, "server-mail-body", serverMailOptions.Body, "Server mail body regexp, named groups become labels")
+	flags.StringSliceVar(&serverFeedsOptions.URLs, "server-feeds-urls", serverFeedsOptions.URLs, "Server RSS/Atom feed URLs")
+	flags.IntVar(&serverFeedsOptions.Interval, "server-feeds-interval", serverFeedsOptions.Interval, "Server feeds poll interval in seconds")
+	flags.StringVar(&serverFeedsOptions.StateFile, "server-feeds-state-file", serverFeedsOptions.StateFile, "Server feeds state file with seen entries")
+	flags.IntVar(&serverFeedsOptions.Timeout, "server-feeds-timeout", serverFeedsOptions.Timeout, "Server feeds timeout in seconds")
+	flags.BoolVar(&serverFeedsOptions.Insecure, "server-feeds-insecure", serverFeedsOptions.Insecure, "Server feeds insecure")
+	flags.StringSliceVar(&serverStatusOptions.Providers, "server-status-providers", serverStatusOptions.Providers, "Server status providers: aws, gcp, azure, github or name=url of RSS/Atom status feed")
+	flags.StringVar(&serverStatusOptions.Services, "server-status-services", serverStatusOptions.Services, "Server status services regexp, e.g. eu-central-1|Frankfurt")
+	flags.IntVar(&serverStatusOptions.Interval, "server-status-interval", serverStatusOptions.Interval, "Server status poll interval in seconds")
+	flags.StringVar(&serverStatusOptions.StateFile, "server-status-state-file", serverStatusOptions.StateFile, "Server status state file with seen entries")
+	flags.IntVar(&serverStatusOptions.Timeout, "server-status-timeout", serverStatusOptions.Timeout, "Server status timeout in seconds")
+	flags.BoolVar(&serverStatusOptions.Insecure, "server-status-insecure", serverStatusOptions.Insecure, "Server status insecure")
+	flags.StringVar(&serverWebhookOptions.Listen, "server-webhook-listen", serverWebhookOptions.Listen, "Server webhook listen address, e.g. :8080")
+	flags.StringVar(&serverWebhookOptions.Alertmanager, "server-webhook-alertmanager", serverWebhookOptions.Alertmanager, "Server webhook path of Alertmanager payloads, disabled if empty")
+	flags.StringVar(&serverWebhookOptions.Grafana, "server-webhook-grafana", serverWebhookOptions.Grafana, "Server webhook path of Grafana unified alerting payloads, disabled if empty")
+	flags.StringVar(&serverWebhookOptions.Sentry, "server-webhook-sentry", serverWebhookOptions.Sentry, "Server webhook path of Sentry issue alert payloads, disabled if empty")
+	flags.StringVar(&serverWebhookOptions.Github, "server-webhook-github", serverWebhookOptions.Github, "Server webhook path of GitHub push, pull_request and workflow_run events, disabled if empty")
+	flags.StringVar(&serverWebhookOptions.GithubSecret, "server-webhook-github-secret", serverWebhookOptions.GithubSecret, "Server webhook GitHub secret to verify X-Hub-Signature-256")
+	flags.StringVar(&serverWebhookOptions.Gitlab, "server-webhook-gitlab", serverWebhookOptions.Gitlab, "Server webhook path of GitLab push, merge request and pipeline events, disabled if empty")
+	flags.StringVar(&serverWebhookOptions.GitlabToken, "server-webhook-gitlab-token", serverWebhookOptions.GitlabToken, "Server webhook GitLab secret token to verify X-Gitlab-Token")
+	flags.StringVar(&serverWebhookOptions.Harbor, "server-webhook-harbor", serverWebhookOptions.Harbor, "Server webhook path of Harbor events, disabled if empty")
+	flags.StringVar(&serverWebhookOptions.HarborAuth, "server-webhook-harbor-auth", serverWebhookOptions.HarborAuth, "Server webhook Harbor auth header to verify Authorization")
+	flags.StringVar(&serverWebhookOptions.HarborProduction, "server-webhook-harbor-production", serverWebhookOptions.HarborProduction, "Server webhook Harbor regexp of production projects alerted on critical vulnerabilities, all if empty")
+	flags.StringVar(&serverWebhookOptions.Flux, "server-webhook-flux", serverWebhookOptions.Flux, "Server webhook path of Flux notification-controller events of generic provider, disabled if empty")
+	flags.StringVar(&serverWebhookOptions.FluxSecret, "server-webhook-flux-secret", serverWebhookOptions.FluxSecret, "Server webhook Flux generic-hmac provider secret to verify X-Signature")
+	flags.StringVar(&serverCloudEventsOptions.File, "server-cloudevents-file", serverCloudEventsOptions.File, "Server CloudEvents JSON file, - for stdin, events are one per line or batches")
+
+	serverCmd.AddCommand(newServerAdminCommand())
+
+	return serverCmd
+}
+
+func serverAdminGet(path string, params url.Values) {
+
+	u := strings.TrimRight(serverAdminOptions.URL, "/") + path
+	if len(params) > 0 {
+		u = fmt.Sprintf("%s?%s", u, params.Encode())
+	}
+	stdout.Debug("Server admin getting %s...", u)
+
+	client := common.NewHttpClient(serverAdminOptions.Timeout, serverAdminOptions.Insecure)
+	bytes, err := utils.HttpGetRaw(client, u, "application/json", fmt.Sprintf("Bearer %s", serverOptions.AdminToken))
+	if err != nil {
+		stdout.Error(err)
+		return
+	}
+	common.OutputJson(serverAdminOutput, "Server", []interface{}{serverAdminOptions}, bytes, stdout)
+}
+
+func newServerAdminCommand() *cobra.Command {
+
+	adminCmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Inspect running server via admin endpoints",
+	}
+	flags := adminCmd.PersistentFlags()
+	flags.StringVar(&serverAdminOptions.URL, "server-admin-url", serverAdminOptions.URL, "Server admin URL, listen address of server")
+	flags.IntVar(&serverAdminOptions.Timeout, "server-admin-timeout", serverAdminOptions.Timeout, "Server admin timeout in seconds")
+	flags.BoolVar(&serverAdminOptions.Insecure, "server-admin-insecure", serverAdminOptions.Insecure, "Server admin insecure")
+	flags.StringVar(&serverAdminOutput.Output, "server-admin-output", serverAdminOutput.Output, "Server admin output")
+	flags.StringVar(&serverAdminOutput.Query, "server-admin-output-query", serverAdminOutput.Query, "Server admin output query")
+
+	adminCmd.AddCommand(&cobra.Command{
+		Use:   "queue",
+		Short: "List deliveries being sent by targets",
+		Run: func(cmd *cobra.Command, args []string) {
+			serverAdminGet("/admin/queue", nil)
+		},
+	})
+
+	adminCmd.AddCommand(&cobra.Command{
+		Use:   "threads",
+		Short: "List threads of route keys",
+		Run: func(cmd *cobra.Command, args []string) {
+			serverAdminGet("/admin/threads", nil)
+		},
+	})
+
+	deliveriesCmd := &cobra.Command{
+		Use:   "deliveries",
+		Short: "List the last deliveries with statuses",
+		Run: func(cmd *cobra.Command, args []string) {
+			params := make(url.Values)
+			params.Set("limit", strconv.Itoa(serverAdminOptions.Limit))
+			if !utils.IsEmpty(serverAdminOptions.Status) {
+				params.Set("status", serverAdminOptions.Status)
+			}
+			serverAdminGet("/admin/deliveries", params)
+		},
+	}
+	flags = deliveriesCmd.PersistentFlags()
+	flags.IntVar(&serverAdminOptions.Limit, "server-admin-limit", serverAdminOptions.Limit, "Server admin deliveries limit")
+	flags.StringVar(&serverAdminOptions.Status, "server-admin-status", serverAdminOptions.Status, "Server admin deliveries status: sent, failed")
+	adminCmd.AddCommand(deliveriesCmd)
+
+	receiptsCmd := &cobra.Command{
+		Use:   "receipts",
+		Short: "List receipts of recipients of the last deliveries",
+		Run: func(cmd *cobra.Command, args []string) {
+			params := make(url.Values)
+			params.Set("limit", strconv.Itoa(serverAdminOptions.Limit))
+			if !utils.IsEmpty(serverAdminOptions.State) {
+				params.Set("state", serverAdminOptions.State)
+			}
+			if !utils.IsEmpty(serverAdminOptions.Recipient) {
+				params.Set("recipient", serverAdminOptions.Recipient)
+			}
+			serverAdminGet("/admin/receipts", params)
+		},
+	}
+	flags = receiptsCmd.PersistentFlags()
+	flags.IntVar(&serverAdminOptions.Limit, "server-admin-limit", serverAdminOptions.Limit, "Server admin receipts limit")
+	flags.StringVar(&serverAdminOptions.State, "server-admin-state", serverAdminOptions.State, "Server admin receipts state: sent, delivered, read, edited, delayed, failed")
+	flags.StringVar(&serverAdminOptions.Recipient, "server-admin-recipient", serverAdminOptions.Recipient, "Server admin receipts recipient")
+	adminCmd.AddCommand(receiptsCmd)
+
+	return adminCmd
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+type ServiceOptions struct {
+	Name        string
+	Description string
+	User        string
+	Dir         string
+}
+
+// serviceConfig is what service runs, the current TOOLS_ environment is its config
+type serviceConfig struct {
+	Name        string
+	Description string
+	User        string
+	Dir         string
+	Executable  string
+	Args        []string
+	Env         []string
+}
+
+var serviceOptions = ServiceOptions{
+	Name:        envGet("SERVICE_NAME", "tools").(string),
+	Description: envGet("SERVICE_DESCRIPTION", "Tools server").(string),
+	User:        envGet("SERVICE_USER", "").(string),
+	Dir:         envGet("SERVICE_DIR", "/etc/systemd/system").(string),
+}
+
+// serviceContext is cancelled on interrupt or termination, or when service manager stops service
+func serviceContext() (context.Context, context.CancelFunc) {
+
+	ctx, cancel := signal.NotifyContext(deadlineContext, os.Interrupt, syscall.SIGTERM)
+	serviceNotify(cancel)
+	return ctx, cancel
+}
+
+func serviceEnv() []string {
+
+	prefix := APPNAME + "_"
+	var r []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, prefix) && !strings.HasPrefix(kv, prefix+"SERVICE_") {
+			r = append(r, kv)
+		}
+	}
+	sort.Strings(r)
+	return r
+}
+
+func serviceNewConfig(args []string) (*serviceConfig, error) {
+
+	if len(args) == 0 {
+		args = []string{"server"}
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return &serviceConfig{
+		Name:        serviceOptions.Name,
+		Description: serviceOptions.Description,
+		User:        serviceOptions.User,
+		Dir:         serviceOptions.Dir,
+		Executable:  exe,
+		Args:        args,
+		Env:         serviceEnv(),
+	}, nil
+}
+
+func serviceRun(name string, fn func(string) error) {
+
+	if serviceOptions.Name == "" {
+		stdout.Error(errors.New("no service name"))
+		return
+	}
+	stdout.Debug("Service %s %s...", name, serviceOptions.Name)
+	if err := fn(serviceOptions.Name); err != nil {
+		stdout.Error(err)
+		return
+	}
+	stdout.Info("Service %s is %s", serviceOptions.Name, name)
+}
+
+func NewServiceCommand() *cobra.Command {
+
+	serviceCmd := &cobra.Command{
+		Use:   "service",
+		Short: "Service tools, systemd unit or Windows service of server mode",
+	}
+	flags := serviceCmd.PersistentFlags()
+	flags.StringVar(&serviceOptions.Name, "service-name", serviceOptions.Name, "Service name")
+
+	installCmd := &cobra.Command{
+		Use:   "install [-- command args]",
+		Short: "Install service running command with the current TOOLS_ environment, server if empty",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			cfg, err := serviceNewConfig(args)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			stdout.Debug("Service installing %s to run %s...", cfg.Name, strings.Join(cfg.Args, " "))
+
+			if err := serviceInstall(cfg); err != nil {
+				stdout.Error(err)
+				return
+			}
+			stdout.Info("Service %s is installed with %d environment variables", cfg.Name, len(cfg.Env))
+		},
+	}
+	flags = installCmd.PersistentFlags()
+	flags.StringVar(&serviceOptions.Description, "service-description", serviceOptions.Description, "Service description")
+	flags.StringVar(&serviceOptions.User, "service-user", serviceOptions.User, "Service user of systemd unit, root if empty")
+	flags.StringVar(&serviceOptions.Dir, "service-dir", serviceOptions.Dir, "Service directory of systemd unit")
+	serviceCmd.AddCommand(installCmd)
+
+	uninstallCmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop and uninstall service",
+		Run: func(cmd *cobra.Command, args []string) {
+			serviceRun("uninstalled", serviceUninstall)
+		},
+	}
+	flags = uninstallCmd.PersistentFlags()
+	flags.StringVar(&serviceOptions.Dir, "service-dir", serviceOptions.Dir, "Service directory of systemd unit")
+	serviceCmd.AddCommand(uninstallCmd)
+
+	serviceCmd.AddCommand(&cobra.Command{
+		Use:   "start",
+		Short: "Start service",
+		Run: func(cmd *cobra.Command, args []string) {
+			serviceRun("started", serviceStart)
+		},
+	})
+
+	serviceCmd.AddCommand(&cobra.Command{
+		Use:   "stop",
+		Short: "Stop service",
+		Run: func(cmd *cobra.Command, args []string) {
+			serviceRun("stopped", serviceStop)
+		},
+	})
+
+	return serviceCmd
+}
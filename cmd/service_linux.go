@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const serviceUnitTemplate = `[Unit]
+Description={{.Description}}
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+ExecStart={{.ExecStart}}
+{{- if .User}}
+User={{.User}}
+{{- end}}
+{{- range .Env}}
+Environment={{.}}
+{{- end}}
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=multi-user.target
+`
+
+var serviceUnitQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "%", "%%", "$", "$$")
+
+// serviceUnitQuote quotes word of unit file, specifiers and variables aren't expanded
+func serviceUnitQuote(s string) string {
+	return fmt.Sprintf(`"%s"`, serviceUnitQuoter.Replace(s))
+}
+
+func serviceUnitFile(name string) string {
+	return filepath.Join(serviceOptions.Dir, name+".service")
+}
+
+func serviceSystemctl(args ...string) error {
+
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %s: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// serviceInstall writes unit readable by root only, as environment may have secrets, and enables it
+func serviceInstall(cfg *serviceConfig) error {
+
+	words := []string{serviceUnitQuote(cfg.Executable)}
+	for _, a := range cfg.Args {
+		words = append(words, serviceUnitQuote(a))
+	}
+	var env []string
+	for _, kv := range cfg.Env {
+		env = append(env, serviceUnitQuote(kv))
+	}
+
+	t, err := template.New("unit").Parse(serviceUnitTemplate)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	err = t.Execute(&b, map[string]interface{}{
+		"Description": strings.ReplaceAll(cfg.Description, "\n", " "),
+		"ExecStart":   strings.Join(words, " "),
+		"User":        cfg.User,
+		"Env":         env,
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(serviceUnitFile(cfg.Name), b.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := serviceSystemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return serviceSystemctl("enable", cfg.Name)
+}
+
+func serviceUninstall(name string) error {
+
+	file := serviceUnitFile(name)
+	if _, err := os.Stat(file); err != nil {
+		return err
+	}
+	if err := serviceSystemctl("disable", "--now", name); err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil {
+		return err
+	}
+	return serviceSystemctl("daemon-reload")
+}
+
+func serviceStart(name string) error {
+	return serviceSystemctl("start", name)
+}
+
+func serviceStop(name string) error {
+	return serviceSystemctl("stop", name)
+}
+
+// serviceNotify does nothing, systemd stops service by SIGTERM
+func serviceNotify(cancel context.CancelFunc) {
+}
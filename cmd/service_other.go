@@ -0,0 +1,29 @@
+//go:build !linux && !windows
+
+package cmd
+
+import (
+	"context"
+	"errors"
+)
+
+var errServiceUnsupported = errors.New("service is supported on Linux with systemd and on Windows")
+
+func serviceInstall(cfg *serviceConfig) error {
+	return errServiceUnsupported
+}
+
+func serviceUninstall(name string) error {
+	return errServiceUnsupported
+}
+
+func serviceStart(name string) error {
+	return errServiceUnsupported
+}
+
+func serviceStop(name string) error {
+	return errServiceUnsupported
+}
+
+func serviceNotify(cancel context.CancelFunc) {
+}
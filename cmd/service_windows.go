@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+type serviceHandler struct {
+	cancel context.CancelFunc
+}
+
+func (h *serviceHandler) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for c := range r {
+		switch c.Cmd {
+		case svc.Interrogate:
+			changes <- c.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			changes <- svc.Status{State: svc.StopPending}
+			h.cancel()
+			return false, 0
+		}
+	}
+	return false, 0
+}
+
+func serviceOpen(name string, fn func(s *mgr.Service) error) error {
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s: %s", name, err)
+	}
+	defer s.Close()
+	return fn(s)
+}
+
+// serviceInstall creates service started automatically, environment is set in service registry key
+func serviceInstall(cfg *serviceConfig) error {
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.CreateService(cfg.Name, cfg.Executable, mgr.Config{
+		DisplayName: cfg.Name,
+		Description: cfg.Description,
+		StartType:   mgr.StartAutomatic,
+	}, cfg.Args...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if len(cfg.Env) == 0 {
+		return nil
+	}
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+cfg.Name, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	return k.SetStringsValue("Environment", cfg.Env)
+}
+
+func serviceUninstall(name string) error {
+
+	return serviceOpen(name, func(s *mgr.Service) error {
+		if st, err := s.Query(); err == nil && st.State != svc.Stopped {
+			if _, err := s.Control(svc.Stop); err != nil {
+				return err
+			}
+		}
+		return s.Delete()
+	})
+}
+
+func serviceStart(name string) error {
+	return serviceOpen(name, func(s *mgr.Service) error {
+		return s.Start()
+	})
+}
+
+// serviceStop waits until service is stopped, for the time service manager gives to stop
+func serviceStop(name string) error {
+
+	return serviceOpen(name, func(s *mgr.Service) error {
+		st, err := s.Control(svc.Stop)
+		if err != nil {
+			return err
+		}
+		deadline := time.Now().Add(20 * time.Second)
+		for st.State != svc.Stopped {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("service %s isn't stopped", name)
+			}
+			time.Sleep(300 * time.Millisecond)
+			if st, err = s.Query(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// serviceNotify reports to service manager if process runs as service, stop request cancels context
+func serviceNotify(cancel context.CancelFunc) {
+
+	ok, err := svc.IsWindowsService()
+	if err != nil || !ok {
+		return
+	}
+	go func() {
+		if err := svc.Run(serviceOptions.Name, &serviceHandler{cancel: cancel}); err != nil {
+			stdout.Error(err)
+			cancel()
+		}
+	}()
+}
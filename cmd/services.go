@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var servicesOptions = common.ServicesOptions{
+	File:    envGet("SERVICES_FILE", "").(string),
+	Service: envGet("SERVICE", "").(string),
+}
+
+var servicesBackstageOptions = vendors.BackstageOptions{
+	Timeout:   envGet("SERVICES_BACKSTAGE_TIMEOUT", 30).(int),
+	Insecure:  envGet("SERVICES_BACKSTAGE_INSECURE", false).(bool),
+	URL:       envGet("SERVICES_BACKSTAGE_URL", "").(string),
+	Token:     envGet("SERVICES_BACKSTAGE_TOKEN", "").(string),
+	Namespace: envGet("SERVICES_BACKSTAGE_NAMESPACE", "default").(string),
+}
+
+var servicesOutput = common.OutputOptions{
+	Output: envGet("SERVICES_OUTPUT", "").(string),
+	Query:  envGet("SERVICES_OUTPUT_QUERY", "").(string),
+}
+
+func servicesNew(stdout *common.Stdout) common.ServiceCatalog {
+
+	common.Debug("Services", servicesOptions, stdout)
+	common.Debug("Services", servicesBackstageOptions, stdout)
+
+	if !utils.IsEmpty(servicesBackstageOptions.URL) {
+		return vendors.NewBackstage(servicesBackstageOptions)
+	}
+
+	catalog, err := common.NewFileServiceCatalog(servicesOptions)
+	if err != nil {
+		stdout.Panic(err)
+	}
+	return catalog
+}
+
+// serviceChannel returns channel of the --service for vendor, or def if no service is passed
+func serviceChannel(stdout *common.Stdout, vendor, def string) string {
+
+	if !utils.IsEmpty(def) || utils.IsEmpty(servicesOptions.Service) {
+		return def
+	}
+
+	service, err := servicesNew(stdout).Get(servicesOptions.Service)
+	if err != nil {
+		stdout.Panic(err)
+	}
+	channel := service.Channel(vendor)
+	stdout.Debug("Service %s %s channel => %s", service.Name, vendor, channel)
+	return channel
+}
+
+func NewServicesCommand() *cobra.Command {
+
+	servicesCmd := &cobra.Command{
+		Use:   "services",
+		Short: "Service catalog tools",
+	}
+	flags := servicesCmd.PersistentFlags()
+	flags.StringVar(&servicesOutput.Output, "services-output", servicesOutput.Output, "Services output")
+	flags.StringVar(&servicesOutput.Query, "services-output-query", servicesOutput.Query, "Services output query")
+
+	servicesCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List services",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Services listing...")
+
+			services, err := servicesNew(stdout).List()
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes, err := json.Marshal(services)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(servicesOutput, "Services", []interface{}{servicesOptions}, bytes, stdout)
+		},
+	})
+
+	servicesCmd.AddCommand(&cobra.Command{
+		Use:   "get",
+		Short: "Get service by --service",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Services getting %s...", servicesOptions.Service)
+
+			service, err := servicesNew(stdout).Get(servicesOptions.Service)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes, err := json.Marshal(service)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(servicesOutput, "Services", []interface{}{servicesOptions}, bytes, stdout)
+		},
+	})
+
+	return servicesCmd
+}
@@ -1,3 +1,5 @@
+//go:build !minimal || monitoring || site24x7
+
 package cmd
 
 import (
@@ -56,7 +58,7 @@ func site24x7New(stdout *common.Stdout) *vendors.Site24x7 {
 	common.Debug("Site24x7", site24x7Options, stdout)
 	common.Debug("Site24x7", site24x7Output, stdout)
 
-	site24x7 := vendors.NewSite24x7(site24x7Options, stdout)
+	site24x7 := vendors.NewSite24x7(site24x7Options, stdout.Module("site24x7"))
 	if site24x7 == nil {
 		stdout.Panic("No site24x7")
 	}
@@ -87,7 +89,9 @@ func NewSite24x7Command() *cobra.Command {
 			stdout.Debug("Creating website monitor...")
 			common.Debug("Site24x7", site24x7WebsiteMonitorOptions, stdout)
 
-			bytes, err := site24x7New(stdout).CreateWebsiteMonitor(site24x7WebsiteMonitorOptions)
+			bytes, err := idempotent("", site24x7WebsiteMonitorOptions, func() ([]byte, error) {
+				return site24x7New(stdout).CreateWebsiteMonitor(site24x7WebsiteMonitorOptions)
+			})
 			if err != nil {
 				stdout.Error("Error: %v %s", err, string(bytes))
 				return
@@ -107,7 +111,7 @@ func NewSite24x7Command() *cobra.Command {
 	flags.StringSliceVar(&site24x7WebsiteMonitorOptions.UserGroupIDs, "site24x7-website-monitor-user-group-ids", site24x7WebsiteMonitorOptions.UserGroupIDs, "Site24x7 website monitor user group ids")
 	flags.StringVar(&site24x7WebsiteMonitorOptions.NotificationProfileID, "site24x7-website-monitor-notification-profile-id", site24x7WebsiteMonitorOptions.NotificationProfileID, "Site24x7 website monitor notification profile id")
 	flags.StringVar(&site24x7WebsiteMonitorOptions.ThresholdProfileID, "site24x7-website-monitor-threshold-profile-id", site24x7WebsiteMonitorOptions.ThresholdProfileID, "Site24x7 website monitor threshold profile id")
-	site24x7Cmd.AddCommand(site24x7CreateMonitorCmd)
+	site24x7Cmd.AddCommand(idempotentCommand(site24x7CreateMonitorCmd))
 
 	site24x7DeleteMonitorCmd := &cobra.Command{
 		Use:   "delete-monitor",
@@ -117,7 +121,9 @@ func NewSite24x7Command() *cobra.Command {
 			stdout.Debug("Deleting monitor...")
 			common.Debug("Site24x7", site24x7MonitorOptions, stdout)
 
-			bytes, err := site24x7New(stdout).DeleteMonitor(site24x7MonitorOptions)
+			bytes, err := idempotent("", site24x7MonitorOptions, func() ([]byte, error) {
+				return site24x7New(stdout).DeleteMonitor(site24x7MonitorOptions)
+			})
 			if err != nil {
 				stdout.Error("Error: %v %s", err, string(bytes))
 				return
@@ -127,7 +133,7 @@ func NewSite24x7Command() *cobra.Command {
 	}
 	flags = site24x7DeleteMonitorCmd.PersistentFlags()
 	flags.StringVar(&site24x7MonitorOptions.ID, "site24x7-monitor-id", site24x7MonitorOptions.ID, "Site24x7 monitor id")
-	site24x7Cmd.AddCommand(site24x7DeleteMonitorCmd)
+	site24x7Cmd.AddCommand(idempotentCommand(site24x7DeleteMonitorCmd))
 
 	site24x7PollMonitorCmd := &cobra.Command{
 		Use:   "poll-monitor",
@@ -137,7 +143,9 @@ func NewSite24x7Command() *cobra.Command {
 			stdout.Debug("Polling monitor...")
 			common.Debug("Site24x7", site24x7MonitorOptions, stdout)
 
-			bytes, err := site24x7New(stdout).PollMonitor(site24x7MonitorOptions)
+			bytes, err := idempotent("", site24x7MonitorOptions, func() ([]byte, error) {
+				return site24x7New(stdout).PollMonitor(site24x7MonitorOptions)
+			})
 			if err != nil {
 				stdout.Error("Error: %v %s", err, string(bytes))
 				return
@@ -147,7 +155,7 @@ func NewSite24x7Command() *cobra.Command {
 	}
 	flags = site24x7PollMonitorCmd.PersistentFlags()
 	flags.StringVar(&site24x7MonitorOptions.ID, "site24x7-monitor-id", site24x7MonitorOptions.ID, "Site24x7 monitor id")
-	site24x7Cmd.AddCommand(site24x7PollMonitorCmd)
+	site24x7Cmd.AddCommand(idempotentCommand(site24x7PollMonitorCmd))
 
 	site24x7PollingStatusCmd := &cobra.Command{
 		Use:   "get-polling-status",
@@ -199,7 +207,9 @@ func NewSite24x7Command() *cobra.Command {
 			stdout.Debug("Deleting location profile...")
 			common.Debug("Site24x7", site24x7LocationProfileOptions, stdout)
 
-			bytes, err := site24x7New(stdout).DeleteLocationProfile(site24x7LocationProfileOptions)
+			bytes, err := idempotent("", site24x7LocationProfileOptions, func() ([]byte, error) {
+				return site24x7New(stdout).DeleteLocationProfile(site24x7LocationProfileOptions)
+			})
 			if err != nil {
 				stdout.Error("Error: %v %s", err, string(bytes))
 				return
@@ -209,7 +219,11 @@ func NewSite24x7Command() *cobra.Command {
 	}
 	flags = site24x7DeleteLocationProfileCmd.PersistentFlags()
 	flags.StringVar(&site24x7LocationProfileOptions.ID, "site24x7-location-profile-id", site24x7LocationProfileOptions.ID, "Site24x7 location profile id")
-	site24x7Cmd.AddCommand(site24x7DeleteLocationProfileCmd)
+	site24x7Cmd.AddCommand(idempotentCommand(site24x7DeleteLocationProfileCmd))
 
 	return site24x7Cmd
 }
+
+func init() {
+	registerVendor("site24x7", vendorGroupMonitoring, NewSite24x7Command, site24x7New)
+}
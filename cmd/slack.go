@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/devopsext/tools/common"
@@ -10,6 +14,7 @@ import (
 )
 
 var slackOptions = vendors.SlackOptions{
+	URL:      envGet("SLACK_URL", "https://slack.com/api").(string),
 	Timeout:  envGet("SLACK_TIMEOUT", 30).(int),
 	Insecure: envGet("SLACK_INSECURE", false).(bool),
 	Token:    envGet("SLACK_TOKEN", "").(string),
@@ -47,6 +52,20 @@ var slackUsergroupUsers = vendors.SlackUsergroupUsers{
 	Users:     strings.Split(envGet("SLACK_USERS", "").(string), " "),
 }
 
+// slackChannels are channels of broadcast
+var slackChannels = strings.Split(envGet("SLACK_CHANNELS", "").(string), ",")
+
+// slackUserEmails are emails of users of usergroup sync
+var slackUserEmails = strings.Split(envGet("SLACK_USER_EMAILS", "").(string), ",")
+
+// slackUsergroupSync is output of usergroup sync, usergroup isn't updated if users of some of emails aren't found
+type slackUsergroupSync struct {
+	Usergroup string             `json:"usergroup"`
+	Users     []string           `json:"users"`
+	Lookups   *common.PoolReport `json:"lookups"`
+	Response  json.RawMessage    `json:"response,omitempty"`
+}
+
 var slackOutput = common.OutputOptions{
 	Output: envGet("SLACK_OUTPUT", "").(string),
 	Query:  envGet("SLACK_OUTPUT_QUERY", "").(string),
@@ -57,9 +76,84 @@ func slackNew(stdout *common.Stdout) *vendors.Slack {
 	common.Debug("Slack", slackOptions, stdout)
 	common.Debug("Slack", slackOutput, stdout)
 
+	slackMessageOptions.Channel = serviceChannel(stdout, "slack", slackMessageOptions.Channel)
+	slackFileOptions.Channel = serviceChannel(stdout, "slack", slackFileOptions.Channel)
+
 	return vendors.NewSlack(slackOptions)
 }
 
+// slackSyncUsergroup looks users of emails up by worker pool and sets them as users of usergroup,
+// output is returned with failed lookups as well
+func slackSyncUsergroup(slack *vendors.Slack, usergroup string, emails []string) ([]byte, error) {
+
+	if len(emails) == 0 {
+		return nil, fmt.Errorf("Slack usergroup %s has no emails", usergroup)
+	}
+	users := make([]string, len(emails))
+	index := make(map[string]int)
+	for i, email := range emails {
+		index[email] = i
+	}
+	lookups := common.PoolRun(deadlineContext, bulkOptions, emails, func(ctx context.Context, email string) ([]byte, error) {
+		b, err := slack.WithContext(ctx).GetUser(vendors.SlackUserEmail{Email: email})
+		if err != nil {
+			return nil, err
+		}
+		var r struct {
+			User struct {
+				ID string `json:"id"`
+			} `json:"user"`
+		}
+		if err := json.Unmarshal(b, &r); err != nil {
+			return nil, err
+		}
+		if utils.IsEmpty(r.User.ID) {
+			return nil, fmt.Errorf("no user of %s", email)
+		}
+		users[index[email]] = r.User.ID
+		return json.Marshal(r.User.ID)
+	})
+
+	sync := &slackUsergroupSync{Usergroup: usergroup, Lookups: lookups}
+	err := lookups.Err()
+	if err == nil {
+		sync.Users = users
+		sync.Response, err = slack.UpdateUsergroup(vendors.SlackUsergroupUsers{Usergroup: usergroup, Users: users})
+		if len(sync.Response) > 0 && !json.Valid(sync.Response) {
+			sync.Response, _ = json.Marshal(string(sync.Response))
+		}
+	} else {
+		// it's not partial failure, as usergroup isn't updated at all
+		err = errors.New(bulkError("Slack usergroup "+usergroup+" isn't updated, lookup", lookups, err).Error())
+	}
+	b, merr := json.Marshal(sync)
+	if merr != nil {
+		return nil, merr
+	}
+	return b, err
+}
+
+// slackCompleteChannels completes channel flags by names of public channels, pages of channels are of HTTP cache
+func slackCompleteChannels(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+
+	if !completionStart(cmd) || utils.IsEmpty(slackOptions.Token) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	b, err := vendors.NewSlack(slackOptions).GetChannels(vendors.SlackChannelOptions{})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var channels []*vendors.SlackChannel
+	if err := json.Unmarshal(b, &channels); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	names := []string{}
+	for _, c := range channels {
+		names = append(names, c.Name)
+	}
+	return completionFilter(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
 func NewSlackCommand() *cobra.Command {
 
 	slackCmd := &cobra.Command{
@@ -68,11 +162,13 @@ func NewSlackCommand() *cobra.Command {
 	}
 
 	flags := slackCmd.PersistentFlags()
+	flags.StringVar(&slackOptions.URL, "slack-url", slackOptions.URL, "Slack API URL, e.g. of GovSlack")
 	flags.IntVar(&slackOptions.Timeout, "slack-timeout", slackOptions.Timeout, "Slack timeout")
 	flags.BoolVar(&slackOptions.Insecure, "slack-insecure", slackOptions.Insecure, "Slack insecure")
 	flags.StringVar(&slackOptions.Token, "slack-token", slackOptions.Token, "Slack token")
 	flags.StringVar(&slackOutput.Output, "slack-output", slackOutput.Output, "Slack output")
 	flags.StringVar(&slackOutput.Query, "slack-output-query", slackOutput.Query, "Slack output query")
+	requiredFlags(flags, "slack-token")
 
 	sendMessage := &cobra.Command{
 		Use:   "send-message",
@@ -88,11 +184,19 @@ func NewSlackCommand() *cobra.Command {
 			}
 			slackMessageOptions.Text = string(textBytes)
 
-			bytes, err := slackNew(stdout).SendMessage(slackMessageOptions)
+			if !hooksPreSend(stdout, "slack", &slackMessageOptions) {
+				return
+			}
+
+			slack := slackNew(stdout)
+			bytes, err := idempotent("", slackMessageOptions, func() ([]byte, error) {
+				return slack.SendMessage(slackMessageOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
 			}
+			bytes = hooksPostResponse(stdout, "slack", bytes)
 			common.OutputJson(slackOutput, "Slack", []interface{}{slackOptions, slackMessageOptions}, bytes, stdout)
 		},
 	}
@@ -103,7 +207,9 @@ func NewSlackCommand() *cobra.Command {
 	flags.StringVar(&slackMessageOptions.Text, "slack-text", slackMessageOptions.Text, "Slack text")
 	flags.StringVar(&slackMessageOptions.Attachments, "slack-attachments", slackMessageOptions.Attachments, "Slack attachments json")
 	flags.StringVar(&slackMessageOptions.Blocks, "slack-blocks", slackMessageOptions.Blocks, "Slack blocks json")
-	slackCmd.AddCommand(sendMessage)
+	requiredFlags(flags, "slack-channel", "slack-text")
+	sendMessage.RegisterFlagCompletionFunc("slack-channel", slackCompleteChannels)
+	slackCmd.AddCommand(idempotentCommand(sendMessage))
 
 	sendFile := &cobra.Command{
 		Use:   "send-file",
@@ -125,11 +231,19 @@ func NewSlackCommand() *cobra.Command {
 			}
 			slackFileOptions.Content = string(contentBytes)
 
-			bytes, err := slackNew(stdout).SendFile(slackFileOptions)
+			if !hooksPreSend(stdout, "slack", &slackFileOptions) {
+				return
+			}
+
+			slack := slackNew(stdout)
+			bytes, err := idempotent("", slackFileOptions, func() ([]byte, error) {
+				return slack.SendFile(slackFileOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
 			}
+			bytes = hooksPostResponse(stdout, "slack", bytes)
 			common.OutputJson(slackOutput, "Slack", []interface{}{slackOptions, slackFileOptions}, bytes, stdout)
 		},
 	}
@@ -141,7 +255,9 @@ func NewSlackCommand() *cobra.Command {
 	flags.StringVar(&slackFileOptions.Name, "slack-name", slackFileOptions.Name, "Slack file name")
 	flags.StringVar(&slackFileOptions.Content, "slack-content", slackFileOptions.Content, "Slack file content")
 	flags.StringVar(&slackFileOptions.Type, "slack-type", slackFileOptions.Type, "Slack file type")
-	slackCmd.AddCommand(sendFile)
+	requiredFlags(flags, "slack-channel", "slack-content")
+	sendFile.RegisterFlagCompletionFunc("slack-channel", slackCompleteChannels)
+	slackCmd.AddCommand(idempotentCommand(sendFile))
 
 	addReactionCmd := &cobra.Command{
 		Use:   "add-reaction",
@@ -151,7 +267,9 @@ func NewSlackCommand() *cobra.Command {
 			stdout.Debug("Slack add reaction...")
 			common.Debug("Slack", slackReactionOptions, stdout)
 
-			bytes, err := slackNew(stdout).AddReaction(slackReactionOptions)
+			bytes, err := idempotent("", slackReactionOptions, func() ([]byte, error) {
+				return slackNew(stdout).AddReaction(slackReactionOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -161,7 +279,8 @@ func NewSlackCommand() *cobra.Command {
 	}
 	flags = addReactionCmd.PersistentFlags()
 	flags.StringVar(&slackReactionOptions.Name, "slack-reaction-name", slackReactionOptions.Name, "Slack reaction name")
-	slackCmd.AddCommand(addReactionCmd)
+	requiredFlags(flags, "slack-reaction-name")
+	slackCmd.AddCommand(idempotentCommand(addReactionCmd))
 
 	lookupByEmailCmd := &cobra.Command{
 		Use:   "lookup-by-email",
@@ -180,6 +299,7 @@ func NewSlackCommand() *cobra.Command {
 	}
 	flags = lookupByEmailCmd.PersistentFlags()
 	flags.StringVar(&slackUserEmail.Email, "slack-user-email", slackUserEmail.Email, "Slack user email")
+	requiredFlags(flags, "slack-user-email")
 	slackCmd.AddCommand(lookupByEmailCmd)
 
 	usergroupUpdateCmd := &cobra.Command{
@@ -189,7 +309,9 @@ func NewSlackCommand() *cobra.Command {
 			stdout.Debug("Updateing usergroup...")
 			common.Debug("Slack", slackUsergroupUsers, stdout)
 
-			bytes, err := slackNew(stdout).UpdateUsergroup(slackUsergroupUsers)
+			bytes, err := idempotent("", slackUsergroupUsers, func() ([]byte, error) {
+				return slackNew(stdout).UpdateUsergroup(slackUsergroupUsers)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
@@ -200,7 +322,77 @@ func NewSlackCommand() *cobra.Command {
 	flags = usergroupUpdateCmd.PersistentFlags()
 	flags.StringVar(&slackUsergroupUsers.Usergroup, "slack-usergroup", slackUsergroupUsers.Usergroup, "Slack usergroup")
 	flags.StringSliceVar(&slackUsergroupUsers.Users, "slack-users", slackUsergroupUsers.Users, "Slack usergroup")
-	slackCmd.AddCommand(usergroupUpdateCmd)
+	requiredFlags(flags, "slack-usergroup", "slack-users")
+	slackCmd.AddCommand(idempotentCommand(usergroupUpdateCmd))
+
+	broadcastCmd := &cobra.Command{
+		Use:   "broadcast",
+		Short: "Send text message to many channels",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Slack broadcasting message...")
+			common.Debug("Slack", slackMessageOptions, stdout)
+
+			textBytes, err := utils.Content(slackMessageOptions.Text)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			slackMessageOptions.Text = string(textBytes)
+
+			if !hooksPreSend(stdout, "slack", &slackMessageOptions) {
+				return
+			}
+
+			slack := slackNew(stdout)
+			bulkRun(slackOutput, "Slack", []interface{}{slackOptions, slackMessageOptions}, slackMessageOptions, bulkItems(slackChannels),
+				func(ctx context.Context, channel string) ([]byte, error) {
+					m := slackMessageOptions
+					m.Channel = channel
+					return slack.WithContext(ctx).SendMessage(m)
+				})
+		},
+	}
+	flags = broadcastCmd.PersistentFlags()
+	flags.StringSliceVar(&slackChannels, "slack-channels", slackChannels, "Slack channels of broadcast, comma separated")
+	flags.StringVar(&slackMessageOptions.Thread, "slack-thread", slackMessageOptions.Thread, "Slack thread")
+	flags.StringVar(&slackMessageOptions.Title, "slack-title", slackMessageOptions.Title, "Slack title")
+	flags.StringVar(&slackMessageOptions.Text, "slack-text", slackMessageOptions.Text, "Slack text")
+	flags.StringVar(&slackMessageOptions.Attachments, "slack-attachments", slackMessageOptions.Attachments, "Slack attachments json")
+	flags.StringVar(&slackMessageOptions.Blocks, "slack-blocks", slackMessageOptions.Blocks, "Slack blocks json")
+	bulkFlags(flags)
+	requiredFlags(flags, "slack-channels", "slack-text")
+	broadcastCmd.RegisterFlagCompletionFunc("slack-channels", slackCompleteChannels)
+	slackCmd.AddCommand(idempotentCommand(broadcastCmd))
+
+	usergroupSyncCmd := &cobra.Command{
+		Use:   "usergroup-sync",
+		Short: "Sync usergroup with users of emails",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Slack syncing usergroup...")
+			common.Debug("Slack", slackUsergroupUsers, stdout)
+
+			bytes, err := idempotent("", slackUsergroupUsers, func() ([]byte, error) {
+				return slackSyncUsergroup(slackNew(stdout), slackUsergroupUsers.Usergroup, bulkItems(slackUserEmails))
+			})
+			if len(bytes) > 0 {
+				common.OutputJson(slackOutput, "Slack", []interface{}{slackOptions, slackUsergroupUsers, bulkOptions}, bytes, stdout)
+			}
+			if err != nil {
+				stdout.Error(err)
+			}
+		},
+	}
+	flags = usergroupSyncCmd.PersistentFlags()
+	flags.StringVar(&slackUsergroupUsers.Usergroup, "slack-usergroup", slackUsergroupUsers.Usergroup, "Slack usergroup")
+	flags.StringSliceVar(&slackUserEmails, "slack-user-emails", slackUserEmails, "Slack emails of usergroup users, comma separated")
+	requiredFlags(flags, "slack-usergroup", "slack-user-emails")
+	bulkFlags(flags)
+	slackCmd.AddCommand(idempotentCommand(usergroupSyncCmd))
 
 	return slackCmd
 }
+
+func init() {
+	registerVendor("slack", vendorGroupChat, NewSlackCommand, slackNew)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+)
+
+func TestSlackAPIError(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		response string
+		call     func(s *vendors.Slack) ([]byte, error)
+		status   int
+	}{
+		{"auth", `{"ok":false,"error":"invalid_auth"}`, func(s *vendors.Slack) ([]byte, error) {
+			return s.AuthTest()
+		}, http.StatusUnauthorized},
+		{"create channel", `{"ok":false,"error":"missing_scope"}`, func(s *vendors.Slack) ([]byte, error) {
+			return s.CreateChannel(vendors.SlackChannelOptions{Name: "inc-1"})
+		}, http.StatusForbidden},
+		{"get channel", `{"ok":false,"error":"token_revoked"}`, func(s *vendors.Slack) ([]byte, error) {
+			return s.GetChannel(vendors.SlackChannelOptions{Name: "inc-1"})
+		}, http.StatusUnauthorized},
+		{"reactions", `{"ok":false,"error":"message_not_found"}`, func(s *vendors.Slack) ([]byte, error) {
+			return s.GetReactions(vendors.SlackReactionOptions{Channel: "C0123456789", Thread: "1700000000.000100"})
+		}, http.StatusNotFound},
+		{"unknown", `{"ok":false,"error":"name_taken"}`, func(s *vendors.Slack) ([]byte, error) {
+			return s.CreateChannel(vendors.SlackChannelOptions{Name: "inc-1"})
+		}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(tt.response))
+		}))
+		slack := vendors.NewSlack(vendors.SlackOptions{URL: srv.URL, Timeout: 5, Token: "xoxb-1"})
+		_, err := tt.call(slack)
+		srv.Close()
+
+		var apiErr *common.APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("%s: expected API error, got %v", tt.name, err)
+			continue
+		}
+		if apiErr.Status != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, apiErr.Status)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+//go:build !minimal || ci || sonarqube
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+var sonarQubeOptions = vendors.SonarQubeOptions{
+	Timeout:  envGet("SONARQUBE_TIMEOUT", 30).(int),
+	Insecure: envGet("SONARQUBE_INSECURE", false).(bool),
+	URL:      envGet("SONARQUBE_URL", "").(string),
+	Token:    envGet("SONARQUBE_TOKEN", "").(string),
+}
+
+var sonarQubeProjectOptions = vendors.SonarQubeProjectOptions{
+	Key:         envGet("SONARQUBE_PROJECT", "").(string),
+	Name:        envGet("SONARQUBE_PROJECT_NAME", "").(string),
+	Branch:      envGet("SONARQUBE_BRANCH", "").(string),
+	PullRequest: envGet("SONARQUBE_PULL_REQUEST", "").(string),
+	Visibility:  envGet("SONARQUBE_VISIBILITY", "").(string),
+}
+
+var sonarQubeIssuesOptions = vendors.SonarQubeIssuesOptions{
+	Severities: strings.Split(envGet("SONARQUBE_SEVERITIES", "").(string), ","),
+	Types:      strings.Split(envGet("SONARQUBE_TYPES", "").(string), ","),
+	Limit:      envGet("SONARQUBE_LIMIT", 100).(int),
+}
+
+var sonarQubeFail = envGet("SONARQUBE_FAIL", false).(bool)
+
+var sonarQubeOutput = common.OutputOptions{
+	Output: envGet("SONARQUBE_OUTPUT", "").(string),
+	Query:  envGet("SONARQUBE_OUTPUT_QUERY", "").(string),
+}
+
+func sonarQubeNew(stdout *common.Stdout) *vendors.SonarQube {
+
+	common.Debug("SonarQube", sonarQubeOptions, stdout)
+	common.Debug("SonarQube", sonarQubeOutput, stdout)
+
+	return vendors.NewSonarQube(sonarQubeOptions)
+}
+
+func NewSonarQubeCommand() *cobra.Command {
+
+	sonarQubeCmd := &cobra.Command{
+		Use:   "sonarqube",
+		Short: "SonarQube tools",
+	}
+	flags := sonarQubeCmd.PersistentFlags()
+	flags.IntVar(&sonarQubeOptions.Timeout, "sonarqube-timeout", sonarQubeOptions.Timeout, "SonarQube timeout in seconds")
+	flags.BoolVar(&sonarQubeOptions.Insecure, "sonarqube-insecure", sonarQubeOptions.Insecure, "SonarQube insecure")
+	flags.StringVar(&sonarQubeOptions.URL, "sonarqube-url", sonarQubeOptions.URL, "SonarQube URL")
+	flags.StringVar(&sonarQubeOptions.Token, "sonarqube-token", sonarQubeOptions.Token, "SonarQube token")
+	flags.StringVar(&sonarQubeProjectOptions.Key, "sonarqube-project", sonarQubeProjectOptions.Key, "SonarQube project key")
+	flags.StringVar(&sonarQubeProjectOptions.Branch, "sonarqube-branch", sonarQubeProjectOptions.Branch, "SonarQube branch, main branch if empty")
+	flags.StringVar(&sonarQubeOutput.Output, "sonarqube-output", sonarQubeOutput.Output, "SonarQube output")
+	flags.StringVar(&sonarQubeOutput.Query, "sonarqube-output-query", sonarQubeOutput.Query, "SonarQube output query")
+
+	qualityGateCmd := &cobra.Command{
+		Use:   "quality-gate",
+		Short: "Get quality gate status of project",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("SonarQube getting quality gate status of %s...", sonarQubeProjectOptions.Key)
+			common.Debug("SonarQube", sonarQubeProjectOptions, stdout)
+
+			bytes, err := sonarQubeNew(stdout).GetQualityGateStatus(sonarQubeProjectOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(sonarQubeOutput, "SonarQube", []interface{}{sonarQubeOptions, sonarQubeProjectOptions}, bytes, stdout)
+
+			if !sonarQubeFail {
+				return
+			}
+			var gate vendors.SonarQubeQualityGate
+			if err := json.Unmarshal(bytes, &gate); err != nil {
+				stdout.Error(err)
+				return
+			}
+			if gate.Status != "OK" {
+				stdout.Error("SonarQube quality gate of %s is %s", sonarQubeProjectOptions.Key, gate.Status)
+				os.Exit(1)
+			}
+		},
+	}
+	flags = qualityGateCmd.PersistentFlags()
+	flags.StringVar(&sonarQubeProjectOptions.PullRequest, "sonarqube-pull-request", sonarQubeProjectOptions.PullRequest, "SonarQube pull request ID, it's used instead of branch")
+	flags.BoolVar(&sonarQubeFail, "sonarqube-fail", sonarQubeFail, "SonarQube exits with error if quality gate isn't passed")
+	sonarQubeCmd.AddCommand(qualityGateCmd)
+
+	issuesCmd := &cobra.Command{
+		Use:   "issues",
+		Short: "List unresolved issues of project by severity",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("SonarQube getting issues of %s...", sonarQubeProjectOptions.Key)
+			common.Debug("SonarQube", sonarQubeProjectOptions, stdout)
+			common.Debug("SonarQube", sonarQubeIssuesOptions, stdout)
+
+			bytes, err := sonarQubeNew(stdout).GetIssues(sonarQubeProjectOptions, sonarQubeIssuesOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(sonarQubeOutput, "SonarQube", []interface{}{sonarQubeOptions, sonarQubeProjectOptions, sonarQubeIssuesOptions}, bytes, stdout)
+		},
+	}
+	flags = issuesCmd.PersistentFlags()
+	flags.StringVar(&sonarQubeProjectOptions.PullRequest, "sonarqube-pull-request", sonarQubeProjectOptions.PullRequest, "SonarQube pull request ID, it's used instead of branch")
+	flags.StringSliceVar(&sonarQubeIssuesOptions.Severities, "sonarqube-severities", sonarQubeIssuesOptions.Severities, "SonarQube severities: BLOCKER, CRITICAL, MAJOR, MINOR, INFO, all if empty")
+	flags.StringSliceVar(&sonarQubeIssuesOptions.Types, "sonarqube-types", sonarQubeIssuesOptions.Types, "SonarQube types: BUG, VULNERABILITY, CODE_SMELL, all if empty")
+	flags.IntVar(&sonarQubeIssuesOptions.Limit, "sonarqube-limit", sonarQubeIssuesOptions.Limit, "SonarQube issues limit, all if zero")
+	sonarQubeCmd.AddCommand(issuesCmd)
+
+	createProjectCmd := &cobra.Command{
+		Use:   "create-project",
+		Short: "Create project",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("SonarQube creating project %s...", sonarQubeProjectOptions.Key)
+			common.Debug("SonarQube", sonarQubeProjectOptions, stdout)
+
+			if !hooksPreSend(stdout, "sonarqube", &sonarQubeProjectOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", sonarQubeProjectOptions, func() ([]byte, error) {
+				return sonarQubeNew(stdout).CreateProject(sonarQubeProjectOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "sonarqube", bytes)
+			common.OutputJson(sonarQubeOutput, "SonarQube", []interface{}{sonarQubeOptions, sonarQubeProjectOptions}, bytes, stdout)
+		},
+	}
+	flags = createProjectCmd.PersistentFlags()
+	flags.StringVar(&sonarQubeProjectOptions.Name, "sonarqube-project-name", sonarQubeProjectOptions.Name, "SonarQube project name, key if empty")
+	flags.StringVar(&sonarQubeProjectOptions.Visibility, "sonarqube-visibility", sonarQubeProjectOptions.Visibility, "SonarQube project visibility: public, private, default of organization if empty")
+	sonarQubeCmd.AddCommand(idempotentCommand(createProjectCmd))
+
+	return sonarQubeCmd
+}
+
+func init() {
+	registerVendor("sonarqube", vendorGroupCI, NewSonarQubeCommand, sonarQubeNew)
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var sshOptions = vendors.SSHOptions{
+	User:                  envGet("SSH_USER", "").(string),
+	Address:               envGet("SSH_HOST", "").(string),
+	Port:                  envGet("SSH_PORT", 22).(int),
+	Passphrase:            envGet("SSH_PASSPHRASE", "").(string),
+	Password:              envGet("SSH_PASSWORD", "").(string),
+	Agent:                 envGet("SSH_AGENT", false).(bool),
+	Command:               envGet("SSH_COMMAND", "").(string),
+	Timeout:               envGet("SSH_TIMEOUT", 40).(int),
+	KnownHosts:            envGet("SSH_KNOWN_HOSTS", vendors.SSHDefaultKnownHosts()).(string),
+	InsecureIgnoreHostKey: envGet("SSH_INSECURE_IGNORE_HOST_KEY", false).(bool),
+}
+
+var sshPrivateKey = envGet("SSH_PRIVATE_KEY", "").(string)
+
+var sshUploadOptions = vendors.SSHUploadOptions{
+	Source:      envGet("SSH_UPLOAD_SOURCE", "").(string),
+	Destination: envGet("SSH_UPLOAD_DESTINATION", "").(string),
+	Mode:        envGet("SSH_UPLOAD_MODE", "").(string),
+}
+
+var sshOutput = common.OutputOptions{
+	Output: envGet("SSH_OUTPUT", "").(string),
+	Query:  envGet("SSH_OUTPUT_QUERY", "").(string),
+}
+
+func sshNew(stdout *common.Stdout) *vendors.SSH {
+
+	common.Debug("SSH", sshOutput, stdout)
+
+	if !utils.IsEmpty(sshPrivateKey) {
+		keyBytes, err := utils.Content(sshPrivateKey)
+		if err != nil {
+			stdout.Panic(err)
+		}
+		sshOptions.PrivateKey = keyBytes
+	}
+	if sshOptions.InsecureIgnoreHostKey {
+		stdout.Warn("SSH host key of %s isn't verified, host can be posed by any machine in the middle", sshOptions.Address)
+	}
+	return vendors.NewSSH(sshOptions)
+}
+
+func NewSSHCommand() *cobra.Command {
+
+	sshCmd := &cobra.Command{
+		Use:   "ssh",
+		Short: "SSH tools",
+	}
+	flags := sshCmd.PersistentFlags()
+	flags.StringVar(&sshOptions.User, "ssh-user", sshOptions.User, "SSH user")
+	flags.StringVar(&sshOptions.Address, "ssh-host", sshOptions.Address, "SSH host")
+	flags.IntVar(&sshOptions.Port, "ssh-port", sshOptions.Port, "SSH port")
+	flags.StringVar(&sshPrivateKey, "ssh-private-key", sshPrivateKey, "SSH private key content or file")
+	flags.StringVar(&sshOptions.Passphrase, "ssh-passphrase", sshOptions.Passphrase, "SSH private key passphrase")
+	flags.StringVar(&sshOptions.Password, "ssh-password", sshOptions.Password, "SSH password")
+	flags.BoolVar(&sshOptions.Agent, "ssh-agent", sshOptions.Agent, "SSH agent auth via SSH_AUTH_SOCK")
+	flags.IntVar(&sshOptions.Timeout, "ssh-timeout", sshOptions.Timeout, "SSH timeout in seconds")
+	flags.StringVar(&sshOptions.KnownHosts, "ssh-known-hosts", sshOptions.KnownHosts, "SSH known hosts file which host key is verified by")
+	flags.BoolVar(&sshOptions.InsecureIgnoreHostKey, "ssh-insecure-ignore-host-key", sshOptions.InsecureIgnoreHostKey, "SSH host key isn't verified, insecure")
+	flags.StringVar(&sshOutput.Output, "ssh-output", sshOutput.Output, "SSH output")
+	flags.StringVar(&sshOutput.Query, "ssh-output-query", sshOutput.Query, "SSH output query")
+
+	runCmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run remote command",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("SSH running command on %s...", sshOptions.Address)
+
+			bytes, err := idempotent("", sshOptions, func() ([]byte, error) {
+				return sshNew(stdout).Run(sshOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputRaw(sshOutput.Output, bytes, stdout)
+		},
+	}
+	flags = runCmd.PersistentFlags()
+	flags.StringVar(&sshOptions.Command, "ssh-command", sshOptions.Command, "SSH command")
+	sshCmd.AddCommand(idempotentCommand(runCmd))
+
+	uploadCmd := &cobra.Command{
+		Use:   "upload",
+		Short: "Upload file over SFTP",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("SSH uploading %s to %s...", sshUploadOptions.Source, sshOptions.Address)
+			common.Debug("SSH", sshUploadOptions, stdout)
+
+			bytes, err := idempotent("", []interface{}{sshOptions, sshUploadOptions}, func() ([]byte, error) {
+				return sshNew(stdout).Upload(sshOptions, sshUploadOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(sshOutput, "SSH", []interface{}{sshUploadOptions}, bytes, stdout)
+		},
+	}
+	flags = uploadCmd.PersistentFlags()
+	flags.StringVar(&sshUploadOptions.Source, "ssh-upload-source", sshUploadOptions.Source, "SSH upload local source file")
+	flags.StringVar(&sshUploadOptions.Destination, "ssh-upload-destination", sshUploadOptions.Destination, "SSH upload remote destination, directory if ends with /")
+	flags.StringVar(&sshUploadOptions.Mode, "ssh-upload-mode", sshUploadOptions.Mode, "SSH upload file mode: 0644")
+	sshCmd.AddCommand(idempotentCommand(uploadCmd))
+
+	return sshCmd
+}
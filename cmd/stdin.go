@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const stdinValue = "-"
+
+// stdinResolve replaces string flags of command which are "-" with content of stdin, e.g. message of output
+// of previous command in pipeline, stdin is read once and all such flags get the same content
+func stdinResolve(cmd *cobra.Command) error {
+
+	var content *string
+	var err error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+
+		if err != nil || f.Value.Type() != "string" || f.Value.String() != stdinValue {
+			return
+		}
+		if content == nil {
+			b, rerr := io.ReadAll(os.Stdin)
+			if rerr != nil {
+				err = fmt.Errorf("flag %s: %s", f.Name, rerr)
+				return
+			}
+			s := strings.TrimRight(string(b), "\r\n")
+			content = &s
+		}
+		err = f.Value.Set(*content)
+	})
+	return err
+}
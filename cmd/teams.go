@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var teamsOptions = vendors.TeamsOptions{
+	Timeout:    envGet("TEAMS_TIMEOUT", 30).(int),
+	Insecure:   envGet("TEAMS_INSECURE", false).(bool),
+	WebhookURL: envGet("TEAMS_WEBHOOK_URL", "").(string),
+}
+
+var teamsMessageOptions = vendors.TeamsMessageOptions{
+	Title: envGet("TEAMS_TITLE", "").(string),
+	Text:  envGet("TEAMS_TEXT", "").(string),
+	Card:  envGet("TEAMS_CARD", "").(string),
+}
+
+var teamsOutput = common.OutputOptions{
+	Output: envGet("TEAMS_OUTPUT", "").(string),
+	Query:  envGet("TEAMS_OUTPUT_QUERY", "").(string),
+}
+
+func teamsNew(stdout *common.Stdout) *vendors.Teams {
+
+	common.Debug("Teams", teamsOptions, stdout)
+	common.Debug("Teams", teamsOutput, stdout)
+
+	return vendors.NewTeams(teamsOptions)
+}
+
+func NewTeamsCommand() *cobra.Command {
+
+	teamsCmd := &cobra.Command{
+		Use:   "teams",
+		Short: "Microsoft Teams tools",
+	}
+
+	flags := teamsCmd.PersistentFlags()
+	flags.IntVar(&teamsOptions.Timeout, "teams-timeout", teamsOptions.Timeout, "Teams timeout")
+	flags.BoolVar(&teamsOptions.Insecure, "teams-insecure", teamsOptions.Insecure, "Teams insecure")
+	flags.StringVar(&teamsOptions.WebhookURL, "teams-webhook-url", teamsOptions.WebhookURL, "Teams incoming webhook URL of channel, of connector or of Workflows")
+	flags.StringVar(&teamsOutput.Output, "teams-output", teamsOutput.Output, "Teams output")
+	flags.StringVar(&teamsOutput.Query, "teams-output-query", teamsOutput.Query, "Teams output query")
+
+	sendMessage := &cobra.Command{
+		Use:   "send-message",
+		Short: "Send message to channel of webhook",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Teams sending message...")
+			common.Debug("Teams", teamsMessageOptions, stdout)
+
+			for _, s := range []*string{&teamsMessageOptions.Text, &teamsMessageOptions.Card} {
+				b, err := utils.Content(*s)
+				if err != nil {
+					stdout.Panic(err)
+				}
+				*s = string(b)
+			}
+
+			if !hooksPreSend(stdout, "teams", &teamsMessageOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", teamsMessageOptions, func() ([]byte, error) {
+				return teamsNew(stdout).SendMessage(teamsMessageOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "teams", bytes)
+			common.OutputJson(teamsOutput, "Teams", []interface{}{teamsOptions, teamsMessageOptions}, bytes, stdout)
+		},
+	}
+	flags = sendMessage.PersistentFlags()
+	flags.StringVar(&teamsMessageOptions.Title, "teams-title", teamsMessageOptions.Title, "Teams message title")
+	flags.StringVar(&teamsMessageOptions.Text, "teams-text", teamsMessageOptions.Text, "Teams message text, markdown of Teams, content or path")
+	flags.StringVar(&teamsMessageOptions.Card, "teams-card", teamsMessageOptions.Card, "Teams Adaptive Card json replacing card of title and text, content or path")
+	teamsCmd.AddCommand(idempotentCommand(sendMessage))
+
+	return teamsCmd
+}
+
+func init() {
+	registerVendor("teams", vendorGroupChat, NewTeamsCommand, teamsNew)
+}
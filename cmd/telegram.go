@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"context"
 	"path/filepath"
+	"strings"
 
 	"github.com/devopsext/tools/common"
 	"github.com/devopsext/tools/vendors"
@@ -20,7 +22,8 @@ var telegramOptions = vendors.TelegramOptions{
 }
 
 var telegramMessageOptions = vendors.TelegramMessageOptions{
-	Text: envGet("TELEGRAM_MESSAGE_TEXT", "").(string),
+	Text:    envGet("TELEGRAM_MESSAGE_TEXT", "").(string),
+	ReplyTo: envGet("TELEGRAM_MESSAGE_REPLY_TO", "").(string),
 }
 
 var telegramPhotoOptions = vendors.TelegramPhotoOptions{
@@ -35,6 +38,9 @@ var telegramDocumentOptions = vendors.TelegramDocumentOptions{
 	Content: envGet("TELEGRAM_DOCUMENT_CONTENT", "").(string),
 }
 
+// telegramChatIDs are chats of broadcast
+var telegramChatIDs = strings.Split(envGet("TELEGRAM_CHAT_IDS", "").(string), ",")
+
 var telegramOutput = common.OutputOptions{
 	Output: envGet("TELEGRAM_OUTPUT", "").(string),
 	Query:  envGet("TELEGRAM_OUTPUT_QUERY", "").(string),
@@ -45,6 +51,8 @@ func telegramNew(stdout *common.Stdout) *vendors.Telegram {
 	common.Debug("Telegram", telegramOptions, stdout)
 	common.Debug("Telegram", telegramOutput, stdout)
 
+	telegramOptions.ChatID = serviceChannel(stdout, "telegram", telegramOptions.ChatID)
+
 	return vendors.NewTelegram(telegramOptions)
 }
 
@@ -65,6 +73,7 @@ func NewTelegramCommand() *cobra.Command {
 	flags.BoolVar(&telegramOptions.DisableWebPagePreview, "telegram-disable-webpage-preview", telegramOptions.DisableWebPagePreview, "Telegram disable webpage preview")
 	flags.StringVar(&telegramOutput.Output, "telegram-output", telegramOutput.Output, "Telegram output")
 	flags.StringVar(&telegramOutput.Query, "telegram-output-query", telegramOutput.Query, "Telegram output query")
+	requiredFlags(flags, "telegram-id-token")
 
 	sendMessageCmd := &cobra.Command{
 		Use:   "send-message",
@@ -80,17 +89,27 @@ func NewTelegramCommand() *cobra.Command {
 			}
 			telegramMessageOptions.Text = string(textBytes)
 
-			bytes, err := telegramNew(stdout).SendMessage(telegramMessageOptions)
+			if !hooksPreSend(stdout, "telegram", &telegramMessageOptions) {
+				return
+			}
+
+			telegram := telegramNew(stdout)
+			bytes, err := idempotent("", telegramMessageOptions, func() ([]byte, error) {
+				return telegram.SendMessage(telegramMessageOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
 			}
+			bytes = hooksPostResponse(stdout, "telegram", bytes)
 			common.OutputJson(telegramOutput, "Telegram", []interface{}{telegramOptions, telegramMessageOptions}, bytes, stdout)
 		},
 	}
 	flags = sendMessageCmd.PersistentFlags()
 	flags.StringVar(&telegramMessageOptions.Text, "telegram-message-text", telegramMessageOptions.Text, "Telegram message text")
-	telegramCmd.AddCommand(sendMessageCmd)
+	flags.StringVar(&telegramMessageOptions.ReplyTo, "telegram-message-reply-to", telegramMessageOptions.ReplyTo, "Telegram message ID to reply to")
+	requiredFlags(flags, "telegram-message-text")
+	telegramCmd.AddCommand(idempotentCommand(sendMessageCmd))
 
 	sendPhotoCmd := &cobra.Command{
 		Use:   "send-photo",
@@ -110,11 +129,19 @@ func NewTelegramCommand() *cobra.Command {
 				telegramPhotoOptions.Name = filepath.Base(telegramPhotoOptions.Content)
 			}
 
-			bytes, err := telegramNew(stdout).SendPhoto(telegramPhotoOptions)
+			if !hooksPreSend(stdout, "telegram", &telegramPhotoOptions) {
+				return
+			}
+
+			telegram := telegramNew(stdout)
+			bytes, err := idempotent("", telegramPhotoOptions, func() ([]byte, error) {
+				return telegram.SendPhoto(telegramPhotoOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
 			}
+			bytes = hooksPostResponse(stdout, "telegram", bytes)
 			common.OutputJson(telegramOutput, "Telegram", []interface{}{telegramOptions, telegramPhotoOptions}, bytes, stdout)
 		},
 	}
@@ -122,7 +149,8 @@ func NewTelegramCommand() *cobra.Command {
 	flags.StringVar(&telegramPhotoOptions.Caption, "telegram-photo-caption", telegramPhotoOptions.Caption, "Telegram photo caption")
 	flags.StringVar(&telegramPhotoOptions.Name, "telegram-photo-name", telegramPhotoOptions.Name, "Telegram photo name")
 	flags.StringVar(&telegramPhotoOptions.Content, "telegram-photo-content", telegramPhotoOptions.Content, "Telegram photo content")
-	telegramCmd.AddCommand(sendPhotoCmd)
+	requiredFlags(flags, "telegram-photo-content")
+	telegramCmd.AddCommand(idempotentCommand(sendPhotoCmd))
 
 	sendDocumentCmd := &cobra.Command{
 		Use:   "send-document",
@@ -142,11 +170,19 @@ func NewTelegramCommand() *cobra.Command {
 				telegramDocumentOptions.Name = filepath.Base(telegramDocumentOptions.Content)
 			}
 
-			bytes, err := telegramNew(stdout).SendDocument(telegramDocumentOptions)
+			if !hooksPreSend(stdout, "telegram", &telegramDocumentOptions) {
+				return
+			}
+
+			telegram := telegramNew(stdout)
+			bytes, err := idempotent("", telegramDocumentOptions, func() ([]byte, error) {
+				return telegram.SendDocument(telegramDocumentOptions)
+			})
 			if err != nil {
 				stdout.Error(err)
 				return
 			}
+			bytes = hooksPostResponse(stdout, "telegram", bytes)
 			common.OutputJson(telegramOutput, "Telegram", []interface{}{telegramOptions, telegramDocumentOptions}, bytes, stdout)
 		},
 	}
@@ -154,7 +190,45 @@ func NewTelegramCommand() *cobra.Command {
 	flags.StringVar(&telegramDocumentOptions.Caption, "telegram-document-caption", telegramDocumentOptions.Caption, "Telegram document caption")
 	flags.StringVar(&telegramDocumentOptions.Name, "telegram-document-name", telegramDocumentOptions.Name, "Telegram document name")
 	flags.StringVar(&telegramDocumentOptions.Content, "telegram-document-content", telegramDocumentOptions.Content, "Telegram document content")
-	telegramCmd.AddCommand(sendDocumentCmd)
+	requiredFlags(flags, "telegram-document-content")
+	telegramCmd.AddCommand(idempotentCommand(sendDocumentCmd))
+
+	broadcastCmd := &cobra.Command{
+		Use:   "broadcast",
+		Short: "Send text message to many chats",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Telegram broadcasting message...")
+			common.Debug("Telegram", telegramMessageOptions, stdout)
+
+			textBytes, err := utils.Content(telegramMessageOptions.Text)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			telegramMessageOptions.Text = string(textBytes)
+
+			if !hooksPreSend(stdout, "telegram", &telegramMessageOptions) {
+				return
+			}
+
+			telegram := telegramNew(stdout)
+			bulkRun(telegramOutput, "Telegram", []interface{}{telegramOptions, telegramMessageOptions}, telegramMessageOptions, bulkItems(telegramChatIDs),
+				func(ctx context.Context, chatID string) ([]byte, error) {
+					options := telegramOptions
+					options.ChatID = chatID
+					return telegram.WithContext(ctx).CustomSendMessage(options, telegramMessageOptions)
+				})
+		},
+	}
+	flags = broadcastCmd.PersistentFlags()
+	flags.StringSliceVar(&telegramChatIDs, "telegram-chat-ids", telegramChatIDs, "Telegram chat IDs of broadcast, comma separated")
+	flags.StringVar(&telegramMessageOptions.Text, "telegram-message-text", telegramMessageOptions.Text, "Telegram message text")
+	bulkFlags(flags)
+	telegramCmd.AddCommand(idempotentCommand(broadcastCmd))
 
 	return &telegramCmd
 }
+
+func init() {
+	registerVendor("telegram", vendorGroupChat, NewTelegramCommand, telegramNew)
+}
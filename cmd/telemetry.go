@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/spf13/cobra"
+)
+
+var telemetryOptions = common.TelemetryOptions{
+	URL:      envGet("TELEMETRY_URL", "").(string),
+	Timeout:  envGet("TELEMETRY_TIMEOUT", 5).(int),
+	Insecure: envGet("TELEMETRY_INSECURE", false).(bool),
+	Source:   envGet("TELEMETRY_SOURCE", "").(string),
+}
+
+var telemetry *common.Telemetry
+
+// telemetrySinks are registered by vendors, sink is nil if vendor isn't configured to get events
+var telemetrySinks = []func() common.TelemetrySink{}
+
+func registerTelemetrySink(sink func() common.TelemetrySink) {
+	telemetrySinks = append(telemetrySinks, sink)
+}
+
+// telemetryStart starts event of command, vendor is set if command belongs to compiled in vendor
+func telemetryStart(cmd *cobra.Command) {
+
+	sinks := []common.TelemetrySink{}
+	for _, s := range telemetrySinks {
+		if sink := s(); sink != nil {
+			sinks = append(sinks, sink)
+		}
+	}
+	telemetry = common.NewTelemetry(telemetryOptions, sinks...)
+	if !telemetry.Enabled() {
+		return
+	}
+
+	command := strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()))
+	vendor := ""
+	name, _, _ := strings.Cut(command, " ")
+	for _, v := range vendorsRegistered {
+		if v.Name == name {
+			vendor = name
+			break
+		}
+	}
+	telemetry.Start(version, command, vendor)
+}
+
+// telemetrySend sends event of command, failures are not errors of command
+func telemetrySend() {
+
+	if err := telemetry.Send(); err != nil {
+		stdout.Debug("Telemetry sending failed: %s", err)
+	}
+}
@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/devopsext/tools/common"
 	"github.com/devopsext/tools/render"
+	"github.com/devopsext/tools/vendors"
 	"github.com/devopsext/utils"
 	"github.com/spf13/cobra"
 )
@@ -19,6 +22,28 @@ var templateOptions = render.TemplateOptions{
 	Pattern:    envGet("TEMPLATE_PATTERN", "").(string),
 }
 
+// TemplateTestOptions validate rendered template as payload of vendor, and send it to sandbox channel
+type TemplateTestOptions struct {
+	Vendor  string
+	Send    bool
+	Channel string
+}
+
+// TemplateTestResult is rendered payload with its issues, response is of sandbox channel
+type TemplateTestResult struct {
+	Vendor   string          `json:"vendor"`
+	Valid    bool            `json:"valid"`
+	Issues   []string        `json:"issues,omitempty"`
+	Payload  string          `json:"payload"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+var templateTestOptions = TemplateTestOptions{
+	Vendor:  envGet("TEMPLATE_VENDOR", "slack").(string),
+	Send:    envGet("TEMPLATE_SEND", false).(bool),
+	Channel: envGet("TEMPLATE_CHANNEL", "").(string),
+}
+
 var templateOutput = common.OutputOptions{
 	Output: envGet("TEMPLATE_OUTPUT", "").(string),
 	Query:  envGet("TEMPLATE_OUTPUT_QUERY", "").(string),
@@ -38,7 +63,7 @@ func textTemplateNew(stdout *common.Stdout) *render.TextTemplate {
 	if err != nil {
 		stdout.Panic(err)
 	}
-	templateOptions.Object = string(objectBytes)
+	templateOptions.Object = enrichObject(stdout, string(objectBytes))
 
 	template, err := render.NewTextTemplate(templateOptions, stdout)
 	if err != nil {
@@ -61,7 +86,7 @@ func htmlTemplateNew(stdout *common.Stdout) *render.HtmlTemplate {
 	if err != nil {
 		stdout.Panic(err)
 	}
-	templateOptions.Object = string(objectBytes)
+	templateOptions.Object = enrichObject(stdout, string(objectBytes))
 
 	template, err := render.NewHtmlTemplate(templateOptions, stdout)
 	if err != nil {
@@ -118,5 +143,102 @@ func NewTemplateCommand() *cobra.Command {
 		},
 	})
 
+	testCmd := &cobra.Command{
+		Use:   "test",
+		Short: "Render text and validate it as vendor payload",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Template testing %s payload...", templateTestOptions.Vendor)
+			common.Debug("Template", templateTestOptions, stdout)
+
+			bytes, err := textTemplateNew(stdout).Render()
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			issues, err := vendors.ValidatePayload(templateTestOptions.Vendor, string(bytes))
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			r := &TemplateTestResult{
+				Vendor:  templateTestOptions.Vendor,
+				Valid:   len(issues) == 0,
+				Issues:  issues,
+				Payload: string(bytes),
+			}
+			for _, issue := range issues {
+				stdout.Warn("Template %s payload: %s", templateTestOptions.Vendor, issue)
+			}
+
+			if templateTestOptions.Send && r.Valid {
+				r.Response, err = templateSend(templateTestOptions, string(bytes))
+				if err != nil {
+					stdout.Error(err)
+					return
+				}
+			}
+
+			b, err := json.Marshal(r)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(templateOutput, "template", []interface{}{templateOptions, templateTestOptions}, b, stdout)
+		},
+	}
+	flags = testCmd.PersistentFlags()
+	flags.StringVar(&templateTestOptions.Vendor, "template-vendor", templateTestOptions.Vendor, fmt.Sprintf("Template vendor of payload: %s", strings.Join(vendors.PayloadVendors(), ", ")))
+	flags.BoolVar(&templateTestOptions.Send, "template-send", templateTestOptions.Send, "Template sends valid payload to sandbox channel")
+	flags.StringVar(&templateTestOptions.Channel, "template-channel", templateTestOptions.Channel, "Template sandbox channel: Slack channel, Telegram chat or Discord webhook URL, vendor default if empty")
+	templateCmd.AddCommand(testCmd)
+
 	return templateCmd
 }
+
+// templateSend sends payload with vendor options from env, Slack payload may be JSON with text and blocks
+func templateSend(opts TemplateTestOptions, payload string) ([]byte, error) {
+
+	if err := secretsVendor(opts.Vendor); err != nil {
+		return nil, err
+	}
+	switch opts.Vendor {
+	case "slack":
+		text, blocks, err := vendors.SlackPayload(payload)
+		if err != nil {
+			return nil, err
+		}
+		channel := opts.Channel
+		if utils.IsEmpty(channel) {
+			channel = slackMessageOptions.Channel
+		}
+		return vendors.NewSlack(slackOptions).SendMessage(vendors.SlackMessageOptions{Channel: channel, Text: text, Blocks: blocks})
+	case "telegram":
+		telegramOpts := telegramOptions
+		if !utils.IsEmpty(opts.Channel) {
+			telegramOpts.ChatID = opts.Channel
+		}
+		return vendors.NewTelegram(telegramOpts).SendMessage(vendors.TelegramMessageOptions{Text: payload})
+	case "discord":
+		discordOpts := discordOptions
+		if !utils.IsEmpty(opts.Channel) {
+			discordOpts.WebhookURL = opts.Channel
+		}
+		messageOpts := vendors.DiscordMessageOptions{Content: payload}
+		if strings.HasPrefix(strings.TrimSpace(payload), "{") {
+			var m struct {
+				Content string          `json:"content"`
+				Embeds  json.RawMessage `json:"embeds"`
+			}
+			if err := json.Unmarshal([]byte(payload), &m); err != nil {
+				return nil, err
+			}
+			messageOpts.Content = m.Content
+			if len(m.Embeds) > 0 {
+				messageOpts.Embeds = string(m.Embeds)
+			}
+		}
+		return vendors.NewDiscord(discordOpts).SendMessage(messageOpts)
+	}
+	return nil, fmt.Errorf("template can't send to %s", opts.Vendor)
+}
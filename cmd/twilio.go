@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var twilioOptions = vendors.TwilioOptions{
+	Timeout:    envGet("TWILIO_TIMEOUT", 30).(int),
+	Insecure:   envGet("TWILIO_INSECURE", false).(bool),
+	URL:        envGet("TWILIO_URL", "https://api.twilio.com").(string),
+	AccountSID: envGet("TWILIO_ACCOUNT_SID", "").(string),
+	AuthToken:  envGet("TWILIO_AUTH_TOKEN", "").(string),
+	From:       envGet("TWILIO_FROM", "").(string),
+}
+
+var twilioSMSOptions = vendors.TwilioSMSOptions{
+	To:                  envGet("TWILIO_TO", "").(string),
+	Body:                envGet("TWILIO_SMS_BODY", "").(string),
+	MessagingServiceSID: envGet("TWILIO_MESSAGING_SERVICE_SID", "").(string),
+}
+
+var twilioCallOptions = vendors.TwilioCallOptions{
+	To:       envGet("TWILIO_TO", "").(string),
+	Text:     envGet("TWILIO_CALL_TEXT", "").(string),
+	Voice:    envGet("TWILIO_CALL_VOICE", "alice").(string),
+	Language: envGet("TWILIO_CALL_LANGUAGE", "en-US").(string),
+	Loop:     envGet("TWILIO_CALL_LOOP", 2).(int),
+	TwimlURL: envGet("TWILIO_CALL_TWIML_URL", "").(string),
+}
+
+var twilioOutput = common.OutputOptions{
+	Output: envGet("TWILIO_OUTPUT", "").(string),
+	Query:  envGet("TWILIO_OUTPUT_QUERY", "").(string),
+}
+
+func twilioNew(stdout *common.Stdout) *vendors.Twilio {
+
+	common.Debug("Twilio", twilioOptions, stdout)
+	common.Debug("Twilio", twilioOutput, stdout)
+
+	return vendors.NewTwilio(twilioOptions)
+}
+
+func NewTwilioCommand() *cobra.Command {
+
+	twilioCmd := &cobra.Command{
+		Use:   "twilio",
+		Short: "Twilio tools",
+	}
+	flags := twilioCmd.PersistentFlags()
+	flags.IntVar(&twilioOptions.Timeout, "twilio-timeout", twilioOptions.Timeout, "Twilio timeout in seconds")
+	flags.BoolVar(&twilioOptions.Insecure, "twilio-insecure", twilioOptions.Insecure, "Twilio insecure")
+	flags.StringVar(&twilioOptions.URL, "twilio-url", twilioOptions.URL, "Twilio URL")
+	flags.StringVar(&twilioOptions.AccountSID, "twilio-account-sid", twilioOptions.AccountSID, "Twilio account SID")
+	flags.StringVar(&twilioOptions.AuthToken, "twilio-auth-token", twilioOptions.AuthToken, "Twilio auth token")
+	flags.StringVar(&twilioOptions.From, "twilio-from", twilioOptions.From, "Twilio sender phone number")
+	flags.StringVar(&twilioOutput.Output, "twilio-output", twilioOutput.Output, "Twilio output")
+	flags.StringVar(&twilioOutput.Query, "twilio-output-query", twilioOutput.Query, "Twilio output query")
+
+	sendSMSCmd := &cobra.Command{
+		Use:   "send-sms",
+		Short: "Send SMS",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Twilio sending SMS to %s...", twilioSMSOptions.To)
+			common.Debug("Twilio", twilioSMSOptions, stdout)
+
+			bodyBytes, err := utils.Content(twilioSMSOptions.Body)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			twilioSMSOptions.Body = string(bodyBytes)
+
+			if !hooksPreSend(stdout, "twilio", &twilioSMSOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", twilioSMSOptions, func() ([]byte, error) {
+				return twilioNew(stdout).SendSMS(twilioSMSOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "twilio", bytes)
+			common.OutputJson(twilioOutput, "Twilio", []interface{}{twilioOptions, twilioSMSOptions}, bytes, stdout)
+		},
+	}
+	flags = sendSMSCmd.PersistentFlags()
+	flags.StringVar(&twilioSMSOptions.To, "twilio-to", twilioSMSOptions.To, "Twilio recipient phone number")
+	flags.StringVar(&twilioSMSOptions.Body, "twilio-sms-body", twilioSMSOptions.Body, "Twilio SMS body")
+	flags.StringVar(&twilioSMSOptions.MessagingServiceSID, "twilio-messaging-service-sid", twilioSMSOptions.MessagingServiceSID, "Twilio messaging service SID instead of sender number")
+	twilioCmd.AddCommand(idempotentCommand(sendSMSCmd))
+
+	callCmd := &cobra.Command{
+		Use:   "call",
+		Short: "Make voice call",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Twilio calling %s...", twilioCallOptions.To)
+			common.Debug("Twilio", twilioCallOptions, stdout)
+
+			textBytes, err := utils.Content(twilioCallOptions.Text)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			twilioCallOptions.Text = string(textBytes)
+
+			if !hooksPreSend(stdout, "twilio", &twilioCallOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", twilioCallOptions, func() ([]byte, error) {
+				return twilioNew(stdout).Call(twilioCallOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "twilio", bytes)
+			common.OutputJson(twilioOutput, "Twilio", []interface{}{twilioOptions, twilioCallOptions}, bytes, stdout)
+		},
+	}
+	flags = callCmd.PersistentFlags()
+	flags.StringVar(&twilioCallOptions.To, "twilio-to", twilioCallOptions.To, "Twilio recipient phone number")
+	flags.StringVar(&twilioCallOptions.Text, "twilio-call-text", twilioCallOptions.Text, "Twilio call text to say")
+	flags.StringVar(&twilioCallOptions.Voice, "twilio-call-voice", twilioCallOptions.Voice, "Twilio call voice")
+	flags.StringVar(&twilioCallOptions.Language, "twilio-call-language", twilioCallOptions.Language, "Twilio call language")
+	flags.IntVar(&twilioCallOptions.Loop, "twilio-call-loop", twilioCallOptions.Loop, "Twilio call text repeats")
+	flags.StringVar(&twilioCallOptions.TwimlURL, "twilio-call-twiml-url", twilioCallOptions.TwimlURL, "Twilio call TwiML URL instead of text")
+	twilioCmd.AddCommand(idempotentCommand(callCmd))
+
+	return twilioCmd
+}
+
+func init() {
+	registerVendor("twilio", vendorGroupChat, NewTwilioCommand, twilioNew)
+}
@@ -0,0 +1,120 @@
+//go:build !minimal || config || unleash
+
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/spf13/cobra"
+)
+
+var unleashOptions = vendors.UnleashOptions{
+	Timeout:  envGet("UNLEASH_TIMEOUT", 30).(int),
+	Insecure: envGet("UNLEASH_INSECURE", false).(bool),
+	URL:      envGet("UNLEASH_URL", "").(string),
+	Token:    envGet("UNLEASH_TOKEN", "").(string),
+}
+
+var unleashFlagOptions = vendors.UnleashFlagOptions{
+	Project:     envGet("UNLEASH_PROJECT", "default").(string),
+	Key:         envGet("UNLEASH_FLAG", "").(string),
+	Environment: envGet("UNLEASH_ENVIRONMENT", "").(string),
+	Comment:     envGet("UNLEASH_COMMENT", "").(string),
+}
+
+var unleashOutput = common.OutputOptions{
+	Output: envGet("UNLEASH_OUTPUT", "").(string),
+	Query:  envGet("UNLEASH_OUTPUT_QUERY", "").(string),
+}
+
+func unleashNew(stdout *common.Stdout) *vendors.Unleash {
+
+	common.Debug("Unleash", unleashOptions, stdout)
+	common.Debug("Unleash", unleashOutput, stdout)
+
+	return vendors.NewUnleash(unleashOptions, stdout.Module("unleash"))
+}
+
+func NewUnleashCommand() *cobra.Command {
+
+	unleashCmd := &cobra.Command{
+		Use:   "unleash",
+		Short: "Unleash tools",
+	}
+	flags := unleashCmd.PersistentFlags()
+	flags.IntVar(&unleashOptions.Timeout, "unleash-timeout", unleashOptions.Timeout, "Unleash timeout in seconds")
+	flags.BoolVar(&unleashOptions.Insecure, "unleash-insecure", unleashOptions.Insecure, "Unleash insecure")
+	flags.StringVar(&unleashOptions.URL, "unleash-url", unleashOptions.URL, "Unleash URL")
+	flags.StringVar(&unleashOptions.Token, "unleash-token", unleashOptions.Token, "Unleash admin API token")
+	flags.StringVar(&unleashOutput.Output, "unleash-output", unleashOutput.Output, "Unleash output")
+	flags.StringVar(&unleashOutput.Query, "unleash-output-query", unleashOutput.Query, "Unleash output query")
+
+	flagCmd := &cobra.Command{
+		Use:   "flag",
+		Short: "Feature flag methods",
+	}
+	flags = flagCmd.PersistentFlags()
+	flags.StringVar(&unleashFlagOptions.Project, "unleash-project", unleashFlagOptions.Project, "Unleash project")
+	flags.StringVar(&unleashFlagOptions.Key, "unleash-flag", unleashFlagOptions.Key, "Unleash feature name")
+	flags.StringVar(&unleashFlagOptions.Environment, "unleash-environment", unleashFlagOptions.Environment, "Unleash environment")
+	flags.StringVar(&unleashFlagOptions.Comment, "unleash-comment", unleashFlagOptions.Comment, "Unleash change comment, logged with toggle")
+	unleashCmd.AddCommand(idempotentCommand(flagCmd))
+
+	flagCmd.AddCommand(&cobra.Command{
+		Use:   "get",
+		Short: "Get flag",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Unleash getting flag %s...", unleashFlagOptions.Key)
+
+			bytes, err := unleashNew(stdout).GetFlag(unleashFlagOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(unleashOutput, "Unleash", []interface{}{unleashOptions, unleashFlagOptions}, bytes, stdout)
+		},
+	})
+
+	flagCmd.AddCommand(&cobra.Command{
+		Use:   "on",
+		Short: "Turn flag on",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Unleash turning flag %s on...", unleashFlagOptions.Key)
+
+			bytes, err := idempotent("", unleashFlagOptions, func() ([]byte, error) {
+				return unleashNew(stdout).TurnFlagOn(unleashFlagOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(unleashOutput, "Unleash", []interface{}{unleashOptions, unleashFlagOptions}, bytes, stdout)
+		},
+	})
+
+	flagCmd.AddCommand(&cobra.Command{
+		Use:   "off",
+		Short: "Turn flag off",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Unleash turning flag %s off...", unleashFlagOptions.Key)
+
+			bytes, err := idempotent("", unleashFlagOptions, func() ([]byte, error) {
+				return unleashNew(stdout).TurnFlagOff(unleashFlagOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(unleashOutput, "Unleash", []interface{}{unleashOptions, unleashFlagOptions}, bytes, stdout)
+		},
+	})
+
+	return unleashCmd
+}
+
+func init() {
+	registerVendor("unleash", vendorGroupConfig, NewUnleashCommand, unleashNew)
+}
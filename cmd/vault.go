@@ -0,0 +1,177 @@
+//go:build !minimal || config || vault
+
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var vaultOptions = vendors.VaultOptions{
+	Timeout:    envGet("VAULT_TIMEOUT", 30).(int),
+	Insecure:   envGet("VAULT_INSECURE", false).(bool),
+	URL:        envGet("VAULT_URL", "").(string),
+	Namespace:  envGet("VAULT_NAMESPACE", "").(string),
+	AuthMethod: envGet("VAULT_AUTH_METHOD", "token").(string),
+	AuthMount:  envGet("VAULT_AUTH_MOUNT", "").(string),
+	Token:      envGet("VAULT_TOKEN", "").(string),
+	RoleID:     envGet("VAULT_ROLE_ID", "").(string),
+	SecretID:   envGet("VAULT_SECRET_ID", "").(string),
+	Role:       envGet("VAULT_ROLE", "").(string),
+	JWTFile:    envGet("VAULT_JWT_FILE", "").(string),
+}
+
+var vaultKVOptions = vendors.VaultKVOptions{
+	Mount:   envGet("VAULT_KV_MOUNT", "secret").(string),
+	Path:    envGet("VAULT_KV_PATH", "").(string),
+	Version: envGet("VAULT_KV_VERSION", 0).(int),
+	Field:   envGet("VAULT_KV_FIELD", "").(string),
+	Data:    envGet("VAULT_KV_DATA", "").(string),
+	CAS:     envGet("VAULT_KV_CAS", 0).(int),
+}
+
+var vaultCredsOptions = vendors.VaultCredsOptions{
+	Mount: envGet("VAULT_CREDS_MOUNT", "database").(string),
+	Role:  envGet("VAULT_CREDS_ROLE", "").(string),
+}
+
+var vaultOutput = common.OutputOptions{
+	Output: envGet("VAULT_OUTPUT", "").(string),
+	Query:  envGet("VAULT_OUTPUT_QUERY", "").(string),
+}
+
+func vaultNew(stdout *common.Stdout) *vendors.Vault {
+
+	common.Debug("Vault", vaultOptions, stdout)
+	common.Debug("Vault", vaultOutput, stdout)
+
+	return vendors.NewVault(vaultOptions)
+}
+
+// vaultSecret resolves vault://mount/path#field references of other options by KV v2 secrets,
+// Vault options may be references of other schemes, e.g. file:// of token
+func vaultSecret(path, key string) (string, error) {
+
+	if err := secretsVendor("vault"); err != nil {
+		return "", err
+	}
+	return vendors.NewVault(vaultOptions).Secret(path, key)
+}
+
+func NewVaultCommand() *cobra.Command {
+
+	vaultCmd := &cobra.Command{
+		Use:   "vault",
+		Short: "Vault tools",
+	}
+	flags := vaultCmd.PersistentFlags()
+	flags.IntVar(&vaultOptions.Timeout, "vault-timeout", vaultOptions.Timeout, "Vault timeout in seconds")
+	flags.BoolVar(&vaultOptions.Insecure, "vault-insecure", vaultOptions.Insecure, "Vault insecure")
+	flags.StringVar(&vaultOptions.URL, "vault-url", vaultOptions.URL, "Vault URL")
+	flags.StringVar(&vaultOptions.Namespace, "vault-namespace", vaultOptions.Namespace, "Vault enterprise namespace")
+	flags.StringVar(&vaultOptions.AuthMethod, "vault-auth-method", vaultOptions.AuthMethod, "Vault auth method: token, approle, kubernetes")
+	flags.StringVar(&vaultOptions.AuthMount, "vault-auth-mount", vaultOptions.AuthMount, "Vault auth mount, name of auth method if empty")
+	flags.StringVar(&vaultOptions.Token, "vault-token", vaultOptions.Token, "Vault token")
+	flags.StringVar(&vaultOptions.RoleID, "vault-role-id", vaultOptions.RoleID, "Vault AppRole role ID")
+	flags.StringVar(&vaultOptions.SecretID, "vault-secret-id", vaultOptions.SecretID, "Vault AppRole secret ID")
+	flags.StringVar(&vaultOptions.Role, "vault-role", vaultOptions.Role, "Vault Kubernetes auth role")
+	flags.StringVar(&vaultOptions.JWTFile, "vault-jwt-file", vaultOptions.JWTFile, "Vault Kubernetes service account token file, in-cluster token if empty")
+	flags.StringVar(&vaultOutput.Output, "vault-output", vaultOutput.Output, "Vault output")
+	flags.StringVar(&vaultOutput.Query, "vault-output-query", vaultOutput.Query, "Vault output query")
+
+	kvCmd := &cobra.Command{
+		Use:   "kv",
+		Short: "KV v2 secrets",
+	}
+	flags = kvCmd.PersistentFlags()
+	flags.StringVar(&vaultKVOptions.Mount, "vault-kv-mount", vaultKVOptions.Mount, "Vault KV mount")
+	flags.StringVar(&vaultKVOptions.Path, "vault-kv-path", vaultKVOptions.Path, "Vault KV secret path")
+	vaultCmd.AddCommand(kvCmd)
+
+	kvGetCmd := &cobra.Command{
+		Use:   "get",
+		Short: "Read secret",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Vault reading secret %s/%s...", vaultKVOptions.Mount, vaultKVOptions.Path)
+
+			bytes, err := vaultNew(stdout).ReadKV(vaultKVOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			if !utils.IsEmpty(vaultKVOptions.Field) {
+				common.OutputRaw(vaultOutput.Output, bytes, stdout)
+				return
+			}
+			common.OutputJson(vaultOutput, "Vault", []interface{}{vaultOptions, vaultKVOptions}, bytes, stdout)
+		},
+	}
+	flags = kvGetCmd.PersistentFlags()
+	flags.IntVar(&vaultKVOptions.Version, "vault-kv-version", vaultKVOptions.Version, "Vault KV secret version, the latest if empty")
+	flags.StringVar(&vaultKVOptions.Field, "vault-kv-field", vaultKVOptions.Field, "Vault KV secret field to output as is")
+	kvCmd.AddCommand(kvGetCmd)
+
+	kvPutCmd := &cobra.Command{
+		Use:   "put",
+		Short: "Write secret",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Vault writing secret %s/%s...", vaultKVOptions.Mount, vaultKVOptions.Path)
+
+			dataBytes, err := utils.Content(vaultKVOptions.Data)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			vaultKVOptions.Data = string(dataBytes)
+
+			if !hooksPreSend(stdout, "vault", &vaultKVOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", vaultKVOptions, func() ([]byte, error) {
+				return vaultNew(stdout).WriteKV(vaultKVOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "vault", bytes)
+			common.OutputJson(vaultOutput, "Vault", []interface{}{vaultOptions, vaultKVOptions}, bytes, stdout)
+		},
+	}
+	flags = kvPutCmd.PersistentFlags()
+	flags.StringVar(&vaultKVOptions.Data, "vault-kv-data", vaultKVOptions.Data, "Vault KV secret data, JSON or key=value pairs, file or content")
+	flags.IntVar(&vaultKVOptions.CAS, "vault-kv-cas", vaultKVOptions.CAS, "Vault KV check-and-set version")
+	kvCmd.AddCommand(idempotentCommand(kvPutCmd))
+
+	credsCmd := &cobra.Command{
+		Use:   "creds",
+		Short: "Issue dynamic credentials",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Vault issuing credentials of %s/%s...", vaultCredsOptions.Mount, vaultCredsOptions.Role)
+
+			bytes, err := vaultNew(stdout).IssueCredentials(vaultCredsOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(vaultOutput, "Vault", []interface{}{vaultOptions, vaultCredsOptions}, bytes, stdout)
+		},
+	}
+	flags = credsCmd.PersistentFlags()
+	flags.StringVar(&vaultCredsOptions.Mount, "vault-creds-mount", vaultCredsOptions.Mount, "Vault secrets engine mount, e.g. database, aws")
+	flags.StringVar(&vaultCredsOptions.Role, "vault-creds-role", vaultCredsOptions.Role, "Vault secrets engine role")
+	vaultCmd.AddCommand(credsCmd)
+
+	return vaultCmd
+}
+
+func init() {
+	registerVendor("vault", vendorGroupConfig, NewVaultCommand, vaultNew)
+	common.RegisterSecretResolver("vault", vaultSecret)
+}
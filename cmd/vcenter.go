@@ -1,3 +1,5 @@
+//go:build !minimal || cloud || vcenter
+
 package cmd
 
 import (
@@ -141,3 +143,7 @@ func NewVCenterCommand() *cobra.Command {
 
 	return vcenterCmd
 }
+
+func init() {
+	registerVendor("vcenter", vendorGroupCloud, NewVCenterCommand, vcenterNew)
+}
@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/server"
+	"github.com/spf13/cobra"
+)
+
+// Vendor is integration compiled into binary, vendors are excluded by build tags, e.g.
+// -tags minimal has chat vendors only, -tags minimal,monitoring has monitoring vendors as well,
+// vendors of plugins are listed with path of plugin
+type Vendor struct {
+	Name    string `json:"name"`
+	Group   string `json:"group"`
+	Plugin  string `json:"plugin,omitempty"`
+	command func() *cobra.Command
+	news    []interface{}
+}
+
+const (
+	vendorGroupChat       = "chat"
+	vendorGroupLogs       = "logs"
+	vendorGroupCI         = "ci"
+	vendorGroupConfig     = "config"
+	vendorGroupCloud      = "cloud"
+	vendorGroupMonitoring = "monitoring"
+	vendorGroupIncident   = "incident"
+	vendorGroupIdentity   = "identity"
+	vendorGroupPlugin     = "plugin"
+)
+
+var vendorsOutput = common.OutputOptions{
+	Output: envGet("VENDORS_OUTPUT", "").(string),
+	Query:  envGet("VENDORS_OUTPUT_QUERY", "").(string),
+}
+
+// vendorsOptions is JSON object of options of vendor method, e.g. {"Channel":"C123","Text":"Deployed"}
+var vendorsOptions = envGet("VENDORS_OPTIONS", "").(string)
+
+var vendorsRegistered = []*Vendor{}
+
+// commands across vendors, which are compiled in along with all of their vendors
+var vendorsCommands = []func() *cobra.Command{}
+
+// server targets and enrichers of vendors, which are added if vendors are compiled in
+var vendorsTargets = []func(targets map[string]server.Target){}
+var vendorsEnrichers = make(map[string]common.Enricher)
+
+// registerVendor is called by init of vendor command file, so that its build tags decide if vendor is compiled in,
+// news are constructors of command file, e.g. slackNew, their methods are methods of vendor of registry
+func registerVendor(name, group string, command func() *cobra.Command, news ...interface{}) {
+
+	vendorsRegistered = append(vendorsRegistered, &Vendor{
+		Name:    name,
+		Group:   group,
+		command: command,
+		news:    news,
+	})
+}
+
+func registerVendorCommand(command func() *cobra.Command) {
+	vendorsCommands = append(vendorsCommands, command)
+}
+
+func registerVendorTargets(targets func(targets map[string]server.Target)) {
+	vendorsTargets = append(vendorsTargets, targets)
+}
+
+func registerVendorEnricher(name string, enricher common.Enricher) {
+	vendorsEnrichers[name] = enricher
+}
+
+// vendorsPlugins returns vendor plugins, plugins which aren't vendors are commands only
+func vendorsPlugins() ([]*common.Plugin, error) {
+
+	plugins, err := common.FindPlugins(pluginsOptions)
+	if err != nil {
+		return nil, err
+	}
+	r := []*common.Plugin{}
+	for _, p := range plugins {
+		if p.Vendor {
+			r = append(r, p)
+		}
+	}
+	return r, nil
+}
+
+// vendorsRegistry returns registry of compiled in vendors and vendor plugins,
+// vendor plugins can't override compiled in vendors, so that they are skipped with warning
+func vendorsRegistry() (*common.VendorRegistry, error) {
+
+	registry := common.NewVendorRegistry()
+	for _, v := range vendorsRegistered {
+		vendor, err := common.NewVendor(v.Name, stdout.Module(v.Name), v.news...)
+		if err != nil {
+			return nil, err
+		}
+		if err := registry.Register(vendor); err != nil {
+			return nil, err
+		}
+	}
+	plugins, err := vendorsPlugins()
+	if err != nil {
+		return nil, err
+	}
+	// plugins are loaded by registry once they're got, so that Go plugins aren't opened for other vendors
+	for _, p := range plugins {
+		plugin := p
+		err := registry.RegisterLoader(plugin.Name, func() (common.Vendor, error) {
+			return common.NewVendorPlugin(plugin, stdout.Module(plugin.Name))
+		})
+		if err != nil {
+			stdout.Warn("Vendor plugin %s is skipped: %s", plugin.Path, err)
+		}
+	}
+	return registry, nil
+}
+
+// vendorsExecute executes method of vendor of registry by options of --vendors-options
+func vendorsExecute(name, method string) ([]byte, error) {
+
+	if err := secretsVendor(name); err != nil {
+		return nil, err
+	}
+	registry, err := vendorsRegistry()
+	if err != nil {
+		return nil, err
+	}
+	vendor, err := registry.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return vendor.Execute(deadlineContext, method, []byte(vendorsOptions))
+}
+
+// vendorsList returns vendors sorted by group and name
+func vendorsList() []*Vendor {
+
+	list := append([]*Vendor{}, vendorsRegistered...)
+	if plugins, err := vendorsPlugins(); err == nil {
+		for _, p := range plugins {
+			list = append(list, &Vendor{Name: p.Name, Group: vendorGroupPlugin, Plugin: p.Path})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Group != list[j].Group {
+			return list[i].Group < list[j].Group
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// addVendorCommands adds commands of compiled in vendors to root
+func addVendorCommands(rootCmd *cobra.Command) {
+
+	for _, v := range vendorsRegistered {
+		rootCmd.AddCommand(v.command())
+	}
+	for _, command := range vendorsCommands {
+		rootCmd.AddCommand(command())
+	}
+}
+
+func NewVendorsCommand() *cobra.Command {
+
+	vendorsCmd := &cobra.Command{
+		Use:   "vendors",
+		Short: "Vendor tools",
+	}
+	flags := vendorsCmd.PersistentFlags()
+	flags.StringVar(&vendorsOutput.Output, "vendors-output", vendorsOutput.Output, "Vendors output")
+	flags.StringVar(&vendorsOutput.Query, "vendors-output-query", vendorsOutput.Query, "Vendors output query")
+
+	vendorsCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List vendors compiled into binary",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Vendors listing...")
+
+			bytes, err := json.Marshal(vendorsList())
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(vendorsOutput, "Vendors", []interface{}{}, bytes, stdout)
+		},
+	})
+
+	vendorsCmd.AddCommand(&cobra.Command{
+		Use:   "methods [vendor]",
+		Short: "List methods of vendor",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Vendor %s methods listing...", args[0])
+
+			registry, err := vendorsRegistry()
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			vendor, err := registry.Get(args[0])
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			methods, err := vendor.Methods()
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes, err := json.Marshal(methods)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(vendorsOutput, "Vendors", []interface{}{}, bytes, stdout)
+		},
+	})
+
+	executeCmd := &cobra.Command{
+		Use:   "execute [vendor] [method]",
+		Short: "Execute method of vendor by JSON options",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Vendor %s method %s executing...", args[0], args[1])
+
+			bytes, err := vendorsExecute(args[0], args[1])
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(vendorsOutput, "Vendors", []interface{}{vendorsOptions}, bytes, stdout)
+		},
+	}
+	flags = executeCmd.PersistentFlags()
+	flags.StringVar(&vendorsOptions, "vendors-options", vendorsOptions, "Vendors JSON object of options of method, e.g. {\"Channel\":\"C123\",\"Text\":\"Deployed\"}, - is stdin")
+	vendorsCmd.AddCommand(executeCmd)
+
+	return vendorsCmd
+}
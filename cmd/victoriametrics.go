@@ -0,0 +1,140 @@
+//go:build !minimal || monitoring || victoriametrics
+
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var victoriaMetricsOptions = vendors.VictoriaMetricsOptions{
+	Timeout:  envGet("VICTORIAMETRICS_TIMEOUT", 30).(int),
+	Insecure: envGet("VICTORIAMETRICS_INSECURE", false).(bool),
+	URL:      envGet("VICTORIAMETRICS_URL", "").(string),
+	Mode:     envGet("VICTORIAMETRICS_MODE", vendors.VictoriaMetricsModeImport).(string),
+	User:     envGet("VICTORIAMETRICS_USER", "").(string),
+	Password: envGet("VICTORIAMETRICS_PASSWORD", "").(string),
+	Token:    envGet("VICTORIAMETRICS_TOKEN", "").(string),
+}
+
+var victoriaMetricsPushOptions = vendors.VictoriaMetricsPushOptions{
+	Metrics: envGet("VICTORIAMETRICS_METRICS", "").(string),
+	Labels:  envGet("VICTORIAMETRICS_LABELS", "").(string),
+}
+
+// commands are recorded as metrics if it's set, env only as they are recorded for any command
+var victoriaMetricsRecord = envGet("VICTORIAMETRICS_RECORD", false).(bool)
+var victoriaMetricsRecordPrefix = envGet("VICTORIAMETRICS_RECORD_PREFIX", "tools").(string)
+
+var victoriaMetricsOutput = common.OutputOptions{
+	Output: envGet("VICTORIAMETRICS_OUTPUT", "").(string),
+	Query:  envGet("VICTORIAMETRICS_OUTPUT_QUERY", "").(string),
+}
+
+func victoriaMetricsNew(stdout *common.Stdout) *vendors.VictoriaMetrics {
+
+	common.Debug("VictoriaMetrics", victoriaMetricsOptions, stdout)
+	common.Debug("VictoriaMetrics", victoriaMetricsOutput, stdout)
+
+	return vendors.NewVictoriaMetrics(victoriaMetricsOptions)
+}
+
+// victoriaMetricsSink records duration and errors of command, e.g. messages sent by chat vendors and their latency
+func victoriaMetricsSink() common.TelemetrySink {
+
+	if !victoriaMetricsRecord || utils.IsEmpty(victoriaMetricsOptions.URL) {
+		return nil
+	}
+	return func(event *common.TelemetryEvent) error {
+
+		labels := map[string]string{
+			"command": event.Command,
+			"status":  event.Status,
+			"version": event.Version,
+		}
+		if !utils.IsEmpty(event.Vendor) {
+			labels["vendor"] = event.Vendor
+		}
+		if !utils.IsEmpty(event.Source) {
+			labels["source"] = event.Source
+		}
+		ts := event.Time.UnixMilli()
+
+		samples := []*vendors.VictoriaMetricsSample{{
+			Name:      victoriaMetricsRecordPrefix + "_command_duration_seconds",
+			Labels:    labels,
+			Value:     float64(event.DurationMs) / 1000,
+			Timestamp: ts,
+		}}
+		for category, count := range event.Errors {
+			errorLabels := map[string]string{"category": category}
+			for k, v := range labels {
+				errorLabels[k] = v
+			}
+			samples = append(samples, &vendors.VictoriaMetricsSample{
+				Name:      victoriaMetricsRecordPrefix + "_command_errors",
+				Labels:    errorLabels,
+				Value:     float64(count),
+				Timestamp: ts,
+			})
+		}
+		_, err := vendors.NewVictoriaMetrics(victoriaMetricsOptions).PushSamples(samples)
+		return err
+	}
+}
+
+func NewVictoriaMetricsCommand() *cobra.Command {
+
+	victoriaMetricsCmd := &cobra.Command{
+		Use:   "victoriametrics",
+		Short: "VictoriaMetrics tools",
+	}
+	flags := victoriaMetricsCmd.PersistentFlags()
+	flags.IntVar(&victoriaMetricsOptions.Timeout, "victoriametrics-timeout", victoriaMetricsOptions.Timeout, "VictoriaMetrics timeout in seconds")
+	flags.BoolVar(&victoriaMetricsOptions.Insecure, "victoriametrics-insecure", victoriaMetricsOptions.Insecure, "VictoriaMetrics insecure")
+	flags.StringVar(&victoriaMetricsOptions.URL, "victoriametrics-url", victoriaMetricsOptions.URL, "VictoriaMetrics URL, e.g. http://victoriametrics:8428 or http://prometheus:9090 for remote write")
+	flags.StringVar(&victoriaMetricsOptions.Mode, "victoriametrics-mode", victoriaMetricsOptions.Mode, "VictoriaMetrics mode: import, remote-write")
+	flags.StringVar(&victoriaMetricsOptions.User, "victoriametrics-user", victoriaMetricsOptions.User, "VictoriaMetrics user")
+	flags.StringVar(&victoriaMetricsOptions.Password, "victoriametrics-password", victoriaMetricsOptions.Password, "VictoriaMetrics password")
+	flags.StringVar(&victoriaMetricsOptions.Token, "victoriametrics-token", victoriaMetricsOptions.Token, "VictoriaMetrics bearer token")
+	flags.StringVar(&victoriaMetricsOutput.Output, "victoriametrics-output", victoriaMetricsOutput.Output, "VictoriaMetrics output")
+	flags.StringVar(&victoriaMetricsOutput.Query, "victoriametrics-output-query", victoriaMetricsOutput.Query, "VictoriaMetrics output query")
+
+	pushCmd := &cobra.Command{
+		Use:   "push",
+		Short: "Push metrics",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("VictoriaMetrics pushing metrics...")
+			common.Debug("VictoriaMetrics", victoriaMetricsPushOptions, stdout)
+
+			metricsBytes, err := utils.Content(victoriaMetricsPushOptions.Metrics)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			victoriaMetricsPushOptions.Metrics = string(metricsBytes)
+
+			bytes, err := idempotent("", victoriaMetricsPushOptions, func() ([]byte, error) {
+				return victoriaMetricsNew(stdout).Push(victoriaMetricsPushOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(victoriaMetricsOutput, "VictoriaMetrics", []interface{}{victoriaMetricsOptions, victoriaMetricsPushOptions}, bytes, stdout)
+		},
+	}
+	flags = pushCmd.PersistentFlags()
+	flags.StringVar(&victoriaMetricsPushOptions.Metrics, "victoriametrics-metrics", victoriaMetricsPushOptions.Metrics, "VictoriaMetrics metrics in Prometheus text format: content or path")
+	flags.StringVar(&victoriaMetricsPushOptions.Labels, "victoriametrics-labels", victoriaMetricsPushOptions.Labels, "VictoriaMetrics labels added to metrics: name=value,name=value")
+	victoriaMetricsCmd.AddCommand(idempotentCommand(pushCmd))
+
+	return victoriaMetricsCmd
+}
+
+func init() {
+	registerVendor("victoriametrics", vendorGroupMonitoring, NewVictoriaMetricsCommand, victoriaMetricsNew)
+	registerTelemetrySink(victoriaMetricsSink)
+}
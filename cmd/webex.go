@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var webexOptions = vendors.WebexOptions{
+	Timeout:  envGet("WEBEX_TIMEOUT", 30).(int),
+	Insecure: envGet("WEBEX_INSECURE", false).(bool),
+	URL:      envGet("WEBEX_URL", "https://webexapis.com/v1").(string),
+	Token:    envGet("WEBEX_TOKEN", "").(string),
+}
+
+var webexRoomOptions = vendors.WebexRoomOptions{
+	Title:  envGet("WEBEX_ROOM_TITLE", "").(string),
+	TeamID: envGet("WEBEX_TEAM_ID", "").(string),
+}
+
+var webexMessageOptions = vendors.WebexMessageOptions{
+	Room:          envGet("WEBEX_ROOM", "").(string),
+	ToPersonEmail: envGet("WEBEX_TO_PERSON_EMAIL", "").(string),
+	Parent:        envGet("WEBEX_PARENT", "").(string),
+	Text:          envGet("WEBEX_MESSAGE_TEXT", "").(string),
+	Markdown:      envGet("WEBEX_MESSAGE_MARKDOWN", "").(string),
+	Attachments:   envGet("WEBEX_MESSAGE_ATTACHMENTS", "").(string),
+}
+
+var webexFileOptions = vendors.WebexFileOptions{
+	WebexMessageOptions: webexMessageOptions,
+	Name:                envGet("WEBEX_FILE_NAME", "").(string),
+	File:                envGet("WEBEX_FILE", "").(string),
+}
+
+var webexMembershipsOptions = vendors.WebexMembershipsOptions{
+	Room:        envGet("WEBEX_ROOM", "").(string),
+	PersonEmail: envGet("WEBEX_PERSON_EMAIL", "").(string),
+	Max:         envGet("WEBEX_MEMBERSHIPS_MAX", 100).(int),
+}
+
+var webexOutput = common.OutputOptions{
+	Output: envGet("WEBEX_OUTPUT", "").(string),
+	Query:  envGet("WEBEX_OUTPUT_QUERY", "").(string),
+}
+
+func webexNew(stdout *common.Stdout) *vendors.Webex {
+
+	common.Debug("Webex", webexOptions, stdout)
+	common.Debug("Webex", webexOutput, stdout)
+
+	webexMessageOptions.Room = serviceChannel(stdout, "webex", webexMessageOptions.Room)
+	webexFileOptions.Room = serviceChannel(stdout, "webex", webexFileOptions.Room)
+	webexMembershipsOptions.Room = serviceChannel(stdout, "webex", webexMembershipsOptions.Room)
+
+	return vendors.NewWebex(webexOptions)
+}
+
+func webexMessageFlags(cmd *cobra.Command, opts *vendors.WebexMessageOptions) {
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&opts.Room, "webex-room", opts.Room, "Webex room ID")
+	flags.StringVar(&opts.ToPersonEmail, "webex-to-person-email", opts.ToPersonEmail, "Webex person email for direct message, if there is no room")
+	flags.StringVar(&opts.Parent, "webex-parent", opts.Parent, "Webex parent message ID to reply in thread")
+	flags.StringVar(&opts.Text, "webex-message-text", opts.Text, "Webex message text")
+	flags.StringVar(&opts.Markdown, "webex-message-markdown", opts.Markdown, "Webex message markdown")
+	flags.StringVar(&opts.Attachments, "webex-message-attachments", opts.Attachments, "Webex message attachments json, e.g. adaptive cards")
+}
+
+// webexContent reads text, markdown and attachments, each of them is content or path
+func webexContent(opts *vendors.WebexMessageOptions) {
+
+	for _, s := range []*string{&opts.Text, &opts.Markdown, &opts.Attachments} {
+		b, err := utils.Content(*s)
+		if err != nil {
+			stdout.Panic(err)
+		}
+		*s = string(b)
+	}
+}
+
+func NewWebexCommand() *cobra.Command {
+
+	webexCmd := &cobra.Command{
+		Use:   "webex",
+		Short: "Webex tools",
+	}
+
+	flags := webexCmd.PersistentFlags()
+	flags.IntVar(&webexOptions.Timeout, "webex-timeout", webexOptions.Timeout, "Webex timeout")
+	flags.BoolVar(&webexOptions.Insecure, "webex-insecure", webexOptions.Insecure, "Webex insecure")
+	flags.StringVar(&webexOptions.URL, "webex-url", webexOptions.URL, "Webex API URL")
+	flags.StringVar(&webexOptions.Token, "webex-token", webexOptions.Token, "Webex bot or integration token")
+	flags.StringVar(&webexOutput.Output, "webex-output", webexOutput.Output, "Webex output")
+	flags.StringVar(&webexOutput.Query, "webex-output-query", webexOutput.Query, "Webex output query")
+
+	createRoom := &cobra.Command{
+		Use:   "create-room",
+		Short: "Create room",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Webex creating room %s...", webexRoomOptions.Title)
+			common.Debug("Webex", webexRoomOptions, stdout)
+
+			bytes, err := idempotent("", webexRoomOptions, func() ([]byte, error) {
+				return webexNew(stdout).CreateRoom(webexRoomOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(webexOutput, "Webex", []interface{}{webexOptions, webexRoomOptions}, bytes, stdout)
+		},
+	}
+	flags = createRoom.PersistentFlags()
+	flags.StringVar(&webexRoomOptions.Title, "webex-room-title", webexRoomOptions.Title, "Webex room title")
+	flags.StringVar(&webexRoomOptions.TeamID, "webex-team-id", webexRoomOptions.TeamID, "Webex team ID the room belongs to")
+	webexCmd.AddCommand(idempotentCommand(createRoom))
+
+	sendMessage := &cobra.Command{
+		Use:   "send-message",
+		Short: "Send message",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Webex sending message...")
+			common.Debug("Webex", webexMessageOptions, stdout)
+
+			webexContent(&webexMessageOptions)
+
+			if !hooksPreSend(stdout, "webex", &webexMessageOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", webexMessageOptions, func() ([]byte, error) {
+				return webexNew(stdout).SendMessage(webexMessageOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "webex", bytes)
+			common.OutputJson(webexOutput, "Webex", []interface{}{webexOptions, webexMessageOptions}, bytes, stdout)
+		},
+	}
+	webexMessageFlags(sendMessage, &webexMessageOptions)
+	webexCmd.AddCommand(idempotentCommand(sendMessage))
+
+	sendFile := &cobra.Command{
+		Use:   "send-file",
+		Short: "Send file",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Webex sending file...")
+			common.Debug("Webex", webexFileOptions, stdout)
+
+			webexContent(&webexFileOptions.WebexMessageOptions)
+
+			fileBytes, err := utils.Content(webexFileOptions.File)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			webexFileOptions.File = string(fileBytes)
+
+			if !hooksPreSend(stdout, "webex", &webexFileOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", webexFileOptions, func() ([]byte, error) {
+				return webexNew(stdout).SendFile(webexFileOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "webex", bytes)
+			common.OutputJson(webexOutput, "Webex", []interface{}{webexOptions, webexFileOptions}, bytes, stdout)
+		},
+	}
+	webexMessageFlags(sendFile, &webexFileOptions.WebexMessageOptions)
+	flags = sendFile.PersistentFlags()
+	flags.StringVar(&webexFileOptions.Name, "webex-file-name", webexFileOptions.Name, "Webex file name")
+	flags.StringVar(&webexFileOptions.File, "webex-file", webexFileOptions.File, "Webex file content or path")
+	webexCmd.AddCommand(idempotentCommand(sendFile))
+
+	listMemberships := &cobra.Command{
+		Use:   "list-memberships",
+		Short: "List memberships of room or person",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Webex listing memberships...")
+			common.Debug("Webex", webexMembershipsOptions, stdout)
+
+			bytes, err := webexNew(stdout).GetMemberships(webexMembershipsOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(webexOutput, "Webex", []interface{}{webexOptions, webexMembershipsOptions}, bytes, stdout)
+		},
+	}
+	flags = listMemberships.PersistentFlags()
+	flags.StringVar(&webexMembershipsOptions.Room, "webex-room", webexMembershipsOptions.Room, "Webex room ID")
+	flags.StringVar(&webexMembershipsOptions.PersonEmail, "webex-person-email", webexMembershipsOptions.PersonEmail, "Webex person email")
+	flags.IntVar(&webexMembershipsOptions.Max, "webex-memberships-max", webexMembershipsOptions.Max, "Webex memberships max count")
+	webexCmd.AddCommand(listMemberships)
+
+	return webexCmd
+}
+
+func init() {
+	registerVendor("webex", vendorGroupChat, NewWebexCommand, webexNew)
+}
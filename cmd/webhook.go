@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/render"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+	"github.com/spf13/cobra"
+)
+
+var webhookOptions = vendors.WebhookOptions{
+	Timeout:         envGet("WEBHOOK_TIMEOUT", 30).(int),
+	Insecure:        envGet("WEBHOOK_INSECURE", false).(bool),
+	URL:             envGet("WEBHOOK_URL", "").(string),
+	Method:          envGet("WEBHOOK_METHOD", "POST").(string),
+	Headers:         envGet("WEBHOOK_HEADERS", "").(string),
+	Secret:          envGet("WEBHOOK_SECRET", "").(string),
+	Signature:       envGet("WEBHOOK_SIGNATURE", "").(string),
+	SignatureHeader: envGet("WEBHOOK_SIGNATURE_HEADER", "X-Signature").(string),
+	Retries:         envGet("WEBHOOK_RETRIES", 0).(int),
+	RetryDelay:      envGet("WEBHOOK_RETRY_DELAY", 1).(int),
+}
+
+var webhookSendOptions = vendors.WebhookSendOptions{
+	Payload:     envGet("WEBHOOK_PAYLOAD", "").(string),
+	Format:      envGet("WEBHOOK_FORMAT", vendors.WebhookFormatJSON).(string),
+	ExpectCodes: webhookCodes(envGet("WEBHOOK_EXPECT_CODES", "").(string)),
+	ExpectBody:  envGet("WEBHOOK_EXPECT_BODY", "").(string),
+	ExpectQuery: envGet("WEBHOOK_EXPECT_QUERY", "").(string),
+}
+
+var webhookObject = envGet("WEBHOOK_OBJECT", "").(string)
+
+var webhookOutput = common.OutputOptions{
+	Output: envGet("WEBHOOK_OUTPUT", "").(string),
+	Query:  envGet("WEBHOOK_OUTPUT_QUERY", "").(string),
+}
+
+// webhookCodes returns codes of comma separated list, invalid codes are skipped
+func webhookCodes(s string) []int {
+
+	codes := []int{}
+	for _, c := range strings.Split(s, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(c))
+		if err == nil {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+func webhookNew(stdout *common.Stdout) *vendors.Webhook {
+
+	common.Debug("Webhook", webhookOptions, stdout)
+	common.Debug("Webhook", webhookOutput, stdout)
+
+	return vendors.NewWebhook(webhookOptions)
+}
+
+// webhookRender renders payload as template of object, payload without actions is kept as is
+func webhookRender(stdout *common.Stdout) (string, error) {
+
+	payload, err := utils.Content(webhookSendOptions.Payload)
+	if err != nil {
+		return "", err
+	}
+	object, err := utils.Content(webhookObject)
+	if err != nil {
+		return "", err
+	}
+	tpl, err := render.NewTextTemplate(render.TemplateOptions{
+		Name:       "webhook",
+		Content:    string(payload),
+		Object:     enrichObject(stdout, string(object)),
+		TimeFormat: time.RFC3339,
+	}, stdout)
+	if err != nil {
+		return "", err
+	}
+	b, err := tpl.Render()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func NewWebhookCommand() *cobra.Command {
+
+	webhookCmd := &cobra.Command{
+		Use:   "webhook",
+		Short: "Webhook tools",
+	}
+
+	flags := webhookCmd.PersistentFlags()
+	flags.IntVar(&webhookOptions.Timeout, "webhook-timeout", webhookOptions.Timeout, "Webhook timeout")
+	flags.BoolVar(&webhookOptions.Insecure, "webhook-insecure", webhookOptions.Insecure, "Webhook insecure")
+	flags.StringVar(&webhookOptions.URL, "webhook-url", webhookOptions.URL, "Webhook URL")
+	flags.StringVar(&webhookOptions.Method, "webhook-method", webhookOptions.Method, "Webhook method: POST, PUT, PATCH")
+	flags.StringVar(&webhookOptions.Headers, "webhook-headers", webhookOptions.Headers, "Webhook headers: Name=value,Name=value")
+	flags.StringVar(&webhookOptions.Secret, "webhook-secret", webhookOptions.Secret, "Webhook secret to sign payload")
+	flags.StringVar(&webhookOptions.Signature, "webhook-signature", webhookOptions.Signature, "Webhook signature: github, slack, hmac, none if empty")
+	flags.StringVar(&webhookOptions.SignatureHeader, "webhook-signature-header", webhookOptions.SignatureHeader, "Webhook header of hmac signature")
+	flags.IntVar(&webhookOptions.Retries, "webhook-retries", webhookOptions.Retries, "Webhook retries on network errors, 429 and 5xx")
+	flags.IntVar(&webhookOptions.RetryDelay, "webhook-retry-delay", webhookOptions.RetryDelay, "Webhook delay before first retry in seconds, it's doubled for next ones")
+	flags.StringVar(&webhookOutput.Output, "webhook-output", webhookOutput.Output, "Webhook output")
+	flags.StringVar(&webhookOutput.Query, "webhook-output-query", webhookOutput.Query, "Webhook output query")
+
+	sendCmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send payload",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Webhook sending to %s...", webhookOptions.URL)
+			common.Debug("Webhook", webhookSendOptions, stdout)
+
+			payload, err := webhookRender(stdout)
+			if err != nil {
+				stdout.Panic(err)
+			}
+			webhookSendOptions.Payload = payload
+
+			if !hooksPreSend(stdout, "webhook", &webhookSendOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", webhookSendOptions, func() ([]byte, error) {
+				return webhookNew(stdout).Send(webhookSendOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "webhook", bytes)
+			common.OutputJson(webhookOutput, "Webhook", []interface{}{webhookOptions, webhookSendOptions}, bytes, stdout)
+		},
+	}
+	flags = sendCmd.PersistentFlags()
+	flags.StringVar(&webhookSendOptions.Payload, "webhook-payload", webhookSendOptions.Payload, "Webhook payload template content or path")
+	flags.StringVar(&webhookObject, "webhook-object", webhookObject, "Webhook object of payload template: json content or path")
+	flags.StringVar(&webhookSendOptions.Format, "webhook-format", webhookSendOptions.Format, "Webhook format: json, form")
+	flags.IntSliceVar(&webhookSendOptions.ExpectCodes, "webhook-expect-codes", webhookSendOptions.ExpectCodes, "Webhook expected response codes, 2xx if empty")
+	flags.StringVar(&webhookSendOptions.ExpectBody, "webhook-expect-body", webhookSendOptions.ExpectBody, "Webhook regex response body should match")
+	flags.StringVar(&webhookSendOptions.ExpectQuery, "webhook-expect-query", webhookSendOptions.ExpectQuery, "Webhook JSONata query response body should satisfy")
+	webhookCmd.AddCommand(idempotentCommand(sendCmd))
+
+	return webhookCmd
+}
+
+func init() {
+	registerVendor("webhook", vendorGroupChat, NewWebhookCommand, webhookNew)
+}
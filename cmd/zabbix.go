@@ -1,3 +1,5 @@
+//go:build !minimal || monitoring || zabbix
+
 package cmd
 
 import (
@@ -14,6 +16,31 @@ var zabbixHostOptions = vendors.ZabbixHostOptions{
 	Interfaces: strings.Split(envGet("ZABBIX_HOST_INTERFACSES", "").(string), ","),
 }
 
+var zabbixAcknowledgeOptions = vendors.ZabbixAcknowledgeOptions{
+	EventIDs: strings.Split(envGet("ZABBIX_EVENT_IDS", "").(string), ","),
+	Message:  envGet("ZABBIX_ACKNOWLEDGE_MESSAGE", "").(string),
+	Close:    envGet("ZABBIX_ACKNOWLEDGE_CLOSE", false).(bool),
+	Severity: envGet("ZABBIX_ACKNOWLEDGE_SEVERITY", -1).(int),
+}
+
+var zabbixMaintenanceOptions = vendors.ZabbixMaintenanceOptions{
+	Name:        envGet("ZABBIX_MAINTENANCE_NAME", "").(string),
+	Description: envGet("ZABBIX_MAINTENANCE_DESCRIPTION", "").(string),
+	HostIDs:     strings.Split(envGet("ZABBIX_MAINTENANCE_HOST_IDS", "").(string), ","),
+	GroupIDs:    strings.Split(envGet("ZABBIX_MAINTENANCE_GROUP_IDS", "").(string), ","),
+	StartsAt:    envGet("ZABBIX_MAINTENANCE_STARTS_AT", "").(string),
+	Duration:    envGet("ZABBIX_MAINTENANCE_DURATION", "1h").(string),
+	NoData:      envGet("ZABBIX_MAINTENANCE_NO_DATA", false).(bool),
+}
+
+var zabbixTriggerOptions = vendors.ZabbixTriggerOptions{
+	HostIDs:     strings.Split(envGet("ZABBIX_TRIGGER_HOST_IDS", "").(string), ","),
+	GroupIDs:    strings.Split(envGet("ZABBIX_TRIGGER_GROUP_IDS", "").(string), ","),
+	MinSeverity: envGet("ZABBIX_TRIGGER_MIN_SEVERITY", 0).(int),
+	Problems:    envGet("ZABBIX_TRIGGER_PROBLEMS", true).(bool),
+	Limit:       envGet("ZABBIX_TRIGGER_LIMIT", 0).(int),
+}
+
 var zabbixOptions = vendors.ZabbixOptions{
 	Timeout:  envGet("ZABBIX_TIMEOUT", 30).(int),
 	Insecure: envGet("ZABBIX_INSECURE", false).(bool),
@@ -78,5 +105,96 @@ func NewZabbixCommand() *cobra.Command {
 	flags.StringSliceVar(&zabbixHostOptions.Interfaces, "zabbix-host-interfaces", zabbixHostOptions.Interfaces, "Zabbix get host interfaces")
 	zabbixCmd.AddCommand(zabbixGetHostsCmd)
 
+	zabbixGetTriggersCmd := &cobra.Command{
+		Use:   "get-triggers",
+		Short: "Get current triggers of monitored hosts",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Zabbix getting triggers...")
+			common.Debug("Zabbix", zabbixTriggerOptions, stdout)
+
+			bytes, err := zabbixNew(stdout).GetTriggers(zabbixTriggerOptions)
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			common.OutputJson(zabbixOutput, "Zabbix", []interface{}{zabbixOptions, zabbixTriggerOptions}, bytes, stdout)
+		},
+	}
+	flags = zabbixGetTriggersCmd.PersistentFlags()
+	flags.StringSliceVar(&zabbixTriggerOptions.HostIDs, "zabbix-trigger-host-ids", zabbixTriggerOptions.HostIDs, "Zabbix trigger host IDs, all if empty")
+	flags.StringSliceVar(&zabbixTriggerOptions.GroupIDs, "zabbix-trigger-group-ids", zabbixTriggerOptions.GroupIDs, "Zabbix trigger host group IDs, all if empty")
+	flags.IntVar(&zabbixTriggerOptions.MinSeverity, "zabbix-trigger-min-severity", zabbixTriggerOptions.MinSeverity, "Zabbix trigger min severity: 0 not classified, 1 information, 2 warning, 3 average, 4 high, 5 disaster")
+	flags.BoolVar(&zabbixTriggerOptions.Problems, "zabbix-trigger-problems", zabbixTriggerOptions.Problems, "Zabbix triggers in problem state only")
+	flags.IntVar(&zabbixTriggerOptions.Limit, "zabbix-trigger-limit", zabbixTriggerOptions.Limit, "Zabbix triggers limit, all if zero")
+	zabbixCmd.AddCommand(zabbixGetTriggersCmd)
+
+	zabbixAcknowledgeCmd := &cobra.Command{
+		Use:   "acknowledge",
+		Short: "Acknowledge problem events",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Zabbix acknowledging events %s...", strings.Join(zabbixAcknowledgeOptions.EventIDs, ","))
+			common.Debug("Zabbix", zabbixAcknowledgeOptions, stdout)
+
+			if !hooksPreSend(stdout, "zabbix", &zabbixAcknowledgeOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", zabbixAcknowledgeOptions, func() ([]byte, error) {
+				return zabbixNew(stdout).Acknowledge(zabbixAcknowledgeOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "zabbix", bytes)
+			common.OutputJson(zabbixOutput, "Zabbix", []interface{}{zabbixOptions, zabbixAcknowledgeOptions}, bytes, stdout)
+		},
+	}
+	flags = zabbixAcknowledgeCmd.PersistentFlags()
+	flags.StringSliceVar(&zabbixAcknowledgeOptions.EventIDs, "zabbix-event-ids", zabbixAcknowledgeOptions.EventIDs, "Zabbix event IDs, e.g. lastEvent.eventid of triggers")
+	flags.StringVar(&zabbixAcknowledgeOptions.Message, "zabbix-acknowledge-message", zabbixAcknowledgeOptions.Message, "Zabbix acknowledge message")
+	flags.BoolVar(&zabbixAcknowledgeOptions.Close, "zabbix-acknowledge-close", zabbixAcknowledgeOptions.Close, "Zabbix closes problems, trigger must allow manual close")
+	flags.IntVar(&zabbixAcknowledgeOptions.Severity, "zabbix-acknowledge-severity", zabbixAcknowledgeOptions.Severity, "Zabbix changes severity to 0-5, unchanged if negative")
+	zabbixCmd.AddCommand(idempotentCommand(zabbixAcknowledgeCmd))
+
+	zabbixCreateMaintenanceCmd := &cobra.Command{
+		Use:   "create-maintenance",
+		Short: "Create maintenance of hosts and groups",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			stdout.Debug("Zabbix creating maintenance %s...", zabbixMaintenanceOptions.Name)
+			common.Debug("Zabbix", zabbixMaintenanceOptions, stdout)
+
+			if !hooksPreSend(stdout, "zabbix", &zabbixMaintenanceOptions) {
+				return
+			}
+
+			bytes, err := idempotent("", zabbixMaintenanceOptions, func() ([]byte, error) {
+				return zabbixNew(stdout).CreateMaintenance(zabbixMaintenanceOptions)
+			})
+			if err != nil {
+				stdout.Error(err)
+				return
+			}
+			bytes = hooksPostResponse(stdout, "zabbix", bytes)
+			common.OutputJson(zabbixOutput, "Zabbix", []interface{}{zabbixOptions, zabbixMaintenanceOptions}, bytes, stdout)
+		},
+	}
+	flags = zabbixCreateMaintenanceCmd.PersistentFlags()
+	flags.StringVar(&zabbixMaintenanceOptions.Name, "zabbix-maintenance-name", zabbixMaintenanceOptions.Name, "Zabbix maintenance name, it's unique")
+	flags.StringVar(&zabbixMaintenanceOptions.Description, "zabbix-maintenance-description", zabbixMaintenanceOptions.Description, "Zabbix maintenance description")
+	flags.StringSliceVar(&zabbixMaintenanceOptions.HostIDs, "zabbix-maintenance-host-ids", zabbixMaintenanceOptions.HostIDs, "Zabbix maintenance host IDs")
+	flags.StringSliceVar(&zabbixMaintenanceOptions.GroupIDs, "zabbix-maintenance-group-ids", zabbixMaintenanceOptions.GroupIDs, "Zabbix maintenance host group IDs")
+	flags.StringVar(&zabbixMaintenanceOptions.StartsAt, "zabbix-maintenance-starts-at", zabbixMaintenanceOptions.StartsAt, "Zabbix maintenance start in RFC3339, now if empty")
+	flags.StringVar(&zabbixMaintenanceOptions.Duration, "zabbix-maintenance-duration", zabbixMaintenanceOptions.Duration, "Zabbix maintenance duration, e.g. 2h30m")
+	flags.BoolVar(&zabbixMaintenanceOptions.NoData, "zabbix-maintenance-no-data", zabbixMaintenanceOptions.NoData, "Zabbix maintenance without data collection")
+	zabbixCmd.AddCommand(idempotentCommand(zabbixCreateMaintenanceCmd))
+
 	return zabbixCmd
 }
+
+func init() {
+	registerVendor("zabbix", vendorGroupMonitoring, NewZabbixCommand, zabbixNew)
+}
@@ -0,0 +1,214 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devopsext/utils"
+	"github.com/google/uuid"
+)
+
+const auditSyslog = "syslog"
+
+// env vars of CI users, the first one which is set is actor of audit records
+var auditActorEnvs = []string{"GITHUB_ACTOR", "GITLAB_USER_LOGIN", "BUILD_USER_ID", "BITBUCKET_STEP_TRIGGERER_UUID", "BUILD_REQUESTEDFOR"}
+
+// headers of vendor responses having ids of requests, so that records can be found in logs of vendors
+var auditRequestIDHeaders = []string{"X-Request-Id", "X-Slack-Req-Id", "X-Correlation-Id", "X-Amzn-Requestid", "X-Github-Request-Id"}
+
+type AuditOptions struct {
+	Log           string
+	Actor         string
+	CorrelationID string
+}
+
+// AuditRecord is written as JSON line for each mutating request of vendors, e.g. message sent or event created,
+// URL is redacted and bodies aren't written, so that secrets aren't in audit log, target is what's changed,
+// e.g. channel or calendar
+type AuditRecord struct {
+	Time          time.Time `json:"time"`
+	CorrelationID string    `json:"correlation_id"`
+	Actor         string    `json:"actor"`
+	Host          string    `json:"host,omitempty"`
+	Command       string    `json:"command,omitempty"`
+	Vendor        string    `json:"vendor,omitempty"`
+	Method        string    `json:"method"`
+	URL           string    `json:"url,omitempty"`
+	Target        string    `json:"target,omitempty"`
+	Status        int       `json:"status,omitempty"`
+	RequestID     string    `json:"request_id,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	Duration      float64   `json:"duration"`
+}
+
+var audit = struct {
+	mutex         sync.Mutex
+	writer        io.Writer
+	closer        io.Closer
+	actor         string
+	host          string
+	command       string
+	target        string
+	correlationID string
+}{}
+
+// auditActor returns actor of options, CI user or OS user
+func auditActor(options AuditOptions) string {
+
+	if !utils.IsEmpty(options.Actor) {
+		return options.Actor
+	}
+	for _, env := range auditActorEnvs {
+		if v := os.Getenv(env); !utils.IsEmpty(v) {
+			return v
+		}
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// SetAudit starts audit log of command, it's JSON lines file or syslog, e.g. syslog or syslog://host:514,
+// audit is disabled if log is empty, correlation id is generated if it isn't set, e.g. by CI job id,
+// target of command, e.g. slack-channel=C123, is target of its records
+func SetAudit(options AuditOptions, command, target string) error {
+
+	CloseAudit()
+	if utils.IsEmpty(options.Log) {
+		return nil
+	}
+
+	var w io.Writer
+	var c io.Closer
+	switch {
+	case options.Log == auditSyslog || strings.HasPrefix(options.Log, auditSyslog+"://"):
+		s, err := auditSyslogWriter(strings.TrimPrefix(strings.TrimPrefix(options.Log, auditSyslog), "://"))
+		if err != nil {
+			return fmt.Errorf("audit syslog: %s", err)
+		}
+		w, c = s, s
+	default:
+		f, err := os.OpenFile(options.Log, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("audit log: %s", err)
+		}
+		w, c = f, f
+	}
+
+	correlationID := options.CorrelationID
+	if utils.IsEmpty(correlationID) {
+		correlationID = uuid.New().String()
+	}
+	host, _ := os.Hostname()
+
+	audit.mutex.Lock()
+	defer audit.mutex.Unlock()
+
+	audit.writer = w
+	audit.closer = c
+	audit.actor = auditActor(options)
+	audit.host = host
+	audit.command = command
+	audit.target = target
+	audit.correlationID = correlationID
+	return nil
+}
+
+func CloseAudit() {
+
+	audit.mutex.Lock()
+	defer audit.mutex.Unlock()
+
+	if audit.closer != nil {
+		audit.closer.Close()
+	}
+	audit.writer = nil
+	audit.closer = nil
+}
+
+// auditWrite writes record of time, actor and command of audit, failures of writing aren't errors of operations
+func auditWrite(r *AuditRecord) {
+
+	audit.mutex.Lock()
+	defer audit.mutex.Unlock()
+
+	if audit.writer == nil {
+		return
+	}
+	r.CorrelationID = audit.correlationID
+	r.Actor = audit.actor
+	r.Host = audit.host
+	r.Command = audit.command
+	if utils.IsEmpty(r.Target) {
+		r.Target = audit.target
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	audit.writer.Write(append(b, '\n'))
+}
+
+type auditSkipKey struct{}
+
+// AuditSkipContext returns context of requests which aren't audited, e.g. of queries sent by POST, such as searches
+func AuditSkipContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, auditSkipKey{}, true)
+}
+
+// auditRequest writes record of request which isn't safe, e.g. POST, records of failed requests are written as well,
+// as they could be done by vendor, requests of context of AuditSkipContext aren't written
+func auditRequest(vendor string, req *http.Request, resp *http.Response, err error, start time.Time) {
+
+	if httpSafeMethods[req.Method] || req.Context().Value(auditSkipKey{}) != nil {
+		return
+	}
+	r := &AuditRecord{
+		Time:     start.UTC(),
+		Vendor:   vendor,
+		Method:   req.Method,
+		URL:      httpRedactURL(req.URL),
+		Duration: time.Since(start).Seconds(),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	if resp != nil {
+		r.Status = resp.StatusCode
+		for _, h := range auditRequestIDHeaders {
+			if v := resp.Header.Get(h); !utils.IsEmpty(v) {
+				r.RequestID = v
+				break
+			}
+		}
+	}
+	auditWrite(r)
+}
+
+// AuditOperation writes record of operation of vendors which don't send HTTP requests, e.g. email sent over SMTP
+// or command run over SSH, method is operation, e.g. SEND or RUN, target is what it's done on, e.g. recipients,
+// target of command is used if it's empty
+func AuditOperation(vendor, method, url, target string, err error, start time.Time) {
+
+	r := &AuditRecord{
+		Time:     start.UTC(),
+		Vendor:   vendor,
+		Method:   method,
+		URL:      url,
+		Target:   target,
+		Duration: time.Since(start).Seconds(),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	auditWrite(r)
+}
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAudit(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := SetAudit(AuditOptions{Log: file, Actor: "ci", CorrelationID: "job-1"}, "slack send-message", "slack-channel=C123"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(CloseAudit)
+
+	client, url, _ := testHttpClient(t, HttpClientOptions{},
+		func(w http.ResponseWriter, r *http.Request, calls int32) {
+			w.Header().Set("X-Slack-Req-Id", "req-1")
+			w.WriteHeader(http.StatusCreated)
+		})
+
+	// GET isn't mutating, so that it isn't recorded, secrets of URL are redacted
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		req, _ := http.NewRequest(method, url+"?token=secret", strings.NewReader("{}"))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// queries sent by POST, telemetry and metrics aren't recorded either
+	skip, _ := http.NewRequestWithContext(AuditSkipContext(context.Background()), http.MethodPost, url, strings.NewReader("{}"))
+	internal, _ := http.NewRequest(http.MethodPost, url, strings.NewReader("{}"))
+	for _, r := range []struct {
+		client *http.Client
+		req    *http.Request
+	}{{client, skip}, {HttpClientAuditSkip(client), internal}} {
+		resp, err := r.client.Do(r.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// operations which aren't HTTP are recorded by vendors, target of command is used if operation has none
+	AuditOperation("email", "SEND", "smtp://mail:25", "ops@example.com", nil, time.Now())
+	AuditOperation("ssh", "RUN", "ssh://deploy@host:22", "", errors.New("failed"), time.Now())
+	CloseAudit()
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records := []AuditRecord{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var r AuditRecord
+		if err := json.Unmarshal(s.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+	r := records[0]
+	if r.Method != http.MethodPost || r.Status != http.StatusCreated || r.RequestID != "req-1" || r.Target != "slack-channel=C123" ||
+		r.Actor != "ci" || r.CorrelationID != "job-1" || r.Command != "slack send-message" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+	if r := records[1]; r.Vendor != "email" || r.Method != "SEND" || r.Target != "ops@example.com" || r.Actor != "ci" || r.Error != "" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+	if r := records[2]; r.Vendor != "ssh" || r.Target != "slack-channel=C123" || r.Error != "failed" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+	if strings.Contains(r.URL, "secret") {
+		t.Fatalf("expected redacted URL, got %s", r.URL)
+	}
+
+	// correlation id is generated if it isn't set
+	if err := SetAudit(AuditOptions{Log: file}, "telegram send", ""); err != nil {
+		t.Fatal(err)
+	}
+	if audit.correlationID == "" || audit.actor == "" {
+		t.Fatalf("expected correlation id and actor, got %q and %q", audit.correlationID, audit.actor)
+	}
+}
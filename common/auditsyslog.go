@@ -0,0 +1,19 @@
+//go:build !windows && !plan9
+
+package common
+
+import (
+	"io"
+	"log/syslog"
+
+	"github.com/devopsext/utils"
+)
+
+// auditSyslogWriter returns writer of local syslog or of remote one by UDP, e.g. host:514
+func auditSyslogWriter(addr string) (io.WriteCloser, error) {
+
+	if utils.IsEmpty(addr) {
+		return syslog.New(syslog.LOG_INFO|syslog.LOG_AUTH, "tools")
+	}
+	return syslog.Dial("udp", addr, syslog.LOG_INFO|syslog.LOG_AUTH, "tools")
+}
@@ -0,0 +1,12 @@
+//go:build windows || plan9
+
+package common
+
+import (
+	"errors"
+	"io"
+)
+
+func auditSyslogWriter(addr string) (io.WriteCloser, error) {
+	return nil, errors.New("syslog isn't supported, audit log should be file")
+}
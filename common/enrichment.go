@@ -0,0 +1,135 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/devopsext/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// EnrichmentKey is the object key under which step results are injected
+const EnrichmentKey = "enrichment"
+
+type EnrichmentOptions struct {
+	File string
+}
+
+// EnrichmentStep is configured in enrichment file, params are templates rendered against the object
+type EnrichmentStep struct {
+	Name   string            `yaml:"name"`
+	Type   string            `yaml:"type"`
+	Params map[string]string `yaml:"params"`
+}
+
+type fileEnrichment struct {
+	Steps []*EnrichmentStep `yaml:"steps"`
+}
+
+// Enricher gets additional data for a step by its rendered params
+type Enricher func(params map[string]string) (interface{}, error)
+
+type Enrichment struct {
+	options   EnrichmentOptions
+	steps     []*EnrichmentStep
+	enrichers map[string]Enricher
+	logger    Logger
+}
+
+func (e *Enrichment) Register(typ string, enricher Enricher) {
+	e.enrichers[typ] = enricher
+}
+
+func (e *Enrichment) renderParams(step *EnrichmentStep, object map[string]interface{}) (map[string]string, error) {
+
+	r := make(map[string]string)
+	for k, v := range step.Params {
+
+		t, err := template.New(fmt.Sprintf("%s.%s", step.Name, k)).Option("missingkey=zero").Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		var b bytes.Buffer
+		err = t.Execute(&b, object)
+		if err != nil {
+			return nil, err
+		}
+		r[k] = b.String()
+	}
+	return r, nil
+}
+
+// Enrich runs steps in order and injects results into object, failed steps are skipped
+// so that a notification is still sent without enrichment
+func (e *Enrichment) Enrich(object map[string]interface{}) map[string]interface{} {
+
+	if object == nil {
+		object = make(map[string]interface{})
+	}
+
+	results := make(map[string]interface{})
+	object[EnrichmentKey] = results
+
+	for _, step := range e.steps {
+
+		enricher, ok := e.enrichers[step.Type]
+		if !ok {
+			e.logger.Warn("Enrichment step %s has unknown type %s", step.Name, step.Type)
+			continue
+		}
+
+		params, err := e.renderParams(step, object)
+		if err != nil {
+			e.logger.Warn("Enrichment step %s params error: %s", step.Name, err)
+			continue
+		}
+
+		e.logger.Debug("Enrichment step %s (%s)...", step.Name, step.Type)
+		result, err := enricher(params)
+		if err != nil {
+			e.logger.Warn("Enrichment step %s error: %s", step.Name, err)
+			continue
+		}
+		results[step.Name] = result
+	}
+	return object
+}
+
+func NewEnrichment(options EnrichmentOptions, logger Logger) (*Enrichment, error) {
+
+	if utils.IsEmpty(options.File) {
+		return nil, errors.New("no enrichment file")
+	}
+
+	b, err := os.ReadFile(options.File)
+	if err != nil {
+		return nil, err
+	}
+
+	var fe fileEnrichment
+	err = yaml.Unmarshal(b, &fe)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []*EnrichmentStep{}
+	for i, s := range fe.Steps {
+		if s == nil {
+			continue
+		}
+		if utils.IsEmpty(s.Name) {
+			s.Name = fmt.Sprintf("%s%d", s.Type, i)
+		}
+		steps = append(steps, s)
+	}
+
+	return &Enrichment{
+		options:   options,
+		steps:     steps,
+		enrichers: make(map[string]Enricher),
+		logger:    logger,
+	}, nil
+}
@@ -0,0 +1,120 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/devopsext/utils"
+)
+
+const apiErrorBodyLimit = 512
+
+// APIError is returned by vendors on non-2xx responses, error starts with status,
+// so that telemetry and exit codes are categorized by it
+type APIError struct {
+	Status    int    `json:"status"`
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Body      string `json:"body,omitempty"`
+	Retryable bool   `json:"retryable"`
+}
+
+func (e *APIError) Error() string {
+
+	s := fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status))
+	if !utils.IsEmpty(e.Code) {
+		s = fmt.Sprintf("%s: %s", s, e.Code)
+	}
+	switch {
+	case !utils.IsEmpty(e.Message):
+		s = fmt.Sprintf("%s: %s", s, e.Message)
+	case !utils.IsEmpty(e.Body):
+		s = fmt.Sprintf("%s: %s", s, e.Body)
+	}
+	return s
+}
+
+// NewAPIError returns error of status with code and message of body if they are found in common fields,
+// body is kept as snippet
+func NewAPIError(status int, body []byte) *APIError {
+
+	e := &APIError{
+		Status:    status,
+		Body:      apiErrorSnippet(body),
+		Retryable: status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || (status >= 500 && status != http.StatusNotImplemented),
+	}
+
+	var m map[string]interface{}
+	if json.Unmarshal(body, &m) != nil {
+		return e
+	}
+	e.Code = apiErrorField(m, "code", "error_code", "errorCode", "error")
+	e.Message = apiErrorField(m, "message", "error_description", "errorMessage", "detail", "description", "title", "error", "errorMessages", "errors")
+	if e.Code == e.Message {
+		e.Code = ""
+	}
+	return e
+}
+
+// APIErrorMessage sets message of vendor, which it parsed itself, to API error, other errors are wrapped with it
+func APIErrorMessage(err error, message string) error {
+
+	if utils.IsEmpty(message) {
+		return err
+	}
+	var e *APIError
+	if errors.As(err, &e) {
+		e.Message = message
+		return err
+	}
+	return fmt.Errorf("%s: %s", err, message)
+}
+
+// apiErrorField returns first string of keys, nested errors like {"error":{"message":...}} and lists are handled
+func apiErrorField(m map[string]interface{}, keys ...string) string {
+
+	for _, key := range keys {
+		if s := apiErrorString(m[key], keys); !utils.IsEmpty(s) {
+			return s
+		}
+	}
+	return ""
+}
+
+func apiErrorString(v interface{}, keys []string) string {
+
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return fmt.Sprintf("%v", v)
+	case map[string]interface{}:
+		return apiErrorField(v, keys...)
+	case []interface{}:
+		messages := []string{}
+		for _, item := range v {
+			if s := apiErrorString(item, keys); !utils.IsEmpty(s) {
+				messages = append(messages, s)
+			}
+		}
+		return strings.Join(messages, "; ")
+	}
+	return ""
+}
+
+func apiErrorSnippet(body []byte) string {
+
+	s := strings.TrimSpace(string(body))
+	if len(s) <= apiErrorBodyLimit {
+		return s
+	}
+	s = s[:apiErrorBodyLimit]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s + "..."
+}
@@ -0,0 +1,75 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewAPIError(t *testing.T) {
+
+	tests := []struct {
+		status    int
+		body      string
+		err       string
+		retryable bool
+	}{
+		{404, `{"errorMessages":["Issue does not exist"]}`, "404 Not Found: Issue does not exist", false},
+		{401, `{"error":{"code":"unauthorized","message":"bad token"}}`, "401 Unauthorized: unauthorized: bad token", false},
+		{400, `{"error":"invalid_grant","error_description":"expired"}`, "400 Bad Request: invalid_grant: expired", false},
+		{503, `<html>down</html>`, "503 Service Unavailable: <html>down</html>", true},
+		{429, ``, "429 Too Many Requests", true},
+		{501, ``, "501 Not Implemented", false},
+	}
+	for _, test := range tests {
+		e := NewAPIError(test.status, []byte(test.body))
+		if e.Error() != test.err || e.Retryable != test.retryable {
+			t.Errorf("expected %q retryable %t, got %q retryable %t", test.err, test.retryable, e.Error(), e.Retryable)
+		}
+	}
+
+	e := NewAPIError(500, []byte(strings.Repeat("x", 1000)))
+	if len(e.Body) != apiErrorBodyLimit+3 {
+		t.Errorf("expected body snippet, got %d bytes", len(e.Body))
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+
+	err := APIErrorMessage(fmt.Errorf("Jenkins job: %w", NewAPIError(403, nil)), "no permission")
+	var e *APIError
+	if !errors.As(err, &e) || e.Message != "no permission" || TelemetryErrorCategory(err) != TelemetryErrorAuth {
+		t.Fatalf("expected auth API error with message, got %v", err)
+	}
+	if err := APIErrorMessage(errors.New("not successful"), "message"); err.Error() != "not successful: message" {
+		t.Fatalf("expected wrapped error, got %v", err)
+	}
+}
+
+func TestHttpAPIError(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"no such channel"}`))
+	}))
+	defer srv.Close()
+
+	b, err := HttpGetRaw(srv.Client(), srv.URL, "", "")
+	var e *APIError
+	if !errors.As(err, &e) || e.Status != http.StatusNotFound || e.Message != "no such channel" || len(b) == 0 {
+		t.Fatalf("expected 404 API error with body, got %v", err)
+	}
+
+	// body of failed request is returned as is in raw mode
+	prev := GetHttpClientOptions()
+	SetHttpClientOptions(HttpClientOptions{Raw: true})
+	defer SetHttpClientOptions(prev)
+
+	b, err = HttpGetRaw(srv.Client(), srv.URL, "", "")
+	if err != nil || string(b) != `{"message":"no such channel"}` {
+		t.Fatalf("expected body without error in raw mode, got %s, %v", b, err)
+	}
+}
@@ -0,0 +1,254 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/devopsext/utils"
+	starjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+)
+
+// Starlark hook functions which could be defined in hooks file:
+//
+//	def pre_send(vendor, message): returns None to keep message, dict to replace its fields, False to skip sending
+//	def post_response(vendor, response): returns None to keep response or a new value
+const (
+	hooksPreSendFunc      = "pre_send"
+	hooksPostResponseFunc = "post_response"
+)
+
+type HooksOptions struct {
+	File string
+}
+
+type Hooks struct {
+	options HooksOptions
+	thread  *starlark.Thread
+	globals starlark.StringDict
+	logger  Logger
+}
+
+func hooksToStarlark(v interface{}) (starlark.Value, error) {
+
+	switch t := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(t), nil
+	case string:
+		return starlark.String(t), nil
+	case float64:
+		if t == math.Trunc(t) && math.Abs(t) < 1<<53 {
+			return starlark.MakeInt64(int64(t)), nil
+		}
+		return starlark.Float(t), nil
+	case []interface{}:
+		items := []starlark.Value{}
+		for _, i := range t {
+			sv, err := hooksToStarlark(i)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, sv)
+		}
+		return starlark.NewList(items), nil
+	case map[string]interface{}:
+		keys := []string{}
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		d := starlark.NewDict(len(t))
+		for _, k := range keys {
+			sv, err := hooksToStarlark(t[k])
+			if err != nil {
+				return nil, err
+			}
+			err = d.SetKey(starlark.String(k), sv)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return d, nil
+	}
+	return nil, fmt.Errorf("unsupported hook value type %T", v)
+}
+
+func hooksFromStarlark(v starlark.Value) (interface{}, error) {
+
+	switch t := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(t), nil
+	case starlark.String:
+		return string(t), nil
+	case starlark.Int:
+		i, ok := t.Int64()
+		if !ok {
+			return nil, errors.New("hook int is too big")
+		}
+		return i, nil
+	case starlark.Float:
+		return float64(t), nil
+	case *starlark.List:
+		r := []interface{}{}
+		for i := 0; i < t.Len(); i++ {
+			item, err := hooksFromStarlark(t.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			r = append(r, item)
+		}
+		return r, nil
+	case starlark.Tuple:
+		r := []interface{}{}
+		for _, i := range t {
+			item, err := hooksFromStarlark(i)
+			if err != nil {
+				return nil, err
+			}
+			r = append(r, item)
+		}
+		return r, nil
+	case *starlark.Dict:
+		r := make(map[string]interface{})
+		for _, item := range t.Items() {
+			k, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("hook dict key %s is not a string", item[0])
+			}
+			val, err := hooksFromStarlark(item[1])
+			if err != nil {
+				return nil, err
+			}
+			r[string(k)] = val
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("unsupported hook value type %s", v.Type())
+}
+
+func (h *Hooks) call(name string, args ...interface{}) (starlark.Value, bool, error) {
+
+	fn, ok := h.globals[name]
+	if !ok {
+		return nil, false, nil
+	}
+	callable, ok := fn.(starlark.Callable)
+	if !ok {
+		return nil, false, fmt.Errorf("hook %s is not a function", name)
+	}
+
+	sargs := starlark.Tuple{}
+	for _, a := range args {
+		sv, err := hooksToStarlark(a)
+		if err != nil {
+			return nil, false, err
+		}
+		sargs = append(sargs, sv)
+	}
+
+	r, err := starlark.Call(h.thread, callable, sargs, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	return r, true, nil
+}
+
+// PreSend passes message to pre_send hook and updates message from its result, false means message should not be sent
+func (h *Hooks) PreSend(vendor string, message interface{}) (bool, error) {
+
+	m, err := InterfaceToMap("", message)
+	if err != nil {
+		return false, err
+	}
+
+	r, ok, err := h.call(hooksPreSendFunc, vendor, m)
+	if err != nil || !ok {
+		return true, err
+	}
+
+	switch t := r.(type) {
+	case starlark.NoneType:
+		return true, nil
+	case starlark.Bool:
+		return bool(t), nil
+	case *starlark.Dict:
+		fields, err := hooksFromStarlark(t)
+		if err != nil {
+			return false, err
+		}
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return false, err
+		}
+		return true, json.Unmarshal(data, message)
+	}
+	return false, fmt.Errorf("hook %s returned unsupported %s", hooksPreSendFunc, r.Type())
+}
+
+// PostResponse passes json response to post_response hook and returns its result as json
+func (h *Hooks) PostResponse(vendor string, response []byte) ([]byte, error) {
+
+	if _, ok := h.globals[hooksPostResponseFunc]; !ok {
+		return response, nil
+	}
+
+	var v interface{}
+	err := json.Unmarshal(response, &v)
+	if err != nil {
+		v = string(response)
+	}
+
+	r, _, err := h.call(hooksPostResponseFunc, vendor, v)
+	if err != nil {
+		return nil, err
+	}
+	if r == starlark.None {
+		return response, nil
+	}
+
+	nv, err := hooksFromStarlark(r)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := nv.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(nv)
+}
+
+func NewHooks(options HooksOptions, logger Logger) (*Hooks, error) {
+
+	if utils.IsEmpty(options.File) {
+		return nil, errors.New("no hooks file")
+	}
+
+	thread := &starlark.Thread{
+		Name: "hooks",
+		Print: func(_ *starlark.Thread, msg string) {
+			logger.Info(msg)
+		},
+	}
+
+	predeclared := starlark.StringDict{
+		"json": starjson.Module,
+	}
+
+	globals, err := starlark.ExecFile(thread, options.File, nil, predeclared)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Hooks{
+		options: options,
+		thread:  thread,
+		globals: globals,
+		logger:  logger,
+	}, nil
+}
@@ -0,0 +1,184 @@
+package common
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/devopsext/utils"
+)
+
+const (
+	httpMaxIdleConns        = 200
+	httpMaxIdleConnsPerHost = 50
+	httpIdleConnTimeout     = 90 * time.Second
+)
+
+var httpClients = struct {
+	mutex   sync.Mutex
+	clients map[string]*http.Client
+}{clients: make(map[string]*http.Client)}
+
+// NewHttpClient returns client shared by vendors with the same timeout and insecure options,
+// so that keep-alive connections are reused across vendor instances and calls,
+// requests are retried and limited by HttpClientOptions, timeout covers retries as well,
+// client of vendor has own transport of the same connections, so that its requests are counted in metrics of vendor
+func NewHttpClient(timeout int, insecure bool) *http.Client {
+
+	client := httpSharedClient(timeout, insecure)
+	vendor := metricsVendor(1)
+	if utils.IsEmpty(vendor) {
+		return client
+	}
+	t := *client.Transport.(*httpTransport)
+	t.vendor = vendor
+	c := *client
+	c.Transport = &t
+	return &c
+}
+
+func httpSharedClient(timeout int, insecure bool) *http.Client {
+
+	key := fmt.Sprintf("%d/%t", timeout, insecure)
+
+	httpClients.mutex.Lock()
+	defer httpClients.mutex.Unlock()
+
+	if client, ok := httpClients.clients[key]; ok {
+		return client
+	}
+
+	// client of invalid TLS options fails its requests, options are validated by commands before anyway
+	options := GetHttpClientOptions()
+	tlsConfig, err := HttpTLSConfig(options, insecure)
+	if err != nil {
+		tlsConfig = &tls.Config{InsecureSkipVerify: insecure}
+	}
+	d := time.Duration(timeout) * time.Second
+	connect := d
+	if options.ConnectTimeout > 0 {
+		connect = time.Duration(options.ConnectTimeout) * time.Second
+	}
+	transport := &http.Transport{
+		Proxy:               HttpProxy,
+		DialContext:         (&net.Dialer{Timeout: connect, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout: connect,
+		TLSClientConfig:     tlsConfig,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        httpMaxIdleConns,
+		MaxIdleConnsPerHost: httpMaxIdleConnsPerHost,
+		IdleConnTimeout:     httpIdleConnTimeout,
+	}
+	client := &http.Client{
+		Timeout:   d,
+		Transport: &httpTransport{transport: transport, err: err},
+	}
+	httpClients.clients[key] = client
+	return client
+}
+
+// NewHttpClientTransport returns client of own transport of vendor, e.g. of TLS of kubeconfig, its requests are
+// of HttpClientOptions as requests of shared clients, so that they are retried, traced, audited and not sent by dry run
+func NewHttpClientTransport(timeout int, transport http.RoundTripper) *http.Client {
+
+	return &http.Client{
+		Timeout:   time.Duration(timeout) * time.Second,
+		Transport: &httpTransport{transport: transport, vendor: metricsVendor(1)},
+	}
+}
+
+// HttpStatusError validates status of response, it's APIError of non-2xx status unless raw mode is set,
+// so that body of failed request is returned as is
+func HttpStatusError(code int, body []byte) error {
+
+	if code >= 200 && code < 300 {
+		return nil
+	}
+	if GetHttpClientOptions().Raw {
+		return nil
+	}
+	return NewAPIError(code, body)
+}
+
+// HttpRequestRawWithHeadersOutCode is utils' one which validates status of response,
+// the rest of Http functions are the same as utils' ones, so that vendors use them instead
+func HttpRequestRawWithHeadersOutCode(client *http.Client, method, URL string, headers map[string]string, raw []byte) ([]byte, int, error) {
+
+	b, code, err := utils.HttpRequestRawWithHeadersOutCode(client, method, URL, headers, raw)
+	if err != nil && code != 0 && (code < 200 || code >= 300) {
+		return b, code, HttpStatusError(code, b)
+	}
+	return b, code, err
+}
+
+// HttpRequestRawWithRetry is utils' one which validates status of response
+func HttpRequestRawWithRetry(client *http.Client, method, URL string, headers map[string]string, raw []byte, maxRetries int, retryHeader string) ([]byte, int, error) {
+
+	b, code, err := utils.HttpRequestRawWithRetry(client, method, URL, headers, raw, maxRetries, retryHeader)
+	if err != nil && code != 0 && (code < 200 || code >= 300) {
+		return b, code, HttpStatusError(code, b)
+	}
+	return b, code, err
+}
+
+func httpContentTypeAndAuthorizationHeaders(contentType string, authorization string) map[string]string {
+
+	headers := make(map[string]string)
+	if !utils.IsEmpty(contentType) {
+		headers["Content-Type"] = contentType
+	}
+	if !utils.IsEmpty(authorization) {
+		headers["Authorization"] = authorization
+	}
+	return headers
+}
+
+func HttpRequestRawWithHeaders(client *http.Client, method, URL string, headers map[string]string, raw []byte) ([]byte, error) {
+	b, _, err := HttpRequestRawWithHeadersOutCode(client, method, URL, headers, raw)
+	return b, err
+}
+
+func HttpPostRawWithHeaders(client *http.Client, URL string, headers map[string]string, raw []byte) ([]byte, error) {
+	return HttpRequestRawWithHeaders(client, "POST", URL, headers, raw)
+}
+
+func HttpPostRaw(client *http.Client, URL, contentType string, authorization string, raw []byte) ([]byte, error) {
+	return HttpPostRawWithHeaders(client, URL, httpContentTypeAndAuthorizationHeaders(contentType, authorization), raw)
+}
+
+func HttpPostRawRetry(client *http.Client, URL, contentType string, authorization string, raw []byte, maxRetries int, retryHeader string) ([]byte, error) {
+	b, _, err := HttpRequestRawWithRetry(client, "POST", URL, httpContentTypeAndAuthorizationHeaders(contentType, authorization), raw, maxRetries, retryHeader)
+	return b, err
+}
+
+func HttpPostRawOutCode(client *http.Client, URL, contentType string, authorization string, raw []byte) ([]byte, int, error) {
+	return HttpRequestRawWithHeadersOutCode(client, "POST", URL, httpContentTypeAndAuthorizationHeaders(contentType, authorization), raw)
+}
+
+func HttpPutRaw(client *http.Client, URL, contentType string, authorization string, raw []byte) ([]byte, error) {
+	return HttpRequestRawWithHeaders(client, "PUT", URL, httpContentTypeAndAuthorizationHeaders(contentType, authorization), raw)
+}
+
+func HttpDeleteRawWithHeaders(client *http.Client, URL string, headers map[string]string, raw []byte) ([]byte, error) {
+	return HttpRequestRawWithHeaders(client, "DELETE", URL, headers, raw)
+}
+
+func HttpDeleteRaw(client *http.Client, URL, contentType string, authorization string, raw []byte) ([]byte, error) {
+	return HttpDeleteRawWithHeaders(client, URL, httpContentTypeAndAuthorizationHeaders(contentType, authorization), raw)
+}
+
+func HttpGetRawWithHeaders(client *http.Client, URL string, headers map[string]string) ([]byte, error) {
+	return HttpRequestRawWithHeaders(client, "GET", URL, headers, nil)
+}
+
+func HttpGetRaw(client *http.Client, URL, contentType string, authorization string) ([]byte, error) {
+	return HttpGetRawWithHeaders(client, URL, httpContentTypeAndAuthorizationHeaders(contentType, authorization))
+}
+
+func HttpGetRawRetry(client *http.Client, URL, contentType string, authorization string, maxRetries int, retryHeader string) ([]byte, error) {
+	b, _, err := HttpRequestRawWithRetry(client, "GET", URL, httpContentTypeAndAuthorizationHeaders(contentType, authorization), nil, maxRetries, retryHeader)
+	return b, err
+}
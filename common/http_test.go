@@ -0,0 +1,48 @@
+package common
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devopsext/utils"
+)
+
+func benchmarkHttpClient(b *testing.B, client func() *http.Client, done func(*http.Client)) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c := client()
+			resp, err := c.Get(srv.URL)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			done(c)
+		}
+	})
+}
+
+func BenchmarkHttpClientPooled(b *testing.B) {
+	benchmarkHttpClient(b, func() *http.Client {
+		return NewHttpClient(30, false)
+	}, func(*http.Client) {})
+}
+
+// client per call as vendors used to do, idle connections are closed so that sockets aren't exhausted
+func BenchmarkHttpClientNew(b *testing.B) {
+	benchmarkHttpClient(b, func() *http.Client {
+		return utils.NewHttpClient(30, false)
+	}, func(c *http.Client) {
+		c.CloseIdleConnections()
+	})
+}
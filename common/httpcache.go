@@ -0,0 +1,181 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devopsext/utils"
+)
+
+// httpCacheMaxBody limits responses being cached, larger ones are returned as is
+const httpCacheMaxBody = 8 << 20
+
+// headers of request which aren't in cache key, as they don't change response or differ by request, e.g. of tracing,
+// other headers are in key, so that responses of different credentials of any vendor aren't shared
+var httpCacheIgnoredHeaders = map[string]bool{
+	"User-Agent":       true,
+	"Accept-Encoding":  true,
+	"Cache-Control":    true,
+	"Content-Length":   true,
+	"Idempotency-Key":  true,
+	"Traceparent":      true,
+	"Tracestate":       true,
+	"Baggage":          true,
+	"X-Request-Id":     true,
+	"X-Correlation-Id": true,
+}
+
+// vendors and headers of secret stores, responses of them aren't written to disk
+var (
+	httpCacheSecretVendors = map[string]bool{"vault": true}
+	httpCacheSecretHeaders = []string{"X-Vault-Token"}
+)
+
+type httpCacheEntry struct {
+	Time   time.Time   `json:"time"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// HttpCacheDir returns directory of cache, it's user cache dir if it isn't set, e.g. ~/.cache/tools/http
+func HttpCacheDir(options HttpClientOptions) string {
+
+	if !utils.IsEmpty(options.CacheDir) {
+		return options.CacheDir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "tools", "http")
+}
+
+// httpCacheKey returns key of GET and HEAD of URL and headers, it's empty if request isn't cached,
+// e.g. of secret store
+func httpCacheKey(options HttpClientOptions, vendor string, req *http.Request) string {
+
+	if options.CacheTTL <= 0 || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return ""
+	}
+	if strings.Contains(req.Header.Get("Cache-Control"), "no-cache") || httpCacheSecretVendors[vendor] {
+		return ""
+	}
+	for _, k := range httpCacheSecretHeaders {
+		if req.Header.Get(k) != "" {
+			return ""
+		}
+	}
+	keys := []string{}
+	for k := range req.Header {
+		if !httpCacheIgnoredHeaders[http.CanonicalHeaderKey(k)] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	h.Write([]byte(req.Method + "\n" + req.URL.String() + "\n"))
+	for _, k := range keys {
+		h.Write([]byte(http.CanonicalHeaderKey(k) + ":" + strings.Join(req.Header.Values(k), ",") + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func httpCacheFile(options HttpClientOptions, key string) string {
+	return filepath.Join(HttpCacheDir(options), key[:2], key+".json")
+}
+
+// httpCacheGet returns response of cache, it's nil if there is no entry or it's expired
+func httpCacheGet(options HttpClientOptions, key string, req *http.Request) *http.Response {
+
+	b, err := os.ReadFile(httpCacheFile(options, key))
+	if err != nil {
+		return nil
+	}
+	var e httpCacheEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil
+	}
+	if time.Since(e.Time) > time.Duration(options.CacheTTL)*time.Second {
+		return nil
+	}
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("X-Cache", "HIT")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// httpCachePut stores 2xx response unless it's no-store or too large, body of response is replaced by read one,
+// cache is best effort, so that errors of writing it don't fail requests
+func httpCachePut(options HttpClientOptions, key string, resp *http.Response) (*http.Response, error) {
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || strings.Contains(resp.Header.Get("Cache-Control"), "no-store") {
+		return resp, nil
+	}
+	if resp.ContentLength > httpCacheMaxBody {
+		return resp, nil
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, httpCacheMaxBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(b) > httpCacheMaxBody {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(b), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+	resp.ContentLength = int64(len(b))
+
+	data, err := json.Marshal(&httpCacheEntry{Time: time.Now(), Status: resp.StatusCode, Header: resp.Header, Body: b})
+	if err != nil {
+		return resp, nil
+	}
+	file := httpCacheFile(options, key)
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return resp, nil
+	}
+	// entry is renamed, so that concurrent commands don't read partial ones
+	tmp, err := os.CreateTemp(filepath.Dir(file), key+".*")
+	if err != nil {
+		return resp, nil
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return resp, nil
+}
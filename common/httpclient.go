@@ -0,0 +1,436 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// HttpClientOptions are applied to requests of all vendors, retries are made for idempotent methods only,
+// POST and PATCH are retried if it's allowed or request has Idempotency-Key header,
+// responses of non-2xx are errors unless raw mode is set, requests except of safe methods aren't sent in dry run,
+// requests and responses are logged with secrets redacted in debug, proxy, CA bundle and client certificate
+// are of all vendors, connect timeout is of dial and TLS handshake, request timeout is of each attempt,
+// timeouts are in seconds and timeout of vendor is used if they are zero, responses of GET and HEAD are cached
+// in cache dir for cache TTL seconds if it's set
+type HttpClientOptions struct {
+	Retries         int
+	RetryDelay      int
+	RetryMaxDelay   int
+	RetryPost       bool
+	BreakerFailures int
+	BreakerTimeout  int
+	MaxRequestSize  int64
+	MaxResponseSize int64
+	Raw             bool
+	DryRun          bool
+	Debug           bool
+	Proxy           string
+	NoProxy         []string
+	CAFile          string
+	CertFile        string
+	KeyFile         string
+	ConnectTimeout  int
+	RequestTimeout  int
+	CacheTTL        int
+	CacheDir        string
+}
+
+type httpBreaker struct {
+	failures int
+	opened   time.Time
+	probing  bool
+}
+
+type httpTransport struct {
+	transport http.RoundTripper
+	ctx       context.Context
+	vendor    string
+	err       error
+	auditSkip bool
+}
+
+// context of process, e.g. cancelled by signal, requests of clients without own context are cancelled with it
+var httpContext = struct {
+	mutex sync.Mutex
+	ctx   context.Context
+}{}
+
+var httpClientOptions = struct {
+	mutex    sync.Mutex
+	options  HttpClientOptions
+	breakers map[string]*httpBreaker
+}{
+	options: HttpClientOptions{
+		Retries:         2,
+		RetryDelay:      500,
+		RetryMaxDelay:   10000,
+		BreakerFailures: 5,
+		BreakerTimeout:  30,
+	},
+	breakers: make(map[string]*httpBreaker),
+}
+
+var httpIdempotentMethods = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"OPTIONS": true,
+	"TRACE":   true,
+	"PUT":     true,
+	"DELETE":  true,
+}
+
+var httpSafeMethods = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"OPTIONS": true,
+	"TRACE":   true,
+}
+
+// SetHttpClientOptions is called before vendors are created, clients which are already created use new options as well
+// except of TLS, which is of clients created after it
+func SetHttpClientOptions(options HttpClientOptions) {
+
+	httpClientOptions.mutex.Lock()
+	httpClientOptions.options = options
+	httpClientOptions.breakers = make(map[string]*httpBreaker)
+	httpClientOptions.mutex.Unlock()
+
+	httpClients.mutex.Lock()
+	defer httpClients.mutex.Unlock()
+
+	httpClients.clients = make(map[string]*http.Client)
+}
+
+func GetHttpClientOptions() HttpClientOptions {
+
+	httpClientOptions.mutex.Lock()
+	defer httpClientOptions.mutex.Unlock()
+
+	return httpClientOptions.options
+}
+
+// SetHttpContext sets context of process, e.g. of signals
+func SetHttpContext(ctx context.Context) {
+
+	httpContext.mutex.Lock()
+	defer httpContext.mutex.Unlock()
+
+	httpContext.ctx = ctx
+}
+
+// HttpContext returns context of process, so that work of vendors other than requests, e.g. processes they run,
+// is cancelled on interrupt and at deadline as well
+func HttpContext() context.Context {
+
+	httpContext.mutex.Lock()
+	defer httpContext.mutex.Unlock()
+
+	if httpContext.ctx == nil {
+		return context.Background()
+	}
+	return httpContext.ctx
+}
+
+// HttpClientContext returns client of the same connections, which requests are cancelled with ctx,
+// so that vendors are cancelled by callers, e.g. slack.WithContext(ctx).SendMessage(...)
+func HttpClientContext(client *http.Client, ctx context.Context) *http.Client {
+
+	c := *client
+	t := &httpTransport{transport: client.Transport, ctx: ctx}
+	if ct, ok := client.Transport.(*httpTransport); ok {
+		t.transport = ct.transport
+		t.vendor = ct.vendor
+		t.err = ct.err
+		t.auditSkip = ct.auditSkip
+	}
+	if t.transport == nil {
+		t.transport = http.DefaultTransport
+	}
+	c.Transport = t
+	return &c
+}
+
+// HttpClientAuditSkip returns client of the same connections, which requests aren't audited,
+// e.g. of queries sent by POST or of telemetry and metrics of tools itself
+func HttpClientAuditSkip(client *http.Client) *http.Client {
+
+	c := *client
+	t := &httpTransport{transport: client.Transport}
+	if ct, ok := client.Transport.(*httpTransport); ok {
+		*t = *ct
+	}
+	if t.transport == nil {
+		t.transport = http.DefaultTransport
+	}
+	t.auditSkip = true
+	c.Transport = t
+	return &c
+}
+
+// httpRequestContext returns request which is cancelled with context as well, done is called once response is closed
+func httpRequestContext(req *http.Request, ctx context.Context) (*http.Request, func()) {
+
+	if ctx == nil {
+		return req, func() {}
+	}
+	rctx, cancel := context.WithCancelCause(req.Context())
+	stop := context.AfterFunc(ctx, func() {
+		cancel(context.Cause(ctx))
+	})
+	return req.WithContext(rctx), func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+type httpDoneBody struct {
+	io.ReadCloser
+	done func()
+}
+
+func (b *httpDoneBody) Close() error {
+
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
+
+// httpBreakerAllow fails fast while breaker of host is open, one request probes host after breaker timeout
+func httpBreakerAllow(options HttpClientOptions, host string) error {
+
+	if options.BreakerFailures <= 0 {
+		return nil
+	}
+	httpClientOptions.mutex.Lock()
+	defer httpClientOptions.mutex.Unlock()
+
+	b, ok := httpClientOptions.breakers[host]
+	if !ok || b.failures < options.BreakerFailures {
+		return nil
+	}
+	if b.probing || time.Since(b.opened) < time.Duration(options.BreakerTimeout)*time.Second {
+		return fmt.Errorf("circuit breaker of %s is open after %d failures", host, b.failures)
+	}
+	b.probing = true
+	return nil
+}
+
+func httpBreakerDone(options HttpClientOptions, host string, failed bool) {
+
+	if options.BreakerFailures <= 0 {
+		return
+	}
+	httpClientOptions.mutex.Lock()
+	defer httpClientOptions.mutex.Unlock()
+
+	b, ok := httpClientOptions.breakers[host]
+	if !ok {
+		b = &httpBreaker{}
+		httpClientOptions.breakers[host] = b
+	}
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= options.BreakerFailures {
+		b.opened = time.Now()
+	}
+}
+
+// httpRetryable returns true for network errors, 429 and 5xx except 501
+func httpRetryable(resp *http.Response, err error) bool {
+
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented
+}
+
+// httpRetryDelay is exponential backoff with jitter, Retry-After of response is used if it's set
+func httpRetryDelay(options HttpClientOptions, attempt int, resp *http.Response) time.Duration {
+
+	max := time.Duration(options.RetryMaxDelay) * time.Millisecond
+	if resp != nil {
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s >= 0 {
+			d := time.Duration(s) * time.Second
+			if max > 0 && d > max {
+				d = max
+			}
+			return d
+		}
+	}
+	d := time.Duration(options.RetryDelay) * time.Millisecond << attempt
+	if max > 0 && (d > max || d <= 0) {
+		d = max
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+type httpLimitedBody struct {
+	body  io.ReadCloser
+	limit int64
+	read  int64
+}
+
+func (l *httpLimitedBody) Read(p []byte) (int, error) {
+
+	n, err := l.body.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n, fmt.Errorf("response exceeds %d bytes", l.limit)
+	}
+	return n, err
+}
+
+func (l *httpLimitedBody) Close() error {
+	return l.body.Close()
+}
+
+func (t *httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	if t.err != nil {
+		return nil, t.err
+	}
+	options := GetHttpClientOptions()
+	if options.DryRun && !httpSafeMethods[req.Method] {
+		return httpDryRun(req)
+	}
+	if options.MaxRequestSize > 0 && req.ContentLength > options.MaxRequestSize {
+		return nil, fmt.Errorf("request of %d bytes exceeds %d bytes", req.ContentLength, options.MaxRequestSize)
+	}
+
+	ctx := t.ctx
+	if ctx == nil {
+		httpContext.mutex.Lock()
+		ctx = httpContext.ctx
+		httpContext.mutex.Unlock()
+	}
+	key := httpCacheKey(options, t.vendor, req)
+	if key != "" {
+		if resp := httpCacheGet(options, key, req); resp != nil {
+			if stdout := httpTraceStdout(options); stdout != nil {
+				stdout.Info("HTTP response %s of %s %s from cache", resp.Status, req.Method, httpRedactURL(req.URL))
+			}
+			metricsCacheHit(t.vendor, req)
+			return resp, nil
+		}
+	}
+
+	if t.auditSkip {
+		req = req.WithContext(AuditSkipContext(req.Context()))
+	}
+	req, done := httpRequestContext(req, ctx)
+	start := time.Now()
+	resp, err := t.roundTrip(req, options)
+	auditRequest(t.vendor, req, resp, err, start)
+	if err != nil {
+		done()
+		return nil, err
+	}
+	resp.Body = &httpDoneBody{ReadCloser: resp.Body, done: done}
+	if key != "" {
+		return httpCachePut(options, key, resp)
+	}
+	return resp, nil
+}
+
+// httpAttemptContext returns request of attempt, which is cancelled after request timeout,
+// done is called once response of attempt is closed
+func httpAttemptContext(req *http.Request, options HttpClientOptions) (*http.Request, func()) {
+
+	if options.RequestTimeout <= 0 {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), time.Duration(options.RequestTimeout)*time.Second)
+	return req.WithContext(ctx), cancel
+}
+
+func (t *httpTransport) roundTrip(req *http.Request, options HttpClientOptions) (*http.Response, error) {
+
+	retries := options.Retries
+	idempotent := httpIdempotentMethods[req.Method] || options.RetryPost || req.Header.Get("Idempotency-Key") != ""
+	if !idempotent || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		retries = 0
+	}
+
+	host := req.URL.Host
+	stdout := httpTraceStdout(options)
+	if stdout != nil {
+		httpTraceRequest(stdout, req)
+	}
+	var resp *http.Response
+	var err error
+	done := func() {}
+	for attempt := 0; ; attempt++ {
+
+		if err := httpBreakerAllow(options, host); err != nil {
+			return nil, err
+		}
+		r := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+		r, done = httpAttemptContext(r, options)
+
+		start := time.Now()
+		resp, err = t.transport.RoundTrip(r)
+		if stdout != nil {
+			httpTraceResponse(stdout, r, resp, err, attempt, start)
+		}
+		metricsRequest(t.vendor, r, resp, err, attempt, start)
+		retryable := httpRetryable(resp, err)
+		httpBreakerDone(options, host, retryable)
+		if !retryable || attempt >= retries {
+			break
+		}
+
+		delay := httpRetryDelay(options, attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		done()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+	if err != nil {
+		done()
+		if errors.Is(err, context.DeadlineExceeded) && req.Context().Err() == nil {
+			err = fmt.Errorf("request timeout of %d seconds: %w", options.RequestTimeout, err)
+		}
+		return nil, err
+	}
+	resp.Body = &httpDoneBody{ReadCloser: resp.Body, done: done}
+
+	if options.MaxResponseSize > 0 {
+		if resp.ContentLength > options.MaxResponseSize {
+			resp.Body.Close()
+			return nil, fmt.Errorf("response of %d bytes exceeds %d bytes", resp.ContentLength, options.MaxResponseSize)
+		}
+		resp.Body = &httpLimitedBody{body: resp.Body, limit: options.MaxResponseSize}
+	}
+	return resp, nil
+}
@@ -0,0 +1,55 @@
+package common
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// HttpDryRunRequest is response of request which isn't sent in dry run, so that command outputs request instead,
+// secrets of headers, query and body are redacted
+type HttpDryRunRequest struct {
+	DryRun  bool              `json:"dry_run"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+func httpDryRun(req *http.Request) (*http.Response, error) {
+
+	var b []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		b, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r := HttpDryRunRequest{
+		DryRun:  true,
+		Method:  req.Method,
+		URL:     httpRedactURL(req.URL),
+		Headers: httpRedactHeaders(req.Header),
+		Body:    httpRedactBody(req.Header.Get("Content-Type"), b),
+	}
+
+	data, err := JsonMarshal(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}, "X-Dry-Run": {"true"}},
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,204 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const httpRedacted = "***"
+
+// names of headers, query and body fields which are redacted, plain key isn't as it's of issues and projects
+var httpSecrets = []string{"authorization", "token", "secret", "password", "passwd", "cookie", "signature", "credential",
+	"apikey", "api_key", "api-key", "application-key", "access_key", "private_key", "routing_key", "integration_key"}
+
+// names which are secrets as they are, e.g. sig of Azure Logic Apps webhooks of Teams Workflows
+var httpSecretNames = map[string]bool{"sig": true}
+
+func httpSecret(name string) bool {
+
+	name = strings.ToLower(name)
+	if httpSecretNames[name] {
+		return true
+	}
+	for _, s := range httpSecrets {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// HttpSecretName returns true if name of option, header or field is of secret, e.g. slack-token
+func HttpSecretName(name string) bool {
+	return httpSecret(name)
+}
+
+// httpSecretPattern matches names of secrets, e.g. keys of JSON or form which isn't parsed as it's truncated
+var httpSecretPattern = func() string {
+
+	names := []string{}
+	for _, s := range httpSecrets {
+		names = append(names, regexp.QuoteMeta(s))
+	}
+	return strings.Join(names, "|")
+}()
+
+var (
+	httpSecretJsonText = regexp.MustCompile(`(?i)("[^"]*(?:` + httpSecretPattern + `)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+	httpSecretFormText = regexp.MustCompile(`(?i)((?:^|[?&\s])[^=&\s"]*(?:` + httpSecretPattern + `)[^=&\s"]*=)[^&\s"]*`)
+)
+
+// segments of paths which are followed by tokens of webhooks, e.g. /services/T0/B0/<token> of Slack
+// and /webhookb2/<id>/IncomingWebhook/<token> of Teams
+var httpSecretPathPrefixes = map[string]bool{"hooks": true, "webhook": true, "webhooks": true, "webhookb2": true, "services": true, "integrations": true}
+
+// httpSecretPathLength is length of segments of webhooks which are tokens, ids of them are short
+const httpSecretPathLength = 16
+
+var httpSecretBotPath = regexp.MustCompile(`^bot[0-9]+:[A-Za-z0-9_-]+$`)
+
+// httpRedactText redacts values of secret keys of text, e.g. "token":"..." or token=...
+func httpRedactText(s string) string {
+
+	s = httpSecretJsonText.ReplaceAllString(s, `${1}"`+httpRedacted+`"`)
+	return httpSecretFormText.ReplaceAllString(s, "${1}"+httpRedacted)
+}
+
+// httpRedactPath redacts tokens of paths, e.g. /bot<token>/sendMessage of Telegram and long segments of webhooks
+func httpRedactPath(path string) string {
+
+	segments := strings.Split(path, "/")
+	webhook := false
+	for i, segment := range segments {
+		switch {
+		case httpSecretBotPath.MatchString(segment):
+			segments[i] = "bot" + httpRedacted
+		case webhook && len(segment) >= httpSecretPathLength:
+			segments[i] = httpRedacted
+		case httpSecretPathPrefixes[strings.ToLower(segment)]:
+			webhook = true
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func httpRedactURL(u *url.URL) string {
+
+	r := *u
+	if path := httpRedactPath(r.Path); path != r.Path {
+		r.Path = path
+		r.RawPath = httpRedactPath(u.EscapedPath())
+	}
+	query := r.Query()
+	for k := range query {
+		if httpSecret(k) {
+			query.Set(k, httpRedacted)
+		}
+	}
+	if len(query) > 0 {
+		r.RawQuery = query.Encode()
+	}
+	return r.Redacted()
+}
+
+// httpRedactJson redacts values of secret keys of JSON objects
+func httpRedactJson(v interface{}) interface{} {
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			if _, ok := item.(string); ok && httpSecret(k) {
+				v[k] = httpRedacted
+				continue
+			}
+			v[k] = httpRedactJson(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = httpRedactJson(item)
+		}
+	}
+	return v
+}
+
+// httpRedactMultipart returns fields of multipart form, files are of their names and sizes
+func httpRedactMultipart(boundary string, b []byte) (map[string]interface{}, error) {
+
+	fields := make(map[string]interface{})
+	r := multipart.NewReader(bytes.NewReader(b), boundary)
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			return fields, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case part.FileName() != "":
+			fields[part.FormName()] = fmt.Sprintf("file %s of %d bytes", part.FileName(), len(data))
+		case httpSecret(part.FormName()):
+			fields[part.FormName()] = httpRedacted
+		default:
+			fields[part.FormName()] = string(data)
+		}
+	}
+}
+
+// httpRedactBody returns JSON and multipart bodies as objects, form body and the rest as string
+func httpRedactBody(contentType string, b []byte) interface{} {
+
+	if len(b) == 0 {
+		return nil
+	}
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "multipart/form-data":
+		if fields, err := httpRedactMultipart(params["boundary"], b); err == nil {
+			return fields
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(b))
+		if err != nil {
+			break
+		}
+		for k := range values {
+			if httpSecret(k) {
+				values.Set(k, httpRedacted)
+			}
+		}
+		return values.Encode()
+	case strings.Contains(mediaType, "json") || json.Valid(b):
+		var v interface{}
+		if json.Unmarshal(b, &v) == nil {
+			return httpRedactJson(v)
+		}
+	}
+	return string(b)
+}
+
+// httpRedactHeaders returns first values of headers, values of secret ones are redacted
+func httpRedactHeaders(header http.Header) map[string]string {
+
+	headers := make(map[string]string)
+	for k := range header {
+		v := header.Get(k)
+		if httpSecret(k) {
+			v = httpRedacted
+		}
+		headers[k] = v
+	}
+	return headers
+}
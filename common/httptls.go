@@ -0,0 +1,101 @@
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/devopsext/utils"
+)
+
+// httpNoProxy returns true if host is in no proxy list of hosts, domains and IPs, e.g. vault.internal or .corp,
+// domain matches its subdomains as well, * matches all
+func httpNoProxy(host string, noProxy []string) bool {
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	for _, item := range noProxy {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if h, _, err := net.SplitHostPort(item); err == nil {
+			item = h
+		}
+		if utils.IsEmpty(item) {
+			continue
+		}
+		if item == "*" || item == host {
+			return true
+		}
+		domain := strings.TrimPrefix(item, ".")
+		if strings.HasSuffix(host, "."+domain) || host == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// HttpProxy returns proxy of request, it's proxy of options which may be http, https or socks5 URL,
+// unless host is in no proxy, proxy of HTTP_PROXY, HTTPS_PROXY and NO_PROXY env vars is used if it isn't set
+func HttpProxy(req *http.Request) (*url.URL, error) {
+
+	options := GetHttpClientOptions()
+	if utils.IsEmpty(options.Proxy) {
+		return http.ProxyFromEnvironment(req)
+	}
+	if httpNoProxy(req.URL.Host, options.NoProxy) {
+		return nil, nil
+	}
+	return url.Parse(options.Proxy)
+}
+
+// HttpTLSConfig returns TLS config of CA bundle, which is added to system CAs, and client certificate of mTLS
+func HttpTLSConfig(options HttpClientOptions, insecure bool) (*tls.Config, error) {
+
+	config := &tls.Config{InsecureSkipVerify: insecure}
+	if !utils.IsEmpty(options.CAFile) {
+		b, err := os.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("CA file: %s", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("CA file %s has no PEM certificates", options.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if !utils.IsEmpty(options.CertFile) || !utils.IsEmpty(options.KeyFile) {
+		cert, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate: %s", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// HttpValidateOptions validates proxy and TLS files, so that commands fail before requests are made
+func HttpValidateOptions(options HttpClientOptions) error {
+
+	if !utils.IsEmpty(options.Proxy) {
+		u, err := url.Parse(options.Proxy)
+		if err != nil {
+			return fmt.Errorf("proxy: %s", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("proxy %s should be http, https or socks5 URL", options.Proxy)
+		}
+	}
+	_, err := HttpTLSConfig(options, false)
+	return err
+}
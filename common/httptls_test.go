@@ -0,0 +1,66 @@
+package common
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHttpProxy(t *testing.T) {
+
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer proxy.Close()
+
+	prev := GetHttpClientOptions()
+	SetHttpClientOptions(HttpClientOptions{Proxy: proxy.URL, NoProxy: []string{".internal"}})
+	defer SetHttpClientOptions(prev)
+
+	b, err := HttpGetRaw(NewHttpClient(5, false), "http://slack.example.com/api/auth.test", "", "")
+	if err != nil || string(b) != `{"ok":true}` || proxied != "http://slack.example.com/api/auth.test" {
+		t.Fatalf("expected request via proxy, got %s, %s, %v", proxied, b, err)
+	}
+	for host, expected := range map[string]bool{"vault.internal:8200": true, "internal": true, "vault.corp": false} {
+		if httpNoProxy(host, []string{".internal"}) != expected {
+			t.Fatalf("expected no proxy of %s to be %t", host, expected)
+		}
+	}
+}
+
+func TestHttpCAFile(t *testing.T) {
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := GetHttpClientOptions()
+	defer SetHttpClientOptions(prev)
+
+	SetHttpClientOptions(HttpClientOptions{})
+	if _, err := HttpGetRaw(NewHttpClient(5, false), srv.URL, "", ""); err == nil {
+		t.Fatal("expected certificate of unknown CA to fail")
+	}
+	SetHttpClientOptions(HttpClientOptions{CAFile: file})
+	if _, err := HttpGetRaw(NewHttpClient(5, false), srv.URL, "", ""); err != nil {
+		t.Fatalf("expected certificate of CA file to be valid, got %v", err)
+	}
+
+	if err := HttpValidateOptions(HttpClientOptions{CertFile: file}); err == nil {
+		t.Fatal("expected client certificate without key to fail")
+	}
+	if err := HttpValidateOptions(HttpClientOptions{Proxy: "ftp://proxy"}); err == nil {
+		t.Fatal("expected proxy of unknown scheme to fail")
+	}
+}
@@ -0,0 +1,106 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// httpTraceBodyLimit limits bodies of logs, bodies are read by httpTraceReadLimit, so that they're redacted before
+const (
+	httpTraceBodyLimit = 4096
+	httpTraceReadLimit = 64 << 10
+)
+
+// stdout of HTTP debug, it's set by command, so that requests of all vendors are traced
+var httpStdout = struct {
+	mutex  sync.Mutex
+	stdout *Stdout
+}{}
+
+func SetHttpStdout(stdout *Stdout) {
+
+	httpStdout.mutex.Lock()
+	defer httpStdout.mutex.Unlock()
+
+	httpStdout.stdout = stdout
+}
+
+func httpTraceStdout(options HttpClientOptions) *Stdout {
+
+	if !options.Debug {
+		return nil
+	}
+	httpStdout.mutex.Lock()
+	defer httpStdout.mutex.Unlock()
+
+	return httpStdout.stdout
+}
+
+// httpTraceBody returns redacted body limited by size, as JSON if it's an object, body is redacted before it's truncated,
+// text of body which isn't parsed, e.g. of read limit, is redacted by patterns of secrets
+func httpTraceBody(contentType string, b []byte) string {
+
+	s := ""
+	switch v := httpRedactBody(contentType, b).(type) {
+	case nil:
+	case string:
+		s = httpRedactText(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			data = b
+		}
+		s = httpRedactText(string(data))
+	}
+	if len(s) > httpTraceBodyLimit {
+		return s[:httpTraceBodyLimit] + "..."
+	}
+	return s
+}
+
+func httpTraceHeaders(header http.Header) string {
+
+	data, err := json.Marshal(httpRedactHeaders(header))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// httpTraceRequest logs request, body is logged if it can be read again, e.g. it's not a stream of file
+func httpTraceRequest(stdout *Stdout, req *http.Request) {
+
+	var b []byte
+	if req.GetBody != nil && req.ContentLength <= httpTraceReadLimit {
+		if body, err := req.GetBody(); err == nil {
+			b, _ = io.ReadAll(io.LimitReader(body, httpTraceReadLimit))
+			body.Close()
+		}
+	}
+	stdout.Info("HTTP request %s %s headers %s body %s", req.Method, httpRedactURL(req.URL), httpTraceHeaders(req.Header),
+		httpTraceBody(req.Header.Get("Content-Type"), b))
+}
+
+// httpTraceResponse logs response of attempt, head of body is read and put back to body
+func httpTraceResponse(stdout *Stdout, req *http.Request, resp *http.Response, err error, attempt int, start time.Time) {
+
+	duration := time.Since(start).Milliseconds()
+	stdout = stdout.With(map[string]interface{}{"method": req.Method, "host": req.URL.Host, "duration_ms": duration, "attempt": attempt + 1})
+	if err != nil {
+		stdout.Info("HTTP request %s %s failed in %dms, attempt %d: %s", req.Method, httpRedactURL(req.URL), duration, attempt+1, err)
+		return
+	}
+
+	head, _ := io.ReadAll(io.LimitReader(resp.Body, httpTraceReadLimit))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+
+	stdout.Info("HTTP response %s of %s %s in %dms, attempt %d, headers %s body %s", resp.Status, req.Method, httpRedactURL(req.URL),
+		duration, attempt+1, httpTraceHeaders(resp.Header), httpTraceBody(resp.Header.Get("Content-Type"), head))
+}
@@ -0,0 +1,317 @@
+package common
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/utils"
+)
+
+// idempotencyRedisPrefix is prefix of keys of Redis store, so that it can be shared with other data
+const idempotencyRedisPrefix = "tools:idempotency:"
+
+type IdempotencyOptions struct {
+	Key     string
+	Store   string
+	TTL     int
+	Timeout int
+}
+
+// IdempotencyEntry is operation which is done, response of it is output again if operation is repeated,
+// hash is of options of operation, so that key of other operation isn't skipped, pending operation is reserved
+type IdempotencyEntry struct {
+	Time     time.Time       `json:"time"`
+	Key      string          `json:"key"`
+	Hash     string          `json:"hash"`
+	Pending  bool            `json:"pending,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// IdempotencyStore records operations which are done, entries expire by TTL, Reserve puts entry
+// if there is no entry of key, so that concurrent operations of the same key aren't done twice
+type IdempotencyStore interface {
+	Get(key string) (*IdempotencyEntry, error)
+	Put(entry *IdempotencyEntry, ttl time.Duration) error
+	Reserve(entry *IdempotencyEntry, ttl time.Duration) (bool, error)
+	Delete(key string) error
+}
+
+type idempotencyFileStore struct {
+	dir string
+}
+
+type idempotencyRedisStore struct {
+	url     *url.URL
+	timeout time.Duration
+}
+
+// IdempotencyDir returns directory of file store, it's user cache dir if it isn't set, e.g. ~/.cache/tools/idempotency
+func IdempotencyDir(options IdempotencyOptions) string {
+
+	if !utils.IsEmpty(options.Store) {
+		return options.Store
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "tools", "idempotency")
+}
+
+// NewIdempotencyStore returns Redis store of redis:// URL, e.g. redis://:password@redis:6379/1, or file store of dir
+func NewIdempotencyStore(options IdempotencyOptions) (IdempotencyStore, error) {
+
+	if strings.HasPrefix(options.Store, "redis://") {
+		u, err := url.Parse(options.Store)
+		if err != nil {
+			return nil, err
+		}
+		if utils.IsEmpty(u.Host) {
+			return nil, fmt.Errorf("invalid idempotency store %s, it has no host", options.Store)
+		}
+		timeout := time.Duration(options.Timeout) * time.Second
+		if timeout <= 0 {
+			timeout = 5 * time.Second
+		}
+		return &idempotencyRedisStore{url: u, timeout: timeout}, nil
+	}
+	return &idempotencyFileStore{dir: IdempotencyDir(options)}, nil
+}
+
+func idempotencyHash(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// IdempotencyOptionsHash returns hash of options of operation, e.g. channel and text of message
+func IdempotencyOptionsHash(options interface{}) (string, error) {
+
+	b, err := json.Marshal(options)
+	if err != nil {
+		return "", err
+	}
+	return idempotencyHash(string(b)), nil
+}
+
+func (s *idempotencyFileStore) file(key string) string {
+	return filepath.Join(s.dir, idempotencyHash(key)+".json")
+}
+
+func (s *idempotencyFileStore) Get(key string) (*IdempotencyEntry, error) {
+
+	b, err := os.ReadFile(s.file(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var e struct {
+		IdempotencyEntry
+		Expires time.Time `json:"expires"`
+	}
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, err
+	}
+	if e.Key != key || time.Now().After(e.Expires) {
+		return nil, nil
+	}
+	return &e.IdempotencyEntry, nil
+}
+
+// write writes entry to temp file which is renamed or linked if file doesn't exist,
+// so that concurrent commands don't read partial ones
+func (s *idempotencyFileStore) write(entry *IdempotencyEntry, ttl time.Duration, exclusive bool) error {
+
+	data, err := json.Marshal(struct {
+		*IdempotencyEntry
+		Expires time.Time `json:"expires"`
+	}{entry, entry.Time.Add(ttl)})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	file := s.file(entry.Key)
+	tmp, err := os.CreateTemp(s.dir, filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if exclusive {
+		return os.Link(tmp.Name(), file)
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+func (s *idempotencyFileStore) Put(entry *IdempotencyEntry, ttl time.Duration) error {
+	return s.write(entry, ttl, false)
+}
+
+// Reserve links file of entry, which fails if it exists, expired file is removed before
+func (s *idempotencyFileStore) Reserve(entry *IdempotencyEntry, ttl time.Duration) (bool, error) {
+
+	if e, err := s.Get(entry.Key); err == nil && e == nil {
+		if err := s.Delete(entry.Key); err != nil {
+			return false, err
+		}
+	}
+	err := s.write(entry, ttl, true)
+	if errors.Is(err, os.ErrExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *idempotencyFileStore) Delete(key string) error {
+
+	err := os.Remove(s.file(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// do sends command of RESP protocol by new connection, auth and db are of URL, reply is bulk string or nil
+func (s *idempotencyRedisStore) do(args ...string) ([]byte, error) {
+
+	conn, err := net.DialTimeout("tcp", s.url.Host, s.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.timeout))
+	r := bufio.NewReader(conn)
+
+	commands := [][]string{}
+	if password, ok := s.url.User.Password(); ok {
+		if user := s.url.User.Username(); !utils.IsEmpty(user) {
+			commands = append(commands, []string{"AUTH", user, password})
+		} else {
+			commands = append(commands, []string{"AUTH", password})
+		}
+	}
+	if db := strings.Trim(s.url.Path, "/"); !utils.IsEmpty(db) {
+		commands = append(commands, []string{"SELECT", db})
+	}
+	commands = append(commands, args)
+
+	var reply []byte
+	for _, c := range commands {
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%d\r\n", len(c))
+		for _, arg := range c {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+		if _, err := conn.Write([]byte(b.String())); err != nil {
+			return nil, err
+		}
+		reply, err = idempotencyRedisReply(r)
+		if err != nil {
+			return nil, fmt.Errorf("redis %s: %s", c[0], err)
+		}
+	}
+	return reply, nil
+}
+
+func idempotencyRedisReply(r *bufio.Reader) ([]byte, error) {
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if len(line) == 0 {
+		return nil, errors.New("empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+	return nil, fmt.Errorf("unsupported reply %s", line)
+}
+
+func (s *idempotencyRedisStore) Get(key string) (*IdempotencyEntry, error) {
+
+	b, err := s.do("GET", idempotencyRedisPrefix+idempotencyHash(key))
+	if err != nil || b == nil {
+		return nil, err
+	}
+	var e IdempotencyEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, err
+	}
+	if e.Key != key {
+		return nil, nil
+	}
+	return &e, nil
+}
+
+func (s *idempotencyRedisStore) Put(entry *IdempotencyEntry, ttl time.Duration) error {
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	seconds := int(ttl / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	_, err = s.do("SET", idempotencyRedisPrefix+idempotencyHash(entry.Key), string(data), "EX", strconv.Itoa(seconds))
+	return err
+}
+
+// Reserve sets entry by NX, reply is nil if key exists
+func (s *idempotencyRedisStore) Reserve(entry *IdempotencyEntry, ttl time.Duration) (bool, error) {
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return false, err
+	}
+	seconds := int(ttl / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	b, err := s.do("SET", idempotencyRedisPrefix+idempotencyHash(entry.Key), string(data), "NX", "EX", strconv.Itoa(seconds))
+	return b != nil, err
+}
+
+func (s *idempotencyRedisStore) Delete(key string) error {
+	_, err := s.do("DEL", idempotencyRedisPrefix+idempotencyHash(key))
+	return err
+}
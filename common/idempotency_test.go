@@ -0,0 +1,133 @@
+package common
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testIdempotencyStore(t *testing.T, store IdempotencyStore) {
+
+	if e, err := store.Get("slack send-message:1"); err != nil || e != nil {
+		t.Fatalf("expected no entry, got %v %v", e, err)
+	}
+	entry := &IdempotencyEntry{Time: time.Now(), Key: "slack send-message:1", Response: json.RawMessage(`{"ok":true}`)}
+	if err := store.Put(entry, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	e, err := store.Get("slack send-message:1")
+	if err != nil || e == nil {
+		t.Fatalf("expected entry, got %v %v", e, err)
+	}
+	if string(e.Response) != `{"ok":true}` {
+		t.Errorf("expected response, got %s", e.Response)
+	}
+	if e, _ := store.Get("slack send-message:2"); e != nil {
+		t.Errorf("expected no entry of other key, got %v", e)
+	}
+
+	// key is reserved once, till it's deleted
+	pending := &IdempotencyEntry{Time: time.Now(), Key: "slack send-message:3", Hash: "h", Pending: true}
+	for i, expected := range []bool{true, false} {
+		if ok, err := store.Reserve(pending, time.Minute); err != nil || ok != expected {
+			t.Fatalf("expected reserve %d to be %t, got %t %v", i, expected, ok, err)
+		}
+	}
+	if e, err := store.Get(pending.Key); err != nil || e == nil || !e.Pending || e.Hash != "h" {
+		t.Fatalf("expected pending entry, got %v %v", e, err)
+	}
+	if err := store.Delete(pending.Key); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := store.Reserve(pending, time.Minute); err != nil || !ok {
+		t.Fatalf("expected reserve of deleted key, got %t %v", ok, err)
+	}
+}
+
+func TestIdempotencyFileStore(t *testing.T) {
+
+	store, err := NewIdempotencyStore(IdempotencyOptions{Store: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testIdempotencyStore(t, store)
+
+	entry := &IdempotencyEntry{Time: time.Now().Add(-time.Hour), Key: "expired"}
+	if err := store.Put(entry, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := store.Get("expired"); e != nil {
+		t.Errorf("expected expired entry to be skipped, got %v", e)
+	}
+	if ok, err := store.Reserve(&IdempotencyEntry{Time: time.Now(), Key: "expired"}, time.Minute); err != nil || !ok {
+		t.Errorf("expected reserve of expired key, got %t %v", ok, err)
+	}
+}
+
+// TestIdempotencyRedisStore is of fake Redis server having GET, SET with NX and DEL of RESP protocol
+func TestIdempotencyRedisStore(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	values := make(chan map[string]string, 1)
+	values <- make(map[string]string)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					break
+				}
+				n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+				args := []string{}
+				for i := 0; i < n; i++ {
+					r.ReadString('\n')
+					arg, _ := r.ReadString('\n')
+					args = append(args, strings.TrimRight(arg, "\r\n"))
+				}
+				m := <-values
+				switch args[0] {
+				case "SET":
+					if _, ok := m[args[1]]; ok && len(args) > 3 && args[3] == "NX" {
+						conn.Write([]byte("$-1\r\n"))
+						break
+					}
+					m[args[1]] = args[2]
+					conn.Write([]byte("+OK\r\n"))
+				case "DEL":
+					delete(m, args[1])
+					conn.Write([]byte(":1\r\n"))
+				case "GET":
+					if v, ok := m[args[1]]; ok {
+						conn.Write([]byte("$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"))
+					} else {
+						conn.Write([]byte("$-1\r\n"))
+					}
+				default:
+					conn.Write([]byte("+OK\r\n"))
+				}
+				values <- m
+			}
+			conn.Close()
+		}
+	}()
+
+	store, err := NewIdempotencyStore(IdempotencyOptions{Store: "redis://:secret@" + l.Addr().String() + "/1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testIdempotencyStore(t, store)
+}
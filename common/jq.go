@@ -0,0 +1,73 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/itchyny/gojq"
+)
+
+// JqEval returns values of jq query, vars are jq variables, e.g. $slackChannel of options
+func JqEval(v interface{}, query string, vars map[string]interface{}) ([]interface{}, error) {
+
+	q, err := gojq.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for k := range vars {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	values := []interface{}{}
+	variables := []string{}
+	for _, k := range names {
+		variables = append(variables, "$"+k)
+		values = append(values, vars[k])
+	}
+
+	code, err := gojq.Compile(q, gojq.WithVariables(variables))
+	if err != nil {
+		return nil, err
+	}
+
+	r := []interface{}{}
+	iter := code.Run(v, values...)
+	for {
+		item, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := item.(error); ok {
+			var halt *gojq.HaltError
+			if errors.As(err, &halt) && halt.Value() == nil {
+				break
+			}
+			return nil, err
+		}
+		r = append(r, item)
+	}
+	return r, nil
+}
+
+// JqString returns values one per line as jq -r does, strings are raw and the rest are JSON,
+// so that single scalar can be captured by shell
+func JqString(values []interface{}) (string, error) {
+
+	lines := []string{}
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			lines = append(lines, s)
+			continue
+		}
+		b, err := JsonMarshal(v)
+		if err != nil {
+			return "", fmt.Errorf("jq value: %s", err)
+		}
+		lines = append(lines, strings.TrimSpace(string(b)))
+	}
+	return strings.Join(lines, "\n"), nil
+}
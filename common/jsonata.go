@@ -2,6 +2,7 @@ package common
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
 
@@ -77,7 +78,8 @@ func (j *Jsonata) fRegexMatchObjectByField(obj map[string]interface{}, field, va
 	return keys[0]
 }*/
 
-func (j *Jsonata) Eval(data interface{}, query string) (interface{}, error) {
+// Eval returns error of malformed query, evaluation panics are returned as errors as well
+func (j *Jsonata) Eval(data interface{}, query string) (v interface{}, err error) {
 
 	exts := make(map[string]jsonata.Extension)
 	exts["env"] = jsonata.Extension{
@@ -97,12 +99,16 @@ func (j *Jsonata) Eval(data interface{}, query string) (interface{}, error) {
 	}*/
 	jsonata.RegisterExts(exts)
 
-	expr := jsonata.MustCompile(query)
-	v, err := expr.Eval(data)
+	expr, err := jsonata.Compile(query)
 	if err != nil {
 		return nil, err
 	}
-	return v, nil
+	defer func() {
+		if r := recover(); r != nil {
+			v, err = nil, fmt.Errorf("query %s: %v", query, r)
+		}
+	}()
+	return expr.Eval(data)
 }
 
 func NewJsonata(options JsonataOptions) *Jsonata {
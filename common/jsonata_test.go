@@ -0,0 +1,28 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// output queries are user input, so that malformed ones must return errors instead of panics
+func FuzzJsonataEval(f *testing.F) {
+
+	f.Add(`$[group="ci"].name`, `[{"name":"gitlab","group":"ci"}]`)
+	f.Add(`result.items[0].id`, `{"result":{"items":[{"id":1}]}}`)
+	f.Add(`$count(items)`, `{"items":[1,2,3]}`)
+	f.Add(`$sum(items.price) > 10 ? "high" : "low"`, `{"items":[{"price":5},{"price":7}]}`)
+	f.Add(`[?group==`+"`ci`"+`].name`, `[]`)
+	f.Add(`$string(`, `{}`)
+	f.Add(`**`, `{"a":{"b":{"c":1}}}`)
+
+	jnata := NewJsonata(JsonataOptions{})
+	f.Fuzz(func(t *testing.T, query, data string) {
+
+		var v interface{}
+		if json.Unmarshal([]byte(data), &v) != nil {
+			return
+		}
+		jnata.Eval(v, query)
+	})
+}
@@ -0,0 +1,134 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/devopsext/utils"
+)
+
+// messageIncludeDepth limits includes of includes, so that file including itself fails
+const messageIncludeDepth = 10
+
+// IsMessageTemplate returns true if message has template actions, other messages are sent as is
+func IsMessageTemplate(message string) bool {
+	return strings.Contains(message, "{{")
+}
+
+// MessageTemplate renders message of vars, e.g. "Deployed {{ .Vars.version }}", functions are of sprig and helpers
+// of messages: timeFormat, truncate and pretty of JSON, env vars of .Env, sprig env and include of file are of env only,
+// so that messages don't read secrets of env or files unless it's allowed
+func MessageTemplate(name, message string, vars map[string]string, env bool) (string, error) {
+
+	if vars == nil {
+		vars = make(map[string]string)
+	}
+	data := map[string]interface{}{"Vars": vars}
+	if env {
+		m := make(map[string]string)
+		for _, kv := range os.Environ() {
+			k, v, _ := strings.Cut(kv, "=")
+			m[k] = v
+		}
+		data["Env"] = m
+	}
+	return messageRender(name, message, data, env, 0)
+}
+
+func messageRender(name, message string, data interface{}, env bool, depth int) (string, error) {
+
+	funcs := sprig.TxtFuncMap()
+	if env {
+		funcs["include"] = func(file string) (string, error) {
+			if depth >= messageIncludeDepth {
+				return "", fmt.Errorf("include %s exceeds %d includes", file, messageIncludeDepth)
+			}
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return "", err
+			}
+			return messageRender(file, string(b), data, env, depth+1)
+		}
+	} else {
+		delete(funcs, "env")
+		delete(funcs, "expandenv")
+	}
+	funcs["timeFormat"] = messageTimeFormat
+	funcs["truncate"] = messageTruncate
+	funcs["pretty"] = messagePretty
+
+	t, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(message)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// messageTimeFormat formats time of layout, time is RFC3339 string, unix seconds or now if it's empty
+func messageTimeFormat(layout string, v ...interface{}) (string, error) {
+
+	t := time.Now()
+	if len(v) > 0 {
+		switch v := v[0].(type) {
+		case time.Time:
+			t = v
+		case int:
+			t = time.Unix(int64(v), 0)
+		case int64:
+			t = time.Unix(v, 0)
+		case float64:
+			t = time.Unix(int64(v), 0)
+		case string:
+			if !utils.IsEmpty(v) {
+				p, err := time.Parse(time.RFC3339Nano, v)
+				if err != nil {
+					return "", err
+				}
+				t = p
+			}
+		default:
+			return "", fmt.Errorf("time of %T isn't supported", v)
+		}
+	}
+	return t.Format(layout), nil
+}
+
+// messageTruncate cuts string to length of runes with ellipsis, e.g. for limits of titles
+func messageTruncate(length int, s string) string {
+
+	r := []rune(s)
+	if length <= 0 || len(r) <= length {
+		return s
+	}
+	if length <= 3 {
+		return string(r[:length])
+	}
+	return string(r[:length-3]) + "..."
+}
+
+// messagePretty returns indented JSON of value, strings are JSON to be indented
+func messagePretty(v interface{}) (string, error) {
+
+	if s, ok := v.(string); ok {
+		var b bytes.Buffer
+		if err := json.Indent(&b, []byte(s), "", "  "); err != nil {
+			return s, nil
+		}
+		return b.String(), nil
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,46 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMessageTemplate(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "footer.tmpl")
+	if err := os.WriteFile(file, []byte("by {{ .Env.TEST_MESSAGE_USER }}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_MESSAGE_USER", "ci")
+
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{"Deployed {{ .Vars.version }} {{ include \"" + file + "\" }}", "Deployed 1.2.3 by ci"},
+		{"{{ .Vars.missing }}", ""},
+		{"{{ truncate 8 .Vars.title }}", "Rollo..."},
+		{"{{ timeFormat \"2006-01-02\" \"2024-01-02T03:04:05Z\" }}", "2024-01-02"},
+		{"{{ pretty .Vars.json }}", "{\n  \"a\": 1\n}"},
+		{"{{ .Vars.version | upper }}", "1.2.3"},
+	}
+	vars := map[string]string{"version": "1.2.3", "title": "Rollout of checkout", "json": `{"a":1}`}
+	for _, test := range tests {
+		s, err := MessageTemplate("test", test.message, vars, true)
+		if err != nil || s != test.expected {
+			t.Fatalf("expected %q of %s, got %q, %v", test.expected, test.message, s, err)
+		}
+	}
+
+	if _, err := MessageTemplate("test", "{{ include \"missing.tmpl\" }}", vars, true); err == nil {
+		t.Fatal("expected error of missing include")
+	}
+
+	// env vars and files aren't of messages without env
+	for _, message := range []string{"{{ .Env.TEST_MESSAGE_USER }}", "{{ env \"TEST_MESSAGE_USER\" }}", "{{ include \"" + file + "\" }}"} {
+		if s, err := MessageTemplate("test", message, vars, false); err == nil && s != "" {
+			t.Fatalf("expected no env of %s, got %q", message, s)
+		}
+	}
+}
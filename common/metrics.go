@@ -0,0 +1,264 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devopsext/utils"
+)
+
+const (
+	MetricsHttpRequests  = "tools_http_requests_total"
+	MetricsHttpErrors    = "tools_http_request_errors_total"
+	MetricsHttpDuration  = "tools_http_request_duration_seconds"
+	MetricsHttpRetries   = "tools_http_request_retries_total"
+	MetricsHttpCacheHits = "tools_http_cache_hits_total"
+)
+
+// MetricsOptions of Pushgateway, metrics of command are pushed after it's done, job groups them
+type MetricsOptions struct {
+	PushURL  string
+	PushJob  string
+	Timeout  int
+	Insecure bool
+}
+
+type metricsHistogram struct {
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+type metricsFamily struct {
+	help       string
+	histogram  bool
+	counters   map[string]float64
+	histograms map[string]*metricsHistogram
+}
+
+var metricsBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+var metrics = struct {
+	mutex    sync.Mutex
+	families map[string]*metricsFamily
+}{
+	families: map[string]*metricsFamily{
+		MetricsHttpRequests:  {help: "Requests of vendors by method and status code, code is error of network errors"},
+		MetricsHttpErrors:    {help: "Failed requests of vendors by error category"},
+		MetricsHttpDuration:  {help: "Duration of requests of vendors in seconds", histogram: true},
+		MetricsHttpRetries:   {help: "Retries of requests of vendors"},
+		MetricsHttpCacheHits: {help: "Requests of vendors served from cache"},
+	},
+}
+
+// metricsLabels returns labels in Prometheus format sorted by name, e.g. {code="200",vendor="slack"}
+func metricsLabels(labels map[string]string) string {
+
+	if len(labels) == 0 {
+		return ""
+	}
+	names := []string{}
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	items := []string{}
+	for _, k := range names {
+		items = append(items, fmt.Sprintf("%s=%s", k, strconv.Quote(labels[k])))
+	}
+	return "{" + strings.Join(items, ",") + "}"
+}
+
+func metricsFamilyOf(name string) *metricsFamily {
+
+	f, ok := metrics.families[name]
+	if !ok {
+		f = &metricsFamily{}
+		metrics.families[name] = f
+	}
+	return f
+}
+
+// MetricsAdd adds value to counter of labels
+func MetricsAdd(name string, labels map[string]string, value float64) {
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	f := metricsFamilyOf(name)
+	if f.counters == nil {
+		f.counters = make(map[string]float64)
+	}
+	f.counters[metricsLabels(labels)] += value
+}
+
+// MetricsObserve adds value to histogram of labels
+func MetricsObserve(name string, labels map[string]string, value float64) {
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	f := metricsFamilyOf(name)
+	f.histogram = true
+	if f.histograms == nil {
+		f.histograms = make(map[string]*metricsHistogram)
+	}
+	key := metricsLabels(labels)
+	h, ok := f.histograms[key]
+	if !ok {
+		h = &metricsHistogram{buckets: make([]uint64, len(metricsBuckets))}
+		f.histograms[key] = h
+	}
+	for i, b := range metricsBuckets {
+		if value <= b {
+			h.buckets[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// metricsBucketLabels returns labels with le label of bucket
+func metricsBucketLabels(labels, le string) string {
+
+	if utils.IsEmpty(labels) {
+		return fmt.Sprintf("{le=%q}", le)
+	}
+	return fmt.Sprintf("%s,le=%q}", strings.TrimSuffix(labels, "}"), le)
+}
+
+// MetricsText returns metrics in Prometheus text format, families without values are skipped
+func MetricsText() []byte {
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	names := []string{}
+	for name := range metrics.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, name := range names {
+		f := metrics.families[name]
+		if len(f.counters) == 0 && len(f.histograms) == 0 {
+			continue
+		}
+		typ := "counter"
+		if f.histogram {
+			typ = "histogram"
+		}
+		if !utils.IsEmpty(f.help) {
+			fmt.Fprintf(&b, "# HELP %s %s\n", name, f.help)
+		}
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, typ)
+
+		keys := []string{}
+		for k := range f.counters {
+			keys = append(keys, k)
+		}
+		for k := range f.histograms {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			h, ok := f.histograms[k]
+			if !ok {
+				fmt.Fprintf(&b, "%s%s %s\n", name, k, strconv.FormatFloat(f.counters[k], 'g', -1, 64))
+				continue
+			}
+			for i, le := range metricsBuckets {
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", name, metricsBucketLabels(k, strconv.FormatFloat(le, 'g', -1, 64)), h.buckets[i])
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", name, metricsBucketLabels(k, "+Inf"), h.count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", name, k, strconv.FormatFloat(h.sum, 'g', -1, 64))
+			fmt.Fprintf(&b, "%s_count%s %d\n", name, k, h.count)
+		}
+	}
+	return b.Bytes()
+}
+
+// MetricsHandler serves metrics for Prometheus, e.g. at /metrics of listen address
+func MetricsHandler() http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(MetricsText())
+	})
+}
+
+// MetricsPush replaces metrics of job in Pushgateway, e.g. of one-shot commands which aren't scraped
+func MetricsPush(options MetricsOptions) error {
+
+	if utils.IsEmpty(options.PushURL) {
+		return nil
+	}
+	b := MetricsText()
+	if len(b) == 0 {
+		return nil
+	}
+	job := options.PushJob
+	if utils.IsEmpty(job) {
+		job = "tools"
+	}
+	u := fmt.Sprintf("%s/metrics/job/%s", strings.TrimRight(options.PushURL, "/"), url.PathEscape(job))
+
+	_, err := HttpPutRaw(HttpClientAuditSkip(NewHttpClient(options.Timeout, options.Insecure)), u, "text/plain; version=0.0.4", "", b)
+	return err
+}
+
+// metricsVendor returns vendor of client, which is file name of vendor creating it, e.g. vendors/slack.go
+func metricsVendor(skip int) string {
+
+	_, file, _, ok := runtime.Caller(skip + 1)
+	if !ok || filepath.Base(filepath.Dir(file)) != "vendors" {
+		return ""
+	}
+	return strings.TrimSuffix(filepath.Base(file), ".go")
+}
+
+// metricsRequest records attempt of request, vendor is host of request if client isn't created by vendor
+func metricsRequest(vendor string, req *http.Request, resp *http.Response, err error, attempt int, start time.Time) {
+
+	if utils.IsEmpty(vendor) {
+		vendor = req.URL.Host
+	}
+	labels := map[string]string{"vendor": vendor}
+	MetricsObserve(MetricsHttpDuration, labels, time.Since(start).Seconds())
+	if attempt > 0 {
+		MetricsAdd(MetricsHttpRetries, labels, 1)
+	}
+
+	code := "error"
+	var failure interface{} = err
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+		failure = nil
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			failure = NewAPIError(resp.StatusCode, nil)
+		}
+	}
+	MetricsAdd(MetricsHttpRequests, map[string]string{"vendor": vendor, "method": req.Method, "code": code}, 1)
+	if failure != nil {
+		MetricsAdd(MetricsHttpErrors, map[string]string{"vendor": vendor, "category": TelemetryErrorCategory(failure)}, 1)
+	}
+}
+
+// metricsCacheHit records request served from cache, it isn't counted in requests as it's not sent
+func metricsCacheHit(vendor string, req *http.Request) {
+
+	if utils.IsEmpty(vendor) {
+		vendor = req.URL.Host
+	}
+	MetricsAdd(MetricsHttpCacheHits, map[string]string{"vendor": vendor, "method": req.Method}, 1)
+}
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsText(t *testing.T) {
+
+	MetricsAdd("test_requests_total", map[string]string{"vendor": "slack", "code": "200"}, 2)
+	MetricsObserve("test_duration_seconds", map[string]string{"vendor": "slack"}, 0.3)
+
+	text := string(MetricsText())
+	for _, line := range []string{
+		`# TYPE test_requests_total counter`,
+		`test_requests_total{code="200",vendor="slack"} 2`,
+		`# TYPE test_duration_seconds histogram`,
+		`test_duration_seconds_bucket{vendor="slack",le="0.25"} 0`,
+		`test_duration_seconds_bucket{vendor="slack",le="0.5"} 1`,
+		`test_duration_seconds_bucket{vendor="slack",le="+Inf"} 1`,
+		`test_duration_seconds_count{vendor="slack"} 1`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Fatalf("expected %s, got %s", line, text)
+		}
+	}
+}
+
+func TestMetricsPush(t *testing.T) {
+
+	client, url, _ := testHttpClient(t, HttpClientOptions{Retries: 1},
+		func(w http.ResponseWriter, r *http.Request, calls int32) {
+			if calls == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		})
+	HttpGetRaw(client, url, "", "")
+
+	var path, body string
+	pushgateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		path, body = r.Method+" "+r.URL.Path, string(b)
+	}))
+	defer pushgateway.Close()
+
+	if err := MetricsPush(MetricsOptions{PushURL: pushgateway.URL, PushJob: "deploy", Timeout: 5}); err != nil {
+		t.Fatal(err)
+	}
+	host := strings.TrimPrefix(url, "http://")
+	for _, line := range []string{
+		`tools_http_requests_total{code="503",method="GET",vendor="` + host + `"} 1`,
+		`tools_http_requests_total{code="200",method="GET",vendor="` + host + `"} 1`,
+		`tools_http_request_errors_total{category="http_5xx",vendor="` + host + `"} 1`,
+		`tools_http_request_retries_total{vendor="` + host + `"} 1`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Fatalf("expected %s, got %s", line, body)
+		}
+	}
+	if path != "PUT /metrics/job/deploy" {
+		t.Fatalf("expected push of job, got %s", path)
+	}
+}
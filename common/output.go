@@ -0,0 +1,232 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/devopsext/utils"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	OutputFormatJson     = "json"
+	OutputFormatYaml     = "yaml"
+	OutputFormatTable    = "table"
+	OutputFormatTsv      = "tsv"
+	OutputFormatRaw      = "raw"
+	OutputFormatTemplate = "template"
+)
+
+const (
+	OutputQueryJsonata = "jsonata"
+	OutputQueryJq      = "jq"
+)
+
+var OutputFormats = []string{OutputFormatJson, OutputFormatYaml, OutputFormatTable, OutputFormatTsv, OutputFormatRaw, OutputFormatTemplate}
+
+// OutputFormatOptions are applied to output of all commands, columns are of table and tsv,
+// template is Go template of file or string over parsed response, query engine is of output queries,
+// plain output is written as is without stdout fields, e.g. to be captured by shell
+type OutputFormatOptions struct {
+	Format      string
+	Columns     []string
+	Template    string
+	QueryEngine string
+	Plain       bool
+}
+
+var outputFormatOptions = struct {
+	mutex   sync.Mutex
+	options OutputFormatOptions
+}{}
+
+func SetOutputFormatOptions(options OutputFormatOptions) {
+
+	outputFormatOptions.mutex.Lock()
+	defer outputFormatOptions.mutex.Unlock()
+
+	outputFormatOptions.options = options
+}
+
+func GetOutputFormatOptions() OutputFormatOptions {
+
+	outputFormatOptions.mutex.Lock()
+	defer outputFormatOptions.mutex.Unlock()
+
+	return outputFormatOptions.options
+}
+
+// OutputFormat formats JSON of response or result of query, content which isn't JSON is kept as is
+// except of template which gets it as string
+func OutputFormat(options OutputFormatOptions, b []byte) (string, error) {
+
+	format := strings.ToLower(options.Format)
+	switch format {
+	case "", OutputFormatJson, OutputFormatRaw:
+		return string(b), nil
+	case OutputFormatYaml, OutputFormatTable, OutputFormatTsv, OutputFormatTemplate:
+	default:
+		return "", fmt.Errorf("unknown output format %s", options.Format)
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		if format != OutputFormatTemplate {
+			return string(b), nil
+		}
+		v = string(b)
+	}
+	v = outputNormalize(v)
+
+	switch format {
+	case OutputFormatYaml:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return "", err
+		}
+		return strings.TrimRight(buf.String(), "\n"), nil
+	case OutputFormatTemplate:
+		return outputTemplate(options.Template, v)
+	}
+	return outputTable(v, options.Columns, format == OutputFormatTsv), nil
+}
+
+// outputNormalize turns whole numbers into integers, so that IDs and timestamps aren't of exponent format
+func outputNormalize(v interface{}) interface{} {
+
+	switch v := v.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return int64(v)
+		}
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = outputNormalize(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = outputNormalize(item)
+		}
+	}
+	return v
+}
+
+func outputTemplate(content string, v interface{}) (string, error) {
+
+	b, err := utils.Content(content)
+	if err != nil {
+		return "", err
+	}
+	if len(b) == 0 {
+		return "", fmt.Errorf("no output template")
+	}
+	t, err := template.New("output").Funcs(sprig.TxtFuncMap()).Parse(string(b))
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, v); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// outputTable returns rows of array or one row of object, columns are all keys if they aren't set,
+// nested keys are selected by path, e.g. user.name
+func outputTable(v interface{}, columns []string, tsv bool) string {
+
+	rows := []interface{}{v}
+	if items, ok := v.([]interface{}); ok {
+		rows = items
+	}
+
+	selected := []string{}
+	for _, c := range columns {
+		if c = strings.TrimSpace(c); !utils.IsEmpty(c) {
+			selected = append(selected, c)
+		}
+	}
+	if len(selected) == 0 {
+		keys := make(map[string]bool)
+		for _, row := range rows {
+			if m, ok := row.(map[string]interface{}); ok {
+				for k := range m {
+					keys[k] = true
+				}
+			}
+		}
+		for k := range keys {
+			selected = append(selected, k)
+		}
+		sort.Strings(selected)
+	}
+
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var tw *tabwriter.Writer
+	if !tsv {
+		tw = tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		w = tw
+	}
+
+	if len(selected) > 0 {
+		fmt.Fprintln(w, strings.Join(selected, "\t"))
+	}
+	for _, row := range rows {
+		cells := []string{}
+		if len(selected) == 0 {
+			cells = append(cells, outputCell(row))
+		}
+		for _, c := range selected {
+			cells = append(cells, outputCell(outputPath(row, c)))
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	if tw != nil {
+		tw.Flush()
+	}
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+func outputPath(v interface{}, path string) interface{} {
+
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+func outputCell(v interface{}) string {
+
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		s = v
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		s = string(b)
+	default:
+		s = fmt.Sprintf("%v", v)
+	}
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+}
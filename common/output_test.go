@@ -0,0 +1,71 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestOutputFormat(t *testing.T) {
+
+	body := []byte(`[{"key":"OPS-1","id":10001,"fields":{"status":{"name":"Open"}}},{"key":"OPS-2","id":10002}]`)
+
+	tests := []struct {
+		options OutputFormatOptions
+		output  string
+	}{
+		{OutputFormatOptions{Format: OutputFormatJson}, string(body)},
+		{OutputFormatOptions{Format: OutputFormatYaml}, "- fields:\n    status:\n      name: Open\n  id: 10001\n  key: OPS-1\n- id: 10002\n  key: OPS-2"},
+		{OutputFormatOptions{Format: OutputFormatTsv, Columns: []string{"key", "fields.status.name"}}, "key\tfields.status.name\nOPS-1\tOpen\nOPS-2\t"},
+		{OutputFormatOptions{Format: OutputFormatTable, Columns: []string{"key", "id"}}, "key    id\nOPS-1  10001\nOPS-2  10002"},
+		{OutputFormatOptions{Format: OutputFormatTemplate, Template: "{{range .}}{{.key}};{{end}}"}, "OPS-1;OPS-2;"},
+	}
+	for _, test := range tests {
+		output, err := OutputFormat(test.options, body)
+		if err != nil || output != test.output {
+			t.Errorf("%s: expected %q, got %q, %v", test.options.Format, test.output, output, err)
+		}
+	}
+
+	if output, _ := OutputFormat(OutputFormatOptions{Format: OutputFormatYaml}, []byte("not json")); output != "not json" {
+		t.Errorf("expected content as is, got %q", output)
+	}
+	if _, err := OutputFormat(OutputFormatOptions{Format: "xml"}, body); err == nil {
+		t.Error("expected unknown format error")
+	}
+}
+
+func TestJqEval(t *testing.T) {
+
+	v := map[string]interface{}{
+		"ok": true,
+		"ts": "1700000000.000100",
+		"messages": []interface{}{
+			map[string]interface{}{"user": "U1", "reactions": float64(2)},
+			map[string]interface{}{"user": "U2"},
+		},
+	}
+
+	tests := []struct {
+		query  string
+		output string
+	}{
+		{".ts", "1700000000.000100"},
+		{".messages[] | select(.reactions) | .user", "U1"},
+		{"[.messages[].user]", `["U1","U2"]`},
+		{".messages[0].reactions", "2"},
+		{"$SlackChannel", "C1"},
+	}
+	for _, test := range tests {
+		values, err := JqEval(v, test.query, map[string]interface{}{"SlackChannel": "C1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := JqString(values)
+		if err != nil || output != test.output {
+			t.Errorf("%s: expected %q, got %q, %v", test.query, test.output, output, err)
+		}
+	}
+
+	if _, err := JqEval(v, ".[", nil); err == nil {
+		t.Error("expected query error")
+	}
+}
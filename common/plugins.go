@@ -0,0 +1,101 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/devopsext/utils"
+)
+
+// PluginPrefix is the executable name prefix of plugin, tools-<name> becomes "tools <name>" command
+const PluginPrefix = "tools-"
+
+// VendorPluginPrefix is prefix of name of vendor plugin, tools-vendor-<name> is executable of exec protocol
+// and tools-vendor-<name>.so is Go plugin, vendor becomes "tools <name>" command and vendor of registry
+const VendorPluginPrefix = "vendor-"
+
+type PluginsOptions struct {
+	Dir string
+}
+
+type Plugin struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Vendor bool   `json:"vendor,omitempty"`
+}
+
+func pluginName(file string) (string, bool) {
+
+	name := strings.TrimPrefix(file, PluginPrefix)
+	ext := filepath.Ext(name)
+	if ext == ".exe" || ext == ".so" {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if strings.HasPrefix(name, VendorPluginPrefix) {
+		return strings.TrimPrefix(name, VendorPluginPrefix), true
+	}
+	return name, false
+}
+
+// FindPlugins looks for tools-<name> executables in plugins dir, the first found wins if dirs are separated by path list separator
+func FindPlugins(options PluginsOptions) ([]*Plugin, error) {
+
+	r := []*Plugin{}
+	if utils.IsEmpty(options.Dir) {
+		return r, nil
+	}
+
+	names := make(map[string]bool)
+	for _, dir := range filepath.SplitList(options.Dir) {
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, e := range entries {
+
+			if e.IsDir() || !strings.HasPrefix(e.Name(), PluginPrefix) {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			ext := filepath.Ext(e.Name())
+			if info.Mode()&0111 == 0 && ext != ".exe" && ext != ".so" {
+				continue
+			}
+
+			name, vendor := pluginName(e.Name())
+			if utils.IsEmpty(name) || names[name] || (ext == ".so" && !vendor) {
+				continue
+			}
+			names[name] = true
+			r = append(r, &Plugin{Name: name, Path: filepath.Join(dir, e.Name()), Vendor: vendor})
+		}
+	}
+
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Name < r[j].Name
+	})
+	return r, nil
+}
+
+// NewVendorPlugin returns vendor of vendor plugin, Go plugin is opened, executable is run by calls of vendor
+func NewVendorPlugin(plugin *Plugin, stdout *Stdout) (Vendor, error) {
+
+	if !plugin.Vendor {
+		return nil, fmt.Errorf("plugin %s isn't vendor", plugin.Name)
+	}
+	if filepath.Ext(plugin.Path) == ".so" {
+		return vendorGoPlugin(plugin.Name, plugin.Path)
+	}
+	return NewVendorExec(plugin.Name, plugin.Path, stdout), nil
+}
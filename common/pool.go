@@ -0,0 +1,187 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	PoolStatusOK      = "ok"
+	PoolStatusError   = "error"
+	PoolStatusSkipped = "skipped"
+	PoolStatusPartial = "partial"
+)
+
+// PoolOptions of bulk operations, concurrency is of items in flight, rate is of items started per second,
+// it's unlimited if 0, items aren't started after the first failure if stop on error is set
+type PoolOptions struct {
+	Concurrency int
+	Rate        float64
+	StopOnError bool
+}
+
+// PoolResult is result of item, response is JSON of response or JSON string of it
+type PoolResult struct {
+	Item     string          `json:"item"`
+	Status   string          `json:"status"`
+	Error    string          `json:"error,omitempty"`
+	Duration int64           `json:"duration"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// PoolReport has results of items in their order, status is ok, partial if some of items have failed or error
+type PoolReport struct {
+	Status  string        `json:"status"`
+	Total   int           `json:"total"`
+	Done    int           `json:"done"`
+	Failed  int           `json:"failed"`
+	Skipped int           `json:"skipped"`
+	Results []*PoolResult `json:"results"`
+}
+
+// PoolError is error of report having failed items, so that commands exit with code of partial failure
+type PoolError struct {
+	Total   int
+	Failed  int
+	Skipped int
+}
+
+func (e *PoolError) Error() string {
+	return fmt.Sprintf("%d of %d items failed, %d skipped", e.Failed, e.Total, e.Skipped)
+}
+
+// Partial returns true if some of items are done
+func (e *PoolError) Partial() bool {
+	return e.Failed+e.Skipped < e.Total
+}
+
+// Err returns PoolError if some of items have failed or been skipped, it's nil otherwise
+func (r *PoolReport) Err() error {
+
+	if r.Failed == 0 && r.Skipped == 0 {
+		return nil
+	}
+	return &PoolError{Total: r.Total, Failed: r.Failed, Skipped: r.Skipped}
+}
+
+// poolLimiter lets items start at rate, e.g. of API limits of vendor, it's a ticker shared by workers
+type poolLimiter struct {
+	ticker *time.Ticker
+}
+
+func newPoolLimiter(rate float64) *poolLimiter {
+
+	if rate <= 0 {
+		return nil
+	}
+	return &poolLimiter{ticker: time.NewTicker(time.Duration(float64(time.Second) / rate))}
+}
+
+func (l *poolLimiter) wait(ctx context.Context) error {
+
+	if l == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-l.ticker.C:
+		return nil
+	}
+}
+
+func (l *poolLimiter) stop() {
+	if l != nil {
+		l.ticker.Stop()
+	}
+}
+
+func poolResponse(b []byte) json.RawMessage {
+
+	if len(b) == 0 {
+		return nil
+	}
+	if json.Valid(b) {
+		return b
+	}
+	r, _ := json.Marshal(string(b))
+	return r
+}
+
+// PoolRun runs fn of items by workers, items which aren't started as context is done or after failure
+// of stop on error are skipped, items in flight are finished
+func PoolRun(ctx context.Context, options PoolOptions, items []string, fn func(ctx context.Context, item string) ([]byte, error)) *PoolReport {
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	start, stop := context.WithCancel(ctx)
+	defer stop()
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > len(items) {
+		concurrency = len(items)
+	}
+	limiter := newPoolLimiter(options.Rate)
+	defer limiter.stop()
+
+	report := &PoolReport{Total: len(items), Results: make([]*PoolResult, len(items))}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				r := &PoolResult{Item: items[i], Status: PoolStatusSkipped}
+				report.Results[i] = r
+				if limiter.wait(start) != nil {
+					continue
+				}
+				t := time.Now()
+				b, err := fn(ctx, items[i])
+				r.Duration = time.Since(t).Milliseconds()
+				r.Response = poolResponse(b)
+				r.Status = PoolStatusOK
+				if err != nil {
+					r.Status = PoolStatusError
+					r.Error = err.Error()
+					if options.StopOnError {
+						stop()
+					}
+				}
+			}
+		}()
+	}
+	for i := range items {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, r := range report.Results {
+		switch r.Status {
+		case PoolStatusOK:
+			report.Done++
+		case PoolStatusError:
+			report.Failed++
+		default:
+			report.Skipped++
+		}
+	}
+	switch {
+	case report.Failed == 0 && report.Skipped == 0:
+		report.Status = PoolStatusOK
+	case report.Done > 0:
+		report.Status = PoolStatusPartial
+	default:
+		report.Status = PoolStatusError
+	}
+	return report
+}
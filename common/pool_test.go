@@ -0,0 +1,72 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolRun(t *testing.T) {
+
+	var inFlight, max int32
+	items := []string{"a", "b", "c", "d", "e", "f"}
+	report := PoolRun(context.Background(), PoolOptions{Concurrency: 2}, items, func(ctx context.Context, item string) ([]byte, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if item == "c" {
+			return nil, errors.New("failed")
+		}
+		return []byte(item), nil
+	})
+
+	if max > 2 {
+		t.Fatalf("expected 2 items in flight at most, got %d", max)
+	}
+	if report.Status != PoolStatusPartial || report.Done != 5 || report.Failed != 1 {
+		t.Fatalf("expected partial of 5 done and 1 failed, got %s of %d and %d", report.Status, report.Done, report.Failed)
+	}
+	for i, r := range report.Results {
+		if r.Item != items[i] {
+			t.Fatalf("expected result %d of %s, got %s", i, items[i], r.Item)
+		}
+	}
+	if string(report.Results[0].Response) != `"a"` {
+		t.Fatalf("expected JSON string response, got %s", report.Results[0].Response)
+	}
+	var poolErr *PoolError
+	if !errors.As(report.Err(), &poolErr) || !poolErr.Partial() {
+		t.Fatalf("expected partial pool error, got %v", report.Err())
+	}
+}
+
+func TestPoolRunStopOnError(t *testing.T) {
+
+	report := PoolRun(context.Background(), PoolOptions{Concurrency: 1, StopOnError: true}, []string{"a", "b", "c"},
+		func(ctx context.Context, item string) ([]byte, error) {
+			return nil, errors.New("failed")
+		})
+	if report.Status != PoolStatusError || report.Failed != 1 || report.Skipped != 2 {
+		t.Fatalf("expected 1 failed and 2 skipped, got %d and %d", report.Failed, report.Skipped)
+	}
+}
+
+func TestPoolRunRate(t *testing.T) {
+
+	start := time.Now()
+	report := PoolRun(context.Background(), PoolOptions{Concurrency: 4, Rate: 50}, []string{"a", "b", "c", "d", "e"},
+		func(ctx context.Context, item string) ([]byte, error) {
+			return nil, nil
+		})
+	if report.Status != PoolStatusOK || time.Since(start) < 80*time.Millisecond {
+		t.Fatalf("expected 5 items at 50 per second, got %s in %s", report.Status, time.Since(start))
+	}
+}
@@ -0,0 +1,142 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/devopsext/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// SecretResolver returns secret of path and optional key of reference scheme://path#key,
+// so that tokens of options can be kept in secret stores instead of env vars and flags
+type SecretResolver func(path, key string) (string, error)
+
+var secretResolvers = map[string]SecretResolver{
+	"env":  secretEnv,
+	"file": secretFile,
+	"sops": secretSops,
+}
+
+// local schemes are resolved before registered ones, so that token of Vault can be reference as well
+var secretSchemes = []string{"env", "file", "sops"}
+var secretMutex sync.Mutex
+
+// RegisterSecretResolver adds scheme, e.g. vault:// by Vault vendor
+func RegisterSecretResolver(scheme string, resolver SecretResolver) {
+
+	secretMutex.Lock()
+	defer secretMutex.Unlock()
+
+	if _, ok := secretResolvers[scheme]; !ok {
+		secretSchemes = append(secretSchemes, scheme)
+	}
+	secretResolvers[scheme] = resolver
+}
+
+// SecretSchemes returns schemes in order of resolving
+func SecretSchemes() []string {
+
+	secretMutex.Lock()
+	defer secretMutex.Unlock()
+
+	return append([]string{}, secretSchemes...)
+}
+
+// SecretScheme returns scheme of reference, it's empty if value isn't reference of known scheme
+func SecretScheme(value string) string {
+
+	scheme, _, ok := strings.Cut(value, "://")
+	if !ok {
+		return ""
+	}
+	secretMutex.Lock()
+	defer secretMutex.Unlock()
+
+	if _, ok := secretResolvers[scheme]; !ok {
+		return ""
+	}
+	return scheme
+}
+
+// ResolveSecret returns secret of reference, value which isn't reference is returned as is
+func ResolveSecret(value string) (string, error) {
+
+	scheme := SecretScheme(value)
+	if utils.IsEmpty(scheme) {
+		return value, nil
+	}
+	secretMutex.Lock()
+	resolver := secretResolvers[scheme]
+	secretMutex.Unlock()
+
+	ref := strings.TrimPrefix(value, scheme+"://")
+	path, key, _ := strings.Cut(ref, "#")
+	if utils.IsEmpty(path) {
+		return "", fmt.Errorf("no path of %s secret", scheme)
+	}
+	secret, err := resolver(path, key)
+	if err != nil {
+		return "", fmt.Errorf("%s secret %s: %s", scheme, path, err)
+	}
+	return secret, nil
+}
+
+func secretEnv(path, key string) (string, error) {
+
+	value, ok := os.LookupEnv(path)
+	if !ok {
+		return "", errors.New("no env var")
+	}
+	return secretKey([]byte(value), key)
+}
+
+// secretFile reads file, e.g. of Docker or Kubernetes secret, with key of JSON or YAML file
+func secretFile(path, key string) (string, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return secretKey(b, key)
+}
+
+// secretSops decrypts file by sops binary, so that keys of KMS, age or PGP are configured as sops does
+func secretSops(path, key string) (string, error) {
+
+	args := []string{"--decrypt"}
+	if !utils.IsEmpty(key) {
+		args = append(args, "--extract", fmt.Sprintf("[%q]", key))
+	}
+	args = append(args, path)
+
+	var stderr strings.Builder
+	cmd := exec.Command("sops", args...)
+	cmd.Stderr = &stderr
+	b, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// secretKey returns content without trailing new line, or field of JSON or YAML content if key is set
+func secretKey(content []byte, key string) (string, error) {
+
+	if utils.IsEmpty(key) {
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(content, &m); err != nil {
+		return "", err
+	}
+	v, ok := m[key]
+	if !ok {
+		return "", fmt.Errorf("no key %s", key)
+	}
+	return fmt.Sprintf("%v", v), nil
+}
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+
+	dir := t.TempDir()
+	token := filepath.Join(dir, "token")
+	if err := os.WriteFile(token, []byte("xoxb-1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keys := filepath.Join(dir, "keys.yaml")
+	if err := os.WriteFile(keys, []byte("slack: xoxb-2\ntelegram: 123:abc\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SECRET_TEST_TOKEN", "xoxb-3")
+
+	tests := map[string]string{
+		"xoxb-plain":                   "xoxb-plain",
+		"https://slack.com":            "https://slack.com",
+		"file://" + token:              "xoxb-1",
+		"file://" + keys + "#telegram": "123:abc",
+		"env://SECRET_TEST_TOKEN":      "xoxb-3",
+	}
+	for value, expected := range tests {
+		secret, err := ResolveSecret(value)
+		if err != nil {
+			t.Fatalf("%s: %s", value, err)
+		}
+		if secret != expected {
+			t.Errorf("%s: expected %s, got %s", value, expected, secret)
+		}
+	}
+
+	for _, value := range []string{"env://SECRET_TEST_NONE", "file://" + keys + "#none", "file://"} {
+		if _, err := ResolveSecret(value); err == nil {
+			t.Errorf("%s: error expected", value)
+		}
+	}
+}
+
+func TestRegisterSecretResolver(t *testing.T) {
+
+	RegisterSecretResolver("test", func(path, key string) (string, error) {
+		return path + "/" + key, nil
+	})
+	secret, err := ResolveSecret("test://secret/slack#token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret != "secret/slack/token" {
+		t.Errorf("unexpected secret %s", secret)
+	}
+	schemes := SecretSchemes()
+	if schemes[len(schemes)-1] != "test" {
+		t.Errorf("registered scheme is expected to be resolved last: %v", schemes)
+	}
+}
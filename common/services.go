@@ -0,0 +1,104 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/devopsext/utils"
+	"gopkg.in/yaml.v3"
+)
+
+type Service struct {
+	Name       string            `json:"name" yaml:"name"`
+	Team       string            `json:"team,omitempty" yaml:"team"`
+	Channels   map[string]string `json:"channels,omitempty" yaml:"channels"`
+	Escalation string            `json:"escalation,omitempty" yaml:"escalation"`
+	Dashboards []string          `json:"dashboards,omitempty" yaml:"dashboards"`
+	Labels     map[string]string `json:"labels,omitempty" yaml:"labels"`
+}
+
+// ServiceCatalog maps service name to its owner, channels, escalation and dashboards
+type ServiceCatalog interface {
+	Get(name string) (*Service, error)
+	List() ([]*Service, error)
+}
+
+type ServicesOptions struct {
+	File    string
+	Service string
+}
+
+type fileServices struct {
+	Services map[string]*Service `yaml:"services"`
+}
+
+type FileServiceCatalog struct {
+	options  ServicesOptions
+	services map[string]*Service
+}
+
+func (fsc *FileServiceCatalog) Get(name string) (*Service, error) {
+
+	s, ok := fsc.services[name]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", name)
+	}
+	return s, nil
+}
+
+func (fsc *FileServiceCatalog) List() ([]*Service, error) {
+
+	r := []*Service{}
+	for _, s := range fsc.services {
+		r = append(r, s)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Name < r[j].Name
+	})
+	return r, nil
+}
+
+// Channel returns service channel for vendor, empty if there is no such
+func (s *Service) Channel(vendor string) string {
+
+	if s == nil || s.Channels == nil {
+		return ""
+	}
+	return s.Channels[vendor]
+}
+
+func NewFileServiceCatalog(options ServicesOptions) (*FileServiceCatalog, error) {
+
+	if utils.IsEmpty(options.File) {
+		return nil, errors.New("no services file")
+	}
+
+	b, err := os.ReadFile(options.File)
+	if err != nil {
+		return nil, err
+	}
+
+	var fs fileServices
+	err = yaml.Unmarshal(b, &fs)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[string]*Service)
+	for k, v := range fs.Services {
+		if v == nil {
+			v = &Service{}
+		}
+		if utils.IsEmpty(v.Name) {
+			v.Name = k
+		}
+		services[k] = v
+	}
+
+	return &FileServiceCatalog{
+		options:  options,
+		services: services,
+	}, nil
+}
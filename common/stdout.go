@@ -3,8 +3,12 @@ package common
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
 	"text/template"
+	"time"
 
 	"github.com/devopsext/utils"
 	"github.com/sirupsen/logrus"
@@ -16,12 +20,39 @@ type StdoutOptions struct {
 	Template        string
 	TimestampFormat string
 	TextColors      bool
+	Stderr          bool
+	// Levels are levels of modules over level, e.g. http=debug or slack=warn
+	Levels []string
+	// Fields are key=value fields of all logs, e.g. job=deploy
+	Fields []string
+	// Sampling is debug logs of the same caller per second, the rest is dropped and counted, unlimited if 0
+	Sampling int
+	// File is file which logs are appended to as well, e.g. of long-lived server
+	File string
 }
 
 type Stdout struct {
 	log          *logrus.Logger
 	options      StdoutOptions
 	callerOffset int
+	errorHandler func(obj interface{})
+	fields       logrus.Fields
+	level        logrus.Level
+	levels       map[string]logrus.Level
+	sampler      *stdoutSampler
+}
+
+// stdoutSampler counts debug logs of callers per second, logs over limit are dropped
+type stdoutSampler struct {
+	mutex   sync.Mutex
+	limit   int
+	callers map[string]*stdoutSample
+}
+
+type stdoutSample struct {
+	second  int64
+	count   int
+	dropped int
 }
 
 type templateFormatter struct {
@@ -65,10 +96,46 @@ func (f *templateFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 func (so *Stdout) addCallerFields(offset int) logrus.Fields {
 
 	function, file, line := utils.CallerGetInfo(so.callerOffset + offset)
-	return logrus.Fields{
-		"file": fmt.Sprintf("%s:%d", file, line),
-		"func": function,
+	fields := logrus.Fields{}
+	for k, v := range so.fields {
+		fields[k] = v
+	}
+	fields["file"] = fmt.Sprintf("%s:%d", file, line)
+	fields["func"] = function
+	return fields
+}
+
+// sample returns false if debug log of caller is over limit of second, count of logs dropped before is added to fields
+func (s *stdoutSampler) sample(fields logrus.Fields) bool {
+
+	if s == nil || s.limit <= 0 {
+		return true
+	}
+	caller := fmt.Sprintf("%v", fields["file"])
+	second := time.Now().Unix()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c, ok := s.callers[caller]
+	if !ok {
+		c = &stdoutSample{}
+		s.callers[caller] = c
+	}
+	if c.second != second {
+		c.second = second
+		c.count = 0
+	}
+	c.count++
+	if c.count > s.limit {
+		c.dropped++
+		return false
 	}
+	if c.dropped > 0 {
+		fields["sampled"] = c.dropped
+		c.dropped = 0
+	}
+	return true
 }
 
 func prepare(message string, args ...interface{}) string {
@@ -97,7 +164,7 @@ func (so *Stdout) exists(level logrus.Level, obj interface{}, args ...interface{
 		message = "not implemented"
 	}
 
-	flag := message != "" && so.log.IsLevelEnabled(level)
+	flag := message != "" && so.level >= level
 	if flag {
 		message = prepare(message, args...)
 	}
@@ -120,6 +187,14 @@ func (so *Stdout) Warn(obj interface{}, args ...interface{}) {
 
 func (so *Stdout) Error(obj interface{}, args ...interface{}) {
 
+	if obj != nil && so.errorHandler != nil {
+		// handler gets message of args
+		if s, ok := obj.(string); ok && len(args) > 0 {
+			so.errorHandler(prepare(s, args...))
+		} else {
+			so.errorHandler(obj)
+		}
+	}
 	if exists, message := so.exists(logrus.ErrorLevel, obj, args...); exists {
 		so.log.WithFields(so.addCallerFields(3)).Errorln(message)
 	}
@@ -128,7 +203,10 @@ func (so *Stdout) Error(obj interface{}, args ...interface{}) {
 func (so *Stdout) Debug(obj interface{}, args ...interface{}) {
 
 	if exists, message := so.exists(logrus.DebugLevel, obj, args...); exists {
-		so.log.WithFields(so.addCallerFields(3)).Debugln(message)
+		fields := so.addCallerFields(3)
+		if so.sampler.sample(fields) {
+			so.log.WithFields(fields).Debugln(message)
+		}
 	}
 }
 
@@ -168,29 +246,115 @@ func newLog(options StdoutOptions) *logrus.Logger {
 		log.SetFormatter(formatter)
 	}
 
-	switch options.Level {
-	case "info":
-		log.SetLevel(logrus.InfoLevel)
+	// logger has the most verbose level of modules, levels of modules are checked by stdout
+	level := stdoutLevel(options.Level)
+	for _, l := range stdoutLevels(options.Levels) {
+		if l > level {
+			level = l
+		}
+	}
+	log.SetLevel(level)
+
+	// logs are on stderr, so that stdout has output of command only, e.g. for pipelines
+	var out io.Writer = os.Stdout
+	if options.Stderr {
+		out = os.Stderr
+	}
+	if !utils.IsEmpty(options.File) {
+		f, err := os.OpenFile(options.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			log.SetOutput(out)
+			log.Panic(err)
+		}
+		out = io.MultiWriter(out, f)
+	}
+	log.SetOutput(out)
+	return log
+}
+
+func stdoutLevel(level string) logrus.Level {
+
+	switch strings.ToLower(strings.TrimSpace(level)) {
 	case "error":
-		log.SetLevel(logrus.ErrorLevel)
+		return logrus.ErrorLevel
 	case "panic":
-		log.SetLevel(logrus.PanicLevel)
+		return logrus.PanicLevel
 	case "warn":
-		log.SetLevel(logrus.WarnLevel)
+		return logrus.WarnLevel
 	case "debug":
-		log.SetLevel(logrus.DebugLevel)
+		return logrus.DebugLevel
 	default:
-		log.SetLevel(logrus.InfoLevel)
+		return logrus.InfoLevel
 	}
+}
 
-	log.SetOutput(os.Stdout)
-	return log
+// stdoutLevels returns levels of modules of module=level items
+func stdoutLevels(items []string) map[string]logrus.Level {
+
+	levels := make(map[string]logrus.Level)
+	for _, item := range items {
+		module, level, ok := strings.Cut(item, "=")
+		if !ok || utils.IsEmpty(strings.TrimSpace(module)) {
+			continue
+		}
+		levels[strings.TrimSpace(module)] = stdoutLevel(level)
+	}
+	return levels
+}
+
+// stdoutFields returns fields of key=value items
+func stdoutFields(items []string) logrus.Fields {
+
+	fields := logrus.Fields{}
+	for _, item := range items {
+		k, v, ok := strings.Cut(item, "=")
+		if !ok || utils.IsEmpty(strings.TrimSpace(k)) {
+			continue
+		}
+		fields[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return fields
 }
 
 func (so *Stdout) SetCallerOffset(offset int) {
 	so.callerOffset = offset
 }
 
+// SetErrorHandler sets handler which gets errors regardless of level, e.g. to count them
+func (so *Stdout) SetErrorHandler(handler func(obj interface{})) {
+	so.errorHandler = handler
+}
+
+// With returns stdout of logs having fields as well, e.g. ids of request or event, stdout isn't changed
+func (so *Stdout) With(fields map[string]interface{}) *Stdout {
+
+	if so == nil {
+		return nil
+	}
+	c := *so
+	c.fields = logrus.Fields{}
+	for k, v := range so.fields {
+		c.fields[k] = v
+	}
+	for k, v := range fields {
+		c.fields[k] = v
+	}
+	return &c
+}
+
+// Module returns stdout of module having module field and level of module if it's set, e.g. http or slack
+func (so *Stdout) Module(module string) *Stdout {
+
+	if so == nil {
+		return nil
+	}
+	c := so.With(map[string]interface{}{"module": module})
+	if level, ok := so.levels[module]; ok {
+		c.level = level
+	}
+	return c
+}
+
 func NewStdout(options StdoutOptions) *Stdout {
 
 	log := newLog(options)
@@ -199,5 +363,9 @@ func NewStdout(options StdoutOptions) *Stdout {
 		log:          log,
 		options:      options,
 		callerOffset: 1,
+		fields:       stdoutFields(options.Fields),
+		level:        stdoutLevel(options.Level),
+		levels:       stdoutLevels(options.Levels),
+		sampler:      &stdoutSampler{limit: options.Sampling, callers: make(map[string]*stdoutSample)},
 	}
 }
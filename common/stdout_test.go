@@ -0,0 +1,57 @@
+package common
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStdout(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "tools.log")
+	stdout := NewStdout(StdoutOptions{
+		Format:   "json",
+		Level:    "info",
+		Levels:   []string{"http=debug", "slack=error"},
+		Fields:   []string{"job=deploy"},
+		Sampling: 2,
+		File:     file,
+		Stderr:   true,
+	})
+
+	stdout.Debug("dropped by level")
+	stdout.Module("slack").Info("dropped by level of module")
+	stdout.Module("slack").Error("error of module")
+	http := stdout.Module("http").With(map[string]interface{}{"attempt": 1})
+	for i := 0; i < 5; i++ {
+		http.Debug("request %d", i)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 logs, got %d: %s", len(lines), b)
+	}
+	var logs []map[string]interface{}
+	for _, line := range lines {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatal(err)
+		}
+		if m["job"] != "deploy" {
+			t.Errorf("expected job field of %s", line)
+		}
+		logs = append(logs, m)
+	}
+	if logs[0]["module"] != "slack" || logs[0]["msg"] != "error of module" {
+		t.Errorf("expected error of slack module, got %v", logs[0])
+	}
+	if logs[1]["module"] != "http" || logs[1]["attempt"] != float64(1) || logs[2]["msg"] != "request 1" {
+		t.Errorf("expected sampled debug logs of http module, got %v", logs[1:])
+	}
+}
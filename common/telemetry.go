@@ -0,0 +1,206 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devopsext/utils"
+)
+
+// telemetry is opt-in, it's sent if URL is set, events have counts and categories of errors only,
+// so that options, messages and responses of vendors never leave host, sinks get events locally, e.g. to record them as metrics
+const (
+	TelemetryStatusOK    = "ok"
+	TelemetryStatusError = "error"
+
+	TelemetryErrorTimeout    = "timeout"
+	TelemetryErrorNetwork    = "network"
+	TelemetryErrorAuth       = "auth"
+	TelemetryErrorNotFound   = "not_found"
+	TelemetryErrorRateLimit  = "rate_limit"
+	TelemetryErrorClient     = "http_4xx"
+	TelemetryErrorServer     = "http_5xx"
+	TelemetryErrorValidation = "validation"
+	TelemetryErrorOther      = "other"
+)
+
+type TelemetryOptions struct {
+	URL      string
+	Timeout  int
+	Insecure bool
+	Source   string
+}
+
+type TelemetryEvent struct {
+	Time       time.Time      `json:"time"`
+	Source     string         `json:"source,omitempty"`
+	Version    string         `json:"version"`
+	OS         string         `json:"os"`
+	Arch       string         `json:"arch"`
+	Command    string         `json:"command"`
+	Vendor     string         `json:"vendor,omitempty"`
+	Status     string         `json:"status"`
+	DurationMs int64          `json:"duration_ms"`
+	Errors     map[string]int `json:"errors,omitempty"`
+}
+
+// TelemetrySink gets event of command once it's finished
+type TelemetrySink func(event *TelemetryEvent) error
+
+type Telemetry struct {
+	options TelemetryOptions
+	sinks   []TelemetrySink
+	client  *http.Client
+	event   *TelemetryEvent
+	start   time.Time
+	mutex   sync.Mutex
+}
+
+var telemetryHttpStatus = regexp.MustCompile(`^([1-5][0-9]{2})\b`)
+
+// TelemetryErrorCategory returns category of error, HTTP errors of vendors are APIError or start with status
+func TelemetryErrorCategory(obj interface{}) string {
+
+	err, ok := obj.(error)
+	if !ok {
+		s, ok := obj.(string)
+		if !ok {
+			return TelemetryErrorOther
+		}
+		err = errors.New(s)
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return TelemetryErrorTimeout
+	}
+	if errors.As(err, &netErr) {
+		return TelemetryErrorNetwork
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return TelemetryErrorValidation
+	}
+
+	message := strings.TrimSpace(err.Error())
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		message = strconv.Itoa(apiErr.Status)
+	}
+	if m := telemetryHttpStatus.FindStringSubmatch(message); len(m) > 1 {
+		switch {
+		case m[1] == "401" || m[1] == "403":
+			return TelemetryErrorAuth
+		case m[1] == "404":
+			return TelemetryErrorNotFound
+		case m[1] == "429":
+			return TelemetryErrorRateLimit
+		case m[1][0] == '4':
+			return TelemetryErrorClient
+		case m[1][0] == '5':
+			return TelemetryErrorServer
+		}
+	}
+	return TelemetryErrorOther
+}
+
+func (t *Telemetry) Enabled() bool {
+	return t != nil && (!utils.IsEmpty(t.options.URL) || len(t.sinks) > 0)
+}
+
+// Start begins event of command, command is path without root, e.g. slack send
+func (t *Telemetry) Start(version, command, vendor string) {
+
+	if !t.Enabled() {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.start = time.Now()
+	t.event = &TelemetryEvent{
+		Time:    t.start.UTC(),
+		Source:  t.options.Source,
+		Version: version,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Command: command,
+		Vendor:  vendor,
+		Status:  TelemetryStatusOK,
+	}
+}
+
+// Error counts error by its category, error itself is not kept
+func (t *Telemetry) Error(obj interface{}) {
+
+	if !t.Enabled() {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.event == nil {
+		return
+	}
+	if t.event.Errors == nil {
+		t.event.Errors = make(map[string]int)
+	}
+	t.event.Errors[TelemetryErrorCategory(obj)]++
+	t.event.Status = TelemetryStatusError
+}
+
+// Send posts event as JSON and passes it to sinks, event is sent once
+func (t *Telemetry) Send() error {
+
+	if !t.Enabled() {
+		return nil
+	}
+	t.mutex.Lock()
+	event := t.event
+	t.event = nil
+	t.mutex.Unlock()
+
+	if event == nil {
+		return nil
+	}
+	event.DurationMs = time.Since(t.start).Milliseconds()
+
+	errs := []error{}
+	for _, sink := range t.sinks {
+		if err := sink(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if utils.IsEmpty(t.options.URL) {
+		return errors.Join(errs...)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	_, err = utils.HttpPostRawWithHeaders(t.client, t.options.URL, headers, data)
+	return errors.Join(append(errs, err)...)
+}
+
+func NewTelemetry(options TelemetryOptions, sinks ...TelemetrySink) *Telemetry {
+
+	return &Telemetry{
+		options: options,
+		sinks:   sinks,
+		client:  HttpClientAuditSkip(NewHttpClient(options.Timeout, options.Insecure)),
+	}
+}
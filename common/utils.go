@@ -59,8 +59,39 @@ func Output(query, to string, prefix string, opts []interface{}, bytes []byte, s
 	}
 	query = string(b)
 
+	format := GetOutputFormatOptions()
 	output := string(bytes)
-	if !utils.IsEmpty(query) {
+	jq := strings.ToLower(format.QueryEngine) == OutputQueryJq
+	if !utils.IsEmpty(query) && format.Format != OutputFormatRaw && jq {
+
+		var v interface{}
+		err = json.Unmarshal(bytes, &v)
+		if err != nil {
+			stdout.Panic(err)
+		}
+
+		vars := make(map[string]interface{})
+		for _, o := range opts {
+			m, err := InterfaceToMap(prefix, o)
+			if err != nil {
+				continue
+			}
+			for k, v := range m {
+				vars[k] = v
+			}
+		}
+
+		values, err := JqEval(v, query, vars)
+		if err != nil {
+			stdout.Panic(err)
+		}
+		output, err = JqString(values)
+		if err != nil {
+			stdout.Panic(err)
+		}
+	}
+
+	if !utils.IsEmpty(query) && format.Format != OutputFormatRaw && !jq {
 
 		jnata := NewJsonata(JsonataOptions{})
 
@@ -108,9 +139,17 @@ func Output(query, to string, prefix string, opts []interface{}, bytes []byte, s
 		}
 	}
 
-	if utils.IsEmpty(to) {
+	output, err = OutputFormat(format, []byte(output))
+	if err != nil {
+		stdout.Panic(err)
+	}
+
+	switch {
+	case utils.IsEmpty(to) && format.Plain:
+		fmt.Fprintln(os.Stdout, output)
+	case utils.IsEmpty(to):
 		stdout.Info(output)
-	} else {
+	default:
 		stdout.Debug("Writing output to %s...", to)
 		err := os.WriteFile(to, []byte(output), 0600)
 		if err != nil {
@@ -128,9 +167,12 @@ func OutputRaw(output string, bytes []byte, stdout *Stdout) {
 	stdout.Debug("Raw output => %s", string(bytes))
 
 	out := string(bytes)
-	if utils.IsEmpty(output) {
+	switch {
+	case utils.IsEmpty(output) && GetOutputFormatOptions().Plain:
+		fmt.Fprintln(os.Stdout, out)
+	case utils.IsEmpty(output):
 		stdout.Info(out)
-	} else {
+	default:
 		stdout.Debug("Writing output to %s...", output)
 		err := os.WriteFile(output, bytes, 0600)
 		if err != nil {
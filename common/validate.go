@@ -0,0 +1,117 @@
+package common
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/devopsext/utils"
+)
+
+// layout of date time without offset, which is local time of time zone option
+const validateLocalTime = "2006-01-02T15:04:05"
+
+// ValidationError is error of invalid options, issues of all options are reported at once,
+// so that they are fixed before vendor is requested
+type ValidationError struct {
+	Name   string   `json:"name"`
+	Issues []string `json:"issues"`
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s options: %s", e.Name, strings.Join(e.Issues, "; "))
+}
+
+// Validation collects issues of options, empty options are valid, as they are checked by vendors if they are required
+type Validation struct {
+	name   string
+	issues []string
+}
+
+func NewValidation(name string) *Validation {
+	return &Validation{name: name}
+}
+
+func (v *Validation) Issue(format string, args ...interface{}) {
+	v.issues = append(v.issues, fmt.Sprintf(format, args...))
+}
+
+// Time checks RFC3339 date time, e.g. 2024-05-01T10:00:00Z, date time without offset is valid if it's local,
+// e.g. of time zone option, time is returned if it's valid
+func (v *Validation) Time(field, value string, local bool) (time.Time, bool) {
+
+	if utils.IsEmpty(value) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, true
+	}
+	if local {
+		if t, err := time.Parse(validateLocalTime, value); err == nil {
+			return t, true
+		}
+		v.Issue("%s %s isn't RFC3339 date time, e.g. 2024-05-01T10:00:00Z or 2024-05-01T10:00:00", field, value)
+		return time.Time{}, false
+	}
+	v.Issue("%s %s isn't RFC3339 date time with offset, e.g. 2024-05-01T10:00:00Z or 2024-05-01T10:00:00+02:00", field, value)
+	return time.Time{}, false
+}
+
+// TimeZone checks IANA time zone name, e.g. Europe/Berlin
+func (v *Validation) TimeZone(field, value string) {
+
+	if utils.IsEmpty(value) {
+		return
+	}
+	if _, err := time.LoadLocation(value); err != nil || value == "Local" {
+		v.Issue("%s %s isn't IANA time zone name, e.g. UTC or Europe/Berlin", field, value)
+	}
+}
+
+// Email checks address of email, names and angle brackets aren't valid, e.g. of "Jane <jane@example.com>"
+func (v *Validation) Email(field, value string) {
+
+	if utils.IsEmpty(value) {
+		return
+	}
+	a, err := mail.ParseAddress(value)
+	if err != nil || a.Address != value || !strings.Contains(value[strings.LastIndex(value, "@")+1:], ".") {
+		v.Issue("%s %s isn't email address", field, value)
+	}
+}
+
+func (v *Validation) Emails(field string, values []string) {
+
+	for _, value := range values {
+		v.Email(field, strings.TrimSpace(value))
+	}
+}
+
+// Match checks value by regexp, example is of issue, e.g. C0123456789 or alerts
+func (v *Validation) Match(field, value string, re *regexp.Regexp, example string) {
+
+	if utils.IsEmpty(value) || re.MatchString(value) {
+		return
+	}
+	v.Issue("%s %s isn't valid, e.g. %s", field, value, example)
+}
+
+func (v *Validation) OneOf(field, value string, values []string) {
+
+	if utils.IsEmpty(value) || utils.Contains(values, value) {
+		return
+	}
+	v.Issue("%s %s isn't one of %s", field, value, strings.Join(values, ", "))
+}
+
+// Err returns error of all issues, it's nil if options are valid
+func (v *Validation) Err() error {
+
+	if len(v.issues) == 0 {
+		return nil
+	}
+	return &ValidationError{Name: v.name, Issues: v.issues}
+}
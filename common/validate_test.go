@@ -0,0 +1,39 @@
+package common
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestValidation(t *testing.T) {
+
+	v := NewValidation("test")
+	v.Time("start", "2024-05-01T10:00:00Z", false)
+	v.Time("start", "2024-05-01T10:00:00", true)
+	v.Time("start", "", false)
+	v.TimeZone("time zone", "Europe/Berlin")
+	v.Email("email", "jane@example.com")
+	v.Match("channel", "alerts", regexp.MustCompile(`^[a-z]+$`), "alerts")
+	if err := v.Err(); err != nil {
+		t.Fatalf("expected no issues, got %s", err)
+	}
+
+	v = NewValidation("test")
+	v.Time("start", "2024-05-01T10:00:00", false)
+	v.Time("end", "tomorrow", true)
+	v.TimeZone("time zone", "Mars/Olympus")
+	v.Emails("attendee", []string{"jane@example.com", "Jane <jane@example.com>", "john"})
+	v.Match("channel", "Alerts", regexp.MustCompile(`^[a-z]+$`), "alerts")
+	err := v.Err()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if len(verr.Issues) != 6 {
+		t.Errorf("expected 6 issues, got %d: %s", len(verr.Issues), err)
+	}
+	if TelemetryErrorCategory(err) != TelemetryErrorValidation {
+		t.Errorf("expected validation category of %s", err)
+	}
+}
@@ -0,0 +1,277 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/devopsext/utils"
+)
+
+// VendorMethod is method of vendor, options of method are fields of its option structs, e.g. SlackMessageOptions
+type VendorMethod struct {
+	Name    string   `json:"name"`
+	Options []string `json:"options,omitempty"`
+}
+
+// Vendor is integration executed by method name and JSON object of options, vendors compiled into binary
+// and vendors of plugins are the same for registry
+type Vendor interface {
+	Name() string
+	Methods() ([]VendorMethod, error)
+	Execute(ctx context.Context, method string, options []byte) ([]byte, error)
+}
+
+// VendorLoader returns vendor once it's got from registry, e.g. of Go plugin, which is opened by it
+type VendorLoader func() (Vendor, error)
+
+// VendorRegistry is vendors keyed by name, the first registered vendor of name wins,
+// vendors of loaders are loaded once they're got
+type VendorRegistry struct {
+	mutex   sync.Mutex
+	vendors map[string]Vendor
+	loaders map[string]VendorLoader
+}
+
+type vendorReflectMethod struct {
+	VendorMethod
+	new int
+}
+
+// vendorReflect is vendor of constructors of vendors package, e.g. func(*Stdout) *vendors.Slack,
+// vendors are created once they're executed, so that their clients aren't created for listing
+type vendorReflect struct {
+	name      string
+	stdout    *Stdout
+	news      []reflect.Value
+	instances []reflect.Value
+	methods   []*vendorReflectMethod
+	mutex     sync.Mutex
+}
+
+var (
+	vendorBytesType   = reflect.TypeOf([]byte{})
+	vendorErrorType   = reflect.TypeOf((*error)(nil)).Elem()
+	vendorStdoutType  = reflect.TypeOf(&Stdout{})
+	vendorContextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+func NewVendorRegistry() *VendorRegistry {
+	return &VendorRegistry{vendors: make(map[string]Vendor), loaders: make(map[string]VendorLoader)}
+}
+
+func (r *VendorRegistry) Register(v Vendor) error {
+	return r.RegisterLoader(v.Name(), func() (Vendor, error) { return v, nil })
+}
+
+func (r *VendorRegistry) RegisterLoader(name string, loader VendorLoader) error {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.loaders[name]; ok {
+		return fmt.Errorf("vendor %s is already registered", name)
+	}
+	r.loaders[name] = loader
+	return nil
+}
+
+// Get returns vendor of name, it's loaded once, failed loading is tried again
+func (r *VendorRegistry) Get(name string) (Vendor, error) {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if v, ok := r.vendors[name]; ok {
+		return v, nil
+	}
+	loader, ok := r.loaders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown vendor %s", name)
+	}
+	v, err := loader()
+	if err != nil {
+		return nil, err
+	}
+	r.vendors[name] = v
+	return v, nil
+}
+
+// Names returns names of vendors sorted, vendors aren't loaded
+func (r *VendorRegistry) Names() []string {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	names := []string{}
+	for name := range r.loaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// vendorMethodArgs returns option structs of method, methods of interfaces have no receiver,
+// methods having other args, e.g. ids or context, aren't vendor methods
+func vendorMethodArgs(m reflect.Method, iface bool) ([]reflect.Type, bool) {
+
+	t := m.Type
+	if t.NumOut() != 2 || t.Out(0) != vendorBytesType || t.Out(1) != vendorErrorType {
+		return nil, false
+	}
+	first := 1
+	if iface {
+		first = 0
+	}
+	args := []reflect.Type{}
+	for i := first; i < t.NumIn(); i++ {
+		if t.In(i).Kind() != reflect.Struct {
+			return nil, false
+		}
+		args = append(args, t.In(i))
+	}
+	return args, true
+}
+
+// NewVendor returns vendor of constructors of command files, e.g. slackNew, methods are exported methods of option
+// structs returning response, Custom methods aren't vendor methods, as options of vendor are of flags, env and config
+func NewVendor(name string, stdout *Stdout, news ...interface{}) (Vendor, error) {
+
+	v := &vendorReflect{name: name, stdout: stdout}
+	names := make(map[string]bool)
+	for i, new := range news {
+
+		f := reflect.ValueOf(new)
+		t := f.Type()
+		if t.Kind() != reflect.Func || t.NumIn() != 1 || t.In(0) != vendorStdoutType || t.NumOut() != 1 {
+			return nil, fmt.Errorf("vendor %s constructor %s isn't func(*Stdout) vendor", name, t)
+		}
+		vt := t.Out(0)
+		for j := 0; j < vt.NumMethod(); j++ {
+
+			m := vt.Method(j)
+			if strings.HasPrefix(m.Name, "Custom") || names[m.Name] {
+				continue
+			}
+			args, ok := vendorMethodArgs(m, vt.Kind() == reflect.Interface)
+			if !ok {
+				continue
+			}
+			names[m.Name] = true
+			options := []string{}
+			for _, a := range args {
+				options = append(options, a.Name())
+			}
+			v.methods = append(v.methods, &vendorReflectMethod{VendorMethod{Name: m.Name, Options: options}, i})
+		}
+		v.news = append(v.news, f)
+	}
+	v.instances = make([]reflect.Value, len(news))
+	sort.Slice(v.methods, func(i, j int) bool {
+		return v.methods[i].Name < v.methods[j].Name
+	})
+	return v, nil
+}
+
+func (v *vendorReflect) Name() string {
+	return v.name
+}
+
+func (v *vendorReflect) Methods() ([]VendorMethod, error) {
+
+	r := []VendorMethod{}
+	for _, m := range v.methods {
+		r = append(r, m.VendorMethod)
+	}
+	return r, nil
+}
+
+// instance returns vendor of method of context, vendors are created once
+func (v *vendorReflect) instance(ctx context.Context, new int) reflect.Value {
+
+	v.mutex.Lock()
+	if !v.instances[new].IsValid() {
+		v.instances[new] = v.news[new].Call([]reflect.Value{reflect.ValueOf(v.stdout)})[0]
+	}
+	instance := v.instances[new]
+	v.mutex.Unlock()
+
+	withContext := instance.MethodByName("WithContext")
+	if ctx == nil || !withContext.IsValid() {
+		return instance
+	}
+	t := withContext.Type()
+	if t.NumIn() != 1 || t.In(0) != vendorContextType || t.NumOut() != 1 || t.Out(0) != instance.Type() {
+		return instance
+	}
+	return withContext.Call([]reflect.Value{reflect.ValueOf(ctx)})[0]
+}
+
+// vendorOptions returns JSON options of option structs of method, which are keyed by names of structs,
+// e.g. {"GoogleCalendarOptions":{"ID":"team"},"GoogleCalendarGetEventOptions":{"ID":"e1"}}, options of method
+// of one struct can be the struct itself, e.g. {"Channel":"C123","Text":"Deployed"} of SendMessage of Slack
+func vendorOptions(m *vendorReflectMethod, options []byte) ([]json.RawMessage, error) {
+
+	r := make([]json.RawMessage, len(m.Options))
+	if len(strings.TrimSpace(string(options))) == 0 {
+		return r, nil
+	}
+	keyed := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(options, &keyed); err != nil {
+		return nil, err
+	}
+	if len(m.Options) == 1 {
+		if _, ok := keyed[m.Options[0]]; !ok {
+			r[0] = options
+			return r, nil
+		}
+	}
+	for k := range keyed {
+		if !utils.Contains(m.Options, k) {
+			return nil, fmt.Errorf("unknown options %s, options are keyed by %s", k, strings.Join(m.Options, ", "))
+		}
+	}
+	for i, name := range m.Options {
+		r[i] = keyed[name]
+	}
+	return r, nil
+}
+
+// Execute calls method by options of its option structs, names of method are case insensitive
+func (v *vendorReflect) Execute(ctx context.Context, method string, options []byte) ([]byte, error) {
+
+	var m *vendorReflectMethod
+	for _, vm := range v.methods {
+		if strings.EqualFold(vm.Name, method) {
+			m = vm
+			break
+		}
+	}
+	if m == nil {
+		return nil, fmt.Errorf("unknown method %s of vendor %s", method, v.name)
+	}
+
+	opts, err := vendorOptions(m, options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid options of %s of vendor %s: %s", m.Name, v.name, err)
+	}
+	f := v.instance(ctx, m.new).MethodByName(m.Name)
+	args := []reflect.Value{}
+	for i := 0; i < f.Type().NumIn(); i++ {
+		arg := reflect.New(f.Type().In(i))
+		if len(opts[i]) > 0 {
+			if err := json.Unmarshal(opts[i], arg.Interface()); err != nil {
+				return nil, fmt.Errorf("invalid options %s of %s of vendor %s: %s", m.Options[i], m.Name, v.name, err)
+			}
+		}
+		args = append(args, arg.Elem())
+	}
+	out := f.Call(args)
+	b, _ := out[0].Interface().([]byte)
+	err, _ = out[1].Interface().(error)
+	return b, err
+}
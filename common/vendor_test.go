@@ -0,0 +1,166 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+type testVendorOptions struct {
+	Channel string
+	Text    string
+}
+
+type testVendorThreadOptions struct {
+	Channel string
+	Thread  string
+}
+
+type testVendor struct {
+	ctx context.Context
+}
+
+func (v *testVendor) CustomSend(vendorOptions testVendorOptions, options testVendorOptions) ([]byte, error) {
+	return nil, nil
+}
+
+func (v *testVendor) Send(options testVendorOptions) ([]byte, error) {
+	if v.ctx == nil {
+		return nil, os.ErrInvalid
+	}
+	return json.Marshal(options)
+}
+
+func (v *testVendor) Get(id string) ([]byte, error) {
+	return nil, nil
+}
+
+func (v *testVendor) Reply(options testVendorOptions, threadOptions testVendorThreadOptions) ([]byte, error) {
+	return json.Marshal([]interface{}{options, threadOptions})
+}
+
+func (v *testVendor) WithContext(ctx context.Context) *testVendor {
+	return &testVendor{ctx: ctx}
+}
+
+func TestVendorRegistry(t *testing.T) {
+
+	news := 0
+	vendor, err := NewVendor("test", nil, func(stdout *Stdout) *testVendor {
+		news++
+		return &testVendor{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewVendor("test", nil, func() *testVendor { return nil }); err == nil {
+		t.Fatal("expected error of constructor without stdout")
+	}
+
+	// Custom methods and methods of other args aren't vendor methods, vendor isn't created for listing
+	methods, _ := vendor.Methods()
+	if len(methods) != 2 || methods[1].Name != "Send" || methods[1].Options[0] != "testVendorOptions" || news != 0 {
+		t.Fatalf("unexpected methods %+v of %d vendors", methods, news)
+	}
+
+	registry := NewVendorRegistry()
+	if err := registry.Register(vendor); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(vendor); err == nil {
+		t.Fatal("expected error of duplicate vendor")
+	}
+	v, err := registry.Get("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		b, err := v.Execute(context.Background(), "send", []byte(`{"Channel":"C123","Text":"Deployed"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != `{"Channel":"C123","Text":"Deployed"}` {
+			t.Fatalf("unexpected response %s", b)
+		}
+	}
+	if news != 1 {
+		t.Fatalf("expected vendor created once, got %d", news)
+	}
+	// options of structs of method are keyed by names of structs, so that fields of the same name aren't shared
+	b, err := v.Execute(context.Background(), "Reply", []byte(`{"testVendorOptions":{"Channel":"C1"},"testVendorThreadOptions":{"Channel":"C2"}}`))
+	if err != nil || string(b) != `[{"Channel":"C1","Text":""},{"Channel":"C2","Thread":""}]` {
+		t.Fatalf("unexpected response %s: %v", b, err)
+	}
+	if _, err := v.Execute(context.Background(), "Reply", []byte(`{"Channel":"C1"}`)); err == nil {
+		t.Fatal("expected error of options which aren't keyed by structs")
+	}
+	if _, err := v.Execute(context.Background(), "Get", nil); err == nil {
+		t.Fatal("expected error of unknown method")
+	}
+	if _, err := registry.Get("unknown"); err == nil {
+		t.Fatal("expected error of unknown vendor")
+	}
+
+	// vendor of loader is loaded once it's got
+	loads := 0
+	registry.RegisterLoader("plugin", func() (Vendor, error) {
+		loads++
+		return vendor, nil
+	})
+	if names := registry.Names(); len(names) != 2 || loads != 0 {
+		t.Fatalf("unexpected names %v of %d loads", names, loads)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := registry.Get("plugin"); err != nil || loads != 1 {
+			t.Fatalf("expected 1 load, got %d: %v", loads, err)
+		}
+	}
+}
+
+func TestVendorPlugin(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin is shell script")
+	}
+	dir := t.TempDir()
+	script := `#!/bin/sh
+[ "$TOOLS_PLUGIN_MAGIC_COOKIE" = "` + VendorPluginMagicCookieValue + `" ] || exit 1
+case "$1" in
+methods) echo '[{"name":"Echo"}]' ;;
+execute) [ "$2" = "Echo" ] || { echo "unknown method $2" >&2; exit 2; }; cat ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "tools-vendor-acme"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tools-vendor-acme.so"), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Go plugin of the same name is skipped, the first found wins
+	plugins, err := FindPlugins(PluginsOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 1 || plugins[0].Name != "acme" || !plugins[0].Vendor || filepath.Ext(plugins[0].Path) != "" {
+		t.Fatalf("unexpected plugins %+v", plugins)
+	}
+	vendor, err := NewVendorPlugin(plugins[0], NewStdout(StdoutOptions{Level: "panic"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	methods, err := vendor.Methods()
+	if err != nil || len(methods) != 1 || methods[0].Name != "Echo" {
+		t.Fatalf("unexpected methods %+v: %v", methods, err)
+	}
+	b, err := vendor.Execute(context.Background(), "Echo", []byte(`{"Text":"Deployed"}`))
+	if err != nil || string(b) != `{"Text":"Deployed"}` {
+		t.Fatalf("unexpected response %s: %v", b, err)
+	}
+	if _, err := vendor.Execute(context.Background(), "Fail", nil); err == nil || err.Error() != "vendor plugin acme: unknown method Fail" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// VendorPluginProtocolVersion is version of exec protocol of vendor plugins, plugin fails if it doesn't support it
+const VendorPluginProtocolVersion = "1"
+
+// VendorPluginMagicCookie is handshake of exec protocol, plugin is run by tools if env var has the value,
+// so that plugin which is run by user explains that it isn't command
+const (
+	VendorPluginMagicCookieKey   = "TOOLS_PLUGIN_MAGIC_COOKIE"
+	VendorPluginMagicCookieValue = "d2f1a5c7e0b64b8f9a3e6c1b7d4f2a90"
+)
+
+// vendorExec is vendor of executable plugin, e.g. tools-vendor-acme, plugin is run for each call:
+//
+//	tools-vendor-acme methods           writes JSON array of methods, e.g. [{"name":"SendMessage"}]
+//	tools-vendor-acme execute <method>  reads JSON object of options from stdin and writes response to stdout
+//
+// plugin exits with non-zero code if call fails, stderr of it is error
+type vendorExec struct {
+	name    string
+	path    string
+	stdout  *Stdout
+	methods []VendorMethod
+	mutex   sync.Mutex
+}
+
+func NewVendorExec(name, path string, stdout *Stdout) Vendor {
+	return &vendorExec{name: name, path: path, stdout: stdout}
+}
+
+func (v *vendorExec) run(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	v.stdout.Debug("Vendor plugin %s running %s %v...", v.name, v.path, args)
+
+	c := exec.CommandContext(ctx, v.path, args...)
+	c.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%s", VendorPluginMagicCookieKey, VendorPluginMagicCookieValue),
+		fmt.Sprintf("TOOLS_PLUGIN_PROTOCOL_VERSION=%s", VendorPluginProtocolVersion),
+		fmt.Sprintf("TOOLS_PLUGIN_NAME=%s", v.name),
+	)
+	c.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+
+	err := c.Run()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(stderr.String()); errors.As(err, &exitErr) && msg != "" {
+			return nil, fmt.Errorf("vendor plugin %s: %s", v.name, msg)
+		}
+		return nil, fmt.Errorf("vendor plugin %s: %s", v.name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func (v *vendorExec) Name() string {
+	return v.name
+}
+
+// Methods runs plugin once, methods are the same till plugin is replaced
+func (v *vendorExec) Methods() ([]VendorMethod, error) {
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if v.methods != nil {
+		return v.methods, nil
+	}
+	b, err := v.run(context.Background(), nil, "methods")
+	if err != nil {
+		return nil, err
+	}
+	methods := []VendorMethod{}
+	if err := json.Unmarshal(b, &methods); err != nil {
+		return nil, fmt.Errorf("vendor plugin %s methods: %s", v.name, err)
+	}
+	v.methods = methods
+	return methods, nil
+}
+
+func (v *vendorExec) Execute(ctx context.Context, method string, options []byte) ([]byte, error) {
+	return v.run(ctx, options, "execute", method)
+}
@@ -0,0 +1,31 @@
+//go:build (linux || darwin || freebsd) && cgo
+
+package common
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// vendorGoPlugin opens Go plugin, e.g. tools-vendor-acme.so, which is built by -buildmode=plugin
+// with the same Go and common package as binary, plugin exports func NewVendor() common.Vendor
+func vendorGoPlugin(name, path string) (Vendor, error) {
+
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("vendor plugin %s: %s", name, err)
+	}
+	sym, err := p.Lookup("NewVendor")
+	if err != nil {
+		return nil, fmt.Errorf("vendor plugin %s: %s", name, err)
+	}
+	new, ok := sym.(func() Vendor)
+	if !ok {
+		return nil, fmt.Errorf("vendor plugin %s: NewVendor isn't func() common.Vendor", name)
+	}
+	v := new()
+	if v == nil || v.Name() != name {
+		return nil, fmt.Errorf("vendor plugin %s: NewVendor returns vendor of other name", name)
+	}
+	return v, nil
+}
@@ -0,0 +1,9 @@
+//go:build !((linux || darwin || freebsd) && cgo)
+
+package common
+
+import "fmt"
+
+func vendorGoPlugin(name, path string) (Vendor, error) {
+	return nil, fmt.Errorf("vendor plugin %s: Go plugins aren't supported by binary, plugin should be executable", name)
+}
@@ -0,0 +1,387 @@
+//go:build integration
+
+// Package integration exercises vendors end to end against sandboxes of docker-compose.yml,
+// endpoints are overridden by environment variables, e.g. to run against sandboxes elsewhere
+package integration
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devopsext/tools/vendors"
+)
+
+// integrationTimeout is time to wait for sandbox, Rocket.Chat and Mattermost start slowly
+const integrationTimeout = 3 * time.Minute
+
+func env(key, def string) string {
+
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
+}
+
+func randomID(t *testing.T) string {
+
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// eventually retries f until it succeeds or sandbox timeout is exceeded
+func eventually(t *testing.T, name string, f func() error) {
+
+	t.Helper()
+	deadline := time.Now().Add(integrationTimeout)
+	for {
+		err := f()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: %s", name, err)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func getJSON(URL string, headers map[string]string, v interface{}) error {
+
+	req, err := http.NewRequest("GET", URL, nil)
+	if err != nil {
+		return err
+	}
+	for k, h := range headers {
+		req.Header.Set(k, h)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, b)
+	}
+	return json.Unmarshal(b, v)
+}
+
+func TestEmailMailhog(t *testing.T) {
+
+	smtp := env("INTEGRATION_SMTP", "localhost:1025")
+	api := env("INTEGRATION_MAILHOG_URL", "http://localhost:8025")
+	subject := "tools integration " + randomID(t)
+
+	email := vendors.NewEmail(vendors.EmailOptions{Timeout: 30, Server: smtp})
+	eventually(t, "send email", func() error {
+		_, err := email.Send(vendors.EmailMessageOptions{
+			From:    "Tools <tools@example.com>",
+			To:      []string{"ops@example.com"},
+			Cc:      []string{"dev@example.com"},
+			Subject: subject,
+			Text:    "plain",
+			HTML:    "<b>html</b>",
+		})
+		return err
+	})
+
+	var r struct {
+		Total int `json:"total"`
+		Items []struct {
+			Raw struct {
+				To []string `json:"To"`
+			} `json:"Raw"`
+		} `json:"items"`
+	}
+	eventually(t, "find email", func() error {
+		u := fmt.Sprintf("%s/api/v2/search?kind=containing&query=%s", api, url.QueryEscape(subject))
+		if err := getJSON(u, nil, &r); err != nil {
+			return err
+		}
+		if r.Total != 1 {
+			return fmt.Errorf("%d emails found", r.Total)
+		}
+		return nil
+	})
+	if len(r.Items[0].Raw.To) != 2 {
+		t.Fatalf("email has recipients %v, not To and Cc", r.Items[0].Raw.To)
+	}
+}
+
+func TestS3MinIO(t *testing.T) {
+
+	s3 := vendors.NewAWSS3(vendors.AWSS3Options{
+		Timeout:  60,
+		Region:   "us-east-1",
+		Endpoint: env("INTEGRATION_S3_ENDPOINT", "http://localhost:9000"),
+		Bucket:   env("INTEGRATION_S3_BUCKET", "tools"),
+		PartSize: 5,
+		AWSKeys: vendors.AWSKeys{
+			AccessKey: env("INTEGRATION_S3_ACCESSKEY", "minioadmin"),
+			SecretKey: env("INTEGRATION_S3_SECRETKEY", "minioadmin"),
+		},
+	})
+	dir := t.TempDir()
+	prefix := "integration/" + randomID(t) + "/"
+
+	// 6MB is uploaded by multipart upload of 5MB parts
+	for name, size := range map[string]int{"small file.txt": 1024, "large.bin": 6 * 1024 * 1024} {
+
+		data := make([]byte, size)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatal(err)
+		}
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		eventually(t, "upload "+name, func() error {
+			_, err := s3.Upload(vendors.AWSS3UploadOptions{Key: prefix + name, File: file})
+			return err
+		})
+
+		downloaded := filepath.Join(dir, "downloaded")
+		if _, err := s3.Download(vendors.AWSS3DownloadOptions{Key: prefix + name, File: downloaded}); err != nil {
+			t.Fatalf("download %s: %s", name, err)
+		}
+		b, err := os.ReadFile(downloaded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, data) {
+			t.Fatalf("downloaded %s differs from uploaded", name)
+		}
+	}
+
+	b, err := s3.List(vendors.AWSS3ListOptions{Prefix: prefix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var objects []*vendors.AWSS3Object
+	if err := json.Unmarshal(b, &objects); err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("%d objects listed, not 2", len(objects))
+	}
+
+	b, err = s3.Presign(vendors.AWSS3PresignOptions{Key: prefix + "small file.txt", Expires: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var presigned vendors.AWSS3Result
+	if err := json.Unmarshal(b, &presigned); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(presigned.PresignedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("presigned URL returns %s", resp.Status)
+	}
+}
+
+func TestPrometheus(t *testing.T) {
+
+	// Prometheus scrapes itself, so that up is there after first scrape
+	prometheus := vendors.NewPrometheus(vendors.PrometheusOptions{
+		URL:     env("INTEGRATION_PROMETHEUS_URL", "http://localhost:9090"),
+		Timeout: 30,
+		Query:   `up{job="prometheus"}`,
+	})
+	eventually(t, "query", func() error {
+		b, err := prometheus.Get()
+		if err != nil {
+			return err
+		}
+		var r struct {
+			Status string `json:"status"`
+			Data   struct {
+				Result []interface{} `json:"result"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(b, &r); err != nil {
+			return err
+		}
+		if r.Status != "success" || len(r.Data.Result) == 0 {
+			return fmt.Errorf("query result is empty: %s", b)
+		}
+		return nil
+	})
+}
+
+func rocketChatLogin(URL, user, password string) (string, string, error) {
+
+	data, err := json.Marshal(map[string]string{"user": user, "password": password})
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := http.Post(URL+"/api/v1/login", "application/json", bytes.NewReader(data))
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	var r struct {
+		Status string `json:"status"`
+		Data   struct {
+			UserID    string `json:"userId"`
+			AuthToken string `json:"authToken"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", "", err
+	}
+	if r.Status != "success" {
+		return "", "", fmt.Errorf("login status is %s", r.Status)
+	}
+	return r.Data.UserID, r.Data.AuthToken, nil
+}
+
+func TestRocketChat(t *testing.T) {
+
+	URL := env("INTEGRATION_ROCKETCHAT_URL", "http://localhost:3000")
+	var userID, token string
+	eventually(t, "login", func() (err error) {
+		userID, token, err = rocketChatLogin(URL, env("INTEGRATION_ROCKETCHAT_USER", "tools"), env("INTEGRATION_ROCKETCHAT_PASSWORD", "tools-integration"))
+		return err
+	})
+
+	rocketChat := vendors.NewRocketChat(vendors.RocketChatOptions{Timeout: 30, URL: URL, UserID: userID, Token: token})
+	channel := "integration-" + randomID(t)
+	if _, err := rocketChat.CreateChannel(vendors.RocketChatChannelOptions{Name: channel}); err != nil {
+		t.Fatalf("create channel: %s", err)
+	}
+	text := "tools integration " + randomID(t)
+	if _, err := rocketChat.SendMessage(vendors.RocketChatMessageOptions{Channel: "#" + channel, Text: text}); err != nil {
+		t.Fatalf("send message: %s", err)
+	}
+
+	var r struct {
+		Messages []struct {
+			Msg string `json:"msg"`
+		} `json:"messages"`
+	}
+	headers := map[string]string{"X-User-Id": userID, "X-Auth-Token": token}
+	if err := getJSON(fmt.Sprintf("%s/api/v1/channels.history?roomName=%s", URL, url.QueryEscape(channel)), headers, &r); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range r.Messages {
+		if m.Msg == text {
+			return
+		}
+	}
+	t.Fatalf("message %q is not in channel %s", text, channel)
+}
+
+// mattermostAPI sends request to API v4 of Mattermost, token of login is returned by header
+func mattermostAPI(URL, token, method, p string, body, v interface{}) (string, error) {
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(method, URL+"/api/v4"+p, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("%s %s: %s: %s", method, p, resp.Status, b)
+	}
+	if v != nil {
+		if err := json.Unmarshal(b, v); err != nil {
+			return "", err
+		}
+	}
+	return resp.Header.Get("Token"), nil
+}
+
+func TestMattermost(t *testing.T) {
+
+	URL := env("INTEGRATION_MATTERMOST_URL", "http://localhost:8065")
+	user := env("INTEGRATION_MATTERMOST_USER", "tools")
+	password := env("INTEGRATION_MATTERMOST_PASSWORD", "Tools-integration-1")
+
+	// first user of open server is created by sign up, it's there already on reruns
+	var token string
+	eventually(t, "login", func() (err error) {
+		token, err = mattermostAPI(URL, "", "POST", "/users/login", map[string]string{"login_id": user, "password": password}, nil)
+		if err == nil {
+			return nil
+		}
+		if _, err := mattermostAPI(URL, "", "POST", "/users", map[string]string{"email": "tools@example.com", "username": user, "password": password}, nil); err != nil {
+			return err
+		}
+		token, err = mattermostAPI(URL, "", "POST", "/users/login", map[string]string{"login_id": user, "password": password}, nil)
+		return err
+	})
+
+	id := randomID(t)
+	var team, channel struct {
+		ID string `json:"id"`
+	}
+	if _, err := mattermostAPI(URL, token, "POST", "/teams", map[string]string{"name": "integration-" + id, "display_name": "Integration " + id, "type": "O"}, &team); err != nil {
+		t.Fatalf("create team: %s", err)
+	}
+	if _, err := mattermostAPI(URL, token, "POST", "/channels", map[string]string{"team_id": team.ID, "name": "integration-" + id, "display_name": "Integration " + id, "type": "O"}, &channel); err != nil {
+		t.Fatalf("create channel: %s", err)
+	}
+
+	mattermost := vendors.NewMattermost(vendors.MattermostOptions{Timeout: 30, URL: URL, Token: token})
+	text := "tools integration " + randomID(t)
+	if _, err := mattermost.SendMessage(vendors.MattermostMessageOptions{Channel: channel.ID, Text: text}); err != nil {
+		t.Fatalf("send message: %s", err)
+	}
+	if _, err := mattermost.SendFile(vendors.MattermostFileOptions{Channel: channel.ID, Text: text + " file", Name: "report.txt", Content: "report"}); err != nil {
+		t.Fatalf("send file: %s", err)
+	}
+
+	var r struct {
+		Posts map[string]struct {
+			Message string   `json:"message"`
+			FileIDs []string `json:"file_ids"`
+		} `json:"posts"`
+	}
+	if err := getJSON(fmt.Sprintf("%s/api/v4/channels/%s/posts", URL, channel.ID), map[string]string{"Authorization": "Bearer " + token}, &r); err != nil {
+		t.Fatal(err)
+	}
+	message, file := false, false
+	for _, p := range r.Posts {
+		message = message || p.Message == text
+		file = file || (p.Message == text+" file" && len(p.FileIDs) == 1)
+	}
+	if !message || !file {
+		t.Fatalf("message %q or its file is not in channel %s", text, channel.ID)
+	}
+}
@@ -448,6 +448,73 @@ func (tpl *Template) FromJson(i interface{}) (interface{}, error) {
 	return r, nil
 }
 
+// payloadBytes returns JSON of webhook payload given as string, bytes or object
+func (tpl *Template) payloadBytes(i interface{}) ([]byte, error) {
+
+	switch v := i.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return json.Marshal(i)
+}
+
+// AlertmanagerWebhook converts webhook payload as JSON string, bytes or object to typed one,
+// e.g. {{ range (alertmanagerWebhook .).Firing }}{{ .Summary }}{{ end }}
+func (tpl *Template) AlertmanagerWebhook(i interface{}) (*vendors.AlertmanagerWebhook, error) {
+
+	d, err := tpl.payloadBytes(i)
+	if err != nil {
+		return nil, err
+	}
+	return vendors.ParseAlertmanagerWebhook(d)
+}
+
+// GrafanaWebhook converts unified alerting payload as JSON string, bytes or object to typed one,
+// e.g. {{ (grafanaWebhook .).ImageURL }}
+func (tpl *Template) GrafanaWebhook(i interface{}) (*vendors.GrafanaWebhook, error) {
+
+	d, err := tpl.payloadBytes(i)
+	if err != nil {
+		return nil, err
+	}
+	return vendors.ParseGrafanaWebhook(d)
+}
+
+// SentryWebhook converts issue alert payload as JSON string, bytes or object to typed one,
+// e.g. {{ with sentryWebhook . }}{{ .Title }} in {{ .Culprit }} ({{ .Release }}) {{ .URL }}{{ end }}
+func (tpl *Template) SentryWebhook(i interface{}) (*vendors.SentryWebhook, error) {
+
+	d, err := tpl.payloadBytes(i)
+	if err != nil {
+		return nil, err
+	}
+	return vendors.ParseSentryWebhook(d)
+}
+
+// HarborWebhook converts event payload as JSON string, bytes or object to typed one,
+// e.g. {{ with harborWebhook . }}{{ .Repository }} critical={{ .Critical }}{{ end }}
+func (tpl *Template) HarborWebhook(i interface{}) (*vendors.HarborWebhook, error) {
+
+	d, err := tpl.payloadBytes(i)
+	if err != nil {
+		return nil, err
+	}
+	return vendors.ParseHarborWebhook(d)
+}
+
+// FluxEvent converts notification-controller event as JSON string, bytes or object to typed one,
+// e.g. {{ with fluxEvent . }}{{ .Title }}: {{ .Message }}{{ end }}
+func (tpl *Template) FluxEvent(i interface{}) (*vendors.FluxEvent, error) {
+
+	d, err := tpl.payloadBytes(i)
+	if err != nil {
+		return nil, err
+	}
+	return vendors.ParseFluxEvent(d)
+}
+
 // split is a version of strings.Split that can be piped
 func (tpl *Template) Split(sep, s string) ([]string, error) {
 	s = strings.TrimSpace(s)
@@ -1538,6 +1605,40 @@ func (tpl *Template) PagerDutySendNoteToIncident(params map[string]interface{})
 	return pagerDuty.CreateIncidentNote(noteOptions, createOptions)
 }
 
+func (tpl *Template) OpsgenieGetOnCalls(params map[string]interface{}) ([]byte, error) {
+
+	url, _ := params["url"].(string)
+	key, _ := params["key"].(string)
+	timeout, _ := params["timeout"].(int)
+	if timeout == 0 {
+		timeout = 5
+	}
+	insecure, _ := params["insecure"].(bool)
+
+	schedule, _ := params["schedule"].(string)
+	if utils.IsEmpty(schedule) {
+		return nil, fmt.Errorf("OpsgenieGetOnCalls err => %s", "schedule is empty")
+	}
+	scheduleType, _ := params["scheduleType"].(string)
+	date, _ := params["date"].(string)
+
+	opsgenieOptions := vendors.OpsgenieOptions{
+		URL:      url,
+		Key:      key,
+		Timeout:  timeout,
+		Insecure: insecure,
+	}
+
+	onCallsOptions := vendors.OpsgenieOnCallsOptions{
+		Schedule:     schedule,
+		ScheduleType: scheduleType,
+		Flat:         true,
+		Date:         date,
+	}
+
+	return vendors.NewOpsgenie(opsgenieOptions).GetOnCalls(onCallsOptions)
+}
+
 func (tpl *Template) PrometheusGet(params map[string]interface{}) ([]byte, error) {
 
 	if len(params) == 0 {
@@ -1770,28 +1871,48 @@ func (tpl *Template) GoogleCalendarDeleteEvents(params map[string]interface{}) (
 	return google.CalendarDeleteEvents(calendarOptions, calendarGetEventsOptions)
 }
 
-func (tpl *Template) SSHRun(params map[string]interface{}) ([]byte, error) {
+func (tpl *Template) sshOptions(params map[string]interface{}) (vendors.SSHOptions, error) {
 
 	user, _ := params["user"].(string)
 	host, _ := params["host"].(string)
+	port, _ := params["port"].(int)
 	command, _ := params["command"].(string)
 	key, _ := params["key"].(string)
+	passphrase, _ := params["passphrase"].(string)
+	password, _ := params["password"].(string)
+	agent, _ := params["agent"].(bool)
 	timeout, _ := params["timeout"].(int)
 	if timeout == 0 {
 		timeout = 40
 	}
 
-	privateKey, err := utils.Content(key)
-	if err != nil {
-		return nil, err
+	var privateKey []byte
+	if !utils.IsEmpty(key) {
+		k, err := utils.Content(key)
+		if err != nil {
+			return vendors.SSHOptions{}, err
+		}
+		privateKey = k
 	}
 
-	sshOptions := vendors.SSHOptions{
+	return vendors.SSHOptions{
 		User:       user,
 		Address:    host,
+		Port:       port,
 		PrivateKey: privateKey,
+		Passphrase: passphrase,
+		Password:   password,
+		Agent:      agent,
 		Command:    command,
 		Timeout:    timeout,
+	}, nil
+}
+
+func (tpl *Template) SSHRun(params map[string]interface{}) ([]byte, error) {
+
+	sshOptions, err := tpl.sshOptions(params)
+	if err != nil {
+		return nil, err
 	}
 
 	ssh := vendors.NewSSH(sshOptions)
@@ -1804,6 +1925,75 @@ func (tpl *Template) SSHRun(params map[string]interface{}) ([]byte, error) {
 
 }
 
+func (tpl *Template) SSHUpload(params map[string]interface{}) ([]byte, error) {
+
+	sshOptions, err := tpl.sshOptions(params)
+	if err != nil {
+		return nil, err
+	}
+
+	source, _ := params["source"].(string)
+	destination, _ := params["destination"].(string)
+	mode, _ := params["mode"].(string)
+
+	uploadOptions := vendors.SSHUploadOptions{
+		Source:      source,
+		Destination: destination,
+		Mode:        mode,
+	}
+
+	return vendors.NewSSH(sshOptions).Upload(sshOptions, uploadOptions)
+}
+
+func (tpl *Template) ExecRun(params map[string]interface{}) ([]byte, error) {
+
+	command, _ := params["command"].(string)
+	if utils.IsEmpty(command) {
+		return nil, fmt.Errorf("ExecRun err => %s", "command is empty")
+	}
+	dir, _ := params["dir"].(string)
+	stdin, _ := params["stdin"].(string)
+	shell, _ := params["shell"].(bool)
+	timeout, _ := params["timeout"].(int)
+	if timeout == 0 {
+		timeout = 40
+	}
+
+	toStrings := func(v interface{}) []string {
+		r := []string{}
+		switch t := v.(type) {
+		case []string:
+			r = t
+		case []interface{}:
+			for _, i := range t {
+				r = append(r, fmt.Sprintf("%v", i))
+			}
+		case string:
+			r = strings.Fields(t)
+		}
+		return r
+	}
+
+	execOptions := vendors.ExecOptions{
+		Command: command,
+		Args:    toStrings(params["args"]),
+		Shell:   shell,
+		Dir:     dir,
+		Env:     toStrings(params["env"]),
+		Stdin:   stdin,
+		Timeout: timeout,
+		Redact:  toStrings(params["redact"]),
+	}
+
+	noerror, _ := params["noerror"].(bool)
+
+	d, err := vendors.NewExec(execOptions, tpl.logger).Run()
+	if noerror {
+		err = nil
+	}
+	return d, err
+}
+
 func (tpl *Template) ListFilesWithModTime(rootDir string) (map[string]string, error) {
 	filesMap := make(map[string]string)
 
@@ -2090,6 +2280,11 @@ func (tpl *Template) setTemplateFuncs(funcs map[string]any) {
 	funcs["toJSON"] = tpl.ToJson // deprecated
 	funcs["toJson"] = tpl.ToJson
 	funcs["fromJson"] = tpl.FromJson
+	funcs["alertmanagerWebhook"] = tpl.AlertmanagerWebhook
+	funcs["grafanaWebhook"] = tpl.GrafanaWebhook
+	funcs["sentryWebhook"] = tpl.SentryWebhook
+	funcs["harborWebhook"] = tpl.HarborWebhook
+	funcs["fluxEvent"] = tpl.FluxEvent
 	funcs["split"] = tpl.Split
 	funcs["join"] = tpl.Join
 	funcs["isEmpty"] = tpl.IsEmpty
@@ -2141,6 +2336,8 @@ func (tpl *Template) setTemplateFuncs(funcs map[string]any) {
 	funcs["googleCalendarInsertEvent"] = tpl.GoogleCalendarInsertEvent
 	funcs["googleCalendarDeleteEvents"] = tpl.GoogleCalendarDeleteEvents
 	funcs["sshRun"] = tpl.SSHRun
+	funcs["sshUpload"] = tpl.SSHUpload
+	funcs["execRun"] = tpl.ExecRun
 	funcs["listFilesWithModTime"] = tpl.ListFilesWithModTime
 	funcs["vmRestart"] = tpl.VMRestart
 	funcs["vmStart"] = tpl.VMStart
@@ -2148,6 +2345,7 @@ func (tpl *Template) setTemplateFuncs(funcs map[string]any) {
 	funcs["vmStatus"] = tpl.VMStatus
 
 	funcs["prometheusGet"] = tpl.PrometheusGet
+	funcs["opsgenieGetOnCalls"] = tpl.OpsgenieGetOnCalls
 }
 
 func (tpl *Template) filterFuncsByContent(funcs map[string]any, content string) map[string]any {
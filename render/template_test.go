@@ -0,0 +1,92 @@
+package render
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// templateFuzzContents have functions which parse payloads, they are rendered with payloads of webhooks,
+// functions doing requests or commands are excluded as they aren't given user input by pipelines
+var templateFuzzContents = []string{
+	`{{ $a := alertmanagerWebhook . }}{{ range $a.Alerts }}{{ .Labels.alertname }} {{ .Status }}{{ end }}`,
+	`{{ $g := grafanaWebhook . }}{{ $g.Title }}`,
+	`{{ $s := sentryWebhook . }}{{ $s.Action }}`,
+	`{{ $h := harborWebhook . }}{{ $h.Type }}`,
+	`{{ $f := fluxEvent . }}{{ $f.Reason }}`,
+	`{{ gjson . "alerts.0.labels.severity" }} {{ jsonata . "alerts[0].labels" }} {{ toJson . }}`,
+	`{{ $m := fromJson (toJson .) }}{{ findKeys $m "name" "value" }} {{ regexMatchFindKeys $m "name" "va.*" }}`,
+	`{{ tagValue (toString .tags) "env" }} {{ timeFormat (toString .time) "2006-01-02" }} {{ timeNano (toString .time) }}`,
+	`{{ range (split "," (toString .list)) }}{{ toUpper . }} {{ jsonEscape . }} {{ escapeString . }}{{ end }}`,
+	`{{ if ifIP .host }}ip{{ end }} {{ if ifIPAndPort .host }}ip:port{{ end }} {{ ifDef .missing "default" }}`,
+	`{{ regexReplaceAll "[0-9]+" "N" (toString .message) }} {{ regexFindSubmatch "(\\w+)=(\\w+)" (toString .message) }}`,
+	`{{ $d := dateParse (toString .time) }}{{ durationBetween $d $d }}`,
+}
+
+func FuzzTextTemplateRender(f *testing.F) {
+
+	f.Add(`{"version":"4","status":"firing","alerts":[{"status":"firing","labels":{"alertname":"Down","severity":"critical"}}]}`)
+	f.Add(`{"status":"firing","title":"[FIRING:1] CPU","alerts":[{"status":"firing","labels":{"alertname":"CPU"},"values":{"A":1}}]}`)
+	f.Add(`{"action":"triggered","data":{"event":{"title":"panic","level":"error"}}}`)
+	f.Add(`{"project":"api","url":"https://sentry/1","event":{"title":"panic"}}`)
+	f.Add(`{"type":"PUSH_ARTIFACT","event_data":{"resources":[{"tag":"v1"}],"repository":{"name":"app"}}}`)
+	f.Add(`{"involvedObject":{"kind":"Kustomization","name":"apps"},"severity":"error","reason":"ReconciliationFailed"}`)
+	f.Add(`{"tags":"env:prod,team:ops","time":"2024-01-02T03:04:05Z","list":"a,b","host":"10.0.0.1:80","message":"a=b 42"}`)
+	f.Add(`"not an object"`)
+	f.Add(`[1,2,{"name":"value"}]`)
+	f.Add(`{"time":"1 Jan 2024 +","host":null,"tags":{}}`)
+
+	templates := []*TextTemplate{}
+	for _, content := range templateFuzzContents {
+		tpl, err := NewTextTemplate(TemplateOptions{Name: "fuzz", Content: content, TimeFormat: time.RFC3339}, nil)
+		if err != nil {
+			f.Fatal(err)
+		}
+		templates = append(templates, tpl)
+	}
+	f.Fuzz(func(t *testing.T, object string) {
+
+		var obj interface{}
+		if json.Unmarshal([]byte(object), &obj) != nil {
+			return
+		}
+		for _, tpl := range templates {
+			tpl.RenderObject(obj)
+			tpl.RenderObject(object)
+		}
+	})
+}
+
+// templates are parsed with all functions, so that malformed ones return errors
+func FuzzTextTemplateParse(f *testing.F) {
+
+	for _, content := range templateFuzzContents {
+		f.Add(content)
+	}
+	f.Add(`{{ range .Alerts }}{{ .Labels.alertname }}{{ end }}`)
+	f.Add(`{{ define "a" }}{{ template "a" . }}{{ end }}`)
+	f.Add(`{{ if }}`)
+	f.Add(`{{ .a | toJson | fromJson }`)
+
+	f.Fuzz(func(t *testing.T, content string) {
+		NewTextTemplate(TemplateOptions{Name: "fuzz", Content: content}, nil)
+		NewHtmlTemplate(TemplateOptions{Name: "fuzz", Content: content}, nil)
+	})
+}
+
+// dates are parsed from payloads and whois output in any format
+func FuzzDateParse(f *testing.F) {
+
+	f.Add("2024-01-02T03:04:05Z")
+	f.Add("2024-01-02 03:04:05 +0100 CET")
+	f.Add("Mon Jan  2 15:04:05 MST 2006")
+	f.Add("1704164645")
+	f.Add("02-Jan-2024")
+	f.Add("1 Jan 2024 +")
+	f.Add("12 Feb 2006, 19:17")
+
+	tpl := &Template{}
+	f.Fuzz(func(t *testing.T, s string) {
+		tpl.DateParse(s)
+	})
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+)
+
+// adminMaxBody is max size of notifications posted to admin endpoints
+const adminMaxBody = 1 << 20
+
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+)
+
+// Delivery is event sent by route to target, pending until target returns
+type Delivery struct {
+	ID       int64      `json:"id"`
+	Time     time.Time  `json:"time"`
+	Route    string     `json:"route"`
+	Target   string     `json:"target"`
+	Source   string     `json:"source"`
+	Type     string     `json:"type"`
+	Thread   string     `json:"thread,omitempty"`
+	Status   string     `json:"status"`
+	Error    string     `json:"error,omitempty"`
+	Duration float64    `json:"duration,omitempty"`
+	Receipts []*Receipt `json:"receipts,omitempty"`
+}
+
+// AdminThread is thread of route key, the next events of key are sent to it
+type AdminThread struct {
+	Key  string    `json:"key"`
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+}
+
+// copy copies delivery with its receipts, so that they can be encoded while trackers update them
+func (d *Delivery) copy() *Delivery {
+
+	c := *d
+	c.Receipts = nil
+	for _, r := range d.Receipts {
+		rc := *r
+		c.Receipts = append(c.Receipts, &rc)
+	}
+	return &c
+}
+
+func (s *Server) startDelivery(r *Route, e *Event, thread string) *Delivery {
+
+	s.deliveriesMutex.Lock()
+	defer s.deliveriesMutex.Unlock()
+
+	s.deliveryID++
+	d := &Delivery{
+		ID:     s.deliveryID,
+		Time:   time.Now(),
+		Route:  r.Name,
+		Target: r.Target,
+		Source: e.Source,
+		Type:   e.Type,
+		Thread: thread,
+		Status: DeliveryPending,
+	}
+	s.pending[d.ID] = d
+	return d
+}
+
+// finishDelivery moves delivery from pending to recent ones with receipts of its recipients,
+// only the last deliveries are kept
+func (s *Server) finishDelivery(d *Delivery, err error, receipts []*Receipt) {
+
+	s.deliveriesMutex.Lock()
+	defer s.deliveriesMutex.Unlock()
+
+	delete(s.pending, d.ID)
+	d.Duration = time.Since(d.Time).Seconds()
+	d.Status = DeliverySent
+	if err != nil {
+		d.Status = DeliveryFailed
+		d.Error = err.Error()
+	}
+	d.Receipts = receipts
+
+	limit := s.options.Deliveries
+	if limit <= 0 {
+		return
+	}
+	s.deliveries = append(s.deliveries, d)
+	if len(s.deliveries) > limit {
+		s.deliveries = s.deliveries[len(s.deliveries)-limit:]
+	}
+}
+
+// Pending returns deliveries being sent by targets, the oldest first
+func (s *Server) Pending() []*Delivery {
+
+	s.deliveriesMutex.Lock()
+	defer s.deliveriesMutex.Unlock()
+
+	r := make([]*Delivery, 0, len(s.pending))
+	for _, d := range s.pending {
+		r = append(r, d.copy())
+	}
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].ID < r[j].ID
+	})
+	return r
+}
+
+// Deliveries returns the last deliveries of status, or of any status if it's empty, the newest first
+func (s *Server) Deliveries(limit int, status string) []*Delivery {
+
+	s.deliveriesMutex.Lock()
+	defer s.deliveriesMutex.Unlock()
+
+	r := []*Delivery{}
+	for i := len(s.deliveries) - 1; i >= 0; i-- {
+		if limit > 0 && len(r) >= limit {
+			break
+		}
+		d := s.deliveries[i]
+		if !utils.IsEmpty(status) && d.Status != status {
+			continue
+		}
+		r = append(r, d.copy())
+	}
+	return r
+}
+
+// Threads returns threads which aren't expired, the latest used first
+func (s *Server) Threads() []*AdminThread {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	r := []*AdminThread{}
+	for k, t := range s.threads {
+		if time.Since(t.time) > serverThreadTTL {
+			continue
+		}
+		r = append(r, &AdminThread{Key: k, ID: t.id, Time: t.time})
+	}
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Time.After(r[j].Time)
+	})
+	return r
+}
+
+// admin checks bearer token and method, admin endpoints are disabled without token
+func (s *Server) admin(method string, fn func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if utils.IsEmpty(s.options.AdminToken) {
+			http.Error(w, "admin is disabled", http.StatusNotFound)
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.options.AdminToken)) != 1 {
+			s.logger.Warn("Server admin %s unauthorized request from %s", r.URL.Path, r.RemoteAddr)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != method {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		v, err := fn(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+// emailDSN updates receipts from delivery status notification, so that MTA can pipe notifications to it
+func (s *Server) emailDSN(r *http.Request) (interface{}, error) {
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, adminMaxBody))
+	if err != nil {
+		return nil, err
+	}
+	dsn, err := vendors.ParseEmailDSN(body)
+	if err != nil {
+		return nil, err
+	}
+	n := s.UpdateEmailDSN(dsn)
+	s.logger.Debug("Server email %s DSN updated %d receipts", dsn.EnvelopeID, n)
+	return map[string]int{"updated": n}, nil
+}
+
+func (s *Server) adminHandlers(mux *http.ServeMux) {
+
+	mux.HandleFunc("/admin/queue", s.admin(http.MethodGet, func(r *http.Request) (interface{}, error) {
+		return s.Pending(), nil
+	}))
+	mux.HandleFunc("/admin/threads", s.admin(http.MethodGet, func(r *http.Request) (interface{}, error) {
+		return s.Threads(), nil
+	}))
+	mux.HandleFunc("/admin/deliveries", s.admin(http.MethodGet, func(r *http.Request) (interface{}, error) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		return s.Deliveries(limit, r.URL.Query().Get("status")), nil
+	}))
+	mux.HandleFunc("/admin/receipts", s.admin(http.MethodGet, func(r *http.Request) (interface{}, error) {
+		q := r.URL.Query()
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		return s.Receipts(limit, q.Get("state"), q.Get("recipient")), nil
+	}))
+	mux.HandleFunc("/admin/receipts/email", s.admin(http.MethodPost, s.emailDSN))
+}
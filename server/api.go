@@ -0,0 +1,372 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// apiMessageParams are params of request which are message of target, the rest are params of target
+var apiMessageParams = []string{"message", "text"}
+
+// APIRoute is POST endpoint of target in API routes file, tokens are names of clients and their bearer tokens,
+// which may be secret references, e.g. env://CI_TOKEN, required are params which requests must have,
+// params are defaults of requests, concurrency limits requests being sent, the rest are rejected with 429
+type APIRoute struct {
+	Path        string            `yaml:"path"`
+	Target      string            `yaml:"target"`
+	Tokens      map[string]string `yaml:"tokens"`
+	Required    []string          `yaml:"required"`
+	Params      map[string]string `yaml:"params"`
+	Concurrency int               `yaml:"concurrency"`
+
+	slots chan struct{}
+}
+
+type fileAPIRoutes struct {
+	Routes []*APIRoute `yaml:"routes"`
+}
+
+// APIOptions of API, concurrency is of routes which don't set their own, body is limited by max body
+type APIOptions struct {
+	RoutesFile  string
+	Listen      string
+	Concurrency int
+	MaxBody     int64
+}
+
+// APIAudit is written as JSON line for each request, params are names only, so that secrets aren't logged
+type APIAudit struct {
+	Time     time.Time `json:"time"`
+	Remote   string    `json:"remote"`
+	Method   string    `json:"method"`
+	Path     string    `json:"path"`
+	Target   string    `json:"target,omitempty"`
+	Client   string    `json:"client,omitempty"`
+	Params   []string  `json:"params,omitempty"`
+	Status   int       `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Duration float64   `json:"duration"`
+}
+
+type apiError struct {
+	Error  string `json:"error"`
+	Status int    `json:"status,omitempty"`
+}
+
+// API exposes targets as REST endpoints, so that services send messages without running commands
+type API struct {
+	options    APIOptions
+	routes     map[string]*APIRoute
+	targets    map[string]Target
+	audit      io.Writer
+	auditMutex sync.Mutex
+	logger     common.Logger
+}
+
+// apiShutdownTimeout is time to finish requests being served
+const apiShutdownTimeout = 10 * time.Second
+
+func (a *API) AddTarget(name string, target Target) {
+	a.targets[name] = target
+}
+
+func (a *API) writeAudit(audit *APIAudit) {
+
+	if a.audit == nil {
+		return
+	}
+	b, err := json.Marshal(audit)
+	if err != nil {
+		a.logger.Error("API audit error: %s", err)
+		return
+	}
+
+	a.auditMutex.Lock()
+	defer a.auditMutex.Unlock()
+
+	a.audit.Write(append(b, '\n'))
+}
+
+func (a *API) writeError(w http.ResponseWriter, audit *APIAudit, code int, err error) {
+
+	audit.Status = code
+	audit.Error = err.Error()
+	e := &apiError{Error: err.Error()}
+
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) {
+		e.Status = apiErr.Status
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(e)
+}
+
+// client returns name of client of bearer token, all tokens are compared, so that timing doesn't tell them
+func (r *APIRoute) client(req *http.Request) (string, bool) {
+
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if utils.IsEmpty(token) {
+		return "", false
+	}
+	name := ""
+	for k, v := range r.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(v)) == 1 {
+			name = k
+		}
+	}
+	return name, !utils.IsEmpty(name)
+}
+
+// params returns message and params of JSON object of request over defaults of route,
+// values are strings, numbers and booleans, objects and arrays are JSON of them
+func (r *APIRoute) params(body []byte) (string, map[string]string, error) {
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(body, &values); err != nil {
+		return "", nil, fmt.Errorf("request is not JSON object: %s", err)
+	}
+
+	params := make(map[string]string)
+	for k, v := range r.Params {
+		params[k] = v
+	}
+	for k, v := range values {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			params[k] = v
+		case float64, bool:
+			params[k] = fmt.Sprintf("%v", v)
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "", nil, fmt.Errorf("param %s: %s", k, err)
+			}
+			params[k] = string(b)
+		}
+	}
+
+	missing := []string{}
+	for _, k := range r.Required {
+		if utils.IsEmpty(params[k]) {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) > 0 {
+		return "", nil, fmt.Errorf("no params %s", strings.Join(missing, ", "))
+	}
+
+	message := ""
+	for _, k := range apiMessageParams {
+		if m, ok := params[k]; ok {
+			if utils.IsEmpty(message) {
+				message = m
+			}
+			delete(params, k)
+		}
+	}
+	return message, params, nil
+}
+
+func (a *API) handler(route *APIRoute) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		start := time.Now()
+		audit := &APIAudit{Time: start, Remote: r.RemoteAddr, Method: r.Method, Path: r.URL.Path, Target: route.Target}
+		defer func() {
+			audit.Duration = time.Since(start).Seconds()
+			a.writeAudit(audit)
+		}()
+
+		if r.Method != http.MethodPost {
+			a.writeError(w, audit, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		client, ok := route.client(r)
+		if !ok {
+			a.logger.Warn("API %s unauthorized request from %s", r.URL.Path, r.RemoteAddr)
+			a.writeError(w, audit, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		audit.Client = client
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, a.options.MaxBody))
+		if err != nil {
+			a.writeError(w, audit, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		message, params, err := route.params(body)
+		if err != nil {
+			a.writeError(w, audit, http.StatusBadRequest, err)
+			return
+		}
+		for k := range params {
+			audit.Params = append(audit.Params, k)
+		}
+		sort.Strings(audit.Params)
+
+		select {
+		case route.slots <- struct{}{}:
+			defer func() { <-route.slots }()
+		default:
+			w.Header().Set("Retry-After", "1")
+			a.writeError(w, audit, http.StatusTooManyRequests, fmt.Errorf("route %s has %d requests in flight", route.Path, cap(route.slots)))
+			return
+		}
+
+		a.logger.Debug("API %s sending to %s for %s...", route.Path, route.Target, client)
+		b, err := a.targets[route.Target](params, message)
+		if err != nil {
+			a.logger.Error("API %s target %s error: %s", route.Path, route.Target, err)
+			a.writeError(w, audit, http.StatusBadGateway, err)
+			return
+		}
+
+		audit.Status = http.StatusOK
+		w.Header().Set("Content-Type", "application/json")
+		if !json.Valid(b) {
+			b, _ = json.Marshal(map[string]string{"response": string(b)})
+		}
+		w.Write(b)
+	}
+}
+
+// Run serves routes, health and metrics until context is done, requests being served are finished
+func (a *API) Run(ctx context.Context) error {
+
+	mux := http.NewServeMux()
+	for _, route := range a.routes {
+		if _, ok := a.targets[route.Target]; !ok {
+			return fmt.Errorf("route %s has unknown target %s", route.Path, route.Target)
+		}
+		mux.Handle(route.Path, a.handler(route))
+	}
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	mux.Handle("/metrics", common.MetricsHandler())
+
+	listener, err := net.Listen("tcp", a.options.Listen)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), apiShutdownTimeout)
+		defer cancel()
+		srv.Shutdown(sctx)
+	}()
+
+	a.logger.Info("API listening on %s with %d routes", listener.Addr(), len(a.routes))
+	err = srv.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		<-stopped
+		return nil
+	}
+	return err
+}
+
+func loadAPIRoutes(file string, concurrency int) (map[string]*APIRoute, error) {
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var fr fileAPIRoutes
+	if err := yaml.Unmarshal(b, &fr); err != nil {
+		return nil, err
+	}
+
+	routes := make(map[string]*APIRoute)
+	for i, r := range fr.Routes {
+		if r == nil {
+			continue
+		}
+		if utils.IsEmpty(r.Path) || !strings.HasPrefix(r.Path, "/") {
+			return nil, fmt.Errorf("route %d has no path starting with /", i)
+		}
+		if _, ok := routes[r.Path]; ok {
+			return nil, fmt.Errorf("route %s is duplicated", r.Path)
+		}
+		if utils.IsEmpty(r.Target) {
+			return nil, fmt.Errorf("route %s has no target", r.Path)
+		}
+		if len(r.Tokens) == 0 {
+			return nil, fmt.Errorf("route %s has no tokens", r.Path)
+		}
+		for k, v := range r.Tokens {
+			token, err := common.ResolveSecret(v)
+			if err != nil {
+				return nil, fmt.Errorf("route %s token %s: %s", r.Path, k, err)
+			}
+			if utils.IsEmpty(token) {
+				return nil, fmt.Errorf("route %s token %s is empty", r.Path, k)
+			}
+			r.Tokens[k] = token
+		}
+		if r.Concurrency <= 0 {
+			r.Concurrency = concurrency
+		}
+		if r.Concurrency <= 0 {
+			r.Concurrency = 1
+		}
+		r.slots = make(chan struct{}, r.Concurrency)
+		routes[r.Path] = r
+	}
+	if len(routes) == 0 {
+		return nil, errors.New("no API routes")
+	}
+	return routes, nil
+}
+
+// NewAPI returns API of routes file, audit records are written to audit if it's set
+func NewAPI(options APIOptions, audit io.Writer, logger common.Logger) (*API, error) {
+
+	if utils.IsEmpty(options.RoutesFile) {
+		return nil, errors.New("no API routes file")
+	}
+	if utils.IsEmpty(options.Listen) {
+		return nil, errors.New("no API listen address")
+	}
+	if options.MaxBody <= 0 {
+		options.MaxBody = adminMaxBody
+	}
+
+	routes, err := loadAPIRoutes(options.RoutesFile, options.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &API{
+		options: options,
+		routes:  routes,
+		targets: make(map[string]Target),
+		audit:   audit,
+		logger:  logger,
+	}, nil
+}
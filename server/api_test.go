@@ -0,0 +1,91 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testAPI(t *testing.T, routes string) (*API, *bytes.Buffer) {
+
+	file := filepath.Join(t.TempDir(), "routes.yaml")
+	if err := os.WriteFile(file, []byte(routes), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var audit bytes.Buffer
+	a, err := NewAPI(APIOptions{RoutesFile: file, Listen: ":0", Concurrency: 1}, &audit, benchLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, &audit
+}
+
+func TestAPIHandler(t *testing.T) {
+
+	t.Setenv("TEST_API_TOKEN", "t1")
+	a, audit := testAPI(t, "routes:\n  - path: /slack/message\n    target: slack\n    tokens:\n      ci: env://TEST_API_TOKEN\n    required: [channel, text]\n    params:\n      title: Deploy\n")
+
+	var sent map[string]string
+	a.AddTarget("slack", func(params map[string]string, message string) ([]byte, error) {
+		sent = params
+		sent["message"] = message
+		return []byte(`{"ok":true}`), nil
+	})
+	route := a.routes["/slack/message"]
+
+	tests := []struct {
+		token string
+		body  string
+		code  int
+	}{
+		{"", `{"channel":"C1","text":"Deployed"}`, http.StatusUnauthorized},
+		{"t2", `{"channel":"C1","text":"Deployed"}`, http.StatusUnauthorized},
+		{"t1", `{"channel":"C1"}`, http.StatusBadRequest},
+		{"t1", `["C1"]`, http.StatusBadRequest},
+		{"t1", `{"channel":"C1","text":"Deployed"}`, http.StatusOK},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/slack/message", strings.NewReader(test.body))
+		req.Header.Set("Authorization", "Bearer "+test.token)
+		w := httptest.NewRecorder()
+		a.handler(route).ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Fatalf("expected %d of %s, got %d %s", test.code, test.body, w.Code, w.Body)
+		}
+	}
+	if sent["channel"] != "C1" || sent["message"] != "Deployed" || sent["title"] != "Deploy" {
+		t.Fatalf("expected params of request and route, got %v", sent)
+	}
+
+	lines := strings.Split(strings.TrimSpace(audit.String()), "\n")
+	var last APIAudit
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil || len(lines) != len(tests) {
+		t.Fatalf("expected audit of each request, got %s", audit)
+	}
+	if last.Client != "ci" || last.Status != http.StatusOK || strings.Contains(audit.String(), "Deployed") {
+		t.Fatalf("expected audit of client without values of params, got %s", audit)
+	}
+}
+
+func TestAPIConcurrency(t *testing.T) {
+
+	a, _ := testAPI(t, "routes:\n  - path: /webhook\n    target: webhook\n    tokens:\n      ci: t1\n")
+	a.AddTarget("webhook", func(params map[string]string, message string) ([]byte, error) {
+		return nil, nil
+	})
+	route := a.routes["/webhook"]
+	route.slots <- struct{}{}
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"message":"test"}`))
+	req.Header.Set("Authorization", "Bearer t1")
+	w := httptest.NewRecorder()
+	a.handler(route).ServeHTTP(w, req)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 while route is busy, got %d", w.Code)
+	}
+}
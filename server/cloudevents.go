@@ -0,0 +1,295 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const cloudEventsSourceName = "cloudevents"
+
+type CloudEventsSourceOptions struct {
+	File string
+}
+
+// CloudEvent is JSON format of CloudEvents 1.0, extension attributes are kept as strings
+// https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/formats/json-format.md
+type CloudEvent struct {
+	SpecVersion     string            `json:"specversion"`
+	ID              string            `json:"id"`
+	Source          string            `json:"source"`
+	Type            string            `json:"type"`
+	Subject         string            `json:"subject,omitempty"`
+	Time            *time.Time        `json:"time,omitempty"`
+	DataContentType string            `json:"datacontenttype,omitempty"`
+	DataSchema      string            `json:"dataschema,omitempty"`
+	Data            interface{}       `json:"data,omitempty"`
+	Extensions      map[string]string `json:"extensions,omitempty"`
+}
+
+type CloudEventsSource struct {
+	options CloudEventsSourceOptions
+	logger  common.Logger
+}
+
+var cloudEventAttributes = map[string]bool{
+	"specversion":     true,
+	"id":              true,
+	"source":          true,
+	"type":            true,
+	"subject":         true,
+	"time":            true,
+	"datacontenttype": true,
+	"dataschema":      true,
+	"data":            true,
+	"data_base64":     true,
+}
+
+func cloudEventString(raw json.RawMessage) string {
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+func (ce *CloudEvent) UnmarshalJSON(b []byte) error {
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	for k := range m {
+		if k != strings.ToLower(k) {
+			return fmt.Errorf("cloud event attribute %s must be lower-case", k)
+		}
+	}
+
+	ce.SpecVersion = cloudEventString(m["specversion"])
+	ce.ID = cloudEventString(m["id"])
+	ce.Source = cloudEventString(m["source"])
+	ce.Type = cloudEventString(m["type"])
+	ce.Subject = cloudEventString(m["subject"])
+	ce.DataContentType = cloudEventString(m["datacontenttype"])
+	ce.DataSchema = cloudEventString(m["dataschema"])
+
+	if raw, ok := m["time"]; ok {
+		t, err := time.Parse(time.RFC3339Nano, cloudEventString(raw))
+		if err != nil {
+			return fmt.Errorf("cloud event time: %s", err)
+		}
+		ce.Time = &t
+	}
+
+	if raw, ok := m["data_base64"]; ok {
+		data, err := base64.StdEncoding.DecodeString(cloudEventString(raw))
+		if err != nil {
+			return fmt.Errorf("cloud event data_base64: %s", err)
+		}
+		ce.Data = string(data)
+	} else if raw, ok := m["data"]; ok {
+		if err := json.Unmarshal(raw, &ce.Data); err != nil {
+			return err
+		}
+	}
+
+	for k, raw := range m {
+		if cloudEventAttributes[k] {
+			continue
+		}
+		if ce.Extensions == nil {
+			ce.Extensions = make(map[string]string)
+		}
+		ce.Extensions[k] = cloudEventString(raw)
+	}
+	return nil
+}
+
+// Validate checks required attributes
+func (ce *CloudEvent) Validate() error {
+
+	if ce.SpecVersion != "1.0" {
+		return fmt.Errorf("unsupported cloud event specversion %q", ce.SpecVersion)
+	}
+	missing := []string{}
+	if utils.IsEmpty(ce.ID) {
+		missing = append(missing, "id")
+	}
+	if utils.IsEmpty(ce.Source) {
+		missing = append(missing, "source")
+	}
+	if utils.IsEmpty(ce.Type) {
+		missing = append(missing, "type")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("cloud event has no %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// DataString returns first non empty string field of JSON object data
+func (ce *CloudEvent) DataString(keys ...string) string {
+
+	m, ok := ce.Data.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	for _, k := range keys {
+		if s, ok := m[k].(string); ok && !utils.IsEmpty(s) {
+			return s
+		}
+	}
+	return ""
+}
+
+// Message is text of data if any, subject or type otherwise
+func (ce *CloudEvent) Message() string {
+
+	if s := ce.DataString("message", "text", "summary", "description"); !utils.IsEmpty(s) {
+		return s
+	}
+	if s, ok := ce.Data.(string); ok && !utils.IsEmpty(s) {
+		return s
+	}
+	if !utils.IsEmpty(ce.Subject) {
+		return ce.Subject
+	}
+	return ce.Type
+}
+
+// Event converts cloud event to server event, attributes and extensions become labels
+func (ce *CloudEvent) Event() *Event {
+
+	labels := map[string]string{
+		"id":     ce.ID,
+		"source": ce.Source,
+	}
+	if !utils.IsEmpty(ce.Subject) {
+		labels["subject"] = ce.Subject
+	}
+	for k, v := range ce.Extensions {
+		labels[k] = v
+	}
+
+	t := time.Now()
+	if ce.Time != nil {
+		t = *ce.Time
+	}
+	return &Event{
+		Source:  cloudEventsSourceName,
+		Type:    ce.Type,
+		Time:    t,
+		Message: ce.Message(),
+		Labels:  labels,
+		Data:    ce.Data,
+	}
+}
+
+// ReadCloudEvents decodes stream of JSON events or batches, e.g. one event per line,
+// invalid events are passed to fn with error so that caller decides to skip them
+func ReadCloudEvents(r io.Reader, fn func(ce *CloudEvent, err error) error) error {
+
+	decoder := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		raws := []json.RawMessage{raw}
+		if trimmed := strings.TrimSpace(string(raw)); strings.HasPrefix(trimmed, "[") {
+			raws = nil
+			if err := json.Unmarshal(raw, &raws); err != nil {
+				return err
+			}
+		}
+
+		for _, raw := range raws {
+			ce := &CloudEvent{}
+			err := json.Unmarshal(raw, ce)
+			if err == nil {
+				err = ce.Validate()
+			}
+			if err := fn(ce, err); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *CloudEventsSource) Name() string {
+	return cloudEventsSourceName
+}
+
+// Start sends events until input ends or context is done, reading isn't interruptible
+// so that reader sends events via own channel and Start never leaves it sending to events
+func (c *CloudEventsSource) Start(ctx context.Context, events chan<- *Event) error {
+
+	var r io.Reader = os.Stdin
+	if !utils.IsEmpty(c.options.File) && c.options.File != "-" {
+		f, err := os.Open(c.options.File)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	out := make(chan *Event)
+	done := make(chan error, 1)
+	go func() {
+		done <- ReadCloudEvents(r, func(ce *CloudEvent, err error) error {
+			if err != nil {
+				c.logger.Error("Cloud event skipped: %s", err)
+				return nil
+			}
+			select {
+			case out <- ce.Event():
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		})
+	}()
+
+	for {
+		select {
+		case e := <-out:
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return nil
+			}
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func NewCloudEventsSource(options CloudEventsSourceOptions, logger common.Logger) (*CloudEventsSource, error) {
+
+	if !utils.IsEmpty(options.File) && options.File != "-" {
+		if _, err := os.Stat(options.File); err != nil {
+			return nil, err
+		}
+	}
+	return &CloudEventsSource{
+		options: options,
+		logger:  logger,
+	}, nil
+}
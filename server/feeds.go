@@ -0,0 +1,361 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const (
+	feedsSourceName  = "feeds"
+	statusSourceName = "status"
+	feedsStateLimit  = 1000
+)
+
+type FeedsSourceOptions struct {
+	URLs      []string
+	Interval  int
+	StateFile string
+	Timeout   int
+	Insecure  bool
+}
+
+// status feeds of major providers, others could be set as name=url
+var statusProviders = map[string]string{
+	"aws":    "https://status.aws.amazon.com/rss/all.rss",
+	"gcp":    "https://status.cloud.google.com/en/feed.atom",
+	"azure":  "https://azurestatuscdn.azureedge.net/en-us/status/feed/",
+	"github": "https://www.githubstatus.com/history.atom",
+}
+
+type StatusSourceOptions struct {
+	Providers []string
+	Services  string
+	Interval  int
+	StateFile string
+	Timeout   int
+	Insecure  bool
+}
+
+type feedsRSS struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			GUID        string `xml:"guid"`
+			PubDate     string `xml:"pubDate"`
+			Description string `xml:"description"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type feedsAtom struct {
+	Title   string `xml:"title"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		ID        string `xml:"id"`
+		Updated   string `xml:"updated"`
+		Published string `xml:"published"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+	} `xml:"entry"`
+}
+
+type FeedEntry struct {
+	Feed      string `json:"feed"`
+	Title     string `json:"title"`
+	Link      string `json:"link"`
+	ID        string `json:"id"`
+	Published string `json:"published"`
+	Summary   string `json:"summary"`
+}
+
+// FeedsSource polls RSS and Atom feeds and sends new entries, seen entry IDs are kept in state file
+// so that restarts don't repost; entries existing at the first poll of a feed are only remembered
+type FeedsSource struct {
+	name      string
+	options   FeedsSourceOptions
+	providers map[string]string
+	filter    *regexp.Regexp
+	client    *http.Client
+	state     map[string][]string
+	logger    common.Logger
+}
+
+func (f *FeedsSource) Name() string {
+	return f.name
+}
+
+func (f *FeedsSource) parse(data []byte) ([]*FeedEntry, error) {
+
+	var probe struct {
+		XMLName xml.Name
+	}
+	err := xml.Unmarshal(data, &probe)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*FeedEntry{}
+	switch probe.XMLName.Local {
+	case "rss":
+		var rss feedsRSS
+		err = xml.Unmarshal(data, &rss)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range rss.Channel.Items {
+			id := i.GUID
+			if utils.IsEmpty(id) {
+				id = i.Link
+			}
+			entries = append(entries, &FeedEntry{
+				Feed:      strings.TrimSpace(rss.Channel.Title),
+				Title:     strings.TrimSpace(i.Title),
+				Link:      strings.TrimSpace(i.Link),
+				ID:        strings.TrimSpace(id),
+				Published: i.PubDate,
+				Summary:   strings.TrimSpace(i.Description),
+			})
+		}
+	case "feed":
+		var atom feedsAtom
+		err = xml.Unmarshal(data, &atom)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range atom.Entries {
+			link := ""
+			for _, l := range e.Links {
+				if utils.IsEmpty(link) || l.Rel == "alternate" {
+					link = l.Href
+				}
+			}
+			published := e.Published
+			if utils.IsEmpty(published) {
+				published = e.Updated
+			}
+			summary := e.Summary
+			if utils.IsEmpty(summary) {
+				summary = e.Content
+			}
+			entries = append(entries, &FeedEntry{
+				Feed:      strings.TrimSpace(atom.Title),
+				Title:     strings.TrimSpace(e.Title),
+				Link:      strings.TrimSpace(link),
+				ID:        strings.TrimSpace(e.ID),
+				Published: published,
+				Summary:   strings.TrimSpace(summary),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported feed %s", probe.XMLName.Local)
+	}
+	return entries, nil
+}
+
+func (f *FeedsSource) loadState() {
+
+	if utils.IsEmpty(f.options.StateFile) {
+		return
+	}
+	data, err := os.ReadFile(f.options.StateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			f.logger.Warn("Feeds state error: %s", err)
+		}
+		return
+	}
+	err = json.Unmarshal(data, &f.state)
+	if err != nil {
+		f.logger.Warn("Feeds state error: %s", err)
+	}
+}
+
+func (f *FeedsSource) saveState() {
+
+	if utils.IsEmpty(f.options.StateFile) {
+		return
+	}
+	data, err := json.Marshal(f.state)
+	if err != nil {
+		f.logger.Warn("Feeds state error: %s", err)
+		return
+	}
+	// write and rename, so that state isn't lost if process is killed while writing
+	tmp := f.options.StateFile + ".tmp"
+	err = os.WriteFile(tmp, data, 0600)
+	if err == nil {
+		err = os.Rename(tmp, f.options.StateFile)
+	}
+	if err != nil {
+		f.logger.Warn("Feeds state error: %s", err)
+	}
+}
+
+// poll returns new entries of feed in publishing order and updates state
+func (f *FeedsSource) poll(url string) ([]*FeedEntry, error) {
+
+	data, err := utils.HttpGetRaw(f.client, url, "", "")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := f.parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	seen, known := f.state[url]
+	ids := make(map[string]bool)
+	for _, id := range seen {
+		ids[id] = true
+	}
+
+	// feeds list newest entries first
+	r := []*FeedEntry{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if utils.IsEmpty(e.ID) || ids[e.ID] {
+			continue
+		}
+		ids[e.ID] = true
+		seen = append(seen, e.ID)
+		if f.filter != nil && !f.filter.MatchString(fmt.Sprintf("%s %s %s", e.Title, e.Summary, e.ID)) {
+			continue
+		}
+		if known {
+			r = append(r, e)
+		}
+	}
+	if len(seen) > feedsStateLimit {
+		seen = seen[len(seen)-feedsStateLimit:]
+	}
+	f.state[url] = seen
+	return r, nil
+}
+
+func (f *FeedsSource) Start(ctx context.Context, events chan<- *Event) error {
+
+	interval := time.Duration(f.options.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	f.loadState()
+	defer f.saveState()
+
+	for {
+		for _, url := range f.options.URLs {
+
+			entries, err := f.poll(url)
+			if err != nil {
+				f.logger.Warn("Feeds %s error: %s", url, err)
+				continue
+			}
+			for _, e := range entries {
+				labels := map[string]string{
+					"feed":      e.Feed,
+					"url":       url,
+					"title":     e.Title,
+					"link":      e.Link,
+					"id":        e.ID,
+					"published": e.Published,
+				}
+				if provider, ok := f.providers[url]; ok {
+					labels["provider"] = provider
+				}
+				select {
+				case events <- &Event{
+					Source:  f.name,
+					Type:    "Entry",
+					Time:    time.Now(),
+					Message: fmt.Sprintf("%s %s", e.Title, e.Link),
+					Labels:  labels,
+					Data:    e,
+				}:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+		f.saveState()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+func NewFeedsSource(options FeedsSourceOptions, logger common.Logger) (*FeedsSource, error) {
+
+	options.URLs = common.RemoveEmptyStrings(options.URLs)
+	if len(options.URLs) == 0 {
+		return nil, errors.New("no feeds URLs")
+	}
+
+	return &FeedsSource{
+		name:      feedsSourceName,
+		options:   options,
+		providers: make(map[string]string),
+		client:    common.NewHttpClient(options.Timeout, options.Insecure),
+		state:     make(map[string][]string),
+		logger:    logger,
+	}, nil
+}
+
+// NewStatusSource is feeds source of provider status pages, services regexp filters entries by title, summary and ID,
+// e.g. "eu-central-1|Frankfurt"
+func NewStatusSource(options StatusSourceOptions, logger common.Logger) (*FeedsSource, error) {
+
+	providers := make(map[string]string)
+	urls := []string{}
+	for _, p := range common.RemoveEmptyStrings(options.Providers) {
+		name, url, ok := strings.Cut(strings.TrimSpace(p), "=")
+		if !ok {
+			url, ok = statusProviders[strings.ToLower(name)]
+			if !ok {
+				return nil, fmt.Errorf("unknown status provider %s", name)
+			}
+		}
+		providers[url] = name
+		urls = append(urls, url)
+	}
+
+	f, err := NewFeedsSource(FeedsSourceOptions{
+		URLs:      urls,
+		Interval:  options.Interval,
+		StateFile: options.StateFile,
+		Timeout:   options.Timeout,
+		Insecure:  options.Insecure,
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+	f.name = statusSourceName
+	f.providers = providers
+
+	if !utils.IsEmpty(options.Services) {
+		f.filter, err = regexp.Compile(options.Services)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
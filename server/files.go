@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+	"github.com/fsnotify/fsnotify"
+)
+
+const filesSourceName = "files"
+
+type FilesSourceOptions struct {
+	Paths    []string
+	Pattern  string
+	Ops      []string
+	Debounce int
+}
+
+// FilesSource watches files and directories (not recursive), events for the same path are debounced
+// so that a file being written produces one notification
+type FilesSource struct {
+	options FilesSourceOptions
+	ops     map[string]bool
+	logger  common.Logger
+
+	mutex   sync.Mutex
+	pending map[string]*filesPending
+	wg      sync.WaitGroup
+}
+
+type filesPending struct {
+	timer *time.Timer
+	op    string
+}
+
+func (f *FilesSource) Name() string {
+	return filesSourceName
+}
+
+func (f *FilesSource) op(op fsnotify.Op) string {
+
+	switch {
+	case op.Has(fsnotify.Create):
+		return "create"
+	case op.Has(fsnotify.Write):
+		return "write"
+	case op.Has(fsnotify.Remove):
+		return "remove"
+	case op.Has(fsnotify.Rename):
+		return "rename"
+	case op.Has(fsnotify.Chmod):
+		return "chmod"
+	}
+	return ""
+}
+
+func (f *FilesSource) event(name, op string) *Event {
+
+	labels := map[string]string{
+		"path": name,
+		"name": filepath.Base(name),
+		"dir":  filepath.Dir(name),
+		"op":   op,
+	}
+	if fi, err := os.Stat(name); err == nil {
+		labels["size"] = fmt.Sprintf("%d", fi.Size())
+		labels["modified"] = fi.ModTime().Format(time.RFC3339)
+	}
+
+	return &Event{
+		Source:  filesSourceName,
+		Type:    strings.ToUpper(op[:1]) + op[1:],
+		Time:    time.Now(),
+		Message: fmt.Sprintf("File %s %s", name, op),
+		Labels:  labels,
+	}
+}
+
+func (f *FilesSource) send(ctx context.Context, events chan<- *Event, name, op string) {
+
+	debounce := time.Duration(f.options.Debounce) * time.Second
+	if debounce <= 0 {
+		select {
+		case events <- f.event(name, op):
+		case <-ctx.Done():
+		}
+		return
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	// create followed by writes is still reported as create
+	if p, ok := f.pending[name]; ok && p.timer.Stop() {
+		f.wg.Done()
+		if p.op == "create" && op == "write" {
+			op = "create"
+		}
+	}
+	p := &filesPending{op: op}
+	f.wg.Add(1)
+	p.timer = time.AfterFunc(debounce, func() {
+		defer f.wg.Done()
+		f.mutex.Lock()
+		if f.pending[name] == p {
+			delete(f.pending, name)
+		}
+		f.mutex.Unlock()
+		select {
+		case events <- f.event(name, p.op):
+		case <-ctx.Done():
+		}
+	})
+	f.pending[name] = p
+}
+
+// stop cancels pending events and waits for those being sent, so that events channel isn't used after Start
+func (f *FilesSource) stop() {
+
+	f.mutex.Lock()
+	for name, p := range f.pending {
+		if p.timer.Stop() {
+			f.wg.Done()
+		}
+		delete(f.pending, name)
+	}
+	f.mutex.Unlock()
+	f.wg.Wait()
+}
+
+func (f *FilesSource) Start(ctx context.Context, events chan<- *Event) error {
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	defer f.stop()
+
+	for _, p := range f.options.Paths {
+		err := watcher.Add(p)
+		if err != nil {
+			return fmt.Errorf("Files watch %s: %s", p, err)
+		}
+		f.logger.Debug("Files watching %s...", p)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			f.logger.Warn("Files watch error: %s", err)
+		case e, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			op := f.op(e.Op)
+			if !f.ops[op] {
+				continue
+			}
+			if !utils.IsEmpty(f.options.Pattern) {
+				matched, err := filepath.Match(f.options.Pattern, filepath.Base(e.Name))
+				if err != nil || !matched {
+					continue
+				}
+			}
+			f.send(ctx, events, e.Name, op)
+		}
+	}
+}
+
+func NewFilesSource(options FilesSourceOptions, logger common.Logger) (*FilesSource, error) {
+
+	options.Paths = common.RemoveEmptyStrings(options.Paths)
+	if len(options.Paths) == 0 {
+		return nil, fmt.Errorf("no files paths")
+	}
+	if !utils.IsEmpty(options.Pattern) {
+		if _, err := filepath.Match(options.Pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	ops := make(map[string]bool)
+	for _, op := range common.RemoveEmptyStrings(options.Ops) {
+		ops[strings.ToLower(strings.TrimSpace(op))] = true
+	}
+	if len(ops) == 0 {
+		ops["create"] = true
+		ops["write"] = true
+	}
+
+	return &FilesSource{
+		options: options,
+		ops:     ops,
+		logger:  logger,
+		pending: make(map[string]*filesPending),
+	}, nil
+}
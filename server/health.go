@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/devopsext/tools/common"
+)
+
+// Check verifies that target is able to deliver, e.g. its token is valid
+type Check func() error
+
+type serverCheck struct {
+	check Check
+	err   error
+	time  time.Time
+}
+
+type serverHealth struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// serverShutdownTimeout is time to finish requests being served
+const serverShutdownTimeout = 5 * time.Second
+
+// AddCheck adds readiness check of target, it's used if target is in routes and checks are enabled
+func (s *Server) AddCheck(target string, check Check) {
+	s.checks[target] = &serverCheck{check: check}
+}
+
+// ready runs checks of targets in routes, results are kept for check interval
+// so that probes don't call vendors every time
+func (s *Server) ready() (bool, map[string]string) {
+
+	r := make(map[string]string)
+	if !s.options.Checks {
+		return true, r
+	}
+	interval := time.Duration(s.options.CheckInterval) * time.Second
+
+	s.checksMutex.Lock()
+	defer s.checksMutex.Unlock()
+
+	ok := true
+	for _, route := range s.getRoutes() {
+		c, exists := s.checks[route.Target]
+		if !exists {
+			continue
+		}
+		if _, done := r[route.Target]; done {
+			continue
+		}
+		if c.time.IsZero() || time.Since(c.time) > interval {
+			c.err = c.check()
+			c.time = time.Now()
+			if c.err != nil {
+				s.logger.Warn("Server target %s check error: %s", route.Target, c.err)
+			}
+		}
+		r[route.Target] = "ok"
+		if c.err != nil {
+			r[route.Target] = c.err.Error()
+			ok = false
+		}
+	}
+	return ok, r
+}
+
+func (s *Server) writeHealth(w http.ResponseWriter, ok bool, checks map[string]string) {
+
+	h := &serverHealth{Status: "ok", Checks: checks}
+	code := http.StatusOK
+	if !ok {
+		h.Status = "failed"
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(h)
+}
+
+// healthz is ok while server is running
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	s.writeHealth(w, true, nil)
+}
+
+// readyz is ok when sources are started and targets pass their checks
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+
+	if !s.started.Load() {
+		s.writeHealth(w, false, map[string]string{"sources": "not started"})
+		return
+	}
+	ok, checks := s.ready()
+	s.writeHealth(w, ok, checks)
+}
+
+// listen serves health, readiness, metrics and admin endpoints until context is done
+func (s *Server) listen(ctx context.Context) error {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.healthz)
+	mux.HandleFunc("/readyz", s.readyz)
+	mux.Handle("/metrics", common.MetricsHandler())
+	s.adminHandlers(mux)
+
+	listener, err := net.Listen("tcp", s.options.Listen)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+		defer cancel()
+		srv.Shutdown(sctx)
+	}()
+
+	s.logger.Info("Server listening on %s", listener.Addr())
+	err = srv.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		wg.Wait()
+		return nil
+	}
+	return err
+}
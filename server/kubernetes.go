@@ -0,0 +1,456 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const (
+	kubernetesSourceName       = "kubernetes"
+	kubernetesServiceAccount   = "/var/run/secrets/kubernetes.io/serviceaccount"
+	kubernetesReconnectTimeout = 5 * time.Second
+)
+
+type KubernetesSourceOptions struct {
+	URL           string
+	Token         string
+	Insecure      bool
+	Namespace     string
+	FieldSelector string
+	LabelSelector string
+	Events        bool
+	PodRestarts   bool
+	Rollouts      bool
+}
+
+type kubernetesMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	UID             string            `json:"uid"`
+	ResourceVersion string            `json:"resourceVersion"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+}
+
+type kubernetesList struct {
+	Metadata kubernetesMeta    `json:"metadata"`
+	Items    []json.RawMessage `json:"items"`
+}
+
+type kubernetesWatchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+type kubernetesStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type kubernetesEvent struct {
+	Metadata       kubernetesMeta `json:"metadata"`
+	InvolvedObject struct {
+		Kind      string `json:"kind"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"involvedObject"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Count   int    `json:"count"`
+	Source  struct {
+		Component string `json:"component"`
+		Host      string `json:"host"`
+	} `json:"source"`
+}
+
+type kubernetesContainerStatus struct {
+	Name         string `json:"name"`
+	RestartCount int    `json:"restartCount"`
+	LastState    struct {
+		Terminated *struct {
+			Reason   string `json:"reason"`
+			ExitCode int    `json:"exitCode"`
+		} `json:"terminated,omitempty"`
+	} `json:"lastState"`
+}
+
+type kubernetesPod struct {
+	Metadata kubernetesMeta `json:"metadata"`
+	Spec     struct {
+		NodeName string `json:"nodeName"`
+	} `json:"spec"`
+	Status struct {
+		ContainerStatuses []*kubernetesContainerStatus `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// KubernetesSource watches events and pod restarts via API server, in cluster config is used if URL is empty
+type KubernetesSource struct {
+	options  KubernetesSourceOptions
+	client   *http.Client
+	restarts map[string]int
+	rollouts map[string]string
+	logger   common.Logger
+}
+
+func (k *KubernetesSource) Name() string {
+	return kubernetesSourceName
+}
+
+func (k *KubernetesSource) url(resource string, params url.Values) (string, error) {
+
+	u, err := url.Parse(k.options.URL)
+	if err != nil {
+		return "", err
+	}
+	p := "/api/v1"
+	// resources of API groups are group/version/plural, e.g. argoproj.io/v1alpha1/rollouts
+	if parts := strings.Split(resource, "/"); len(parts) == 3 {
+		p = path.Join("/apis", parts[0], parts[1])
+		resource = parts[2]
+	}
+	if !utils.IsEmpty(k.options.Namespace) {
+		p = path.Join(p, "namespaces", k.options.Namespace)
+	}
+	u.Path = path.Join(u.Path, p, resource)
+	u.RawQuery = params.Encode()
+	return u.String(), nil
+}
+
+func (k *KubernetesSource) get(ctx context.Context, resource string, params url.Values) (*http.Response, error) {
+
+	u, err := k.url(resource, params)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.IsEmpty(k.options.Token) {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", k.options.Token))
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Kubernetes %s returned %s", resource, resp.Status)
+	}
+	return resp, nil
+}
+
+// list returns current resource version, so that watch doesn't replay existing objects
+func (k *KubernetesSource) list(ctx context.Context, resource, labelSelector string, fn func(item json.RawMessage)) (string, error) {
+
+	params := make(url.Values)
+	if !utils.IsEmpty(k.options.FieldSelector) && resource == "events" {
+		params.Add("fieldSelector", k.options.FieldSelector)
+	}
+	if !utils.IsEmpty(labelSelector) {
+		params.Add("labelSelector", labelSelector)
+	}
+
+	resp, err := k.get(ctx, resource, params)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var list kubernetesList
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	if err != nil {
+		return "", err
+	}
+	if fn != nil {
+		for _, item := range list.Items {
+			fn(item)
+		}
+	}
+	return list.Metadata.ResourceVersion, nil
+}
+
+// watch streams objects from resource version, returns empty version if it's expired
+func (k *KubernetesSource) watch(ctx context.Context, resource, labelSelector, version string, fn func(typ string, object json.RawMessage)) (string, error) {
+
+	params := make(url.Values)
+	params.Add("watch", "true")
+	params.Add("allowWatchBookmarks", "true")
+	params.Add("resourceVersion", version)
+	if !utils.IsEmpty(k.options.FieldSelector) && resource == "events" {
+		params.Add("fieldSelector", k.options.FieldSelector)
+	}
+	if !utils.IsEmpty(labelSelector) {
+		params.Add("labelSelector", labelSelector)
+	}
+
+	resp, err := k.get(ctx, resource, params)
+	if err != nil {
+		return version, err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var we kubernetesWatchEvent
+		err := decoder.Decode(&we)
+		if err != nil {
+			if ctx.Err() != nil {
+				return version, nil
+			}
+			return version, err
+		}
+
+		if we.Type == "ERROR" {
+			var status kubernetesStatus
+			json.Unmarshal(we.Object, &status)
+			if status.Code == http.StatusGone {
+				return "", nil
+			}
+			return version, fmt.Errorf("Kubernetes %s watch error: %s", resource, status.Message)
+		}
+
+		var object struct {
+			Metadata kubernetesMeta `json:"metadata"`
+		}
+		if json.Unmarshal(we.Object, &object) == nil && !utils.IsEmpty(object.Metadata.ResourceVersion) {
+			version = object.Metadata.ResourceVersion
+		}
+		if we.Type != "BOOKMARK" {
+			fn(we.Type, we.Object)
+		}
+	}
+}
+
+// loop lists and watches resource, reconnecting until context is done
+func (k *KubernetesSource) loop(ctx context.Context, resource, labelSelector string, seed func(item json.RawMessage), fn func(typ string, object json.RawMessage)) {
+
+	version := ""
+	for ctx.Err() == nil {
+
+		var err error
+		if utils.IsEmpty(version) {
+			version, err = k.list(ctx, resource, labelSelector, seed)
+		}
+		if err == nil {
+			version, err = k.watch(ctx, resource, labelSelector, version, fn)
+		}
+		if err != nil && ctx.Err() == nil {
+			k.logger.Warn("Kubernetes %s watch error: %s, reconnecting...", resource, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(kubernetesReconnectTimeout):
+			}
+		}
+	}
+}
+
+func (k *KubernetesSource) event(events chan<- *Event) func(typ string, object json.RawMessage) {
+
+	return func(typ string, object json.RawMessage) {
+
+		if typ != "ADDED" && typ != "MODIFIED" {
+			return
+		}
+		var ke kubernetesEvent
+		err := json.Unmarshal(object, &ke)
+		if err != nil {
+			k.logger.Warn("Kubernetes event error: %s", err)
+			return
+		}
+
+		events <- &Event{
+			Source:  kubernetesSourceName,
+			Type:    "Event",
+			Time:    time.Now(),
+			Message: ke.Message,
+			Labels: map[string]string{
+				"namespace": ke.InvolvedObject.Namespace,
+				"kind":      ke.InvolvedObject.Kind,
+				"name":      ke.InvolvedObject.Name,
+				"reason":    ke.Reason,
+				"type":      ke.Type,
+				"count":     strconv.Itoa(ke.Count),
+				"component": ke.Source.Component,
+				"host":      ke.Source.Host,
+			},
+			Data: ke,
+		}
+	}
+}
+
+func (k *KubernetesSource) restartKey(pod *kubernetesPod, cs *kubernetesContainerStatus) string {
+	return fmt.Sprintf("%s/%s", pod.Metadata.UID, cs.Name)
+}
+
+func (k *KubernetesSource) seedPod(item json.RawMessage) {
+
+	var pod kubernetesPod
+	if json.Unmarshal(item, &pod) != nil {
+		return
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		k.restarts[k.restartKey(&pod, cs)] = cs.RestartCount
+	}
+}
+
+func (k *KubernetesSource) pod(events chan<- *Event) func(typ string, object json.RawMessage) {
+
+	return func(typ string, object json.RawMessage) {
+
+		var pod kubernetesPod
+		err := json.Unmarshal(object, &pod)
+		if err != nil {
+			k.logger.Warn("Kubernetes pod error: %s", err)
+			return
+		}
+
+		for _, cs := range pod.Status.ContainerStatuses {
+
+			key := k.restartKey(&pod, cs)
+			if typ == "DELETED" {
+				delete(k.restarts, key)
+				continue
+			}
+
+			last, ok := k.restarts[key]
+			k.restarts[key] = cs.RestartCount
+			if !ok || cs.RestartCount <= last {
+				continue
+			}
+
+			reason := ""
+			exitCode := ""
+			if cs.LastState.Terminated != nil {
+				reason = cs.LastState.Terminated.Reason
+				exitCode = strconv.Itoa(cs.LastState.Terminated.ExitCode)
+			}
+
+			events <- &Event{
+				Source:  kubernetesSourceName,
+				Type:    "PodRestart",
+				Time:    time.Now(),
+				Message: fmt.Sprintf("Pod %s/%s container %s restarted (%d): %s", pod.Metadata.Namespace, pod.Metadata.Name, cs.Name, cs.RestartCount, reason),
+				Labels: map[string]string{
+					"namespace": pod.Metadata.Namespace,
+					"kind":      "Pod",
+					"name":      pod.Metadata.Name,
+					"container": cs.Name,
+					"reason":    reason,
+					"exitCode":  exitCode,
+					"restarts":  strconv.Itoa(cs.RestartCount),
+					"host":      pod.Spec.NodeName,
+				},
+				Data: pod,
+			}
+		}
+	}
+}
+
+func (k *KubernetesSource) Start(ctx context.Context, events chan<- *Event) error {
+
+	done := make(chan struct{}, 3)
+	n := 0
+
+	if k.options.Events {
+		n++
+		go func() {
+			k.loop(ctx, "events", "", nil, k.event(events))
+			done <- struct{}{}
+		}()
+	}
+	if k.options.PodRestarts {
+		n++
+		go func() {
+			k.loop(ctx, "pods", k.options.LabelSelector, k.seedPod, k.pod(events))
+			done <- struct{}{}
+		}()
+	}
+	if k.options.Rollouts {
+		n++
+		go func() {
+			k.loop(ctx, kubernetesRolloutsResource, k.options.LabelSelector, k.seedRollout, k.rollout(events))
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		<-done
+	}
+	return nil
+}
+
+func kubernetesInCluster(options *KubernetesSourceOptions, tlsConfig *tls.Config) error {
+
+	host := os.Getenv("KUBERNETES_SERVICE_HOST")
+	port := os.Getenv("KUBERNETES_SERVICE_PORT")
+	if utils.IsEmpty(host) || utils.IsEmpty(port) {
+		return fmt.Errorf("no Kubernetes URL and not in cluster")
+	}
+	options.URL = fmt.Sprintf("https://%s", net.JoinHostPort(host, port))
+
+	if utils.IsEmpty(options.Token) {
+		token, err := os.ReadFile(path.Join(kubernetesServiceAccount, "token"))
+		if err != nil {
+			return err
+		}
+		options.Token = strings.TrimSpace(string(token))
+	}
+
+	if !options.Insecure {
+		ca, err := os.ReadFile(path.Join(kubernetesServiceAccount, "ca.crt"))
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(ca)
+		tlsConfig.RootCAs = pool
+	}
+	return nil
+}
+
+func NewKubernetesSource(options KubernetesSourceOptions, logger common.Logger) (*KubernetesSource, error) {
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: options.Insecure}
+	if utils.IsEmpty(options.URL) {
+		err := kubernetesInCluster(&options, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// watches are long running, so there is no overall client timeout
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:               common.HttpProxy,
+			DialContext:         (&net.Dialer{Timeout: 30 * time.Second}).DialContext,
+			TLSHandshakeTimeout: 30 * time.Second,
+			TLSClientConfig:     tlsConfig,
+		},
+	}
+
+	return &KubernetesSource{
+		options:  options,
+		client:   client,
+		restarts: make(map[string]int),
+		rollouts: make(map[string]string),
+		logger:   logger,
+	}, nil
+}
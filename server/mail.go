@@ -0,0 +1,442 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+	"github.com/emersion/go-imap"
+	imapclient "github.com/emersion/go-imap/client"
+)
+
+const mailSourceName = "mail"
+
+type MailSourceOptions struct {
+	Protocol string
+	Address  string
+	TLS      bool
+	Insecure bool
+	User     string
+	Password string
+	Mailbox  string
+	Interval int
+	Delete   bool
+	Subject  string
+	Body     string
+}
+
+type MailAttachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int    `json:"size"`
+}
+
+type MailMessage struct {
+	From        string            `json:"from"`
+	To          string            `json:"to"`
+	Subject     string            `json:"subject"`
+	Date        string            `json:"date"`
+	Body        string            `json:"body"`
+	Attachments []*MailAttachment `json:"attachments,omitempty"`
+}
+
+// MailSource polls mailbox via IMAP (unseen messages) or POP3 and sends matched messages as events,
+// named groups of subject and body regexps become event labels
+type MailSource struct {
+	options MailSourceOptions
+	subject *regexp.Regexp
+	body    *regexp.Regexp
+	uidls   map[string]bool
+	logger  common.Logger
+}
+
+func (m *MailSource) Name() string {
+	return mailSourceName
+}
+
+func (m *MailSource) dial() (net.Conn, error) {
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	if m.options.TLS {
+		host, _, _ := net.SplitHostPort(m.options.Address)
+		return tls.DialWithDialer(dialer, "tcp", m.options.Address, &tls.Config{ServerName: host, InsecureSkipVerify: m.options.Insecure})
+	}
+	return dialer.Dial("tcp", m.options.Address)
+}
+
+func mailDecode(r io.Reader, encoding string) ([]byte, error) {
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	}
+	return io.ReadAll(r)
+}
+
+// mailParts walks through multipart tree, first text part is body, parts with file names are attachments
+func mailParts(msg *MailMessage, header textproto.MIMEHeader, r io.Reader) error {
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			err = mailParts(msg, p.Header, p)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := mailDecode(r, header.Get("Content-Transfer-Encoding"))
+	if err != nil {
+		return err
+	}
+
+	name := params["name"]
+	if _, dparams, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && !utils.IsEmpty(dparams["filename"]) {
+		name = dparams["filename"]
+	}
+	if !utils.IsEmpty(name) {
+		msg.Attachments = append(msg.Attachments, &MailAttachment{Name: name, ContentType: mediaType, Size: len(data)})
+		return nil
+	}
+
+	if utils.IsEmpty(msg.Body) && strings.HasPrefix(mediaType, "text/") {
+		msg.Body = string(data)
+	}
+	return nil
+}
+
+func (m *MailSource) parse(raw []byte) (*MailMessage, error) {
+
+	mm, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := new(mime.WordDecoder)
+	header := func(name string) string {
+		v := mm.Header.Get(name)
+		if d, err := decoder.DecodeHeader(v); err == nil {
+			return d
+		}
+		return v
+	}
+
+	msg := &MailMessage{
+		From:    header("From"),
+		To:      header("To"),
+		Subject: header("Subject"),
+		Date:    mm.Header.Get("Date"),
+	}
+	err = mailParts(msg, textproto.MIMEHeader(mm.Header), mm.Body)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func mailGroups(re *regexp.Regexp, s string, labels map[string]string) bool {
+
+	if re == nil {
+		return true
+	}
+	match := re.FindStringSubmatch(s)
+	if match == nil {
+		return false
+	}
+	for i, name := range re.SubexpNames() {
+		if i > 0 && !utils.IsEmpty(name) {
+			labels[name] = match[i]
+		}
+	}
+	return true
+}
+
+// event returns nil if message doesn't match subject or body regexps
+func (m *MailSource) event(raw []byte) *Event {
+
+	msg, err := m.parse(raw)
+	if err != nil {
+		m.logger.Warn("Mail parse error: %s", err)
+		return nil
+	}
+
+	names := []string{}
+	for _, a := range msg.Attachments {
+		names = append(names, a.Name)
+	}
+	labels := map[string]string{
+		"from":        msg.From,
+		"to":          msg.To,
+		"subject":     msg.Subject,
+		"date":        msg.Date,
+		"attachments": strings.Join(names, ","),
+	}
+	if !mailGroups(m.subject, msg.Subject, labels) || !mailGroups(m.body, msg.Body, labels) {
+		m.logger.Debug("Mail %s doesn't match", msg.Subject)
+		return nil
+	}
+
+	return &Event{
+		Source:  mailSourceName,
+		Type:    "Mail",
+		Time:    time.Now(),
+		Message: msg.Subject,
+		Labels:  labels,
+		Data:    msg,
+	}
+}
+
+// https://www.rfc-editor.org/rfc/rfc3501
+func (m *MailSource) pollIMAP(fn func(raw []byte)) error {
+
+	conn, err := m.dial()
+	if err != nil {
+		return err
+	}
+	c, err := imapclient.New(conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Logout()
+
+	err = c.Login(m.options.User, m.options.Password)
+	if err != nil {
+		return err
+	}
+	_, err = c.Select(m.options.Mailbox, false)
+	if err != nil {
+		return err
+	}
+
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+	ids, err := c.Search(criteria)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(ids...)
+
+	// fetching body without peek marks messages as seen
+	section := &imap.BodySectionName{}
+	messages := make(chan *imap.Message, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Fetch(seqset, []imap.FetchItem{section.FetchItem()}, messages)
+	}()
+	for msg := range messages {
+		if r := msg.GetBody(section); r != nil {
+			raw, err := io.ReadAll(r)
+			if err == nil {
+				fn(raw)
+			}
+		}
+	}
+	err = <-done
+	if err != nil {
+		return err
+	}
+
+	if m.options.Delete {
+		err = c.Store(seqset, imap.FormatFlagsOp(imap.AddFlags, true), []interface{}{imap.DeletedFlag}, nil)
+		if err != nil {
+			return err
+		}
+		return c.Expunge(nil)
+	}
+	return nil
+}
+
+func pop3Cmd(c *textproto.Conn, format string, args ...interface{}) (string, error) {
+
+	if !utils.IsEmpty(format) {
+		if err := c.PrintfLine(format, args...); err != nil {
+			return "", err
+		}
+	}
+	line, err := c.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return "", fmt.Errorf("POP3 %s", line)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "+OK")), nil
+}
+
+// https://www.rfc-editor.org/rfc/rfc1939
+// without delete, messages present at first poll are skipped and others are tracked by UIDL
+func (m *MailSource) pollPOP3(fn func(raw []byte)) error {
+
+	conn, err := m.dial()
+	if err != nil {
+		return err
+	}
+	c := textproto.NewConn(conn)
+	defer c.Close()
+
+	if _, err = pop3Cmd(c, ""); err != nil {
+		return err
+	}
+	if _, err = pop3Cmd(c, "USER %s", m.options.User); err != nil {
+		return err
+	}
+	if _, err = pop3Cmd(c, "PASS %s", m.options.Password); err != nil {
+		return err
+	}
+
+	if _, err = pop3Cmd(c, "UIDL"); err != nil {
+		return err
+	}
+	lines, err := c.ReadDotLines()
+	if err != nil {
+		return err
+	}
+
+	first := m.uidls == nil
+	if first {
+		m.uidls = make(map[string]bool)
+	}
+	current := make(map[string]bool)
+
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		n, uidl := fields[0], fields[1]
+		current[uidl] = true
+		if m.uidls[uidl] || (first && !m.options.Delete) {
+			m.uidls[uidl] = true
+			continue
+		}
+
+		if _, err = pop3Cmd(c, "RETR %s", n); err != nil {
+			return err
+		}
+		raw, err := io.ReadAll(c.DotReader())
+		if err != nil {
+			return err
+		}
+		fn(raw)
+		m.uidls[uidl] = true
+
+		if m.options.Delete {
+			if _, err = pop3Cmd(c, "DELE %s", n); err != nil {
+				return err
+			}
+		}
+	}
+
+	for uidl := range m.uidls {
+		if !current[uidl] {
+			delete(m.uidls, uidl)
+		}
+	}
+	_, err = pop3Cmd(c, "QUIT")
+	return err
+}
+
+func (m *MailSource) Start(ctx context.Context, events chan<- *Event) error {
+
+	interval := time.Duration(m.options.Interval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	fn := func(raw []byte) {
+		if e := m.event(raw); e != nil {
+			select {
+			case events <- e:
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	for {
+		var err error
+		if m.options.Protocol == "pop3" {
+			err = m.pollPOP3(fn)
+		} else {
+			err = m.pollIMAP(fn)
+		}
+		if err != nil {
+			m.logger.Warn("Mail %s poll error: %s", m.options.Protocol, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+func NewMailSource(options MailSourceOptions, logger common.Logger) (*MailSource, error) {
+
+	if utils.IsEmpty(options.Address) {
+		return nil, errors.New("no mail address")
+	}
+	options.Protocol = strings.ToLower(options.Protocol)
+	if options.Protocol != "imap" && options.Protocol != "pop3" {
+		return nil, fmt.Errorf("unsupported mail protocol %s", options.Protocol)
+	}
+	if utils.IsEmpty(options.Mailbox) {
+		options.Mailbox = "INBOX"
+	}
+
+	m := &MailSource{
+		options: options,
+		logger:  logger,
+	}
+
+	var err error
+	if !utils.IsEmpty(options.Subject) {
+		m.subject, err = regexp.Compile(options.Subject)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !utils.IsEmpty(options.Body) {
+		m.body, err = regexp.Compile(options.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
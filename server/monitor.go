@@ -0,0 +1,496 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	monitorSourceName = "monitor"
+	monitorWhoisIANA  = "whois.iana.org:43"
+
+	MonitorOK       = "ok"
+	MonitorWarning  = "warning"
+	MonitorCritical = "critical"
+)
+
+// whois servers format expiry differently, the first matched line is used
+var monitorWhoisExpiry = regexp.MustCompile(`(?im)^\s*(?:registry expiry date|registrar registration expiration date|expiration date|expiry date|expiration time|expires on|expires|expire|paid-till|renewal date)\s*:\s*(.+?)\s*$`)
+
+var monitorWhoisRefer = regexp.MustCompile(`(?im)^\s*(?:refer|whois)\s*:\s*(\S+)\s*$`)
+
+// MonitorCheck is configured in suite file, thresholds are days left for tls and whois checks
+type MonitorCheck struct {
+	Name     string   `yaml:"name" json:"name"`
+	Type     string   `yaml:"type" json:"type"`
+	Target   string   `yaml:"target" json:"target"`
+	Record   string   `yaml:"record" json:"record,omitempty"`
+	Expect   []string `yaml:"expect" json:"expect,omitempty"`
+	Server   string   `yaml:"server" json:"server,omitempty"`
+	Warning  int      `yaml:"warning" json:"warning,omitempty"`
+	Critical int      `yaml:"critical" json:"critical,omitempty"`
+}
+
+type monitorSuite struct {
+	Checks []*MonitorCheck `yaml:"checks"`
+}
+
+type MonitorSourceOptions struct {
+	SuiteFile  string
+	TLS        []string
+	Whois      []string
+	DNS        []string
+	DNSRecord  string
+	DNSServer  string
+	Warning    int
+	Critical   int
+	Interval   int
+	Timeout    int
+	Insecure   bool
+	Concurrent int
+}
+
+type MonitorResult struct {
+	Name    string     `json:"name"`
+	Type    string     `json:"type"`
+	Target  string     `json:"target"`
+	Status  string     `json:"status"`
+	Message string     `json:"message"`
+	Days    *int       `json:"days,omitempty"`
+	Expires *time.Time `json:"expires,omitempty"`
+	Values  []string   `json:"values,omitempty"`
+}
+
+// MonitorSource runs suite of TLS certificate expiry, domain expiry and DNS checks on schedule,
+// events are sent when check status changes, recovery is sent as Resolved
+type MonitorSource struct {
+	options MonitorSourceOptions
+	checks  []*MonitorCheck
+	states  map[string]string
+	logger  common.Logger
+}
+
+func (m *MonitorSource) Name() string {
+	return monitorSourceName
+}
+
+func (m *MonitorSource) Checks() []*MonitorCheck {
+	return m.checks
+}
+
+func (m *MonitorSource) dialer() *net.Dialer {
+	return &net.Dialer{Timeout: time.Duration(m.options.Timeout) * time.Second}
+}
+
+// expiry sets days and status by thresholds
+func (m *MonitorSource) expiry(c *MonitorCheck, r *MonitorResult, t time.Time) {
+
+	days := int(time.Until(t).Hours() / 24)
+	r.Days = &days
+	r.Expires = &t
+	r.Status = MonitorOK
+	switch {
+	case days <= c.Critical:
+		r.Status = MonitorCritical
+	case days <= c.Warning:
+		r.Status = MonitorWarning
+	}
+	r.Message = fmt.Sprintf("expires in %d days on %s", days, t.Format("2006-01-02"))
+}
+
+func (m *MonitorSource) checkTLS(c *MonitorCheck, r *MonitorResult) error {
+
+	address := c.Target
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "443")
+	}
+	host, _, _ := net.SplitHostPort(address)
+	serverName := host
+	if !utils.IsEmpty(c.Server) {
+		serverName = c.Server
+	}
+
+	config := &tls.Config{ServerName: serverName, InsecureSkipVerify: m.options.Insecure}
+	conn, err := tls.DialWithDialer(m.dialer(), "tcp", address, config)
+	verifyErr := error(nil)
+	if err != nil && !m.options.Insecure {
+		// certificate is still read to report expiry, but verification error makes it critical
+		var certErr *tls.CertificateVerificationError
+		if !errors.As(err, &certErr) {
+			return err
+		}
+		verifyErr = err
+		config.InsecureSkipVerify = true
+		conn, err = tls.DialWithDialer(m.dialer(), "tcp", address, config)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return errors.New("no peer certificates")
+	}
+	leaf := certs[0]
+	r.Values = []string{leaf.Subject.CommonName, leaf.Issuer.CommonName}
+	m.expiry(c, r, leaf.NotAfter)
+
+	if verifyErr != nil {
+		r.Status = MonitorCritical
+		r.Message = fmt.Sprintf("%s, %s", r.Message, verifyErr)
+	}
+	return nil
+}
+
+func (m *MonitorSource) whois(server, query string) (string, error) {
+
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "43")
+	}
+	conn, err := m.dialer().Dial("tcp", server)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if m.options.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(time.Duration(m.options.Timeout) * time.Second))
+	}
+
+	_, err = fmt.Fprintf(conn, "%s\r\n", query)
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(conn)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// https://www.rfc-editor.org/rfc/rfc3912
+// whois server of domain TLD is referred by IANA, unless it's set in check
+func (m *MonitorSource) checkWhois(c *MonitorCheck, r *MonitorResult) error {
+
+	domain := strings.ToLower(strings.TrimSuffix(c.Target, "."))
+	server := c.Server
+	if utils.IsEmpty(server) {
+		tld := domain[strings.LastIndex(domain, ".")+1:]
+		s, err := m.whois(monitorWhoisIANA, tld)
+		if err != nil {
+			return err
+		}
+		match := monitorWhoisRefer.FindStringSubmatch(s)
+		if match == nil {
+			return fmt.Errorf("no whois server for %s", tld)
+		}
+		server = match[1]
+	}
+
+	s, err := m.whois(server, domain)
+	if err != nil {
+		return err
+	}
+	match := monitorWhoisExpiry.FindStringSubmatch(s)
+	if match == nil {
+		return fmt.Errorf("no expiry date in whois of %s", server)
+	}
+	t, err := dateparse.ParseAny(match[1])
+	if err != nil {
+		return err
+	}
+	r.Values = []string{server}
+	m.expiry(c, r, t)
+	return nil
+}
+
+func (m *MonitorSource) resolver(c *MonitorCheck) *net.Resolver {
+
+	server := c.Server
+	if utils.IsEmpty(server) {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return m.dialer().DialContext(ctx, network, server)
+		},
+	}
+}
+
+func (m *MonitorSource) checkDNS(c *MonitorCheck, r *MonitorResult) error {
+
+	timeout := time.Duration(m.options.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	resolver := m.resolver(c)
+	values := []string{}
+	switch strings.ToUpper(c.Record) {
+	case "", "A", "AAAA":
+		network := "ip4"
+		if strings.ToUpper(c.Record) == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, c.Target)
+		if err != nil {
+			return err
+		}
+		for _, ip := range ips {
+			values = append(values, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, c.Target)
+		if err != nil {
+			return err
+		}
+		values = append(values, cname)
+	case "MX":
+		mxs, err := resolver.LookupMX(ctx, c.Target)
+		if err != nil {
+			return err
+		}
+		for _, mx := range mxs {
+			values = append(values, mx.Host)
+		}
+	case "NS":
+		nss, err := resolver.LookupNS(ctx, c.Target)
+		if err != nil {
+			return err
+		}
+		for _, ns := range nss {
+			values = append(values, ns.Host)
+		}
+	case "TXT":
+		txts, err := resolver.LookupTXT(ctx, c.Target)
+		if err != nil {
+			return err
+		}
+		values = append(values, txts...)
+	default:
+		return fmt.Errorf("unsupported DNS record %s", c.Record)
+	}
+	sort.Strings(values)
+	r.Values = values
+
+	if len(values) == 0 {
+		return errors.New("no DNS records")
+	}
+	found := make(map[string]bool)
+	for _, v := range values {
+		found[strings.TrimSuffix(strings.ToLower(v), ".")] = true
+	}
+	missing := []string{}
+	for _, e := range c.Expect {
+		if !found[strings.TrimSuffix(strings.ToLower(e), ".")] {
+			missing = append(missing, e)
+		}
+	}
+	if len(missing) > 0 {
+		r.Status = MonitorCritical
+		r.Message = fmt.Sprintf("missing %s in %s", strings.Join(missing, ", "), strings.Join(values, ", "))
+		return nil
+	}
+	r.Status = MonitorOK
+	r.Message = strings.Join(values, ", ")
+	return nil
+}
+
+// Check runs single check, failures to check are critical
+func (m *MonitorSource) Check(c *MonitorCheck) *MonitorResult {
+
+	r := &MonitorResult{
+		Name:   c.Name,
+		Type:   c.Type,
+		Target: c.Target,
+	}
+
+	var err error
+	switch c.Type {
+	case "tls":
+		err = m.checkTLS(c, r)
+	case "whois":
+		err = m.checkWhois(c, r)
+	case "dns":
+		err = m.checkDNS(c, r)
+	default:
+		err = fmt.Errorf("unsupported check type %s", c.Type)
+	}
+	if err != nil {
+		r.Status = MonitorCritical
+		r.Message = err.Error()
+	}
+	return r
+}
+
+// CheckAll runs checks concurrently and returns results in suite order
+func (m *MonitorSource) CheckAll() []*MonitorResult {
+
+	results := make([]*MonitorResult, len(m.checks))
+	limit := make(chan struct{}, m.options.Concurrent)
+	var wg sync.WaitGroup
+	for i, c := range m.checks {
+		wg.Add(1)
+		go func(i int, c *MonitorCheck) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			results[i] = m.Check(c)
+		}(i, c)
+	}
+	wg.Wait()
+	return results
+}
+
+func (m *MonitorSource) event(r *MonitorResult, previous string) *Event {
+
+	typ := strings.ToUpper(r.Status[:1]) + r.Status[1:]
+	if r.Status == MonitorOK {
+		typ = "Resolved"
+	}
+	labels := map[string]string{
+		"check":    r.Name,
+		"type":     r.Type,
+		"target":   r.Target,
+		"status":   r.Status,
+		"previous": previous,
+	}
+	if r.Days != nil {
+		labels["days"] = strconv.Itoa(*r.Days)
+		labels["expires"] = r.Expires.Format(time.RFC3339)
+	}
+	return &Event{
+		Source:  monitorSourceName,
+		Type:    typ,
+		Time:    time.Now(),
+		Message: fmt.Sprintf("%s %s %s: %s", r.Type, r.Target, r.Status, r.Message),
+		Labels:  labels,
+		Data:    r,
+	}
+}
+
+func (m *MonitorSource) Start(ctx context.Context, events chan<- *Event) error {
+
+	interval := time.Duration(m.options.Interval) * time.Second
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	for {
+		for _, r := range m.CheckAll() {
+
+			previous, ok := m.states[r.Name]
+			if !ok {
+				previous = MonitorOK
+			}
+			m.states[r.Name] = r.Status
+			if previous == r.Status {
+				m.logger.Debug("Monitor %s is %s: %s", r.Name, r.Status, r.Message)
+				continue
+			}
+
+			select {
+			case events <- m.event(r, previous):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+func loadMonitorChecks(file string) ([]*MonitorCheck, error) {
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var suite monitorSuite
+	err = yaml.Unmarshal(data, &suite)
+	if err != nil {
+		return nil, err
+	}
+	return suite.Checks, nil
+}
+
+func NewMonitorSource(options MonitorSourceOptions, logger common.Logger) (*MonitorSource, error) {
+
+	checks := []*MonitorCheck{}
+	if !utils.IsEmpty(options.SuiteFile) {
+		suite, err := loadMonitorChecks(options.SuiteFile)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, suite...)
+	}
+	for _, t := range common.RemoveEmptyStrings(options.TLS) {
+		checks = append(checks, &MonitorCheck{Type: "tls", Target: strings.TrimSpace(t)})
+	}
+	for _, d := range common.RemoveEmptyStrings(options.Whois) {
+		checks = append(checks, &MonitorCheck{Type: "whois", Target: strings.TrimSpace(d)})
+	}
+	for _, d := range common.RemoveEmptyStrings(options.DNS) {
+		checks = append(checks, &MonitorCheck{Type: "dns", Target: strings.TrimSpace(d), Record: options.DNSRecord, Server: options.DNSServer})
+	}
+	if len(checks) == 0 {
+		return nil, errors.New("no monitor checks")
+	}
+
+	names := make(map[string]bool)
+	for _, c := range checks {
+		c.Type = strings.ToLower(c.Type)
+		if utils.IsEmpty(c.Target) {
+			return nil, fmt.Errorf("monitor %s check has no target", c.Type)
+		}
+		if utils.IsEmpty(c.Name) {
+			c.Name = fmt.Sprintf("%s-%s", c.Type, c.Target)
+		}
+		if names[c.Name] {
+			return nil, fmt.Errorf("monitor check %s is duplicated", c.Name)
+		}
+		names[c.Name] = true
+		if c.Warning == 0 {
+			c.Warning = options.Warning
+		}
+		if c.Critical == 0 {
+			c.Critical = options.Critical
+		}
+	}
+	if options.Concurrent <= 0 {
+		options.Concurrent = 1
+	}
+
+	return &MonitorSource{
+		options: options,
+		checks:  checks,
+		states:  make(map[string]string),
+		logger:  logger,
+	}, nil
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+)
+
+const (
+	ReceiptSent      = "sent"
+	ReceiptDelivered = "delivered"
+	ReceiptRead      = "read"
+	ReceiptEdited    = "edited"
+	ReceiptDelayed   = "delayed"
+	ReceiptFailed    = "failed"
+)
+
+// Receipt is state of delivery to recipient, e.g. chat, channel or email address,
+// message ID is ID of vendor which is used to track it, e.g. Slack ts or Telegram message_id
+type Receipt struct {
+	Recipient string    `json:"recipient"`
+	MessageID string    `json:"messageId,omitempty"`
+	State     string    `json:"state"`
+	Time      time.Time `json:"time"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// AdminReceipt is receipt with delivery it belongs to
+type AdminReceipt struct {
+	Delivery int64  `json:"delivery"`
+	Route    string `json:"route"`
+	Target   string `json:"target"`
+	*Receipt
+}
+
+// Receipts returns receipts of recipients from target response
+type Receipts func(params map[string]string, response []byte) []*Receipt
+
+// Tracker returns the current state of receipt and its detail, e.g. read if message has reactions,
+// it's called until receipt is read or failed
+type Tracker func(receipt Receipt) (string, string, error)
+
+// serverReceiptTTL is how long receipts are tracked after delivery
+const serverReceiptTTL = 24 * time.Hour
+
+// AddReceipts adds receipts of target, delivery states are recorded for its recipients
+func (s *Server) AddReceipts(target string, receipts Receipts) {
+	s.receipts[target] = receipts
+}
+
+// AddTracker adds tracker of target receipts, it's called every track interval
+func (s *Server) AddTracker(target string, tracker Tracker) {
+	s.trackers[target] = tracker
+}
+
+func (s *Server) targetReceipts(target string, params map[string]string, response []byte) []*Receipt {
+
+	fn, ok := s.receipts[target]
+	if !ok {
+		return nil
+	}
+	receipts := fn(params, response)
+	for _, r := range receipts {
+		if r.Time.IsZero() {
+			r.Time = time.Now()
+		}
+	}
+	return receipts
+}
+
+func receiptFinal(state string) bool {
+	return state == ReceiptRead || state == ReceiptFailed
+}
+
+// UpdateReceipt sets state of receipt having message ID and recipient, recipients are compared ignoring case,
+// it returns false if there is no such receipt
+func (s *Server) UpdateReceipt(messageID, recipient, state, detail string) bool {
+
+	s.deliveriesMutex.Lock()
+	defer s.deliveriesMutex.Unlock()
+
+	updated := false
+	for _, d := range s.deliveries {
+		for _, r := range d.Receipts {
+			if r.MessageID != messageID || !strings.EqualFold(r.Recipient, recipient) {
+				continue
+			}
+			r.State = state
+			r.Detail = detail
+			r.Time = time.Now()
+			updated = true
+		}
+	}
+	return updated
+}
+
+// UpdateEmailDSN sets states of email receipts from delivery status notification
+func (s *Server) UpdateEmailDSN(dsn *vendors.EmailDSN) int {
+
+	// message ID of receipt has brackets, envelope ID doesn't
+	messageID := dsn.EnvelopeID
+	if !strings.HasPrefix(messageID, "<") {
+		messageID = "<" + messageID + ">"
+	}
+
+	n := 0
+	for _, r := range dsn.Recipients {
+		state := ReceiptDelivered
+		switch r.Action {
+		case "failed":
+			state = ReceiptFailed
+		case "delayed":
+			state = ReceiptDelayed
+		}
+		detail := r.Status
+		if !utils.IsEmpty(r.Diagnostic) {
+			detail = strings.TrimSpace(detail + " " + r.Diagnostic)
+		}
+		if s.UpdateReceipt(messageID, r.Recipient, state, detail) {
+			n++
+		}
+	}
+	return n
+}
+
+// Receipts returns receipts of the last deliveries having state, or any state if it's empty,
+// and recipient, or any recipient if it's empty, the newest first
+func (s *Server) Receipts(limit int, state, recipient string) []*AdminReceipt {
+
+	s.deliveriesMutex.Lock()
+	defer s.deliveriesMutex.Unlock()
+
+	r := []*AdminReceipt{}
+	for i := len(s.deliveries) - 1; i >= 0; i-- {
+		d := s.deliveries[i]
+		for _, rc := range d.Receipts {
+			if limit > 0 && len(r) >= limit {
+				return r
+			}
+			if !utils.IsEmpty(state) && rc.State != state {
+				continue
+			}
+			if !utils.IsEmpty(recipient) && !strings.EqualFold(rc.Recipient, recipient) {
+				continue
+			}
+			c := *rc
+			r = append(r, &AdminReceipt{Delivery: d.ID, Route: d.Route, Target: d.Target, Receipt: &c})
+		}
+	}
+	return r
+}
+
+type serverTrack struct {
+	receipt *Receipt
+	tracker Tracker
+	state   Receipt
+}
+
+// trackReceipts calls trackers of receipts which aren't final, trackers are called without lock
+// as they call vendors
+func (s *Server) trackReceipts() {
+
+	tracks := []*serverTrack{}
+	s.deliveriesMutex.Lock()
+	for _, d := range s.deliveries {
+		tracker, ok := s.trackers[d.Target]
+		if !ok || time.Since(d.Time) > serverReceiptTTL {
+			continue
+		}
+		for _, r := range d.Receipts {
+			if receiptFinal(r.State) {
+				continue
+			}
+			tracks = append(tracks, &serverTrack{receipt: r, tracker: tracker, state: *r})
+		}
+	}
+	s.deliveriesMutex.Unlock()
+
+	for _, t := range tracks {
+		state, detail, err := t.tracker(t.state)
+		if err != nil {
+			s.logger.Debug("Server receipt %s of %s track error: %s", t.state.MessageID, t.state.Recipient, err)
+			continue
+		}
+		if utils.IsEmpty(state) || (state == t.state.State && detail == t.state.Detail) {
+			continue
+		}
+		s.deliveriesMutex.Lock()
+		t.receipt.State = state
+		t.receipt.Detail = detail
+		t.receipt.Time = time.Now()
+		s.deliveriesMutex.Unlock()
+	}
+}
+
+// track tracks receipts every track interval until context is done
+func (s *Server) track(ctx context.Context) {
+
+	if s.options.TrackInterval <= 0 || len(s.trackers) == 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(s.options.TrackInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.trackReceipts()
+		}
+	}
+}
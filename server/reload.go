@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// serverReloadDebounce is time to wait for routes file writes to finish
+const serverReloadDebounce = time.Second
+
+// Reload loads routes file again, the previous routes are kept if it has errors,
+// events being routed are sent by routes they were matched with
+func (s *Server) Reload() error {
+
+	routes, err := loadRoutes(s.options.RoutesFile)
+	if err != nil {
+		return err
+	}
+
+	s.routesMutex.Lock()
+	s.routes = routes
+	s.routesMutex.Unlock()
+
+	s.logger.Info("Server reloaded %d routes of %s", len(routes), s.options.RoutesFile)
+	return nil
+}
+
+func (s *Server) getRoutes() []*Route {
+
+	s.routesMutex.RLock()
+	defer s.routesMutex.RUnlock()
+	return s.routes
+}
+
+// reload reloads routes on SIGHUP, and on changes of routes file if it's watched,
+// directory is watched as editors and Kubernetes config maps replace file rather than write it
+func (s *Server) reload(ctx context.Context) {
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events chan fsnotify.Event
+	var errs chan error
+	if s.options.Watch {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			s.logger.Error("Server routes watch error: %s", err)
+			return
+		}
+		defer watcher.Close()
+
+		dir := filepath.Dir(s.options.RoutesFile)
+		if err := watcher.Add(dir); err != nil {
+			s.logger.Error("Server routes watch %s error: %s", dir, err)
+			return
+		}
+		s.logger.Debug("Server watching routes file %s...", s.options.RoutesFile)
+		events = watcher.Events
+		errs = watcher.Errors
+	}
+
+	name := filepath.Base(s.options.RoutesFile)
+	timer := time.NewTimer(serverReloadDebounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			timer.Reset(0)
+		case err, ok := <-errs:
+			if ok {
+				s.logger.Warn("Server routes watch error: %s", err)
+			}
+		case e, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			// config map swaps ..data link to directory having new files
+			base := filepath.Base(e.Name)
+			if base != name && base != "..data" {
+				continue
+			}
+			timer.Reset(serverReloadDebounce)
+		case <-timer.C:
+			if err := s.Reload(); err != nil {
+				s.logger.Error("Server routes reload error: %s, previous routes are kept", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/utils"
+)
+
+const (
+	kubernetesRolloutsResource = "argoproj.io/v1alpha1/rollouts"
+	kubernetesRolloutRevision  = "rollout.argoproj.io/revision"
+)
+
+type kubernetesRolloutStep struct {
+	SetWeight *int `json:"setWeight,omitempty"`
+}
+
+// kubernetesRollout is part of Argo Rollouts resource which describes progressive delivery
+// https://argo-rollouts.readthedocs.io/en/stable/features/specification/
+type kubernetesRollout struct {
+	Metadata kubernetesMeta `json:"metadata"`
+	Spec     struct {
+		Strategy struct {
+			Canary *struct {
+				Steps []*kubernetesRolloutStep `json:"steps"`
+			} `json:"canary,omitempty"`
+		} `json:"strategy"`
+	} `json:"spec"`
+	Status struct {
+		Phase            string `json:"phase"`
+		Message          string `json:"message"`
+		Abort            bool   `json:"abort"`
+		CurrentStepIndex *int   `json:"currentStepIndex,omitempty"`
+		Canary           struct {
+			CurrentStepAnalysisRunStatus *struct {
+				Name    string `json:"name"`
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			} `json:"currentStepAnalysisRunStatus,omitempty"`
+			Weights *struct {
+				Canary struct {
+					Weight int `json:"weight"`
+				} `json:"canary"`
+			} `json:"weights,omitempty"`
+		} `json:"canary"`
+	} `json:"status"`
+}
+
+func (r *kubernetesRollout) revision() string {
+	return r.Metadata.Annotations[kubernetesRolloutRevision]
+}
+
+func (r *kubernetesRollout) steps() int {
+
+	if r.Spec.Strategy.Canary == nil {
+		return 0
+	}
+	return len(r.Spec.Strategy.Canary.Steps)
+}
+
+// weight is canary weight of traffic routing, or weight of the last passed setWeight step
+func (r *kubernetesRollout) weight() int {
+
+	if r.Status.Canary.Weights != nil {
+		return r.Status.Canary.Weights.Canary.Weight
+	}
+	if r.Spec.Strategy.Canary == nil || r.Status.CurrentStepIndex == nil {
+		return 0
+	}
+	steps := r.Spec.Strategy.Canary.Steps
+	if *r.Status.CurrentStepIndex >= len(steps) {
+		return 100
+	}
+	w := 0
+	for i := 0; i <= *r.Status.CurrentStepIndex; i++ {
+		if steps[i].SetWeight != nil {
+			w = *steps[i].SetWeight
+		}
+	}
+	return w
+}
+
+func (r *kubernetesRollout) analysis() (string, string) {
+
+	a := r.Status.Canary.CurrentStepAnalysisRunStatus
+	if a == nil {
+		return "", ""
+	}
+	return a.Name, a.Status
+}
+
+// state changes on new revision, step, weight, phase or analysis status, other updates aren't events
+func (r *kubernetesRollout) state() string {
+
+	step := ""
+	if r.Status.CurrentStepIndex != nil {
+		step = strconv.Itoa(*r.Status.CurrentStepIndex)
+	}
+	_, status := r.analysis()
+	return strings.Join([]string{r.revision(), step, strconv.Itoa(r.weight()), r.Status.Phase, status, strconv.FormatBool(r.Status.Abort)}, "/")
+}
+
+func (k *KubernetesSource) seedRollout(item json.RawMessage) {
+
+	var r kubernetesRollout
+	if json.Unmarshal(item, &r) != nil {
+		return
+	}
+	k.rollouts[r.Metadata.UID] = r.state()
+}
+
+// rollout sends progressive delivery updates, thread label is the same for all updates of revision,
+// so that routes can keep them in one chat thread
+func (k *KubernetesSource) rollout(events chan<- *Event) func(typ string, object json.RawMessage) {
+
+	return func(typ string, object json.RawMessage) {
+
+		var r kubernetesRollout
+		err := json.Unmarshal(object, &r)
+		if err != nil {
+			k.logger.Warn("Kubernetes rollout error: %s", err)
+			return
+		}
+		if typ == "DELETED" {
+			delete(k.rollouts, r.Metadata.UID)
+			return
+		}
+
+		state := r.state()
+		if k.rollouts[r.Metadata.UID] == state {
+			return
+		}
+		k.rollouts[r.Metadata.UID] = state
+
+		name := fmt.Sprintf("%s/%s", r.Metadata.Namespace, r.Metadata.Name)
+		labels := map[string]string{
+			"namespace": r.Metadata.Namespace,
+			"kind":      "Rollout",
+			"name":      r.Metadata.Name,
+			"revision":  r.revision(),
+			"phase":     r.Status.Phase,
+			"weight":    strconv.Itoa(r.weight()),
+			"steps":     strconv.Itoa(r.steps()),
+			"aborted":   strconv.FormatBool(r.Status.Abort),
+			"thread":    fmt.Sprintf("%s/%s", name, r.revision()),
+		}
+
+		line := fmt.Sprintf("Rollout %s revision %s", name, r.revision())
+		if r.Status.CurrentStepIndex != nil && r.steps() > 0 {
+			step := *r.Status.CurrentStepIndex
+			labels["step"] = strconv.Itoa(step)
+			line = fmt.Sprintf("%s step %d/%d canary weight %d%%", line, step, r.steps(), r.weight())
+		}
+		line = fmt.Sprintf("%s: %s", line, r.Status.Phase)
+		if r.Status.Abort {
+			line = fmt.Sprintf("%s, aborted", line)
+		}
+		lines := []string{line}
+		if analysis, status := r.analysis(); !utils.IsEmpty(analysis) {
+			labels["analysis"] = analysis
+			labels["analysis_status"] = status
+			lines = append(lines, fmt.Sprintf("Analysis %s: %s", analysis, status))
+		}
+		if !utils.IsEmpty(r.Status.Message) {
+			lines = append(lines, r.Status.Message)
+		}
+
+		events <- &Event{
+			Source:  kubernetesSourceName,
+			Type:    "Rollout",
+			Time:    time.Now(),
+			Message: strings.Join(lines, "\n"),
+			Labels:  labels,
+			Data:    r,
+		}
+	}
+}
@@ -0,0 +1,490 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Event is produced by a source and routed to targets
+type Event struct {
+	Source  string            `json:"source"`
+	Type    string            `json:"type"`
+	Time    time.Time         `json:"time"`
+	Message string            `json:"message"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Data    interface{}       `json:"data,omitempty"`
+}
+
+// Source watches something and sends events until context is done
+type Source interface {
+	Name() string
+	Start(ctx context.Context, events chan<- *Event) error
+}
+
+// Target sends rendered message, params come from route,
+// image param is URL of image to attach, it's set from image_url label of event if route has none,
+// thread param is ID of the first message of thread, e.g. Slack ts or Telegram message_id
+type Target func(params map[string]string, message string) ([]byte, error)
+
+// Route is configured in routes file, match values are regexps against event type and labels,
+// params may be templates of event, e.g. channel: "#{{ .Labels.team }}",
+// thread is template of key, events having the same key are sent to thread of the first one
+type Route struct {
+	Name     string            `yaml:"name"`
+	Source   string            `yaml:"source"`
+	Type     string            `yaml:"type"`
+	Match    map[string]string `yaml:"match"`
+	Target   string            `yaml:"target"`
+	Template string            `yaml:"template"`
+	Params   map[string]string `yaml:"params"`
+	Thread   string            `yaml:"thread"`
+
+	match    map[string]*regexp.Regexp
+	typ      *regexp.Regexp
+	template *template.Template
+	params   map[string]*template.Template
+	thread   *template.Template
+}
+
+type serverThread struct {
+	id   string
+	time time.Time
+}
+
+type fileRoutes struct {
+	Routes []*Route `yaml:"routes"`
+}
+
+// Options of server, routes file is reloaded on SIGHUP, and on its changes if it's watched,
+// listen address serves health, readiness and metrics endpoints, and admin endpoints if there is admin token
+type Options struct {
+	RoutesFile    string
+	Workers       int
+	Watch         bool
+	Listen        string
+	Checks        bool
+	CheckInterval int
+	AdminToken    string
+	Deliveries    int
+	TrackInterval int
+}
+
+type Server struct {
+	options     Options
+	routes      []*Route
+	routesMutex sync.RWMutex
+	sources     []Source
+	targets     map[string]Target
+	receipts    map[string]Receipts
+	trackers    map[string]Tracker
+	services    common.ServiceCatalog
+	threads     map[string]*serverThread
+	mutex       sync.Mutex
+	checks      map[string]*serverCheck
+	checksMutex sync.Mutex
+	started     atomic.Bool
+
+	deliveries      []*Delivery
+	pending         map[int64]*Delivery
+	deliveryID      int64
+	deliveriesMutex sync.Mutex
+
+	logger common.Logger
+}
+
+// serverThreadTTL is how long thread of key is kept after its last event
+const serverThreadTTL = 24 * time.Hour
+
+// targetChannelParams are params which address destination of targets, channel is used for others
+var targetChannelParams = map[string]string{
+	"telegram": "chat",
+	"email":    "to",
+	"twilio":   "to",
+	"sns":      "topic",
+	"sqs":      "queue",
+}
+
+func (s *Server) AddSource(source Source) {
+	s.sources = append(s.sources, source)
+}
+
+func (s *Server) AddTarget(name string, target Target) {
+	s.targets[name] = target
+}
+
+// SetServices enables routing to channel of service owning event, service is taken from service label
+func (s *Server) SetServices(services common.ServiceCatalog) {
+	s.services = services
+}
+
+// serviceChannel sets destination param from service catalog if route has no such
+func (s *Server) serviceChannel(target string, e *Event, params map[string]string) {
+
+	name := e.Labels["service"]
+	if s.services == nil || utils.IsEmpty(name) {
+		return
+	}
+	param, ok := targetChannelParams[target]
+	if !ok {
+		param = "channel"
+	}
+	if _, ok := params[param]; ok {
+		return
+	}
+	service, err := s.services.Get(name)
+	if err != nil {
+		s.logger.Debug("Server service %s error: %s", name, err)
+		return
+	}
+	if channel := service.Channel(target); !utils.IsEmpty(channel) {
+		params[param] = channel
+	}
+}
+
+func (r *Route) matches(e *Event) bool {
+
+	if !utils.IsEmpty(r.Source) && r.Source != e.Source {
+		return false
+	}
+	if r.typ != nil && !r.typ.MatchString(e.Type) {
+		return false
+	}
+	for k, re := range r.match {
+		if !re.MatchString(e.Labels[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Route) render(e *Event) (string, error) {
+
+	if r.template == nil {
+		return e.Message, nil
+	}
+	var b bytes.Buffer
+	err := r.template.Execute(&b, e)
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (r *Route) renderParams(e *Event) (map[string]string, error) {
+
+	params := make(map[string]string)
+	for k, v := range r.Params {
+		params[k] = v
+	}
+	for k, t := range r.params {
+		var b bytes.Buffer
+		if err := t.Execute(&b, e); err != nil {
+			return nil, fmt.Errorf("param %s: %s", k, err)
+		}
+		params[k] = b.String()
+	}
+	if _, ok := params["image"]; !ok && !utils.IsEmpty(e.Labels["image_url"]) {
+		params["image"] = e.Labels["image_url"]
+	}
+	return params, nil
+}
+
+func (r *Route) renderThread(e *Event) (string, error) {
+
+	if r.thread == nil {
+		return "", nil
+	}
+	var b bytes.Buffer
+	if err := r.thread.Execute(&b, e); err != nil {
+		return "", err
+	}
+	key := strings.TrimSpace(b.String())
+	if utils.IsEmpty(key) {
+		return "", nil
+	}
+	return fmt.Sprintf("%s/%s", r.Name, key), nil
+}
+
+func (s *Server) thread(key string) string {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	t, ok := s.threads[key]
+	if !ok || time.Since(t.time) > serverThreadTTL {
+		return ""
+	}
+	t.time = time.Now()
+	return t.id
+}
+
+// threadID returns ID of message in response, which is Slack message ts or Telegram message_id
+func threadID(response []byte) string {
+
+	var r struct {
+		TS     string `json:"ts"`
+		Result struct {
+			MessageID int64 `json:"message_id"`
+		} `json:"result"`
+	}
+	if json.Unmarshal(response, &r) != nil {
+		return ""
+	}
+	if !utils.IsEmpty(r.TS) {
+		return r.TS
+	}
+	if r.Result.MessageID > 0 {
+		return strconv.FormatInt(r.Result.MessageID, 10)
+	}
+	return ""
+}
+
+// setThread keeps thread of response, expired threads are removed
+func (s *Server) setThread(key string, response []byte) {
+
+	id := threadID(response)
+	if utils.IsEmpty(id) {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for k, t := range s.threads {
+		if time.Since(t.time) > serverThreadTTL {
+			delete(s.threads, k)
+		}
+	}
+	s.threads[key] = &serverThread{id: id, time: time.Now()}
+}
+
+// Route sends event to targets of all matched routes
+func (s *Server) Route(e *Event) {
+
+	matched := false
+	for _, r := range s.getRoutes() {
+
+		if !r.matches(e) {
+			continue
+		}
+		matched = true
+
+		target, ok := s.targets[r.Target]
+		if !ok {
+			s.logger.Warn("Server route %s has unknown target %s", r.Name, r.Target)
+			continue
+		}
+
+		message, err := r.render(e)
+		if err != nil {
+			s.logger.Error("Server route %s template error: %s", r.Name, err)
+			continue
+		}
+		params, err := r.renderParams(e)
+		if err != nil {
+			s.logger.Error("Server route %s template error: %s", r.Name, err)
+			continue
+		}
+		s.serviceChannel(r.Target, e, params)
+
+		thread, err := r.renderThread(e)
+		if err != nil {
+			s.logger.Error("Server route %s thread error: %s", r.Name, err)
+			continue
+		}
+		if !utils.IsEmpty(thread) && utils.IsEmpty(params["thread"]) {
+			params["thread"] = s.thread(thread)
+		}
+
+		s.logger.Debug("Server route %s sending %s event to %s...", r.Name, e.Source, r.Target)
+		d := s.startDelivery(r, e, thread)
+		b, err := target(params, message)
+		var receipts []*Receipt
+		if err == nil {
+			receipts = s.targetReceipts(r.Target, params, b)
+		}
+		s.finishDelivery(d, err, receipts)
+		if err != nil {
+			s.logger.Error("Server route %s target %s error: %s", r.Name, r.Target, err)
+			continue
+		}
+		if !utils.IsEmpty(thread) && utils.IsEmpty(params["thread"]) {
+			s.setThread(thread, b)
+		}
+	}
+	if !matched {
+		s.logger.Debug("Server %s event %s has no routes", e.Source, e.Type)
+	}
+}
+
+// Run starts all sources and routes their events until context is done
+func (s *Server) Run(ctx context.Context) error {
+
+	if len(s.sources) == 0 {
+		return errors.New("no server sources")
+	}
+
+	events := make(chan *Event)
+	var wg sync.WaitGroup
+
+	go s.reload(ctx)
+	go s.track(ctx)
+
+	// listener is stopped when sources are, even if they stop by themselves
+	lctx, lcancel := context.WithCancel(ctx)
+	defer lcancel()
+	listening := make(chan struct{})
+	if utils.IsEmpty(s.options.Listen) {
+		close(listening)
+	} else {
+		go func() {
+			defer close(listening)
+			if err := s.listen(lctx); err != nil {
+				s.logger.Error("Server listen error: %s", err)
+			}
+		}()
+	}
+
+	for _, source := range s.sources {
+		wg.Add(1)
+		go func(source Source) {
+			defer wg.Done()
+			s.logger.Info("Server source %s started", source.Name())
+			err := source.Start(ctx, events)
+			if err != nil && ctx.Err() == nil {
+				s.logger.Error("Server source %s error: %s", source.Name(), err)
+				return
+			}
+			s.logger.Info("Server source %s stopped", source.Name())
+		}(source)
+	}
+
+	s.started.Store(true)
+
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+
+	workers := s.options.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	var rwg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		rwg.Add(1)
+		go func() {
+			defer rwg.Done()
+			for e := range events {
+				s.Route(e)
+			}
+		}()
+	}
+	rwg.Wait()
+	lcancel()
+	<-listening
+	return nil
+}
+
+func loadRoutes(file string) ([]*Route, error) {
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var fr fileRoutes
+	err = yaml.Unmarshal(b, &fr)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := []*Route{}
+	for i, r := range fr.Routes {
+		if r == nil {
+			continue
+		}
+		if utils.IsEmpty(r.Name) {
+			r.Name = fmt.Sprintf("%s%d", r.Target, i)
+		}
+		if utils.IsEmpty(r.Target) {
+			return nil, fmt.Errorf("route %s has no target", r.Name)
+		}
+		if !utils.IsEmpty(r.Type) {
+			r.typ, err = regexp.Compile(r.Type)
+			if err != nil {
+				return nil, fmt.Errorf("route %s type: %s", r.Name, err)
+			}
+		}
+		r.match = make(map[string]*regexp.Regexp)
+		for k, v := range r.Match {
+			r.match[k], err = regexp.Compile(v)
+			if err != nil {
+				return nil, fmt.Errorf("route %s match %s: %s", r.Name, k, err)
+			}
+		}
+		if !utils.IsEmpty(r.Template) {
+			r.template, err = template.New(r.Name).Option("missingkey=zero").Parse(r.Template)
+			if err != nil {
+				return nil, fmt.Errorf("route %s template: %s", r.Name, err)
+			}
+		}
+		if !utils.IsEmpty(r.Thread) {
+			r.thread, err = template.New(r.Name).Option("missingkey=zero").Parse(r.Thread)
+			if err != nil {
+				return nil, fmt.Errorf("route %s thread: %s", r.Name, err)
+			}
+		}
+		r.params = make(map[string]*template.Template)
+		for k, v := range r.Params {
+			if !strings.Contains(v, "{{") {
+				continue
+			}
+			r.params[k], err = template.New(k).Option("missingkey=zero").Parse(v)
+			if err != nil {
+				return nil, fmt.Errorf("route %s param %s: %s", r.Name, k, err)
+			}
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+func NewServer(options Options, logger common.Logger) (*Server, error) {
+
+	if utils.IsEmpty(options.RoutesFile) {
+		return nil, errors.New("no routes file")
+	}
+
+	routes, err := loadRoutes(options.RoutesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		options:  options,
+		routes:   routes,
+		targets:  make(map[string]Target),
+		receipts: make(map[string]Receipts),
+		trackers: make(map[string]Tracker),
+		threads:  make(map[string]*serverThread),
+		checks:   make(map[string]*serverCheck),
+		pending:  make(map[int64]*Delivery),
+		logger:   logger,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type benchLogger struct{}
+
+func (benchLogger) Info(obj interface{}, args ...interface{})  {}
+func (benchLogger) Warn(obj interface{}, args ...interface{})  {}
+func (benchLogger) Debug(obj interface{}, args ...interface{}) {}
+func (benchLogger) Error(obj interface{}, args ...interface{}) {}
+
+type benchSource struct {
+	count int
+}
+
+func (s *benchSource) Name() string {
+	return "bench"
+}
+
+func (s *benchSource) Start(ctx context.Context, events chan<- *Event) error {
+
+	for i := 0; i < s.count; i++ {
+		events <- &Event{Source: "bench", Type: "Test", Message: "test", Labels: map[string]string{"level": "critical"}}
+	}
+	return nil
+}
+
+// target latency is simulated, so that workers benefit shows as in real notifications
+func benchmarkServerRun(b *testing.B, workers int) {
+
+	file := filepath.Join(b.TempDir(), "routes.yaml")
+	routes := "routes:\n  - source: bench\n    match:\n      level: critical\n    target: bench\n    template: '{{ .Type }} {{ .Message }}'\n"
+	if err := os.WriteFile(file, []byte(routes), 0600); err != nil {
+		b.Fatal(err)
+	}
+
+	s, err := NewServer(Options{RoutesFile: file, Workers: workers}, benchLogger{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	s.AddTarget("bench", func(params map[string]string, message string) ([]byte, error) {
+		time.Sleep(time.Millisecond)
+		return nil, nil
+	})
+	s.AddSource(&benchSource{count: b.N})
+
+	b.ResetTimer()
+	err = s.Run(context.Background())
+	if err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkServerRun1(b *testing.B) {
+	benchmarkServerRun(b, 1)
+}
+
+func BenchmarkServerRun10(b *testing.B) {
+	benchmarkServerRun(b, 10)
+}
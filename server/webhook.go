@@ -0,0 +1,618 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/tools/vendors"
+	"github.com/devopsext/utils"
+)
+
+const (
+	webhookSourceName      = "webhook"
+	webhookMaxBody         = 10 << 20
+	webhookShutdownTimeout = 5 * time.Second
+)
+
+type WebhookSourceOptions struct {
+	Listen           string
+	Alertmanager     string
+	Grafana          string
+	Sentry           string
+	Github           string
+	GithubSecret     string
+	Gitlab           string
+	GitlabToken      string
+	Harbor           string
+	HarborAuth       string
+	HarborProduction string
+	Flux             string
+	FluxSecret       string
+}
+
+// errWebhookUnauthorized is returned by parsers if request isn't signed by webhook secret
+var errWebhookUnauthorized = errors.New("unauthorized")
+
+// webhookParser converts request body to events, source of events is kind of webhook
+type webhookParser func(r *http.Request, body []byte) ([]*Event, error)
+
+type WebhookSource struct {
+	options          WebhookSourceOptions
+	parsers          map[string]webhookParser
+	harborProduction *regexp.Regexp
+	logger           common.Logger
+}
+
+func (w *WebhookSource) Name() string {
+	return webhookSourceName
+}
+
+// webhookAlertLine formats alert summary with labels which aren't common to the group
+func webhookAlertLine(summary string, unique map[string]string) string {
+
+	line := fmt.Sprintf("- %s", summary)
+	if len(unique) == 0 {
+		return line
+	}
+	keys := make([]string, 0, len(unique))
+	for k := range unique {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kvs := []string{}
+	for _, k := range keys {
+		kvs = append(kvs, fmt.Sprintf("%s=%s", k, unique[k]))
+	}
+	return fmt.Sprintf("%s (%s)", line, strings.Join(kvs, ", "))
+}
+
+func webhookStatusType(status string) string {
+	return strings.ToUpper(status[:1]) + status[1:]
+}
+
+// alertmanager sends one event per group, so that templates see firing and resolved alerts together
+func (w *WebhookSource) alertmanager(r *http.Request, body []byte) ([]*Event, error) {
+
+	wh, err := vendors.ParseAlertmanagerWebhook(body)
+	if err != nil {
+		return nil, err
+	}
+
+	firing := wh.Firing()
+	resolved := wh.Resolved()
+
+	labels := make(map[string]string)
+	for k, v := range wh.CommonLabels {
+		labels[k] = v
+	}
+	labels["status"] = wh.Status
+	labels["receiver"] = wh.Receiver
+	labels["group_key"] = wh.GroupKey
+	labels["firing"] = strconv.Itoa(len(firing))
+	labels["resolved"] = strconv.Itoa(len(resolved))
+
+	lines := []string{wh.Title()}
+	for _, a := range append(firing, resolved...) {
+		line := webhookAlertLine(a.Summary(), wh.UniqueLabels(a))
+		if a.Status == "resolved" {
+			line = fmt.Sprintf("%s resolved after %s", line, a.Duration())
+		}
+		lines = append(lines, line)
+	}
+
+	return []*Event{{
+		Source:  "alertmanager",
+		Type:    webhookStatusType(wh.Status),
+		Time:    time.Now(),
+		Message: strings.Join(lines, "\n"),
+		Labels:  labels,
+		Data:    wh,
+	}}, nil
+}
+
+// grafana sends one event per group, URLs of first alert having them become labels,
+// image_url is attached by targets which support images
+func (w *WebhookSource) grafana(r *http.Request, body []byte) ([]*Event, error) {
+
+	wh, err := vendors.ParseGrafanaWebhook(body)
+	if err != nil {
+		return nil, err
+	}
+
+	firing := wh.Firing()
+	resolved := wh.Resolved()
+
+	labels := make(map[string]string)
+	for k, v := range wh.CommonLabels {
+		labels[k] = v
+	}
+	labels["status"] = wh.Status
+	labels["receiver"] = wh.Receiver
+	labels["group_key"] = wh.GroupKey
+	labels["org_id"] = strconv.FormatInt(wh.OrgID, 10)
+	labels["firing"] = strconv.Itoa(len(firing))
+	labels["resolved"] = strconv.Itoa(len(resolved))
+	urls := map[string]string{
+		"image_url":     wh.ImageURL(),
+		"panel_url":     wh.PanelURL(),
+		"dashboard_url": wh.DashboardURL(),
+		"silence_url":   wh.SilenceURL(),
+	}
+	for k, v := range urls {
+		if !utils.IsEmpty(v) {
+			labels[k] = v
+		}
+	}
+
+	title := wh.Title
+	if utils.IsEmpty(title) {
+		title = fmt.Sprintf("[%s] %s", strings.ToUpper(wh.Status), wh.GroupLabels["alertname"])
+	}
+	lines := []string{title}
+	for _, a := range append(firing, resolved...) {
+		line := webhookAlertLine(a.Summary(), wh.UniqueLabels(a))
+		if !utils.IsEmpty(a.ValueString) && a.Status == "firing" {
+			line = fmt.Sprintf("%s %s", line, a.ValueString)
+		}
+		if a.Status == "resolved" {
+			line = fmt.Sprintf("%s resolved after %s", line, a.Duration())
+		}
+		lines = append(lines, line)
+	}
+	if u := wh.PanelURL(); !utils.IsEmpty(u) {
+		lines = append(lines, u)
+	}
+
+	return []*Event{{
+		Source:  "grafana",
+		Type:    webhookStatusType(wh.Status),
+		Time:    time.Now(),
+		Message: strings.Join(lines, "\n"),
+		Labels:  labels,
+		Data:    wh,
+	}}, nil
+}
+
+// sentry sends event per issue alert, service label is service tag or project,
+// so that server routes it to channel of owning team
+func (w *WebhookSource) sentry(r *http.Request, body []byte) ([]*Event, error) {
+
+	wh, err := vendors.ParseSentryWebhook(body)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{
+		"project":     wh.Project,
+		"level":       wh.Level,
+		"environment": wh.Environment,
+		"release":     wh.Release,
+		"culprit":     wh.Culprit,
+		"url":         wh.URL,
+		"rule":        wh.Rule,
+		"issue_id":    wh.IssueID,
+		"service":     wh.Tags["service"],
+	}
+	if utils.IsEmpty(labels["service"]) {
+		labels["service"] = wh.Project
+	}
+	for k, v := range labels {
+		if utils.IsEmpty(v) {
+			delete(labels, k)
+		}
+	}
+
+	level := wh.Level
+	if utils.IsEmpty(level) {
+		level = "error"
+	}
+	lines := []string{fmt.Sprintf("[%s] %s", strings.ToUpper(level), wh.Title)}
+	if !utils.IsEmpty(wh.Culprit) {
+		lines = append(lines, fmt.Sprintf("Culprit: %s", wh.Culprit))
+	}
+	if !utils.IsEmpty(wh.Release) {
+		lines = append(lines, fmt.Sprintf("Release: %s", wh.Release))
+	}
+	if !utils.IsEmpty(wh.Environment) {
+		lines = append(lines, fmt.Sprintf("Environment: %s", wh.Environment))
+	}
+	if !utils.IsEmpty(wh.URL) {
+		lines = append(lines, wh.URL)
+	}
+
+	return []*Event{{
+		Source:  "sentry",
+		Type:    webhookStatusType(level),
+		Time:    time.Now(),
+		Message: strings.Join(lines, "\n"),
+		Labels:  labels,
+		Data:    wh,
+	}}, nil
+}
+
+// webhookCommitLine formats commit of push with short id and first line of message
+func webhookCommitLine(id, message, author string) string {
+
+	if len(id) > 7 {
+		id = id[:7]
+	}
+	message, _, _ = strings.Cut(message, "\n")
+	return fmt.Sprintf("- %s %s (%s)", id, message, author)
+}
+
+// github verifies signature if secret is set, event type is event with action, e.g. pull_request.opened
+func (w *WebhookSource) github(r *http.Request, body []byte) ([]*Event, error) {
+
+	if !utils.IsEmpty(w.options.GithubSecret) {
+		if err := vendors.VerifyGithubSignature(w.options.GithubSecret, body, r.Header.Get("X-Hub-Signature-256")); err != nil {
+			return nil, fmt.Errorf("%w: %s", errWebhookUnauthorized, err)
+		}
+	}
+	wh, err := vendors.ParseGithubWebhook(r.Header.Get("X-GitHub-Event"), body)
+	if err != nil {
+		return nil, err
+	}
+	if wh == nil {
+		return nil, nil
+	}
+
+	labels := map[string]string{
+		"repository": wh.Repository,
+		"sender":     wh.Sender,
+		"branch":     wh.Branch,
+		"tag":        wh.Tag,
+		"action":     wh.Action,
+		"delivery":   r.Header.Get("X-GitHub-Delivery"),
+	}
+	lines := []string{wh.Summary()}
+	switch {
+	case wh.PullRequest != nil:
+		labels["number"] = strconv.Itoa(wh.PullRequest.Number)
+		labels["url"] = wh.PullRequest.URL
+		lines = append(lines, fmt.Sprintf("%s -> %s", wh.PullRequest.Head, wh.PullRequest.Base))
+	case wh.WorkflowRun != nil:
+		labels["status"] = wh.WorkflowRun.Status
+		labels["conclusion"] = wh.WorkflowRun.Conclusion
+		labels["url"] = wh.WorkflowRun.URL
+	default:
+		labels["url"] = wh.Compare
+		for _, c := range wh.Commits {
+			lines = append(lines, webhookCommitLine(c.ID, c.Message, c.Author))
+		}
+	}
+	if !utils.IsEmpty(labels["url"]) {
+		lines = append(lines, labels["url"])
+	}
+	for k, v := range labels {
+		if utils.IsEmpty(v) {
+			delete(labels, k)
+		}
+	}
+
+	typ := wh.Event
+	if !utils.IsEmpty(wh.Action) {
+		typ = fmt.Sprintf("%s.%s", wh.Event, wh.Action)
+	}
+	return []*Event{{
+		Source:  "github",
+		Type:    typ,
+		Time:    time.Now(),
+		Message: strings.Join(lines, "\n"),
+		Labels:  labels,
+		Data:    wh,
+	}}, nil
+}
+
+// gitlab verifies secret token if it's set, event type is object kind with action, e.g. merge_request.open
+func (w *WebhookSource) gitlab(r *http.Request, body []byte) ([]*Event, error) {
+
+	if !utils.IsEmpty(w.options.GitlabToken) {
+		if err := vendors.VerifyGitlabToken(w.options.GitlabToken, r.Header.Get("X-Gitlab-Token")); err != nil {
+			return nil, fmt.Errorf("%w: %s", errWebhookUnauthorized, err)
+		}
+	}
+	wh, err := vendors.ParseGitlabWebhook(body)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{
+		"project": wh.Project,
+		"user":    wh.User,
+		"branch":  wh.Branch,
+		"tag":     wh.Tag,
+		"action":  wh.Action,
+	}
+	lines := []string{wh.Summary()}
+	switch {
+	case wh.MergeRequest != nil:
+		labels["number"] = strconv.Itoa(wh.MergeRequest.IID)
+		labels["url"] = wh.MergeRequest.URL
+		lines = append(lines, fmt.Sprintf("%s -> %s", wh.MergeRequest.Source, wh.MergeRequest.Target))
+	case wh.Pipeline != nil:
+		labels["status"] = wh.Pipeline.Status
+		labels["url"] = wh.Pipeline.URL
+		if wh.Pipeline.Duration > 0 {
+			lines = append(lines, fmt.Sprintf("Duration: %s", time.Duration(wh.Pipeline.Duration)*time.Second))
+		}
+	default:
+		for _, c := range wh.Commits {
+			lines = append(lines, webhookCommitLine(c.ID, c.Message, c.Author))
+		}
+	}
+	if !utils.IsEmpty(labels["url"]) {
+		lines = append(lines, labels["url"])
+	}
+	for k, v := range labels {
+		if utils.IsEmpty(v) {
+			delete(labels, k)
+		}
+	}
+
+	typ := wh.Event
+	if !utils.IsEmpty(wh.Action) {
+		typ = fmt.Sprintf("%s.%s", wh.Event, wh.Action)
+	}
+	return []*Event{{
+		Source:  "gitlab",
+		Type:    typ,
+		Time:    time.Now(),
+		Message: strings.Join(lines, "\n"),
+		Labels:  labels,
+		Data:    wh,
+	}}, nil
+}
+
+// harbor verifies auth header if it's set, production projects match regexp or all are production,
+// their scans having critical vulnerabilities become CRITICAL_VULNERABILITIES events, others keep Harbor type
+func (w *WebhookSource) harbor(r *http.Request, body []byte) ([]*Event, error) {
+
+	if !utils.IsEmpty(w.options.HarborAuth) {
+		auth := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(w.options.HarborAuth), []byte(auth)) != 1 {
+			return nil, fmt.Errorf("%w: auth header mismatch", errWebhookUnauthorized)
+		}
+	}
+	wh, err := vendors.ParseHarborWebhook(body)
+	if err != nil {
+		return nil, err
+	}
+
+	production := w.harborProduction == nil || w.harborProduction.MatchString(wh.Project)
+	critical := wh.Critical()
+	labels := map[string]string{
+		"project":    wh.Project,
+		"repository": wh.Repository,
+		"operator":   wh.Operator,
+		"production": strconv.FormatBool(production),
+		"critical":   strconv.Itoa(critical),
+	}
+
+	typ := wh.Type
+	title := fmt.Sprintf("[%s] %s", wh.Type, wh.Repository)
+	if wh.Type == "SCANNING_COMPLETED" && production && critical > 0 {
+		typ = "CRITICAL_VULNERABILITIES"
+		title = fmt.Sprintf("[CRITICAL] %d critical vulnerabilities in production project %s", critical, wh.Project)
+	}
+	lines := []string{title}
+	for _, res := range wh.Resources {
+		line := fmt.Sprintf("- %s", res.ResourceURL)
+		if v := res.Vulnerabilities; v != nil {
+			keys := make([]string, 0, len(v.Severity))
+			for k := range v.Severity {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			kvs := []string{}
+			for _, k := range keys {
+				kvs = append(kvs, fmt.Sprintf("%s=%d", k, v.Severity[k]))
+			}
+			line = fmt.Sprintf("%s %s, fixable %d of %d", line, strings.Join(kvs, " "), v.Fixable, v.Total)
+			if !utils.IsEmpty(res.Scanner) {
+				line = fmt.Sprintf("%s (%s)", line, res.Scanner)
+			}
+		}
+		lines = append(lines, line)
+	}
+	if len(wh.Resources) > 0 {
+		labels["tag"] = wh.Resources[0].Tag
+		labels["digest"] = wh.Resources[0].Digest
+		labels["resource_url"] = wh.Resources[0].ResourceURL
+	}
+	for k, v := range labels {
+		if utils.IsEmpty(v) {
+			delete(labels, k)
+		}
+	}
+
+	return []*Event{{
+		Source:  "harbor",
+		Type:    typ,
+		Time:    wh.Time(),
+		Message: strings.Join(lines, "\n"),
+		Labels:  labels,
+		Data:    wh,
+	}}, nil
+}
+
+// flux verifies signature of generic-hmac provider if secret is set, message is formatted as Flux text providers do,
+// routes can send it as Flux Slack provider with attachments: "{{ .Data.SlackAttachments }}"
+func (w *WebhookSource) flux(r *http.Request, body []byte) ([]*Event, error) {
+
+	if !utils.IsEmpty(w.options.FluxSecret) {
+		if err := vendors.VerifyFluxSignature(w.options.FluxSecret, body, r.Header.Get("X-Signature")); err != nil {
+			return nil, fmt.Errorf("%w: %s", errWebhookUnauthorized, err)
+		}
+	}
+	fe, err := vendors.ParseFluxEvent(body)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{
+		"kind":       fe.InvolvedObject.Kind,
+		"name":       fe.InvolvedObject.Name,
+		"namespace":  fe.InvolvedObject.Namespace,
+		"severity":   fe.Severity,
+		"reason":     fe.Reason,
+		"controller": fe.ReportingController,
+		"revision":   fe.Revision(),
+		"summary":    fe.Summary(),
+	}
+	for k, v := range labels {
+		if utils.IsEmpty(v) {
+			delete(labels, k)
+		}
+	}
+	t := fe.Timestamp
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	return []*Event{{
+		Source:  "flux",
+		Type:    webhookStatusType(fe.Severity),
+		Time:    t,
+		Message: fe.Text(),
+		Labels:  labels,
+		Data:    fe,
+	}}, nil
+}
+
+func (w *WebhookSource) handler(ctx context.Context, events chan<- *Event, parser webhookParser) http.HandlerFunc {
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+
+		if r.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, webhookMaxBody))
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		evs, err := parser(r, body)
+		if errors.Is(err, errWebhookUnauthorized) {
+			w.logger.Warn("Webhook %s error: %s", r.URL.Path, err)
+			http.Error(rw, errWebhookUnauthorized.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			w.logger.Error("Webhook %s error: %s", r.URL.Path, err)
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, e := range evs {
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				http.Error(rw, "server stopping", http.StatusServiceUnavailable)
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(rw, `{"events":%d}`, len(evs))
+	}
+}
+
+// Start serves webhooks until context is done, requests being handled are finished
+func (w *WebhookSource) Start(ctx context.Context, events chan<- *Event) error {
+
+	mux := http.NewServeMux()
+	for p, parser := range w.parsers {
+		mux.Handle(p, w.handler(ctx, events, parser))
+	}
+
+	listener, err := net.Listen("tcp", w.options.Listen)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Serve returns as soon as shutdown starts, handlers are waited so that they don't send to closed events
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+		defer cancel()
+		srv.Shutdown(sctx)
+	}()
+
+	w.logger.Info("Webhook listening on %s", listener.Addr())
+	err = srv.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		<-stopped
+		return nil
+	}
+	return err
+}
+
+func NewWebhookSource(options WebhookSourceOptions, logger common.Logger) (*WebhookSource, error) {
+
+	if utils.IsEmpty(options.Listen) {
+		return nil, errors.New("no webhook listen address")
+	}
+
+	w := &WebhookSource{
+		options: options,
+		parsers: make(map[string]webhookParser),
+		logger:  logger,
+	}
+	if !utils.IsEmpty(options.Alertmanager) {
+		w.parsers[options.Alertmanager] = w.alertmanager
+	}
+	if !utils.IsEmpty(options.Grafana) {
+		w.parsers[options.Grafana] = w.grafana
+	}
+	if !utils.IsEmpty(options.Sentry) {
+		w.parsers[options.Sentry] = w.sentry
+	}
+	if !utils.IsEmpty(options.Github) {
+		if utils.IsEmpty(options.GithubSecret) {
+			logger.Warn("Webhook %s has no secret, signatures aren't verified", options.Github)
+		}
+		w.parsers[options.Github] = w.github
+	}
+	if !utils.IsEmpty(options.Gitlab) {
+		if utils.IsEmpty(options.GitlabToken) {
+			logger.Warn("Webhook %s has no token, requests aren't verified", options.Gitlab)
+		}
+		w.parsers[options.Gitlab] = w.gitlab
+	}
+	if !utils.IsEmpty(options.Harbor) {
+		if !utils.IsEmpty(options.HarborProduction) {
+			re, err := regexp.Compile(options.HarborProduction)
+			if err != nil {
+				return nil, fmt.Errorf("harbor production: %s", err)
+			}
+			w.harborProduction = re
+		}
+		w.parsers[options.Harbor] = w.harbor
+	}
+	if !utils.IsEmpty(options.Flux) {
+		w.parsers[options.Flux] = w.flux
+	}
+	if len(w.parsers) == 0 {
+		return nil, errors.New("no webhook paths")
+	}
+	return w, nil
+}
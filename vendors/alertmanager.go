@@ -0,0 +1,377 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+type AlertmanagerOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	User     string
+	Password string
+}
+
+type AlertmanagerSilenceOptions struct {
+	Matchers  []string
+	StartsAt  string
+	Duration  string
+	CreatedBy string
+	Comment   string
+}
+
+type AlertmanagerExpireSilenceOptions struct {
+	ID string
+}
+
+type AlertmanagerAlertsOptions struct {
+	Filter    []string
+	Receiver  string
+	Silenced  bool
+	Inhibited bool
+}
+
+type AlertmanagerMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+type AlertmanagerSilence struct {
+	Matchers  []*AlertmanagerMatcher `json:"matchers"`
+	StartsAt  string                 `json:"startsAt"`
+	EndsAt    string                 `json:"endsAt"`
+	CreatedBy string                 `json:"createdBy"`
+	Comment   string                 `json:"comment"`
+}
+
+// AlertmanagerWebhook is payload sent by webhook receiver, alerts are grouped by group labels
+// https://prometheus.io/docs/alerting/latest/configuration/#webhook_config
+type AlertmanagerWebhook struct {
+	Version           string                      `json:"version"`
+	GroupKey          string                      `json:"groupKey"`
+	TruncatedAlerts   int                         `json:"truncatedAlerts"`
+	Status            string                      `json:"status"`
+	Receiver          string                      `json:"receiver"`
+	GroupLabels       map[string]string           `json:"groupLabels"`
+	CommonLabels      map[string]string           `json:"commonLabels"`
+	CommonAnnotations map[string]string           `json:"commonAnnotations"`
+	ExternalURL       string                      `json:"externalURL"`
+	Alerts            []*AlertmanagerWebhookAlert `json:"alerts"`
+}
+
+type AlertmanagerWebhookAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+type Alertmanager struct {
+	client  *http.Client
+	options AlertmanagerOptions
+}
+
+// matcher is in prometheus style: name=value, name!=value, name=~regex, name!~regex,
+// matcher is split at the first operator, so that values can have operators, e.g. path=~/a=b
+func (a *Alertmanager) parseMatcher(s string) (*AlertmanagerMatcher, error) {
+
+	for idx := 0; idx < len(s); idx++ {
+
+		for _, op := range []string{"!=", "=~", "!~", "="} {
+
+			if !strings.HasPrefix(s[idx:], op) {
+				continue
+			}
+			name := strings.TrimSpace(s[:idx])
+			if utils.IsEmpty(name) {
+				return nil, fmt.Errorf("invalid matcher %s", s)
+			}
+			value := strings.TrimSpace(s[idx+len(op):])
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			return &AlertmanagerMatcher{
+				Name:    name,
+				Value:   value,
+				IsRegex: strings.HasSuffix(op, "~"),
+				IsEqual: !strings.HasPrefix(op, "!"),
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid matcher %s", s)
+}
+
+func (a *Alertmanager) getAuth(opts AlertmanagerOptions) string {
+
+	if !utils.IsEmpty(opts.User) && !utils.IsEmpty(opts.Password) {
+		return common.FormatBasicAuth(opts.User, opts.Password)
+	}
+	return ""
+}
+
+func (a *Alertmanager) apiURL(opts AlertmanagerOptions, p string, params url.Values) (string, error) {
+
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, p)
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+	return u.String(), nil
+}
+
+// https://github.com/prometheus/alertmanager/blob/main/api/v2/openapi.yaml
+
+func (a *Alertmanager) CustomCreateSilence(alertmanagerOptions AlertmanagerOptions, silenceOptions AlertmanagerSilenceOptions) ([]byte, error) {
+
+	matchers := common.RemoveEmptyStrings(silenceOptions.Matchers)
+	if len(matchers) == 0 {
+		return nil, errors.New("no silence matchers")
+	}
+
+	silence := &AlertmanagerSilence{
+		CreatedBy: silenceOptions.CreatedBy,
+		Comment:   silenceOptions.Comment,
+	}
+	for _, m := range matchers {
+		matcher, err := a.parseMatcher(m)
+		if err != nil {
+			return nil, err
+		}
+		silence.Matchers = append(silence.Matchers, matcher)
+	}
+
+	startsAt := time.Now().UTC()
+	if !utils.IsEmpty(silenceOptions.StartsAt) {
+		t, err := time.Parse(time.RFC3339Nano, silenceOptions.StartsAt)
+		if err != nil {
+			return nil, err
+		}
+		startsAt = t
+	}
+	duration, err := time.ParseDuration(silenceOptions.Duration)
+	if err != nil {
+		return nil, err
+	}
+	silence.StartsAt = startsAt.Format(time.RFC3339Nano)
+	silence.EndsAt = startsAt.Add(duration).Format(time.RFC3339Nano)
+
+	data, err := json.Marshal(silence)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := a.apiURL(alertmanagerOptions, "/api/v2/silences", nil)
+	if err != nil {
+		return nil, err
+	}
+	return common.HttpPostRaw(a.client, u, "application/json", a.getAuth(alertmanagerOptions), data)
+}
+
+func (a *Alertmanager) CreateSilence(silenceOptions AlertmanagerSilenceOptions) ([]byte, error) {
+	return a.CustomCreateSilence(a.options, silenceOptions)
+}
+
+func (a *Alertmanager) CustomExpireSilence(alertmanagerOptions AlertmanagerOptions, expireOptions AlertmanagerExpireSilenceOptions) ([]byte, error) {
+
+	if utils.IsEmpty(expireOptions.ID) {
+		return nil, errors.New("no silence ID")
+	}
+
+	u, err := a.apiURL(alertmanagerOptions, fmt.Sprintf("/api/v2/silence/%s", url.PathEscape(expireOptions.ID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string)
+	auth := a.getAuth(alertmanagerOptions)
+	if !utils.IsEmpty(auth) {
+		headers["Authorization"] = auth
+	}
+	return common.HttpRequestRawWithHeaders(a.client, "DELETE", u, headers, nil)
+}
+
+func (a *Alertmanager) ExpireSilence(expireOptions AlertmanagerExpireSilenceOptions) ([]byte, error) {
+	return a.CustomExpireSilence(a.options, expireOptions)
+}
+
+func (a *Alertmanager) CustomListSilences(alertmanagerOptions AlertmanagerOptions) ([]byte, error) {
+
+	u, err := a.apiURL(alertmanagerOptions, "/api/v2/silences", nil)
+	if err != nil {
+		return nil, err
+	}
+	return common.HttpGetRaw(a.client, u, "application/json", a.getAuth(alertmanagerOptions))
+}
+
+func (a *Alertmanager) ListSilences() ([]byte, error) {
+	return a.CustomListSilences(a.options)
+}
+
+func (a *Alertmanager) CustomListAlerts(alertmanagerOptions AlertmanagerOptions, alertsOptions AlertmanagerAlertsOptions) ([]byte, error) {
+
+	params := make(url.Values)
+	params.Add("active", "true")
+	params.Add("silenced", strconv.FormatBool(alertsOptions.Silenced))
+	params.Add("inhibited", strconv.FormatBool(alertsOptions.Inhibited))
+	for _, f := range common.RemoveEmptyStrings(alertsOptions.Filter) {
+		params.Add("filter", f)
+	}
+	if !utils.IsEmpty(alertsOptions.Receiver) {
+		params.Add("receiver", alertsOptions.Receiver)
+	}
+
+	u, err := a.apiURL(alertmanagerOptions, "/api/v2/alerts", params)
+	if err != nil {
+		return nil, err
+	}
+	return common.HttpGetRaw(a.client, u, "application/json", a.getAuth(alertmanagerOptions))
+}
+
+func (a *Alertmanager) ListAlerts(alertsOptions AlertmanagerAlertsOptions) ([]byte, error) {
+	return a.CustomListAlerts(a.options, alertsOptions)
+}
+
+// Name is alertname label
+func (a *AlertmanagerWebhookAlert) Name() string {
+	return a.Labels["alertname"]
+}
+
+// Summary is first non empty of summary, description and message annotations, or alertname
+func (a *AlertmanagerWebhookAlert) Summary() string {
+
+	for _, k := range []string{"summary", "description", "message"} {
+		if s := a.Annotations[k]; !utils.IsEmpty(s) {
+			return s
+		}
+	}
+	return a.Name()
+}
+
+// Duration is time alert was firing, till now if it's still firing
+func (a *AlertmanagerWebhookAlert) Duration() time.Duration {
+
+	end := a.EndsAt
+	if a.Status != "resolved" || end.IsZero() {
+		end = time.Now()
+	}
+	return end.Sub(a.StartsAt).Round(time.Second)
+}
+
+func (w *AlertmanagerWebhook) filter(status string) []*AlertmanagerWebhookAlert {
+
+	r := []*AlertmanagerWebhookAlert{}
+	for _, a := range w.Alerts {
+		if a.Status == status {
+			r = append(r, a)
+		}
+	}
+	return r
+}
+
+func (w *AlertmanagerWebhook) Firing() []*AlertmanagerWebhookAlert {
+	return w.filter("firing")
+}
+
+func (w *AlertmanagerWebhook) Resolved() []*AlertmanagerWebhookAlert {
+	return w.filter("resolved")
+}
+
+// UniqueLabels returns labels of alert which aren't common to the group, e.g. instance
+func (w *AlertmanagerWebhook) UniqueLabels(a *AlertmanagerWebhookAlert) map[string]string {
+
+	r := make(map[string]string)
+	for k, v := range a.Labels {
+		if _, ok := w.CommonLabels[k]; !ok {
+			r[k] = v
+		}
+	}
+	return r
+}
+
+// Title is in style of default Alertmanager title, e.g. [FIRING:2] HighLatency (api prod)
+func (w *AlertmanagerWebhook) Title() string {
+
+	keys := make([]string, 0, len(w.GroupLabels))
+	for k := range w.GroupLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	name := w.GroupLabels["alertname"]
+	values := []string{}
+	for _, k := range keys {
+		if k != "alertname" {
+			values = append(values, w.GroupLabels[k])
+		}
+	}
+
+	title := fmt.Sprintf("[%s", strings.ToUpper(w.Status))
+	if w.Status == "firing" {
+		title = fmt.Sprintf("%s:%d", title, len(w.Firing()))
+	}
+	title = fmt.Sprintf("%s] %s", title, name)
+	if len(values) > 0 {
+		title = fmt.Sprintf("%s (%s)", title, strings.Join(values, " "))
+	}
+	return strings.TrimSpace(title)
+}
+
+// ParseAlertmanagerWebhook parses and checks webhook payload of version 4
+func ParseAlertmanagerWebhook(data []byte) (*AlertmanagerWebhook, error) {
+
+	var w AlertmanagerWebhook
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	if w.Version != "4" {
+		return nil, fmt.Errorf("unsupported alertmanager webhook version %q", w.Version)
+	}
+	if w.Status != "firing" && w.Status != "resolved" {
+		return nil, fmt.Errorf("unsupported alertmanager webhook status %q", w.Status)
+	}
+	if w.GroupLabels == nil {
+		w.GroupLabels = make(map[string]string)
+	}
+	if w.CommonLabels == nil {
+		w.CommonLabels = make(map[string]string)
+	}
+	if w.CommonAnnotations == nil {
+		w.CommonAnnotations = make(map[string]string)
+	}
+	return &w, nil
+}
+
+func (a *Alertmanager) WithContext(ctx context.Context) *Alertmanager {
+	c := *a
+	c.client = common.HttpClientContext(a.client, ctx)
+	return &c
+}
+
+func NewAlertmanager(options AlertmanagerOptions) *Alertmanager {
+
+	return &Alertmanager{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
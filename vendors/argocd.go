@@ -0,0 +1,363 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+type ArgoCDOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	Token    string
+}
+
+type ArgoCDApplicationOptions struct {
+	Name      string
+	Namespace string
+}
+
+type ArgoCDWaitOptions struct {
+	Wait         bool
+	WaitTimeout  int
+	PollInterval int
+}
+
+type ArgoCDSyncOptions struct {
+	Revision string
+	Prune    bool
+	DryRun   bool
+}
+
+type ArgoCDRollbackOptions struct {
+	ID     int64
+	Prune  bool
+	DryRun bool
+}
+
+type ArgoCDSyncRequest struct {
+	Revision     string `json:"revision,omitempty"`
+	Prune        bool   `json:"prune"`
+	DryRun       bool   `json:"dryRun"`
+	AppNamespace string `json:"appNamespace,omitempty"`
+}
+
+type ArgoCDRollbackRequest struct {
+	ID           int64  `json:"id"`
+	Prune        bool   `json:"prune"`
+	DryRun       bool   `json:"dryRun"`
+	AppNamespace string `json:"appNamespace,omitempty"`
+}
+
+type argoCDApplication struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Project string `json:"project"`
+	} `json:"spec"`
+	// operation is set until controller starts it, so that previous operation state isn't taken
+	Operation json.RawMessage `json:"operation,omitempty"`
+	Status    struct {
+		Sync struct {
+			Status   string `json:"status"`
+			Revision string `json:"revision"`
+		} `json:"sync"`
+		Health struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"health"`
+		OperationState *struct {
+			Phase   string `json:"phase"`
+			Message string `json:"message"`
+		} `json:"operationState,omitempty"`
+		History []struct {
+			ID         int64  `json:"id"`
+			Revision   string `json:"revision"`
+			DeployedAt string `json:"deployedAt"`
+		} `json:"history"`
+	} `json:"status"`
+}
+
+// ArgoCDApplicationStatus is summary of application status
+type ArgoCDApplicationStatus struct {
+	Name             string `json:"name"`
+	Namespace        string `json:"namespace"`
+	Project          string `json:"project"`
+	Sync             string `json:"sync"`
+	Health           string `json:"health"`
+	HealthMessage    string `json:"healthMessage,omitempty"`
+	Revision         string `json:"revision"`
+	Operation        string `json:"operation,omitempty"`
+	OperationMessage string `json:"operationMessage,omitempty"`
+	HistoryID        int64  `json:"historyId,omitempty"`
+}
+
+type ArgoCD struct {
+	client  *http.Client
+	options ArgoCDOptions
+	logger  common.Logger
+}
+
+func (a *ArgoCD) request(opts ArgoCDOptions, method, p string, params url.Values, data []byte) ([]byte, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return nil, errors.New("no URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "/api/v1", p)
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	if !utils.IsEmpty(opts.Token) {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", opts.Token)
+	}
+
+	b, err := common.HttpRequestRawWithHeaders(a.client, method, u.String(), headers, data)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (a *ArgoCD) appPath(appOptions ArgoCDApplicationOptions, action string) (string, error) {
+
+	if utils.IsEmpty(appOptions.Name) {
+		return "", errors.New("no application")
+	}
+	return path.Join("/applications", url.PathEscape(appOptions.Name), action), nil
+}
+
+func (a *ArgoCD) appParams(appOptions ArgoCDApplicationOptions) url.Values {
+
+	if utils.IsEmpty(appOptions.Namespace) {
+		return nil
+	}
+	params := make(url.Values)
+	params.Set("appNamespace", appOptions.Namespace)
+	return params
+}
+
+func (a *ArgoCD) application(opts ArgoCDOptions, appOptions ArgoCDApplicationOptions) (*argoCDApplication, error) {
+
+	p, err := a.appPath(appOptions, "")
+	if err != nil {
+		return nil, err
+	}
+	b, err := a.request(opts, "GET", p, a.appParams(appOptions), nil)
+	if err != nil {
+		return nil, err
+	}
+	var app argoCDApplication
+	if err := json.Unmarshal(b, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+func (a *ArgoCD) status(app *argoCDApplication) *ArgoCDApplicationStatus {
+
+	s := &ArgoCDApplicationStatus{
+		Name:          app.Metadata.Name,
+		Namespace:     app.Metadata.Namespace,
+		Project:       app.Spec.Project,
+		Sync:          app.Status.Sync.Status,
+		Health:        app.Status.Health.Status,
+		HealthMessage: app.Status.Health.Message,
+		Revision:      app.Status.Sync.Revision,
+	}
+	if op := app.Status.OperationState; op != nil {
+		s.Operation = op.Phase
+		s.OperationMessage = op.Message
+	}
+	if len(app.Operation) > 0 && string(app.Operation) != "null" {
+		s.Operation = "Pending"
+	}
+	if n := len(app.Status.History); n > 0 {
+		s.HistoryID = app.Status.History[n-1].ID
+	}
+	return s
+}
+
+// https://argo-cd.readthedocs.io/en/stable/developer-guide/api-docs/
+// result is summary of sync, health and the last operation
+
+func (a *ArgoCD) CustomGetApplicationStatus(argoCDOptions ArgoCDOptions, appOptions ArgoCDApplicationOptions) ([]byte, error) {
+
+	app, err := a.application(argoCDOptions, appOptions)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(a.status(app))
+}
+
+func (a *ArgoCD) GetApplicationStatus(appOptions ArgoCDApplicationOptions) ([]byte, error) {
+	return a.CustomGetApplicationStatus(a.options, appOptions)
+}
+
+// wait polls application until it's healthy and has no running operation, synced as well if it's needed,
+// rolled back application isn't synced, failed operation is error
+func (a *ArgoCD) wait(argoCDOptions ArgoCDOptions, appOptions ArgoCDApplicationOptions, waitOptions ArgoCDWaitOptions, synced bool) ([]byte, error) {
+
+	interval := time.Duration(waitOptions.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(waitOptions.WaitTimeout) * time.Second)
+
+	for {
+		app, err := a.application(argoCDOptions, appOptions)
+		if err != nil {
+			return nil, err
+		}
+		s := a.status(app)
+		data, err := json.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		if a.logger != nil {
+			a.logger.Debug("ArgoCD application %s is %s and %s, operation %s", s.Name, s.Sync, s.Health, s.Operation)
+		}
+
+		switch s.Operation {
+		case "Failed", "Error":
+			return data, fmt.Errorf("ArgoCD application %s operation is %s: %s", s.Name, s.Operation, s.OperationMessage)
+		case "Pending", "Running", "Terminating":
+		default:
+			if (s.Sync == "Synced" || !synced) && s.Health == "Healthy" {
+				return data, nil
+			}
+		}
+
+		if waitOptions.WaitTimeout > 0 && time.Now().After(deadline) {
+			return data, fmt.Errorf("ArgoCD application %s is still %s and %s after %d seconds", s.Name, s.Sync, s.Health, waitOptions.WaitTimeout)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// CustomWaitHealthy polls application until it's synced, healthy and has no running operation
+func (a *ArgoCD) CustomWaitHealthy(argoCDOptions ArgoCDOptions, appOptions ArgoCDApplicationOptions, waitOptions ArgoCDWaitOptions) ([]byte, error) {
+	return a.wait(argoCDOptions, appOptions, waitOptions, true)
+}
+
+func (a *ArgoCD) WaitHealthy(appOptions ArgoCDApplicationOptions, waitOptions ArgoCDWaitOptions) ([]byte, error) {
+	return a.CustomWaitHealthy(a.options, appOptions, waitOptions)
+}
+
+// https://argo-cd.readthedocs.io/en/stable/developer-guide/api-docs/
+// revision of source is used if it's empty, result is status or status after waiting
+
+func (a *ArgoCD) CustomSyncApplication(argoCDOptions ArgoCDOptions, appOptions ArgoCDApplicationOptions, syncOptions ArgoCDSyncOptions, waitOptions ArgoCDWaitOptions) ([]byte, error) {
+
+	p, err := a.appPath(appOptions, "sync")
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(&ArgoCDSyncRequest{
+		Revision:     syncOptions.Revision,
+		Prune:        syncOptions.Prune,
+		DryRun:       syncOptions.DryRun,
+		AppNamespace: appOptions.Namespace,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b, err := a.request(argoCDOptions, "POST", p, nil, data)
+	if err != nil {
+		return nil, err
+	}
+	if !waitOptions.Wait || syncOptions.DryRun {
+		var app argoCDApplication
+		if err := json.Unmarshal(b, &app); err != nil {
+			return nil, err
+		}
+		return json.Marshal(a.status(&app))
+	}
+	return a.CustomWaitHealthy(argoCDOptions, appOptions, waitOptions)
+}
+
+func (a *ArgoCD) SyncApplication(appOptions ArgoCDApplicationOptions, syncOptions ArgoCDSyncOptions, waitOptions ArgoCDWaitOptions) ([]byte, error) {
+	return a.CustomSyncApplication(a.options, appOptions, syncOptions, waitOptions)
+}
+
+// https://argo-cd.readthedocs.io/en/stable/developer-guide/api-docs/
+// history ID of previous deployment is used if it's empty, auto sync must be disabled by ArgoCD
+
+func (a *ArgoCD) CustomRollback(argoCDOptions ArgoCDOptions, appOptions ArgoCDApplicationOptions, rollbackOptions ArgoCDRollbackOptions, waitOptions ArgoCDWaitOptions) ([]byte, error) {
+
+	p, err := a.appPath(appOptions, "rollback")
+	if err != nil {
+		return nil, err
+	}
+	id := rollbackOptions.ID
+	if id == 0 {
+		app, err := a.application(argoCDOptions, appOptions)
+		if err != nil {
+			return nil, err
+		}
+		history := app.Status.History
+		if len(history) < 2 {
+			return nil, fmt.Errorf("ArgoCD application %s has no previous deployment", appOptions.Name)
+		}
+		id = history[len(history)-2].ID
+	}
+
+	data, err := json.Marshal(&ArgoCDRollbackRequest{
+		ID:           id,
+		Prune:        rollbackOptions.Prune,
+		DryRun:       rollbackOptions.DryRun,
+		AppNamespace: appOptions.Namespace,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b, err := a.request(argoCDOptions, "POST", p, nil, data)
+	if err != nil {
+		return nil, err
+	}
+	if !waitOptions.Wait || rollbackOptions.DryRun {
+		var app argoCDApplication
+		if err := json.Unmarshal(b, &app); err != nil {
+			return nil, err
+		}
+		return json.Marshal(a.status(&app))
+	}
+	return a.wait(argoCDOptions, appOptions, waitOptions, false)
+}
+
+func (a *ArgoCD) Rollback(appOptions ArgoCDApplicationOptions, rollbackOptions ArgoCDRollbackOptions, waitOptions ArgoCDWaitOptions) ([]byte, error) {
+	return a.CustomRollback(a.options, appOptions, rollbackOptions, waitOptions)
+}
+
+func (a *ArgoCD) WithContext(ctx context.Context) *ArgoCD {
+	c := *a
+	c.client = common.HttpClientContext(a.client, ctx)
+	return &c
+}
+
+func NewArgoCD(options ArgoCDOptions, logger common.Logger) *ArgoCD {
+
+	return &ArgoCD{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+		logger:  logger,
+	}
+}
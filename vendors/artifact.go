@@ -0,0 +1,264 @@
+package vendors
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+type ArtifactUploadOptions struct {
+	Repository string
+	Path       string
+	File       string
+	Properties []string
+}
+
+type ArtifactDownloadOptions struct {
+	Repository string
+	Path       string
+	File       string
+}
+
+// ArtifactSearchOptions searches by checksum if it's set, otherwise by group, artifact, version and classifier
+type ArtifactSearchOptions struct {
+	Repository string
+	Checksum   string
+	Group      string
+	Artifact   string
+	Version    string
+	Classifier string
+}
+
+// ArtifactPropertiesOptions has properties as key=value, Nexus has no properties, so they're attributes of tag
+type ArtifactPropertiesOptions struct {
+	Repository string
+	Path       string
+	Properties []string
+	Recursive  bool
+	Tag        string
+}
+
+type Artifact struct {
+	Repository string            `json:"repository"`
+	Path       string            `json:"path"`
+	URL        string            `json:"url"`
+	File       string            `json:"file,omitempty"`
+	Size       int64             `json:"size,omitempty"`
+	Checksums  map[string]string `json:"checksums,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// ArtifactRepository is repository of artifacts, e.g. Artifactory or Nexus, results are JSON of artifacts
+type ArtifactRepository interface {
+	Upload(options ArtifactUploadOptions) ([]byte, error)
+	Download(options ArtifactDownloadOptions) ([]byte, error)
+	Search(options ArtifactSearchOptions) ([]byte, error)
+	SetProperties(options ArtifactPropertiesOptions) ([]byte, error)
+}
+
+// artifactChecksumType returns type of checksum by its length
+func artifactChecksumType(checksum string) (string, error) {
+
+	if _, err := hex.DecodeString(checksum); err != nil {
+		return "", fmt.Errorf("checksum %s isn't hex", checksum)
+	}
+	switch len(checksum) {
+	case 32:
+		return "md5", nil
+	case 40:
+		return "sha1", nil
+	case 64:
+		return "sha256", nil
+	}
+	return "", fmt.Errorf("checksum %s isn't MD5, SHA-1 or SHA-256", checksum)
+}
+
+func artifactHashes() map[string]hash.Hash {
+	return map[string]hash.Hash{"md5": md5.New(), "sha1": sha1.New(), "sha256": sha256.New()}
+}
+
+func artifactSums(hashes map[string]hash.Hash) map[string]string {
+
+	r := make(map[string]string)
+	for k, h := range hashes {
+		r[k] = hex.EncodeToString(h.Sum(nil))
+	}
+	return r
+}
+
+// artifactChecksums returns checksums and size of file, repositories verify upload by them
+func artifactChecksums(file string) (map[string]string, int64, error) {
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	hashes := artifactHashes()
+	w := io.MultiWriter(hashes["md5"], hashes["sha1"], hashes["sha256"])
+	size, err := io.Copy(w, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	return artifactSums(hashes), size, nil
+}
+
+func artifactProperties(properties []string) (map[string]string, error) {
+
+	r := make(map[string]string)
+	for _, p := range properties {
+		if utils.IsEmpty(p) {
+			continue
+		}
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 || utils.IsEmpty(strings.TrimSpace(kv[0])) {
+			return nil, fmt.Errorf("property %s isn't key=value", p)
+		}
+		r[strings.TrimSpace(kv[0])] = kv[1]
+	}
+	return r, nil
+}
+
+func artifactPath(repository, path string) (string, error) {
+
+	if utils.IsEmpty(repository) {
+		return "", errors.New("no repository")
+	}
+	path = strings.TrimPrefix(path, "/")
+	if utils.IsEmpty(path) {
+		return "", errors.New("no path")
+	}
+	return path, nil
+}
+
+// artifactRequest sends request, body is error if response isn't 2xx
+func artifactRequest(client *http.Client, method, URL string, headers map[string]string, body io.Reader, length int64) (*http.Response, error) {
+
+	if body == nil || length == 0 {
+		body = http.NoBody
+	}
+	req, err := http.NewRequest(method, URL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = length
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, common.NewAPIError(resp.StatusCode, data)
+	}
+	return resp, nil
+}
+
+func artifactRequestBytes(client *http.Client, method, URL string, headers map[string]string, data []byte) ([]byte, error) {
+
+	resp, err := artifactRequest(client, method, URL, headers, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// artifactUpload puts file to URL, checksums of file are sent as headers
+func artifactUpload(client *http.Client, URL string, headers map[string]string, file string) (*Artifact, error) {
+
+	checksums, size, err := artifactChecksums(file)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := map[string]string{
+		"Content-Type":      "application/octet-stream",
+		"X-Checksum-Md5":    checksums["md5"],
+		"X-Checksum-Sha1":   checksums["sha1"],
+		"X-Checksum-Sha256": checksums["sha256"],
+	}
+	for k, v := range headers {
+		h[k] = v
+	}
+	resp, err := artifactRequest(client, "PUT", URL, h, f, size)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return &Artifact{
+		URL:       URL,
+		File:      file,
+		Size:      size,
+		Checksums: checksums,
+	}, nil
+}
+
+// artifactDownload writes URL to file, which is written and renamed, so that partial download doesn't replace it,
+// SHA-1 of file is verified if response has it
+func artifactDownload(client *http.Client, URL string, headers map[string]string, file string) (*Artifact, error) {
+
+	resp, err := artifactRequest(client, "GET", URL, headers, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	tmp := file + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, err
+	}
+	hashes := artifactHashes()
+	w := io.MultiWriter(f, hashes["md5"], hashes["sha1"], hashes["sha256"])
+	size, err := io.Copy(w, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	checksums := artifactSums(hashes)
+	if expected := strings.ToLower(resp.Header.Get("X-Checksum-Sha1")); err == nil && !utils.IsEmpty(expected) && expected != checksums["sha1"] {
+		err = fmt.Errorf("SHA-1 of %s is %s, expected %s", file, checksums["sha1"], expected)
+	}
+	if err == nil {
+		err = os.Rename(tmp, file)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	return &Artifact{
+		URL:       URL,
+		File:      file,
+		Size:      size,
+		Checksums: checksums,
+	}, nil
+}
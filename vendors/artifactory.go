@@ -0,0 +1,306 @@
+package vendors
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+type ArtifactoryOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	Token    string
+	User     string
+	Password string
+}
+
+type artifactorySearchResult struct {
+	Repo        string              `json:"repo"`
+	Path        string              `json:"path"`
+	DownloadURI string              `json:"downloadUri"`
+	Size        string              `json:"size"`
+	Checksums   map[string]string   `json:"checksums"`
+	Properties  map[string][]string `json:"properties"`
+}
+
+type artifactorySearchResponse struct {
+	Results []*artifactorySearchResult `json:"results"`
+}
+
+type Artifactory struct {
+	client  *http.Client
+	options ArtifactoryOptions
+}
+
+// headers has bearer token if it's set, otherwise basic auth of user
+func (a *Artifactory) headers(opts ArtifactoryOptions) map[string]string {
+
+	headers := make(map[string]string)
+	if !utils.IsEmpty(opts.Token) {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", opts.Token)
+	} else if !utils.IsEmpty(opts.User) {
+		auth := base64.StdEncoding.EncodeToString([]byte(opts.User + ":" + opts.Password))
+		headers["Authorization"] = fmt.Sprintf("Basic %s", auth)
+	}
+	return headers
+}
+
+func (a *Artifactory) url(opts ArtifactoryOptions, elem ...string) (string, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return "", errors.New("no URL")
+	}
+	segments := []string{strings.TrimSuffix(opts.URL, "/")}
+	for _, e := range elem {
+		for _, s := range strings.Split(strings.Trim(e, "/"), "/") {
+			segments = append(segments, url.PathEscape(s))
+		}
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// propertyEscape escapes characters which separate properties and their values
+func (a *Artifactory) propertyEscape(s string) string {
+
+	r := strings.NewReplacer(`\`, `\\`, ",", `\,`, "|", `\|`, "=", `\=`, ";", `\;`)
+	return r.Replace(s)
+}
+
+// matrixEscape escapes matrix parameter, path escape keeps = as is
+func (a *Artifactory) matrixEscape(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), "=", "%3D")
+}
+
+func (a *Artifactory) propertyKeys(properties map[string]string) []string {
+
+	keys := []string{}
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// https://jfrog.com/help/r/jfrog-rest-apis/deploy-artifact
+// properties are set by matrix parameters of path
+
+func (a *Artifactory) CustomUpload(artifactoryOptions ArtifactoryOptions, uploadOptions ArtifactUploadOptions) ([]byte, error) {
+
+	if utils.IsEmpty(uploadOptions.File) {
+		return nil, errors.New("no file")
+	}
+	p := uploadOptions.Path
+	if utils.IsEmpty(p) {
+		p = filepath.Base(uploadOptions.File)
+	}
+	p, err := artifactPath(uploadOptions.Repository, p)
+	if err != nil {
+		return nil, err
+	}
+	properties, err := artifactProperties(uploadOptions.Properties)
+	if err != nil {
+		return nil, err
+	}
+	u, err := a.url(artifactoryOptions, uploadOptions.Repository, p)
+	if err != nil {
+		return nil, err
+	}
+
+	matrix := ""
+	for _, k := range a.propertyKeys(properties) {
+		matrix += fmt.Sprintf(";%s=%s", a.matrixEscape(k), a.matrixEscape(properties[k]))
+	}
+	r, err := artifactUpload(a.client, u+matrix, a.headers(artifactoryOptions), uploadOptions.File)
+	if err != nil {
+		return nil, err
+	}
+	r.Repository = uploadOptions.Repository
+	r.Path = p
+	r.URL = u
+	if len(properties) > 0 {
+		r.Properties = properties
+	}
+	return json.Marshal(r)
+}
+
+func (a *Artifactory) Upload(uploadOptions ArtifactUploadOptions) ([]byte, error) {
+	return a.CustomUpload(a.options, uploadOptions)
+}
+
+// https://jfrog.com/help/r/jfrog-rest-apis/retrieve-artifact
+// file is base name of path if it's empty
+
+func (a *Artifactory) CustomDownload(artifactoryOptions ArtifactoryOptions, downloadOptions ArtifactDownloadOptions) ([]byte, error) {
+
+	p, err := artifactPath(downloadOptions.Repository, downloadOptions.Path)
+	if err != nil {
+		return nil, err
+	}
+	u, err := a.url(artifactoryOptions, downloadOptions.Repository, p)
+	if err != nil {
+		return nil, err
+	}
+	file := downloadOptions.File
+	if utils.IsEmpty(file) {
+		file = filepath.Base(p)
+	}
+
+	r, err := artifactDownload(a.client, u, a.headers(artifactoryOptions), file)
+	if err != nil {
+		return nil, err
+	}
+	r.Repository = downloadOptions.Repository
+	r.Path = p
+	return json.Marshal(r)
+}
+
+func (a *Artifactory) Download(downloadOptions ArtifactDownloadOptions) ([]byte, error) {
+	return a.CustomDownload(a.options, downloadOptions)
+}
+
+// https://jfrog.com/help/r/jfrog-rest-apis/checksum-search
+// https://jfrog.com/help/r/jfrog-rest-apis/gavc-search
+// results have info and properties of artifacts
+
+func (a *Artifactory) CustomSearch(artifactoryOptions ArtifactoryOptions, searchOptions ArtifactSearchOptions) ([]byte, error) {
+
+	params := make(url.Values)
+	search := "checksum"
+	if !utils.IsEmpty(searchOptions.Checksum) {
+		t, err := artifactChecksumType(searchOptions.Checksum)
+		if err != nil {
+			return nil, err
+		}
+		params.Set(t, strings.ToLower(searchOptions.Checksum))
+	} else {
+		if utils.IsEmpty(searchOptions.Group) && utils.IsEmpty(searchOptions.Artifact) {
+			return nil, errors.New("no checksum and no group or artifact")
+		}
+		search = "gavc"
+		for k, v := range map[string]string{"g": searchOptions.Group, "a": searchOptions.Artifact, "v": searchOptions.Version, "c": searchOptions.Classifier} {
+			if !utils.IsEmpty(v) {
+				params.Set(k, v)
+			}
+		}
+	}
+	if !utils.IsEmpty(searchOptions.Repository) {
+		params.Set("repos", searchOptions.Repository)
+	}
+	u, err := a.url(artifactoryOptions, "api", "search", search)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := a.headers(artifactoryOptions)
+	headers["X-Result-Detail"] = "info, properties"
+	b, err := artifactRequestBytes(a.client, "GET", u+"?"+params.Encode(), headers, nil)
+	if err != nil {
+		return nil, err
+	}
+	var sr artifactorySearchResponse
+	if err := json.Unmarshal(b, &sr); err != nil {
+		return nil, err
+	}
+
+	r := []*Artifact{}
+	for _, s := range sr.Results {
+		artifact := &Artifact{
+			Repository: s.Repo,
+			Path:       strings.TrimPrefix(s.Path, "/"),
+			URL:        s.DownloadURI,
+			Checksums:  s.Checksums,
+		}
+		artifact.Size, _ = strconv.ParseInt(s.Size, 10, 64)
+		if len(s.Properties) > 0 {
+			artifact.Properties = make(map[string]string)
+			for k, v := range s.Properties {
+				artifact.Properties[k] = strings.Join(v, ",")
+			}
+		}
+		r = append(r, artifact)
+	}
+	return json.Marshal(r)
+}
+
+func (a *Artifactory) Search(searchOptions ArtifactSearchOptions) ([]byte, error) {
+	return a.CustomSearch(a.options, searchOptions)
+}
+
+// https://jfrog.com/help/r/jfrog-rest-apis/set-item-properties
+// properties of folder are set to its children if it's recursive
+
+func (a *Artifactory) CustomSetProperties(artifactoryOptions ArtifactoryOptions, propertiesOptions ArtifactPropertiesOptions) ([]byte, error) {
+
+	p, err := artifactPath(propertiesOptions.Repository, propertiesOptions.Path)
+	if err != nil {
+		return nil, err
+	}
+	properties, err := artifactProperties(propertiesOptions.Properties)
+	if err != nil {
+		return nil, err
+	}
+	if len(properties) == 0 {
+		return nil, errors.New("no properties")
+	}
+	u, err := a.url(artifactoryOptions, "api", "storage", propertiesOptions.Repository, p)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []string{}
+	for _, k := range a.propertyKeys(properties) {
+		items = append(items, fmt.Sprintf("%s=%s", a.propertyEscape(k), a.propertyEscape(properties[k])))
+	}
+	params := make(url.Values)
+	params.Set("properties", strings.Join(items, ";"))
+	params.Set("recursive", "0")
+	if propertiesOptions.Recursive {
+		params.Set("recursive", "1")
+	}
+
+	_, err = artifactRequestBytes(a.client, "PUT", u+"?"+params.Encode(), a.headers(artifactoryOptions), nil)
+	if err != nil {
+		return nil, err
+	}
+	download, err := a.url(artifactoryOptions, propertiesOptions.Repository, p)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&Artifact{
+		Repository: propertiesOptions.Repository,
+		Path:       p,
+		URL:        download,
+		Properties: properties,
+	})
+}
+
+func (a *Artifactory) SetProperties(propertiesOptions ArtifactPropertiesOptions) ([]byte, error) {
+	return a.CustomSetProperties(a.options, propertiesOptions)
+}
+
+func (a *Artifactory) WithContext(ctx context.Context) *Artifactory {
+	c := *a
+	c.client = common.HttpClientContext(a.client, ctx)
+	return &c
+}
+
+func NewArtifactory(options ArtifactoryOptions) *Artifactory {
+
+	artifactory := &Artifactory{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+	return artifactory
+}
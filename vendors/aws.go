@@ -2,6 +2,7 @@ package vendors
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/xml"
@@ -15,8 +16,13 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/devopsext/tools/common"
 )
 
+// requests of AWS API share client, so that they are retried as requests of other vendors
+const awsTimeout = 30
+
 const (
 	iSO8601BasicFormat          = "20060102T150405Z"
 	iSO8601BasicFormatShort     = "20060102"
@@ -163,7 +169,7 @@ func (e *awsBase) assumeAWSRole() error {
 	assumeRoleURL := fmt.Sprintf("%s%s", defaultAWSEC2AssumeRoleURL, assumeRoleParameters)
 	client := &AWSClient{
 		Keys:       &e.keys,
-		HttpClient: http.DefaultClient,
+		HttpClient: common.NewHttpClient(awsTimeout, false),
 		Url:        assumeRoleURL,
 	}
 
@@ -175,10 +181,14 @@ func (e *awsBase) assumeAWSRole() error {
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return awsError("sts", resp.StatusCode, body)
+	}
 	response := awsAssumeRoleResponse{}
 	err = xml.Unmarshal(body, &response)
 	if err != nil {
@@ -196,7 +206,7 @@ func (e *awsBase) assumeAWSRole() error {
 func (e *awsBase) getAvailableAWSRegions() ([]AWSRegion, error) {
 	client := &AWSClient{
 		Keys:       &e.keys,
-		HttpClient: http.DefaultClient,
+		HttpClient: common.NewHttpClient(awsTimeout, false),
 		Url:        defaultAWSEC2RegionsURL,
 	}
 	r, err := http.NewRequest("GET", client.Url, nil)
@@ -207,10 +217,14 @@ func (e *awsBase) getAvailableAWSRegions() ([]AWSRegion, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, awsError("ec2", resp.StatusCode, body)
+	}
 	response := awsDescribeRegionsResponse{}
 	err = xml.Unmarshal(body, &response)
 	if err != nil {
@@ -228,7 +242,7 @@ func (e *awsBase) generateAWSClients(regions []AWSRegion) error {
 		client := AWSClient{
 			Region:     region.RegionName,
 			Keys:       &e.keys,
-			HttpClient: http.DefaultClient,
+			HttpClient: common.NewHttpClient(awsTimeout, false),
 			Url:        "https://" + region.RegionEndpoint + "/?Action=DescribeInstances&Version=2016-11-15",
 		}
 		client.getAWSAccountID()
@@ -309,6 +323,23 @@ func (e *AWSEC2) GetAllAWSEC2Instances() ([]AWSEC2Instance, error) {
 	return instances, nil
 }
 
+// WithContext returns EC2 of clients of all accounts and regions, which requests are cancelled with ctx
+func (e *AWSEC2) WithContext(ctx context.Context) *AWSEC2 {
+
+	c := &AWSEC2{baseConfigs: make([]awsBase, len(e.baseConfigs))}
+	for i, cfg := range e.baseConfigs {
+		clients := make([]*AWSClient, 0, len(cfg.clients))
+		for _, client := range cfg.clients {
+			cc := *client
+			cc.HttpClient = common.HttpClientContext(client.HttpClient, ctx)
+			clients = append(clients, &cc)
+		}
+		cfg.clients = clients
+		c.baseConfigs[i] = cfg
+	}
+	return c
+}
+
 // getAWSAccountID retrieves the AWS account ID associated with the AWS client.
 func (c *AWSClient) getAWSAccountID() error {
 	r, err := http.NewRequest("GET", defaultAWSAccountDetailsURL, nil)
@@ -396,14 +427,9 @@ func (s *AWSService) creds(t time.Time) string {
 func (s *AWSService) writeQuery(w io.Writer, r *http.Request) {
 	var a []string
 	for k, vs := range r.URL.Query() {
-		k = url.QueryEscape(k)
+		k = awsQueryEscape(k)
 		for _, v := range vs {
-			if v == "" {
-				a = append(a, k)
-			} else {
-				v = url.QueryEscape(v)
-				a = append(a, k+"="+v)
-			}
+			a = append(a, k+"="+awsQueryEscape(v))
 		}
 	}
 	sort.Strings(a)
@@ -446,7 +472,17 @@ func (s *AWSService) writeHeaderList(w io.Writer, r *http.Request) {
 	}
 }
 
+// awsQueryEscape escapes spaces as %20, canonical query doesn't allow +
+func awsQueryEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
 func (s *AWSService) writeBody(w io.Writer, r *http.Request) {
+	// payload hash is set explicitly for streaming or unsigned payloads, e.g. S3
+	if hash := r.Header.Get("X-Amz-Content-Sha256"); hash != "" {
+		w.Write([]byte(hash))
+		return
+	}
 	var b []byte
 	if r.Body == nil {
 		b = []byte("")
@@ -0,0 +1,147 @@
+package vendors
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+// query APIs wrap error into ErrorResponse, S3 returns Error as root
+type awsErrorResponse struct {
+	XMLName xml.Name
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+	Error   struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+}
+
+const awsSTSVersion = "2011-06-15"
+
+// keys of web identity are refreshed before they expire, so that requests being signed by them don't fail
+const awsCredentialsRefresh = 5 * time.Minute
+
+type awsWebIdentityResponse struct {
+	AccessKey    string    `xml:"AssumeRoleWithWebIdentityResult>Credentials>AccessKeyId"`
+	SecretKey    string    `xml:"AssumeRoleWithWebIdentityResult>Credentials>SecretAccessKey"`
+	SessionToken string    `xml:"AssumeRoleWithWebIdentityResult>Credentials>SessionToken"`
+	Expiration   time.Time `xml:"AssumeRoleWithWebIdentityResult>Credentials>Expiration"`
+}
+
+// awsCredentials resolves keys from options, then from environment, then from web identity (IRSA),
+// keys of web identity are kept for next requests till they expire, token file is read again for new keys,
+// as it's rotated by Kubernetes
+type awsCredentials struct {
+	client  *http.Client
+	keys    *AWSKeys
+	expires time.Time
+	mutex   sync.Mutex
+}
+
+// awsSTSEndpoint returns endpoint of STS, it's AWS_ENDPOINT_URL_STS or AWS_ENDPOINT_URL if they're set
+func awsSTSEndpoint(region string) string {
+
+	for _, env := range []string{"AWS_ENDPOINT_URL_STS", "AWS_ENDPOINT_URL"} {
+		if v := os.Getenv(env); !utils.IsEmpty(v) {
+			return v
+		}
+	}
+	return fmt.Sprintf("https://sts.%s.amazonaws.com/", region)
+}
+
+func (c *awsCredentials) get(region string, static AWSKeys) (*AWSKeys, error) {
+
+	if !utils.IsEmpty(static.AccessKey) {
+		return &static, nil
+	}
+
+	if !utils.IsEmpty(os.Getenv("AWS_ACCESS_KEY_ID")) {
+		return &AWSKeys{
+			AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.keys != nil && (c.expires.IsZero() || time.Until(c.expires) > awsCredentialsRefresh) {
+		return c.keys, nil
+	}
+
+	tokenFile := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	roleARN := os.Getenv("AWS_ROLE_ARN")
+	if utils.IsEmpty(tokenFile) || utils.IsEmpty(roleARN) {
+		return nil, errors.New("no AWS credentials")
+	}
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithWebIdentity.html
+	params := make(url.Values)
+	params.Add("Action", "AssumeRoleWithWebIdentity")
+	params.Add("Version", awsSTSVersion)
+	params.Add("RoleArn", roleARN)
+	params.Add("RoleSessionName", "tools")
+	params.Add("WebIdentityToken", strings.TrimSpace(string(token)))
+
+	data, err := common.HttpPostRaw(c.client, awsSTSEndpoint(region), "application/x-www-form-urlencoded", "", []byte(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	var r awsWebIdentityResponse
+	err = xml.Unmarshal(data, &r)
+	if err != nil {
+		return nil, err
+	}
+	c.keys = &AWSKeys{AccessKey: r.AccessKey, SecretKey: r.SecretKey, SessionToken: r.SessionToken}
+	c.expires = r.Expiration
+	return c.keys, nil
+}
+
+// awsSign signs request with SigV4 for service and region explicitly, so that custom endpoints work as well
+func awsSign(keys *AWSKeys, service, region string, req *http.Request) error {
+
+	if !utils.IsEmpty(keys.SessionToken) {
+		req.Header.Set("X-Amz-Security-Token", keys.SessionToken)
+	}
+	sv := &AWSService{Name: service, Region: region}
+	return sv.awsSignService(keys, req)
+}
+
+func awsError(service string, status int, body []byte) error {
+
+	var code, message string
+	var e awsErrorResponse
+	if xml.Unmarshal(body, &e) == nil {
+		code, message = e.Error.Code, e.Error.Message
+		if utils.IsEmpty(code) {
+			code, message = e.Code, e.Message
+		}
+	}
+	// error of successful response
+	if status == 0 {
+		if !utils.IsEmpty(code) {
+			return fmt.Errorf("AWS %s %s: %s", service, code, message)
+		}
+		return fmt.Errorf("AWS %s: %s", service, string(body))
+	}
+	err := common.NewAPIError(status, body)
+	if !utils.IsEmpty(code) {
+		err.Code, err.Message = code, message
+	}
+	return err
+}
@@ -0,0 +1,320 @@
+package vendors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const awsSNSVersion = "2010-03-31"
+
+type AWSMessagingOptions struct {
+	Timeout  int
+	Insecure bool
+	Region   string
+	Endpoint string
+	AWSKeys
+}
+
+type AWSSNSPublishOptions struct {
+	TopicARN        string
+	Message         string
+	Subject         string
+	Attributes      string
+	GroupID         string
+	DeduplicationID string
+}
+
+type AWSSQSSendOptions struct {
+	QueueURL        string
+	Body            string
+	DelaySeconds    int
+	Attributes      string
+	GroupID         string
+	DeduplicationID string
+}
+
+type AWSSQSReceiveOptions struct {
+	QueueURL          string
+	MaxMessages       int
+	WaitSeconds       int
+	VisibilityTimeout int
+	Delete            bool
+}
+
+type awsSNSPublishResponse struct {
+	MessageID      string `xml:"PublishResult>MessageId" json:"messageId"`
+	SequenceNumber string `xml:"PublishResult>SequenceNumber" json:"sequenceNumber,omitempty"`
+	RequestID      string `xml:"ResponseMetadata>RequestId" json:"requestId"`
+}
+
+type awsSQSMessageAttribute struct {
+	DataType    string `json:"DataType"`
+	StringValue string `json:"StringValue"`
+}
+
+type awsSQSMessage struct {
+	MessageID     string `json:"MessageId"`
+	ReceiptHandle string `json:"ReceiptHandle"`
+}
+
+type AWSMessaging struct {
+	client      *http.Client
+	options     AWSMessagingOptions
+	credentials *awsCredentials
+}
+
+func (a *AWSMessaging) endpoint(opts AWSMessagingOptions, service string) string {
+
+	if !utils.IsEmpty(opts.Endpoint) {
+		return opts.Endpoint
+	}
+	return fmt.Sprintf("https://%s.%s.amazonaws.com/", service, opts.Region)
+}
+
+func (a *AWSMessaging) request(opts AWSMessagingOptions, service string, headers map[string]string, data []byte) ([]byte, error) {
+
+	if utils.IsEmpty(opts.Region) {
+		return nil, errors.New("no AWS region")
+	}
+	keys, err := a.credentials.get(opts.Region, opts.AWSKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", a.endpoint(opts, service), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	err = awsSign(keys, service, opts.Region, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, awsError(service, resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+func awsAttributes(s string) map[string]string {
+
+	if utils.IsEmpty(s) {
+		return nil
+	}
+	return utils.MapGetKeyValues(s)
+}
+
+func awsSortedKeys(m map[string]string) []string {
+
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// https://docs.aws.amazon.com/sns/latest/api/API_Publish.html
+
+func (a *AWSMessaging) CustomPublishSNS(awsOptions AWSMessagingOptions, publishOptions AWSSNSPublishOptions) ([]byte, error) {
+
+	if utils.IsEmpty(publishOptions.TopicARN) {
+		return nil, errors.New("no topic ARN")
+	}
+	if utils.IsEmpty(publishOptions.Message) {
+		return nil, errors.New("no message")
+	}
+
+	params := make(url.Values)
+	params.Add("Action", "Publish")
+	params.Add("Version", awsSNSVersion)
+	params.Add("TopicArn", publishOptions.TopicARN)
+	params.Add("Message", publishOptions.Message)
+	if !utils.IsEmpty(publishOptions.Subject) {
+		params.Add("Subject", publishOptions.Subject)
+	}
+	if !utils.IsEmpty(publishOptions.GroupID) {
+		params.Add("MessageGroupId", publishOptions.GroupID)
+	}
+	if !utils.IsEmpty(publishOptions.DeduplicationID) {
+		params.Add("MessageDeduplicationId", publishOptions.DeduplicationID)
+	}
+	attributes := awsAttributes(publishOptions.Attributes)
+	for i, k := range awsSortedKeys(attributes) {
+		prefix := fmt.Sprintf("MessageAttributes.entry.%d", i+1)
+		params.Add(prefix+".Name", k)
+		params.Add(prefix+".Value.DataType", "String")
+		params.Add(prefix+".Value.StringValue", attributes[k])
+	}
+
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	data, err := a.request(awsOptions, "sns", headers, []byte(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var r awsSNSPublishResponse
+	err = xml.Unmarshal(data, &r)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&r)
+}
+
+func (a *AWSMessaging) PublishSNS(publishOptions AWSSNSPublishOptions) ([]byte, error) {
+	return a.CustomPublishSNS(a.options, publishOptions)
+}
+
+// SQS JSON protocol
+// https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/sqs-json-faqs.html
+func (a *AWSMessaging) sqs(awsOptions AWSMessagingOptions, action string, request map[string]interface{}) ([]byte, error) {
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{
+		"Content-Type": "application/x-amz-json-1.0",
+		"X-Amz-Target": fmt.Sprintf("AmazonSQS.%s", action),
+	}
+	return a.request(awsOptions, "sqs", headers, data)
+}
+
+// https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_SendMessage.html
+
+func (a *AWSMessaging) CustomSendSQS(awsOptions AWSMessagingOptions, sendOptions AWSSQSSendOptions) ([]byte, error) {
+
+	if utils.IsEmpty(sendOptions.QueueURL) {
+		return nil, errors.New("no queue URL")
+	}
+	if utils.IsEmpty(sendOptions.Body) {
+		return nil, errors.New("no message body")
+	}
+
+	request := map[string]interface{}{
+		"QueueUrl":    sendOptions.QueueURL,
+		"MessageBody": sendOptions.Body,
+	}
+	if sendOptions.DelaySeconds > 0 {
+		request["DelaySeconds"] = sendOptions.DelaySeconds
+	}
+	if !utils.IsEmpty(sendOptions.GroupID) {
+		request["MessageGroupId"] = sendOptions.GroupID
+	}
+	if !utils.IsEmpty(sendOptions.DeduplicationID) {
+		request["MessageDeduplicationId"] = sendOptions.DeduplicationID
+	}
+	attributes := awsAttributes(sendOptions.Attributes)
+	if len(attributes) > 0 {
+		m := make(map[string]*awsSQSMessageAttribute)
+		for k, v := range attributes {
+			m[k] = &awsSQSMessageAttribute{DataType: "String", StringValue: v}
+		}
+		request["MessageAttributes"] = m
+	}
+	return a.sqs(awsOptions, "SendMessage", request)
+}
+
+func (a *AWSMessaging) SendSQS(sendOptions AWSSQSSendOptions) ([]byte, error) {
+	return a.CustomSendSQS(a.options, sendOptions)
+}
+
+// https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_ReceiveMessage.html
+// https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_DeleteMessage.html
+
+func (a *AWSMessaging) CustomReceiveSQS(awsOptions AWSMessagingOptions, receiveOptions AWSSQSReceiveOptions) ([]byte, error) {
+
+	if utils.IsEmpty(receiveOptions.QueueURL) {
+		return nil, errors.New("no queue URL")
+	}
+
+	request := map[string]interface{}{
+		"QueueUrl":              receiveOptions.QueueURL,
+		"MessageAttributeNames": []string{"All"},
+		"AttributeNames":        []string{"All"},
+	}
+	if receiveOptions.MaxMessages > 0 {
+		request["MaxNumberOfMessages"] = receiveOptions.MaxMessages
+	}
+	if receiveOptions.WaitSeconds > 0 {
+		request["WaitTimeSeconds"] = receiveOptions.WaitSeconds
+	}
+	if receiveOptions.VisibilityTimeout > 0 {
+		request["VisibilityTimeout"] = receiveOptions.VisibilityTimeout
+	}
+
+	data, err := a.sqs(awsOptions, "ReceiveMessage", request)
+	if err != nil {
+		return nil, err
+	}
+	if !receiveOptions.Delete {
+		return data, nil
+	}
+
+	var r struct {
+		Messages []*awsSQSMessage `json:"Messages"`
+	}
+	err = json.Unmarshal(data, &r)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range r.Messages {
+		_, err := a.sqs(awsOptions, "DeleteMessage", map[string]interface{}{
+			"QueueUrl":      receiveOptions.QueueURL,
+			"ReceiptHandle": m.ReceiptHandle,
+		})
+		if err != nil {
+			return data, fmt.Errorf("AWS sqs delete message %s: %s", m.MessageID, err)
+		}
+	}
+	return data, nil
+}
+
+func (a *AWSMessaging) ReceiveSQS(receiveOptions AWSSQSReceiveOptions) ([]byte, error) {
+	return a.CustomReceiveSQS(a.options, receiveOptions)
+}
+
+func (a *AWSMessaging) WithContext(ctx context.Context) *AWSMessaging {
+	c := *a
+	c.client = common.HttpClientContext(a.client, ctx)
+	return &c
+}
+
+func NewAWSMessaging(options AWSMessagingOptions) *AWSMessaging {
+
+	// long polling receive waits up to 20 seconds
+	timeout := options.Timeout
+	if timeout > 0 && timeout < 25 {
+		timeout = 25
+	}
+	client := common.NewHttpClient(timeout, options.Insecure)
+	return &AWSMessaging{
+		client:      client,
+		options:     options,
+		credentials: &awsCredentials{client: client},
+	}
+}
@@ -0,0 +1,552 @@
+package vendors
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const (
+	awsS3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	awsS3EmptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	awsS3MinPartSize     = 5
+	awsS3MaxPresign      = 7 * 24 * 3600
+)
+
+type AWSS3Options struct {
+	Timeout  int
+	Insecure bool
+	Region   string
+	Endpoint string
+	Bucket   string
+	PartSize int
+	AWSKeys
+}
+
+type AWSS3UploadOptions struct {
+	Key         string
+	File        string
+	ContentType string
+	Presign     int
+}
+
+type AWSS3DownloadOptions struct {
+	Key  string
+	File string
+}
+
+type AWSS3ListOptions struct {
+	Prefix  string
+	MaxKeys int
+}
+
+type AWSS3PresignOptions struct {
+	Key     string
+	Method  string
+	Expires int
+}
+
+type AWSS3Object struct {
+	Key          string `xml:"Key" json:"key"`
+	Size         int64  `xml:"Size" json:"size"`
+	LastModified string `xml:"LastModified" json:"lastModified"`
+	ETag         string `xml:"ETag" json:"etag"`
+	StorageClass string `xml:"StorageClass" json:"storageClass,omitempty"`
+}
+
+type AWSS3Result struct {
+	Bucket       string `json:"bucket"`
+	Key          string `json:"key"`
+	File         string `json:"file,omitempty"`
+	Size         int64  `json:"size"`
+	ETag         string `json:"etag,omitempty"`
+	ContentType  string `json:"contentType,omitempty"`
+	Parts        int    `json:"parts,omitempty"`
+	URL          string `json:"url"`
+	PresignedURL string `json:"presignedUrl,omitempty"`
+	Expires      string `json:"expires,omitempty"`
+}
+
+type awsS3ListResponse struct {
+	Contents              []*AWSS3Object `xml:"Contents"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	NextContinuationToken string         `xml:"NextContinuationToken"`
+}
+
+type awsS3InitiateResponse struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type awsS3CompletePart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type awsS3CompleteRequest struct {
+	XMLName xml.Name             `xml:"CompleteMultipartUpload"`
+	Parts   []*awsS3CompletePart `xml:"Part"`
+}
+
+type AWSS3 struct {
+	client      *http.Client
+	options     AWSS3Options
+	credentials *awsCredentials
+}
+
+// awsS3Escape escapes path as S3 canonical URI, keeping slashes
+func awsS3Escape(path string) string {
+
+	var b strings.Builder
+	for _, c := range []byte(path) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// url is virtual-hosted style for AWS, path style for custom endpoints like MinIO
+func (s *AWSS3) url(opts AWSS3Options, key string) (*url.URL, error) {
+
+	if utils.IsEmpty(opts.Bucket) {
+		return nil, errors.New("no S3 bucket")
+	}
+
+	var u *url.URL
+	path := "/" + strings.TrimPrefix(key, "/")
+	if utils.IsEmpty(opts.Endpoint) {
+		u = &url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", opts.Bucket, opts.Region)}
+	} else {
+		var err error
+		u, err = url.Parse(opts.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/")+"/"+opts.Bucket+path, "/")
+	}
+	u.Path = path
+	u.RawPath = awsS3Escape(path)
+	return u, nil
+}
+
+func (s *AWSS3) request(opts AWSS3Options, method string, u *url.URL, headers map[string]string, body io.Reader, length int64, hash string) (*http.Response, error) {
+
+	if utils.IsEmpty(opts.Region) {
+		return nil, errors.New("no AWS region")
+	}
+	keys, err := s.credentials.get(opts.Region, opts.AWSKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if body == nil || length == 0 {
+		body = http.NoBody
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = length
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("X-Amz-Content-Sha256", hash)
+	err = awsSign(keys, "s3", opts.Region, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, awsError("s3", resp.StatusCode, data)
+	}
+	return resp, nil
+}
+
+func (s *AWSS3) requestBytes(opts AWSS3Options, method string, u *url.URL, headers map[string]string, data []byte) ([]byte, error) {
+
+	h := sha256.Sum256(data)
+	resp, err := s.request(opts, method, u, headers, bytes.NewReader(data), int64(len(data)), hex.EncodeToString(h[:]))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
+func (s *AWSS3) presign(opts AWSS3Options, method string, u *url.URL, expires int) (string, time.Time, error) {
+
+	if expires <= 0 || expires > awsS3MaxPresign {
+		return "", time.Time{}, fmt.Errorf("S3 presign expires should be between 1 and %d seconds", awsS3MaxPresign)
+	}
+	if utils.IsEmpty(opts.Region) {
+		return "", time.Time{}, errors.New("no AWS region")
+	}
+	keys, err := s.credentials.get(opts.Region, opts.AWSKeys)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	t := time.Now().UTC()
+	sv := &AWSService{Name: "s3", Region: opts.Region}
+	params := make(url.Values)
+	params.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	params.Set("X-Amz-Credential", keys.AccessKey+"/"+sv.creds(t))
+	params.Set("X-Amz-Date", t.Format(iSO8601BasicFormat))
+	params.Set("X-Amz-Expires", strconv.Itoa(expires))
+	params.Set("X-Amz-SignedHeaders", "host")
+	if !utils.IsEmpty(keys.SessionToken) {
+		params.Set("X-Amz-Security-Token", keys.SessionToken)
+	}
+	query := strings.ReplaceAll(params.Encode(), "+", "%20")
+
+	canonical := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		query,
+		"host:" + u.Host + "\n",
+		"host",
+		awsS3UnsignedPayload,
+	}, "\n")
+	h := sha256.Sum256([]byte(canonical))
+	toSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		t.Format(iSO8601BasicFormat),
+		sv.creds(t),
+		hex.EncodeToString(h[:]),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, keys.sign(sv, t))
+	mac.Write([]byte(toSign))
+
+	signed := *u
+	signed.RawQuery = query + "&X-Amz-Signature=" + hex.EncodeToString(mac.Sum(nil))
+	return signed.String(), t.Add(time.Duration(expires) * time.Second), nil
+}
+
+func (s *AWSS3) withQuery(u *url.URL, params url.Values) *url.URL {
+
+	p := *u
+	p.RawQuery = strings.ReplaceAll(params.Encode(), "+", "%20")
+	return &p
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/mpuoverview.html
+// parts are streamed from file one by one, upload is aborted on failure
+func (s *AWSS3) multipartUpload(opts AWSS3Options, u *url.URL, f *os.File, size, partSize int64, contentType string) (string, int, error) {
+
+	data, err := s.requestBytes(opts, "POST", s.withQuery(u, url.Values{"uploads": {""}}), map[string]string{"Content-Type": contentType}, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	var initiate awsS3InitiateResponse
+	err = xml.Unmarshal(data, &initiate)
+	if err != nil {
+		return "", 0, err
+	}
+	if utils.IsEmpty(initiate.UploadID) {
+		return "", 0, errors.New("S3 returned no upload ID")
+	}
+
+	abort := func(err error) (string, int, error) {
+		if _, aerr := s.requestBytes(opts, "DELETE", s.withQuery(u, url.Values{"uploadId": {initiate.UploadID}}), nil, nil); aerr != nil {
+			return "", 0, fmt.Errorf("%s, abort: %s", err, aerr)
+		}
+		return "", 0, err
+	}
+
+	complete := &awsS3CompleteRequest{}
+	for offset, n := int64(0), 1; offset < size; offset, n = offset+partSize, n+1 {
+
+		length := partSize
+		if offset+length > size {
+			length = size - offset
+		}
+		params := url.Values{
+			"partNumber": {strconv.Itoa(n)},
+			"uploadId":   {initiate.UploadID},
+		}
+		resp, err := s.request(opts, "PUT", s.withQuery(u, params), nil, io.NewSectionReader(f, offset, length), length, awsS3UnsignedPayload)
+		if err != nil {
+			return abort(fmt.Errorf("S3 part %d: %s", n, err))
+		}
+		resp.Body.Close()
+		complete.Parts = append(complete.Parts, &awsS3CompletePart{PartNumber: n, ETag: resp.Header.Get("ETag")})
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return abort(err)
+	}
+	data, err = s.requestBytes(opts, "POST", s.withQuery(u, url.Values{"uploadId": {initiate.UploadID}}), map[string]string{"Content-Type": "application/xml"}, body)
+	if err != nil {
+		return abort(err)
+	}
+	// complete may fail with 200 and error in body
+	if bytes.Contains(data, []byte("<Error>")) {
+		return abort(awsError("s3", 0, data))
+	}
+	var r struct {
+		ETag string `xml:"ETag"`
+	}
+	xml.Unmarshal(data, &r)
+	return r.ETag, len(complete.Parts), nil
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObject.html
+
+func (s *AWSS3) CustomUpload(s3Options AWSS3Options, uploadOptions AWSS3UploadOptions) ([]byte, error) {
+
+	if utils.IsEmpty(uploadOptions.File) {
+		return nil, errors.New("no file")
+	}
+	key := uploadOptions.Key
+	if utils.IsEmpty(key) {
+		key = filepath.Base(uploadOptions.File)
+	}
+	u, err := s.url(s3Options, key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(uploadOptions.File)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := stat.Size()
+
+	contentType := uploadOptions.ContentType
+	if utils.IsEmpty(contentType) {
+		contentType = mime.TypeByExtension(filepath.Ext(uploadOptions.File))
+	}
+	if utils.IsEmpty(contentType) {
+		contentType = "application/octet-stream"
+	}
+
+	partSize := int64(s3Options.PartSize)
+	if partSize < awsS3MinPartSize {
+		partSize = awsS3MinPartSize
+	}
+	partSize = partSize * 1024 * 1024
+
+	r := &AWSS3Result{
+		Bucket:      s3Options.Bucket,
+		Key:         key,
+		File:        uploadOptions.File,
+		Size:        size,
+		ContentType: contentType,
+		URL:         u.String(),
+	}
+
+	if size > partSize {
+		r.ETag, r.Parts, err = s.multipartUpload(s3Options, u, f, size, partSize, contentType)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		resp, err := s.request(s3Options, "PUT", u, map[string]string{"Content-Type": contentType}, f, size, awsS3UnsignedPayload)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		r.ETag = resp.Header.Get("ETag")
+	}
+
+	if uploadOptions.Presign > 0 {
+		presigned, expires, err := s.presign(s3Options, "GET", u, uploadOptions.Presign)
+		if err != nil {
+			return nil, err
+		}
+		r.PresignedURL = presigned
+		r.Expires = expires.Format(time.RFC3339)
+	}
+	return json.Marshal(r)
+}
+
+func (s *AWSS3) Upload(uploadOptions AWSS3UploadOptions) ([]byte, error) {
+	return s.CustomUpload(s.options, uploadOptions)
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObject.html
+
+func (s *AWSS3) CustomDownload(s3Options AWSS3Options, downloadOptions AWSS3DownloadOptions) ([]byte, error) {
+
+	if utils.IsEmpty(downloadOptions.Key) {
+		return nil, errors.New("no S3 key")
+	}
+	u, err := s.url(s3Options, downloadOptions.Key)
+	if err != nil {
+		return nil, err
+	}
+	file := downloadOptions.File
+	if utils.IsEmpty(file) {
+		file = filepath.Base(downloadOptions.Key)
+	}
+
+	resp, err := s.request(s3Options, "GET", u, nil, nil, 0, awsS3EmptyPayload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// write and rename, so that partial download doesn't replace file
+	tmp := file + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, err
+	}
+	size, err := io.Copy(f, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, file)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	return json.Marshal(&AWSS3Result{
+		Bucket:      s3Options.Bucket,
+		Key:         downloadOptions.Key,
+		File:        file,
+		Size:        size,
+		ETag:        resp.Header.Get("ETag"),
+		ContentType: resp.Header.Get("Content-Type"),
+		URL:         u.String(),
+	})
+}
+
+func (s *AWSS3) Download(downloadOptions AWSS3DownloadOptions) ([]byte, error) {
+	return s.CustomDownload(s.options, downloadOptions)
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjectsV2.html
+
+func (s *AWSS3) CustomList(s3Options AWSS3Options, listOptions AWSS3ListOptions) ([]byte, error) {
+
+	u, err := s.url(s3Options, "")
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []*AWSS3Object{}
+	token := ""
+	for {
+		params := url.Values{"list-type": {"2"}}
+		if !utils.IsEmpty(listOptions.Prefix) {
+			params.Set("prefix", listOptions.Prefix)
+		}
+		if listOptions.MaxKeys > 0 {
+			params.Set("max-keys", strconv.Itoa(listOptions.MaxKeys-len(objects)))
+		}
+		if !utils.IsEmpty(token) {
+			params.Set("continuation-token", token)
+		}
+
+		data, err := s.requestBytes(s3Options, "GET", s.withQuery(u, params), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var r awsS3ListResponse
+		err = xml.Unmarshal(data, &r)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, r.Contents...)
+
+		if !r.IsTruncated || utils.IsEmpty(r.NextContinuationToken) {
+			break
+		}
+		if listOptions.MaxKeys > 0 && len(objects) >= listOptions.MaxKeys {
+			break
+		}
+		token = r.NextContinuationToken
+	}
+	return json.Marshal(objects)
+}
+
+func (s *AWSS3) List(listOptions AWSS3ListOptions) ([]byte, error) {
+	return s.CustomList(s.options, listOptions)
+}
+
+func (s *AWSS3) CustomPresign(s3Options AWSS3Options, presignOptions AWSS3PresignOptions) ([]byte, error) {
+
+	if utils.IsEmpty(presignOptions.Key) {
+		return nil, errors.New("no S3 key")
+	}
+	u, err := s.url(s3Options, presignOptions.Key)
+	if err != nil {
+		return nil, err
+	}
+	method := strings.ToUpper(presignOptions.Method)
+	if utils.IsEmpty(method) {
+		method = "GET"
+	}
+
+	presigned, expires, err := s.presign(s3Options, method, u, presignOptions.Expires)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&AWSS3Result{
+		Bucket:       s3Options.Bucket,
+		Key:          presignOptions.Key,
+		URL:          u.String(),
+		PresignedURL: presigned,
+		Expires:      expires.Format(time.RFC3339),
+	})
+}
+
+func (s *AWSS3) Presign(presignOptions AWSS3PresignOptions) ([]byte, error) {
+	return s.CustomPresign(s.options, presignOptions)
+}
+
+func (s *AWSS3) WithContext(ctx context.Context) *AWSS3 {
+	c := *s
+	c.client = common.HttpClientContext(s.client, ctx)
+	return &c
+}
+
+func NewAWSS3(options AWSS3Options) *AWSS3 {
+
+	client := common.NewHttpClient(options.Timeout, options.Insecure)
+	return &AWSS3{
+		client:      client,
+		options:     options,
+		credentials: &awsCredentials{client: client},
+	}
+}
@@ -0,0 +1,235 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+	"gopkg.in/yaml.v3"
+)
+
+type AWXOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	Token    string
+	User     string
+	Password string
+}
+
+type AWXLaunchOptions struct {
+	JobTemplateID string
+	ExtraVars     string
+	Limit         string
+	Inventory     string
+	Wait          bool
+	WaitTimeout   int
+	PollInterval  int
+}
+
+type AWXJobOptions struct {
+	ID string
+}
+
+type AWXLaunch struct {
+	ExtraVars interface{} `json:"extra_vars,omitempty"`
+	Limit     string      `json:"limit,omitempty"`
+	Inventory string      `json:"inventory,omitempty"`
+}
+
+type AWXJob struct {
+	ID       int    `json:"id"`
+	Job      int    `json:"job"`
+	Status   string `json:"status"`
+	Failed   bool   `json:"failed"`
+	Finished string `json:"finished"`
+}
+
+type AWX struct {
+	client  *http.Client
+	options AWXOptions
+	logger  common.Logger
+}
+
+func (a *AWX) getAuth(opts AWXOptions) string {
+
+	if !utils.IsEmpty(opts.Token) {
+		return fmt.Sprintf("Bearer %s", opts.Token)
+	}
+	if !utils.IsEmpty(opts.User) {
+		return common.FormatBasicAuth(opts.User, opts.Password)
+	}
+	return ""
+}
+
+func (a *AWX) apiURL(opts AWXOptions, p string, params url.Values) (string, error) {
+
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return "", err
+	}
+	// AWX requires trailing slash
+	u.Path = path.Join(u.Path, p) + "/"
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+	return u.String(), nil
+}
+
+func (a *AWX) jobFinished(status string) bool {
+	return status == "successful" || status == "failed" || status == "error" || status == "canceled"
+}
+
+// extra vars are accepted as json or yaml
+func (a *AWX) extraVars(s string) (interface{}, error) {
+
+	if utils.IsEmpty(s) {
+		return nil, nil
+	}
+	var v interface{}
+	err := yaml.Unmarshal([]byte(s), &v)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (a *AWX) waitJob(opts AWXOptions, id int, launchOptions AWXLaunchOptions) ([]byte, error) {
+
+	interval := time.Duration(launchOptions.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(launchOptions.WaitTimeout) * time.Second)
+
+	for {
+		data, err := a.CustomGetJob(opts, AWXJobOptions{ID: fmt.Sprintf("%d", id)})
+		if err != nil {
+			return nil, err
+		}
+
+		var job AWXJob
+		err = json.Unmarshal(data, &job)
+		if err != nil {
+			return nil, err
+		}
+		if a.logger != nil {
+			a.logger.Debug("AWX job %d is %s", id, job.Status)
+		}
+
+		if a.jobFinished(job.Status) {
+			if job.Status != "successful" {
+				return data, fmt.Errorf("AWX job %d is %s", id, job.Status)
+			}
+			return data, nil
+		}
+
+		if launchOptions.WaitTimeout > 0 && time.Now().After(deadline) {
+			return data, fmt.Errorf("AWX job %d is still %s after %d seconds", id, job.Status, launchOptions.WaitTimeout)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// https://docs.ansible.com/ansible-tower/latest/html/towerapi/api_ref.html#/Job_Templates/Job_Templates_job_templates_launch_create
+
+func (a *AWX) CustomLaunchJobTemplate(awxOptions AWXOptions, launchOptions AWXLaunchOptions) ([]byte, error) {
+
+	if utils.IsEmpty(launchOptions.JobTemplateID) {
+		return nil, errors.New("no job template ID")
+	}
+
+	vars, err := a.extraVars(launchOptions.ExtraVars)
+	if err != nil {
+		return nil, err
+	}
+
+	launch := &AWXLaunch{
+		ExtraVars: vars,
+		Limit:     launchOptions.Limit,
+		Inventory: launchOptions.Inventory,
+	}
+	data, err := json.Marshal(launch)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := a.apiURL(awxOptions, fmt.Sprintf("/api/v2/job_templates/%s/launch", url.PathEscape(launchOptions.JobTemplateID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := common.HttpPostRaw(a.client, u, "application/json", a.getAuth(awxOptions), data)
+	if err != nil {
+		return nil, err
+	}
+	if !launchOptions.Wait {
+		return resp, nil
+	}
+
+	var job AWXJob
+	err = json.Unmarshal(resp, &job)
+	if err != nil {
+		return nil, err
+	}
+	id := job.Job
+	if id == 0 {
+		id = job.ID
+	}
+	return a.waitJob(awxOptions, id, launchOptions)
+}
+
+func (a *AWX) LaunchJobTemplate(launchOptions AWXLaunchOptions) ([]byte, error) {
+	return a.CustomLaunchJobTemplate(a.options, launchOptions)
+}
+
+func (a *AWX) CustomGetJob(awxOptions AWXOptions, jobOptions AWXJobOptions) ([]byte, error) {
+
+	u, err := a.apiURL(awxOptions, fmt.Sprintf("/api/v2/jobs/%s", url.PathEscape(jobOptions.ID)), nil)
+	if err != nil {
+		return nil, err
+	}
+	return common.HttpGetRaw(a.client, u, "application/json", a.getAuth(awxOptions))
+}
+
+func (a *AWX) GetJob(jobOptions AWXJobOptions) ([]byte, error) {
+	return a.CustomGetJob(a.options, jobOptions)
+}
+
+func (a *AWX) CustomGetJobStdout(awxOptions AWXOptions, jobOptions AWXJobOptions) ([]byte, error) {
+
+	params := make(url.Values)
+	params.Add("format", "txt")
+
+	u, err := a.apiURL(awxOptions, fmt.Sprintf("/api/v2/jobs/%s/stdout", url.PathEscape(jobOptions.ID)), params)
+	if err != nil {
+		return nil, err
+	}
+	return common.HttpGetRaw(a.client, u, "text/plain", a.getAuth(awxOptions))
+}
+
+func (a *AWX) GetJobStdout(jobOptions AWXJobOptions) ([]byte, error) {
+	return a.CustomGetJobStdout(a.options, jobOptions)
+}
+
+func (a *AWX) WithContext(ctx context.Context) *AWX {
+	c := *a
+	c.client = common.HttpClientContext(a.client, ctx)
+	return &c
+}
+
+func NewAWX(options AWXOptions, logger common.Logger) *AWX {
+
+	return &AWX{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+		logger:  logger,
+	}
+}
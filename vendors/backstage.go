@@ -0,0 +1,180 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+// annotations used to get service routing from Backstage component
+const (
+	backstageChannelAnnotationPrefix = "tools/channel."
+	backstageEscalationAnnotation    = "tools/escalation"
+	backstageDashboardLinkType       = "dashboard"
+)
+
+type BackstageOptions struct {
+	Timeout   int
+	Insecure  bool
+	URL       string
+	Token     string
+	Namespace string
+}
+
+type BackstageEntityLink struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	Type  string `json:"type"`
+}
+
+type BackstageEntityMetadata struct {
+	Name        string                 `json:"name"`
+	Namespace   string                 `json:"namespace"`
+	Labels      map[string]string      `json:"labels"`
+	Annotations map[string]string      `json:"annotations"`
+	Links       []*BackstageEntityLink `json:"links"`
+}
+
+type BackstageEntitySpec struct {
+	Owner string `json:"owner"`
+}
+
+type BackstageEntity struct {
+	Kind     string                   `json:"kind"`
+	Metadata *BackstageEntityMetadata `json:"metadata"`
+	Spec     *BackstageEntitySpec     `json:"spec"`
+}
+
+type Backstage struct {
+	client  *http.Client
+	options BackstageOptions
+}
+
+func (b *Backstage) getAuth(opts BackstageOptions) string {
+
+	auth := ""
+	if !utils.IsEmpty(opts.Token) {
+		auth = fmt.Sprintf("Bearer %s", opts.Token)
+	}
+	return auth
+}
+
+func (b *Backstage) toService(e *BackstageEntity) *common.Service {
+
+	s := &common.Service{
+		Channels: make(map[string]string),
+	}
+	if e.Metadata != nil {
+		s.Name = e.Metadata.Name
+		s.Labels = e.Metadata.Labels
+		for k, v := range e.Metadata.Annotations {
+			if strings.HasPrefix(k, backstageChannelAnnotationPrefix) {
+				s.Channels[strings.TrimPrefix(k, backstageChannelAnnotationPrefix)] = v
+			}
+		}
+		s.Escalation = e.Metadata.Annotations[backstageEscalationAnnotation]
+		for _, l := range e.Metadata.Links {
+			if l != nil && l.Type == backstageDashboardLinkType {
+				s.Dashboards = append(s.Dashboards, l.URL)
+			}
+		}
+	}
+	if e.Spec != nil {
+		s.Team = e.Spec.Owner
+	}
+	return s
+}
+
+// https://backstage.io/docs/features/software-catalog/software-catalog-api
+
+func (b *Backstage) CustomGetService(options BackstageOptions, name string) (*common.Service, error) {
+
+	u, err := url.Parse(options.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := options.Namespace
+	if utils.IsEmpty(namespace) {
+		namespace = "default"
+	}
+	u.Path = path.Join(u.Path, "/api/catalog/entities/by-name/component", namespace, name)
+
+	data, err := common.HttpGetRaw(b.client, u.String(), "application/json", b.getAuth(options))
+	if err != nil {
+		return nil, err
+	}
+
+	var e BackstageEntity
+	err = json.Unmarshal(data, &e)
+	if err != nil {
+		return nil, err
+	}
+	return b.toService(&e), nil
+}
+
+func (b *Backstage) Get(name string) (*common.Service, error) {
+	return b.CustomGetService(b.options, name)
+}
+
+func (b *Backstage) CustomListServices(options BackstageOptions) ([]*common.Service, error) {
+
+	u, err := url.Parse(options.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "/api/catalog/entities")
+
+	params := make(url.Values)
+	params.Add("filter", "kind=component")
+	u.RawQuery = params.Encode()
+
+	data, err := common.HttpGetRaw(b.client, u.String(), "application/json", b.getAuth(options))
+	if err != nil {
+		return nil, err
+	}
+
+	var entities []*BackstageEntity
+	err = json.Unmarshal(data, &entities)
+	if err != nil {
+		return nil, err
+	}
+
+	r := []*common.Service{}
+	for _, e := range entities {
+		if e == nil {
+			continue
+		}
+		r = append(r, b.toService(e))
+	}
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Name < r[j].Name
+	})
+	return r, nil
+}
+
+func (b *Backstage) List() ([]*common.Service, error) {
+	return b.CustomListServices(b.options)
+}
+
+func (b *Backstage) WithContext(ctx context.Context) *Backstage {
+	c := *b
+	c.client = common.HttpClientContext(b.client, ctx)
+	return &c
+}
+
+func NewBackstage(options BackstageOptions) *Backstage {
+
+	return &Backstage{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
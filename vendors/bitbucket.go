@@ -0,0 +1,546 @@
+package vendors
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const bitbucketCloudHost = "api.bitbucket.org"
+
+type BitbucketOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	Token    string
+	User     string
+	Password string
+}
+
+// BitbucketRepoOptions has workspace of Cloud or project key of Server
+type BitbucketRepoOptions struct {
+	Workspace string
+	Repo      string
+}
+
+type BitbucketPullRequestOptions struct {
+	Title             string
+	Description       string
+	Source            string
+	Destination       string
+	Reviewers         []string
+	CloseSourceBranch bool
+}
+
+type BitbucketMergeOptions struct {
+	ID                int
+	Strategy          string
+	Message           string
+	CloseSourceBranch bool
+}
+
+type BitbucketBuildStatusOptions struct {
+	Commit      string
+	Key         string
+	State       string
+	Name        string
+	URL         string
+	Description string
+}
+
+type BitbucketVariableOptions struct {
+	Key     string
+	Value   string
+	Secured bool
+}
+
+type bitbucketBranch struct {
+	Name string `json:"name"`
+}
+
+type bitbucketEndpoint struct {
+	Branch bitbucketBranch `json:"branch"`
+}
+
+type bitbucketCloudReviewer struct {
+	UUID      string `json:"uuid,omitempty"`
+	AccountID string `json:"account_id,omitempty"`
+}
+
+type bitbucketCloudPullRequest struct {
+	Title             string                   `json:"title"`
+	Description       string                   `json:"description,omitempty"`
+	Source            bitbucketEndpoint        `json:"source"`
+	Destination       *bitbucketEndpoint       `json:"destination,omitempty"`
+	Reviewers         []bitbucketCloudReviewer `json:"reviewers,omitempty"`
+	CloseSourceBranch bool                     `json:"close_source_branch"`
+}
+
+type bitbucketCloudMerge struct {
+	Type              string `json:"type"`
+	MergeStrategy     string `json:"merge_strategy,omitempty"`
+	Message           string `json:"message,omitempty"`
+	CloseSourceBranch bool   `json:"close_source_branch"`
+}
+
+type bitbucketServerRef struct {
+	ID string `json:"id"`
+}
+
+type bitbucketServerUser struct {
+	Name string `json:"name"`
+}
+
+type bitbucketServerReviewer struct {
+	User bitbucketServerUser `json:"user"`
+}
+
+type bitbucketServerPullRequest struct {
+	Title       string                    `json:"title"`
+	Description string                    `json:"description,omitempty"`
+	FromRef     bitbucketServerRef        `json:"fromRef"`
+	ToRef       bitbucketServerRef        `json:"toRef"`
+	Reviewers   []bitbucketServerReviewer `json:"reviewers,omitempty"`
+}
+
+type bitbucketServerMerge struct {
+	Message    string `json:"message,omitempty"`
+	StrategyID string `json:"strategyId,omitempty"`
+}
+
+type bitbucketBuildStatus struct {
+	Key         string `json:"key"`
+	State       string `json:"state"`
+	Name        string `json:"name,omitempty"`
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type bitbucketVariable struct {
+	UUID    string `json:"uuid,omitempty"`
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+	Secured bool   `json:"secured"`
+}
+
+type bitbucketVariablesPage struct {
+	Values []*bitbucketVariable `json:"values"`
+	Next   string               `json:"next"`
+}
+
+type Bitbucket struct {
+	client  *http.Client
+	options BitbucketOptions
+}
+
+// isCloud is true for api.bitbucket.org or URLs of 2.0 API like proxies have,
+// other URLs are Bitbucket Server or Data Center
+func (b *Bitbucket) isCloud(opts BitbucketOptions) bool {
+
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Hostname(), bitbucketCloudHost) || strings.HasSuffix(strings.TrimRight(u.Path, "/"), "/2.0")
+}
+
+func (b *Bitbucket) headers(opts BitbucketOptions) map[string]string {
+
+	headers := make(map[string]string)
+	headers["Content-Type"] = "application/json"
+	headers["Accept"] = "application/json"
+	if !utils.IsEmpty(opts.Token) {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", opts.Token)
+	} else if !utils.IsEmpty(opts.User) {
+		userPass := fmt.Sprintf("%s:%s", opts.User, opts.Password)
+		headers["Authorization"] = fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(userPass)))
+	}
+	return headers
+}
+
+// request sends to path relative to URL, or to absolute URL of the next page
+func (b *Bitbucket) request(opts BitbucketOptions, method, p string, params url.Values, data []byte) ([]byte, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return nil, errors.New("no URL")
+	}
+	s := p
+	if !strings.HasPrefix(p, "http://") && !strings.HasPrefix(p, "https://") {
+		u, err := url.Parse(opts.URL)
+		if err != nil {
+			return nil, err
+		}
+		u.Path = path.Join(u.Path, p)
+		if params != nil {
+			u.RawQuery = params.Encode()
+		}
+		s = u.String()
+	}
+
+	r, err := common.HttpRequestRawWithHeaders(b.client, method, s, b.headers(opts), data)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (b *Bitbucket) repoPath(opts BitbucketOptions, repoOptions BitbucketRepoOptions) (string, error) {
+
+	if utils.IsEmpty(repoOptions.Workspace) || utils.IsEmpty(repoOptions.Repo) {
+		return "", errors.New("no workspace or repo")
+	}
+	if b.isCloud(opts) {
+		return fmt.Sprintf("/repositories/%s/%s", repoOptions.Workspace, repoOptions.Repo), nil
+	}
+	return fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s", repoOptions.Workspace, repoOptions.Repo), nil
+}
+
+func (b *Bitbucket) serverRef(branch string) bitbucketServerRef {
+
+	if strings.HasPrefix(branch, "refs/") {
+		return bitbucketServerRef{ID: branch}
+	}
+	return bitbucketServerRef{ID: fmt.Sprintf("refs/heads/%s", branch)}
+}
+
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-pullrequests/#api-repositories-workspace-repo-slug-pullrequests-post
+// https://developer.atlassian.com/server/bitbucket/rest/v906/api-group-pull-requests/#api-api-latest-projects-projectkey-repos-repositoryslug-pull-requests-post
+// destination is the main branch of repository if it's empty on Cloud
+
+func (b *Bitbucket) CustomCreatePullRequest(bitbucketOptions BitbucketOptions, repoOptions BitbucketRepoOptions, pullRequestOptions BitbucketPullRequestOptions) ([]byte, error) {
+
+	p, err := b.repoPath(bitbucketOptions, repoOptions)
+	if err != nil {
+		return nil, err
+	}
+	if utils.IsEmpty(pullRequestOptions.Title) || utils.IsEmpty(pullRequestOptions.Source) {
+		return nil, errors.New("no title or source branch")
+	}
+	reviewers := common.RemoveEmptyStrings(pullRequestOptions.Reviewers)
+
+	var data []byte
+	if b.isCloud(bitbucketOptions) {
+		pr := &bitbucketCloudPullRequest{
+			Title:             pullRequestOptions.Title,
+			Description:       pullRequestOptions.Description,
+			Source:            bitbucketEndpoint{Branch: bitbucketBranch{Name: pullRequestOptions.Source}},
+			CloseSourceBranch: pullRequestOptions.CloseSourceBranch,
+		}
+		if !utils.IsEmpty(pullRequestOptions.Destination) {
+			pr.Destination = &bitbucketEndpoint{Branch: bitbucketBranch{Name: pullRequestOptions.Destination}}
+		}
+		// reviewers are {uuid} or account IDs
+		for _, r := range reviewers {
+			if strings.HasPrefix(r, "{") {
+				pr.Reviewers = append(pr.Reviewers, bitbucketCloudReviewer{UUID: r})
+				continue
+			}
+			pr.Reviewers = append(pr.Reviewers, bitbucketCloudReviewer{AccountID: r})
+		}
+		data, err = json.Marshal(pr)
+		if err != nil {
+			return nil, err
+		}
+		return b.request(bitbucketOptions, "POST", path.Join(p, "/pullrequests"), nil, data)
+	}
+
+	if utils.IsEmpty(pullRequestOptions.Destination) {
+		return nil, errors.New("no destination branch")
+	}
+	pr := &bitbucketServerPullRequest{
+		Title:       pullRequestOptions.Title,
+		Description: pullRequestOptions.Description,
+		FromRef:     b.serverRef(pullRequestOptions.Source),
+		ToRef:       b.serverRef(pullRequestOptions.Destination),
+	}
+	for _, r := range reviewers {
+		pr.Reviewers = append(pr.Reviewers, bitbucketServerReviewer{User: bitbucketServerUser{Name: r}})
+	}
+	data, err = json.Marshal(pr)
+	if err != nil {
+		return nil, err
+	}
+	return b.request(bitbucketOptions, "POST", path.Join(p, "/pull-requests"), nil, data)
+}
+
+func (b *Bitbucket) CreatePullRequest(repoOptions BitbucketRepoOptions, pullRequestOptions BitbucketPullRequestOptions) ([]byte, error) {
+	return b.CustomCreatePullRequest(b.options, repoOptions, pullRequestOptions)
+}
+
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-pullrequests/#api-repositories-workspace-repo-slug-pullrequests-pull-request-id-merge-post
+// https://developer.atlassian.com/server/bitbucket/rest/v906/api-group-pull-requests/#api-api-latest-projects-projectkey-repos-repositoryslug-pull-requests-pullrequestid-merge-post
+// Server requires the current version of pull request, so that it's read before merge
+
+func (b *Bitbucket) CustomMergePullRequest(bitbucketOptions BitbucketOptions, repoOptions BitbucketRepoOptions, mergeOptions BitbucketMergeOptions) ([]byte, error) {
+
+	p, err := b.repoPath(bitbucketOptions, repoOptions)
+	if err != nil {
+		return nil, err
+	}
+	if mergeOptions.ID <= 0 {
+		return nil, errors.New("no pull request ID")
+	}
+
+	if b.isCloud(bitbucketOptions) {
+		merge := &bitbucketCloudMerge{
+			Type:              "pullrequest",
+			MergeStrategy:     mergeOptions.Strategy,
+			Message:           mergeOptions.Message,
+			CloseSourceBranch: mergeOptions.CloseSourceBranch,
+		}
+		data, err := json.Marshal(merge)
+		if err != nil {
+			return nil, err
+		}
+		return b.request(bitbucketOptions, "POST", path.Join(p, "/pullrequests", strconv.Itoa(mergeOptions.ID), "/merge"), nil, data)
+	}
+
+	prPath := path.Join(p, "/pull-requests", strconv.Itoa(mergeOptions.ID))
+	r, err := b.request(bitbucketOptions, "GET", prPath, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var pr struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(r, &pr); err != nil {
+		return nil, err
+	}
+
+	merge := &bitbucketServerMerge{
+		Message:    mergeOptions.Message,
+		StrategyID: mergeOptions.Strategy,
+	}
+	data, err := json.Marshal(merge)
+	if err != nil {
+		return nil, err
+	}
+	params := make(url.Values)
+	params.Set("version", strconv.Itoa(pr.Version))
+	r, err = b.request(bitbucketOptions, "POST", path.Join(prPath, "/merge"), params, data)
+	if err != nil {
+		return nil, err
+	}
+	if !mergeOptions.CloseSourceBranch {
+		return r, nil
+	}
+
+	var merged struct {
+		FromRef struct {
+			ID           string `json:"id"`
+			LatestCommit string `json:"latestCommit"`
+		} `json:"fromRef"`
+	}
+	if err := json.Unmarshal(r, &merged); err != nil {
+		return nil, err
+	}
+	branch, err := json.Marshal(map[string]interface{}{"name": merged.FromRef.ID, "endPoint": merged.FromRef.LatestCommit})
+	if err != nil {
+		return nil, err
+	}
+	branchPath := fmt.Sprintf("/rest/branch-utils/1.0/projects/%s/repos/%s/branches", repoOptions.Workspace, repoOptions.Repo)
+	if _, err := b.request(bitbucketOptions, "DELETE", branchPath, nil, branch); err != nil {
+		return nil, fmt.Errorf("bitbucket pull request is merged, but source branch is not deleted: %s", err)
+	}
+	return r, nil
+}
+
+func (b *Bitbucket) MergePullRequest(repoOptions BitbucketRepoOptions, mergeOptions BitbucketMergeOptions) ([]byte, error) {
+	return b.CustomMergePullRequest(b.options, repoOptions, mergeOptions)
+}
+
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-commit-statuses/#api-repositories-workspace-repo-slug-commit-commit-statuses-build-post
+// https://developer.atlassian.com/server/bitbucket/rest/v906/api-group-build-status/#api-build-status-1-0-commits-commitid-post
+// states are INPROGRESS, SUCCESSFUL, FAILED and STOPPED of Cloud only
+
+func (b *Bitbucket) CustomSetBuildStatus(bitbucketOptions BitbucketOptions, repoOptions BitbucketRepoOptions, statusOptions BitbucketBuildStatusOptions) ([]byte, error) {
+
+	if utils.IsEmpty(statusOptions.Commit) || utils.IsEmpty(statusOptions.Key) {
+		return nil, errors.New("no commit or key")
+	}
+	status := &bitbucketBuildStatus{
+		Key:         statusOptions.Key,
+		State:       strings.ToUpper(statusOptions.State),
+		Name:        statusOptions.Name,
+		URL:         statusOptions.URL,
+		Description: statusOptions.Description,
+	}
+	data, err := json.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.isCloud(bitbucketOptions) {
+		p, err := b.repoPath(bitbucketOptions, repoOptions)
+		if err != nil {
+			return nil, err
+		}
+		return b.request(bitbucketOptions, "POST", path.Join(p, "/commit", statusOptions.Commit, "/statuses/build"), nil, data)
+	}
+	// Server returns no content
+	_, err = b.request(bitbucketOptions, "POST", path.Join("/rest/build-status/1.0/commits", statusOptions.Commit), nil, data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (b *Bitbucket) SetBuildStatus(repoOptions BitbucketRepoOptions, statusOptions BitbucketBuildStatusOptions) ([]byte, error) {
+	return b.CustomSetBuildStatus(b.options, repoOptions, statusOptions)
+}
+
+func (b *Bitbucket) variablesPath(opts BitbucketOptions, repoOptions BitbucketRepoOptions) (string, error) {
+
+	if !b.isCloud(opts) {
+		return "", errors.New("bitbucket repository variables are pipelines variables of Cloud only")
+	}
+	p, err := b.repoPath(opts, repoOptions)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(p, "/pipelines_config/variables"), nil
+}
+
+func (b *Bitbucket) listVariables(opts BitbucketOptions, p string) ([]*bitbucketVariable, error) {
+
+	params := make(url.Values)
+	params.Set("pagelen", "100")
+
+	r := []*bitbucketVariable{}
+	next := p
+	for !utils.IsEmpty(next) {
+		data, err := b.request(opts, "GET", next, params, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page bitbucketVariablesPage
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, err
+		}
+		r = append(r, page.Values...)
+		next = page.Next
+	}
+	return r, nil
+}
+
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-pipelines/#api-repositories-workspace-repo-slug-pipelines-config-variables-get
+// values of secured variables aren't returned
+
+func (b *Bitbucket) CustomGetVariables(bitbucketOptions BitbucketOptions, repoOptions BitbucketRepoOptions) ([]byte, error) {
+
+	p, err := b.variablesPath(bitbucketOptions, repoOptions)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := b.listVariables(bitbucketOptions, p)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(vars)
+}
+
+func (b *Bitbucket) GetVariables(repoOptions BitbucketRepoOptions) ([]byte, error) {
+	return b.CustomGetVariables(b.options, repoOptions)
+}
+
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-pipelines/#api-repositories-workspace-repo-slug-pipelines-config-variables-post
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-pipelines/#api-repositories-workspace-repo-slug-pipelines-config-variables-variable-uuid-put
+// variable is updated if its key exists, otherwise it's created
+
+func (b *Bitbucket) CustomSetVariable(bitbucketOptions BitbucketOptions, repoOptions BitbucketRepoOptions, variableOptions BitbucketVariableOptions) ([]byte, error) {
+
+	if utils.IsEmpty(variableOptions.Key) {
+		return nil, errors.New("no variable key")
+	}
+	p, err := b.variablesPath(bitbucketOptions, repoOptions)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := b.listVariables(bitbucketOptions, p)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &bitbucketVariable{
+		Key:     variableOptions.Key,
+		Value:   variableOptions.Value,
+		Secured: variableOptions.Secured,
+	}
+	for _, e := range vars {
+		if e.Key != variableOptions.Key {
+			continue
+		}
+		v.UUID = e.UUID
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return b.request(bitbucketOptions, "PUT", path.Join(p, e.UUID), nil, data)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return b.request(bitbucketOptions, "POST", p, nil, data)
+}
+
+func (b *Bitbucket) SetVariable(repoOptions BitbucketRepoOptions, variableOptions BitbucketVariableOptions) ([]byte, error) {
+	return b.CustomSetVariable(b.options, repoOptions, variableOptions)
+}
+
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-pipelines/#api-repositories-workspace-repo-slug-pipelines-config-variables-variable-uuid-delete
+
+func (b *Bitbucket) CustomDeleteVariable(bitbucketOptions BitbucketOptions, repoOptions BitbucketRepoOptions, variableOptions BitbucketVariableOptions) ([]byte, error) {
+
+	if utils.IsEmpty(variableOptions.Key) {
+		return nil, errors.New("no variable key")
+	}
+	p, err := b.variablesPath(bitbucketOptions, repoOptions)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := b.listVariables(bitbucketOptions, p)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range vars {
+		if e.Key != variableOptions.Key {
+			continue
+		}
+		if _, err := b.request(bitbucketOptions, "DELETE", path.Join(p, e.UUID), nil, nil); err != nil {
+			return nil, err
+		}
+		return json.Marshal(e)
+	}
+	return nil, fmt.Errorf("bitbucket variable %s not found", variableOptions.Key)
+}
+
+func (b *Bitbucket) DeleteVariable(repoOptions BitbucketRepoOptions, variableOptions BitbucketVariableOptions) ([]byte, error) {
+	return b.CustomDeleteVariable(b.options, repoOptions, variableOptions)
+}
+
+func (b *Bitbucket) WithContext(ctx context.Context) *Bitbucket {
+	c := *b
+	c.client = common.HttpClientContext(b.client, ctx)
+	return &c
+}
+
+func NewBitbucket(options BitbucketOptions) *Bitbucket {
+
+	bitbucket := &Bitbucket{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+	return bitbucket
+}
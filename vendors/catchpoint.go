@@ -1,6 +1,7 @@
 package vendors
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -266,7 +267,7 @@ func (c *Catchpoint) GetNodesFromGroup(options CatchpointNodeGroup) ([]byte, err
 }
 
 func (c *Catchpoint) CustomGetNodesFromGroup(catchpointOptions CatchpointOptions, options CatchpointNodeGroup) ([]byte, error) {
-	return utils.HttpGetRawRetry(c.client, c.apiURL(catchpointAPINodesGroups+fmt.Sprintf("%d", options.ID)), "application/json", c.getAuth(catchpointOptions), catchpointOptions.Retries, catchpointRetryHeader)
+	return common.HttpGetRawRetry(c.client, c.apiURL(catchpointAPINodesGroups+fmt.Sprintf("%d", options.ID)), "application/json", c.getAuth(catchpointOptions), catchpointOptions.Retries, catchpointRetryHeader)
 }
 
 func (c *Catchpoint) InstantTest(options CatchpointInstantTestOptions) ([]byte, error) {
@@ -298,7 +299,7 @@ func (c *Catchpoint) CustomGetInstantTestResult(catchpointOptions CatchpointOpti
 	params.Add("nodeId", strconv.Itoa(nodeID))
 	u.RawQuery = params.Encode()
 
-	return utils.HttpGetRawRetry(c.client, u.String(), "application/json", c.getAuth(catchpointOptions), catchpointOptions.Retries, catchpointRetryHeader)
+	return common.HttpGetRawRetry(c.client, u.String(), "application/json", c.getAuth(catchpointOptions), catchpointOptions.Retries, catchpointRetryHeader)
 }
 
 func (c *Catchpoint) CustomSearchNodesWithOptions(catchpointOptions CatchpointOptions, catchpointNodesGetAllOptions CatchpointSearchNodesWithOptions) ([]byte, error) {
@@ -349,7 +350,7 @@ func (c *Catchpoint) CustomSearchNodesWithOptions(catchpointOptions CatchpointOp
 	u.Path = path.Join(u.Path, catchpointAPINodesAll)
 	u.RawQuery = params.Encode()
 
-	return utils.HttpGetRawRetry(c.client, u.String(), "application/json", c.getAuth(catchpointOptions), catchpointOptions.Retries, catchpointRetryHeader)
+	return common.HttpGetRawRetry(c.client, u.String(), "application/json", c.getAuth(catchpointOptions), catchpointOptions.Retries, catchpointRetryHeader)
 }
 
 func (c *Catchpoint) CustomInstantTestWithNodeGroup(catchpointOptions CatchpointOptions, catchpointInstantTestWithNodeGroupOptions CatchpointInstantTestWithNodeGroupOptions) ([]byte, error) {
@@ -396,7 +397,7 @@ func (c *Catchpoint) CustomInstantTestWithNodeGroup(catchpointOptions Catchpoint
 	u.Path = path.Join(u.Path, catchpointAPIInstantTest)
 	u.RawQuery = params.Encode()
 
-	return utils.HttpPostRawRetry(c.client, u.String(), "application/json", c.getAuth(catchpointOptions), req, catchpointOptions.Retries, catchpointRetryHeader)
+	return common.HttpPostRawRetry(c.client, u.String(), "application/json", c.getAuth(catchpointOptions), req, catchpointOptions.Retries, catchpointRetryHeader)
 }
 
 func (c *Catchpoint) CustomInstantTest(catchpointOptions CatchpointOptions, catchpointInstantTestOptions CatchpointInstantTestOptions) ([]byte, error) {
@@ -431,13 +432,19 @@ func (c *Catchpoint) CustomInstantTest(catchpointOptions CatchpointOptions, catc
 		return nil, err
 	}
 
-	return utils.HttpPostRawRetry(c.client, u.String(), "application/json", c.getAuth(catchpointOptions), req, catchpointOptions.Retries, catchpointRetryHeader)
+	return common.HttpPostRawRetry(c.client, u.String(), "application/json", c.getAuth(catchpointOptions), req, catchpointOptions.Retries, catchpointRetryHeader)
+}
+
+func (c *Catchpoint) WithContext(ctx context.Context) *Catchpoint {
+	r := *c
+	r.client = common.HttpClientContext(c.client, ctx)
+	return &r
 }
 
 func NewCatchpoint(options CatchpointOptions, logger common.Logger) *Catchpoint {
 
 	catchpoint := &Catchpoint{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 	}
 	return catchpoint
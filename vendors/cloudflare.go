@@ -0,0 +1,474 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const (
+	cloudflareBaseURL = "https://api.cloudflare.com/client/v4"
+	// cloudflarePurgeLimit is max number of files, tags, hosts or prefixes purged by request
+	cloudflarePurgeLimit = 30
+	// cloudflareFirewallPhase is phase of custom rules, which replaced firewall rules
+	cloudflareFirewallPhase = "http_request_firewall_custom"
+)
+
+type CloudflareOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	Token    string
+	Email    string
+	Key      string
+	Zone     string
+}
+
+type CloudflareDNSRecordOptions struct {
+	ID      string
+	Type    string
+	Name    string
+	Content string
+	TTL     int
+	Proxied string
+	Comment string
+}
+
+type CloudflarePurgeOptions struct {
+	Everything bool
+	Files      []string
+	Tags       []string
+	Hosts      []string
+	Prefixes   []string
+}
+
+type CloudflareFirewallRuleOptions struct {
+	Rule    string
+	Enabled bool
+}
+
+type CloudflareDNSRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Content string `json:"content,omitempty"`
+	TTL     int    `json:"ttl,omitempty"`
+	Proxied *bool  `json:"proxied,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+type cloudflareError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type cloudflareResponse struct {
+	Success    bool               `json:"success"`
+	Errors     []*cloudflareError `json:"errors"`
+	Result     json.RawMessage    `json:"result"`
+	ResultInfo *struct {
+		Page       int `json:"page"`
+		TotalPages int `json:"total_pages"`
+	} `json:"result_info"`
+}
+
+type cloudflareZone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type cloudflareRuleset struct {
+	ID    string                   `json:"id"`
+	Rules []map[string]interface{} `json:"rules"`
+}
+
+type Cloudflare struct {
+	client  *http.Client
+	options CloudflareOptions
+}
+
+// headers have API token if it's set, otherwise email and global API key
+func (c *Cloudflare) headers(opts CloudflareOptions) map[string]string {
+
+	headers := make(map[string]string)
+	headers["Content-Type"] = "application/json"
+	if !utils.IsEmpty(opts.Token) {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", opts.Token)
+	} else {
+		headers["X-Auth-Email"] = opts.Email
+		headers["X-Auth-Key"] = opts.Key
+	}
+	return headers
+}
+
+// request returns result of response, errors of response are returned if it isn't successful
+func (c *Cloudflare) request(opts CloudflareOptions, method, p string, params url.Values, data []byte) (*cloudflareResponse, error) {
+
+	base := opts.URL
+	if utils.IsEmpty(base) {
+		base = cloudflareBaseURL
+	}
+	u := strings.TrimSuffix(base, "/") + p
+	if len(params) > 0 {
+		u = u + "?" + params.Encode()
+	}
+
+	b, err := common.HttpRequestRawWithHeaders(c.client, method, u, c.headers(opts), data)
+	var r cloudflareResponse
+	if jerr := json.Unmarshal(b, &r); jerr != nil {
+		if err != nil {
+			return nil, err
+		}
+		return nil, jerr
+	}
+	if err != nil || !r.Success {
+		messages := []string{}
+		for _, e := range r.Errors {
+			messages = append(messages, fmt.Sprintf("%d %s", e.Code, e.Message))
+		}
+		if err == nil {
+			err = errors.New("not successful")
+		}
+		return nil, common.APIErrorMessage(err, strings.Join(messages, "; "))
+	}
+	return &r, nil
+}
+
+// zoneID returns ID of zone, which is set by ID or name
+func (c *Cloudflare) zoneID(opts CloudflareOptions) (string, error) {
+
+	if utils.IsEmpty(opts.Zone) {
+		return "", errors.New("no zone")
+	}
+	if !strings.Contains(opts.Zone, ".") {
+		return opts.Zone, nil
+	}
+	r, err := c.request(opts, "GET", "/zones", url.Values{"name": {opts.Zone}}, nil)
+	if err != nil {
+		return "", err
+	}
+	var zones []*cloudflareZone
+	if err := json.Unmarshal(r.Result, &zones); err != nil {
+		return "", err
+	}
+	if len(zones) == 0 {
+		return "", fmt.Errorf("zone %s is not found", opts.Zone)
+	}
+	return zones[0].ID, nil
+}
+
+// records returns records of zone by name and type, all pages are read
+func (c *Cloudflare) records(opts CloudflareOptions, zone, name, typ string) ([]*CloudflareDNSRecord, error) {
+
+	params := url.Values{"per_page": {"100"}}
+	if !utils.IsEmpty(name) {
+		params.Set("name", name)
+	}
+	if !utils.IsEmpty(typ) {
+		params.Set("type", strings.ToUpper(typ))
+	}
+
+	records := []*CloudflareDNSRecord{}
+	for page := 1; ; page++ {
+		params.Set("page", strconv.Itoa(page))
+		r, err := c.request(opts, "GET", fmt.Sprintf("/zones/%s/dns_records", zone), params, nil)
+		if err != nil {
+			return nil, err
+		}
+		var items []*CloudflareDNSRecord
+		if err := json.Unmarshal(r.Result, &items); err != nil {
+			return nil, err
+		}
+		records = append(records, items...)
+		if r.ResultInfo == nil || page >= r.ResultInfo.TotalPages {
+			break
+		}
+	}
+	return records, nil
+}
+
+// recordID returns ID of record, which is set or found by name and type
+func (c *Cloudflare) recordID(opts CloudflareOptions, zone string, recordOptions CloudflareDNSRecordOptions) (string, error) {
+
+	if !utils.IsEmpty(recordOptions.ID) {
+		return recordOptions.ID, nil
+	}
+	if utils.IsEmpty(recordOptions.Name) {
+		return "", errors.New("no record ID or name")
+	}
+	records, err := c.records(opts, zone, recordOptions.Name, recordOptions.Type)
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(strings.ToUpper(recordOptions.Type) + " " + recordOptions.Name)
+	switch len(records) {
+	case 0:
+		return "", fmt.Errorf("record %s is not found", name)
+	case 1:
+		return records[0].ID, nil
+	default:
+		return "", fmt.Errorf("record %s is ambiguous, %d records are found, set type or ID", name, len(records))
+	}
+}
+
+// record has fields which are set, proxied is kept if it's empty
+func (c *Cloudflare) record(recordOptions CloudflareDNSRecordOptions) (*CloudflareDNSRecord, error) {
+
+	r := &CloudflareDNSRecord{
+		Type:    strings.ToUpper(recordOptions.Type),
+		Name:    recordOptions.Name,
+		Content: recordOptions.Content,
+		TTL:     recordOptions.TTL,
+		Comment: recordOptions.Comment,
+	}
+	if !utils.IsEmpty(recordOptions.Proxied) {
+		proxied, err := strconv.ParseBool(recordOptions.Proxied)
+		if err != nil {
+			return nil, fmt.Errorf("proxied %s is not true or false", recordOptions.Proxied)
+		}
+		r.Proxied = &proxied
+	}
+	return r, nil
+}
+
+// https://developers.cloudflare.com/api/resources/dns/subresources/records/methods/list/
+
+func (c *Cloudflare) CustomGetDNSRecords(cloudflareOptions CloudflareOptions, recordOptions CloudflareDNSRecordOptions) ([]byte, error) {
+
+	zone, err := c.zoneID(cloudflareOptions)
+	if err != nil {
+		return nil, err
+	}
+	records, err := c.records(cloudflareOptions, zone, recordOptions.Name, recordOptions.Type)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(records)
+}
+
+func (c *Cloudflare) GetDNSRecords(recordOptions CloudflareDNSRecordOptions) ([]byte, error) {
+	return c.CustomGetDNSRecords(c.options, recordOptions)
+}
+
+// https://developers.cloudflare.com/api/resources/dns/subresources/records/methods/create/
+// TTL is automatic if it's 0
+
+func (c *Cloudflare) CustomCreateDNSRecord(cloudflareOptions CloudflareOptions, recordOptions CloudflareDNSRecordOptions) ([]byte, error) {
+
+	if utils.IsEmpty(recordOptions.Type) || utils.IsEmpty(recordOptions.Name) || utils.IsEmpty(recordOptions.Content) {
+		return nil, errors.New("no record type, name or content")
+	}
+	zone, err := c.zoneID(cloudflareOptions)
+	if err != nil {
+		return nil, err
+	}
+	record, err := c.record(recordOptions)
+	if err != nil {
+		return nil, err
+	}
+	if record.TTL == 0 {
+		record.TTL = 1
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.request(cloudflareOptions, "POST", fmt.Sprintf("/zones/%s/dns_records", zone), nil, data)
+	if err != nil {
+		return nil, err
+	}
+	return r.Result, nil
+}
+
+func (c *Cloudflare) CreateDNSRecord(recordOptions CloudflareDNSRecordOptions) ([]byte, error) {
+	return c.CustomCreateDNSRecord(c.options, recordOptions)
+}
+
+// https://developers.cloudflare.com/api/resources/dns/subresources/records/methods/edit/
+// record is found by ID or name and type, only fields which are set are updated
+
+func (c *Cloudflare) CustomUpdateDNSRecord(cloudflareOptions CloudflareOptions, recordOptions CloudflareDNSRecordOptions) ([]byte, error) {
+
+	zone, err := c.zoneID(cloudflareOptions)
+	if err != nil {
+		return nil, err
+	}
+	id, err := c.recordID(cloudflareOptions, zone, recordOptions)
+	if err != nil {
+		return nil, err
+	}
+	record, err := c.record(recordOptions)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.request(cloudflareOptions, "PATCH", fmt.Sprintf("/zones/%s/dns_records/%s", zone, id), nil, data)
+	if err != nil {
+		return nil, err
+	}
+	return r.Result, nil
+}
+
+func (c *Cloudflare) UpdateDNSRecord(recordOptions CloudflareDNSRecordOptions) ([]byte, error) {
+	return c.CustomUpdateDNSRecord(c.options, recordOptions)
+}
+
+// https://developers.cloudflare.com/api/resources/dns/subresources/records/methods/delete/
+
+func (c *Cloudflare) CustomDeleteDNSRecord(cloudflareOptions CloudflareOptions, recordOptions CloudflareDNSRecordOptions) ([]byte, error) {
+
+	zone, err := c.zoneID(cloudflareOptions)
+	if err != nil {
+		return nil, err
+	}
+	id, err := c.recordID(cloudflareOptions, zone, recordOptions)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.request(cloudflareOptions, "DELETE", fmt.Sprintf("/zones/%s/dns_records/%s", zone, id), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return r.Result, nil
+}
+
+func (c *Cloudflare) DeleteDNSRecord(recordOptions CloudflareDNSRecordOptions) ([]byte, error) {
+	return c.CustomDeleteDNSRecord(c.options, recordOptions)
+}
+
+// https://developers.cloudflare.com/api/resources/cache/methods/purge/
+// files, tags, hosts and prefixes are purged by batches of request limit
+
+func (c *Cloudflare) CustomPurgeCache(cloudflareOptions CloudflareOptions, purgeOptions CloudflarePurgeOptions) ([]byte, error) {
+
+	zone, err := c.zoneID(cloudflareOptions)
+	if err != nil {
+		return nil, err
+	}
+	p := fmt.Sprintf("/zones/%s/purge_cache", zone)
+
+	if purgeOptions.Everything {
+		data, err := json.Marshal(map[string]bool{"purge_everything": true})
+		if err != nil {
+			return nil, err
+		}
+		r, err := c.request(cloudflareOptions, "POST", p, nil, data)
+		if err != nil {
+			return nil, err
+		}
+		return r.Result, nil
+	}
+
+	batches := 0
+	for _, kind := range []struct {
+		name  string
+		items []string
+	}{
+		{"files", purgeOptions.Files},
+		{"tags", purgeOptions.Tags},
+		{"hosts", purgeOptions.Hosts},
+		{"prefixes", purgeOptions.Prefixes},
+	} {
+		items := common.RemoveEmptyStrings(kind.items)
+		for i := 0; i < len(items); i += cloudflarePurgeLimit {
+			end := i + cloudflarePurgeLimit
+			if end > len(items) {
+				end = len(items)
+			}
+			data, err := json.Marshal(map[string][]string{kind.name: items[i:end]})
+			if err != nil {
+				return nil, err
+			}
+			if _, err := c.request(cloudflareOptions, "POST", p, nil, data); err != nil {
+				return nil, err
+			}
+			batches++
+		}
+	}
+	if batches == 0 {
+		return nil, errors.New("nothing to purge, set everything, files, tags, hosts or prefixes")
+	}
+	return json.Marshal(map[string]interface{}{"id": zone, "requests": batches})
+}
+
+func (c *Cloudflare) PurgeCache(purgeOptions CloudflarePurgeOptions) ([]byte, error) {
+	return c.CustomPurgeCache(c.options, purgeOptions)
+}
+
+// https://developers.cloudflare.com/api/resources/rulesets/subresources/rules/methods/edit/
+// custom rule of zone firewall is found by ID or description, it's updated as is but enabled
+
+func (c *Cloudflare) CustomSetFirewallRule(cloudflareOptions CloudflareOptions, ruleOptions CloudflareFirewallRuleOptions) ([]byte, error) {
+
+	if utils.IsEmpty(ruleOptions.Rule) {
+		return nil, errors.New("no rule ID or description")
+	}
+	zone, err := c.zoneID(cloudflareOptions)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.request(cloudflareOptions, "GET", fmt.Sprintf("/zones/%s/rulesets/phases/%s/entrypoint", zone, cloudflareFirewallPhase), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var ruleset cloudflareRuleset
+	if err := json.Unmarshal(r.Result, &ruleset); err != nil {
+		return nil, err
+	}
+
+	var rule map[string]interface{}
+	for _, rl := range ruleset.Rules {
+		if rl["id"] == ruleOptions.Rule || rl["description"] == ruleOptions.Rule {
+			if rule != nil {
+				return nil, fmt.Errorf("rule %s is ambiguous, set ID", ruleOptions.Rule)
+			}
+			rule = rl
+		}
+	}
+	if rule == nil {
+		return nil, fmt.Errorf("rule %s is not found", ruleOptions.Rule)
+	}
+	rule["enabled"] = ruleOptions.Enabled
+	delete(rule, "version")
+	delete(rule, "last_updated")
+
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return nil, err
+	}
+	r, err = c.request(cloudflareOptions, "PATCH", fmt.Sprintf("/zones/%s/rulesets/%s/rules/%s", zone, ruleset.ID, rule["id"]), nil, data)
+	if err != nil {
+		return nil, err
+	}
+	return r.Result, nil
+}
+
+func (c *Cloudflare) SetFirewallRule(ruleOptions CloudflareFirewallRuleOptions) ([]byte, error) {
+	return c.CustomSetFirewallRule(c.options, ruleOptions)
+}
+
+func (c *Cloudflare) WithContext(ctx context.Context) *Cloudflare {
+	r := *c
+	r.client = common.HttpClientContext(c.client, ctx)
+	return &r
+}
+
+func NewCloudflare(options CloudflareOptions) *Cloudflare {
+
+	cloudflare := &Cloudflare{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+	return cloudflare
+}
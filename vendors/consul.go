@@ -0,0 +1,307 @@
+package vendors
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+type ConsulOptions struct {
+	Timeout    int
+	Insecure   bool
+	URL        string
+	Token      string
+	Datacenter string
+}
+
+type ConsulKVOptions struct {
+	Key     string
+	Value   string
+	Recurse bool
+}
+
+type ConsulCatalogOptions struct {
+	Service string
+	Tag     string
+	Passing bool
+}
+
+type ConsulMaintenanceOptions struct {
+	ServiceID string
+	Enable    bool
+	Reason    string
+}
+
+type consulKVPair struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+type consulHealthService struct {
+	Node struct {
+		Node       string `json:"Node"`
+		Address    string `json:"Address"`
+		Datacenter string `json:"Datacenter"`
+	} `json:"Node"`
+	Service struct {
+		ID      string   `json:"ID"`
+		Service string   `json:"Service"`
+		Address string   `json:"Address"`
+		Port    int      `json:"Port"`
+		Tags    []string `json:"Tags"`
+	} `json:"Service"`
+	Checks []struct {
+		Name   string `json:"Name"`
+		Status string `json:"Status"`
+		Output string `json:"Output"`
+	} `json:"Checks"`
+}
+
+// ConsulServiceInstance is instance of service with the worst status of its checks
+type ConsulServiceInstance struct {
+	ID         string   `json:"id"`
+	Service    string   `json:"service"`
+	Node       string   `json:"node"`
+	Datacenter string   `json:"datacenter,omitempty"`
+	Address    string   `json:"address"`
+	Port       int      `json:"port"`
+	Tags       []string `json:"tags,omitempty"`
+	Status     string   `json:"status"`
+}
+
+type ConsulMaintenance struct {
+	Node      bool   `json:"node,omitempty"`
+	ServiceID string `json:"serviceId,omitempty"`
+	Enabled   bool   `json:"enabled"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type Consul struct {
+	client  *http.Client
+	options ConsulOptions
+}
+
+var consulStatuses = map[string]int{"passing": 0, "warning": 1, "critical": 2}
+
+func (c *Consul) request(opts ConsulOptions, method, p string, params url.Values, data []byte) ([]byte, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return nil, errors.New("no URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "/v1", p)
+	if params == nil {
+		params = make(url.Values)
+	}
+	if !utils.IsEmpty(opts.Datacenter) {
+		params.Set("dc", opts.Datacenter)
+	}
+	// flags like raw and recurse are checked by presence, so that they have empty value
+	u.RawQuery = params.Encode()
+
+	headers := make(map[string]string)
+	if !utils.IsEmpty(opts.Token) {
+		headers["X-Consul-Token"] = opts.Token
+	}
+
+	b, err := common.HttpRequestRawWithHeaders(c.client, method, u.String(), headers, data)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (c *Consul) kvPath(kvOptions ConsulKVOptions) (string, error) {
+
+	key := strings.Trim(kvOptions.Key, "/")
+	if utils.IsEmpty(key) && !kvOptions.Recurse {
+		return "", errors.New("no key")
+	}
+	return path.Join("/kv", key), nil
+}
+
+// https://developer.hashicorp.com/consul/api-docs/kv#read-key
+// result is raw value of key, or JSON object of keys and values if it's recursive
+
+func (c *Consul) CustomGetKV(consulOptions ConsulOptions, kvOptions ConsulKVOptions) ([]byte, error) {
+
+	p, err := c.kvPath(kvOptions)
+	if err != nil {
+		return nil, err
+	}
+	if !kvOptions.Recurse {
+		return c.request(consulOptions, "GET", p, url.Values{"raw": {""}}, nil)
+	}
+
+	b, err := c.request(consulOptions, "GET", p, url.Values{"recurse": {""}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	var pairs []*consulKVPair
+	if err := json.Unmarshal(b, &pairs); err != nil {
+		return nil, err
+	}
+	r := make(map[string]string)
+	for _, kv := range pairs {
+		v, err := base64.StdEncoding.DecodeString(kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		r[kv.Key] = string(v)
+	}
+	return json.Marshal(r)
+}
+
+func (c *Consul) GetKV(kvOptions ConsulKVOptions) ([]byte, error) {
+	return c.CustomGetKV(c.options, kvOptions)
+}
+
+// https://developer.hashicorp.com/consul/api-docs/kv#create-update-key
+// result is true if key is written
+
+func (c *Consul) CustomPutKV(consulOptions ConsulOptions, kvOptions ConsulKVOptions) ([]byte, error) {
+
+	p, err := c.kvPath(ConsulKVOptions{Key: kvOptions.Key})
+	if err != nil {
+		return nil, err
+	}
+	return c.request(consulOptions, "PUT", p, nil, []byte(kvOptions.Value))
+}
+
+func (c *Consul) PutKV(kvOptions ConsulKVOptions) ([]byte, error) {
+	return c.CustomPutKV(c.options, kvOptions)
+}
+
+// https://developer.hashicorp.com/consul/api-docs/kv#delete-key
+// all keys of prefix are deleted if it's recursive, result is true
+
+func (c *Consul) CustomDeleteKV(consulOptions ConsulOptions, kvOptions ConsulKVOptions) ([]byte, error) {
+
+	if utils.IsEmpty(strings.Trim(kvOptions.Key, "/")) {
+		return nil, errors.New("no key")
+	}
+	p, err := c.kvPath(kvOptions)
+	if err != nil {
+		return nil, err
+	}
+	var params url.Values
+	if kvOptions.Recurse {
+		params = url.Values{"recurse": {""}}
+	}
+	return c.request(consulOptions, "DELETE", p, params, nil)
+}
+
+func (c *Consul) DeleteKV(kvOptions ConsulKVOptions) ([]byte, error) {
+	return c.CustomDeleteKV(c.options, kvOptions)
+}
+
+// https://developer.hashicorp.com/consul/api-docs/health#list-service-instances-for-service
+// result is services with their tags if service is empty, otherwise instances of service
+
+func (c *Consul) CustomGetCatalog(consulOptions ConsulOptions, catalogOptions ConsulCatalogOptions) ([]byte, error) {
+
+	if utils.IsEmpty(catalogOptions.Service) {
+		return c.request(consulOptions, "GET", "/catalog/services", nil, nil)
+	}
+
+	params := make(url.Values)
+	if !utils.IsEmpty(catalogOptions.Tag) {
+		params.Set("tag", catalogOptions.Tag)
+	}
+	if catalogOptions.Passing {
+		params.Set("passing", "")
+	}
+	b, err := c.request(consulOptions, "GET", path.Join("/health/service", url.PathEscape(catalogOptions.Service)), params, nil)
+	if err != nil {
+		return nil, err
+	}
+	var services []*consulHealthService
+	if err := json.Unmarshal(b, &services); err != nil {
+		return nil, err
+	}
+
+	r := make([]*ConsulServiceInstance, 0, len(services))
+	for _, s := range services {
+		address := s.Service.Address
+		if utils.IsEmpty(address) {
+			address = s.Node.Address
+		}
+		status := "passing"
+		for _, check := range s.Checks {
+			if consulStatuses[check.Status] > consulStatuses[status] {
+				status = check.Status
+			}
+		}
+		r = append(r, &ConsulServiceInstance{
+			ID:         s.Service.ID,
+			Service:    s.Service.Service,
+			Node:       s.Node.Node,
+			Datacenter: s.Node.Datacenter,
+			Address:    address,
+			Port:       s.Service.Port,
+			Tags:       s.Service.Tags,
+			Status:     status,
+		})
+	}
+	return json.Marshal(r)
+}
+
+func (c *Consul) GetCatalog(catalogOptions ConsulCatalogOptions) ([]byte, error) {
+	return c.CustomGetCatalog(c.options, catalogOptions)
+}
+
+// https://developer.hashicorp.com/consul/api-docs/agent/service#enable-maintenance-mode
+// maintenance is set on agent of URL, of node if service ID is empty
+
+func (c *Consul) CustomSetMaintenance(consulOptions ConsulOptions, maintenanceOptions ConsulMaintenanceOptions) ([]byte, error) {
+
+	p := "/agent/maintenance"
+	if !utils.IsEmpty(maintenanceOptions.ServiceID) {
+		p = path.Join("/agent/service/maintenance", url.PathEscape(maintenanceOptions.ServiceID))
+	}
+	params := url.Values{"enable": {strconv.FormatBool(maintenanceOptions.Enable)}}
+	if !utils.IsEmpty(maintenanceOptions.Reason) {
+		params.Set("reason", maintenanceOptions.Reason)
+	}
+	// agent endpoints have no datacenter
+	consulOptions.Datacenter = ""
+	if _, err := c.request(consulOptions, "PUT", p, params, nil); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&ConsulMaintenance{
+		Node:      utils.IsEmpty(maintenanceOptions.ServiceID),
+		ServiceID: maintenanceOptions.ServiceID,
+		Enabled:   maintenanceOptions.Enable,
+		Reason:    maintenanceOptions.Reason,
+	})
+}
+
+func (c *Consul) SetMaintenance(maintenanceOptions ConsulMaintenanceOptions) ([]byte, error) {
+	return c.CustomSetMaintenance(c.options, maintenanceOptions)
+}
+
+func (c *Consul) WithContext(ctx context.Context) *Consul {
+	r := *c
+	r.client = common.HttpClientContext(c.client, ctx)
+	return &r
+}
+
+func NewConsul(options ConsulOptions) *Consul {
+
+	return &Consul{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
@@ -0,0 +1,274 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const datadogURL = "https://api.datadoghq.com"
+
+type DatadogOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	APIKey   string
+	AppKey   string
+}
+
+type DatadogEventOptions struct {
+	Title          string
+	Text           string
+	Tags           string
+	AlertType      string
+	Priority       string
+	AggregationKey string
+	SourceType     string
+	Host           string
+}
+
+type DatadogMetricOptions struct {
+	Metric   string
+	Type     string
+	Value    float64
+	Tags     string
+	Host     string
+	Interval int
+}
+
+type DatadogMonitorOptions struct {
+	ID       string
+	Scope    string
+	Duration int
+}
+
+type DatadogEvent struct {
+	Title          string   `json:"title"`
+	Text           string   `json:"text"`
+	Tags           []string `json:"tags,omitempty"`
+	AlertType      string   `json:"alert_type,omitempty"`
+	Priority       string   `json:"priority,omitempty"`
+	AggregationKey string   `json:"aggregation_key,omitempty"`
+	SourceTypeName string   `json:"source_type_name,omitempty"`
+	Host           string   `json:"host,omitempty"`
+}
+
+type DatadogMetricPoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+type DatadogMetricResource struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type DatadogMetricSeries struct {
+	Metric    string                   `json:"metric"`
+	Type      int                      `json:"type"`
+	Interval  int                      `json:"interval,omitempty"`
+	Points    []*DatadogMetricPoint    `json:"points"`
+	Tags      []string                 `json:"tags,omitempty"`
+	Resources []*DatadogMetricResource `json:"resources,omitempty"`
+}
+
+type DatadogMetricPayload struct {
+	Series []*DatadogMetricSeries `json:"series"`
+}
+
+type DatadogMute struct {
+	Scope string `json:"scope,omitempty"`
+	End   int64  `json:"end,omitempty"`
+}
+
+type DatadogUnmute struct {
+	Scope     string `json:"scope,omitempty"`
+	AllScopes bool   `json:"all_scopes,omitempty"`
+}
+
+// types of metric intake v2
+var datadogMetricTypes = map[string]int{
+	"":      0,
+	"count": 1,
+	"rate":  2,
+	"gauge": 3,
+}
+
+type Datadog struct {
+	client  *http.Client
+	options DatadogOptions
+}
+
+func (d *Datadog) request(opts DatadogOptions, p string, app bool, data []byte) ([]byte, error) {
+
+	if utils.IsEmpty(opts.APIKey) {
+		return nil, errors.New("no API key")
+	}
+	if app && utils.IsEmpty(opts.AppKey) {
+		return nil, errors.New("no application key")
+	}
+
+	base := opts.URL
+	if utils.IsEmpty(base) {
+		base = datadogURL
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, p)
+
+	headers := make(map[string]string)
+	headers["Content-Type"] = "application/json"
+	headers["DD-API-KEY"] = opts.APIKey
+	if app {
+		headers["DD-APPLICATION-KEY"] = opts.AppKey
+	}
+
+	return common.HttpRequestRawWithHeaders(d.client, "POST", u.String(), headers, data)
+}
+
+func datadogTags(s string) []string {
+
+	tags := []string{}
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); !utils.IsEmpty(t) {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// https://docs.datadoghq.com/api/latest/events/#post-an-event
+
+func (d *Datadog) CustomPostEvent(datadogOptions DatadogOptions, eventOptions DatadogEventOptions) ([]byte, error) {
+
+	if utils.IsEmpty(eventOptions.Title) {
+		return nil, errors.New("no event title")
+	}
+
+	data, err := json.Marshal(&DatadogEvent{
+		Title:          eventOptions.Title,
+		Text:           eventOptions.Text,
+		Tags:           datadogTags(eventOptions.Tags),
+		AlertType:      eventOptions.AlertType,
+		Priority:       eventOptions.Priority,
+		AggregationKey: eventOptions.AggregationKey,
+		SourceTypeName: eventOptions.SourceType,
+		Host:           eventOptions.Host,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.request(datadogOptions, "/api/v1/events", false, data)
+}
+
+func (d *Datadog) PostEvent(eventOptions DatadogEventOptions) ([]byte, error) {
+	return d.CustomPostEvent(d.options, eventOptions)
+}
+
+// https://docs.datadoghq.com/api/latest/metrics/#submit-metrics
+
+func (d *Datadog) CustomSubmitMetric(datadogOptions DatadogOptions, metricOptions DatadogMetricOptions) ([]byte, error) {
+
+	if utils.IsEmpty(metricOptions.Metric) {
+		return nil, errors.New("no metric name")
+	}
+	typ, ok := datadogMetricTypes[strings.ToLower(metricOptions.Type)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported metric type %s", metricOptions.Type)
+	}
+
+	series := &DatadogMetricSeries{
+		Metric:   metricOptions.Metric,
+		Type:     typ,
+		Interval: metricOptions.Interval,
+		Points: []*DatadogMetricPoint{
+			{Timestamp: time.Now().Unix(), Value: metricOptions.Value},
+		},
+		Tags: datadogTags(metricOptions.Tags),
+	}
+	if !utils.IsEmpty(metricOptions.Host) {
+		series.Resources = []*DatadogMetricResource{{Name: metricOptions.Host, Type: "host"}}
+	}
+
+	data, err := json.Marshal(&DatadogMetricPayload{Series: []*DatadogMetricSeries{series}})
+	if err != nil {
+		return nil, err
+	}
+	return d.request(datadogOptions, "/api/v2/series", false, data)
+}
+
+func (d *Datadog) SubmitMetric(metricOptions DatadogMetricOptions) ([]byte, error) {
+	return d.CustomSubmitMetric(d.options, metricOptions)
+}
+
+// https://docs.datadoghq.com/api/latest/monitors/#mute-a-monitor
+// monitor is muted for duration in seconds, or until unmuted if duration is zero
+
+func (d *Datadog) CustomMuteMonitor(datadogOptions DatadogOptions, monitorOptions DatadogMonitorOptions) ([]byte, error) {
+
+	if utils.IsEmpty(monitorOptions.ID) {
+		return nil, errors.New("no monitor ID")
+	}
+
+	mute := &DatadogMute{Scope: monitorOptions.Scope}
+	if monitorOptions.Duration > 0 {
+		mute.End = time.Now().Add(time.Duration(monitorOptions.Duration) * time.Second).Unix()
+	}
+	data, err := json.Marshal(mute)
+	if err != nil {
+		return nil, err
+	}
+	return d.request(datadogOptions, fmt.Sprintf("/api/v1/monitor/%s/mute", monitorOptions.ID), true, data)
+}
+
+func (d *Datadog) MuteMonitor(monitorOptions DatadogMonitorOptions) ([]byte, error) {
+	return d.CustomMuteMonitor(d.options, monitorOptions)
+}
+
+// https://docs.datadoghq.com/api/latest/monitors/#unmute-a-monitor
+// all scopes are unmuted if scope is empty
+
+func (d *Datadog) CustomUnmuteMonitor(datadogOptions DatadogOptions, monitorOptions DatadogMonitorOptions) ([]byte, error) {
+
+	if utils.IsEmpty(monitorOptions.ID) {
+		return nil, errors.New("no monitor ID")
+	}
+
+	data, err := json.Marshal(&DatadogUnmute{
+		Scope:     monitorOptions.Scope,
+		AllScopes: utils.IsEmpty(monitorOptions.Scope),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.request(datadogOptions, fmt.Sprintf("/api/v1/monitor/%s/unmute", monitorOptions.ID), true, data)
+}
+
+func (d *Datadog) UnmuteMonitor(monitorOptions DatadogMonitorOptions) ([]byte, error) {
+	return d.CustomUnmuteMonitor(d.options, monitorOptions)
+}
+
+func (d *Datadog) WithContext(ctx context.Context) *Datadog {
+	c := *d
+	c.client = common.HttpClientContext(d.client, ctx)
+	return &c
+}
+
+func NewDatadog(options DatadogOptions) *Datadog {
+
+	return &Datadog{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
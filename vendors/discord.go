@@ -0,0 +1,256 @@
+package vendors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const discordBaseURL = "https://discord.com/api/v10"
+
+type DiscordOptions struct {
+	Timeout    int
+	Insecure   bool
+	URL        string
+	WebhookURL string
+	BotToken   string
+}
+
+type DiscordMessageOptions struct {
+	Channel    string
+	Thread     string
+	ThreadName string
+	Content    string
+	Username   string
+	AvatarURL  string
+	Embeds     string
+}
+
+type DiscordFileOptions struct {
+	DiscordMessageOptions
+	Name string
+	File string
+}
+
+type DiscordThreadOptions struct {
+	Channel             string
+	MessageID           string
+	Name                string
+	AutoArchiveDuration int
+}
+
+type DiscordMessage struct {
+	Content    string        `json:"content,omitempty"`
+	Username   string        `json:"username,omitempty"`
+	AvatarURL  string        `json:"avatar_url,omitempty"`
+	ThreadName string        `json:"thread_name,omitempty"`
+	Embeds     []interface{} `json:"embeds,omitempty"`
+}
+
+type DiscordThread struct {
+	Name                string `json:"name"`
+	AutoArchiveDuration int    `json:"auto_archive_duration,omitempty"`
+}
+
+type Discord struct {
+	client  *http.Client
+	options DiscordOptions
+}
+
+func (d *Discord) botURL(opts DiscordOptions, p string) (string, error) {
+
+	base := opts.URL
+	if utils.IsEmpty(base) {
+		base = discordBaseURL
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, p)
+	return u.String(), nil
+}
+
+// messages go via bot API if there is bot token and channel, otherwise via webhook
+func (d *Discord) messageURL(opts DiscordOptions, messageOptions DiscordMessageOptions) (string, map[string]string, error) {
+
+	headers := make(map[string]string)
+
+	if !utils.IsEmpty(opts.BotToken) && !utils.IsEmpty(messageOptions.Channel) {
+		// thread is a channel in bot API
+		channel := messageOptions.Channel
+		if !utils.IsEmpty(messageOptions.Thread) {
+			channel = messageOptions.Thread
+		}
+		u, err := d.botURL(opts, fmt.Sprintf("/channels/%s/messages", channel))
+		if err != nil {
+			return "", nil, err
+		}
+		headers["Authorization"] = fmt.Sprintf("Bot %s", opts.BotToken)
+		return u, headers, nil
+	}
+
+	if utils.IsEmpty(opts.WebhookURL) {
+		return "", nil, errors.New("no webhook URL or bot token with channel")
+	}
+	u, err := url.Parse(opts.WebhookURL)
+	if err != nil {
+		return "", nil, err
+	}
+	params := u.Query()
+	params.Set("wait", "true")
+	if !utils.IsEmpty(messageOptions.Thread) {
+		params.Set("thread_id", messageOptions.Thread)
+	}
+	u.RawQuery = params.Encode()
+	return u.String(), headers, nil
+}
+
+func (d *Discord) message(messageOptions DiscordMessageOptions) (*DiscordMessage, error) {
+
+	m := &DiscordMessage{
+		Content:    messageOptions.Content,
+		Username:   messageOptions.Username,
+		AvatarURL:  messageOptions.AvatarURL,
+		ThreadName: messageOptions.ThreadName,
+	}
+	if !utils.IsEmpty(messageOptions.Embeds) {
+		var embeds []interface{}
+		err := json.Unmarshal([]byte(messageOptions.Embeds), &embeds)
+		if err != nil {
+			return nil, err
+		}
+		m.Embeds = embeds
+	}
+	return m, nil
+}
+
+// https://discord.com/developers/docs/resources/webhook#execute-webhook
+// https://discord.com/developers/docs/resources/channel#create-message
+
+func (d *Discord) CustomSendMessage(discordOptions DiscordOptions, messageOptions DiscordMessageOptions) ([]byte, error) {
+
+	u, headers, err := d.messageURL(discordOptions, messageOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := d.message(messageOptions)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	headers["Content-Type"] = "application/json"
+	return common.HttpRequestRawWithHeaders(d.client, "POST", u, headers, data)
+}
+
+func (d *Discord) SendMessage(messageOptions DiscordMessageOptions) ([]byte, error) {
+	return d.CustomSendMessage(d.options, messageOptions)
+}
+
+// https://discord.com/developers/docs/reference#uploading-files
+
+func (d *Discord) CustomSendFile(discordOptions DiscordOptions, fileOptions DiscordFileOptions) ([]byte, error) {
+
+	u, headers, err := d.messageURL(discordOptions, fileOptions.DiscordMessageOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := d.message(fileOptions.DiscordMessageOptions)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	if err := w.WriteField("payload_json", string(payload)); err != nil {
+		return nil, err
+	}
+
+	fw, err := w.CreateFormFile("files[0]", fileOptions.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write([]byte(fileOptions.File)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	headers["Content-Type"] = w.FormDataContentType()
+	return common.HttpRequestRawWithHeaders(d.client, "POST", u, headers, body.Bytes())
+}
+
+func (d *Discord) SendFile(fileOptions DiscordFileOptions) ([]byte, error) {
+	return d.CustomSendFile(d.options, fileOptions)
+}
+
+// https://discord.com/developers/docs/resources/channel#start-thread-from-message
+// https://discord.com/developers/docs/resources/channel#start-thread-without-message
+
+func (d *Discord) CustomCreateThread(discordOptions DiscordOptions, threadOptions DiscordThreadOptions) ([]byte, error) {
+
+	if utils.IsEmpty(discordOptions.BotToken) {
+		return nil, errors.New("no bot token")
+	}
+
+	p := fmt.Sprintf("/channels/%s/threads", threadOptions.Channel)
+	if !utils.IsEmpty(threadOptions.MessageID) {
+		p = fmt.Sprintf("/channels/%s/messages/%s/threads", threadOptions.Channel, threadOptions.MessageID)
+	}
+	u, err := d.botURL(discordOptions, p)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(&DiscordThread{
+		Name:                threadOptions.Name,
+		AutoArchiveDuration: threadOptions.AutoArchiveDuration,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = fmt.Sprintf("Bot %s", discordOptions.BotToken)
+	headers["Content-Type"] = "application/json"
+	return common.HttpRequestRawWithHeaders(d.client, "POST", u, headers, data)
+}
+
+func (d *Discord) CreateThread(threadOptions DiscordThreadOptions) ([]byte, error) {
+	return d.CustomCreateThread(d.options, threadOptions)
+}
+
+func (d *Discord) WithContext(ctx context.Context) *Discord {
+	c := *d
+	c.client = common.HttpClientContext(d.client, ctx)
+	return &c
+}
+
+func NewDiscord(options DiscordOptions) *Discord {
+
+	return &Discord{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
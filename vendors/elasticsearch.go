@@ -0,0 +1,434 @@
+package vendors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const elasticsearchBulkBatch = 500
+
+type ElasticsearchOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	User     string
+	Password string
+	APIKey   string
+}
+
+type ElasticsearchIndexOptions struct {
+	Index    string
+	ID       string
+	Document string
+	Refresh  string
+}
+
+type ElasticsearchBulkOptions struct {
+	Index   string
+	File    string
+	IDField string
+	Batch   int
+	Refresh string
+}
+
+type ElasticsearchSearchOptions struct {
+	Index       string
+	Query       string
+	QueryString string
+	Size        int
+	Sort        string
+}
+
+type ElasticsearchIndicesOptions struct {
+	Index     string
+	Pattern   string
+	Body      string
+	OlderThan int
+	DryRun    bool
+}
+
+type ElasticsearchBulkResult struct {
+	Indexed int      `json:"indexed"`
+	Failed  int      `json:"failed"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+type ElasticsearchCatIndex struct {
+	Index        string `json:"index"`
+	Health       string `json:"health"`
+	Status       string `json:"status"`
+	DocsCount    string `json:"docs.count"`
+	StoreSize    string `json:"store.size"`
+	CreationDate string `json:"creation.date"`
+}
+
+type ElasticsearchPruneResult struct {
+	Deleted []string `json:"deleted"`
+	DryRun  bool     `json:"dryRun,omitempty"`
+}
+
+type Elasticsearch struct {
+	client  *http.Client
+	options ElasticsearchOptions
+}
+
+func (e *Elasticsearch) headers(opts ElasticsearchOptions, contentType string) map[string]string {
+
+	headers := make(map[string]string)
+	if !utils.IsEmpty(contentType) {
+		headers["Content-Type"] = contentType
+	}
+	if !utils.IsEmpty(opts.APIKey) {
+		headers["Authorization"] = fmt.Sprintf("ApiKey %s", opts.APIKey)
+	} else if !utils.IsEmpty(opts.User) {
+		headers["Authorization"] = common.FormatBasicAuth(opts.User, opts.Password)
+	}
+	return headers
+}
+
+// request escapes path segments, so that document IDs may have slashes
+func (e *Elasticsearch) request(opts ElasticsearchOptions, method string, segments []string, params url.Values, contentType string, data []byte) ([]byte, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return nil, errors.New("no URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	raw := []string{u.EscapedPath()}
+	for _, s := range segments {
+		raw = append(raw, url.PathEscape(s))
+	}
+	u.Path = path.Join(append([]string{u.Path}, segments...)...)
+	u.RawPath = path.Join(raw...)
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+
+	return common.HttpRequestRawWithHeaders(e.client, method, u.String(), e.headers(opts, contentType), data)
+}
+
+func elasticsearchRefresh(refresh string) url.Values {
+
+	params := make(url.Values)
+	if !utils.IsEmpty(refresh) {
+		params.Set("refresh", refresh)
+	}
+	return params
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/docs-index_.html
+// document is created with generated ID if ID is empty, replaced otherwise
+
+func (e *Elasticsearch) CustomIndexDocument(elasticsearchOptions ElasticsearchOptions, indexOptions ElasticsearchIndexOptions) ([]byte, error) {
+
+	if utils.IsEmpty(indexOptions.Index) {
+		return nil, errors.New("no index")
+	}
+	if !json.Valid([]byte(indexOptions.Document)) {
+		return nil, errors.New("document isn't valid JSON")
+	}
+
+	method := "POST"
+	p := []string{indexOptions.Index, "_doc"}
+	if !utils.IsEmpty(indexOptions.ID) {
+		method = "PUT"
+		p = append(p, indexOptions.ID)
+	}
+	return e.request(elasticsearchOptions, method, p, elasticsearchRefresh(indexOptions.Refresh), "application/json", []byte(indexOptions.Document))
+}
+
+func (e *Elasticsearch) IndexDocument(indexOptions ElasticsearchIndexOptions) ([]byte, error) {
+	return e.CustomIndexDocument(e.options, indexOptions)
+}
+
+func (e *Elasticsearch) bulk(opts ElasticsearchOptions, bulkOptions ElasticsearchBulkOptions, body []byte, result *ElasticsearchBulkResult) error {
+
+	b, err := e.request(opts, "POST", []string{"_bulk"}, elasticsearchRefresh(bulkOptions.Refresh), "application/x-ndjson", body)
+	if err != nil {
+		return err
+	}
+
+	var r struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return err
+	}
+	for _, item := range r.Items {
+		for _, v := range item {
+			if v.Error == nil {
+				result.Indexed++
+				continue
+			}
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", v.Error.Type, v.Error.Reason))
+		}
+	}
+	return nil
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/docs-bulk.html
+// file has one JSON document per line, documents are sent in batches, ID is taken from ID field if set
+
+func (e *Elasticsearch) CustomBulkIndex(elasticsearchOptions ElasticsearchOptions, bulkOptions ElasticsearchBulkOptions) ([]byte, error) {
+
+	if utils.IsEmpty(bulkOptions.Index) {
+		return nil, errors.New("no index")
+	}
+	if utils.IsEmpty(bulkOptions.File) {
+		return nil, errors.New("no file")
+	}
+	batch := bulkOptions.Batch
+	if batch <= 0 {
+		batch = elasticsearchBulkBatch
+	}
+
+	f, err := os.Open(bulkOptions.File)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	result := &ElasticsearchBulkResult{}
+	var body bytes.Buffer
+	count := 0
+	flush := func() error {
+		if count == 0 {
+			return nil
+		}
+		err := e.bulk(elasticsearchOptions, bulkOptions, body.Bytes(), result)
+		body.Reset()
+		count = 0
+		return err
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		doc := bytes.TrimSpace(scanner.Bytes())
+		if len(doc) == 0 {
+			continue
+		}
+
+		var m map[string]interface{}
+		if err := json.Unmarshal(doc, &m); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		action := map[string]string{"_index": bulkOptions.Index}
+		if !utils.IsEmpty(bulkOptions.IDField) {
+			if id, ok := m[bulkOptions.IDField]; ok {
+				action["_id"] = fmt.Sprintf("%v", id)
+			}
+		}
+		meta, err := json.Marshal(map[string]interface{}{"index": action})
+		if err != nil {
+			return nil, err
+		}
+		body.Write(meta)
+		body.WriteByte('\n')
+		body.Write(doc)
+		body.WriteByte('\n')
+		count++
+
+		if count >= batch {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+func (e *Elasticsearch) BulkIndex(bulkOptions ElasticsearchBulkOptions) ([]byte, error) {
+	return e.CustomBulkIndex(e.options, bulkOptions)
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/search-search.html
+// query is query DSL of request body, query string is simple_query_string if query is empty
+
+func (e *Elasticsearch) CustomSearch(elasticsearchOptions ElasticsearchOptions, searchOptions ElasticsearchSearchOptions) ([]byte, error) {
+
+	body := make(map[string]interface{})
+	if !utils.IsEmpty(searchOptions.Query) {
+		if err := json.Unmarshal([]byte(searchOptions.Query), &body); err != nil {
+			return nil, fmt.Errorf("query DSL: %s", err)
+		}
+	} else if !utils.IsEmpty(searchOptions.QueryString) {
+		body["query"] = map[string]interface{}{
+			"simple_query_string": map[string]interface{}{"query": searchOptions.QueryString},
+		}
+	}
+	if searchOptions.Size > 0 {
+		body["size"] = searchOptions.Size
+	}
+	if !utils.IsEmpty(searchOptions.Sort) {
+		sort := []interface{}{}
+		for _, s := range strings.Split(searchOptions.Sort, ",") {
+			field, order, ok := strings.Cut(strings.TrimSpace(s), ":")
+			if !ok {
+				order = "asc"
+			}
+			sort = append(sort, map[string]string{field: order})
+		}
+		body["sort"] = sort
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	p := []string{"_search"}
+	if !utils.IsEmpty(searchOptions.Index) {
+		p = []string{searchOptions.Index, "_search"}
+	}
+	// search is query sent by POST, so it isn't audited
+	q := *e
+	q.client = common.HttpClientAuditSkip(e.client)
+	return q.request(elasticsearchOptions, "POST", p, nil, "application/json", data)
+}
+
+func (e *Elasticsearch) Search(searchOptions ElasticsearchSearchOptions) ([]byte, error) {
+	return e.CustomSearch(e.options, searchOptions)
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/indices-create-index.html
+// body has settings, mappings and aliases
+
+func (e *Elasticsearch) CustomCreateIndex(elasticsearchOptions ElasticsearchOptions, indicesOptions ElasticsearchIndicesOptions) ([]byte, error) {
+
+	if utils.IsEmpty(indicesOptions.Index) {
+		return nil, errors.New("no index")
+	}
+	var data []byte
+	if !utils.IsEmpty(indicesOptions.Body) {
+		if !json.Valid([]byte(indicesOptions.Body)) {
+			return nil, errors.New("index body isn't valid JSON")
+		}
+		data = []byte(indicesOptions.Body)
+	}
+	return e.request(elasticsearchOptions, "PUT", []string{indicesOptions.Index}, nil, "application/json", data)
+}
+
+func (e *Elasticsearch) CreateIndex(indicesOptions ElasticsearchIndicesOptions) ([]byte, error) {
+	return e.CustomCreateIndex(e.options, indicesOptions)
+}
+
+func (e *Elasticsearch) CustomDeleteIndex(elasticsearchOptions ElasticsearchOptions, indicesOptions ElasticsearchIndicesOptions) ([]byte, error) {
+
+	if utils.IsEmpty(indicesOptions.Index) {
+		return nil, errors.New("no index")
+	}
+	return e.request(elasticsearchOptions, "DELETE", []string{indicesOptions.Index}, nil, "", nil)
+}
+
+func (e *Elasticsearch) DeleteIndex(indicesOptions ElasticsearchIndicesOptions) ([]byte, error) {
+	return e.CustomDeleteIndex(e.options, indicesOptions)
+}
+
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/cat-indices.html
+
+func (e *Elasticsearch) CustomListIndices(elasticsearchOptions ElasticsearchOptions, indicesOptions ElasticsearchIndicesOptions) ([]byte, error) {
+
+	params := make(url.Values)
+	params.Set("format", "json")
+	params.Set("h", "index,health,status,docs.count,store.size,creation.date")
+	params.Set("s", "index")
+
+	p := []string{"_cat", "indices"}
+	if !utils.IsEmpty(indicesOptions.Pattern) {
+		p = append(p, indicesOptions.Pattern)
+	}
+	return e.request(elasticsearchOptions, "GET", p, params, "", nil)
+}
+
+func (e *Elasticsearch) ListIndices(indicesOptions ElasticsearchIndicesOptions) ([]byte, error) {
+	return e.CustomListIndices(e.options, indicesOptions)
+}
+
+// PruneIndices deletes indices matching pattern created more than older than days ago, e.g. audit-*
+
+func (e *Elasticsearch) CustomPruneIndices(elasticsearchOptions ElasticsearchOptions, indicesOptions ElasticsearchIndicesOptions) ([]byte, error) {
+
+	if utils.IsEmpty(indicesOptions.Pattern) {
+		return nil, errors.New("no index pattern")
+	}
+	if indicesOptions.OlderThan <= 0 {
+		return nil, errors.New("no older than days")
+	}
+
+	b, err := e.CustomListIndices(elasticsearchOptions, indicesOptions)
+	if err != nil {
+		return nil, err
+	}
+	var indices []*ElasticsearchCatIndex
+	if err := json.Unmarshal(b, &indices); err != nil {
+		return nil, err
+	}
+
+	before := time.Now().AddDate(0, 0, -indicesOptions.OlderThan)
+	result := &ElasticsearchPruneResult{Deleted: []string{}, DryRun: indicesOptions.DryRun}
+	for _, idx := range indices {
+		ms, err := strconv.ParseInt(idx.CreationDate, 10, 64)
+		if err != nil || !time.UnixMilli(ms).Before(before) {
+			continue
+		}
+		if !indicesOptions.DryRun {
+			_, err := e.request(elasticsearchOptions, "DELETE", []string{idx.Index}, nil, "", nil)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", idx.Index, err)
+			}
+		}
+		result.Deleted = append(result.Deleted, idx.Index)
+	}
+	return json.Marshal(result)
+}
+
+func (e *Elasticsearch) PruneIndices(indicesOptions ElasticsearchIndicesOptions) ([]byte, error) {
+	return e.CustomPruneIndices(e.options, indicesOptions)
+}
+
+func (e *Elasticsearch) WithContext(ctx context.Context) *Elasticsearch {
+	c := *e
+	c.client = common.HttpClientContext(e.client, ctx)
+	return &c
+}
+
+func NewElasticsearch(options ElasticsearchOptions) *Elasticsearch {
+
+	return &Elasticsearch{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
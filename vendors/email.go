@@ -0,0 +1,438 @@
+package vendors
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+	"github.com/google/uuid"
+)
+
+type EmailOptions struct {
+	Timeout  int
+	Insecure bool
+	Server   string
+	User     string
+	Password string
+	TLS      bool
+	StartTLS bool
+	DSN      bool
+}
+
+type EmailMessageOptions struct {
+	From        string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []string
+}
+
+type EmailResult struct {
+	MessageID  string   `json:"messageId"`
+	From       string   `json:"from"`
+	Recipients []string `json:"recipients"`
+	DSN        bool     `json:"dsn,omitempty"`
+}
+
+type EmailDSNRecipient struct {
+	Recipient  string `json:"recipient"`
+	Action     string `json:"action"`
+	Status     string `json:"status,omitempty"`
+	Diagnostic string `json:"diagnostic,omitempty"`
+}
+
+// EmailDSN is delivery status notification, envelope ID is message ID of sent email without brackets
+type EmailDSN struct {
+	EnvelopeID string               `json:"envelopeId"`
+	Recipients []*EmailDSNRecipient `json:"recipients"`
+}
+
+type Email struct {
+	options EmailOptions
+}
+
+// emailAddress returns bare address for SMTP envelope, display name is kept in headers only
+func emailAddress(s string) (string, error) {
+
+	a, err := mail.ParseAddress(strings.TrimSpace(s))
+	if err != nil {
+		return "", fmt.Errorf("invalid email address %s: %s", s, err)
+	}
+	return a.Address, nil
+}
+
+func (e *Email) writePart(w *multipart.Writer, contentType string, text string) error {
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Transfer-Encoding", "quoted-printable")
+	pw, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	qw := quotedprintable.NewWriter(pw)
+	if _, err := qw.Write([]byte(text)); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+func (e *Email) writeAttachment(w *multipart.Writer, file string) error {
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(file)
+	contentType, _, err := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(name)))
+	if err != nil {
+		contentType = "application/octet-stream"
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Type", mime.FormatMediaType(contentType, map[string]string{"name": name}))
+	h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	h.Set("Content-Transfer-Encoding", "base64")
+	pw, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(pw, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = fmt.Fprintf(pw, "%s\r\n", encoded)
+	return err
+}
+
+// message is multipart/mixed with multipart/alternative of text and html, followed by attachments
+func (e *Email) message(messageOptions EmailMessageOptions, messageID string) ([]byte, error) {
+
+	var body bytes.Buffer
+	mixed := multipart.NewWriter(&body)
+
+	var alt bytes.Buffer
+	alternative := multipart.NewWriter(&alt)
+	if !utils.IsEmpty(messageOptions.Text) || utils.IsEmpty(messageOptions.HTML) {
+		if err := e.writePart(alternative, "text/plain; charset=utf-8", messageOptions.Text); err != nil {
+			return nil, err
+		}
+	}
+	if !utils.IsEmpty(messageOptions.HTML) {
+		if err := e.writePart(alternative, "text/html; charset=utf-8", messageOptions.HTML); err != nil {
+			return nil, err
+		}
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%s", alternative.Boundary()))
+	pw, err := mixed.CreatePart(h)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := pw.Write(alt.Bytes()); err != nil {
+		return nil, err
+	}
+
+	for _, a := range common.RemoveEmptyStrings(messageOptions.Attachments) {
+		if err := e.writeAttachment(mixed, a); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	headers := [][2]string{
+		{"From", messageOptions.From},
+		{"To", strings.Join(common.RemoveEmptyStrings(messageOptions.To), ", ")},
+		{"Cc", strings.Join(common.RemoveEmptyStrings(messageOptions.Cc), ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", messageOptions.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", messageID},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/mixed; boundary=%s", mixed.Boundary())},
+	}
+	for _, h := range headers {
+		if !utils.IsEmpty(h[1]) {
+			fmt.Fprintf(&msg, "%s: %s\r\n", h[0], h[1])
+		}
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+func (e *Email) client(opts EmailOptions) (*smtp.Client, error) {
+
+	host, _, err := net.SplitHostPort(opts.Server)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{ServerName: host, InsecureSkipVerify: opts.Insecure}
+	dialer := &net.Dialer{Timeout: time.Duration(opts.Timeout) * time.Second}
+
+	var conn net.Conn
+	if opts.TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", opts.Server, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", opts.Server)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(time.Duration(opts.Timeout) * time.Second))
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if opts.StartTLS && !opts.TLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			c.Close()
+			return nil, errors.New("SMTP server doesn't support STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	if !utils.IsEmpty(opts.User) {
+		if err := c.Auth(smtp.PlainAuth("", opts.User, opts.Password, host)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// xtext encodes value of DSN parameters, https://www.rfc-editor.org/rfc/rfc3461#section-4
+func (e *Email) xtext(s string) string {
+
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if c < 33 || c > 126 || c == '+' || c == '=' {
+			fmt.Fprintf(&b, "+%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// cmd sends command having parameters, which net/smtp doesn't support
+func (e *Email) cmd(c *smtp.Client, code int, format string, args ...interface{}) error {
+
+	id, err := c.Text.Cmd(format, args...)
+	if err != nil {
+		return err
+	}
+	c.Text.StartResponse(id)
+	defer c.Text.EndResponse(id)
+	_, _, err = c.Text.ReadResponse(code)
+	return err
+}
+
+// send sends message over SMTP and returns whether DSN is requested
+func (e *Email) send(emailOptions EmailOptions, from string, recipients []string, messageID string, msg []byte) (bool, error) {
+
+	c, err := e.client(emailOptions)
+	if err != nil {
+		return false, err
+	}
+	defer c.Close()
+
+	// notifications of success and failure are sent to sender by servers supporting DSN
+	dsn := false
+	if emailOptions.DSN {
+		dsn, _ = c.Extension("DSN")
+	}
+	if dsn {
+		err = e.cmd(c, 250, "MAIL FROM:<%s> RET=HDRS ENVID=%s", from, e.xtext(strings.Trim(messageID, "<>")))
+	} else {
+		err = c.Mail(from)
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, r := range recipients {
+		if dsn {
+			err = e.cmd(c, 25, "RCPT TO:<%s> NOTIFY=SUCCESS,FAILURE,DELAY ORCPT=rfc822;%s", r, e.xtext(r))
+		} else {
+			err = c.Rcpt(r)
+		}
+		if err != nil {
+			return false, fmt.Errorf("SMTP recipient %s: %s", r, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return false, err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return false, err
+	}
+	if err := w.Close(); err != nil {
+		return false, err
+	}
+	if err := c.Quit(); err != nil {
+		return false, err
+	}
+	return dsn, nil
+}
+
+func (e *Email) CustomSend(emailOptions EmailOptions, messageOptions EmailMessageOptions) ([]byte, error) {
+
+	if utils.IsEmpty(emailOptions.Server) {
+		return nil, errors.New("no SMTP server")
+	}
+	if utils.IsEmpty(messageOptions.From) {
+		return nil, errors.New("no sender")
+	}
+
+	recipients := []string{}
+	for _, list := range [][]string{messageOptions.To, messageOptions.Cc, messageOptions.Bcc} {
+		for _, r := range common.RemoveEmptyStrings(list) {
+			addr, err := emailAddress(r)
+			if err != nil {
+				return nil, err
+			}
+			recipients = append(recipients, addr)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+	from, err := emailAddress(messageOptions.From)
+	if err != nil {
+		return nil, err
+	}
+
+	_, domain, _ := strings.Cut(from, "@")
+	messageID := fmt.Sprintf("<%s@%s>", uuid.New().String(), domain)
+
+	msg, err := e.message(messageOptions, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	dsn, err := e.send(emailOptions, from, recipients, messageID, msg)
+	common.AuditOperation("email", "SEND", "smtp://"+emailOptions.Server, strings.Join(recipients, ","), err, start)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&EmailResult{
+		MessageID:  messageID,
+		From:       from,
+		Recipients: recipients,
+		DSN:        dsn,
+	})
+}
+
+func (e *Email) Send(messageOptions EmailMessageOptions) ([]byte, error) {
+	return e.CustomSend(e.options, messageOptions)
+}
+
+// ParseEmailDSN parses multipart/report message having delivery status of recipients
+// https://www.rfc-editor.org/rfc/rfc3464
+func ParseEmailDSN(data []byte) (*EmailDSN, error) {
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	if mediaType != "multipart/report" {
+		return nil, fmt.Errorf("email is %s, not delivery status report", mediaType)
+	}
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if partType != "message/delivery-status" {
+			continue
+		}
+
+		// per message fields are followed by blocks of per recipient fields
+		tp := textproto.NewReader(bufio.NewReader(part))
+		h, err := tp.ReadMIMEHeader()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		dsn := &EmailDSN{EnvelopeID: strings.TrimSpace(h.Get("Original-Envelope-Id"))}
+		for err == nil {
+			h, err = tp.ReadMIMEHeader()
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			recipient := h.Get("Final-Recipient")
+			if utils.IsEmpty(recipient) {
+				continue
+			}
+			if _, addr, ok := strings.Cut(recipient, ";"); ok {
+				recipient = addr
+			}
+			dsn.Recipients = append(dsn.Recipients, &EmailDSNRecipient{
+				Recipient:  strings.TrimSpace(recipient),
+				Action:     strings.ToLower(strings.TrimSpace(h.Get("Action"))),
+				Status:     strings.TrimSpace(h.Get("Status")),
+				Diagnostic: strings.TrimSpace(h.Get("Diagnostic-Code")),
+			})
+		}
+		return dsn, nil
+	}
+	return nil, errors.New("email has no delivery status")
+}
+
+func NewEmail(options EmailOptions) *Email {
+
+	return &Email{
+		options: options,
+	}
+}
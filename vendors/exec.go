@@ -0,0 +1,154 @@
+package vendors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const execRedacted = "***"
+
+// env variables with such names are treated as secrets and redacted
+var execSecretEnvRegex = regexp.MustCompile(`(?i)(TOKEN|PASSWORD|SECRET|KEY)`)
+
+type ExecOptions struct {
+	Command string
+	Args    []string
+	Shell   bool
+	Dir     string
+	Env     []string
+	Stdin   string
+	Timeout int
+	Redact  []string
+}
+
+type ExecResult struct {
+	Command  string `json:"command"`
+	ExitCode int    `json:"exitCode"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	Duration string `json:"duration"`
+}
+
+type Exec struct {
+	options ExecOptions
+	logger  common.Logger
+}
+
+func (e *Exec) secrets(options ExecOptions) []string {
+
+	r := common.RemoveEmptyStrings(options.Redact)
+	for _, kv := range options.Env {
+		arr := strings.SplitN(kv, "=", 2)
+		if len(arr) == 2 && !utils.IsEmpty(arr[1]) && execSecretEnvRegex.MatchString(arr[0]) {
+			r = append(r, arr[1])
+		}
+	}
+	return r
+}
+
+// Redact replaces secrets of options in s
+func (e *Exec) Redact(options ExecOptions, s string) string {
+
+	for _, secret := range e.secrets(options) {
+		s = strings.ReplaceAll(s, secret, execRedacted)
+	}
+	return s
+}
+
+func (e *Exec) command(ctx context.Context, options ExecOptions) *exec.Cmd {
+
+	if !options.Shell {
+		return exec.CommandContext(ctx, options.Command, options.Args...)
+	}
+
+	line := strings.Join(append([]string{options.Command}, options.Args...), " ")
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", line)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", line)
+}
+
+// CustomRun runs command and returns its result as json, result is returned with error if command fails,
+// secrets are redacted in stdout and stderr of result
+func (e *Exec) CustomRun(options ExecOptions) ([]byte, error) {
+
+	if utils.IsEmpty(options.Command) {
+		return nil, errors.New("no exec command")
+	}
+
+	// process is killed on interrupt and at deadline of command as requests of vendors are
+	parent := common.HttpContext()
+	ctx := parent
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, time.Duration(options.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	c := e.command(ctx, options)
+	c.Dir = options.Dir
+	c.Env = append(os.Environ(), options.Env...)
+	if !utils.IsEmpty(options.Stdin) {
+		c.Stdin = strings.NewReader(options.Stdin)
+	}
+
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+
+	line := e.Redact(options, strings.Join(c.Args, " "))
+	if e.logger != nil {
+		e.logger.Debug("Exec running %s...", line)
+	}
+
+	t := time.Now()
+	err := c.Run()
+	common.AuditOperation("exec", "RUN", "", line, err, t)
+
+	result := &ExecResult{
+		Command:  line,
+		ExitCode: c.ProcessState.ExitCode(),
+		Stdout:   e.Redact(options, stdout.String()),
+		Stderr:   e.Redact(options, stderr.String()),
+		Duration: time.Since(t).String(),
+	}
+	data, jerr := json.Marshal(result)
+	if jerr != nil {
+		return nil, jerr
+	}
+
+	if parent.Err() != nil {
+		return data, fmt.Errorf("exec command %s is stopped: %w", line, context.Cause(parent))
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return data, fmt.Errorf("exec command %s timed out after %d seconds", line, options.Timeout)
+	}
+	if err != nil {
+		return data, fmt.Errorf("exec command %s failed: %s %s", line, err, strings.TrimSpace(result.Stderr))
+	}
+	return data, nil
+}
+
+func (e *Exec) Run() ([]byte, error) {
+	return e.CustomRun(e.options)
+}
+
+func NewExec(options ExecOptions, logger common.Logger) *Exec {
+
+	return &Exec{
+		options: options,
+		logger:  logger,
+	}
+}
@@ -0,0 +1,230 @@
+package vendors
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devopsext/utils"
+)
+
+type FluxObjectReference struct {
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	UID        string `json:"uid,omitempty"`
+}
+
+// FluxEvent is payload of generic and generic-hmac providers of Flux notification-controller
+// https://fluxcd.io/flux/components/notification/providers/#generic-webhook
+type FluxEvent struct {
+	InvolvedObject      FluxObjectReference `json:"involvedObject"`
+	Severity            string              `json:"severity"`
+	Timestamp           time.Time           `json:"timestamp"`
+	Message             string              `json:"message"`
+	Reason              string              `json:"reason"`
+	Metadata            map[string]string   `json:"metadata,omitempty"`
+	ReportingController string              `json:"reportingController"`
+	ReportingInstance   string              `json:"reportingInstance,omitempty"`
+}
+
+type fluxSlackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type fluxSlackAttachment struct {
+	Color      string            `json:"color"`
+	AuthorName string            `json:"author_name"`
+	Text       string            `json:"text"`
+	MrkdwnIn   []string          `json:"mrkdwn_in"`
+	Fields     []*fluxSlackField `json:"fields,omitempty"`
+}
+
+type fluxDiscordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type fluxDiscordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Color       int                 `json:"color"`
+	Fields      []*fluxDiscordField `json:"fields,omitempty"`
+}
+
+var fluxHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// Title is object reference as Flux providers show it, e.g. kustomization/apps.flux-system
+func (e *FluxEvent) Title() string {
+	return fmt.Sprintf("%s/%s.%s", strings.ToLower(e.InvolvedObject.Kind), e.InvolvedObject.Name, e.InvolvedObject.Namespace)
+}
+
+// Revision is source revision of metadata, the key is prefixed by API group of object
+func (e *FluxEvent) Revision() string {
+
+	for k, v := range e.Metadata {
+		if k == "revision" || strings.HasSuffix(k, "/revision") {
+			return v
+		}
+	}
+	return ""
+}
+
+// Summary is metadata summary set in alert, e.g. cluster name
+func (e *FluxEvent) Summary() string {
+	return e.Metadata["summary"]
+}
+
+// Fields are metadata without summary sorted by key, keys have no API group prefix
+func (e *FluxEvent) Fields() map[string]string {
+
+	r := make(map[string]string)
+	for k, v := range e.Metadata {
+		if k == "summary" {
+			continue
+		}
+		if i := strings.LastIndex(k, "/"); i >= 0 {
+			k = k[i+1:]
+		}
+		r[k] = v
+	}
+	return r
+}
+
+func (e *FluxEvent) fieldKeys() []string {
+
+	fields := e.Fields()
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Text is message with summary and metadata, as text providers like Telegram or Matrix send it
+func (e *FluxEvent) Text() string {
+
+	lines := []string{e.Title()}
+	if s := e.Summary(); !utils.IsEmpty(s) {
+		lines = append(lines, s)
+	}
+	lines = append(lines, e.Message)
+	fields := e.Fields()
+	for _, k := range e.fieldKeys() {
+		lines = append(lines, fmt.Sprintf("%s: %s", k, fields[k]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SlackAttachments is attachments JSON of Flux Slack provider, color depends on severity
+func (e *FluxEvent) SlackAttachments() (string, error) {
+
+	color := "good"
+	if e.Severity == "error" {
+		color = "danger"
+	}
+	text := e.Message
+	if s := e.Summary(); !utils.IsEmpty(s) {
+		text = fmt.Sprintf("%s\n%s", s, text)
+	}
+	a := &fluxSlackAttachment{
+		Color:      color,
+		AuthorName: e.Title(),
+		Text:       text,
+		MrkdwnIn:   []string{"text"},
+	}
+	fields := e.Fields()
+	for _, k := range e.fieldKeys() {
+		a.Fields = append(a.Fields, &fluxSlackField{Title: k, Value: fields[k], Short: false})
+	}
+	b, err := json.Marshal([]*fluxSlackAttachment{a})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// DiscordEmbeds is embeds JSON with the same content as Slack attachments, colors are green and red
+func (e *FluxEvent) DiscordEmbeds() (string, error) {
+
+	color := 0x2eb886
+	if e.Severity == "error" {
+		color = 0xa30200
+	}
+	embed := &fluxDiscordEmbed{
+		Title:       e.Title(),
+		Description: e.Message,
+		Color:       color,
+	}
+	if s := e.Summary(); !utils.IsEmpty(s) {
+		embed.Description = fmt.Sprintf("%s\n%s", s, e.Message)
+	}
+	fields := e.Fields()
+	for _, k := range e.fieldKeys() {
+		embed.Fields = append(embed.Fields, &fluxDiscordField{Name: k, Value: fields[k]})
+	}
+	b, err := json.Marshal([]*fluxDiscordEmbed{embed})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// VerifyFluxSignature checks X-Signature header of generic-hmac provider, e.g. sha256=<hex of HMAC of body>
+// https://fluxcd.io/flux/components/notification/providers/#generic-webhook-with-hmac
+func VerifyFluxSignature(secret string, body []byte, signature string) error {
+
+	if utils.IsEmpty(signature) {
+		return errors.New("no signature")
+	}
+	name, hexSum, ok := strings.Cut(signature, "=")
+	fn, exists := fluxHashes[name]
+	if !ok || !exists {
+		return fmt.Errorf("unsupported signature %s", name)
+	}
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(fn, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// ParseFluxEvent parses event of notification-controller, involved object is required
+func ParseFluxEvent(data []byte) (*FluxEvent, error) {
+
+	var e FluxEvent
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	if utils.IsEmpty(e.InvolvedObject.Kind) || utils.IsEmpty(e.InvolvedObject.Name) {
+		return nil, errors.New("no flux involved object")
+	}
+	if utils.IsEmpty(e.Severity) {
+		e.Severity = "info"
+	}
+	return &e, nil
+}
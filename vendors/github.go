@@ -0,0 +1,781 @@
+package vendors
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const githubBaseURL = "https://api.github.com"
+
+const (
+	githubAcceptHeader     = "application/vnd.github+json"
+	githubAPIVersion       = "2022-11-28"
+	githubContentType      = "application/json"
+	githubAssetContentType = "application/octet-stream"
+)
+
+type GithubOptions struct {
+	Timeout           int
+	Insecure          bool
+	URL               string
+	Token             string
+	AppID             string
+	AppInstallationID string
+	AppPrivateKey     string
+}
+
+type GithubRepoOptions struct {
+	Owner string
+	Repo  string
+}
+
+type GithubIssueOptions struct {
+	Title     string
+	Body      string
+	Labels    []string
+	Assignees []string
+}
+
+type GithubCommentOptions struct {
+	Number int
+	Body   string
+}
+
+type GithubReleaseOptions struct {
+	Tag        string
+	Target     string
+	Name       string
+	Body       string
+	Draft      bool
+	Prerelease bool
+	Assets     []string
+}
+
+type GithubWorkflowDispatchOptions struct {
+	Workflow string
+	Ref      string
+	Inputs   string
+}
+
+type GithubRepositoryDispatchOptions struct {
+	EventType     string
+	ClientPayload string
+}
+
+type GithubPullRequestOptions struct {
+	Number int
+}
+
+type GithubIssue struct {
+	Title     string   `json:"title"`
+	Body      string   `json:"body,omitempty"`
+	Labels    []string `json:"labels,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
+}
+
+type GithubComment struct {
+	Body string `json:"body"`
+}
+
+type GithubRelease struct {
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Body            string `json:"body,omitempty"`
+	Draft           bool   `json:"draft"`
+	Prerelease      bool   `json:"prerelease"`
+}
+
+type GithubReleaseResponse struct {
+	ID        int64           `json:"id"`
+	HtmlURL   string          `json:"html_url"`
+	UploadURL string          `json:"upload_url"`
+	Assets    json.RawMessage `json:"assets,omitempty"`
+}
+
+type GithubWorkflowDispatch struct {
+	Ref    string                 `json:"ref"`
+	Inputs map[string]interface{} `json:"inputs,omitempty"`
+}
+
+type GithubRepositoryDispatch struct {
+	EventType     string                 `json:"event_type"`
+	ClientPayload map[string]interface{} `json:"client_payload,omitempty"`
+}
+
+type GithubPullRequestHead struct {
+	Ref string `json:"ref"`
+	Sha string `json:"sha"`
+}
+
+type GithubPullRequest struct {
+	Number    int                    `json:"number"`
+	State     string                 `json:"state"`
+	Title     string                 `json:"title"`
+	HtmlURL   string                 `json:"html_url"`
+	Draft     bool                   `json:"draft"`
+	Merged    bool                   `json:"merged"`
+	Mergeable *bool                  `json:"mergeable"`
+	Head      *GithubPullRequestHead `json:"head"`
+}
+
+type GithubPullRequestStatus struct {
+	PullRequest *GithubPullRequest `json:"pull_request"`
+	Status      json.RawMessage    `json:"status"`
+	CheckRuns   json.RawMessage    `json:"check_runs"`
+}
+
+type GithubInstallationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type Github struct {
+	client  *http.Client
+	options GithubOptions
+	token   *GithubInstallationToken
+}
+
+func (g *Github) apiURL(opts GithubOptions, p string) (*url.URL, error) {
+
+	base := opts.URL
+	if utils.IsEmpty(base) {
+		base = githubBaseURL
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, p)
+	return u, nil
+}
+
+// https://docs.github.com/en/apps/creating-github-apps/authenticating-with-a-github-app/generating-a-json-web-token-jwt-for-a-github-app
+func (g *Github) appJWT(opts GithubOptions) (string, error) {
+
+	keyBytes, err := utils.Content(opts.AppPrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	block, _ := pem.Decode(keyBytes)
+	if block == nil {
+		return "", errors.New("github app private key is not PEM encoded")
+	}
+
+	var key *rsa.PrivateKey
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return "", err
+		}
+	default:
+		k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return "", err
+		}
+		rk, ok := k.(*rsa.PrivateKey)
+		if !ok {
+			return "", errors.New("github app private key is not RSA")
+		}
+		key = rk
+	}
+
+	now := time.Now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": opts.AppID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (g *Github) installationToken(opts GithubOptions) (string, error) {
+
+	if g.token != nil && time.Now().Add(time.Minute).Before(g.token.ExpiresAt) {
+		return g.token.Token, nil
+	}
+
+	jwt, err := g.appJWT(opts)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := g.apiURL(opts, fmt.Sprintf("/app/installations/%s/access_tokens", opts.AppInstallationID))
+	if err != nil {
+		return "", err
+	}
+
+	headers := g.headers("")
+	headers["Authorization"] = fmt.Sprintf("Bearer %s", jwt)
+
+	b, err := common.HttpPostRawWithHeaders(g.client, u.String(), headers, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var t GithubInstallationToken
+	err = json.Unmarshal(b, &t)
+	if err != nil {
+		return "", err
+	}
+	g.token = &t
+	return t.Token, nil
+}
+
+func (g *Github) getAuth(opts GithubOptions) (string, error) {
+
+	if !utils.IsEmpty(opts.Token) {
+		return fmt.Sprintf("Bearer %s", opts.Token), nil
+	}
+	if !utils.IsEmpty(opts.AppID) && !utils.IsEmpty(opts.AppInstallationID) {
+		token, err := g.installationToken(opts)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Bearer %s", token), nil
+	}
+	return "", errors.New("github token or app credentials are required")
+}
+
+func (g *Github) headers(auth string) map[string]string {
+
+	headers := make(map[string]string)
+	headers["Accept"] = githubAcceptHeader
+	headers["X-GitHub-Api-Version"] = githubAPIVersion
+	headers["Content-Type"] = githubContentType
+	if !utils.IsEmpty(auth) {
+		headers["Authorization"] = auth
+	}
+	return headers
+}
+
+func (g *Github) request(opts GithubOptions, method, p string, params url.Values, data []byte) ([]byte, error) {
+
+	auth, err := g.getAuth(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := g.apiURL(opts, p)
+	if err != nil {
+		return nil, err
+	}
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+	return common.HttpRequestRawWithHeaders(g.client, method, u.String(), g.headers(auth), data)
+}
+
+func (g *Github) parseJsonObject(s string) (map[string]interface{}, error) {
+
+	if utils.IsEmpty(s) {
+		return nil, nil
+	}
+	b, err := utils.Content(s)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	err = json.Unmarshal(b, &m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// https://docs.github.com/en/rest/issues/issues#create-an-issue
+
+func (g *Github) CustomCreateIssue(githubOptions GithubOptions, repoOptions GithubRepoOptions, issueOptions GithubIssueOptions) ([]byte, error) {
+
+	issue := &GithubIssue{
+		Title:     issueOptions.Title,
+		Body:      issueOptions.Body,
+		Labels:    issueOptions.Labels,
+		Assignees: issueOptions.Assignees,
+	}
+
+	data, err := json.Marshal(issue)
+	if err != nil {
+		return nil, err
+	}
+	return g.request(githubOptions, "POST", fmt.Sprintf("/repos/%s/%s/issues", repoOptions.Owner, repoOptions.Repo), nil, data)
+}
+
+func (g *Github) CreateIssue(repoOptions GithubRepoOptions, issueOptions GithubIssueOptions) ([]byte, error) {
+	return g.CustomCreateIssue(g.options, repoOptions, issueOptions)
+}
+
+// pull requests are issues from the comments point of view
+// https://docs.github.com/en/rest/issues/comments#create-an-issue-comment
+
+func (g *Github) CustomComment(githubOptions GithubOptions, repoOptions GithubRepoOptions, commentOptions GithubCommentOptions) ([]byte, error) {
+
+	comment := &GithubComment{
+		Body: commentOptions.Body,
+	}
+
+	data, err := json.Marshal(comment)
+	if err != nil {
+		return nil, err
+	}
+	return g.request(githubOptions, "POST", fmt.Sprintf("/repos/%s/%s/issues/%d/comments", repoOptions.Owner, repoOptions.Repo, commentOptions.Number), nil, data)
+}
+
+func (g *Github) Comment(repoOptions GithubRepoOptions, commentOptions GithubCommentOptions) ([]byte, error) {
+	return g.CustomComment(g.options, repoOptions, commentOptions)
+}
+
+func (g *Github) uploadReleaseAsset(githubOptions GithubOptions, uploadURL, file string) error {
+
+	auth, err := g.getAuth(githubOptions)
+	if err != nil {
+		return err
+	}
+
+	// upload_url looks like https://uploads.github.com/repos/o/r/releases/1/assets{?name,label}
+	if i := strings.Index(uploadURL, "{"); i > 0 {
+		uploadURL = uploadURL[:i]
+	}
+
+	u, err := url.Parse(uploadURL)
+	if err != nil {
+		return err
+	}
+	params := make(url.Values)
+	params.Add("name", filepath.Base(file))
+	u.RawQuery = params.Encode()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	headers := g.headers(auth)
+	headers["Content-Type"] = githubAssetContentType
+
+	_, err = common.HttpPostRawWithHeaders(g.client, u.String(), headers, data)
+	return err
+}
+
+// https://docs.github.com/en/rest/releases/releases#create-a-release
+// https://docs.github.com/en/rest/releases/assets#upload-a-release-asset
+
+func (g *Github) CustomCreateRelease(githubOptions GithubOptions, repoOptions GithubRepoOptions, releaseOptions GithubReleaseOptions) ([]byte, error) {
+
+	release := &GithubRelease{
+		TagName:         releaseOptions.Tag,
+		TargetCommitish: releaseOptions.Target,
+		Name:            releaseOptions.Name,
+		Body:            releaseOptions.Body,
+		Draft:           releaseOptions.Draft,
+		Prerelease:      releaseOptions.Prerelease,
+	}
+
+	data, err := json.Marshal(release)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := g.request(githubOptions, "POST", fmt.Sprintf("/repos/%s/%s/releases", repoOptions.Owner, repoOptions.Repo), nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	assets := common.RemoveEmptyStrings(releaseOptions.Assets)
+	if len(assets) == 0 {
+		return b, nil
+	}
+
+	var r GithubReleaseResponse
+	err = json.Unmarshal(b, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range assets {
+		err = g.uploadReleaseAsset(githubOptions, r.UploadURL, a)
+		if err != nil {
+			return b, fmt.Errorf("upload asset %s: %w", a, err)
+		}
+	}
+
+	// refetch release to get uploaded assets in output
+	return g.request(githubOptions, "GET", fmt.Sprintf("/repos/%s/%s/releases/%d", repoOptions.Owner, repoOptions.Repo, r.ID), nil, nil)
+}
+
+func (g *Github) CreateRelease(repoOptions GithubRepoOptions, releaseOptions GithubReleaseOptions) ([]byte, error) {
+	return g.CustomCreateRelease(g.options, repoOptions, releaseOptions)
+}
+
+// https://docs.github.com/en/rest/actions/workflows#create-a-workflow-dispatch-event
+
+func (g *Github) CustomDispatchWorkflow(githubOptions GithubOptions, repoOptions GithubRepoOptions, dispatchOptions GithubWorkflowDispatchOptions) ([]byte, error) {
+
+	inputs, err := g.parseJsonObject(dispatchOptions.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	dispatch := &GithubWorkflowDispatch{
+		Ref:    dispatchOptions.Ref,
+		Inputs: inputs,
+	}
+
+	data, err := json.Marshal(dispatch)
+	if err != nil {
+		return nil, err
+	}
+	return g.request(githubOptions, "POST", fmt.Sprintf("/repos/%s/%s/actions/workflows/%s/dispatches", repoOptions.Owner, repoOptions.Repo, dispatchOptions.Workflow), nil, data)
+}
+
+func (g *Github) DispatchWorkflow(repoOptions GithubRepoOptions, dispatchOptions GithubWorkflowDispatchOptions) ([]byte, error) {
+	return g.CustomDispatchWorkflow(g.options, repoOptions, dispatchOptions)
+}
+
+// https://docs.github.com/en/rest/repos/repos#create-a-repository-dispatch-event
+
+func (g *Github) CustomDispatchRepository(githubOptions GithubOptions, repoOptions GithubRepoOptions, dispatchOptions GithubRepositoryDispatchOptions) ([]byte, error) {
+
+	payload, err := g.parseJsonObject(dispatchOptions.ClientPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	dispatch := &GithubRepositoryDispatch{
+		EventType:     dispatchOptions.EventType,
+		ClientPayload: payload,
+	}
+
+	data, err := json.Marshal(dispatch)
+	if err != nil {
+		return nil, err
+	}
+	return g.request(githubOptions, "POST", fmt.Sprintf("/repos/%s/%s/dispatches", repoOptions.Owner, repoOptions.Repo), nil, data)
+}
+
+func (g *Github) DispatchRepository(repoOptions GithubRepoOptions, dispatchOptions GithubRepositoryDispatchOptions) ([]byte, error) {
+	return g.CustomDispatchRepository(g.options, repoOptions, dispatchOptions)
+}
+
+// https://docs.github.com/en/rest/pulls/pulls#get-a-pull-request
+// https://docs.github.com/en/rest/commits/statuses#get-the-combined-status-for-a-specific-reference
+// https://docs.github.com/en/rest/checks/runs#list-check-runs-for-a-git-reference
+
+func (g *Github) CustomGetPullRequestStatus(githubOptions GithubOptions, repoOptions GithubRepoOptions, pullRequestOptions GithubPullRequestOptions) ([]byte, error) {
+
+	b, err := g.request(githubOptions, "GET", fmt.Sprintf("/repos/%s/%s/pulls/%d", repoOptions.Owner, repoOptions.Repo, pullRequestOptions.Number), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var pr GithubPullRequest
+	err = json.Unmarshal(b, &pr)
+	if err != nil {
+		return nil, err
+	}
+	if pr.Head == nil {
+		return nil, errors.New("github pull request has no head")
+	}
+
+	status, err := g.request(githubOptions, "GET", fmt.Sprintf("/repos/%s/%s/commits/%s/status", repoOptions.Owner, repoOptions.Repo, pr.Head.Sha), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	checkRuns, err := g.request(githubOptions, "GET", fmt.Sprintf("/repos/%s/%s/commits/%s/check-runs", repoOptions.Owner, repoOptions.Repo, pr.Head.Sha), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &GithubPullRequestStatus{
+		PullRequest: &pr,
+		Status:      status,
+		CheckRuns:   checkRuns,
+	}
+	return json.Marshal(r)
+}
+
+func (g *Github) GetPullRequestStatus(repoOptions GithubRepoOptions, pullRequestOptions GithubPullRequestOptions) ([]byte, error) {
+	return g.CustomGetPullRequestStatus(g.options, repoOptions, pullRequestOptions)
+}
+
+type GithubWebhookCommit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Author  string `json:"author"`
+	URL     string `json:"url"`
+}
+
+type GithubWebhookPullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	Merged bool   `json:"merged"`
+	URL    string `json:"url"`
+	User   string `json:"user"`
+	Head   string `json:"head"`
+	Base   string `json:"base"`
+}
+
+type GithubWebhookWorkflowRun struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	Branch     string `json:"branch"`
+	URL        string `json:"url"`
+}
+
+// GithubWebhook is push, pull_request or workflow_run event normalized from webhook payload
+// https://docs.github.com/en/webhooks/webhook-events-and-payloads
+type GithubWebhook struct {
+	Event         string                    `json:"event"`
+	Action        string                    `json:"action,omitempty"`
+	Repository    string                    `json:"repository"`
+	RepositoryURL string                    `json:"repositoryUrl"`
+	Sender        string                    `json:"sender"`
+	Ref           string                    `json:"ref,omitempty"`
+	Branch        string                    `json:"branch,omitempty"`
+	Tag           string                    `json:"tag,omitempty"`
+	Created       bool                      `json:"created,omitempty"`
+	Deleted       bool                      `json:"deleted,omitempty"`
+	Forced        bool                      `json:"forced,omitempty"`
+	Compare       string                    `json:"compare,omitempty"`
+	Commits       []*GithubWebhookCommit    `json:"commits,omitempty"`
+	PullRequest   *GithubWebhookPullRequest `json:"pullRequest,omitempty"`
+	WorkflowRun   *GithubWebhookWorkflowRun `json:"workflowRun,omitempty"`
+}
+
+type githubWebhookPayload struct {
+	Action     string `json:"action"`
+	Ref        string `json:"ref"`
+	Created    bool   `json:"created"`
+	Deleted    bool   `json:"deleted"`
+	Forced     bool   `json:"forced"`
+	Compare    string `json:"compare"`
+	Repository struct {
+		FullName string `json:"full_name"`
+		HtmlURL  string `json:"html_url"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+	Commits []struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+		URL     string `json:"url"`
+		Author  struct {
+			Name     string `json:"name"`
+			Username string `json:"username"`
+		} `json:"author"`
+	} `json:"commits"`
+	PullRequest *struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		State   string `json:"state"`
+		Merged  bool   `json:"merged"`
+		HtmlURL string `json:"html_url"`
+		User    struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Head struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	WorkflowRun *struct {
+		ID         int64  `json:"id"`
+		Name       string `json:"name"`
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+		HeadBranch string `json:"head_branch"`
+		HtmlURL    string `json:"html_url"`
+	} `json:"workflow_run"`
+}
+
+// Summary is one line description of event, e.g. alice pushed 2 commits to main of org/repo
+func (w *GithubWebhook) Summary() string {
+
+	switch w.Event {
+	case "push":
+		switch {
+		case !utils.IsEmpty(w.Tag) && w.Deleted:
+			return fmt.Sprintf("%s deleted tag %s of %s", w.Sender, w.Tag, w.Repository)
+		case !utils.IsEmpty(w.Tag):
+			return fmt.Sprintf("%s pushed tag %s to %s", w.Sender, w.Tag, w.Repository)
+		case w.Deleted:
+			return fmt.Sprintf("%s deleted branch %s of %s", w.Sender, w.Branch, w.Repository)
+		}
+		verb := "pushed"
+		if w.Forced {
+			verb = "force-pushed"
+		}
+		return fmt.Sprintf("%s %s %d commit(s) to %s of %s", w.Sender, verb, len(w.Commits), w.Branch, w.Repository)
+	case "pull_request":
+		action := w.Action
+		if action == "closed" && w.PullRequest.Merged {
+			action = "merged"
+		}
+		return fmt.Sprintf("%s %s pull request #%d of %s: %s", w.Sender, action, w.PullRequest.Number, w.Repository, w.PullRequest.Title)
+	case "workflow_run":
+		state := w.WorkflowRun.Status
+		if !utils.IsEmpty(w.WorkflowRun.Conclusion) {
+			state = w.WorkflowRun.Conclusion
+		}
+		return fmt.Sprintf("workflow %s of %s on %s is %s", w.WorkflowRun.Name, w.Repository, w.WorkflowRun.Branch, state)
+	}
+	return fmt.Sprintf("%s %s of %s", w.Sender, w.Event, w.Repository)
+}
+
+// VerifyGithubSignature checks X-Hub-Signature-256 header which is HMAC SHA256 of body with webhook secret
+// https://docs.github.com/en/webhooks/using-webhooks/validating-webhook-deliveries
+func VerifyGithubSignature(secret string, body []byte, signature string) error {
+
+	if utils.IsEmpty(signature) {
+		return errors.New("no signature")
+	}
+	hexSum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return errors.New("unsupported signature, sha256 is expected")
+	}
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// ParseGithubWebhook parses payload of event from X-GitHub-Event header,
+// ping event has no webhook and no error
+func ParseGithubWebhook(event string, data []byte) (*GithubWebhook, error) {
+
+	var p githubWebhookPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if event == "ping" {
+		return nil, nil
+	}
+
+	w := &GithubWebhook{
+		Event:         event,
+		Action:        p.Action,
+		Repository:    p.Repository.FullName,
+		RepositoryURL: p.Repository.HtmlURL,
+		Sender:        p.Sender.Login,
+	}
+
+	switch event {
+	case "push":
+		w.Ref = p.Ref
+		w.Created = p.Created
+		w.Deleted = p.Deleted
+		w.Forced = p.Forced
+		w.Compare = p.Compare
+		if tag, ok := strings.CutPrefix(p.Ref, "refs/tags/"); ok {
+			w.Tag = tag
+		} else {
+			w.Branch = strings.TrimPrefix(p.Ref, "refs/heads/")
+		}
+		for _, c := range p.Commits {
+			author := c.Author.Username
+			if utils.IsEmpty(author) {
+				author = c.Author.Name
+			}
+			w.Commits = append(w.Commits, &GithubWebhookCommit{ID: c.ID, Message: c.Message, Author: author, URL: c.URL})
+		}
+	case "pull_request":
+		if p.PullRequest == nil {
+			return nil, errors.New("no pull request")
+		}
+		pr := p.PullRequest
+		w.PullRequest = &GithubWebhookPullRequest{
+			Number: pr.Number,
+			Title:  pr.Title,
+			State:  pr.State,
+			Merged: pr.Merged,
+			URL:    pr.HtmlURL,
+			User:   pr.User.Login,
+			Head:   pr.Head.Ref,
+			Base:   pr.Base.Ref,
+		}
+		w.Branch = pr.Base.Ref
+	case "workflow_run":
+		if p.WorkflowRun == nil {
+			return nil, errors.New("no workflow run")
+		}
+		wr := p.WorkflowRun
+		w.WorkflowRun = &GithubWebhookWorkflowRun{
+			ID:         wr.ID,
+			Name:       wr.Name,
+			Status:     wr.Status,
+			Conclusion: wr.Conclusion,
+			Branch:     wr.HeadBranch,
+			URL:        wr.HtmlURL,
+		}
+		w.Branch = wr.HeadBranch
+	default:
+		return nil, fmt.Errorf("unsupported github event %s", event)
+	}
+	return w, nil
+}
+
+func (g *Github) WithContext(ctx context.Context) *Github {
+	c := *g
+	c.client = common.HttpClientContext(g.client, ctx)
+	return &c
+}
+
+func NewGithub(options GithubOptions) *Github {
+
+	github := &Github{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+	return github
+}
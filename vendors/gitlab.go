@@ -1,6 +1,8 @@
 package vendors
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,9 +10,11 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/devopsext/tools/common"
 	"github.com/devopsext/utils"
 )
 
@@ -41,6 +45,99 @@ type GitlabGetPipelineVariablesOptions struct {
 	Query []string
 }
 
+type GitlabTriggerPipelineOptions struct {
+	ProjectID    int
+	Ref          string
+	TriggerToken string
+	Variables    string
+}
+
+type GitlabPipelineStatusOptions struct {
+	ProjectID  int
+	PipelineID int
+}
+
+type GitlabMergeRequestOptions struct {
+	ProjectID          int
+	SourceBranch       string
+	TargetBranch       string
+	Title              string
+	Description        string
+	Labels             []string
+	RemoveSourceBranch bool
+}
+
+type GitlabMergeRequestNoteOptions struct {
+	ProjectID int
+	IID       int
+	Body      string
+}
+
+type GitlabTagOptions struct {
+	ProjectID int
+	Name      string
+	Ref       string
+	Message   string
+}
+
+type GitlabVariableOptions struct {
+	ProjectID        int
+	Key              string
+	Value            string
+	Type             string
+	Protected        bool
+	Masked           bool
+	Raw              bool
+	EnvironmentScope string
+}
+
+type GitlabDeploymentsOptions struct {
+	ProjectID   int
+	Environment string
+	Status      string
+	Limit       int
+}
+
+type GitlabPipelineVariable struct {
+	Key          string `json:"key"`
+	Value        string `json:"value"`
+	VariableType string `json:"variable_type,omitempty"`
+}
+
+type GitlabPipelineCreate struct {
+	Ref       string                    `json:"ref"`
+	Variables []*GitlabPipelineVariable `json:"variables,omitempty"`
+}
+
+type GitlabMergeRequestCreate struct {
+	SourceBranch       string `json:"source_branch"`
+	TargetBranch       string `json:"target_branch"`
+	Title              string `json:"title"`
+	Description        string `json:"description,omitempty"`
+	Labels             string `json:"labels,omitempty"`
+	RemoveSourceBranch bool   `json:"remove_source_branch"`
+}
+
+type GitlabNoteCreate struct {
+	Body string `json:"body"`
+}
+
+type GitlabTagCreate struct {
+	TagName string `json:"tag_name"`
+	Ref     string `json:"ref"`
+	Message string `json:"message,omitempty"`
+}
+
+type GitlabProjectVariable struct {
+	Key              string `json:"key"`
+	Value            string `json:"value"`
+	VariableType     string `json:"variable_type,omitempty"`
+	Protected        bool   `json:"protected"`
+	Masked           bool   `json:"masked"`
+	Raw              bool   `json:"raw"`
+	EnvironmentScope string `json:"environment_scope,omitempty"`
+}
+
 type GitlabPipelinesResp struct {
 	ID        int       `json:"id"`
 	Iid       int       `json:"iid"`
@@ -74,14 +171,13 @@ func (g *Gitlab) get(url string) ([]byte, error) {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf(resp.Status)
-	}
-
 	b, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
+	if err := common.HttpStatusError(resp.StatusCode, b); err != nil {
+		return nil, err
+	}
 	return b, nil
 }
 
@@ -221,7 +317,7 @@ func (g *Gitlab) getPipelines(gitlabOptions GitlabOptions, pipelineOptions Gitla
 	headers := make(map[string]string)
 	headers["PRIVATE-TOKEN"] = gitlabOptions.Token
 
-	b, err := utils.HttpGetRawWithHeaders(g.client, u.String(), headers)
+	b, err := common.HttpGetRawWithHeaders(g.client, u.String(), headers)
 	if err != nil {
 		return nil, err
 	}
@@ -245,7 +341,7 @@ func (g *Gitlab) getPipelineVariablesEx(gitlabOptions GitlabOptions, projectID,
 	headers := make(map[string]string)
 	headers["PRIVATE-TOKEN"] = gitlabOptions.Token
 
-	b, err := utils.HttpGetRawWithHeaders(g.client, u.String(), headers)
+	b, err := common.HttpGetRawWithHeaders(g.client, u.String(), headers)
 	if err != nil {
 		return nil, err
 	}
@@ -338,10 +434,440 @@ func (g *Gitlab) GetPipelineVariables(pipelineOptions GitlabPipelineOptions, get
 	return g.CustomGetPipelineVariables(g.options, pipelineOptions, getVariablesOptions)
 }
 
+func (g *Gitlab) request(gitlabOptions GitlabOptions, method, path string, params url.Values, data []byte) ([]byte, error) {
+
+	u, err := url.Parse(gitlabOptions.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+
+	headers := make(map[string]string)
+	headers["PRIVATE-TOKEN"] = gitlabOptions.Token
+	headers["Content-Type"] = "application/json"
+
+	return common.HttpRequestRawWithHeaders(g.client, method, u.String(), headers, data)
+}
+
+// https://docs.gitlab.com/ee/ci/triggers/#use-a-webhook
+// https://docs.gitlab.com/ee/api/pipelines.html#create-a-new-pipeline
+
+func (g *Gitlab) CustomTriggerPipeline(gitlabOptions GitlabOptions, triggerOptions GitlabTriggerPipelineOptions) ([]byte, error) {
+
+	variables := utils.MapGetKeyValues(triggerOptions.Variables)
+
+	// trigger token doesn't require private token and could be used cross-project
+	if !utils.IsEmpty(triggerOptions.TriggerToken) {
+
+		params := make(url.Values)
+		params.Add("token", triggerOptions.TriggerToken)
+		params.Add("ref", triggerOptions.Ref)
+		for k, v := range variables {
+			params.Add(fmt.Sprintf("variables[%s]", k), v)
+		}
+
+		u, err := url.Parse(gitlabOptions.URL)
+		if err != nil {
+			return nil, err
+		}
+		u.Path = fmt.Sprintf("/api/v4/projects/%d/trigger/pipeline", triggerOptions.ProjectID)
+
+		return common.HttpPostRaw(g.client, u.String(), "application/x-www-form-urlencoded", "", []byte(params.Encode()))
+	}
+
+	pipeline := &GitlabPipelineCreate{
+		Ref: triggerOptions.Ref,
+	}
+	for k, v := range variables {
+		pipeline.Variables = append(pipeline.Variables, &GitlabPipelineVariable{Key: k, Value: v})
+	}
+
+	data, err := json.Marshal(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return g.request(gitlabOptions, "POST", fmt.Sprintf("/api/v4/projects/%d/pipeline", triggerOptions.ProjectID), nil, data)
+}
+
+func (g *Gitlab) TriggerPipeline(triggerOptions GitlabTriggerPipelineOptions) ([]byte, error) {
+	return g.CustomTriggerPipeline(g.options, triggerOptions)
+}
+
+// https://docs.gitlab.com/ee/api/pipelines.html#get-a-single-pipeline
+
+func (g *Gitlab) CustomGetPipelineStatus(gitlabOptions GitlabOptions, statusOptions GitlabPipelineStatusOptions) ([]byte, error) {
+	return g.request(gitlabOptions, "GET", fmt.Sprintf("/api/v4/projects/%d/pipelines/%d", statusOptions.ProjectID, statusOptions.PipelineID), nil, nil)
+}
+
+func (g *Gitlab) GetPipelineStatus(statusOptions GitlabPipelineStatusOptions) ([]byte, error) {
+	return g.CustomGetPipelineStatus(g.options, statusOptions)
+}
+
+// https://docs.gitlab.com/ee/api/merge_requests.html#create-mr
+
+func (g *Gitlab) CustomCreateMergeRequest(gitlabOptions GitlabOptions, mergeRequestOptions GitlabMergeRequestOptions) ([]byte, error) {
+
+	mr := &GitlabMergeRequestCreate{
+		SourceBranch:       mergeRequestOptions.SourceBranch,
+		TargetBranch:       mergeRequestOptions.TargetBranch,
+		Title:              mergeRequestOptions.Title,
+		Description:        mergeRequestOptions.Description,
+		Labels:             strings.Join(common.RemoveEmptyStrings(mergeRequestOptions.Labels), ","),
+		RemoveSourceBranch: mergeRequestOptions.RemoveSourceBranch,
+	}
+
+	data, err := json.Marshal(mr)
+	if err != nil {
+		return nil, err
+	}
+	return g.request(gitlabOptions, "POST", fmt.Sprintf("/api/v4/projects/%d/merge_requests", mergeRequestOptions.ProjectID), nil, data)
+}
+
+func (g *Gitlab) CreateMergeRequest(mergeRequestOptions GitlabMergeRequestOptions) ([]byte, error) {
+	return g.CustomCreateMergeRequest(g.options, mergeRequestOptions)
+}
+
+// https://docs.gitlab.com/ee/api/notes.html#create-new-merge-request-note
+
+func (g *Gitlab) CustomCommentOnMergeRequest(gitlabOptions GitlabOptions, noteOptions GitlabMergeRequestNoteOptions) ([]byte, error) {
+
+	note := &GitlabNoteCreate{
+		Body: noteOptions.Body,
+	}
+
+	data, err := json.Marshal(note)
+	if err != nil {
+		return nil, err
+	}
+	return g.request(gitlabOptions, "POST", fmt.Sprintf("/api/v4/projects/%d/merge_requests/%d/notes", noteOptions.ProjectID, noteOptions.IID), nil, data)
+}
+
+func (g *Gitlab) CommentOnMergeRequest(noteOptions GitlabMergeRequestNoteOptions) ([]byte, error) {
+	return g.CustomCommentOnMergeRequest(g.options, noteOptions)
+}
+
+// https://docs.gitlab.com/ee/api/tags.html#create-a-new-tag
+
+func (g *Gitlab) CustomCreateTag(gitlabOptions GitlabOptions, tagOptions GitlabTagOptions) ([]byte, error) {
+
+	tag := &GitlabTagCreate{
+		TagName: tagOptions.Name,
+		Ref:     tagOptions.Ref,
+		Message: tagOptions.Message,
+	}
+
+	data, err := json.Marshal(tag)
+	if err != nil {
+		return nil, err
+	}
+	return g.request(gitlabOptions, "POST", fmt.Sprintf("/api/v4/projects/%d/repository/tags", tagOptions.ProjectID), nil, data)
+}
+
+func (g *Gitlab) CreateTag(tagOptions GitlabTagOptions) ([]byte, error) {
+	return g.CustomCreateTag(g.options, tagOptions)
+}
+
+// https://docs.gitlab.com/ee/api/project_level_variables.html
+
+func (g *Gitlab) variableParams(variableOptions GitlabVariableOptions) url.Values {
+
+	params := make(url.Values)
+	if !utils.IsEmpty(variableOptions.EnvironmentScope) && variableOptions.EnvironmentScope != "*" {
+		params.Add("filter[environment_scope]", variableOptions.EnvironmentScope)
+	}
+	return params
+}
+
+func (g *Gitlab) CustomListVariables(gitlabOptions GitlabOptions, variableOptions GitlabVariableOptions) ([]byte, error) {
+	return g.request(gitlabOptions, "GET", fmt.Sprintf("/api/v4/projects/%d/variables", variableOptions.ProjectID), nil, nil)
+}
+
+func (g *Gitlab) ListVariables(variableOptions GitlabVariableOptions) ([]byte, error) {
+	return g.CustomListVariables(g.options, variableOptions)
+}
+
+func (g *Gitlab) CustomGetVariable(gitlabOptions GitlabOptions, variableOptions GitlabVariableOptions) ([]byte, error) {
+	return g.request(gitlabOptions, "GET", fmt.Sprintf("/api/v4/projects/%d/variables/%s", variableOptions.ProjectID, url.PathEscape(variableOptions.Key)),
+		g.variableParams(variableOptions), nil)
+}
+
+func (g *Gitlab) GetVariable(variableOptions GitlabVariableOptions) ([]byte, error) {
+	return g.CustomGetVariable(g.options, variableOptions)
+}
+
+// CustomSetVariable updates variable if it exists, creates it if it's not found, other errors of getting it are returned,
+// so that variable isn't created by failures of auth or outages
+func (g *Gitlab) CustomSetVariable(gitlabOptions GitlabOptions, variableOptions GitlabVariableOptions) ([]byte, error) {
+
+	variable := &GitlabProjectVariable{
+		Key:              variableOptions.Key,
+		Value:            variableOptions.Value,
+		VariableType:     variableOptions.Type,
+		Protected:        variableOptions.Protected,
+		Masked:           variableOptions.Masked,
+		Raw:              variableOptions.Raw,
+		EnvironmentScope: variableOptions.EnvironmentScope,
+	}
+
+	data, err := json.Marshal(variable)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = g.CustomGetVariable(gitlabOptions, variableOptions)
+	if err != nil {
+		var apiErr *common.APIError
+		if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+			return nil, err
+		}
+		return g.request(gitlabOptions, "POST", fmt.Sprintf("/api/v4/projects/%d/variables", variableOptions.ProjectID), nil, data)
+	}
+	return g.request(gitlabOptions, "PUT", fmt.Sprintf("/api/v4/projects/%d/variables/%s", variableOptions.ProjectID, url.PathEscape(variableOptions.Key)),
+		g.variableParams(variableOptions), data)
+}
+
+func (g *Gitlab) SetVariable(variableOptions GitlabVariableOptions) ([]byte, error) {
+	return g.CustomSetVariable(g.options, variableOptions)
+}
+
+func (g *Gitlab) CustomDeleteVariable(gitlabOptions GitlabOptions, variableOptions GitlabVariableOptions) ([]byte, error) {
+	return g.request(gitlabOptions, "DELETE", fmt.Sprintf("/api/v4/projects/%d/variables/%s", variableOptions.ProjectID, url.PathEscape(variableOptions.Key)),
+		g.variableParams(variableOptions), nil)
+}
+
+func (g *Gitlab) DeleteVariable(variableOptions GitlabVariableOptions) ([]byte, error) {
+	return g.CustomDeleteVariable(g.options, variableOptions)
+}
+
+// https://docs.gitlab.com/ee/api/deployments.html#list-project-deployments
+
+func (g *Gitlab) CustomListDeployments(gitlabOptions GitlabOptions, deploymentsOptions GitlabDeploymentsOptions) ([]byte, error) {
+
+	params := make(url.Values)
+	params.Add("order_by", "created_at")
+	params.Add("sort", "desc")
+	if !utils.IsEmpty(deploymentsOptions.Environment) {
+		params.Add("environment", deploymentsOptions.Environment)
+	}
+	if !utils.IsEmpty(deploymentsOptions.Status) {
+		params.Add("status", deploymentsOptions.Status)
+	}
+	if deploymentsOptions.Limit > 0 {
+		params.Add("per_page", strconv.Itoa(deploymentsOptions.Limit))
+	}
+	return g.request(gitlabOptions, "GET", fmt.Sprintf("/api/v4/projects/%d/deployments", deploymentsOptions.ProjectID), params, nil)
+}
+
+func (g *Gitlab) ListDeployments(deploymentsOptions GitlabDeploymentsOptions) ([]byte, error) {
+	return g.CustomListDeployments(g.options, deploymentsOptions)
+}
+
+type GitlabWebhookCommit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Author  string `json:"author"`
+	URL     string `json:"url"`
+}
+
+type GitlabWebhookMergeRequest struct {
+	IID    int    `json:"iid"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	URL    string `json:"url"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+type GitlabWebhookPipeline struct {
+	ID       int64  `json:"id"`
+	Status   string `json:"status"`
+	Ref      string `json:"ref"`
+	Source   string `json:"source"`
+	Duration int64  `json:"duration"`
+	URL      string `json:"url"`
+}
+
+// GitlabWebhook is push, merge request or pipeline event normalized from webhook payload
+// https://docs.gitlab.com/user/project/integrations/webhook_events/
+type GitlabWebhook struct {
+	Event        string                     `json:"event"`
+	Action       string                     `json:"action,omitempty"`
+	Project      string                     `json:"project"`
+	ProjectURL   string                     `json:"projectUrl"`
+	User         string                     `json:"user"`
+	Ref          string                     `json:"ref,omitempty"`
+	Branch       string                     `json:"branch,omitempty"`
+	Tag          string                     `json:"tag,omitempty"`
+	Commits      []*GitlabWebhookCommit     `json:"commits,omitempty"`
+	TotalCommits int                        `json:"totalCommits,omitempty"`
+	MergeRequest *GitlabWebhookMergeRequest `json:"mergeRequest,omitempty"`
+	Pipeline     *GitlabWebhookPipeline     `json:"pipeline,omitempty"`
+}
+
+type gitlabWebhookPayload struct {
+	ObjectKind        string `json:"object_kind"`
+	Ref               string `json:"ref"`
+	UserUsername      string `json:"user_username"`
+	TotalCommitsCount int    `json:"total_commits_count"`
+	User              struct {
+		Name     string `json:"name"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		WebURL            string `json:"web_url"`
+	} `json:"project"`
+	Commits []struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+		URL     string `json:"url"`
+		Author  struct {
+			Name string `json:"name"`
+		} `json:"author"`
+	} `json:"commits"`
+	ObjectAttributes json.RawMessage `json:"object_attributes"`
+}
+
+type gitlabWebhookMergeRequestAttributes struct {
+	IID          int    `json:"iid"`
+	Title        string `json:"title"`
+	State        string `json:"state"`
+	Action       string `json:"action"`
+	URL          string `json:"url"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+}
+
+type gitlabWebhookPipelineAttributes struct {
+	ID       int64  `json:"id"`
+	Status   string `json:"status"`
+	Ref      string `json:"ref"`
+	Tag      bool   `json:"tag"`
+	Source   string `json:"source"`
+	Duration int64  `json:"duration"`
+	URL      string `json:"url"`
+}
+
+// Summary is one line description of event, e.g. alice pushed 2 commits to main of group/project
+func (w *GitlabWebhook) Summary() string {
+
+	switch w.Event {
+	case "push":
+		if w.TotalCommits == 0 {
+			return fmt.Sprintf("%s deleted branch %s of %s", w.User, w.Branch, w.Project)
+		}
+		return fmt.Sprintf("%s pushed %d commit(s) to %s of %s", w.User, w.TotalCommits, w.Branch, w.Project)
+	case "tag_push":
+		return fmt.Sprintf("%s pushed tag %s to %s", w.User, w.Tag, w.Project)
+	case "merge_request":
+		return fmt.Sprintf("%s %s merge request !%d of %s: %s", w.User, w.Action, w.MergeRequest.IID, w.Project, w.MergeRequest.Title)
+	case "pipeline":
+		return fmt.Sprintf("pipeline #%d of %s on %s is %s", w.Pipeline.ID, w.Project, w.Pipeline.Ref, w.Pipeline.Status)
+	}
+	return fmt.Sprintf("%s %s of %s", w.User, w.Event, w.Project)
+}
+
+// VerifyGitlabToken checks X-Gitlab-Token header which is secret token of webhook
+// https://docs.gitlab.com/user/project/integrations/webhooks/#validate-requests-with-a-secret-token
+func VerifyGitlabToken(secret, token string) error {
+
+	if utils.IsEmpty(token) {
+		return errors.New("no token")
+	}
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(token)) != 1 {
+		return errors.New("token mismatch")
+	}
+	return nil
+}
+
+// ParseGitlabWebhook parses push, tag push, merge request and pipeline payloads by object_kind
+func ParseGitlabWebhook(data []byte) (*GitlabWebhook, error) {
+
+	var p gitlabWebhookPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+
+	w := &GitlabWebhook{
+		Event:      p.ObjectKind,
+		Project:    p.Project.PathWithNamespace,
+		ProjectURL: p.Project.WebURL,
+		User:       p.UserUsername,
+	}
+	if utils.IsEmpty(w.User) {
+		w.User = p.User.Username
+	}
+
+	switch p.ObjectKind {
+	case "push", "tag_push":
+		w.Ref = p.Ref
+		w.TotalCommits = p.TotalCommitsCount
+		if p.ObjectKind == "tag_push" {
+			w.Tag = strings.TrimPrefix(p.Ref, "refs/tags/")
+		} else {
+			w.Branch = strings.TrimPrefix(p.Ref, "refs/heads/")
+		}
+		for _, c := range p.Commits {
+			w.Commits = append(w.Commits, &GitlabWebhookCommit{ID: c.ID, Message: c.Message, Author: c.Author.Name, URL: c.URL})
+		}
+	case "merge_request":
+		var a gitlabWebhookMergeRequestAttributes
+		if err := json.Unmarshal(p.ObjectAttributes, &a); err != nil {
+			return nil, err
+		}
+		w.Action = a.Action
+		w.Branch = a.TargetBranch
+		w.MergeRequest = &GitlabWebhookMergeRequest{
+			IID:    a.IID,
+			Title:  a.Title,
+			State:  a.State,
+			URL:    a.URL,
+			Source: a.SourceBranch,
+			Target: a.TargetBranch,
+		}
+	case "pipeline":
+		var a gitlabWebhookPipelineAttributes
+		if err := json.Unmarshal(p.ObjectAttributes, &a); err != nil {
+			return nil, err
+		}
+		w.Action = a.Status
+		w.Ref = a.Ref
+		if a.Tag {
+			w.Tag = a.Ref
+		} else {
+			w.Branch = a.Ref
+		}
+		u := a.URL
+		if utils.IsEmpty(u) && !utils.IsEmpty(p.Project.WebURL) {
+			u = fmt.Sprintf("%s/-/pipelines/%d", p.Project.WebURL, a.ID)
+		}
+		w.Pipeline = &GitlabWebhookPipeline{
+			ID:       a.ID,
+			Status:   a.Status,
+			Ref:      a.Ref,
+			Source:   a.Source,
+			Duration: a.Duration,
+			URL:      u,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported gitlab event %s", p.ObjectKind)
+	}
+	return w, nil
+}
+
+func (g *Gitlab) WithContext(ctx context.Context) *Gitlab {
+	c := *g
+	c.client = common.HttpClientContext(g.client, ctx)
+	return &c
+}
+
 func NewGitlab(options GitlabOptions) *Gitlab {
 
 	gitlab := &Gitlab{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 	}
 	return gitlab
@@ -2,6 +2,7 @@ package vendors
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +11,9 @@ import (
 	"net/url"
 	"path"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/devopsext/tools/common"
 	"github.com/devopsext/utils"
@@ -76,6 +80,21 @@ type GoogleCalendarEvent struct {
 	GuestsCanSeeOtherGuests bool                           `json:"guestsCanSeeOtherGuests"`
 	Source                  *GoogleCalendarEventSource     `json:"source,omitempty"`
 	ConferenceData          *GoogleConferenceData          `json:"conferenceData,omitempty"`
+	HangoutLink             string                         `json:"hangoutLink,omitempty"`
+	HtmlLink                string                         `json:"htmlLink,omitempty"`
+	Status                  string                         `json:"status,omitempty"`
+}
+
+type GoogleCalendarListEntry struct {
+	ID         string `json:"id"`
+	Summary    string `json:"summary"`
+	Primary    bool   `json:"primary,omitempty"`
+	AccessRole string `json:"accessRole,omitempty"`
+}
+
+type GoogleCalendarList struct {
+	Items         []*GoogleCalendarListEntry `json:"items"`
+	NextPageToken string                     `json:"nextPageToken,omitempty"`
 }
 
 type GoogleCalendarEvents struct {
@@ -97,6 +116,12 @@ type GoogleCalendarInsertEventOptions struct {
 	SourceTitle         string
 	SourceURL           string
 	ConferenceID        string
+	ID                  string
+	Attendees           []string
+}
+
+type GoogleCalendarGetEventOptions struct {
+	ID string
 }
 
 type GoogleCalendarDeleteEventOptions struct {
@@ -141,15 +166,23 @@ type Google struct {
 	client  *http.Client
 	options GoogleOptions
 	logger  common.Logger
+	tokens  map[string]*googleToken
+	mutex   *sync.Mutex
+}
+
+type googleToken struct {
+	response *GoogleTokenReponse
+	expires  time.Time
 }
 
 const (
-	googleOAuthURL            = "https://oauth2.googleapis.com"
-	googleCalendarURL         = "https://www.googleapis.com/calendar/v3"
-	googleCalendarEvents      = "/calendars/%s/events"
-	googleCalendarDeleteEvent = "/calendars/%s/events/%s"
-	googleMeetURL             = "https://meet.google.com/%s"
-	googleMeetLabel           = "meet.google.com/%s"
+	googleOAuthURL       = "https://oauth2.googleapis.com"
+	googleCalendarURL    = "https://www.googleapis.com/calendar/v3"
+	googleCalendarEvents = "/calendars/%s/events"
+	googleCalendarEvent  = "/calendars/%s/events/%s"
+	googleCalendarList   = "/users/me/calendarList"
+	googleMeetURL        = "https://meet.google.com/%s"
+	googleMeetLabel      = "meet.google.com/%s"
 )
 
 // go to https://developers.google.com/oauthplayground
@@ -158,9 +191,19 @@ const (
 // select API => https://www.googleapis.com/auth/calendar,https://www.googleapis.com/auth/calendar.events
 // clieck Autorize Api, and Allow for your user
 // use refresh token
+// access token is reused until a minute before it expires
 
 func (g *Google) refreshToken(opts GoogleOptions) (*GoogleTokenReponse, error) {
 
+	key := opts.OAuthClientID + "/" + opts.RefreshToken
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if t, ok := g.tokens[key]; ok && time.Now().Before(t.expires) {
+		return t.response, nil
+	}
+
 	var body bytes.Buffer
 	w := multipart.NewWriter(&body)
 	defer func() {
@@ -196,7 +239,7 @@ func (g *Google) refreshToken(opts GoogleOptions) (*GoogleTokenReponse, error) {
 	}
 	u.Path = path.Join(u.Path, "/token")
 
-	bytes, err := utils.HttpPostRaw(g.client, u.String(), w.FormDataContentType(), "", body.Bytes())
+	bytes, err := common.HttpPostRaw(g.client, u.String(), w.FormDataContentType(), "", body.Bytes())
 	if err != nil {
 		return nil, err
 	}
@@ -206,6 +249,12 @@ func (g *Google) refreshToken(opts GoogleOptions) (*GoogleTokenReponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	if r.ExpiresIn > 0 {
+		g.tokens[key] = &googleToken{
+			response: &r,
+			expires:  time.Now().Add(time.Duration(r.ExpiresIn)*time.Second - time.Minute),
+		}
+	}
 	return &r, nil
 }
 
@@ -252,11 +301,15 @@ func (g *Google) calendarGetEvents(token string, calendarOptions GoogleCalendarO
 	u.Path = path.Join(u.Path, fmt.Sprintf(googleCalendarEvents, calendarOptions.ID))
 	u.RawQuery = params.Encode()
 
-	return utils.HttpGetRawWithHeaders(g.client, u.String(), nil)
+	return common.HttpGetRawWithHeaders(g.client, u.String(), nil)
 }
 
 func (g *Google) CustomCalendarGetEvents(googleOptions GoogleOptions, calendarOptions GoogleCalendarOptions, calendarGetEventsOptions GoogleCalendarGetEventsOptions) ([]byte, error) {
 
+	if err := ValidateGoogleEvents(calendarGetEventsOptions); err != nil {
+		return nil, err
+	}
+
 	r, err := g.refreshToken(googleOptions)
 	if err != nil {
 		return nil, err
@@ -274,6 +327,18 @@ func (g *Google) CalendarGetEvents(calendarOptions GoogleCalendarOptions, calend
 
 func (g *Google) CustomCalendarInsertEvent(googleOptions GoogleOptions, calendarOptions GoogleCalendarOptions, calendarInsertEventOptions GoogleCalendarInsertEventOptions) ([]byte, error) {
 
+	if err := ValidateGoogleEvent(calendarInsertEventOptions); err != nil {
+		return nil, err
+	}
+
+	calendarInsertEventOptions, fixes, err := FixGoogleEvent(calendarInsertEventOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, fix := range fixes {
+		g.logger.Debug("Google event %s", fix)
+	}
+
 	r, err := g.refreshToken(googleOptions)
 	if err != nil {
 		return nil, err
@@ -288,6 +353,13 @@ func (g *Google) CustomCalendarInsertEvent(googleOptions GoogleOptions, calendar
 	params.Add("supportsAttachments", strconv.FormatBool(calendarInsertEventOptions.SupportsAttachments))
 	params.Add("conferenceDataVersion", "1")
 
+	attendees := []*GoogleCalendarEventAttendee{}
+	for _, email := range calendarInsertEventOptions.Attendees {
+		if !utils.IsEmpty(strings.TrimSpace(email)) {
+			attendees = append(attendees, &GoogleCalendarEventAttendee{Email: strings.TrimSpace(email)})
+		}
+	}
+
 	var source *GoogleCalendarEventSource
 	if !utils.IsEmpty(calendarInsertEventOptions.SourceTitle) || !utils.IsEmpty(calendarInsertEventOptions.SourceURL) {
 		source = &GoogleCalendarEventSource{
@@ -326,6 +398,7 @@ func (g *Google) CustomCalendarInsertEvent(googleOptions GoogleOptions, calendar
 	}
 
 	event := &GoogleCalendarEvent{
+		ID:          calendarInsertEventOptions.ID,
 		Summary:     calendarInsertEventOptions.Summary,
 		Description: calendarInsertEventOptions.Description,
 		Start: GoogleCalendarEventDataTime{
@@ -339,7 +412,7 @@ func (g *Google) CustomCalendarInsertEvent(googleOptions GoogleOptions, calendar
 		EventType:               "default",
 		Transparency:            "transparent",
 		Visibility:              calendarInsertEventOptions.Visibility,
-		Attendees:               []*GoogleCalendarEventAttendee{},
+		Attendees:               attendees,
 		GuestsCanInviteOthers:   true,
 		GuestsCanModify:         false,
 		GuestsCanSeeOtherGuests: true,
@@ -360,13 +433,100 @@ func (g *Google) CustomCalendarInsertEvent(googleOptions GoogleOptions, calendar
 	u.Path = path.Join(u.Path, fmt.Sprintf(googleCalendarEvents, calendarOptions.ID))
 	u.RawQuery = params.Encode()
 
-	return utils.HttpPostRawWithHeaders(g.client, u.String(), nil, data)
+	return common.HttpPostRawWithHeaders(g.client, u.String(), nil, data)
 }
 
 func (g *Google) CalendarInsertEvent(calendarOptions GoogleCalendarOptions, calendarInsertEventOptions GoogleCalendarInsertEventOptions) ([]byte, error) {
 	return g.CustomCalendarInsertEvent(g.options, calendarOptions, calendarInsertEventOptions)
 }
 
+// https://developers.google.com/calendar/api/v3/reference/events/get
+// result is nil if there is no such event
+
+func (g *Google) CustomCalendarGetEvent(googleOptions GoogleOptions, calendarOptions GoogleCalendarOptions, calendarGetEventOptions GoogleCalendarGetEventOptions) ([]byte, error) {
+
+	if utils.IsEmpty(calendarGetEventOptions.ID) {
+		return nil, errors.New("no event ID")
+	}
+	r, err := g.refreshToken(googleOptions)
+	if err != nil {
+		return nil, err
+	}
+	g.logger.Debug("Access token => %s", r.AccessToken)
+
+	params := make(url.Values)
+	params.Add("access_token", r.AccessToken)
+
+	u, err := url.Parse(googleCalendarURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, fmt.Sprintf(googleCalendarEvent, calendarOptions.ID, calendarGetEventOptions.ID))
+	u.RawQuery = params.Encode()
+
+	b, code, err := common.HttpRequestRawWithHeadersOutCode(g.client, "GET", u.String(), nil, nil)
+	if code == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (g *Google) CalendarGetEvent(calendarOptions GoogleCalendarOptions, calendarGetEventOptions GoogleCalendarGetEventOptions) ([]byte, error) {
+	return g.CustomCalendarGetEvent(g.options, calendarOptions, calendarGetEventOptions)
+}
+
+// https://developers.google.com/calendar/api/v3/reference/calendarList/list
+// calendars of user are of all pages
+
+func (g *Google) CustomCalendarList(googleOptions GoogleOptions) ([]byte, error) {
+
+	r, err := g.refreshToken(googleOptions)
+	if err != nil {
+		return nil, err
+	}
+	g.logger.Debug("Access token => %s", r.AccessToken)
+
+	u, err := url.Parse(googleCalendarURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, googleCalendarList)
+
+	// token is sent by Authorization header instead of query, so that it isn't in URLs of requests
+	headers := map[string]string{"Authorization": "Bearer " + r.AccessToken}
+
+	list := &GoogleCalendarList{Items: []*GoogleCalendarListEntry{}}
+	pageToken := ""
+	for {
+		params := make(url.Values)
+		if !utils.IsEmpty(pageToken) {
+			params.Add("pageToken", pageToken)
+		}
+		u.RawQuery = params.Encode()
+
+		b, err := common.HttpGetRawWithHeaders(g.client, u.String(), headers)
+		if err != nil {
+			return nil, err
+		}
+		var page GoogleCalendarList
+		if err := json.Unmarshal(b, &page); err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, page.Items...)
+		pageToken = page.NextPageToken
+		if utils.IsEmpty(pageToken) {
+			return json.Marshal(list)
+		}
+	}
+}
+
+func (g *Google) CalendarList() ([]byte, error) {
+	return g.CustomCalendarList(g.options)
+}
+
 // https://developers.google.com/calendar/api/v3/reference/events/delete
 
 func (g *Google) calendarDeleteEvent(token string, calendarOptions GoogleCalendarOptions, calendarDeleteEventOptions GoogleCalendarDeleteEventOptions) ([]byte, error) {
@@ -381,10 +541,10 @@ func (g *Google) calendarDeleteEvent(token string, calendarOptions GoogleCalenda
 		return nil, err
 	}
 
-	u.Path = path.Join(u.Path, fmt.Sprintf(googleCalendarDeleteEvent, calendarOptions.ID, calendarDeleteEventOptions.ID))
+	u.Path = path.Join(u.Path, fmt.Sprintf(googleCalendarEvent, calendarOptions.ID, calendarDeleteEventOptions.ID))
 	u.RawQuery = params.Encode()
 
-	return utils.HttpDeleteRawWithHeaders(g.client, u.String(), nil, nil)
+	return common.HttpDeleteRawWithHeaders(g.client, u.String(), nil, nil)
 }
 
 func (g *Google) CustomCalendarDeleteEvent(googleOptions GoogleOptions, calendarOptions GoogleCalendarOptions, calendarDeleteEventOptions GoogleCalendarDeleteEventOptions) ([]byte, error) {
@@ -404,6 +564,10 @@ func (g *Google) CalendarDeleteEvent(calendarOptions GoogleCalendarOptions, cale
 
 func (g *Google) CustomCalendarDeleteEvents(googleOptions GoogleOptions, calendarOptions GoogleCalendarOptions, calendarGetEventsOptions GoogleCalendarGetEventsOptions) ([]byte, error) {
 
+	if err := ValidateGoogleEvents(calendarGetEventsOptions); err != nil {
+		return nil, err
+	}
+
 	r, err := g.refreshToken(googleOptions)
 	if err != nil {
 		return nil, err
@@ -437,12 +601,20 @@ func (g *Google) CalendarDeleteEvents(calendarOptions GoogleCalendarOptions, cal
 	return g.CustomCalendarDeleteEvents(g.options, calendarOptions, calendarGetEventsOptions)
 }
 
+func (g *Google) WithContext(ctx context.Context) *Google {
+	c := *g
+	c.client = common.HttpClientContext(g.client, ctx)
+	return &c
+}
+
 func NewGoogle(options GoogleOptions, logger common.Logger) *Google {
 
 	google := &Google{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 		logger:  logger,
+		tokens:  make(map[string]*googleToken),
+		mutex:   &sync.Mutex{},
 	}
 	return google
 }
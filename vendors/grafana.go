@@ -1,7 +1,9 @@
 package vendors
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -11,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/devopsext/tools/common"
 	"github.com/devopsext/utils"
 )
 
@@ -127,11 +130,127 @@ type GrafanaAnnotation struct {
 	Text    string   `json:"text"`
 }
 
+// GrafanaWebhook is payload of unified alerting webhook contact point of Grafana 9+,
+// alerts have Alertmanager fields plus dashboard, panel, silence and image URLs
+// https://grafana.com/docs/grafana/latest/alerting/configure-notifications/manage-contact-points/integrations/webhook-notifier/
+type GrafanaWebhook struct {
+	Receiver          string                 `json:"receiver"`
+	Status            string                 `json:"status"`
+	OrgID             int64                  `json:"orgId"`
+	Alerts            []*GrafanaWebhookAlert `json:"alerts"`
+	GroupLabels       map[string]string      `json:"groupLabels"`
+	CommonLabels      map[string]string      `json:"commonLabels"`
+	CommonAnnotations map[string]string      `json:"commonAnnotations"`
+	ExternalURL       string                 `json:"externalURL"`
+	Version           string                 `json:"version"`
+	GroupKey          string                 `json:"groupKey"`
+	TruncatedAlerts   int                    `json:"truncatedAlerts"`
+	Title             string                 `json:"title"`
+	State             string                 `json:"state"`
+	Message           string                 `json:"message"`
+}
+
+type GrafanaWebhookAlert struct {
+	AlertmanagerWebhookAlert
+	SilenceURL   string             `json:"silenceURL"`
+	DashboardURL string             `json:"dashboardURL"`
+	PanelURL     string             `json:"panelURL"`
+	ImageURL     string             `json:"imageURL"`
+	Values       map[string]float64 `json:"values"`
+	ValueString  string             `json:"valueString"`
+}
+
 type Grafana struct {
 	client  *http.Client
 	options GrafanaOptions
 }
 
+func (w *GrafanaWebhook) filter(status string) []*GrafanaWebhookAlert {
+
+	r := []*GrafanaWebhookAlert{}
+	for _, a := range w.Alerts {
+		if a.Status == status {
+			r = append(r, a)
+		}
+	}
+	return r
+}
+
+func (w *GrafanaWebhook) Firing() []*GrafanaWebhookAlert {
+	return w.filter("firing")
+}
+
+func (w *GrafanaWebhook) Resolved() []*GrafanaWebhookAlert {
+	return w.filter("resolved")
+}
+
+// UniqueLabels returns labels of alert which aren't common to the group, e.g. instance
+func (w *GrafanaWebhook) UniqueLabels(a *GrafanaWebhookAlert) map[string]string {
+
+	r := make(map[string]string)
+	for k, v := range a.Labels {
+		if _, ok := w.CommonLabels[k]; !ok {
+			r[k] = v
+		}
+	}
+	return r
+}
+
+// url returns first non empty URL of firing alerts, or of any alert if group is resolved
+func (w *GrafanaWebhook) url(fn func(a *GrafanaWebhookAlert) string) string {
+
+	for _, alerts := range [][]*GrafanaWebhookAlert{w.Firing(), w.Alerts} {
+		for _, a := range alerts {
+			if u := fn(a); !utils.IsEmpty(u) {
+				return u
+			}
+		}
+	}
+	return ""
+}
+
+// ImageURL is screenshot of panel, it's set if image rendering is configured in Grafana
+func (w *GrafanaWebhook) ImageURL() string {
+	return w.url(func(a *GrafanaWebhookAlert) string { return a.ImageURL })
+}
+
+func (w *GrafanaWebhook) PanelURL() string {
+	return w.url(func(a *GrafanaWebhookAlert) string { return a.PanelURL })
+}
+
+func (w *GrafanaWebhook) DashboardURL() string {
+	return w.url(func(a *GrafanaWebhookAlert) string { return a.DashboardURL })
+}
+
+func (w *GrafanaWebhook) SilenceURL() string {
+	return w.url(func(a *GrafanaWebhookAlert) string { return a.SilenceURL })
+}
+
+// ParseGrafanaWebhook parses and checks unified alerting payload, legacy alerting payload has no alerts
+func ParseGrafanaWebhook(data []byte) (*GrafanaWebhook, error) {
+
+	var w GrafanaWebhook
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	if w.Alerts == nil {
+		return nil, errors.New("no grafana webhook alerts, legacy alerting isn't supported")
+	}
+	if w.Status != "firing" && w.Status != "resolved" {
+		return nil, fmt.Errorf("unsupported grafana webhook status %q", w.Status)
+	}
+	if w.GroupLabels == nil {
+		w.GroupLabels = make(map[string]string)
+	}
+	if w.CommonLabels == nil {
+		w.CommonLabels = make(map[string]string)
+	}
+	if w.CommonAnnotations == nil {
+		w.CommonAnnotations = make(map[string]string)
+	}
+	return &w, nil
+}
+
 func (g *Grafana) getAuth(options GrafanaOptions) string {
 	auth := ""
 	if !utils.IsEmpty(options.APIKey) {
@@ -170,7 +289,7 @@ func (g *Grafana) CustomRenderImage(grafanaOptions GrafanaOptions, renderImageOp
 	params.Add("tz", grafanaOptions.DashboardTimezone)
 
 	u.RawQuery = params.Encode()
-	return utils.HttpGetRaw(g.client, u.String(), "", g.getAuth(grafanaOptions))
+	return common.HttpGetRaw(g.client, u.String(), "", g.getAuth(grafanaOptions))
 }
 
 func (g *Grafana) RenderImage(options GrafanaRenderImageOptions) ([]byte, error) {
@@ -184,7 +303,7 @@ func (g *Grafana) CustomGetDashboards(grafanaOptions GrafanaOptions) ([]byte, er
 	}
 
 	u.Path = path.Join(u.Path, fmt.Sprintf("/api/dashboards/uid/%s", grafanaOptions.DashboardUID))
-	return utils.HttpGetRaw(g.client, u.String(), "", g.getAuth(grafanaOptions))
+	return common.HttpGetRaw(g.client, u.String(), "", g.getAuth(grafanaOptions))
 }
 
 func (g *Grafana) GetDashboards() ([]byte, error) {
@@ -235,7 +354,7 @@ func (g Grafana) CustomCopyDashboard(grafanaOptions GrafanaOptions, grafanaCreat
 		return nil, err
 	}
 
-	return utils.HttpPostRaw(g.client, u.String(), "application/json", g.getAuth(grafanaOptions), b)
+	return common.HttpPostRaw(g.client, u.String(), "application/json", g.getAuth(grafanaOptions), b)
 
 }
 
@@ -255,7 +374,7 @@ func (g Grafana) CustomCreateAnnotation(grafanaOptions GrafanaOptions, createAnn
 	if err != nil {
 		return nil, err
 	}
-	return utils.HttpPostRaw(g.client, u.String(), "application/json", g.getAuth(grafanaOptions), b)
+	return common.HttpPostRaw(g.client, u.String(), "application/json", g.getAuth(grafanaOptions), b)
 }
 
 func (g *Grafana) createAnnotation(o *GrafanaCreateAnnotationOptions) *GrafanaAnnotation {
@@ -338,7 +457,7 @@ func (g *Grafana) CustomGetAnnotations(grafanaOptions GrafanaOptions, getAnnotat
 	params.Add("tz", grafanaOptions.DashboardTimezone)
 
 	u.RawQuery = params.Encode()
-	return utils.HttpGetRaw(g.client, u.String(), "", g.getAuth(grafanaOptions))
+	return common.HttpGetRaw(g.client, u.String(), "", g.getAuth(grafanaOptions))
 }
 
 func (g *Grafana) GetAnnotations(options GrafanaGetAnnotationsOptions) ([]byte, error) {
@@ -648,17 +767,23 @@ func (g Grafana) CustomCreateDashboard(grafanaOptions GrafanaOptions, createDash
 	if err != nil {
 		return nil, err
 	}
-	return utils.HttpPostRaw(g.client, u.String(), "application/json", g.getAuth(grafanaOptions), b)
+	return common.HttpPostRaw(g.client, u.String(), "application/json", g.getAuth(grafanaOptions), b)
 }
 
 func (g *Grafana) CreateDashboard(options GrafanaCreateDahboardOptions) ([]byte, error) {
 	return g.CustomCreateDashboard(g.options, options)
 }
 
+func (g *Grafana) WithContext(ctx context.Context) *Grafana {
+	c := *g
+	c.client = common.HttpClientContext(g.client, ctx)
+	return &c
+}
+
 func NewGrafana(options GrafanaOptions) *Grafana {
 
 	grafana := &Grafana{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 	}
 	return grafana
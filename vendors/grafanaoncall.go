@@ -0,0 +1,241 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const (
+	grafanaOnCallEscalation  = "/escalation"
+	grafanaOnCallAlertGroups = "/alert_groups"
+	grafanaOnCallSchedules   = "/schedules"
+	grafanaOnCallUsers       = "/users"
+)
+
+type GrafanaOnCallOptions struct {
+	Timeout    int
+	Insecure   bool
+	URL        string
+	Token      string
+	GrafanaURL string
+}
+
+type GrafanaOnCallEscalationOptions struct {
+	Title      string
+	Message    string
+	SourceURL  string
+	Important  bool
+	Team       string
+	Users      []string
+	AlertGroup string
+}
+
+type GrafanaOnCallAlertGroupOptions struct {
+	ID string
+}
+
+type GrafanaOnCallScheduleOptions struct {
+	Schedule string
+}
+
+type GrafanaOnCallEscalationUser struct {
+	ID        string `json:"id"`
+	Important bool   `json:"important"`
+}
+
+type GrafanaOnCallEscalation struct {
+	Title        string                         `json:"title,omitempty"`
+	Message      string                         `json:"message,omitempty"`
+	SourceURL    string                         `json:"source_url,omitempty"`
+	Important    bool                           `json:"important"`
+	Team         string                         `json:"team,omitempty"`
+	Users        []*GrafanaOnCallEscalationUser `json:"users,omitempty"`
+	AlertGroupID string                         `json:"alert_group_id,omitempty"`
+}
+
+type GrafanaOnCallSchedule struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	OnCallNow []string `json:"on_call_now"`
+}
+
+type GrafanaOnCallOnCall struct {
+	Schedule string            `json:"schedule"`
+	Name     string            `json:"name"`
+	Users    []json.RawMessage `json:"users"`
+}
+
+type GrafanaOnCall struct {
+	client  *http.Client
+	options GrafanaOnCallOptions
+}
+
+func (g *GrafanaOnCall) request(opts GrafanaOnCallOptions, method, p string, params url.Values, data []byte) ([]byte, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return nil, errors.New("no URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "/api/v1", p) + "/"
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+
+	headers := map[string]string{
+		"Authorization": opts.Token,
+		"Content-Type":  "application/json",
+	}
+	// Grafana IRM requires stack URL along with service account token
+	if !utils.IsEmpty(opts.GrafanaURL) {
+		headers["X-Grafana-Url"] = opts.GrafanaURL
+	}
+	b, err := common.HttpRequestRawWithHeaders(g.client, method, u.String(), headers, data)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// https://grafana.com/docs/oncall/latest/oncall-api-reference/escalation/
+// users and team are paged directly, alert group is created, or users are added to existing one
+
+func (g *GrafanaOnCall) CustomEscalate(grafanaOnCallOptions GrafanaOnCallOptions, escalationOptions GrafanaOnCallEscalationOptions) ([]byte, error) {
+
+	users := common.RemoveEmptyStrings(escalationOptions.Users)
+	if utils.IsEmpty(escalationOptions.Team) && len(users) == 0 {
+		return nil, errors.New("no team or users")
+	}
+	e := &GrafanaOnCallEscalation{
+		Title:        escalationOptions.Title,
+		Message:      escalationOptions.Message,
+		SourceURL:    escalationOptions.SourceURL,
+		Important:    escalationOptions.Important,
+		Team:         escalationOptions.Team,
+		AlertGroupID: escalationOptions.AlertGroup,
+	}
+	for _, u := range users {
+		e.Users = append(e.Users, &GrafanaOnCallEscalationUser{ID: u, Important: escalationOptions.Important})
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return g.request(grafanaOnCallOptions, "POST", grafanaOnCallEscalation, nil, data)
+}
+
+func (g *GrafanaOnCall) Escalate(escalationOptions GrafanaOnCallEscalationOptions) ([]byte, error) {
+	return g.CustomEscalate(g.options, escalationOptions)
+}
+
+// https://grafana.com/docs/oncall/latest/oncall-api-reference/alertgroups/
+func (g *GrafanaOnCall) alertGroupAction(grafanaOnCallOptions GrafanaOnCallOptions, alertGroupOptions GrafanaOnCallAlertGroupOptions, action string) ([]byte, error) {
+
+	if utils.IsEmpty(alertGroupOptions.ID) {
+		return nil, errors.New("no alert group")
+	}
+	return g.request(grafanaOnCallOptions, "POST", path.Join(grafanaOnCallAlertGroups, alertGroupOptions.ID, action), nil, nil)
+}
+
+func (g *GrafanaOnCall) CustomAcknowledgeAlertGroup(grafanaOnCallOptions GrafanaOnCallOptions, alertGroupOptions GrafanaOnCallAlertGroupOptions) ([]byte, error) {
+	return g.alertGroupAction(grafanaOnCallOptions, alertGroupOptions, "acknowledge")
+}
+
+func (g *GrafanaOnCall) AcknowledgeAlertGroup(alertGroupOptions GrafanaOnCallAlertGroupOptions) ([]byte, error) {
+	return g.CustomAcknowledgeAlertGroup(g.options, alertGroupOptions)
+}
+
+func (g *GrafanaOnCall) CustomResolveAlertGroup(grafanaOnCallOptions GrafanaOnCallOptions, alertGroupOptions GrafanaOnCallAlertGroupOptions) ([]byte, error) {
+	return g.alertGroupAction(grafanaOnCallOptions, alertGroupOptions, "resolve")
+}
+
+func (g *GrafanaOnCall) ResolveAlertGroup(alertGroupOptions GrafanaOnCallAlertGroupOptions) ([]byte, error) {
+	return g.CustomResolveAlertGroup(g.options, alertGroupOptions)
+}
+
+// schedule is found by name, or by ID if there is no such name
+func (g *GrafanaOnCall) findSchedule(opts GrafanaOnCallOptions, schedule string) (*GrafanaOnCallSchedule, error) {
+
+	params := url.Values{}
+	params.Set("name", schedule)
+	b, err := g.request(opts, "GET", grafanaOnCallSchedules, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Results []*GrafanaOnCallSchedule `json:"results"`
+	}
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, err
+	}
+	if len(list.Results) > 0 {
+		return list.Results[0], nil
+	}
+
+	b, err = g.request(opts, "GET", path.Join(grafanaOnCallSchedules, schedule), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("no schedule %s: %s", schedule, err)
+	}
+	var s GrafanaOnCallSchedule
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// https://grafana.com/docs/oncall/latest/oncall-api-reference/schedules/
+// https://grafana.com/docs/oncall/latest/oncall-api-reference/users/
+// users who are on-call now, with their usernames and emails
+
+func (g *GrafanaOnCall) CustomGetOnCall(grafanaOnCallOptions GrafanaOnCallOptions, scheduleOptions GrafanaOnCallScheduleOptions) ([]byte, error) {
+
+	if utils.IsEmpty(scheduleOptions.Schedule) {
+		return nil, errors.New("no schedule")
+	}
+	s, err := g.findSchedule(grafanaOnCallOptions, scheduleOptions.Schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &GrafanaOnCallOnCall{
+		Schedule: s.ID,
+		Name:     s.Name,
+		Users:    []json.RawMessage{},
+	}
+	for _, id := range s.OnCallNow {
+		b, err := g.request(grafanaOnCallOptions, "GET", path.Join(grafanaOnCallUsers, id), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		r.Users = append(r.Users, b)
+	}
+	return json.Marshal(r)
+}
+
+func (g *GrafanaOnCall) GetOnCall(scheduleOptions GrafanaOnCallScheduleOptions) ([]byte, error) {
+	return g.CustomGetOnCall(g.options, scheduleOptions)
+}
+
+func (g *GrafanaOnCall) WithContext(ctx context.Context) *GrafanaOnCall {
+	c := *g
+	c.client = common.HttpClientContext(g.client, ctx)
+	return &c
+}
+
+func NewGrafanaOnCall(options GrafanaOnCallOptions) *GrafanaOnCall {
+
+	return &GrafanaOnCall{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
@@ -1,14 +1,25 @@
 package vendors
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
 	"strconv"
+	"strings"
+	"time"
 
 	"encoding/base64"
 
+	"github.com/devopsext/tools/common"
 	"github.com/devopsext/utils"
 )
 
@@ -28,6 +39,54 @@ type GraylogOptions struct {
 	Range     string
 }
 
+type GraylogSavedSearchOptions struct {
+	ID string
+}
+
+type GraylogGELFOptions struct {
+	URL          string
+	Host         string
+	ShortMessage string
+	FullMessage  string
+	Level        int
+	Fields       []string
+}
+
+// GraylogMessages are messages of search, they're the same as messages of universal search
+type GraylogMessages struct {
+	Messages     []interface{} `json:"messages"`
+	TotalResults int           `json:"total_results"`
+}
+
+type graylogView struct {
+	ID       string `json:"id"`
+	SearchID string `json:"search_id"`
+	Title    string `json:"title"`
+}
+
+type graylogSearchJob struct {
+	ID        string `json:"id"`
+	Execution struct {
+		Done bool `json:"done"`
+	} `json:"execution"`
+	Results map[string]struct {
+		SearchTypes map[string]struct {
+			Type         string        `json:"type"`
+			Messages     []interface{} `json:"messages"`
+			TotalResults int           `json:"total_results"`
+		} `json:"search_types"`
+		Errors []interface{} `json:"errors"`
+	} `json:"results"`
+	Errors []interface{} `json:"errors"`
+}
+
+const (
+	// graylogGELFChunkSize is max size of UDP chunk, which fits any network
+	graylogGELFChunkSize = 8154
+	// graylogGELFMaxChunks is max number of chunks of UDP message
+	graylogGELFMaxChunks = 128
+)
+
 type Graylog struct {
 	client  *http.Client
 	options GraylogOptions
@@ -41,7 +100,7 @@ func (g *Graylog) get(URL string) ([]byte, error) {
 		auth = fmt.Sprintf("Basic %s", basic)
 	}
 
-	return utils.HttpGetRaw(g.client, URL, "application/json", auth)
+	return common.HttpGetRaw(g.client, URL, "application/json", auth)
 }
 
 // https://graylog.some.host/api/search/universal/relative?query=*&range=3600&limit=100&sort=timestamp:desc&pretty=true
@@ -120,10 +179,298 @@ func (g *Graylog) GetLogs() ([]byte, error) {
 	}
 }
 
+func (g *Graylog) headers(opts GraylogOptions) map[string]string {
+
+	headers := make(map[string]string)
+	headers["Content-Type"] = "application/json"
+	headers["Accept"] = "application/json"
+	// Graylog rejects requests changing state without it
+	headers["X-Requested-By"] = "tools"
+	if !utils.IsEmpty(opts.User) {
+		basic := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", opts.User, opts.Password)))
+		headers["Authorization"] = fmt.Sprintf("Basic %s", basic)
+	}
+	return headers
+}
+
+func (g *Graylog) request(opts GraylogOptions, method, uri string, data []byte) ([]byte, error) {
+
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, uri)
+
+	b, err := common.HttpRequestRawWithHeaders(g.client, method, u.String(), g.headers(opts), data)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// timerange overrides time range of saved search, if range or from and to are set
+func (g *Graylog) timerange(opts GraylogOptions) (map[string]interface{}, error) {
+
+	switch opts.RangeType {
+	case "relative":
+		if utils.IsEmpty(opts.Range) {
+			return nil, nil
+		}
+		r, err := strconv.Atoi(opts.Range)
+		if err != nil {
+			return nil, fmt.Errorf("range %s is not seconds: %s", opts.Range, err)
+		}
+		return map[string]interface{}{"type": "relative", "range": r}, nil
+	case "absolute":
+		if utils.IsEmpty(opts.From) && utils.IsEmpty(opts.To) {
+			return nil, nil
+		}
+		if utils.IsEmpty(opts.From) || utils.IsEmpty(opts.To) {
+			return nil, errors.New("absolute range needs from and to")
+		}
+		return map[string]interface{}{"type": "absolute", "from": opts.From, "to": opts.To}, nil
+	default:
+		return nil, fmt.Errorf("range type %s is not relative or absolute", opts.RangeType)
+	}
+}
+
+// https://go2docs.graylog.org/current/setting_up_graylog/rest_api.html
+// saved search is view, its search is executed with time range and limit overridden by options,
+// messages of all queries are returned, job is polled until it's done if execution is asynchronous
+
+func (g *Graylog) CustomSearchSaved(graylogOptions GraylogOptions, savedOptions GraylogSavedSearchOptions) ([]byte, error) {
+
+	if utils.IsEmpty(savedOptions.ID) {
+		return nil, errors.New("no saved search ID")
+	}
+	b, err := g.request(graylogOptions, "GET", fmt.Sprintf("/api/views/%s", url.PathEscape(savedOptions.ID)), nil)
+	if err != nil {
+		return nil, err
+	}
+	var view graylogView
+	if err := json.Unmarshal(b, &view); err != nil {
+		return nil, err
+	}
+	if utils.IsEmpty(view.SearchID) {
+		return nil, fmt.Errorf("saved search %s has no search", savedOptions.ID)
+	}
+
+	override := make(map[string]interface{})
+	timerange, err := g.timerange(graylogOptions)
+	if err != nil {
+		return nil, err
+	}
+	if timerange != nil {
+		override["timerange"] = timerange
+	}
+	if graylogOptions.Limit > 0 {
+		override["limit"] = graylogOptions.Limit
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"global_override":    override,
+		"parameter_bindings": map[string]interface{}{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	b, err = g.request(graylogOptions, "POST", fmt.Sprintf("/api/views/search/%s/execute", url.PathEscape(view.SearchID)), data)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(time.Duration(graylogOptions.Timeout) * time.Second)
+	for {
+		var job graylogSearchJob
+		if err := json.Unmarshal(b, &job); err != nil {
+			return nil, err
+		}
+		if job.Execution.Done || len(job.Results) > 0 {
+			return g.searchMessages(&job)
+		}
+		if utils.IsEmpty(job.ID) || time.Now().After(deadline) {
+			return nil, fmt.Errorf("saved search %s is not done", savedOptions.ID)
+		}
+		time.Sleep(time.Second)
+		b, err = g.request(graylogOptions, "GET", fmt.Sprintf("/api/views/search/status/%s", url.PathEscape(job.ID)), nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (g *Graylog) SearchSaved(savedOptions GraylogSavedSearchOptions) ([]byte, error) {
+	return g.CustomSearchSaved(g.options, savedOptions)
+}
+
+func (g *Graylog) searchMessages(job *graylogSearchJob) ([]byte, error) {
+
+	errs := []string{}
+	for _, e := range job.Errors {
+		errs = append(errs, fmt.Sprintf("%v", e))
+	}
+	r := &GraylogMessages{Messages: []interface{}{}}
+	for _, q := range job.Results {
+		for _, e := range q.Errors {
+			errs = append(errs, fmt.Sprintf("%v", e))
+		}
+		for _, t := range q.SearchTypes {
+			if t.Type != "messages" {
+				continue
+			}
+			r.Messages = append(r.Messages, t.Messages...)
+			r.TotalResults += t.TotalResults
+		}
+	}
+	if len(errs) > 0 && len(r.Messages) == 0 {
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+	return json.Marshal(r)
+}
+
+// gelfMessage has additional fields prefixed by underscore, _id is reserved by Graylog
+func (g *Graylog) gelfMessage(gelfOptions GraylogGELFOptions) ([]byte, error) {
+
+	if utils.IsEmpty(gelfOptions.ShortMessage) {
+		return nil, errors.New("no short message")
+	}
+	host := gelfOptions.Host
+	if utils.IsEmpty(host) {
+		host, _ = os.Hostname()
+	}
+	m := map[string]interface{}{
+		"version":       "1.1",
+		"host":          host,
+		"short_message": gelfOptions.ShortMessage,
+		"timestamp":     float64(time.Now().UnixMilli()) / 1000,
+		"level":         gelfOptions.Level,
+	}
+	if !utils.IsEmpty(gelfOptions.FullMessage) {
+		m["full_message"] = gelfOptions.FullMessage
+	}
+	for _, f := range common.RemoveEmptyStrings(gelfOptions.Fields) {
+		kv := strings.SplitN(f, "=", 2)
+		k := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || utils.IsEmpty(k) {
+			return nil, fmt.Errorf("field %s is not key=value", f)
+		}
+		if !strings.HasPrefix(k, "_") {
+			k = "_" + k
+		}
+		if k == "_id" {
+			return nil, errors.New("field id is reserved")
+		}
+		m[k] = kv[1]
+	}
+	return json.Marshal(m)
+}
+
+// gelfChunks splits compressed message into chunks having magic bytes, message ID, sequence number and count
+func (g *Graylog) gelfChunks(data []byte) ([][]byte, error) {
+
+	if len(data) <= graylogGELFChunkSize {
+		return [][]byte{data}, nil
+	}
+	count := (len(data) + graylogGELFChunkSize - 1) / graylogGELFChunkSize
+	if count > graylogGELFMaxChunks {
+		return nil, fmt.Errorf("message needs %d chunks, more than %d", count, graylogGELFMaxChunks)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	chunks := [][]byte{}
+	for i := 0; i < count; i++ {
+		end := (i + 1) * graylogGELFChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := []byte{0x1e, 0x0f}
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunks = append(chunks, append(chunk, data[i*graylogGELFChunkSize:end]...))
+	}
+	return chunks, nil
+}
+
+// https://go2docs.graylog.org/current/getting_in_log_data/gelf.html
+// GELF message is sent to input by URL, e.g. http://graylog:12201/gelf, udp://graylog:12201 or tcp://graylog:12201,
+// UDP message is compressed and chunked, TCP message is terminated by null byte
+
+func (g *Graylog) CustomSendGELF(graylogOptions GraylogOptions, gelfOptions GraylogGELFOptions) ([]byte, error) {
+
+	data, err := g.gelfMessage(gelfOptions)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(gelfOptions.URL)
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(graylogOptions.Timeout) * time.Second
+
+	switch u.Scheme {
+	case "http", "https":
+		_, err := common.HttpPostRawWithHeaders(g.client, u.String(), map[string]string{"Content-Type": "application/json"}, data)
+		if err != nil {
+			return nil, err
+		}
+	case "udp":
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		chunks, err := g.gelfChunks(buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		conn, err := net.DialTimeout("udp", u.Host, timeout)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		for _, c := range chunks {
+			if _, err := conn.Write(c); err != nil {
+				return nil, err
+			}
+		}
+	case "tcp":
+		conn, err := net.DialTimeout("tcp", u.Host, timeout)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+			return nil, err
+		}
+		if _, err := conn.Write(append(data, 0)); err != nil {
+			return nil, err
+		}
+	case "":
+		return nil, errors.New("no GELF URL")
+	default:
+		return nil, fmt.Errorf("GELF URL scheme %s is not http, https, udp or tcp", u.Scheme)
+	}
+	return data, nil
+}
+
+func (g *Graylog) SendGELF(gelfOptions GraylogGELFOptions) ([]byte, error) {
+	return g.CustomSendGELF(g.options, gelfOptions)
+}
+
+func (g *Graylog) WithContext(ctx context.Context) *Graylog {
+	c := *g
+	c.client = common.HttpClientContext(g.client, ctx)
+	return &c
+}
+
 func NewGraylog(options GraylogOptions) *Graylog {
 
 	graylog := &Graylog{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 	}
 	return graylog
@@ -0,0 +1,322 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+type HarborOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	User     string
+	Password string
+}
+
+type HarborReplicationOptions struct {
+	PolicyID int
+}
+
+type HarborRetentionOptions struct {
+	Project     string
+	RetentionID int
+	DryRun      bool
+}
+
+type HarborReplicationExecution struct {
+	PolicyID int `json:"policy_id"`
+}
+
+type HarborRetentionExecution struct {
+	DryRun bool `json:"dry_run"`
+}
+
+type HarborProject struct {
+	ProjectID int    `json:"project_id"`
+	Name      string `json:"name"`
+	Metadata  struct {
+		RetentionID string `json:"retention_id"`
+	} `json:"metadata"`
+}
+
+type HarborWebhookVulnerabilities struct {
+	Total    int            `json:"total"`
+	Fixable  int            `json:"fixable"`
+	Severity map[string]int `json:"severity"`
+}
+
+type HarborWebhookResource struct {
+	Digest          string                        `json:"digest"`
+	Tag             string                        `json:"tag"`
+	ResourceURL     string                        `json:"resource_url"`
+	ScanStatus      string                        `json:"scanStatus,omitempty"`
+	Severity        string                        `json:"severity,omitempty"`
+	Scanner         string                        `json:"scanner,omitempty"`
+	Vulnerabilities *HarborWebhookVulnerabilities `json:"vulnerabilities,omitempty"`
+}
+
+// HarborWebhook is webhook payload of Harbor, scan overview of SCANNING_COMPLETED resources is normalized
+// https://goharbor.io/docs/main/working-with-projects/project-configuration/configure-webhooks/
+type HarborWebhook struct {
+	Type       string                   `json:"type"`
+	OccurAt    int64                    `json:"occur_at"`
+	Operator   string                   `json:"operator"`
+	Project    string                   `json:"project"`
+	Repository string                   `json:"repository"`
+	Resources  []*HarborWebhookResource `json:"resources"`
+}
+
+type harborWebhookPayload struct {
+	Type      string `json:"type"`
+	OccurAt   int64  `json:"occur_at"`
+	Operator  string `json:"operator"`
+	EventData struct {
+		Resources []struct {
+			Digest       string `json:"digest"`
+			Tag          string `json:"tag"`
+			ResourceURL  string `json:"resource_url"`
+			ScanOverview map[string]struct {
+				ScanStatus string `json:"scan_status"`
+				Severity   string `json:"severity"`
+				Summary    struct {
+					Total   int            `json:"total"`
+					Fixable int            `json:"fixable"`
+					Summary map[string]int `json:"summary"`
+				} `json:"summary"`
+				Scanner struct {
+					Name string `json:"name"`
+				} `json:"scanner"`
+			} `json:"scan_overview"`
+		} `json:"resources"`
+		Repository struct {
+			Name         string `json:"name"`
+			Namespace    string `json:"namespace"`
+			RepoFullName string `json:"repo_full_name"`
+		} `json:"repository"`
+	} `json:"event_data"`
+}
+
+type Harbor struct {
+	client  *http.Client
+	options HarborOptions
+}
+
+// Critical returns count of critical vulnerabilities of all resources
+func (w *HarborWebhook) Critical() int {
+
+	r := 0
+	for _, res := range w.Resources {
+		if res.Vulnerabilities != nil {
+			r += res.Vulnerabilities.Severity["Critical"]
+		}
+	}
+	return r
+}
+
+// Time returns time of event, now if there is no such
+func (w *HarborWebhook) Time() time.Time {
+
+	if w.OccurAt == 0 {
+		return time.Now()
+	}
+	return time.Unix(w.OccurAt, 0)
+}
+
+// ParseHarborWebhook parses payload of any event type, scan overview is kept for scanning events
+func ParseHarborWebhook(data []byte) (*HarborWebhook, error) {
+
+	var p harborWebhookPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if utils.IsEmpty(p.Type) {
+		return nil, errors.New("no harbor event type")
+	}
+
+	w := &HarborWebhook{
+		Type:       p.Type,
+		OccurAt:    p.OccurAt,
+		Operator:   p.Operator,
+		Project:    p.EventData.Repository.Namespace,
+		Repository: p.EventData.Repository.RepoFullName,
+		Resources:  []*HarborWebhookResource{},
+	}
+	for _, r := range p.EventData.Resources {
+		res := &HarborWebhookResource{
+			Digest:      r.Digest,
+			Tag:         r.Tag,
+			ResourceURL: r.ResourceURL,
+		}
+		// overview is keyed by report mime type, there is one per scanner
+		for _, o := range r.ScanOverview {
+			res.ScanStatus = o.ScanStatus
+			res.Severity = o.Severity
+			res.Scanner = o.Scanner.Name
+			res.Vulnerabilities = &HarborWebhookVulnerabilities{
+				Total:    o.Summary.Total,
+				Fixable:  o.Summary.Fixable,
+				Severity: o.Summary.Summary,
+			}
+			break
+		}
+		w.Resources = append(w.Resources, res)
+	}
+	return w, nil
+}
+
+func (h *Harbor) headers(opts HarborOptions) map[string]string {
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		"Accept":       "application/json",
+	}
+	if !utils.IsEmpty(opts.User) {
+		headers["Authorization"] = common.FormatBasicAuth(opts.User, opts.Password)
+	}
+	return headers
+}
+
+func (h *Harbor) request(opts HarborOptions, method, p string, params url.Values, data []byte) ([]byte, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return nil, errors.New("no URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "/api/v2.0", p)
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+
+	b, err := common.HttpRequestRawWithHeaders(h.client, method, u.String(), h.headers(opts), data)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// https://editor.swagger.io/?url=https://raw.githubusercontent.com/goharbor/harbor/main/api/v2.0/swagger.yaml
+// replication is started asynchronously, result is the latest execution of policy
+
+func (h *Harbor) CustomStartReplication(harborOptions HarborOptions, replicationOptions HarborReplicationOptions) ([]byte, error) {
+
+	if replicationOptions.PolicyID <= 0 {
+		return nil, errors.New("no replication policy")
+	}
+	data, err := json.Marshal(&HarborReplicationExecution{PolicyID: replicationOptions.PolicyID})
+	if err != nil {
+		return nil, err
+	}
+	_, err = h.request(harborOptions, "POST", "/replication/executions", nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make(url.Values)
+	params.Set("policy_id", strconv.Itoa(replicationOptions.PolicyID))
+	params.Set("sort", "-start_time")
+	params.Set("page_size", "1")
+	b, err := h.request(harborOptions, "GET", "/replication/executions", params, nil)
+	if err != nil {
+		return nil, err
+	}
+	var executions []json.RawMessage
+	if err := json.Unmarshal(b, &executions); err != nil {
+		return nil, err
+	}
+	if len(executions) == 0 {
+		return nil, fmt.Errorf("no executions of replication policy %d", replicationOptions.PolicyID)
+	}
+	return executions[0], nil
+}
+
+func (h *Harbor) StartReplication(replicationOptions HarborReplicationOptions) ([]byte, error) {
+	return h.CustomStartReplication(h.options, replicationOptions)
+}
+
+func (h *Harbor) retentionID(harborOptions HarborOptions, retentionOptions HarborRetentionOptions) (int, error) {
+
+	if retentionOptions.RetentionID > 0 {
+		return retentionOptions.RetentionID, nil
+	}
+	if utils.IsEmpty(retentionOptions.Project) {
+		return 0, errors.New("no retention policy or project")
+	}
+	b, err := h.request(harborOptions, "GET", "/projects/"+url.PathEscape(retentionOptions.Project), nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	var project HarborProject
+	if err := json.Unmarshal(b, &project); err != nil {
+		return 0, err
+	}
+	if utils.IsEmpty(project.Metadata.RetentionID) {
+		return 0, fmt.Errorf("project %s has no retention policy", retentionOptions.Project)
+	}
+	return strconv.Atoi(project.Metadata.RetentionID)
+}
+
+// https://editor.swagger.io/?url=https://raw.githubusercontent.com/goharbor/harbor/main/api/v2.0/swagger.yaml
+// retention policy is taken from project if its ID isn't set, result is the latest execution of policy
+
+func (h *Harbor) CustomStartRetention(harborOptions HarborOptions, retentionOptions HarborRetentionOptions) ([]byte, error) {
+
+	id, err := h.retentionID(harborOptions, retentionOptions)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(&HarborRetentionExecution{DryRun: retentionOptions.DryRun})
+	if err != nil {
+		return nil, err
+	}
+	p := fmt.Sprintf("/retentions/%d/executions", id)
+	_, err = h.request(harborOptions, "POST", p, nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make(url.Values)
+	params.Set("page_size", "1")
+	b, err := h.request(harborOptions, "GET", p, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	var executions []json.RawMessage
+	if err := json.Unmarshal(b, &executions); err != nil {
+		return nil, err
+	}
+	if len(executions) == 0 {
+		return nil, fmt.Errorf("no executions of retention policy %d", id)
+	}
+	return executions[0], nil
+}
+
+func (h *Harbor) StartRetention(retentionOptions HarborRetentionOptions) ([]byte, error) {
+	return h.CustomStartRetention(h.options, retentionOptions)
+}
+
+func (h *Harbor) WithContext(ctx context.Context) *Harbor {
+	c := *h
+	c.client = common.HttpClientContext(h.client, ctx)
+	return &c
+}
+
+func NewHarbor(options HarborOptions) *Harbor {
+
+	return &Harbor{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
@@ -0,0 +1,389 @@
+package vendors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+type JenkinsOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	User     string
+	Token    string
+}
+
+type JenkinsJobOptions struct {
+	Job string
+}
+
+type JenkinsBuildOptions struct {
+	Params       []string
+	Wait         bool
+	WaitTimeout  int
+	PollInterval int
+}
+
+type JenkinsBuildStatusOptions struct {
+	Number int
+}
+
+type JenkinsCrumb struct {
+	Crumb             string `json:"crumb"`
+	CrumbRequestField string `json:"crumbRequestField"`
+}
+
+type JenkinsQueueItem struct {
+	ID         int    `json:"id"`
+	Why        string `json:"why,omitempty"`
+	Cancelled  bool   `json:"cancelled,omitempty"`
+	Executable *struct {
+		Number int    `json:"number"`
+		URL    string `json:"url"`
+	} `json:"executable,omitempty"`
+}
+
+type JenkinsBuild struct {
+	Number    int    `json:"number"`
+	URL       string `json:"url"`
+	Result    string `json:"result"`
+	Building  bool   `json:"building"`
+	Duration  int64  `json:"duration"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+type Jenkins struct {
+	client  *http.Client
+	options JenkinsOptions
+	logger  common.Logger
+	ctx     context.Context
+}
+
+// jobPath converts folder/job to job/folder/job/job
+func (j *Jenkins) jobPath(job string) (string, error) {
+
+	job = strings.Trim(job, "/")
+	if utils.IsEmpty(job) {
+		return "", errors.New("no job")
+	}
+	parts := []string{}
+	for _, p := range strings.Split(job, "/") {
+		parts = append(parts, "job", url.PathEscape(p))
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+func (j *Jenkins) buildPath(job string, number int) (string, error) {
+
+	p, err := j.jobPath(job)
+	if err != nil {
+		return "", err
+	}
+	if number > 0 {
+		return path.Join(p, strconv.Itoa(number)), nil
+	}
+	return path.Join(p, "lastBuild"), nil
+}
+
+func (j *Jenkins) request(opts JenkinsOptions, method, p string, params url.Values, headers map[string]string, data []byte) ([]byte, http.Header, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return nil, nil, errors.New("no URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+	// job names are escaped already
+	u.RawPath = strings.TrimRight(u.EscapedPath(), "/") + "/" + strings.TrimLeft(p, "/")
+	u.Path, err = url.PathUnescape(u.RawPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !utils.IsEmpty(opts.User) {
+		req.Header.Set("Authorization", common.FormatBasicAuth(opts.User, opts.Token))
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return b, resp.Header, fmt.Errorf("Jenkins %s: %w", p, common.NewAPIError(resp.StatusCode, b))
+	}
+	return b, resp.Header, nil
+}
+
+// crumb headers are needed if CSRF protection is enabled, crumb is bound to session of cookie,
+// there is no crumb issuer if protection is disabled
+func (j *Jenkins) crumbHeaders(opts JenkinsOptions) (map[string]string, error) {
+
+	headers := make(map[string]string)
+	b, h, err := j.request(opts, "GET", "/crumbIssuer/api/json", nil, nil, nil)
+	if err != nil {
+		var apiErr *common.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return headers, nil
+		}
+		return nil, err
+	}
+	var crumb JenkinsCrumb
+	if err := json.Unmarshal(b, &crumb); err != nil {
+		return nil, err
+	}
+	if !utils.IsEmpty(crumb.CrumbRequestField) {
+		headers[crumb.CrumbRequestField] = crumb.Crumb
+	}
+	cookies := []string{}
+	for _, c := range h.Values("Set-Cookie") {
+		cookie, _, _ := strings.Cut(c, ";")
+		cookies = append(cookies, cookie)
+	}
+	if len(cookies) > 0 {
+		headers["Cookie"] = strings.Join(cookies, "; ")
+	}
+	return headers, nil
+}
+
+func (j *Jenkins) queueItem(opts JenkinsOptions, location string) (*JenkinsQueueItem, error) {
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	p := strings.TrimPrefix(u.Path, base.Path)
+
+	b, _, err := j.request(opts, "GET", path.Join(p, "api/json"), nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var item JenkinsQueueItem
+	if err := json.Unmarshal(b, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// poll stops waiting once context of Jenkins, or of process if there is none, is done, e.g. on interrupt or at deadline
+func (j *Jenkins) poll(interval, timeout int, what string, fn func() (bool, error)) error {
+
+	d := time.Duration(interval) * time.Second
+	if d <= 0 {
+		d = 5 * time.Second
+	}
+	ctx := j.ctx
+	if ctx == nil {
+		ctx = common.HttpContext()
+	}
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		done, err := fn()
+		if err != nil || done {
+			return err
+		}
+		if timeout > 0 && time.Now().After(deadline) {
+			return fmt.Errorf("Jenkins %s after %d seconds", what, timeout)
+		}
+		timer.Reset(d)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Jenkins %s: %w", what, context.Cause(ctx))
+		case <-timer.C:
+		}
+	}
+}
+
+// https://www.jenkins.io/doc/book/using/remote-access-api/
+// build is queued, result is queue item, or the finished build if wait is set
+
+func (j *Jenkins) CustomTriggerBuild(jenkinsOptions JenkinsOptions, jobOptions JenkinsJobOptions, buildOptions JenkinsBuildOptions) ([]byte, error) {
+
+	p, err := j.jobPath(jobOptions.Job)
+	if err != nil {
+		return nil, err
+	}
+	params := make(url.Values)
+	for _, kv := range buildOptions.Params {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || utils.IsEmpty(k) {
+			return nil, fmt.Errorf("invalid parameter %s, key=value is expected", kv)
+		}
+		params.Add(k, v)
+	}
+	p = path.Join(p, "build")
+	if len(params) > 0 {
+		p = p + "WithParameters"
+	}
+
+	headers, err := j.crumbHeaders(jenkinsOptions)
+	if err != nil {
+		return nil, err
+	}
+	headers["Content-Type"] = "application/x-www-form-urlencoded"
+
+	_, h, err := j.request(jenkinsOptions, "POST", p, nil, headers, []byte(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	location := h.Get("Location")
+	if utils.IsEmpty(location) {
+		return nil, errors.New("no queue item location")
+	}
+
+	item, err := j.queueItem(jenkinsOptions, location)
+	if err != nil {
+		return nil, err
+	}
+	if !buildOptions.Wait {
+		return json.Marshal(item)
+	}
+
+	err = j.poll(buildOptions.PollInterval, buildOptions.WaitTimeout, fmt.Sprintf("queue item %d isn't started", item.ID), func() (bool, error) {
+		if item.Executable != nil {
+			return true, nil
+		}
+		if item.Cancelled {
+			return false, fmt.Errorf("Jenkins queue item %d is cancelled", item.ID)
+		}
+		if j.logger != nil {
+			j.logger.Debug("Jenkins queue item %d is waiting: %s", item.ID, item.Why)
+		}
+		item, err = j.queueItem(jenkinsOptions, location)
+		return false, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return j.CustomWaitForBuild(jenkinsOptions, jobOptions, JenkinsBuildStatusOptions{Number: item.Executable.Number}, buildOptions)
+}
+
+func (j *Jenkins) TriggerBuild(jobOptions JenkinsJobOptions, buildOptions JenkinsBuildOptions) ([]byte, error) {
+	return j.CustomTriggerBuild(j.options, jobOptions, buildOptions)
+}
+
+// https://www.jenkins.io/doc/book/using/remote-access-api/
+// the last build is used if number isn't set
+
+func (j *Jenkins) CustomGetBuildStatus(jenkinsOptions JenkinsOptions, jobOptions JenkinsJobOptions, statusOptions JenkinsBuildStatusOptions) ([]byte, error) {
+
+	p, err := j.buildPath(jobOptions.Job, statusOptions.Number)
+	if err != nil {
+		return nil, err
+	}
+	params := make(url.Values)
+	params.Set("tree", "number,url,result,building,duration,timestamp")
+
+	b, _, err := j.request(jenkinsOptions, "GET", path.Join(p, "api/json"), params, nil, nil)
+	return b, err
+}
+
+func (j *Jenkins) GetBuildStatus(jobOptions JenkinsJobOptions, statusOptions JenkinsBuildStatusOptions) ([]byte, error) {
+	return j.CustomGetBuildStatus(j.options, jobOptions, statusOptions)
+}
+
+// CustomWaitForBuild polls build until it's finished, error is returned if result isn't SUCCESS
+func (j *Jenkins) CustomWaitForBuild(jenkinsOptions JenkinsOptions, jobOptions JenkinsJobOptions, statusOptions JenkinsBuildStatusOptions, buildOptions JenkinsBuildOptions) ([]byte, error) {
+
+	var data []byte
+	var build JenkinsBuild
+	err := j.poll(buildOptions.PollInterval, buildOptions.WaitTimeout, fmt.Sprintf("build of %s is still running", jobOptions.Job), func() (bool, error) {
+		b, err := j.CustomGetBuildStatus(jenkinsOptions, jobOptions, statusOptions)
+		if err != nil {
+			return false, err
+		}
+		if err := json.Unmarshal(b, &build); err != nil {
+			return false, err
+		}
+		data = b
+		// the last build number is kept, so that newer builds aren't waited
+		statusOptions.Number = build.Number
+		if j.logger != nil {
+			j.logger.Debug("Jenkins build %s #%d is building: %t", jobOptions.Job, build.Number, build.Building)
+		}
+		return !build.Building, nil
+	})
+	if err != nil {
+		return data, err
+	}
+	if build.Result != "SUCCESS" {
+		return data, fmt.Errorf("Jenkins build %s #%d is %s", jobOptions.Job, build.Number, build.Result)
+	}
+	return data, nil
+}
+
+func (j *Jenkins) WaitForBuild(jobOptions JenkinsJobOptions, statusOptions JenkinsBuildStatusOptions, buildOptions JenkinsBuildOptions) ([]byte, error) {
+	return j.CustomWaitForBuild(j.options, jobOptions, statusOptions, buildOptions)
+}
+
+// https://www.jenkins.io/doc/book/using/remote-access-api/
+// console is plain text of the build, the last build is used if number isn't set
+
+func (j *Jenkins) CustomGetConsoleLog(jenkinsOptions JenkinsOptions, jobOptions JenkinsJobOptions, statusOptions JenkinsBuildStatusOptions) ([]byte, error) {
+
+	p, err := j.buildPath(jobOptions.Job, statusOptions.Number)
+	if err != nil {
+		return nil, err
+	}
+	b, _, err := j.request(jenkinsOptions, "GET", path.Join(p, "consoleText"), nil, nil, nil)
+	return b, err
+}
+
+func (j *Jenkins) GetConsoleLog(jobOptions JenkinsJobOptions, statusOptions JenkinsBuildStatusOptions) ([]byte, error) {
+	return j.CustomGetConsoleLog(j.options, jobOptions, statusOptions)
+}
+
+func (j *Jenkins) WithContext(ctx context.Context) *Jenkins {
+	c := *j
+	c.client = common.HttpClientContext(j.client, ctx)
+	c.ctx = ctx
+	return &c
+}
+
+func NewJenkins(options JenkinsOptions, logger common.Logger) *Jenkins {
+
+	return &Jenkins{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+		logger:  logger,
+	}
+}
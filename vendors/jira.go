@@ -2,6 +2,7 @@ package vendors
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -272,7 +273,7 @@ func (j *Jira) CustomCreateIssue(jiraOptions JiraOptions, createOptions JiraIssu
 		return nil, err
 	}
 	u.Path = path.Join(u.Path, "/rest/api/2/issue")
-	return utils.HttpPostRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions), req)
+	return common.HttpPostRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions), req)
 }
 
 func (j *Jira) CreateIssue(issueCreateOptions JiraIssueOptions) ([]byte, error) {
@@ -295,7 +296,7 @@ func (j *Jira) CustomAddIssueComment(jiraOptions JiraOptions, issueOptions JiraI
 		return nil, err
 	}
 	u.Path = path.Join(u.Path, fmt.Sprintf("/rest/api/2/issue/%s/comment", issueOptions.IdOrKey))
-	return utils.HttpPostRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions), req)
+	return common.HttpPostRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions), req)
 }
 
 func (j *Jira) IssueAddComment(issueOptions JiraIssueOptions, addCommentOptions JiraAddIssueCommentOptions) ([]byte, error) {
@@ -333,7 +334,7 @@ func (j *Jira) CustomAddIssueAttachment(jiraOptions JiraOptions, issueOptions Ji
 	headers["Content-type"] = w.FormDataContentType()
 	headers["Authorization"] = j.getAuth(jiraOptions)
 	headers["X-Atlassian-Token"] = "no-check"
-	return utils.HttpPostRawWithHeaders(j.client, u.String(), headers, body.Bytes())
+	return common.HttpPostRawWithHeaders(j.client, u.String(), headers, body.Bytes())
 }
 
 func (j *Jira) AddIssueAttachment(issueOptions JiraIssueOptions, addAttachmentOptions JiraAddIssueAttachmentOptions) ([]byte, error) {
@@ -378,7 +379,7 @@ func (j *Jira) CustomUpdateIssue(jiraOptions JiraOptions, issueOptions JiraIssue
 		return nil, err
 	}
 	u.Path = path.Join(u.Path, fmt.Sprintf("/rest/api/2/issue/%s", issueOptions.IdOrKey))
-	return utils.HttpPutRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions), req)
+	return common.HttpPutRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions), req)
 }
 
 func (j *Jira) UpdateIssue(options JiraIssueOptions) ([]byte, error) {
@@ -395,7 +396,7 @@ func (j *Jira) GetIssueTransitions(jiraOptions JiraOptions, issueOptions JiraIss
 	q.Set("expand", "transitions.fields")
 	u.RawQuery = q.Encode()
 
-	t, err := utils.HttpGetRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions))
+	t, err := common.HttpGetRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions))
 	if err != nil {
 		return nil, err
 	}
@@ -420,7 +421,7 @@ func (j *Jira) CustomChangeIssueTransitions(jiraOptions JiraOptions, issueOption
 	}
 	u.Path = path.Join(u.Path, fmt.Sprintf("/rest/api/2/issue/%s/transitions", issueOptions.IdOrKey))
 
-	_, c, err := utils.HttpPostRawOutCode(j.client, u.String(), "application/json", j.getAuth(jiraOptions), req)
+	_, c, err := common.HttpPostRawOutCode(j.client, u.String(), "application/json", j.getAuth(jiraOptions), req)
 	if err != nil {
 		return nil, err
 	}
@@ -455,7 +456,7 @@ func (j *Jira) CustomSearchIssue(jiraOptions JiraOptions, search JiraSearchIssue
 	u.Path = path.Join(u.Path, "/rest/api/2/search")
 	u.RawQuery = params.Encode()
 
-	return utils.HttpGetRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions))
+	return common.HttpGetRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions))
 }
 
 func (j *Jira) SearchIssue(options JiraSearchIssueOptions) ([]byte, error) {
@@ -475,7 +476,7 @@ func (j *Jira) CustomSearchAssets(jiraOptions JiraOptions, search JiraSearchAsse
 
 	u.Path = path.Join(u.Path, "/rest/insight/1.0/aql/objects")
 	u.RawQuery = params.Encode()
-	a, err := utils.HttpGetRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions))
+	a, err := common.HttpGetRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions))
 	if err != nil {
 		return nil, err
 	}
@@ -492,7 +493,7 @@ func (j *Jira) CustomSearchAssets(jiraOptions JiraOptions, search JiraSearchAsse
 		for i := 2; i <= int(pageSize); i++ {
 			params.Set("page", strconv.Itoa(i))
 			u.RawQuery = params.Encode()
-			a, err := utils.HttpGetRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions))
+			a, err := common.HttpGetRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions))
 			if err != nil {
 				return nil, err
 			}
@@ -578,7 +579,7 @@ func (j *Jira) CustomCreateAsset(jiraOptions JiraOptions, createOptions JiraCrea
 	params.Add("objectSchemaId", createOptions.ObjectSchemeId)
 	u.Path = path.Join(u.Path, "rest/assets/1.0/object/create")
 	u.RawQuery = params.Encode()
-	return utils.HttpPostRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions), req)
+	return common.HttpPostRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions), req)
 }
 
 func (j *Jira) CreateAsset(createOptions JiraCreateAssetOptions) ([]byte, error) {
@@ -596,17 +597,23 @@ func (j *Jira) CustomUpdateAsset(jiraOptions JiraOptions, updateOptions JiraUpda
 	u.Path = path.Join(u.Path, fmt.Sprintf("rest/assets/1.0/object/%s", updateOptions.ObjectId))
 	u.RawQuery = params.Encode()
 
-	return utils.HttpPutRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions), []byte(updateOptions.Json))
+	return common.HttpPutRaw(j.client, u.String(), "application/json", j.getAuth(jiraOptions), []byte(updateOptions.Json))
 }
 
 func (j *Jira) UpdateAsset(updateOptions JiraUpdateAssetOptions) ([]byte, error) {
 	return j.CustomUpdateAsset(j.options, updateOptions)
 }
 
+func (j *Jira) WithContext(ctx context.Context) *Jira {
+	c := *j
+	c.client = common.HttpClientContext(j.client, ctx)
+	return &c
+}
+
 func NewJira(options JiraOptions) *Jira {
 
 	jira := &Jira{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 	}
 	return jira
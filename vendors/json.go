@@ -1,9 +1,10 @@
 package vendors
 
 import (
+	"context"
 	"net/http"
 
-	"github.com/devopsext/utils"
+	"github.com/devopsext/tools/common"
 )
 
 type JSONOptions struct {
@@ -22,12 +23,18 @@ type JSON struct {
 }
 
 func (c *JSON) Get() ([]byte, error) {
-	return utils.HttpGetRaw(c.client, c.options.URL, "", "")
+	return common.HttpGetRaw(c.client, c.options.URL, "", "")
+}
+
+func (c *JSON) WithContext(ctx context.Context) *JSON {
+	r := *c
+	r.client = common.HttpClientContext(c.client, ctx)
+	return &r
 }
 
 func NewJSON(options JSONOptions) *JSON {
 	return &JSON{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 	}
 }
@@ -0,0 +1,449 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+type KeycloakOptions struct {
+	Timeout      int
+	Insecure     bool
+	URL          string
+	Realm        string
+	AuthRealm    string
+	ClientID     string
+	ClientSecret string
+	User         string
+	Password     string
+	Token        string
+}
+
+type KeycloakUserOptions struct {
+	Username      string
+	Email         string
+	FirstName     string
+	LastName      string
+	Enabled       bool
+	EmailVerified bool
+	Password      string
+	Temporary     bool
+	Attributes    string
+	Groups        []string
+}
+
+type KeycloakRoleOptions struct {
+	User   string
+	Client string
+	Roles  []string
+}
+
+type KeycloakClientOptions struct {
+	Client string
+}
+
+type KeycloakExportOptions struct {
+	Clients        bool
+	GroupsAndRoles bool
+}
+
+type KeycloakCredential struct {
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	Temporary bool   `json:"temporary"`
+}
+
+type KeycloakUser struct {
+	ID            string                `json:"id,omitempty"`
+	Username      string                `json:"username"`
+	Email         string                `json:"email,omitempty"`
+	FirstName     string                `json:"firstName,omitempty"`
+	LastName      string                `json:"lastName,omitempty"`
+	Enabled       bool                  `json:"enabled"`
+	EmailVerified bool                  `json:"emailVerified"`
+	Attributes    map[string][]string   `json:"attributes,omitempty"`
+	Groups        []string              `json:"groups,omitempty"`
+	Credentials   []*KeycloakCredential `json:"credentials,omitempty"`
+}
+
+type KeycloakClient struct {
+	ID       string `json:"id"`
+	ClientID string `json:"clientId"`
+}
+
+type keycloakTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+type keycloakError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	ErrorMessage     string `json:"errorMessage"`
+}
+
+type keycloakToken struct {
+	token   string
+	expires time.Time
+}
+
+type Keycloak struct {
+	client  *http.Client
+	options KeycloakOptions
+	tokens  map[string]*keycloakToken
+	mutex   *sync.Mutex
+}
+
+// apiURL joins escaped segments, so that names of realms, roles and clients can have any chars
+func (k *Keycloak) apiURL(opts KeycloakOptions, params url.Values, segments ...string) (string, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return "", errors.New("no URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return "", err
+	}
+	escaped := []string{}
+	for _, s := range segments {
+		escaped = append(escaped, url.PathEscape(s))
+	}
+	u = u.JoinPath(escaped...)
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+	return u.String(), nil
+}
+
+func (k *Keycloak) do(method, u string, headers map[string]string, data []byte) ([]byte, error) {
+
+	b, err := common.HttpRequestRawWithHeaders(k.client, method, u, headers, data)
+	if err != nil {
+		var e keycloakError
+		if json.Unmarshal(b, &e) == nil {
+			for _, m := range []string{e.ErrorMessage, e.ErrorDescription, e.Error} {
+				if !utils.IsEmpty(m) {
+					return nil, common.APIErrorMessage(err, m)
+				}
+			}
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// login returns token of client credentials, if there is no user, or password grant, tokens are kept until they expire
+func (k *Keycloak) login(opts KeycloakOptions) (string, error) {
+
+	if !utils.IsEmpty(opts.Token) {
+		return opts.Token, nil
+	}
+
+	realm := opts.AuthRealm
+	if utils.IsEmpty(realm) {
+		realm = "master"
+	}
+	clientID := opts.ClientID
+	if utils.IsEmpty(clientID) {
+		clientID = "admin-cli"
+	}
+
+	form := url.Values{"client_id": {clientID}}
+	if !utils.IsEmpty(opts.ClientSecret) {
+		form.Set("client_secret", opts.ClientSecret)
+	}
+	if utils.IsEmpty(opts.User) {
+		if utils.IsEmpty(opts.ClientSecret) {
+			return "", errors.New("no token, user or client secret")
+		}
+		form.Set("grant_type", "client_credentials")
+	} else {
+		form.Set("grant_type", "password")
+		form.Set("username", opts.User)
+		form.Set("password", opts.Password)
+	}
+	key := strings.Join([]string{opts.URL, realm, clientID, opts.User}, "/")
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if t, ok := k.tokens[key]; ok && time.Now().Before(t.expires) {
+		return t.token, nil
+	}
+
+	u, err := k.apiURL(opts, nil, "realms", realm, "protocol", "openid-connect", "token")
+	if err != nil {
+		return "", err
+	}
+	headers := map[string]string{
+		"Content-Type": "application/x-www-form-urlencoded",
+	}
+	b, err := k.do("POST", u, headers, []byte(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	var r keycloakTokenResponse
+	if err := json.Unmarshal(b, &r); err != nil {
+		return "", err
+	}
+	if utils.IsEmpty(r.AccessToken) {
+		return "", fmt.Errorf("no token of %s login", form.Get("grant_type"))
+	}
+	// token is renewed a bit earlier than it expires
+	ttl := time.Duration(r.ExpiresIn) * time.Second * 9 / 10
+	k.tokens[key] = &keycloakToken{token: r.AccessToken, expires: time.Now().Add(ttl)}
+	return r.AccessToken, nil
+}
+
+// admin requests admin API of realm, segments follow /admin/realms/{realm}
+func (k *Keycloak) admin(opts KeycloakOptions, method string, params url.Values, data []byte, segments ...string) ([]byte, error) {
+
+	if utils.IsEmpty(opts.Realm) {
+		return nil, errors.New("no realm")
+	}
+	token, err := k.login(opts)
+	if err != nil {
+		return nil, err
+	}
+	u, err := k.apiURL(opts, params, append([]string{"admin", "realms", opts.Realm}, segments...)...)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": fmt.Sprintf("Bearer %s", token),
+	}
+	return k.do(method, u, headers, data)
+}
+
+// findUser finds user by username, or by ID if there is no such username
+func (k *Keycloak) findUser(opts KeycloakOptions, user string) (*KeycloakUser, error) {
+
+	if utils.IsEmpty(user) {
+		return nil, errors.New("no user")
+	}
+	b, err := k.admin(opts, "GET", url.Values{"username": {user}, "exact": {"true"}}, nil, "users")
+	if err != nil {
+		return nil, err
+	}
+	var users []*KeycloakUser
+	if err := json.Unmarshal(b, &users); err != nil {
+		return nil, err
+	}
+	if len(users) > 0 {
+		return users[0], nil
+	}
+
+	b, err = k.admin(opts, "GET", nil, nil, "users", user)
+	if err != nil {
+		return nil, fmt.Errorf("no user %s: %s", user, err)
+	}
+	var u KeycloakUser
+	if err := json.Unmarshal(b, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// findClient finds client by client ID, or by internal ID if there is no such client ID
+func (k *Keycloak) findClient(opts KeycloakOptions, client string) (*KeycloakClient, error) {
+
+	if utils.IsEmpty(client) {
+		return nil, errors.New("no client")
+	}
+	b, err := k.admin(opts, "GET", url.Values{"clientId": {client}}, nil, "clients")
+	if err != nil {
+		return nil, err
+	}
+	var clients []*KeycloakClient
+	if err := json.Unmarshal(b, &clients); err != nil {
+		return nil, err
+	}
+	if len(clients) > 0 {
+		return clients[0], nil
+	}
+
+	b, err = k.admin(opts, "GET", nil, nil, "clients", client)
+	if err != nil {
+		return nil, fmt.Errorf("no client %s: %s", client, err)
+	}
+	var c KeycloakClient
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// keycloakAttributes accepts key=value pairs separated by comma
+func keycloakAttributes(s string) map[string][]string {
+
+	if utils.IsEmpty(s) {
+		return nil
+	}
+	r := make(map[string][]string)
+	for k, v := range utils.MapGetKeyValues(s) {
+		r[k] = append(r[k], v)
+	}
+	return r
+}
+
+// https://www.keycloak.org/docs-api/latest/rest-api/index.html#_post_adminrealmsrealmusers
+// user is created with password and groups if they are set, result is created user
+
+func (k *Keycloak) CustomCreateUser(keycloakOptions KeycloakOptions, userOptions KeycloakUserOptions) ([]byte, error) {
+
+	if utils.IsEmpty(userOptions.Username) {
+		return nil, errors.New("no username")
+	}
+	user := &KeycloakUser{
+		Username:      userOptions.Username,
+		Email:         userOptions.Email,
+		FirstName:     userOptions.FirstName,
+		LastName:      userOptions.LastName,
+		Enabled:       userOptions.Enabled,
+		EmailVerified: userOptions.EmailVerified,
+		Attributes:    keycloakAttributes(userOptions.Attributes),
+	}
+	for _, g := range userOptions.Groups {
+		if utils.IsEmpty(g) {
+			continue
+		}
+		if !strings.HasPrefix(g, "/") {
+			g = "/" + g
+		}
+		user.Groups = append(user.Groups, g)
+	}
+	if !utils.IsEmpty(userOptions.Password) {
+		user.Credentials = []*KeycloakCredential{{
+			Type:      "password",
+			Value:     userOptions.Password,
+			Temporary: userOptions.Temporary,
+		}}
+	}
+	data, err := json.Marshal(user)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := k.admin(keycloakOptions, "POST", nil, data, "users"); err != nil {
+		return nil, err
+	}
+	created, err := k.findUser(keycloakOptions, userOptions.Username)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(created)
+}
+
+func (k *Keycloak) CreateUser(userOptions KeycloakUserOptions) ([]byte, error) {
+	return k.CustomCreateUser(k.options, userOptions)
+}
+
+// https://www.keycloak.org/docs-api/latest/rest-api/index.html#_role_mapper
+// realm roles are assigned if there is no client, result is role mappings of user
+
+func (k *Keycloak) CustomAssignRoles(keycloakOptions KeycloakOptions, roleOptions KeycloakRoleOptions) ([]byte, error) {
+
+	names := []string{}
+	for _, r := range roleOptions.Roles {
+		if !utils.IsEmpty(r) {
+			names = append(names, strings.TrimSpace(r))
+		}
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no roles")
+	}
+	user, err := k.findUser(keycloakOptions, roleOptions.User)
+	if err != nil {
+		return nil, err
+	}
+
+	rolesPath := []string{"roles"}
+	mappingPath := []string{"users", user.ID, "role-mappings", "realm"}
+	if !utils.IsEmpty(roleOptions.Client) {
+		client, err := k.findClient(keycloakOptions, roleOptions.Client)
+		if err != nil {
+			return nil, err
+		}
+		rolesPath = []string{"clients", client.ID, "roles"}
+		mappingPath = []string{"users", user.ID, "role-mappings", "clients", client.ID}
+	}
+
+	roles := []json.RawMessage{}
+	for _, name := range names {
+		b, err := k.admin(keycloakOptions, "GET", nil, nil, append(rolesPath, name)...)
+		if err != nil {
+			return nil, fmt.Errorf("role %s: %s", name, err)
+		}
+		roles = append(roles, b)
+	}
+	data, err := json.Marshal(roles)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := k.admin(keycloakOptions, "POST", nil, data, mappingPath...); err != nil {
+		return nil, err
+	}
+	return k.admin(keycloakOptions, "GET", nil, nil, "users", user.ID, "role-mappings")
+}
+
+func (k *Keycloak) AssignRoles(roleOptions KeycloakRoleOptions) ([]byte, error) {
+	return k.CustomAssignRoles(k.options, roleOptions)
+}
+
+// https://www.keycloak.org/docs-api/latest/rest-api/index.html#_post_adminrealmsrealmclientsclient_uuidclient_secret
+// new secret of confidential client is generated, result has it as value
+
+func (k *Keycloak) CustomRotateClientSecret(keycloakOptions KeycloakOptions, clientOptions KeycloakClientOptions) ([]byte, error) {
+
+	client, err := k.findClient(keycloakOptions, clientOptions.Client)
+	if err != nil {
+		return nil, err
+	}
+	return k.admin(keycloakOptions, "POST", nil, nil, "clients", client.ID, "client-secret")
+}
+
+func (k *Keycloak) RotateClientSecret(clientOptions KeycloakClientOptions) ([]byte, error) {
+	return k.CustomRotateClientSecret(k.options, clientOptions)
+}
+
+// https://www.keycloak.org/docs-api/latest/rest-api/index.html#_post_adminrealmsrealmpartial_export
+// realm is exported with clients, groups and roles if they are set, secrets are masked by Keycloak
+
+func (k *Keycloak) CustomExportRealm(keycloakOptions KeycloakOptions, exportOptions KeycloakExportOptions) ([]byte, error) {
+
+	params := url.Values{
+		"exportClients":        {strconv.FormatBool(exportOptions.Clients)},
+		"exportGroupsAndRoles": {strconv.FormatBool(exportOptions.GroupsAndRoles)},
+	}
+	return k.admin(keycloakOptions, "POST", params, nil, "partial-export")
+}
+
+func (k *Keycloak) ExportRealm(exportOptions KeycloakExportOptions) ([]byte, error) {
+	return k.CustomExportRealm(k.options, exportOptions)
+}
+
+func (k *Keycloak) WithContext(ctx context.Context) *Keycloak {
+	c := *k
+	c.client = common.HttpClientContext(k.client, ctx)
+	return &c
+}
+
+func NewKeycloak(options KeycloakOptions) *Keycloak {
+
+	return &Keycloak{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+		tokens:  make(map[string]*keycloakToken),
+		mutex:   &sync.Mutex{},
+	}
+}
@@ -0,0 +1,721 @@
+package vendors
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+	"gopkg.in/yaml.v3"
+)
+
+const kubernetesServiceAccount = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+type KubernetesOptions struct {
+	Timeout    int
+	Insecure   bool
+	URL        string
+	Token      string
+	Kubeconfig string
+	Context    string
+}
+
+type KubernetesResourceOptions struct {
+	Namespace     string
+	Name          string
+	LabelSelector string
+	FieldSelector string
+}
+
+type KubernetesWaitOptions struct {
+	Wait         bool
+	WaitTimeout  int
+	PollInterval int
+}
+
+// kubeconfig is part of kubeconfig file, which describes how to reach cluster of context
+// https://kubernetes.io/docs/concepts/configuration/organize-cluster-access-kubeconfig/
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server   string `yaml:"server"`
+			CA       string `yaml:"certificate-authority"`
+			CAData   string `yaml:"certificate-authority-data"`
+			Insecure bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token     string `yaml:"token"`
+			TokenFile string `yaml:"tokenFile"`
+			Cert      string `yaml:"client-certificate"`
+			CertData  string `yaml:"client-certificate-data"`
+			Key       string `yaml:"client-key"`
+			KeyData   string `yaml:"client-key-data"`
+			Exec      *struct {
+				Command string   `yaml:"command"`
+				Args    []string `yaml:"args"`
+				Env     []struct {
+					Name  string `yaml:"name"`
+					Value string `yaml:"value"`
+				} `yaml:"env"`
+			} `yaml:"exec"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+type kubernetesConfig struct {
+	url       string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+type kubernetesObjectMeta struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
+	Generation        int64             `json:"generation"`
+	CreationTimestamp time.Time         `json:"creationTimestamp"`
+	Labels            map[string]string `json:"labels,omitempty"`
+}
+
+type kubernetesPodList struct {
+	Items []struct {
+		Metadata kubernetesObjectMeta `json:"metadata"`
+		Spec     struct {
+			NodeName   string     `json:"nodeName"`
+			Containers []struct{} `json:"containers"`
+		} `json:"spec"`
+		Status struct {
+			Phase             string `json:"phase"`
+			Reason            string `json:"reason"`
+			ContainerStatuses []struct {
+				Ready        bool `json:"ready"`
+				RestartCount int  `json:"restartCount"`
+				State        struct {
+					Waiting *struct {
+						Reason string `json:"reason"`
+					} `json:"waiting,omitempty"`
+				} `json:"state"`
+			} `json:"containerStatuses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+type kubernetesEventList struct {
+	Items []struct {
+		Metadata       kubernetesObjectMeta `json:"metadata"`
+		InvolvedObject struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"involvedObject"`
+		Reason         string    `json:"reason"`
+		Message        string    `json:"message"`
+		Type           string    `json:"type"`
+		Count          int       `json:"count"`
+		LastTimestamp  time.Time `json:"lastTimestamp"`
+		EventTime      time.Time `json:"eventTime"`
+		FirstTimestamp time.Time `json:"firstTimestamp"`
+	} `json:"items"`
+}
+
+type kubernetesDeployment struct {
+	Metadata kubernetesObjectMeta `json:"metadata"`
+	Spec     struct {
+		Replicas *int32 `json:"replicas"`
+	} `json:"spec"`
+	Status struct {
+		ObservedGeneration int64 `json:"observedGeneration"`
+		Replicas           int32 `json:"replicas"`
+		UpdatedReplicas    int32 `json:"updatedReplicas"`
+		ReadyReplicas      int32 `json:"readyReplicas"`
+		AvailableReplicas  int32 `json:"availableReplicas"`
+		Conditions         []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+type KubernetesPod struct {
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	Status    string    `json:"status"`
+	Ready     string    `json:"ready"`
+	Restarts  int       `json:"restarts"`
+	Node      string    `json:"node,omitempty"`
+	Created   time.Time `json:"created"`
+}
+
+type KubernetesEvent struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Reason  string    `json:"reason"`
+	Object  string    `json:"object"`
+	Message string    `json:"message"`
+	Count   int       `json:"count,omitempty"`
+}
+
+// KubernetesRolloutStatus is status of deployment rollout as kubectl rollout status reports it
+type KubernetesRolloutStatus struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Replicas  int32  `json:"replicas"`
+	Updated   int32  `json:"updated"`
+	Ready     int32  `json:"ready"`
+	Available int32  `json:"available"`
+	Complete  bool   `json:"complete"`
+	Failed    bool   `json:"failed,omitempty"`
+	Message   string `json:"message"`
+}
+
+type Kubernetes struct {
+	options KubernetesOptions
+	configs map[string]*kubernetesConfig
+	mutex   *sync.Mutex
+	logger  common.Logger
+	ctx     context.Context
+}
+
+func kubernetesFileOrData(file, data, dir string) ([]byte, error) {
+
+	if !utils.IsEmpty(data) {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if utils.IsEmpty(file) {
+		return nil, nil
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	return os.ReadFile(file)
+}
+
+// kubernetesExecToken runs credential plugin like aws or gke-gcloud-auth-plugin, token of ExecCredential is used
+func kubernetesExecToken(command string, args []string, env []string) (string, error) {
+
+	cmd := exec.Command(command, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stderr = os.Stderr
+	b, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("Kubernetes credential plugin %s: %s", command, err)
+	}
+	var credential struct {
+		Status struct {
+			Token string `json:"token"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(b, &credential); err != nil {
+		return "", err
+	}
+	if utils.IsEmpty(credential.Status.Token) {
+		return "", fmt.Errorf("Kubernetes credential plugin %s returned no token", command)
+	}
+	return credential.Status.Token, nil
+}
+
+func (k *Kubernetes) kubeconfig(opts KubernetesOptions, cfg *kubernetesConfig, tlsConfig *tls.Config) error {
+
+	file := opts.Kubeconfig
+	if utils.IsEmpty(file) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		file = filepath.Join(home, ".kube", "config")
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var kc kubeconfig
+	if err := yaml.Unmarshal(b, &kc); err != nil {
+		return fmt.Errorf("kubeconfig %s: %s", file, err)
+	}
+	dir := filepath.Dir(file)
+
+	name := opts.Context
+	if utils.IsEmpty(name) {
+		name = kc.CurrentContext
+	}
+	var clusterName, userName string
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name == name {
+			clusterName, userName, cfg.namespace = c.Context.Cluster, c.Context.User, c.Context.Namespace
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("kubeconfig %s has no context %s", file, name)
+	}
+
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		cfg.url = c.Cluster.Server
+		tlsConfig.InsecureSkipVerify = tlsConfig.InsecureSkipVerify || c.Cluster.Insecure
+		ca, err := kubernetesFileOrData(c.Cluster.CA, c.Cluster.CAData, dir)
+		if err != nil {
+			return err
+		}
+		if len(ca) > 0 {
+			pool := x509.NewCertPool()
+			pool.AppendCertsFromPEM(ca)
+			tlsConfig.RootCAs = pool
+		}
+	}
+	if utils.IsEmpty(cfg.url) {
+		return fmt.Errorf("kubeconfig %s has no cluster %s", file, clusterName)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		user := u.User
+		switch {
+		case !utils.IsEmpty(user.Token):
+			cfg.token = user.Token
+		case !utils.IsEmpty(user.TokenFile):
+			token, err := kubernetesFileOrData(user.TokenFile, "", dir)
+			if err != nil {
+				return err
+			}
+			cfg.token = strings.TrimSpace(string(token))
+		case user.Exec != nil:
+			var env []string
+			for _, e := range user.Exec.Env {
+				env = append(env, fmt.Sprintf("%s=%s", e.Name, e.Value))
+			}
+			cfg.token, err = kubernetesExecToken(user.Exec.Command, user.Exec.Args, env)
+			if err != nil {
+				return err
+			}
+		}
+		cert, err := kubernetesFileOrData(user.Cert, user.CertData, dir)
+		if err != nil {
+			return err
+		}
+		key, err := kubernetesFileOrData(user.Key, user.KeyData, dir)
+		if err != nil {
+			return err
+		}
+		if len(cert) > 0 && len(key) > 0 {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return err
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+	}
+	return nil
+}
+
+func (k *Kubernetes) inCluster(cfg *kubernetesConfig, tlsConfig *tls.Config) error {
+
+	host := os.Getenv("KUBERNETES_SERVICE_HOST")
+	port := os.Getenv("KUBERNETES_SERVICE_PORT")
+	cfg.url = fmt.Sprintf("https://%s", net.JoinHostPort(host, port))
+
+	if utils.IsEmpty(cfg.token) {
+		token, err := os.ReadFile(path.Join(kubernetesServiceAccount, "token"))
+		if err != nil {
+			return err
+		}
+		cfg.token = strings.TrimSpace(string(token))
+	}
+	if ns, err := os.ReadFile(path.Join(kubernetesServiceAccount, "namespace")); err == nil {
+		cfg.namespace = strings.TrimSpace(string(ns))
+	}
+	if !tlsConfig.InsecureSkipVerify {
+		ca, err := os.ReadFile(path.Join(kubernetesServiceAccount, "ca.crt"))
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(ca)
+		tlsConfig.RootCAs = pool
+	}
+	return nil
+}
+
+// config is URL with token, or kubeconfig if it's set or there is no in cluster config, configs are kept by options
+func (k *Kubernetes) config(opts KubernetesOptions) (*kubernetesConfig, error) {
+
+	key := fmt.Sprintf("%s/%s/%s/%t/%d", opts.URL, opts.Kubeconfig, opts.Context, opts.Insecure, opts.Timeout)
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if cfg, ok := k.configs[key]; ok {
+		return cfg, nil
+	}
+
+	cfg := &kubernetesConfig{url: opts.URL, token: opts.Token}
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.Insecure}
+	var err error
+	switch {
+	case !utils.IsEmpty(opts.URL):
+	case utils.IsEmpty(opts.Kubeconfig) && !utils.IsEmpty(os.Getenv("KUBERNETES_SERVICE_HOST")):
+		err = k.inCluster(cfg, tlsConfig)
+	default:
+		err = k.kubeconfig(opts, cfg, tlsConfig)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !utils.IsEmpty(opts.Token) {
+		cfg.token = opts.Token
+	}
+	if utils.IsEmpty(cfg.namespace) {
+		cfg.namespace = "default"
+	}
+
+	d := time.Duration(opts.Timeout) * time.Second
+	cfg.client = common.NewHttpClientTransport(opts.Timeout, &http.Transport{
+		Proxy:               common.HttpProxy,
+		DialContext:         (&net.Dialer{Timeout: d, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout: d,
+		TLSClientConfig:     tlsConfig,
+	})
+	k.configs[key] = cfg
+	return cfg, nil
+}
+
+// request calls API of resource in namespace, resources of API groups are group/version/plural, e.g. apps/v1/deployments
+func (k *Kubernetes) request(opts KubernetesOptions, method, namespace, resource, name string, params url.Values, contentType string, data []byte) ([]byte, error) {
+
+	cfg, err := k.config(opts)
+	if err != nil {
+		return nil, err
+	}
+	if utils.IsEmpty(namespace) {
+		namespace = cfg.namespace
+	}
+	u, err := url.Parse(cfg.url)
+	if err != nil {
+		return nil, err
+	}
+	p := "/api/v1"
+	if parts := strings.Split(resource, "/"); len(parts) == 3 {
+		p = path.Join("/apis", parts[0], parts[1])
+		resource = parts[2]
+	}
+	u.Path = path.Join(u.Path, p, "namespaces", namespace, resource, name)
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+
+	headers := map[string]string{
+		"Accept": "application/json",
+	}
+	if !utils.IsEmpty(contentType) {
+		headers["Content-Type"] = contentType
+	}
+	if !utils.IsEmpty(cfg.token) {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", cfg.token)
+	}
+
+	client := cfg.client
+	if k.ctx != nil {
+		client = common.HttpClientContext(client, k.ctx)
+	}
+	b, err := common.HttpRequestRawWithHeaders(client, method, u.String(), headers, data)
+	if err != nil {
+		var status struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(b, &status) == nil && !utils.IsEmpty(status.Message) {
+			return nil, common.APIErrorMessage(err, status.Message)
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+func (k *Kubernetes) selectors(resourceOptions KubernetesResourceOptions) url.Values {
+
+	params := make(url.Values)
+	if !utils.IsEmpty(resourceOptions.LabelSelector) {
+		params.Set("labelSelector", resourceOptions.LabelSelector)
+	}
+	if !utils.IsEmpty(resourceOptions.FieldSelector) {
+		params.Set("fieldSelector", resourceOptions.FieldSelector)
+	}
+	return params
+}
+
+// https://kubernetes.io/docs/reference/kubernetes-api/workload-resources/pod-v1/#list-list-or-watch-objects-of-kind-pod
+// result is pods with status, readiness and restarts as kubectl get pods shows them
+
+func (k *Kubernetes) CustomGetPods(kubernetesOptions KubernetesOptions, resourceOptions KubernetesResourceOptions) ([]byte, error) {
+
+	b, err := k.request(kubernetesOptions, "GET", resourceOptions.Namespace, "pods", "", k.selectors(resourceOptions), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	var list kubernetesPodList
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, err
+	}
+
+	pods := make([]*KubernetesPod, 0, len(list.Items))
+	for _, item := range list.Items {
+		status := item.Status.Phase
+		if !utils.IsEmpty(item.Status.Reason) {
+			status = item.Status.Reason
+		}
+		ready, restarts := 0, 0
+		for _, c := range item.Status.ContainerStatuses {
+			if c.Ready {
+				ready++
+			}
+			restarts += c.RestartCount
+			if c.State.Waiting != nil && !utils.IsEmpty(c.State.Waiting.Reason) {
+				status = c.State.Waiting.Reason
+			}
+		}
+		pods = append(pods, &KubernetesPod{
+			Name:      item.Metadata.Name,
+			Namespace: item.Metadata.Namespace,
+			Status:    status,
+			Ready:     fmt.Sprintf("%d/%d", ready, len(item.Spec.Containers)),
+			Restarts:  restarts,
+			Node:      item.Spec.NodeName,
+			Created:   item.Metadata.CreationTimestamp,
+		})
+	}
+	return json.Marshal(pods)
+}
+
+func (k *Kubernetes) GetPods(resourceOptions KubernetesResourceOptions) ([]byte, error) {
+	return k.CustomGetPods(k.options, resourceOptions)
+}
+
+// https://kubernetes.io/docs/reference/kubernetes-api/cluster-resources/event-v1/#list-list-or-watch-objects-of-kind-event
+// result is events sorted by time, events of object if name is set
+
+func (k *Kubernetes) CustomGetEvents(kubernetesOptions KubernetesOptions, resourceOptions KubernetesResourceOptions) ([]byte, error) {
+
+	params := k.selectors(resourceOptions)
+	if !utils.IsEmpty(resourceOptions.Name) {
+		selector := fmt.Sprintf("involvedObject.name=%s", resourceOptions.Name)
+		if !utils.IsEmpty(resourceOptions.FieldSelector) {
+			selector = fmt.Sprintf("%s,%s", resourceOptions.FieldSelector, selector)
+		}
+		params.Set("fieldSelector", selector)
+	}
+	b, err := k.request(kubernetesOptions, "GET", resourceOptions.Namespace, "events", "", params, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	var list kubernetesEventList
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, err
+	}
+
+	events := make([]*KubernetesEvent, 0, len(list.Items))
+	for _, item := range list.Items {
+		t := item.LastTimestamp
+		if t.IsZero() {
+			t = item.EventTime
+		}
+		if t.IsZero() {
+			t = item.FirstTimestamp
+		}
+		events = append(events, &KubernetesEvent{
+			Time:    t,
+			Type:    item.Type,
+			Reason:  item.Reason,
+			Object:  fmt.Sprintf("%s/%s", strings.ToLower(item.InvolvedObject.Kind), item.InvolvedObject.Name),
+			Message: item.Message,
+			Count:   item.Count,
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return json.Marshal(events)
+}
+
+func (k *Kubernetes) GetEvents(resourceOptions KubernetesResourceOptions) ([]byte, error) {
+	return k.CustomGetEvents(k.options, resourceOptions)
+}
+
+// rolloutStatus follows kubectl rollout status, rollout is complete when all replicas are updated and available
+func (k *Kubernetes) rolloutStatus(d *kubernetesDeployment) *KubernetesRolloutStatus {
+
+	s := &KubernetesRolloutStatus{
+		Name:      d.Metadata.Name,
+		Namespace: d.Metadata.Namespace,
+		Replicas:  d.Status.Replicas,
+		Updated:   d.Status.UpdatedReplicas,
+		Ready:     d.Status.ReadyReplicas,
+		Available: d.Status.AvailableReplicas,
+	}
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+
+	switch {
+	case d.Metadata.Generation > d.Status.ObservedGeneration:
+		s.Message = "waiting for deployment spec update to be observed"
+	case d.Status.UpdatedReplicas < replicas:
+		s.Message = fmt.Sprintf("%d out of %d new replicas have been updated", d.Status.UpdatedReplicas, replicas)
+	case d.Status.Replicas > d.Status.UpdatedReplicas:
+		s.Message = fmt.Sprintf("%d old replicas are pending termination", d.Status.Replicas-d.Status.UpdatedReplicas)
+	case d.Status.AvailableReplicas < d.Status.UpdatedReplicas:
+		s.Message = fmt.Sprintf("%d of %d updated replicas are available", d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+	default:
+		s.Complete = true
+		s.Message = "successfully rolled out"
+	}
+	for _, c := range d.Status.Conditions {
+		if c.Type == "Progressing" && c.Reason == "ProgressDeadlineExceeded" {
+			s.Failed = true
+			s.Message = c.Message
+		}
+	}
+	return s
+}
+
+func (k *Kubernetes) deploymentStatus(kubernetesOptions KubernetesOptions, resourceOptions KubernetesResourceOptions) (*KubernetesRolloutStatus, error) {
+
+	if utils.IsEmpty(resourceOptions.Name) {
+		return nil, errors.New("no deployment")
+	}
+	b, err := k.request(kubernetesOptions, "GET", resourceOptions.Namespace, "apps/v1/deployments", resourceOptions.Name, nil, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	var d kubernetesDeployment
+	if err := json.Unmarshal(b, &d); err != nil {
+		return nil, err
+	}
+	return k.rolloutStatus(&d), nil
+}
+
+// https://kubernetes.io/docs/reference/kubernetes-api/workload-resources/deployment-v1/#read-read-the-specified-deployment
+// result is rollout status, or status after waiting until rollout is complete or failed
+
+func (k *Kubernetes) CustomGetRolloutStatus(kubernetesOptions KubernetesOptions, resourceOptions KubernetesResourceOptions, waitOptions KubernetesWaitOptions) ([]byte, error) {
+
+	interval := time.Duration(waitOptions.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(waitOptions.WaitTimeout) * time.Second)
+
+	for {
+		s, err := k.deploymentStatus(kubernetesOptions, resourceOptions)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		if !waitOptions.Wait || s.Complete {
+			return data, nil
+		}
+		if s.Failed {
+			return data, fmt.Errorf("Kubernetes deployment %s rollout failed: %s", s.Name, s.Message)
+		}
+		if k.logger != nil {
+			k.logger.Debug("Kubernetes deployment %s rollout: %s", s.Name, s.Message)
+		}
+		if waitOptions.WaitTimeout > 0 && time.Now().After(deadline) {
+			return data, fmt.Errorf("Kubernetes deployment %s rollout isn't complete after %d seconds: %s", s.Name, waitOptions.WaitTimeout, s.Message)
+		}
+		time.Sleep(interval)
+	}
+}
+
+func (k *Kubernetes) GetRolloutStatus(resourceOptions KubernetesResourceOptions, waitOptions KubernetesWaitOptions) ([]byte, error) {
+	return k.CustomGetRolloutStatus(k.options, resourceOptions, waitOptions)
+}
+
+// https://kubernetes.io/docs/reference/kubectl/generated/kubectl_rollout/kubectl_rollout_restart/
+// pod template is annotated as kubectl does, result is rollout status
+
+func (k *Kubernetes) CustomRestartDeployment(kubernetesOptions KubernetesOptions, resourceOptions KubernetesResourceOptions, waitOptions KubernetesWaitOptions) ([]byte, error) {
+
+	if utils.IsEmpty(resourceOptions.Name) {
+		return nil, errors.New("no deployment")
+	}
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						"kubectl.kubernetes.io/restartedAt": time.Now().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+	_, err = k.request(kubernetesOptions, "PATCH", resourceOptions.Namespace, "apps/v1/deployments", resourceOptions.Name, nil, "application/strategic-merge-patch+json", data)
+	if err != nil {
+		return nil, err
+	}
+	return k.CustomGetRolloutStatus(kubernetesOptions, resourceOptions, waitOptions)
+}
+
+func (k *Kubernetes) RestartDeployment(resourceOptions KubernetesResourceOptions, waitOptions KubernetesWaitOptions) ([]byte, error) {
+	return k.CustomRestartDeployment(k.options, resourceOptions, waitOptions)
+}
+
+// WithContext returns Kubernetes of the same configs, which requests are cancelled with ctx
+func (k *Kubernetes) WithContext(ctx context.Context) *Kubernetes {
+	c := *k
+	c.ctx = ctx
+	return &c
+}
+
+func NewKubernetes(options KubernetesOptions, logger common.Logger) *Kubernetes {
+
+	return &Kubernetes{
+		options: options,
+		configs: make(map[string]*kubernetesConfig),
+		mutex:   &sync.Mutex{},
+		logger:  logger,
+	}
+}
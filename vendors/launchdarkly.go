@@ -0,0 +1,167 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const launchDarklyURL = "https://app.launchdarkly.com"
+
+type LaunchDarklyOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	Token    string
+}
+
+type LaunchDarklyFlagOptions struct {
+	Project     string
+	Key         string
+	Environment string
+	Comment     string
+}
+
+type LaunchDarklyInstruction struct {
+	Kind string `json:"kind"`
+}
+
+type LaunchDarklySemanticPatch struct {
+	EnvironmentKey string                     `json:"environmentKey"`
+	Comment        string                     `json:"comment,omitempty"`
+	Instructions   []*LaunchDarklyInstruction `json:"instructions"`
+}
+
+type LaunchDarkly struct {
+	client  *http.Client
+	options LaunchDarklyOptions
+	logger  common.Logger
+}
+
+func (ld *LaunchDarkly) request(opts LaunchDarklyOptions, method, p string, params url.Values, contentType string, data []byte) ([]byte, error) {
+
+	base := opts.URL
+	if utils.IsEmpty(base) {
+		base = launchDarklyURL
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "/api/v2", p)
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = opts.Token
+	headers["Content-Type"] = contentType
+
+	return common.HttpRequestRawWithHeaders(ld.client, method, u.String(), headers, data)
+}
+
+func (ld *LaunchDarkly) check(flagOptions LaunchDarklyFlagOptions) error {
+
+	if utils.IsEmpty(flagOptions.Project) {
+		return errors.New("no project")
+	}
+	if utils.IsEmpty(flagOptions.Key) {
+		return errors.New("no flag key")
+	}
+	return nil
+}
+
+// https://apidocs.launchdarkly.com/tag/Feature-flags#operation/getFeatureFlag
+
+func (ld *LaunchDarkly) CustomGetFlag(launchDarklyOptions LaunchDarklyOptions, flagOptions LaunchDarklyFlagOptions) ([]byte, error) {
+
+	if err := ld.check(flagOptions); err != nil {
+		return nil, err
+	}
+
+	var params url.Values
+	if !utils.IsEmpty(flagOptions.Environment) {
+		params = make(url.Values)
+		params.Add("env", flagOptions.Environment)
+	}
+	return ld.request(launchDarklyOptions, "GET", fmt.Sprintf("/flags/%s/%s", flagOptions.Project, flagOptions.Key), params, "application/json", nil)
+}
+
+func (ld *LaunchDarkly) GetFlag(flagOptions LaunchDarklyFlagOptions) ([]byte, error) {
+	return ld.CustomGetFlag(ld.options, flagOptions)
+}
+
+// https://apidocs.launchdarkly.com/tag/Feature-flags#operation/patchFeatureFlag
+func (ld *LaunchDarkly) toggle(launchDarklyOptions LaunchDarklyOptions, flagOptions LaunchDarklyFlagOptions, on bool) ([]byte, error) {
+
+	if err := ld.check(flagOptions); err != nil {
+		return nil, err
+	}
+	if utils.IsEmpty(flagOptions.Environment) {
+		return nil, errors.New("no environment")
+	}
+
+	kind := "turnFlagOff"
+	state := "off"
+	if on {
+		kind = "turnFlagOn"
+		state = "on"
+	}
+
+	data, err := json.Marshal(&LaunchDarklySemanticPatch{
+		EnvironmentKey: flagOptions.Environment,
+		Comment:        flagOptions.Comment,
+		Instructions:   []*LaunchDarklyInstruction{{Kind: kind}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ld.request(launchDarklyOptions, "PATCH", fmt.Sprintf("/flags/%s/%s", flagOptions.Project, flagOptions.Key),
+		nil, "application/json; domainModel=launchdarkly.semanticpatch", data)
+	if err != nil {
+		return nil, err
+	}
+	if ld.logger != nil {
+		ld.logger.Info("LaunchDarkly flag %s/%s turned %s in %s: %s", flagOptions.Project, flagOptions.Key, state, flagOptions.Environment, flagOptions.Comment)
+	}
+	return resp, nil
+}
+
+func (ld *LaunchDarkly) CustomTurnFlagOn(launchDarklyOptions LaunchDarklyOptions, flagOptions LaunchDarklyFlagOptions) ([]byte, error) {
+	return ld.toggle(launchDarklyOptions, flagOptions, true)
+}
+
+func (ld *LaunchDarkly) TurnFlagOn(flagOptions LaunchDarklyFlagOptions) ([]byte, error) {
+	return ld.CustomTurnFlagOn(ld.options, flagOptions)
+}
+
+func (ld *LaunchDarkly) CustomTurnFlagOff(launchDarklyOptions LaunchDarklyOptions, flagOptions LaunchDarklyFlagOptions) ([]byte, error) {
+	return ld.toggle(launchDarklyOptions, flagOptions, false)
+}
+
+func (ld *LaunchDarkly) TurnFlagOff(flagOptions LaunchDarklyFlagOptions) ([]byte, error) {
+	return ld.CustomTurnFlagOff(ld.options, flagOptions)
+}
+
+func (ld *LaunchDarkly) WithContext(ctx context.Context) *LaunchDarkly {
+	c := *ld
+	c.client = common.HttpClientContext(ld.client, ctx)
+	return &c
+}
+
+func NewLaunchDarkly(options LaunchDarklyOptions, logger common.Logger) *LaunchDarkly {
+
+	return &LaunchDarkly{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+		logger:  logger,
+	}
+}
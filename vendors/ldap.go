@@ -0,0 +1,351 @@
+package vendors
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/devopsext/utils"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	ldapScopeBase = "base"
+	ldapScopeOne  = "one"
+	ldapScopeSub  = "sub"
+
+	// %s is replaced by escaped user or DN of user
+	ldapUserFilter        = "(|(mail=%s)(userPrincipalName=%s)(sAMAccountName=%s)(uid=%s))"
+	ldapGroupFilter       = "(|(member=%s)(uniqueMember=%s))"
+	ldapNestedGroupFilter = "(member:1.2.840.113556.1.4.1941:=%s)"
+	ldapPagingSize        = 500
+)
+
+type LDAPOptions struct {
+	Timeout      int
+	Insecure     bool
+	URL          string
+	StartTLS     bool
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+}
+
+type LDAPSearchOptions struct {
+	BaseDN     string
+	Filter     string
+	Scope      string
+	Attributes []string
+	SizeLimit  int
+}
+
+type LDAPUserOptions struct {
+	User       string
+	Filter     string
+	Attributes []string
+}
+
+type LDAPGroupsOptions struct {
+	Filter string
+	Nested bool
+}
+
+type LDAPEntry struct {
+	DN         string              `json:"dn"`
+	Attributes map[string][]string `json:"attributes,omitempty"`
+}
+
+type LDAPWhoAmI struct {
+	AuthzID string `json:"authzid"`
+	BindDN  string `json:"bind_dn,omitempty"`
+}
+
+type LDAPGroups struct {
+	User   string       `json:"user"`
+	Groups []*LDAPEntry `json:"groups"`
+}
+
+type LDAP struct {
+	options LDAPOptions
+}
+
+func (l *LDAP) timeout(opts LDAPOptions) time.Duration {
+
+	if opts.Timeout <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(opts.Timeout) * time.Second
+}
+
+// connect dials ldaps or ldap with StartTLS if it's set, and binds, anonymously if there is no bind DN
+func (l *LDAP) connect(opts LDAPOptions) (*ldap.Conn, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return nil, errors.New("no URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: opts.Insecure,
+		ServerName:         u.Hostname(),
+	}
+	timeout := l.timeout(opts)
+
+	conn, err := ldap.DialURL(opts.URL, ldap.DialWithTLSDialer(tlsConfig, &net.Dialer{Timeout: timeout}))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(timeout)
+
+	if opts.StartTLS && u.Scheme == "ldap" {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	if utils.IsEmpty(opts.BindDN) {
+		err = conn.UnauthenticatedBind("")
+	} else {
+		err = conn.Bind(opts.BindDN, opts.BindPassword)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func ldapScope(scope string) (int, error) {
+
+	switch strings.ToLower(scope) {
+	case "", ldapScopeSub:
+		return ldap.ScopeWholeSubtree, nil
+	case ldapScopeOne:
+		return ldap.ScopeSingleLevel, nil
+	case ldapScopeBase:
+		return ldap.ScopeBaseObject, nil
+	default:
+		return 0, fmt.Errorf("unsupported scope %s", scope)
+	}
+}
+
+// ldapEntries converts entries, binary values like objectGUID are base64 encoded
+func ldapEntries(entries []*ldap.Entry) []*LDAPEntry {
+
+	r := []*LDAPEntry{}
+	for _, e := range entries {
+		entry := &LDAPEntry{DN: e.DN}
+		for _, a := range e.Attributes {
+			if entry.Attributes == nil {
+				entry.Attributes = make(map[string][]string)
+			}
+			values := []string{}
+			for _, v := range a.ByteValues {
+				if utf8.Valid(v) {
+					values = append(values, string(v))
+				} else {
+					values = append(values, base64.StdEncoding.EncodeToString(v))
+				}
+			}
+			entry.Attributes[a.Name] = values
+		}
+		r = append(r, entry)
+	}
+	return r
+}
+
+func (l *LDAP) search(conn *ldap.Conn, opts LDAPOptions, searchOptions LDAPSearchOptions) ([]*LDAPEntry, error) {
+
+	base := searchOptions.BaseDN
+	if utils.IsEmpty(base) {
+		base = opts.BaseDN
+	}
+	if utils.IsEmpty(base) {
+		return nil, errors.New("no base DN")
+	}
+	filter := searchOptions.Filter
+	if utils.IsEmpty(filter) {
+		filter = "(objectClass=*)"
+	}
+	scope, err := ldapScope(searchOptions.Scope)
+	if err != nil {
+		return nil, err
+	}
+	attributes := []string{}
+	for _, a := range searchOptions.Attributes {
+		if !utils.IsEmpty(a) {
+			attributes = append(attributes, strings.TrimSpace(a))
+		}
+	}
+
+	req := ldap.NewSearchRequest(base, scope, ldap.NeverDerefAliases, searchOptions.SizeLimit,
+		int(l.timeout(opts).Seconds()), false, filter, attributes, nil)
+
+	// directories limit results, e.g. AD returns 1000 entries, so that all of them are paged
+	var sr *ldap.SearchResult
+	if searchOptions.SizeLimit > 0 {
+		sr, err = conn.Search(req)
+		// entries up to limit are result as limit is set
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) && sr != nil {
+			err = nil
+		}
+	} else {
+		sr, err = conn.SearchWithPaging(req, ldapPagingSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ldapEntries(sr.Entries), nil
+}
+
+func (l *LDAP) findUser(conn *ldap.Conn, opts LDAPOptions, userOptions LDAPUserOptions) (*LDAPEntry, error) {
+
+	if utils.IsEmpty(userOptions.User) {
+		return nil, errors.New("no user")
+	}
+	filter := userOptions.Filter
+	if utils.IsEmpty(filter) {
+		filter = ldapUserFilter
+	}
+	filter = strings.ReplaceAll(filter, "%s", ldap.EscapeFilter(userOptions.User))
+
+	entries, err := l.search(conn, opts, LDAPSearchOptions{
+		Filter:     filter,
+		Attributes: userOptions.Attributes,
+		SizeLimit:  2,
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch len(entries) {
+	case 0:
+		return nil, fmt.Errorf("no user %s", userOptions.User)
+	case 1:
+		return entries[0], nil
+	default:
+		return nil, fmt.Errorf("user %s is ambiguous, filter matches many entries", userOptions.User)
+	}
+}
+
+// https://datatracker.ietf.org/doc/html/rfc4511#section-4.5
+// entries of search, all pages are got if there is no size limit
+
+func (l *LDAP) CustomSearch(ldapOptions LDAPOptions, searchOptions LDAPSearchOptions) ([]byte, error) {
+
+	conn, err := l.connect(ldapOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entries, err := l.search(conn, ldapOptions, searchOptions)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(entries)
+}
+
+func (l *LDAP) Search(searchOptions LDAPSearchOptions) ([]byte, error) {
+	return l.CustomSearch(l.options, searchOptions)
+}
+
+// https://datatracker.ietf.org/doc/html/rfc4532
+// authorization identity of bind, e.g. to check credentials
+
+func (l *LDAP) CustomWhoAmI(ldapOptions LDAPOptions) ([]byte, error) {
+
+	conn, err := l.connect(ldapOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	r, err := conn.WhoAmI(nil)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&LDAPWhoAmI{AuthzID: r.AuthzID, BindDN: ldapOptions.BindDN})
+}
+
+func (l *LDAP) WhoAmI() ([]byte, error) {
+	return l.CustomWhoAmI(l.options)
+}
+
+// user is found by email, user principal name, account name or uid, e.g. to resolve email to AD username
+
+func (l *LDAP) CustomGetUser(ldapOptions LDAPOptions, userOptions LDAPUserOptions) ([]byte, error) {
+
+	conn, err := l.connect(ldapOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	user, err := l.findUser(conn, ldapOptions, userOptions)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(user)
+}
+
+func (l *LDAP) GetUser(userOptions LDAPUserOptions) ([]byte, error) {
+	return l.CustomGetUser(l.options, userOptions)
+}
+
+// https://learn.microsoft.com/en-us/windows/win32/adsi/search-filter-syntax
+// groups having user as member, nested groups are found by AD matching rule in chain
+
+func (l *LDAP) CustomGetGroups(ldapOptions LDAPOptions, userOptions LDAPUserOptions, groupsOptions LDAPGroupsOptions) ([]byte, error) {
+
+	conn, err := l.connect(ldapOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// 1.1 means no attributes, DN is enough
+	userOptions.Attributes = []string{"1.1"}
+	user, err := l.findUser(conn, ldapOptions, userOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := groupsOptions.Filter
+	if utils.IsEmpty(filter) {
+		filter = ldapGroupFilter
+		if groupsOptions.Nested {
+			filter = ldapNestedGroupFilter
+		}
+	}
+	filter = strings.ReplaceAll(filter, "%s", ldap.EscapeFilter(user.DN))
+
+	groups, err := l.search(conn, ldapOptions, LDAPSearchOptions{
+		Filter:     filter,
+		Attributes: []string{"cn", "description"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&LDAPGroups{User: user.DN, Groups: groups})
+}
+
+func (l *LDAP) GetGroups(userOptions LDAPUserOptions, groupsOptions LDAPGroupsOptions) ([]byte, error) {
+	return l.CustomGetGroups(l.options, userOptions, groupsOptions)
+}
+
+func NewLDAP(options LDAPOptions) *LDAP {
+
+	return &LDAP{
+		options: options,
+	}
+}
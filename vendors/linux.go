@@ -3,45 +3,199 @@ package vendors
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
+const sshDefaultPort = 22
+
 type SSH struct {
 	options SSHOptions
 }
 type SSHOptions struct {
-	User       string
-	Address    string
-	PrivateKey []byte
-	Command    string
-	Timeout    int
+	User                  string
+	Address               string
+	Port                  int
+	PrivateKey            []byte
+	Passphrase            string
+	Password              string
+	Agent                 bool
+	Command               string
+	Timeout               int
+	KnownHosts            string
+	InsecureIgnoreHostKey bool
 }
 
-func (s *SSH) Run(options SSHOptions) ([]byte, error) {
-	key, err := ssh.ParsePrivateKey(options.PrivateKey)
+type SSHUploadOptions struct {
+	Source      string
+	Destination string
+	Mode        string
+}
+
+type SSHUploadResult struct {
+	Destination string `json:"destination"`
+	Size        int64  `json:"size"`
+}
+
+// sshClient is client of connection and agent which signed auth of it, agent is closed with client
+type sshClient struct {
+	*ssh.Client
+	agent net.Conn
+}
+
+func (c *sshClient) Close() error {
+
+	err := c.Client.Close()
+	if c.agent != nil {
+		c.agent.Close()
+	}
+	return err
+}
+
+// auth returns methods of options and connection of agent, which is closed once client is closed
+func (s *SSH) auth(options SSHOptions) ([]ssh.AuthMethod, net.Conn, error) {
+
+	methods := []ssh.AuthMethod{}
+
+	if len(options.PrivateKey) > 0 {
+		var key ssh.Signer
+		var err error
+		if !utils.IsEmpty(options.Passphrase) {
+			key, err = ssh.ParsePrivateKeyWithPassphrase(options.PrivateKey, []byte(options.Passphrase))
+		} else {
+			key, err = ssh.ParsePrivateKey(options.PrivateKey)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		methods = append(methods, ssh.PublicKeys(key))
+	}
+
+	var conn net.Conn
+	if options.Agent {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if utils.IsEmpty(sock) {
+			return nil, nil, errors.New("SSH agent is requested but SSH_AUTH_SOCK is not set")
+		}
+		c, err := net.Dial("unix", sock)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect SSH agent: %w", err)
+		}
+		conn = c
+		methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+
+	if !utils.IsEmpty(options.Password) {
+		methods = append(methods, ssh.Password(options.Password))
+	}
+
+	if len(methods) == 0 {
+		return nil, nil, errors.New("no SSH auth: private key, agent or password")
+	}
+	return methods, conn, nil
+}
+
+// SSHDefaultKnownHosts returns known hosts file of user, e.g. ~/.ssh/known_hosts
+func SSHDefaultKnownHosts() string {
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
+
+// hostKeyCallback verifies host key by known hosts file, so that credentials aren't sent to host which poses as it,
+// host key isn't verified only if it's ignored explicitly
+func (s *SSH) hostKeyCallback(options SSHOptions) (ssh.HostKeyCallback, error) {
+
+	if options.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	file := options.KnownHosts
+	if utils.IsEmpty(file) {
+		file = SSHDefaultKnownHosts()
+	}
+	if utils.IsEmpty(file) {
+		return nil, errors.New("no SSH known hosts")
+	}
+	callback, err := knownhosts.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("SSH known hosts %s: %w", file, err)
+	}
+	return callback, nil
+}
+
+func (s *SSH) dial(options SSHOptions) (*sshClient, error) {
+
+	hostKeyCallback, err := s.hostKeyCallback(options)
+	if err != nil {
+		return nil, err
+	}
+	methods, conn, err := s.auth(options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse private key: %w", err)
+		return nil, err
 	}
 
 	config := &ssh.ClientConfig{
 		User:            options.User,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Auth: []ssh.AuthMethod{
-			ssh.PublicKeys(key),
-		},
-		/*
-			Auth: []ssh.AuthMethod{
-				ssh.Password("PASSWORD"),
-			},
-		*/
+		HostKeyCallback: hostKeyCallback,
+		Auth:            methods,
+		Timeout:         time.Duration(options.Timeout) * time.Second,
+	}
+
+	port := options.Port
+	if port == 0 {
+		port = sshDefaultPort
+	}
+	client, err := ssh.Dial("tcp", net.JoinHostPort(options.Address, strconv.Itoa(port)), config)
+	if err != nil {
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, err
+	}
+	return &sshClient{Client: client, agent: conn}, nil
+}
+
+// url returns URL of audit records, e.g. ssh://user@host:22
+func (s *SSH) url(options SSHOptions) string {
+
+	port := options.Port
+	if port == 0 {
+		port = sshDefaultPort
 	}
+	return fmt.Sprintf("ssh://%s@%s", options.User, net.JoinHostPort(options.Address, strconv.Itoa(port)))
+}
+
+// Run runs command, it's audited without command, as command could have secrets
+func (s *SSH) Run(options SSHOptions) ([]byte, error) {
 
-	client, err := ssh.Dial("tcp", net.JoinHostPort(options.Address, "22"), config)
+	start := time.Now()
+	b, err := s.run(options)
+	common.AuditOperation("ssh", "RUN", s.url(options), "", err, start)
+	return b, err
+}
+
+func (s *SSH) run(options SSHOptions) ([]byte, error) {
 
+	client, err := s.dial(options)
 	if err != nil {
 		return nil, err
 	}
@@ -54,10 +208,10 @@ func (s *SSH) Run(options SSHOptions) ([]byte, error) {
 	}
 	defer session.Close()
 
-	var b bytes.Buffer
+	var b, e bytes.Buffer
 	session.Stdout = &b
+	session.Stderr = &e
 
-	ctx := context.Background()
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(options.Timeout)*time.Second)
 	defer cancel()
 
@@ -72,13 +226,75 @@ func (s *SSH) Run(options SSHOptions) ([]byte, error) {
 		return nil, fmt.Errorf("SSH command timed out after %d seconds", options.Timeout)
 	case err := <-done:
 		if err != nil {
-			return nil, fmt.Errorf("SSH command failed: %w", err)
+			return nil, fmt.Errorf("SSH command failed: %w %s", err, strings.TrimSpace(e.String()))
 		}
 	}
 
 	return b.Bytes(), err
 }
 
+// Upload copies local source file to destination over SFTP, destination ending with / is treated as directory
+func (s *SSH) Upload(options SSHOptions, uploadOptions SSHUploadOptions) ([]byte, error) {
+
+	start := time.Now()
+	b, err := s.upload(options, uploadOptions)
+	common.AuditOperation("ssh", "UPLOAD", s.url(options), uploadOptions.Destination, err, start)
+	return b, err
+}
+
+func (s *SSH) upload(options SSHOptions, uploadOptions SSHUploadOptions) ([]byte, error) {
+
+	src, err := os.Open(uploadOptions.Source)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	client, err := s.dial(options)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	sc, err := sftp.NewClient(client.Client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start SFTP: %w", err)
+	}
+	defer sc.Close()
+
+	dest := uploadOptions.Destination
+	if utils.IsEmpty(dest) || strings.HasSuffix(dest, "/") {
+		dest = path.Join(dest, path.Base(strings.ReplaceAll(uploadOptions.Source, "\\", "/")))
+	}
+
+	dst, err := sc.Create(dest)
+	if err != nil {
+		return nil, err
+	}
+	defer dst.Close()
+
+	size, err := io.Copy(dst, src)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.IsEmpty(uploadOptions.Mode) {
+		mode, err := strconv.ParseUint(uploadOptions.Mode, 8, 32)
+		if err != nil {
+			return nil, err
+		}
+		err = sc.Chmod(dest, os.FileMode(mode))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(&SSHUploadResult{
+		Destination: dest,
+		Size:        size,
+	})
+}
+
 func NewSSH(options SSHOptions) *SSH {
 
 	ssh := &SSH{
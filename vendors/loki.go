@@ -0,0 +1,268 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+type LokiOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	User     string
+	Password string
+	TenantID string
+}
+
+type LokiPushOptions struct {
+	Labels string
+	Text   string
+}
+
+type LokiQueryOptions struct {
+	Query     string
+	Since     string
+	From      string
+	To        string
+	Limit     int
+	Direction string
+	Format    string
+}
+
+type LokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type LokiPushRequest struct {
+	Streams []*LokiStream `json:"streams"`
+}
+
+type LokiPushResult struct {
+	Pushed int `json:"pushed"`
+}
+
+type LokiQueryResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type Loki struct {
+	client  *http.Client
+	options LokiOptions
+}
+
+func (l *Loki) headers(opts LokiOptions) map[string]string {
+
+	headers := make(map[string]string)
+	if !utils.IsEmpty(opts.User) {
+		headers["Authorization"] = common.FormatBasicAuth(opts.User, opts.Password)
+	}
+	if !utils.IsEmpty(opts.TenantID) {
+		headers["X-Scope-OrgID"] = opts.TenantID
+	}
+	return headers
+}
+
+func (l *Loki) apiURL(opts LokiOptions, p string, params url.Values) (string, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return "", errors.New("no URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, p)
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+	return u.String(), nil
+}
+
+// https://grafana.com/docs/loki/latest/reference/loki-http-api/#ingest-logs
+// every non empty line of text is entry of one stream, entries keep order by nanosecond increments
+
+func (l *Loki) CustomPush(lokiOptions LokiOptions, pushOptions LokiPushOptions) ([]byte, error) {
+
+	labels := utils.MapGetKeyValues(pushOptions.Labels)
+	if len(labels) == 0 {
+		return nil, errors.New("no labels")
+	}
+
+	stream := &LokiStream{Stream: labels, Values: [][2]string{}}
+	ts := time.Now().UnixNano()
+	for _, line := range strings.Split(pushOptions.Text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if utils.IsEmpty(strings.TrimSpace(line)) {
+			continue
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(ts, 10), line})
+		ts++
+	}
+	if len(stream.Values) == 0 {
+		return nil, errors.New("no lines")
+	}
+
+	data, err := json.Marshal(&LokiPushRequest{Streams: []*LokiStream{stream}})
+	if err != nil {
+		return nil, err
+	}
+	u, err := l.apiURL(lokiOptions, "/loki/api/v1/push", nil)
+	if err != nil {
+		return nil, err
+	}
+	headers := l.headers(lokiOptions)
+	headers["Content-Type"] = "application/json"
+
+	_, err = common.HttpRequestRawWithHeaders(l.client, "POST", u, headers, data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&LokiPushResult{Pushed: len(stream.Values)})
+}
+
+func (l *Loki) Push(pushOptions LokiPushOptions) ([]byte, error) {
+	return l.CustomPush(l.options, pushOptions)
+}
+
+// lokiTime converts RFC3339 time to nanoseconds, others are passed as is, e.g. unix nanoseconds
+func lokiTime(s string) string {
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return s
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// lokiLines formats streams result as lines sorted by time: time {labels} line
+func lokiLines(result json.RawMessage, backward bool) ([]byte, error) {
+
+	var streams []*LokiStream
+	if err := json.Unmarshal(result, &streams); err != nil {
+		return nil, err
+	}
+
+	type entry struct {
+		ts   int64
+		line string
+	}
+	entries := []entry{}
+	for _, s := range streams {
+		keys := make([]string, 0, len(s.Stream))
+		for k := range s.Stream {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		kvs := []string{}
+		for _, k := range keys {
+			kvs = append(kvs, fmt.Sprintf("%s=%q", k, s.Stream[k]))
+		}
+		labels := strings.Join(kvs, ", ")
+
+		for _, v := range s.Values {
+			ts, _ := strconv.ParseInt(v[0], 10, 64)
+			line := fmt.Sprintf("%s {%s} %s", time.Unix(0, ts).UTC().Format(time.RFC3339Nano), labels, v[1])
+			entries = append(entries, entry{ts: ts, line: line})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if backward {
+			return entries[i].ts > entries[j].ts
+		}
+		return entries[i].ts < entries[j].ts
+	})
+
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = e.line
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// https://grafana.com/docs/loki/latest/reference/loki-http-api/#query-logs-within-a-range-of-time
+// result is JSON of data, or plain lines if format is lines and result is streams
+
+func (l *Loki) CustomQueryRange(lokiOptions LokiOptions, queryOptions LokiQueryOptions) ([]byte, error) {
+
+	if utils.IsEmpty(queryOptions.Query) {
+		return nil, errors.New("no query")
+	}
+	format := strings.ToLower(queryOptions.Format)
+	if !utils.IsEmpty(format) && format != "json" && format != "lines" {
+		return nil, fmt.Errorf("unsupported format %s", queryOptions.Format)
+	}
+
+	params := make(url.Values)
+	params.Set("query", queryOptions.Query)
+	if !utils.IsEmpty(queryOptions.Since) {
+		params.Set("since", queryOptions.Since)
+	}
+	if !utils.IsEmpty(queryOptions.From) {
+		params.Set("start", lokiTime(queryOptions.From))
+	}
+	if !utils.IsEmpty(queryOptions.To) {
+		params.Set("end", lokiTime(queryOptions.To))
+	}
+	if queryOptions.Limit > 0 {
+		params.Set("limit", strconv.Itoa(queryOptions.Limit))
+	}
+	if !utils.IsEmpty(queryOptions.Direction) {
+		params.Set("direction", strings.ToLower(queryOptions.Direction))
+	}
+
+	u, err := l.apiURL(lokiOptions, "/loki/api/v1/query_range", params)
+	if err != nil {
+		return nil, err
+	}
+	b, err := common.HttpRequestRawWithHeaders(l.client, "GET", u, l.headers(lokiOptions), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var r LokiQueryResponse
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	if format == "lines" {
+		if r.Data.ResultType != "streams" {
+			return nil, fmt.Errorf("lines format needs log query, result is %s", r.Data.ResultType)
+		}
+		return lokiLines(r.Data.Result, !strings.EqualFold(queryOptions.Direction, "forward"))
+	}
+	return b, nil
+}
+
+func (l *Loki) QueryRange(queryOptions LokiQueryOptions) ([]byte, error) {
+	return l.CustomQueryRange(l.options, queryOptions)
+}
+
+func (l *Loki) WithContext(ctx context.Context) *Loki {
+	c := *l
+	c.client = common.HttpClientContext(l.client, ctx)
+	return &c
+}
+
+func NewLoki(options LokiOptions) *Loki {
+
+	return &Loki{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
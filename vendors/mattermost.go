@@ -0,0 +1,240 @@
+package vendors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+type MattermostOptions struct {
+	Timeout    int
+	Insecure   bool
+	URL        string
+	Token      string
+	WebhookURL string
+}
+
+type MattermostMessageOptions struct {
+	Channel     string
+	Thread      string
+	Text        string
+	Username    string
+	IconURL     string
+	Attachments string
+}
+
+type MattermostFileOptions struct {
+	Channel string
+	Thread  string
+	Text    string
+	Name    string
+	Content string
+}
+
+type MattermostPost struct {
+	ChannelID string                 `json:"channel_id"`
+	RootID    string                 `json:"root_id,omitempty"`
+	Message   string                 `json:"message"`
+	FileIDs   []string               `json:"file_ids,omitempty"`
+	Props     map[string]interface{} `json:"props,omitempty"`
+}
+
+type MattermostWebhookMessage struct {
+	Channel     string        `json:"channel,omitempty"`
+	Text        string        `json:"text"`
+	Username    string        `json:"username,omitempty"`
+	IconURL     string        `json:"icon_url,omitempty"`
+	Attachments []interface{} `json:"attachments,omitempty"`
+}
+
+type Mattermost struct {
+	client  *http.Client
+	options MattermostOptions
+}
+
+func (m *Mattermost) apiURL(opts MattermostOptions, p string) (string, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return "", errors.New("no URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, "/api/v4", p)
+	return u.String(), nil
+}
+
+func (m *Mattermost) post(opts MattermostOptions, p, contentType string, data []byte) ([]byte, error) {
+
+	u, err := m.apiURL(opts, p)
+	if err != nil {
+		return nil, err
+	}
+	return common.HttpPostRaw(m.client, u, contentType, fmt.Sprintf("Bearer %s", opts.Token), data)
+}
+
+func mattermostAttachments(attachments string) ([]interface{}, error) {
+
+	if utils.IsEmpty(attachments) {
+		return nil, nil
+	}
+	var r []interface{}
+	if err := json.Unmarshal([]byte(attachments), &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// https://developers.mattermost.com/integrate/webhooks/incoming/
+// channel is name of channel, channel of webhook is used if it's empty, webhooks can't reply to threads
+
+func (m *Mattermost) sendWebhook(mattermostOptions MattermostOptions, messageOptions MattermostMessageOptions) ([]byte, error) {
+
+	if !utils.IsEmpty(messageOptions.Thread) {
+		return nil, errors.New("webhook can't reply to thread, token is required")
+	}
+	attachments, err := mattermostAttachments(messageOptions.Attachments)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(&MattermostWebhookMessage{
+		Channel:     messageOptions.Channel,
+		Text:        messageOptions.Text,
+		Username:    messageOptions.Username,
+		IconURL:     messageOptions.IconURL,
+		Attachments: attachments,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return common.HttpPostRaw(m.client, mattermostOptions.WebhookURL, "application/json", "", data)
+}
+
+// https://api.mattermost.com/#tag/posts/operation/CreatePost
+// message is sent by webhook if there is no token, channel is ID of channel of API
+
+func (m *Mattermost) CustomSendMessage(mattermostOptions MattermostOptions, messageOptions MattermostMessageOptions) ([]byte, error) {
+
+	if utils.IsEmpty(messageOptions.Text) && utils.IsEmpty(messageOptions.Attachments) {
+		return nil, errors.New("no text or attachments")
+	}
+	if utils.IsEmpty(mattermostOptions.Token) {
+		if utils.IsEmpty(mattermostOptions.WebhookURL) {
+			return nil, errors.New("no token or webhook URL")
+		}
+		return m.sendWebhook(mattermostOptions, messageOptions)
+	}
+	if utils.IsEmpty(messageOptions.Channel) {
+		return nil, errors.New("no channel")
+	}
+
+	p := &MattermostPost{
+		ChannelID: messageOptions.Channel,
+		RootID:    messageOptions.Thread,
+		Message:   messageOptions.Text,
+	}
+	attachments, err := mattermostAttachments(messageOptions.Attachments)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) > 0 {
+		p.Props = map[string]interface{}{"attachments": attachments}
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return m.post(mattermostOptions, "/posts", "application/json", data)
+}
+
+func (m *Mattermost) SendMessage(messageOptions MattermostMessageOptions) ([]byte, error) {
+	return m.CustomSendMessage(m.options, messageOptions)
+}
+
+// https://api.mattermost.com/#tag/files/operation/UploadFile
+// file is uploaded to channel, then it's posted with text
+
+func (m *Mattermost) CustomSendFile(mattermostOptions MattermostOptions, fileOptions MattermostFileOptions) ([]byte, error) {
+
+	if utils.IsEmpty(mattermostOptions.Token) {
+		return nil, errors.New("no token, webhook can't send files")
+	}
+	if utils.IsEmpty(fileOptions.Channel) {
+		return nil, errors.New("no channel")
+	}
+	if utils.IsEmpty(fileOptions.Name) {
+		return nil, errors.New("no file name")
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := w.WriteField("channel_id", fileOptions.Channel); err != nil {
+		return nil, err
+	}
+	fw, err := w.CreateFormFile("files", fileOptions.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write([]byte(fileOptions.Content)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	b, err := m.post(mattermostOptions, "/files", w.FormDataContentType(), body.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	var r struct {
+		FileInfos []struct {
+			ID string `json:"id"`
+		} `json:"file_infos"`
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	// raw and dry run responses are returned as is
+	if len(r.FileInfos) == 0 {
+		return b, nil
+	}
+
+	data, err := json.Marshal(&MattermostPost{
+		ChannelID: fileOptions.Channel,
+		RootID:    fileOptions.Thread,
+		Message:   fileOptions.Text,
+		FileIDs:   []string{r.FileInfos[0].ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.post(mattermostOptions, "/posts", "application/json", data)
+}
+
+func (m *Mattermost) SendFile(fileOptions MattermostFileOptions) ([]byte, error) {
+	return m.CustomSendFile(m.options, fileOptions)
+}
+
+func (m *Mattermost) WithContext(ctx context.Context) *Mattermost {
+	c := *m
+	c.client = common.HttpClientContext(m.client, ctx)
+	return &c
+}
+
+func NewMattermost(options MattermostOptions) *Mattermost {
+
+	return &Mattermost{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
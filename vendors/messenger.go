@@ -0,0 +1,323 @@
+package vendors
+
+import (
+	"errors"
+
+	"github.com/devopsext/utils"
+)
+
+// MessengerMessage is message of any chat vendor, channel and thread are IDs of vendor,
+// channel is default of messenger if it's empty
+type MessengerMessage struct {
+	Channel string
+	Thread  string
+	Title   string
+	Text    string
+}
+
+type MessengerFile struct {
+	MessengerMessage
+	Name    string
+	Content string
+}
+
+// Messenger sends the same message to any chat vendor, reply is message to thread of message
+type Messenger interface {
+	Send(message MessengerMessage) ([]byte, error)
+	SendFile(file MessengerFile) ([]byte, error)
+	Reply(message MessengerMessage) ([]byte, error)
+}
+
+// messengerText prepends title to text for vendors having no title of message
+func messengerText(message MessengerMessage) string {
+
+	if utils.IsEmpty(message.Title) {
+		return message.Text
+	}
+	if utils.IsEmpty(message.Text) {
+		return message.Title
+	}
+	return message.Title + "\n\n" + message.Text
+}
+
+func messengerChannel(message MessengerMessage, channel string) string {
+
+	if utils.IsEmpty(message.Channel) {
+		return channel
+	}
+	return message.Channel
+}
+
+func messengerThread(message MessengerMessage) error {
+
+	if utils.IsEmpty(message.Thread) {
+		return errors.New("no thread")
+	}
+	return nil
+}
+
+type slackMessenger struct {
+	slack   *Slack
+	channel string
+}
+
+func (m *slackMessenger) Send(message MessengerMessage) ([]byte, error) {
+
+	return m.slack.SendMessage(SlackMessageOptions{
+		Channel: messengerChannel(message, m.channel),
+		Thread:  message.Thread,
+		Title:   message.Title,
+		Text:    message.Text,
+	})
+}
+
+func (m *slackMessenger) SendFile(file MessengerFile) ([]byte, error) {
+
+	return m.slack.SendFile(SlackFileOptions{
+		Channel: messengerChannel(file.MessengerMessage, m.channel),
+		Thread:  file.Thread,
+		Title:   file.Title,
+		Text:    file.Text,
+		Name:    file.Name,
+		Content: file.Content,
+	})
+}
+
+func (m *slackMessenger) Reply(message MessengerMessage) ([]byte, error) {
+
+	if err := messengerThread(message); err != nil {
+		return nil, err
+	}
+	return m.Send(message)
+}
+
+// NewSlackMessenger sends to channel of Slack
+func NewSlackMessenger(slack *Slack, channel string) Messenger {
+	return &slackMessenger{slack: slack, channel: channel}
+}
+
+type telegramMessenger struct {
+	telegram *Telegram
+}
+
+func (m *telegramMessenger) options(message MessengerMessage) TelegramOptions {
+
+	opts := m.telegram.options
+	opts.ChatID = messengerChannel(message, opts.ChatID)
+	return opts
+}
+
+func (m *telegramMessenger) Send(message MessengerMessage) ([]byte, error) {
+
+	return m.telegram.CustomSendMessage(m.options(message), TelegramMessageOptions{
+		Text:    messengerText(message),
+		ReplyTo: message.Thread,
+	})
+}
+
+// SendFile sends document, Telegram documents can't reply
+func (m *telegramMessenger) SendFile(file MessengerFile) ([]byte, error) {
+
+	return m.telegram.CustomSendDocument(m.options(file.MessengerMessage), TelegramDocumentOptions{
+		Caption: messengerText(file.MessengerMessage),
+		Name:    file.Name,
+		Content: file.Content,
+	})
+}
+
+func (m *telegramMessenger) Reply(message MessengerMessage) ([]byte, error) {
+
+	if err := messengerThread(message); err != nil {
+		return nil, err
+	}
+	return m.Send(message)
+}
+
+// NewTelegramMessenger sends to chat of Telegram options
+func NewTelegramMessenger(telegram *Telegram) Messenger {
+	return &telegramMessenger{telegram: telegram}
+}
+
+type discordMessenger struct {
+	discord *Discord
+	channel string
+}
+
+func (m *discordMessenger) message(message MessengerMessage) DiscordMessageOptions {
+
+	return DiscordMessageOptions{
+		Channel: messengerChannel(message, m.channel),
+		Thread:  message.Thread,
+		Content: messengerText(message),
+	}
+}
+
+func (m *discordMessenger) Send(message MessengerMessage) ([]byte, error) {
+	return m.discord.SendMessage(m.message(message))
+}
+
+func (m *discordMessenger) SendFile(file MessengerFile) ([]byte, error) {
+
+	return m.discord.SendFile(DiscordFileOptions{
+		DiscordMessageOptions: m.message(file.MessengerMessage),
+		Name:                  file.Name,
+		File:                  file.Content,
+	})
+}
+
+func (m *discordMessenger) Reply(message MessengerMessage) ([]byte, error) {
+
+	if err := messengerThread(message); err != nil {
+		return nil, err
+	}
+	return m.Send(message)
+}
+
+// NewDiscordMessenger sends by webhook, or to channel by bot
+func NewDiscordMessenger(discord *Discord, channel string) Messenger {
+	return &discordMessenger{discord: discord, channel: channel}
+}
+
+type rocketChatMessenger struct {
+	rocketChat *RocketChat
+	channel    string
+}
+
+func (m *rocketChatMessenger) Send(message MessengerMessage) ([]byte, error) {
+
+	return m.rocketChat.SendMessage(RocketChatMessageOptions{
+		Channel: messengerChannel(message, m.channel),
+		Thread:  message.Thread,
+		Text:    messengerText(message),
+	})
+}
+
+// SendFile uploads file to room, channel should be room ID
+func (m *rocketChatMessenger) SendFile(file MessengerFile) ([]byte, error) {
+
+	return m.rocketChat.SendFile(RocketChatFileOptions{
+		RoomID:  messengerChannel(file.MessengerMessage, m.channel),
+		Thread:  file.Thread,
+		Text:    messengerText(file.MessengerMessage),
+		Name:    file.Name,
+		Content: file.Content,
+	})
+}
+
+func (m *rocketChatMessenger) Reply(message MessengerMessage) ([]byte, error) {
+
+	if err := messengerThread(message); err != nil {
+		return nil, err
+	}
+	return m.Send(message)
+}
+
+// NewRocketChatMessenger sends to channel of Rocket.Chat
+func NewRocketChatMessenger(rocketChat *RocketChat, channel string) Messenger {
+	return &rocketChatMessenger{rocketChat: rocketChat, channel: channel}
+}
+
+type webexMessenger struct {
+	webex *Webex
+	room  string
+}
+
+func (m *webexMessenger) message(message MessengerMessage) WebexMessageOptions {
+
+	return WebexMessageOptions{
+		Room:     messengerChannel(message, m.room),
+		Parent:   message.Thread,
+		Markdown: messengerText(message),
+	}
+}
+
+func (m *webexMessenger) Send(message MessengerMessage) ([]byte, error) {
+	return m.webex.SendMessage(m.message(message))
+}
+
+func (m *webexMessenger) SendFile(file MessengerFile) ([]byte, error) {
+
+	return m.webex.SendFile(WebexFileOptions{
+		WebexMessageOptions: m.message(file.MessengerMessage),
+		Name:                file.Name,
+		File:                file.Content,
+	})
+}
+
+func (m *webexMessenger) Reply(message MessengerMessage) ([]byte, error) {
+
+	if err := messengerThread(message); err != nil {
+		return nil, err
+	}
+	return m.Send(message)
+}
+
+// NewWebexMessenger sends to room of Webex
+func NewWebexMessenger(webex *Webex, room string) Messenger {
+	return &webexMessenger{webex: webex, room: room}
+}
+
+type teamsMessenger struct {
+	teams *Teams
+}
+
+func (m *teamsMessenger) Send(message MessengerMessage) ([]byte, error) {
+
+	return m.teams.SendMessage(TeamsMessageOptions{
+		Title: message.Title,
+		Text:  message.Text,
+	})
+}
+
+// SendFile isn't supported, incoming webhooks of Teams can't upload files
+func (m *teamsMessenger) SendFile(file MessengerFile) ([]byte, error) {
+	return nil, errors.New("Teams incoming webhook can't send files")
+}
+
+// Reply isn't supported, incoming webhooks of Teams can't reply to threads
+func (m *teamsMessenger) Reply(message MessengerMessage) ([]byte, error) {
+	return nil, errors.New("Teams incoming webhook can't reply to thread")
+}
+
+// NewTeamsMessenger sends to channel of incoming webhook of Teams
+func NewTeamsMessenger(teams *Teams) Messenger {
+	return &teamsMessenger{teams: teams}
+}
+
+type mattermostMessenger struct {
+	mattermost *Mattermost
+	channel    string
+}
+
+func (m *mattermostMessenger) Send(message MessengerMessage) ([]byte, error) {
+
+	return m.mattermost.SendMessage(MattermostMessageOptions{
+		Channel: messengerChannel(message, m.channel),
+		Thread:  message.Thread,
+		Text:    messengerText(message),
+	})
+}
+
+func (m *mattermostMessenger) SendFile(file MessengerFile) ([]byte, error) {
+
+	return m.mattermost.SendFile(MattermostFileOptions{
+		Channel: messengerChannel(file.MessengerMessage, m.channel),
+		Thread:  file.Thread,
+		Text:    messengerText(file.MessengerMessage),
+		Name:    file.Name,
+		Content: file.Content,
+	})
+}
+
+func (m *mattermostMessenger) Reply(message MessengerMessage) ([]byte, error) {
+
+	if err := messengerThread(message); err != nil {
+		return nil, err
+	}
+	return m.Send(message)
+}
+
+// NewMattermostMessenger sends to channel of Mattermost by API, or by webhook if there is no token
+func NewMattermostMessenger(mattermost *Mattermost, channel string) Messenger {
+	return &mattermostMessenger{mattermost: mattermost, channel: channel}
+}
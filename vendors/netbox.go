@@ -0,0 +1,234 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const (
+	netboxDevices     = "/dcim/devices/"
+	netboxPrefixes    = "/ipam/prefixes/"
+	netboxIPAddresses = "/ipam/ip-addresses/"
+)
+
+type NetBoxOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	Token    string
+}
+
+type NetBoxDeviceOptions struct {
+	Name string
+	Site string
+}
+
+type NetBoxPrefixesOptions struct {
+	Within string
+	Site   string
+	VRF    string
+	Status string
+	Tag    string
+	Limit  int
+}
+
+type NetBoxIPOptions struct {
+	Prefix      string
+	Address     string
+	Status      string
+	DNSName     string
+	Description string
+	Tags        []string
+}
+
+type NetBoxIP struct {
+	Address     string                   `json:"address,omitempty"`
+	Status      string                   `json:"status,omitempty"`
+	DNSName     string                   `json:"dns_name,omitempty"`
+	Description string                   `json:"description,omitempty"`
+	Tags        []map[string]interface{} `json:"tags,omitempty"`
+}
+
+type netboxList struct {
+	Count   int               `json:"count"`
+	Results []json.RawMessage `json:"results"`
+}
+
+type NetBox struct {
+	client  *http.Client
+	options NetBoxOptions
+}
+
+func (n *NetBox) request(opts NetBoxOptions, method, p string, params url.Values, data []byte) ([]byte, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return nil, errors.New("no URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	// NetBox redirects paths without trailing slash
+	u.Path = path.Join(u.Path, "/api", p) + "/"
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Token %s", opts.Token),
+		"Content-Type":  "application/json",
+		"Accept":        "application/json",
+	}
+	b, err := common.HttpRequestRawWithHeaders(n.client, method, u.String(), headers, data)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// one returns the only result of list, it's error if there are none or many
+func (n *NetBox) one(b []byte, kind, name string) ([]byte, error) {
+
+	var l netboxList
+	if err := json.Unmarshal(b, &l); err != nil {
+		return nil, err
+	}
+	switch len(l.Results) {
+	case 0:
+		return nil, fmt.Errorf("no %s %s", kind, name)
+	case 1:
+		return l.Results[0], nil
+	default:
+		return nil, fmt.Errorf("%s %s is ambiguous, %d found", kind, name, l.Count)
+	}
+}
+
+// https://demo.netbox.dev/api/docs/#/dcim/dcim_devices_list
+// device is found by exact name, site narrows it if names are unique per site
+
+func (n *NetBox) CustomGetDevice(netboxOptions NetBoxOptions, deviceOptions NetBoxDeviceOptions) ([]byte, error) {
+
+	if utils.IsEmpty(deviceOptions.Name) {
+		return nil, errors.New("no device name")
+	}
+	params := url.Values{}
+	params.Set("name", deviceOptions.Name)
+	if !utils.IsEmpty(deviceOptions.Site) {
+		params.Set("site", deviceOptions.Site)
+	}
+	b, err := n.request(netboxOptions, "GET", netboxDevices, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	return n.one(b, "device", deviceOptions.Name)
+}
+
+func (n *NetBox) GetDevice(deviceOptions NetBoxDeviceOptions) ([]byte, error) {
+	return n.CustomGetDevice(n.options, deviceOptions)
+}
+
+// https://demo.netbox.dev/api/docs/#/ipam/ipam_prefixes_list
+
+func (n *NetBox) CustomGetPrefixes(netboxOptions NetBoxOptions, prefixesOptions NetBoxPrefixesOptions) ([]byte, error) {
+
+	params := url.Values{}
+	if !utils.IsEmpty(prefixesOptions.Within) {
+		params.Set("within_include", prefixesOptions.Within)
+	}
+	if !utils.IsEmpty(prefixesOptions.Site) {
+		params.Set("site", prefixesOptions.Site)
+	}
+	if !utils.IsEmpty(prefixesOptions.VRF) {
+		params.Set("vrf", prefixesOptions.VRF)
+	}
+	if !utils.IsEmpty(prefixesOptions.Status) {
+		params.Set("status", prefixesOptions.Status)
+	}
+	if !utils.IsEmpty(prefixesOptions.Tag) {
+		params.Set("tag", prefixesOptions.Tag)
+	}
+	if prefixesOptions.Limit > 0 {
+		params.Set("limit", strconv.Itoa(prefixesOptions.Limit))
+	}
+	return n.request(netboxOptions, "GET", netboxPrefixes, params, nil)
+}
+
+func (n *NetBox) GetPrefixes(prefixesOptions NetBoxPrefixesOptions) ([]byte, error) {
+	return n.CustomGetPrefixes(n.options, prefixesOptions)
+}
+
+// https://demo.netbox.dev/api/docs/#/ipam/ipam_prefixes_available_ips_create
+// https://demo.netbox.dev/api/docs/#/ipam/ipam_ip_addresses_create
+// address is reserved as is if it's set, otherwise the next available address of prefix is reserved
+
+func (n *NetBox) CustomReserveIP(netboxOptions NetBoxOptions, ipOptions NetBoxIPOptions) ([]byte, error) {
+
+	ip := &NetBoxIP{
+		Address:     ipOptions.Address,
+		Status:      ipOptions.Status,
+		DNSName:     ipOptions.DNSName,
+		Description: ipOptions.Description,
+	}
+	if utils.IsEmpty(ip.Status) {
+		ip.Status = "reserved"
+	}
+	for _, t := range common.RemoveEmptyStrings(ipOptions.Tags) {
+		ip.Tags = append(ip.Tags, map[string]interface{}{"name": t})
+	}
+	data, err := json.Marshal(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.IsEmpty(ipOptions.Address) {
+		return n.request(netboxOptions, "POST", netboxIPAddresses, nil, data)
+	}
+	if utils.IsEmpty(ipOptions.Prefix) {
+		return nil, errors.New("no prefix or address")
+	}
+
+	params := url.Values{}
+	params.Set("prefix", ipOptions.Prefix)
+	b, err := n.request(netboxOptions, "GET", netboxPrefixes, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	b, err = n.one(b, "prefix", ipOptions.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	var prefix struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(b, &prefix); err != nil {
+		return nil, err
+	}
+	return n.request(netboxOptions, "POST", path.Join(netboxPrefixes, strconv.Itoa(prefix.ID), "available-ips"), nil, data)
+}
+
+func (n *NetBox) ReserveIP(ipOptions NetBoxIPOptions) ([]byte, error) {
+	return n.CustomReserveIP(n.options, ipOptions)
+}
+
+func (n *NetBox) WithContext(ctx context.Context) *NetBox {
+	c := *n
+	c.client = common.HttpClientContext(n.client, ctx)
+	return &c
+}
+
+func NewNetBox(options NetBoxOptions) *NetBox {
+
+	return &NetBox{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
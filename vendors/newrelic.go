@@ -0,0 +1,297 @@
+package vendors
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const newRelicURL = "https://api.newrelic.com/graphql"
+
+type NewRelicOptions struct {
+	Timeout   int
+	Insecure  bool
+	URL       string
+	APIKey    string
+	AccountID int
+}
+
+type NewRelicDeploymentOptions struct {
+	EntityGUID  string
+	Version     string
+	Changelog   string
+	Commit      string
+	Description string
+	User        string
+	DeepLink    string
+	Type        string
+	GroupID     string
+}
+
+type NewRelicNRQLOptions struct {
+	Query   string
+	Format  string
+	Timeout int
+}
+
+type NewRelicDeployment struct {
+	EntityGUID     string `json:"entityGuid"`
+	Version        string `json:"version"`
+	Changelog      string `json:"changelog,omitempty"`
+	Commit         string `json:"commit,omitempty"`
+	Description    string `json:"description,omitempty"`
+	User           string `json:"user,omitempty"`
+	DeepLink       string `json:"deepLink,omitempty"`
+	DeploymentType string `json:"deploymentType,omitempty"`
+	GroupID        string `json:"groupId,omitempty"`
+	Timestamp      int64  `json:"timestamp"`
+}
+
+type NewRelicGraphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type NewRelicGraphQLError struct {
+	Message string `json:"message"`
+}
+
+type NewRelicGraphQLResponse struct {
+	Data   json.RawMessage         `json:"data"`
+	Errors []*NewRelicGraphQLError `json:"errors,omitempty"`
+}
+
+type NewRelic struct {
+	client  *http.Client
+	options NewRelicOptions
+}
+
+const newRelicDeploymentMutation = `mutation($deployment: ChangeTrackingDeploymentInput!) {
+  changeTrackingCreateDeployment(deployment: $deployment) {
+    deploymentId
+    entityGuid
+    version
+    timestamp
+  }
+}`
+
+const newRelicNRQLQuery = `query($accountId: Int!, $nrql: Nrql!, $timeout: Seconds) {
+  actor {
+    account(id: $accountId) {
+      nrql(query: $nrql, timeout: $timeout) {
+        results
+      }
+    }
+  }
+}`
+
+// graphql returns data of response, errors are returned with 200 status so they are checked explicitly,
+// client is of audit skip for queries, as mutations are sent by POST as well
+func (nr *NewRelic) graphql(client *http.Client, opts NewRelicOptions, query string, variables map[string]interface{}) ([]byte, error) {
+
+	if utils.IsEmpty(opts.APIKey) {
+		return nil, errors.New("no API key")
+	}
+
+	u := opts.URL
+	if utils.IsEmpty(u) {
+		u = newRelicURL
+	}
+
+	data, err := json.Marshal(&NewRelicGraphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string)
+	headers["Content-Type"] = "application/json"
+	headers["API-Key"] = opts.APIKey
+
+	b, err := common.HttpRequestRawWithHeaders(client, "POST", u, headers, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var r NewRelicGraphQLResponse
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	if len(r.Errors) > 0 {
+		msgs := []string{}
+		for _, e := range r.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return nil, errors.New(strings.Join(msgs, "; "))
+	}
+	return r.Data, nil
+}
+
+// https://docs.newrelic.com/docs/change-tracking/change-tracking-graphql/
+
+func (nr *NewRelic) CustomCreateDeployment(newRelicOptions NewRelicOptions, deploymentOptions NewRelicDeploymentOptions) ([]byte, error) {
+
+	if utils.IsEmpty(deploymentOptions.EntityGUID) {
+		return nil, errors.New("no entity GUID")
+	}
+	if utils.IsEmpty(deploymentOptions.Version) {
+		return nil, errors.New("no version")
+	}
+
+	deployment := &NewRelicDeployment{
+		EntityGUID:     deploymentOptions.EntityGUID,
+		Version:        deploymentOptions.Version,
+		Changelog:      deploymentOptions.Changelog,
+		Commit:         deploymentOptions.Commit,
+		Description:    deploymentOptions.Description,
+		User:           deploymentOptions.User,
+		DeepLink:       deploymentOptions.DeepLink,
+		DeploymentType: strings.ToUpper(deploymentOptions.Type),
+		GroupID:        deploymentOptions.GroupID,
+		Timestamp:      time.Now().UnixMilli(),
+	}
+
+	b, err := nr.graphql(nr.client, newRelicOptions, newRelicDeploymentMutation, map[string]interface{}{"deployment": deployment})
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Deployment json.RawMessage `json:"changeTrackingCreateDeployment"`
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	return r.Deployment, nil
+}
+
+func (nr *NewRelic) CreateDeployment(deploymentOptions NewRelicDeploymentOptions) ([]byte, error) {
+	return nr.CustomCreateDeployment(nr.options, deploymentOptions)
+}
+
+// newRelicCSV converts NRQL results to CSV, columns are union of result keys sorted by name
+func newRelicCSV(results []map[string]interface{}) ([]byte, error) {
+
+	columns := []string{}
+	seen := make(map[string]bool)
+	for _, r := range results {
+		for k := range r {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+	}
+	sort.Strings(columns)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		row := make([]string, len(columns))
+		for i, c := range columns {
+			switch v := r[c].(type) {
+			case nil:
+			case string:
+				row[i] = v
+			case float64:
+				row[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			case bool:
+				row[i] = strconv.FormatBool(v)
+			default:
+				b, err := json.Marshal(v)
+				if err != nil {
+					return nil, err
+				}
+				row[i] = string(b)
+			}
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// https://docs.newrelic.com/docs/apis/nerdgraph/examples/nerdgraph-nrql-tutorial/
+// results are returned as JSON array, or as CSV if format is csv
+
+func (nr *NewRelic) CustomQueryNRQL(newRelicOptions NewRelicOptions, nrqlOptions NewRelicNRQLOptions) ([]byte, error) {
+
+	if utils.IsEmpty(nrqlOptions.Query) {
+		return nil, errors.New("no NRQL query")
+	}
+	if newRelicOptions.AccountID <= 0 {
+		return nil, errors.New("no account ID")
+	}
+	format := strings.ToLower(nrqlOptions.Format)
+	if !utils.IsEmpty(format) && format != "json" && format != "csv" {
+		return nil, fmt.Errorf("unsupported format %s", nrqlOptions.Format)
+	}
+
+	variables := map[string]interface{}{
+		"accountId": newRelicOptions.AccountID,
+		"nrql":      nrqlOptions.Query,
+	}
+	if nrqlOptions.Timeout > 0 {
+		variables["timeout"] = nrqlOptions.Timeout
+	}
+
+	b, err := nr.graphql(common.HttpClientAuditSkip(nr.client), newRelicOptions, newRelicNRQLQuery, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Actor struct {
+			Account struct {
+				NRQL struct {
+					Results []map[string]interface{} `json:"results"`
+				} `json:"nrql"`
+			} `json:"account"`
+		} `json:"actor"`
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	results := r.Actor.Account.NRQL.Results
+	if results == nil {
+		results = []map[string]interface{}{}
+	}
+
+	if format == "csv" {
+		return newRelicCSV(results)
+	}
+	return json.Marshal(results)
+}
+
+func (nr *NewRelic) QueryNRQL(nrqlOptions NewRelicNRQLOptions) ([]byte, error) {
+	return nr.CustomQueryNRQL(nr.options, nrqlOptions)
+}
+
+func (nr *NewRelic) WithContext(ctx context.Context) *NewRelic {
+	c := *nr
+	c.client = common.HttpClientContext(nr.client, ctx)
+	return &c
+}
+
+func NewNewRelic(options NewRelicOptions) *NewRelic {
+
+	return &NewRelic{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
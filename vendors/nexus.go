@@ -0,0 +1,332 @@
+package vendors
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+type NexusOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	User     string
+	Password string
+}
+
+type nexusAsset struct {
+	Repository  string            `json:"repository"`
+	Path        string            `json:"path"`
+	DownloadURL string            `json:"downloadUrl"`
+	FileSize    int64             `json:"fileSize"`
+	Checksum    map[string]string `json:"checksum"`
+}
+
+type nexusAssets struct {
+	Items             []*nexusAsset `json:"items"`
+	ContinuationToken string        `json:"continuationToken"`
+}
+
+type nexusTag struct {
+	Name       string            `json:"name,omitempty"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+type Nexus struct {
+	client  *http.Client
+	options NexusOptions
+}
+
+func (n *Nexus) headers(opts NexusOptions) map[string]string {
+
+	headers := make(map[string]string)
+	if !utils.IsEmpty(opts.User) {
+		auth := base64.StdEncoding.EncodeToString([]byte(opts.User + ":" + opts.Password))
+		headers["Authorization"] = fmt.Sprintf("Basic %s", auth)
+	}
+	return headers
+}
+
+func (n *Nexus) url(opts NexusOptions, elem ...string) (string, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return "", errors.New("no URL")
+	}
+	segments := []string{strings.TrimSuffix(opts.URL, "/")}
+	for _, e := range elem {
+		for _, s := range strings.Split(strings.Trim(e, "/"), "/") {
+			segments = append(segments, url.PathEscape(s))
+		}
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+func (n *Nexus) jsonHeaders(opts NexusOptions) map[string]string {
+
+	headers := n.headers(opts)
+	headers["Content-Type"] = "application/json"
+	headers["Accept"] = "application/json"
+	return headers
+}
+
+// https://help.sonatype.com/en/raw-repositories.html
+// file is put to path of hosted repository, Nexus has no properties of artifacts
+
+func (n *Nexus) CustomUpload(nexusOptions NexusOptions, uploadOptions ArtifactUploadOptions) ([]byte, error) {
+
+	if utils.IsEmpty(uploadOptions.File) {
+		return nil, errors.New("no file")
+	}
+	if len(common.RemoveEmptyStrings(uploadOptions.Properties)) > 0 {
+		return nil, errors.New("Nexus has no properties, set tag of artifact instead")
+	}
+	p := uploadOptions.Path
+	if utils.IsEmpty(p) {
+		p = filepath.Base(uploadOptions.File)
+	}
+	p, err := artifactPath(uploadOptions.Repository, p)
+	if err != nil {
+		return nil, err
+	}
+	u, err := n.url(nexusOptions, "repository", uploadOptions.Repository, p)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := artifactUpload(n.client, u, n.headers(nexusOptions), uploadOptions.File)
+	if err != nil {
+		return nil, err
+	}
+	r.Repository = uploadOptions.Repository
+	r.Path = p
+	return json.Marshal(r)
+}
+
+func (n *Nexus) Upload(uploadOptions ArtifactUploadOptions) ([]byte, error) {
+	return n.CustomUpload(n.options, uploadOptions)
+}
+
+// file is base name of path if it's empty
+
+func (n *Nexus) CustomDownload(nexusOptions NexusOptions, downloadOptions ArtifactDownloadOptions) ([]byte, error) {
+
+	p, err := artifactPath(downloadOptions.Repository, downloadOptions.Path)
+	if err != nil {
+		return nil, err
+	}
+	u, err := n.url(nexusOptions, "repository", downloadOptions.Repository, p)
+	if err != nil {
+		return nil, err
+	}
+	file := downloadOptions.File
+	if utils.IsEmpty(file) {
+		file = filepath.Base(p)
+	}
+
+	r, err := artifactDownload(n.client, u, n.headers(nexusOptions), file)
+	if err != nil {
+		return nil, err
+	}
+	r.Repository = downloadOptions.Repository
+	r.Path = p
+	return json.Marshal(r)
+}
+
+func (n *Nexus) Download(downloadOptions ArtifactDownloadOptions) ([]byte, error) {
+	return n.CustomDownload(n.options, downloadOptions)
+}
+
+// https://help.sonatype.com/en/search-api.html
+// assets are searched by checksum or Maven coordinates, pages are read by continuation token
+
+func (n *Nexus) CustomSearch(nexusOptions NexusOptions, searchOptions ArtifactSearchOptions) ([]byte, error) {
+
+	params := make(url.Values)
+	if !utils.IsEmpty(searchOptions.Checksum) {
+		t, err := artifactChecksumType(searchOptions.Checksum)
+		if err != nil {
+			return nil, err
+		}
+		params.Set(t, strings.ToLower(searchOptions.Checksum))
+	} else {
+		if utils.IsEmpty(searchOptions.Group) && utils.IsEmpty(searchOptions.Artifact) {
+			return nil, errors.New("no checksum and no group or artifact")
+		}
+		for k, v := range map[string]string{"maven.groupId": searchOptions.Group, "maven.artifactId": searchOptions.Artifact,
+			"maven.baseVersion": searchOptions.Version, "maven.classifier": searchOptions.Classifier} {
+			if !utils.IsEmpty(v) {
+				params.Set(k, v)
+			}
+		}
+	}
+	if !utils.IsEmpty(searchOptions.Repository) {
+		params.Set("repository", searchOptions.Repository)
+	}
+	u, err := n.url(nexusOptions, "service", "rest", "v1", "search", "assets")
+	if err != nil {
+		return nil, err
+	}
+
+	r := []*Artifact{}
+	for {
+		b, err := artifactRequestBytes(n.client, "GET", u+"?"+params.Encode(), n.jsonHeaders(nexusOptions), nil)
+		if err != nil {
+			return nil, err
+		}
+		var assets nexusAssets
+		if err := json.Unmarshal(b, &assets); err != nil {
+			return nil, err
+		}
+		for _, a := range assets.Items {
+			r = append(r, &Artifact{
+				Repository: a.Repository,
+				Path:       strings.TrimPrefix(a.Path, "/"),
+				URL:        a.DownloadURL,
+				Size:       a.FileSize,
+				Checksums:  a.Checksum,
+			})
+		}
+		if utils.IsEmpty(assets.ContinuationToken) {
+			break
+		}
+		params.Set("continuationToken", assets.ContinuationToken)
+	}
+	return json.Marshal(r)
+}
+
+func (n *Nexus) Search(searchOptions ArtifactSearchOptions) ([]byte, error) {
+	return n.CustomSearch(n.options, searchOptions)
+}
+
+// tagExists is true if tag is found, tags are of Nexus Pro
+func (n *Nexus) tagExists(nexusOptions NexusOptions, u string) (bool, error) {
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return false, err
+	}
+	for k, v := range n.jsonHeaders(nexusOptions) {
+		req.Header.Set(k, v)
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return false, common.NewAPIError(resp.StatusCode, nil)
+	}
+	return true, nil
+}
+
+// https://help.sonatype.com/en/tagging.html
+// Nexus has no properties, so they're attributes of tag, which is created or updated, and associated
+// with component having asset of path, asset is found by its SHA-1
+
+func (n *Nexus) CustomSetProperties(nexusOptions NexusOptions, propertiesOptions ArtifactPropertiesOptions) ([]byte, error) {
+
+	p, err := artifactPath(propertiesOptions.Repository, propertiesOptions.Path)
+	if err != nil {
+		return nil, err
+	}
+	if utils.IsEmpty(propertiesOptions.Tag) {
+		return nil, errors.New("no tag, Nexus has properties as attributes of tag")
+	}
+	if propertiesOptions.Recursive {
+		return nil, errors.New("Nexus tags can't be set recursively")
+	}
+	properties, err := artifactProperties(propertiesOptions.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	download, err := n.url(nexusOptions, "repository", propertiesOptions.Repository, p)
+	if err != nil {
+		return nil, err
+	}
+	b, err := artifactRequestBytes(n.client, "GET", download+".sha1", n.headers(nexusOptions), nil)
+	if err != nil {
+		return nil, fmt.Errorf("SHA-1 of %s: %s", p, err)
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("SHA-1 of %s is empty", p)
+	}
+	sha1 := strings.ToLower(fields[0])
+
+	tags, err := n.url(nexusOptions, "service", "rest", "v1", "tags")
+	if err != nil {
+		return nil, err
+	}
+	exists, err := n.tagExists(nexusOptions, tags+"/"+url.PathEscape(propertiesOptions.Tag))
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		data, err := json.Marshal(&nexusTag{Attributes: properties})
+		if err != nil {
+			return nil, err
+		}
+		_, err = artifactRequestBytes(n.client, "PUT", tags+"/"+url.PathEscape(propertiesOptions.Tag), n.jsonHeaders(nexusOptions), data)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		data, err := json.Marshal(&nexusTag{Name: propertiesOptions.Tag, Attributes: properties})
+		if err != nil {
+			return nil, err
+		}
+		_, err = artifactRequestBytes(n.client, "POST", tags, n.jsonHeaders(nexusOptions), data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	params := make(url.Values)
+	params.Set("repository", propertiesOptions.Repository)
+	params.Set("sha1", sha1)
+	associate := tags + "/associate/" + url.PathEscape(propertiesOptions.Tag) + "?" + params.Encode()
+	if _, err := artifactRequestBytes(n.client, "POST", associate, n.jsonHeaders(nexusOptions), nil); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&Artifact{
+		Repository: propertiesOptions.Repository,
+		Path:       p,
+		URL:        download,
+		Checksums:  map[string]string{"sha1": sha1},
+		Properties: properties,
+	})
+}
+
+func (n *Nexus) SetProperties(propertiesOptions ArtifactPropertiesOptions) ([]byte, error) {
+	return n.CustomSetProperties(n.options, propertiesOptions)
+}
+
+func (n *Nexus) WithContext(ctx context.Context) *Nexus {
+	c := *n
+	c.client = common.HttpClientContext(n.client, ctx)
+	return &c
+}
+
+func NewNexus(options NexusOptions) *Nexus {
+
+	nexus := &Nexus{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+	return nexus
+}
@@ -1,12 +1,14 @@
 package vendors
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/url"
 	"path"
 
+	"github.com/devopsext/tools/common"
 	"github.com/devopsext/utils"
 )
 
@@ -48,17 +50,23 @@ func (o *Observium) CustomGetDevices(options ObserviumOptions) ([]byte, error) {
 
 	u.Path = path.Join(u.Path, "/api/v0/devices/")
 
-	return utils.HttpGetRaw(o.client, u.String(), "application/json", o.getAuth(options))
+	return common.HttpGetRaw(o.client, u.String(), "application/json", o.getAuth(options))
 }
 
 func (o *Observium) GetDevices() ([]byte, error) {
 	return o.CustomGetDevices(o.options)
 }
 
+func (o *Observium) WithContext(ctx context.Context) *Observium {
+	c := *o
+	c.client = common.HttpClientContext(o.client, ctx)
+	return &c
+}
+
 func NewObservium(options ObserviumOptions) *Observium {
 
 	return &Observium{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 	}
 }
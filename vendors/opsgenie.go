@@ -0,0 +1,253 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const opsgenieURL = "https://api.opsgenie.com"
+
+type OpsgenieOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	Key      string
+}
+
+type OpsgenieAlertOptions struct {
+	Message     string
+	Alias       string
+	Description string
+	Responders  []string
+	Tags        []string
+	Priority    string
+	Entity      string
+	Source      string
+	Details     string
+}
+
+type OpsgenieAlertActionOptions struct {
+	Identifier     string
+	IdentifierType string
+	User           string
+	Source         string
+	Note           string
+}
+
+type OpsgenieOnCallsOptions struct {
+	Schedule     string
+	ScheduleType string
+	Flat         bool
+	Date         string
+}
+
+type OpsgenieResponder struct {
+	Type     string `json:"type"`
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+type OpsgenieAlert struct {
+	Message     string               `json:"message"`
+	Alias       string               `json:"alias,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Responders  []*OpsgenieResponder `json:"responders,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Priority    string               `json:"priority,omitempty"`
+	Entity      string               `json:"entity,omitempty"`
+	Source      string               `json:"source,omitempty"`
+	Details     map[string]string    `json:"details,omitempty"`
+}
+
+type OpsgenieAlertAction struct {
+	User   string `json:"user,omitempty"`
+	Source string `json:"source,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+type Opsgenie struct {
+	client  *http.Client
+	options OpsgenieOptions
+}
+
+func (o *Opsgenie) request(opts OpsgenieOptions, method, p string, params url.Values, data []byte) ([]byte, error) {
+
+	base := opts.URL
+	if utils.IsEmpty(base) {
+		base = opsgenieURL
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	// identifiers of path are escaped already, e.g. aliases having slashes
+	u.RawPath = path.Join(u.EscapedPath(), p)
+	u.Path, err = url.PathUnescape(u.RawPath)
+	if err != nil {
+		return nil, err
+	}
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = fmt.Sprintf("GenieKey %s", opts.Key)
+	headers["Content-Type"] = "application/json"
+
+	return common.HttpRequestRawWithHeaders(o.client, method, u.String(), headers, data)
+}
+
+// responder is type:value, where type is team, user, escalation or schedule; user is by username, others by name
+func (o *Opsgenie) parseResponder(s string) (*OpsgenieResponder, error) {
+
+	arr := strings.SplitN(s, ":", 2)
+	if len(arr) != 2 {
+		return nil, fmt.Errorf("invalid responder %s, expected type:value", s)
+	}
+	r := &OpsgenieResponder{Type: strings.TrimSpace(arr[0])}
+	value := strings.TrimSpace(arr[1])
+	if r.Type == "user" {
+		r.Username = value
+	} else {
+		r.Name = value
+	}
+	return r, nil
+}
+
+// https://docs.opsgenie.com/docs/alert-api#create-alert
+
+func (o *Opsgenie) CustomCreateAlert(opsgenieOptions OpsgenieOptions, alertOptions OpsgenieAlertOptions) ([]byte, error) {
+
+	if utils.IsEmpty(alertOptions.Message) {
+		return nil, errors.New("no alert message")
+	}
+
+	alert := &OpsgenieAlert{
+		Message:     common.TruncateString(alertOptions.Message, 130),
+		Alias:       alertOptions.Alias,
+		Description: alertOptions.Description,
+		Tags:        common.RemoveEmptyStrings(alertOptions.Tags),
+		Priority:    alertOptions.Priority,
+		Entity:      alertOptions.Entity,
+		Source:      alertOptions.Source,
+	}
+	for _, s := range common.RemoveEmptyStrings(alertOptions.Responders) {
+		r, err := o.parseResponder(s)
+		if err != nil {
+			return nil, err
+		}
+		alert.Responders = append(alert.Responders, r)
+	}
+	if !utils.IsEmpty(alertOptions.Details) {
+		alert.Details = utils.MapGetKeyValues(alertOptions.Details)
+	}
+
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return nil, err
+	}
+	return o.request(opsgenieOptions, "POST", "/v2/alerts", nil, data)
+}
+
+func (o *Opsgenie) CreateAlert(alertOptions OpsgenieAlertOptions) ([]byte, error) {
+	return o.CustomCreateAlert(o.options, alertOptions)
+}
+
+func (o *Opsgenie) alertAction(opsgenieOptions OpsgenieOptions, actionOptions OpsgenieAlertActionOptions, action string) ([]byte, error) {
+
+	if utils.IsEmpty(actionOptions.Identifier) {
+		return nil, errors.New("no alert identifier")
+	}
+
+	params := make(url.Values)
+	identifierType := actionOptions.IdentifierType
+	if utils.IsEmpty(identifierType) {
+		identifierType = "alias"
+	}
+	params.Add("identifierType", identifierType)
+
+	data, err := json.Marshal(&OpsgenieAlertAction{
+		User:   actionOptions.User,
+		Source: actionOptions.Source,
+		Note:   actionOptions.Note,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return o.request(opsgenieOptions, "POST", fmt.Sprintf("/v2/alerts/%s/%s", url.PathEscape(actionOptions.Identifier), action), params, data)
+}
+
+// https://docs.opsgenie.com/docs/alert-api#close-alert
+
+func (o *Opsgenie) CustomCloseAlert(opsgenieOptions OpsgenieOptions, actionOptions OpsgenieAlertActionOptions) ([]byte, error) {
+	return o.alertAction(opsgenieOptions, actionOptions, "close")
+}
+
+func (o *Opsgenie) CloseAlert(actionOptions OpsgenieAlertActionOptions) ([]byte, error) {
+	return o.CustomCloseAlert(o.options, actionOptions)
+}
+
+// https://docs.opsgenie.com/docs/alert-api#add-note-to-alert
+
+func (o *Opsgenie) CustomAddNote(opsgenieOptions OpsgenieOptions, actionOptions OpsgenieAlertActionOptions) ([]byte, error) {
+
+	if utils.IsEmpty(actionOptions.Note) {
+		return nil, errors.New("no note")
+	}
+	return o.alertAction(opsgenieOptions, actionOptions, "notes")
+}
+
+func (o *Opsgenie) AddNote(actionOptions OpsgenieAlertActionOptions) ([]byte, error) {
+	return o.CustomAddNote(o.options, actionOptions)
+}
+
+// https://docs.opsgenie.com/docs/who-is-on-call-api#get-on-calls
+
+func (o *Opsgenie) CustomGetOnCalls(opsgenieOptions OpsgenieOptions, onCallsOptions OpsgenieOnCallsOptions) ([]byte, error) {
+
+	if utils.IsEmpty(onCallsOptions.Schedule) {
+		return nil, errors.New("no schedule")
+	}
+
+	params := make(url.Values)
+	scheduleType := onCallsOptions.ScheduleType
+	if utils.IsEmpty(scheduleType) {
+		scheduleType = "name"
+	}
+	params.Add("scheduleIdentifierType", scheduleType)
+	if onCallsOptions.Flat {
+		params.Add("flat", "true")
+	}
+	if !utils.IsEmpty(onCallsOptions.Date) {
+		params.Add("date", onCallsOptions.Date)
+	}
+	return o.request(opsgenieOptions, "GET", fmt.Sprintf("/v2/schedules/%s/on-calls", url.PathEscape(onCallsOptions.Schedule)), params, nil)
+}
+
+func (o *Opsgenie) GetOnCalls(onCallsOptions OpsgenieOnCallsOptions) ([]byte, error) {
+	return o.CustomGetOnCalls(o.options, onCallsOptions)
+}
+
+func (o *Opsgenie) WithContext(ctx context.Context) *Opsgenie {
+	c := *o
+	c.client = common.HttpClientContext(o.client, ctx)
+	return &c
+}
+
+func NewOpsgenie(options OpsgenieOptions) *Opsgenie {
+
+	return &Opsgenie{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
@@ -1,7 +1,9 @@
 package vendors
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -32,6 +34,27 @@ type PagerDutyGetIncidentsOptions struct {
 	Limit int
 }
 
+type PagerDutyEventOptions struct {
+	RoutingKey    string
+	Action        string
+	DedupKey      string
+	Summary       string
+	Source        string
+	Severity      string
+	Component     string
+	Group         string
+	Class         string
+	CustomDetails string
+}
+
+type PagerDutyOnCallsOptions struct {
+	EscalationPolicyIDs []string
+	ScheduleIDs         []string
+	Since               string
+	Until               string
+	Earliest            bool
+}
+
 type PagerDutyService struct {
 	Type string `json:"type"`
 	ID   string `json:"id"`
@@ -68,11 +91,29 @@ type PagerDutyIncidentNoteRequest struct {
 	Note *PagerDutyIncidentNote `json:"note"`
 }
 
+type PagerDutyEventPayload struct {
+	Summary       string      `json:"summary"`
+	Source        string      `json:"source"`
+	Severity      string      `json:"severity"`
+	Component     string      `json:"component,omitempty"`
+	Group         string      `json:"group,omitempty"`
+	Class         string      `json:"class,omitempty"`
+	CustomDetails interface{} `json:"custom_details,omitempty"`
+}
+
+type PagerDutyEvent struct {
+	RoutingKey  string                 `json:"routing_key"`
+	EventAction string                 `json:"event_action"`
+	DedupKey    string                 `json:"dedup_key,omitempty"`
+	Payload     *PagerDutyEventPayload `json:"payload,omitempty"`
+}
+
 type PagerDutyOptions struct {
-	Timeout  int
-	Insecure bool
-	URL      string
-	Token    string
+	Timeout   int
+	Insecure  bool
+	URL       string
+	Token     string
+	EventsURL string
 }
 
 type PagerDuty struct {
@@ -85,6 +126,9 @@ const (
 	pagerDutyContentType       = "application/json"
 	pagerDutyIncidentsPath     = "/incidents"
 	pagerDutyIncidentNotesPath = "/notes"
+	pagerDutyOnCallsPath       = "/oncalls"
+	pagerDutyEventsPath        = "/v2/enqueue"
+	pagerDutyEventsURL         = "https://events.pagerduty.com"
 )
 
 func (pd *PagerDuty) getAuth(options PagerDutyOptions) string {
@@ -146,7 +190,7 @@ func (pd *PagerDuty) CustomCreateIncident(options PagerDutyOptions, incidentOpti
 		return nil, err
 	}
 
-	return utils.HttpPostRaw(pd.client, u.String(), pagerDutyContentType, pd.getAuth(options), data)
+	return common.HttpPostRaw(pd.client, u.String(), pagerDutyContentType, pd.getAuth(options), data)
 }
 
 func (pd *PagerDuty) CreateIncident(incidentOptions PagerDutyIncidentOptions, createOptions PagerDutyCreateIncidentOptions) ([]byte, error) {
@@ -180,7 +224,7 @@ func (pd *PagerDuty) CustomCreateIncidentNote(options PagerDutyOptions, noteOpti
 		return nil, err
 	}
 
-	return utils.HttpPostRaw(pd.client, u.String(), pagerDutyContentType, pd.getAuth(options), data)
+	return common.HttpPostRaw(pd.client, u.String(), pagerDutyContentType, pd.getAuth(options), data)
 }
 
 func (pd *PagerDuty) CreateIncidentNote(noteOptions PagerDutyIncidentNoteOptions, createOptions PagerDutyCreateIncidentOptions) ([]byte, error) {
@@ -204,16 +248,125 @@ func (pd *PagerDuty) CustomGetIncidents(options PagerDutyOptions, getOptions Pag
 	u.RawQuery = params.Encode()
 	u.Path = path.Join(u.Path, pagerDutyIncidentsPath)
 
-	return utils.HttpGetRaw(pd.client, u.String(), pagerDutyContentType, pd.getAuth(options))
+	return common.HttpGetRaw(pd.client, u.String(), pagerDutyContentType, pd.getAuth(options))
 }
 func (pd *PagerDuty) GetIncidents(getOptions PagerDutyGetIncidentsOptions) ([]byte, error) {
 	return pd.CustomGetIncidents(pd.options, getOptions)
 }
 
+// https://developer.pagerduty.com/docs/events-api-v2/trigger-events/
+// https://developer.pagerduty.com/docs/events-api-v2/acknowledge-events/
+// https://developer.pagerduty.com/docs/events-api-v2/resolve-events/
+
+func (pd *PagerDuty) CustomSendEvent(options PagerDutyOptions, eventOptions PagerDutyEventOptions) ([]byte, error) {
+
+	eventsURL := options.EventsURL
+	if utils.IsEmpty(eventsURL) {
+		eventsURL = pagerDutyEventsURL
+	}
+	u, err := url.Parse(eventsURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, pagerDutyEventsPath)
+
+	if utils.IsEmpty(eventOptions.RoutingKey) {
+		return nil, errors.New("no routing key")
+	}
+
+	event := &PagerDutyEvent{
+		RoutingKey:  eventOptions.RoutingKey,
+		EventAction: eventOptions.Action,
+		DedupKey:    eventOptions.DedupKey,
+	}
+
+	switch eventOptions.Action {
+	case "trigger":
+		var details interface{}
+		if !utils.IsEmpty(eventOptions.CustomDetails) {
+			err = json.Unmarshal([]byte(eventOptions.CustomDetails), &details)
+			if err != nil {
+				details = eventOptions.CustomDetails
+			}
+		}
+		severity := eventOptions.Severity
+		if utils.IsEmpty(severity) {
+			severity = "error"
+		}
+		event.Payload = &PagerDutyEventPayload{
+			Summary:       eventOptions.Summary,
+			Source:        eventOptions.Source,
+			Severity:      severity,
+			Component:     eventOptions.Component,
+			Group:         eventOptions.Group,
+			Class:         eventOptions.Class,
+			CustomDetails: details,
+		}
+	case "acknowledge", "resolve":
+		if utils.IsEmpty(eventOptions.DedupKey) {
+			return nil, fmt.Errorf("no dedup key to %s", eventOptions.Action)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported event action %s", eventOptions.Action)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return common.HttpPostRaw(pd.client, u.String(), pagerDutyContentType, "", data)
+}
+
+func (pd *PagerDuty) SendEvent(eventOptions PagerDutyEventOptions) ([]byte, error) {
+	return pd.CustomSendEvent(pd.options, eventOptions)
+}
+
+// https://developer.pagerduty.com/api-reference/3a6b910f11050-list-all-of-the-on-calls
+
+func (pd *PagerDuty) CustomGetOnCalls(options PagerDutyOptions, onCallsOptions PagerDutyOnCallsOptions) ([]byte, error) {
+
+	u, err := url.Parse(options.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var params = make(url.Values)
+	for _, id := range common.RemoveEmptyStrings(onCallsOptions.EscalationPolicyIDs) {
+		params.Add("escalation_policy_ids[]", id)
+	}
+	for _, id := range common.RemoveEmptyStrings(onCallsOptions.ScheduleIDs) {
+		params.Add("schedule_ids[]", id)
+	}
+	if !utils.IsEmpty(onCallsOptions.Since) {
+		params.Add("since", onCallsOptions.Since)
+	}
+	if !utils.IsEmpty(onCallsOptions.Until) {
+		params.Add("until", onCallsOptions.Until)
+	}
+	if onCallsOptions.Earliest {
+		params.Add("earliest", "true")
+	}
+	params.Add("include[]", "users")
+	u.RawQuery = params.Encode()
+	u.Path = path.Join(u.Path, pagerDutyOnCallsPath)
+
+	return common.HttpGetRaw(pd.client, u.String(), pagerDutyContentType, pd.getAuth(options))
+}
+
+func (pd *PagerDuty) GetOnCalls(onCallsOptions PagerDutyOnCallsOptions) ([]byte, error) {
+	return pd.CustomGetOnCalls(pd.options, onCallsOptions)
+}
+
+func (pd *PagerDuty) WithContext(ctx context.Context) *PagerDuty {
+	c := *pd
+	c.client = common.HttpClientContext(pd.client, ctx)
+	return &c
+}
+
 func NewPagerDuty(options PagerDutyOptions, logger common.Logger) *PagerDuty {
 
 	return &PagerDuty{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 		logger:  logger,
 	}
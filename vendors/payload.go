@@ -0,0 +1,732 @@
+package vendors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/devopsext/utils"
+)
+
+// limits of vendor APIs, payloads exceeding them are rejected or truncated by vendors
+const (
+	slackTextLimit          = 40000
+	slackBlocksLimit        = 50
+	slackSectionTextLimit   = 3000
+	slackHeaderTextLimit    = 150
+	slackFieldsLimit        = 10
+	slackFieldTextLimit     = 2000
+	slackBlockIDLimit       = 255
+	slackContextLimit       = 10
+	slackActionsLimit       = 25
+	telegramTextLimit       = 4096
+	telegramCaptionLimit    = 1024
+	discordContentLimit     = 2000
+	discordEmbedsLimit      = 10
+	discordEmbedTitleLimit  = 256
+	discordEmbedDescLimit   = 4096
+	discordEmbedFieldsLimit = 25
+	discordEmbedsTotalLimit = 6000
+	googleSummaryLimit      = 1024
+	googleDescriptionLimit  = 8192
+)
+
+// payloadEllipsis ends truncated texts
+const payloadEllipsis = "…"
+
+// payloadFence is markdown code block fence, payloadFenceReserve is length reserved in parts to close and reopen it
+const (
+	payloadFence        = "```"
+	payloadFenceReserve = 32
+)
+
+// PayloadValidator returns issues of payload, which is rendered text or JSON of vendor message
+type PayloadValidator func(payload string) []string
+
+var payloadValidators = map[string]PayloadValidator{
+	"slack":    validateSlackPayload,
+	"telegram": validateTelegramPayload,
+	"discord":  validateDiscordPayload,
+}
+
+// telegramTags are tags of Telegram HTML parse mode, others are rejected
+var telegramTags = map[string]bool{
+	"b": true, "strong": true, "i": true, "em": true, "u": true, "ins": true, "s": true, "strike": true, "del": true,
+	"span": true, "tg-spoiler": true, "a": true, "code": true, "pre": true, "blockquote": true, "tg-emoji": true,
+}
+
+var telegramTagRegexp = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9-]*)[^>]*>`)
+
+// payloadTokenRegexp matches tags and entities, Telegram counts entity as one character
+var payloadTokenRegexp = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9-]*)[^>]*>|&(?:#[0-9]+|#x[0-9a-fA-F]+|[a-zA-Z]+);`)
+
+var googleVisibilities = []string{"default", "public", "private", "confidential"}
+var googleSendUpdates = []string{"all", "externalOnly", "none"}
+
+// PayloadVendors returns vendors having payload validators
+func PayloadVendors() []string {
+
+	r := []string{}
+	for k := range payloadValidators {
+		r = append(r, k)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// ValidatePayload returns issues of vendor payload, no issues mean that vendor accepts it as is
+func ValidatePayload(vendor, payload string) ([]string, error) {
+
+	v, ok := payloadValidators[vendor]
+	if !ok {
+		return nil, fmt.Errorf("no payload validator of %s, supported are %s", vendor, strings.Join(PayloadVendors(), ", "))
+	}
+	return v(payload), nil
+}
+
+func payloadLength(field, s string, limit int) []string {
+
+	if n := utf8.RuneCountInString(s); n > limit {
+		return []string{fmt.Sprintf("%s has %d characters, limit is %d", field, n, limit)}
+	}
+	return nil
+}
+
+// payloadTruncate truncates s to limit characters with ellipsis, it returns fix if s is truncated
+func payloadTruncate(field string, s *string, limit int) []string {
+
+	n := utf8.RuneCountInString(*s)
+	if n <= limit {
+		return nil
+	}
+	*s = string([]rune(*s)[:limit-utf8.RuneCountInString(payloadEllipsis)]) + payloadEllipsis
+	return []string{fmt.Sprintf("%s is truncated from %d to %d characters", field, n, limit)}
+}
+
+// payloadError joins issues which can't be fixed into one error
+func payloadError(issues []string) error {
+
+	if len(issues) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(issues, "; "))
+}
+
+func payloadContains(values []string, value string) bool {
+
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// payloadJson is true for JSON objects and arrays, other payloads are text
+func payloadJson(payload string) bool {
+
+	s := strings.TrimSpace(payload)
+	return (strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")) && json.Valid([]byte(s))
+}
+
+type slackText struct {
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string            `json:"type"`
+	BlockID  string            `json:"block_id"`
+	Text     *slackText        `json:"text"`
+	Fields   []*slackText      `json:"fields"`
+	Elements []json.RawMessage `json:"elements"`
+}
+
+// SlackPayload returns text and blocks of payload, which is text, blocks array, or object having them
+func SlackPayload(payload string) (string, string, error) {
+
+	if !payloadJson(payload) {
+		return payload, "", nil
+	}
+	s := strings.TrimSpace(payload)
+	if strings.HasPrefix(s, "[") {
+		return "", s, nil
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return "", "", err
+	}
+	text := ""
+	if t, ok := m["text"]; ok {
+		if err := json.Unmarshal(t, &text); err != nil {
+			return "", "", fmt.Errorf("text: %s", err)
+		}
+	}
+	blocks := ""
+	if b, ok := m["blocks"]; ok {
+		blocks = string(b)
+	}
+	return text, blocks, nil
+}
+
+func validateSlackPayload(payload string) []string {
+
+	text, blocks, err := SlackPayload(payload)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	issues := payloadLength("text", text, slackTextLimit)
+	if utils.IsEmpty(blocks) {
+		if utils.IsEmpty(strings.TrimSpace(text)) {
+			issues = append(issues, "message has no text and no blocks")
+		}
+		return issues
+	}
+
+	var bs []*slackBlock
+	if err := json.Unmarshal([]byte(blocks), &bs); err != nil {
+		return append(issues, fmt.Sprintf("blocks: %s", err))
+	}
+	if len(bs) > slackBlocksLimit {
+		issues = append(issues, fmt.Sprintf("message has %d blocks, limit is %d", len(bs), slackBlocksLimit))
+	}
+	if utils.IsEmpty(strings.TrimSpace(text)) {
+		issues = append(issues, "message has blocks but no text, notifications have no content then")
+	}
+	for i, b := range bs {
+		if b == nil {
+			issues = append(issues, fmt.Sprintf("block %d is null", i))
+			continue
+		}
+		field := fmt.Sprintf("block %d (%s)", i, b.Type)
+		issues = append(issues, payloadLength(field+" block_id", b.BlockID, slackBlockIDLimit)...)
+		switch b.Type {
+		case "section":
+			if b.Text == nil && len(b.Fields) == 0 {
+				issues = append(issues, fmt.Sprintf("%s has no text and no fields", field))
+			}
+			if b.Text != nil {
+				issues = append(issues, payloadLength(field+" text", b.Text.Text, slackSectionTextLimit)...)
+			}
+			if len(b.Fields) > slackFieldsLimit {
+				issues = append(issues, fmt.Sprintf("%s has %d fields, limit is %d", field, len(b.Fields), slackFieldsLimit))
+			}
+			for j, f := range b.Fields {
+				if f != nil {
+					issues = append(issues, payloadLength(fmt.Sprintf("%s field %d", field, j), f.Text, slackFieldTextLimit)...)
+				}
+			}
+		case "header":
+			if b.Text == nil {
+				issues = append(issues, fmt.Sprintf("%s has no text", field))
+				continue
+			}
+			issues = append(issues, payloadLength(field+" text", b.Text.Text, slackHeaderTextLimit)...)
+		case "context":
+			if len(b.Elements) > slackContextLimit {
+				issues = append(issues, fmt.Sprintf("%s has %d elements, limit is %d", field, len(b.Elements), slackContextLimit))
+			}
+		case "actions":
+			if len(b.Elements) > slackActionsLimit {
+				issues = append(issues, fmt.Sprintf("%s has %d elements, limit is %d", field, len(b.Elements), slackActionsLimit))
+			}
+		case "":
+			issues = append(issues, fmt.Sprintf("block %d has no type", i))
+		}
+	}
+	return issues
+}
+
+// validateTelegramPayload checks HTML parse mode, length is of text without tags as Telegram counts it
+func validateTelegramPayload(payload string) []string {
+
+	issues := []string{}
+	if utils.IsEmpty(strings.TrimSpace(payload)) {
+		return append(issues, "message is empty")
+	}
+
+	open := []string{}
+	for _, m := range telegramTagRegexp.FindAllStringSubmatch(payload, -1) {
+		tag := strings.ToLower(m[2])
+		if !telegramTags[tag] {
+			if m[1] == "" {
+				issues = append(issues, fmt.Sprintf("tag <%s> isn't supported by HTML parse mode", tag))
+			}
+			continue
+		}
+		if m[1] == "" {
+			open = append(open, tag)
+			continue
+		}
+		if len(open) == 0 || open[len(open)-1] != tag {
+			issues = append(issues, fmt.Sprintf("tag </%s> isn't opened", tag))
+			continue
+		}
+		open = open[:len(open)-1]
+	}
+	for _, tag := range open {
+		issues = append(issues, fmt.Sprintf("tag <%s> isn't closed", tag))
+	}
+
+	text := telegramTagRegexp.ReplaceAllString(payload, "")
+	return append(issues, payloadLength("text", text, telegramTextLimit)...)
+}
+
+type discordEmbedField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type discordEmbed struct {
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	Fields      []*discordEmbedField `json:"fields"`
+}
+
+type discordPayload struct {
+	Content string          `json:"content"`
+	Embeds  []*discordEmbed `json:"embeds"`
+}
+
+func validateDiscordPayload(payload string) []string {
+
+	p := &discordPayload{Content: payload}
+	if payloadJson(payload) && strings.HasPrefix(strings.TrimSpace(payload), "{") {
+		p = &discordPayload{}
+		if err := json.Unmarshal([]byte(payload), p); err != nil {
+			return []string{err.Error()}
+		}
+	}
+
+	issues := payloadLength("content", p.Content, discordContentLimit)
+	if utils.IsEmpty(strings.TrimSpace(p.Content)) && len(p.Embeds) == 0 {
+		issues = append(issues, "message has no content and no embeds")
+	}
+	if len(p.Embeds) > discordEmbedsLimit {
+		issues = append(issues, fmt.Sprintf("message has %d embeds, limit is %d", len(p.Embeds), discordEmbedsLimit))
+	}
+	total := 0
+	for i, e := range p.Embeds {
+		if e == nil {
+			continue
+		}
+		field := fmt.Sprintf("embed %d", i)
+		issues = append(issues, payloadLength(field+" title", e.Title, discordEmbedTitleLimit)...)
+		issues = append(issues, payloadLength(field+" description", e.Description, discordEmbedDescLimit)...)
+		if len(e.Fields) > discordEmbedFieldsLimit {
+			issues = append(issues, fmt.Sprintf("%s has %d fields, limit is %d", field, len(e.Fields), discordEmbedFieldsLimit))
+		}
+		total += utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description)
+		for _, f := range e.Fields {
+			if f != nil {
+				total += utf8.RuneCountInString(f.Name) + utf8.RuneCountInString(f.Value)
+			}
+		}
+	}
+	if total > discordEmbedsTotalLimit {
+		issues = append(issues, fmt.Sprintf("embeds have %d characters, limit is %d", total, discordEmbedsTotalLimit))
+	}
+	return issues
+}
+
+// slackFixText truncates text of text object of block, e.g. text of section
+func slackFixText(field string, obj interface{}, limit int) []string {
+
+	m, ok := obj.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	text, ok := m["text"].(string)
+	if !ok {
+		return nil
+	}
+	fixes := payloadTruncate(field, &text, limit)
+	m["text"] = text
+	return fixes
+}
+
+// FixSlackMessage truncates texts of message and its blocks to Slack limits, it returns fixed message
+// and what is fixed, it's error if message has limits exceeded which can't be fixed by truncation
+func FixSlackMessage(options SlackMessageOptions) (SlackMessageOptions, []string, error) {
+
+	fixes := payloadTruncate("text", &options.Text, slackTextLimit)
+	if utils.IsEmpty(options.Blocks) {
+		return options, fixes, nil
+	}
+
+	var blocks []map[string]interface{}
+	if err := json.Unmarshal([]byte(options.Blocks), &blocks); err != nil {
+		return options, fixes, fmt.Errorf("blocks aren't array of blocks: %s", err)
+	}
+
+	issues := []string{}
+	if len(blocks) > slackBlocksLimit {
+		issues = append(issues, fmt.Sprintf("message has %d blocks, limit is %d, split it into several messages", len(blocks), slackBlocksLimit))
+	}
+	blockFixes := []string{}
+	for i, b := range blocks {
+		if b == nil {
+			issues = append(issues, fmt.Sprintf("block %d is null, remove it", i))
+			continue
+		}
+		t, _ := b["type"].(string)
+		if utils.IsEmpty(t) {
+			issues = append(issues, fmt.Sprintf("block %d has no type, set it to section, header, context, etc.", i))
+			continue
+		}
+		field := fmt.Sprintf("block %d (%s)", i, t)
+		if id, ok := b["block_id"].(string); ok && utf8.RuneCountInString(id) > slackBlockIDLimit {
+			issues = append(issues, fmt.Sprintf("%s block_id has %d characters, limit is %d, shorten it", field, utf8.RuneCountInString(id), slackBlockIDLimit))
+		}
+		elements, _ := b["elements"].([]interface{})
+		switch t {
+		case "section":
+			blockFixes = append(blockFixes, slackFixText(field+" text", b["text"], slackSectionTextLimit)...)
+			fields, _ := b["fields"].([]interface{})
+			if len(fields) > slackFieldsLimit {
+				issues = append(issues, fmt.Sprintf("%s has %d fields, limit is %d, move them to other sections", field, len(fields), slackFieldsLimit))
+			}
+			for j, f := range fields {
+				blockFixes = append(blockFixes, slackFixText(fmt.Sprintf("%s field %d", field, j), f, slackFieldTextLimit)...)
+			}
+		case "header":
+			blockFixes = append(blockFixes, slackFixText(field+" text", b["text"], slackHeaderTextLimit)...)
+		case "context":
+			if len(elements) > slackContextLimit {
+				issues = append(issues, fmt.Sprintf("%s has %d elements, limit is %d, move them to other contexts", field, len(elements), slackContextLimit))
+			}
+		case "actions":
+			if len(elements) > slackActionsLimit {
+				issues = append(issues, fmt.Sprintf("%s has %d elements, limit is %d, move them to other actions", field, len(elements), slackActionsLimit))
+			}
+		}
+	}
+	if err := payloadError(issues); err != nil {
+		return options, fixes, err
+	}
+	if len(blockFixes) == 0 {
+		return options, fixes, nil
+	}
+
+	b, err := json.Marshal(blocks)
+	if err != nil {
+		return options, fixes, err
+	}
+	options.Blocks = string(b)
+	return options, append(fixes, blockFixes...), nil
+}
+
+// slackSplitSection splits section having text exceeding limit into sections, fields and accessory are kept
+// in the first one, block ID is unique, so it's kept in the first one too
+func slackSplitSection(block map[string]interface{}) []map[string]interface{} {
+
+	if block == nil || block["type"] != "section" {
+		return []map[string]interface{}{block}
+	}
+	obj, ok := block["text"].(map[string]interface{})
+	if !ok {
+		return []map[string]interface{}{block}
+	}
+	text, ok := obj["text"].(string)
+	if !ok || utf8.RuneCountInString(text) <= slackSectionTextLimit {
+		return []map[string]interface{}{block}
+	}
+
+	r := []map[string]interface{}{}
+	for i, part := range payloadSplitCode(text, slackSectionTextLimit) {
+		b := map[string]interface{}{"type": "section"}
+		if i == 0 {
+			for k, v := range block {
+				b[k] = v
+			}
+		}
+		t := make(map[string]interface{})
+		for k, v := range obj {
+			t[k] = v
+		}
+		t["text"] = part
+		b["text"] = t
+		r = append(r, b)
+	}
+	return r
+}
+
+// SplitSlackMessage splits message exceeding Slack limits into messages, which are posted as thread,
+// text is split if there are no blocks, otherwise it's notification text, sections having long texts
+// are split into sections, and blocks are split by limit of message, code blocks are closed and reopened
+// in parts, other limits are fixed as FixSlackMessage does
+func SplitSlackMessage(options SlackMessageOptions) ([]SlackMessageOptions, []string, error) {
+
+	fixes := []string{}
+	texts := []string{options.Text}
+	chunks := []string{options.Blocks}
+
+	if utils.IsEmpty(options.Blocks) {
+		if parts := payloadSplitCode(options.Text, slackTextLimit); len(parts) > 1 {
+			texts = parts
+			fixes = append(fixes, fmt.Sprintf("text is split into %d messages", len(parts)))
+		}
+	} else {
+		var blocks []map[string]interface{}
+		if err := json.Unmarshal([]byte(options.Blocks), &blocks); err != nil {
+			return nil, nil, fmt.Errorf("blocks aren't array of blocks: %s", err)
+		}
+		split := []map[string]interface{}{}
+		for i, b := range blocks {
+			sections := slackSplitSection(b)
+			if len(sections) > 1 {
+				fixes = append(fixes, fmt.Sprintf("block %d (section) text is split into %d sections", i, len(sections)))
+			}
+			split = append(split, sections...)
+		}
+		if len(split) > len(blocks) || len(split) > slackBlocksLimit {
+			chunks = []string{}
+			for i := 0; i < len(split); i += slackBlocksLimit {
+				end := i + slackBlocksLimit
+				if end > len(split) {
+					end = len(split)
+				}
+				b, err := json.Marshal(split[i:end])
+				if err != nil {
+					return nil, nil, err
+				}
+				chunks = append(chunks, string(b))
+			}
+			if len(chunks) > 1 {
+				fixes = append(fixes, fmt.Sprintf("blocks are split into %d messages", len(chunks)))
+			}
+		}
+	}
+
+	n := len(texts)
+	if len(chunks) > n {
+		n = len(chunks)
+	}
+	r := []SlackMessageOptions{}
+	for i := 0; i < n; i++ {
+		m := options
+		m.Text = ""
+		if i < len(texts) {
+			m.Text = texts[i]
+		}
+		m.Blocks = ""
+		if i < len(chunks) {
+			m.Blocks = chunks[i]
+		}
+		if i > 0 {
+			m.Attachments = ""
+		}
+		fixed, f, err := FixSlackMessage(m)
+		if err != nil {
+			if n > 1 {
+				return nil, nil, fmt.Errorf("message %d of %d: %s", i+1, n, err)
+			}
+			return nil, nil, err
+		}
+		fixes = append(fixes, f...)
+		r = append(r, fixed)
+	}
+	return r, fixes, nil
+}
+
+// payloadToken is tag, entity or character of text, tags have no length
+type payloadToken struct {
+	text string
+	tag  string
+	end  bool
+}
+
+func payloadTokens(text string, html bool) []*payloadToken {
+
+	r := []*payloadToken{}
+	chars := func(s string) {
+		for _, c := range s {
+			r = append(r, &payloadToken{text: string(c)})
+		}
+	}
+	if !html {
+		chars(text)
+		return r
+	}
+
+	last := 0
+	for _, m := range payloadTokenRegexp.FindAllStringSubmatchIndex(text, -1) {
+		chars(text[last:m[0]])
+		t := &payloadToken{text: text[m[0]:m[1]]}
+		if m[4] >= 0 {
+			t.tag = strings.ToLower(text[m[4]:m[5]])
+			t.end = m[3] > m[2]
+		}
+		r = append(r, t)
+		last = m[1]
+	}
+	chars(text[last:])
+	return r
+}
+
+// payloadSplit splits text into parts having limit characters at most, parts are split by lines or words
+// if it's possible, HTML tags which are open at the end of part are closed and reopened in the next part
+func payloadSplit(text string, limit int, html bool) []string {
+
+	tokens := payloadTokens(text, html)
+	n := 0
+	for _, t := range tokens {
+		if utils.IsEmpty(t.tag) {
+			n++
+		}
+	}
+	if n <= limit {
+		return []string{text}
+	}
+
+	parts := []string{}
+	open := []*payloadToken{}
+	for start := 0; start < len(tokens); {
+
+		end, n, line, word := start, 0, -1, -1
+		for ; end < len(tokens); end++ {
+			t := tokens[end]
+			if !utils.IsEmpty(t.tag) {
+				continue
+			}
+			if n == limit {
+				break
+			}
+			n++
+			switch t.text {
+			case "\n":
+				line = end + 1
+			case " ":
+				word = end + 1
+			}
+		}
+		if end < len(tokens) {
+			if line > start {
+				end = line
+			} else if word > start {
+				end = word
+			}
+		}
+
+		var sb strings.Builder
+		for _, t := range open {
+			sb.WriteString(t.text)
+		}
+		visible := false
+		for _, t := range tokens[start:end] {
+			sb.WriteString(t.text)
+			switch {
+			case utils.IsEmpty(t.tag):
+				visible = visible || !utils.IsEmpty(strings.TrimSpace(t.text))
+			case !t.end:
+				open = append(open, t)
+			case len(open) > 0 && open[len(open)-1].tag == t.tag:
+				open = open[:len(open)-1]
+			}
+		}
+		for i := len(open) - 1; i >= 0; i-- {
+			sb.WriteString(fmt.Sprintf("</%s>", open[i].tag))
+		}
+		if visible {
+			parts = append(parts, sb.String())
+		}
+		start = end
+	}
+	return parts
+}
+
+// payloadSplitCode splits markdown text, code blocks which are open at the end of part are closed
+// and reopened with their language in the next part
+func payloadSplitCode(text string, limit int) []string {
+
+	if utf8.RuneCountInString(text) <= limit || !strings.Contains(text, payloadFence) {
+		return payloadSplit(text, limit, false)
+	}
+
+	r := []string{}
+	open, lang := false, ""
+	for _, part := range payloadSplit(text, limit-payloadFenceReserve, false) {
+		p := part
+		if open {
+			p = payloadFence + lang + "\n" + p
+		}
+		for rest := part; ; {
+			i := strings.Index(rest, payloadFence)
+			if i < 0 {
+				break
+			}
+			rest = rest[i+len(payloadFence):]
+			open = !open
+			if !open {
+				continue
+			}
+			lang = ""
+			if f := strings.Fields(strings.SplitN(rest, "\n", 2)[0]); len(f) == 1 && len(f[0]) <= payloadFenceReserve/2 && strings.HasPrefix(rest, f[0]) {
+				lang = f[0]
+			}
+		}
+		if open {
+			p = strings.TrimRight(p, "\n") + "\n" + payloadFence
+		}
+		r = append(r, p)
+	}
+	return r
+}
+
+// splitTelegramText splits text of parse mode, which is HTML or markdown
+func splitTelegramText(text string, limit int, html bool) []string {
+
+	if html {
+		return payloadSplit(text, limit, true)
+	}
+	return payloadSplitCode(text, limit)
+}
+
+// truncateTelegramText returns the first part of text split by limit
+func truncateTelegramText(text string, limit int, html bool) string {
+
+	parts := splitTelegramText(text, limit, html)
+	if len(parts) == 0 {
+		return ""
+	}
+	return parts[0]
+}
+
+// FixGoogleEvent truncates summary and description of event to Google Calendar limits, it returns fixed event
+// and what is fixed, it's error if event has fields which Google Calendar rejects
+func FixGoogleEvent(options GoogleCalendarInsertEventOptions) (GoogleCalendarInsertEventOptions, []string, error) {
+
+	issues := []string{}
+	if utils.IsEmpty(strings.TrimSpace(options.Summary)) {
+		issues = append(issues, "event has no summary")
+	}
+	if utils.IsEmpty(options.Start) {
+		issues = append(issues, "event has no start")
+	}
+	if utils.IsEmpty(options.End) {
+		issues = append(issues, "event has no end")
+	}
+	if !utils.IsEmpty(options.Visibility) && !payloadContains(googleVisibilities, options.Visibility) {
+		issues = append(issues, fmt.Sprintf("event visibility %s isn't one of %s", options.Visibility, strings.Join(googleVisibilities, ", ")))
+	}
+	if !utils.IsEmpty(options.SendUpdates) && !payloadContains(googleSendUpdates, options.SendUpdates) {
+		issues = append(issues, fmt.Sprintf("event send updates %s isn't one of %s", options.SendUpdates, strings.Join(googleSendUpdates, ", ")))
+	}
+	if !utils.IsEmpty(options.SourceTitle) || !utils.IsEmpty(options.SourceURL) {
+		u, err := url.Parse(options.SourceURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || utils.IsEmpty(u.Host) {
+			issues = append(issues, fmt.Sprintf("event source URL %s isn't http or https URL", options.SourceURL))
+		}
+	}
+	if err := payloadError(issues); err != nil {
+		return options, nil, err
+	}
+
+	fixes := payloadTruncate("summary", &options.Summary, googleSummaryLimit)
+	fixes = append(fixes, payloadTruncate("description", &options.Description, googleDescriptionLimit)...)
+	return options, fixes, nil
+}
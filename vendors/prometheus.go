@@ -1,6 +1,7 @@
 package vendors
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 	"path"
@@ -84,17 +85,23 @@ func (p *Prometheus) CustomGet(options PrometheusOptions) ([]byte, error) {
 		authorization = common.FormatBasicAuth(options.User, options.Password)
 	}
 
-	return utils.HttpGetRaw(p.client, u.String(), "application/json", authorization)
+	return common.HttpGetRaw(p.client, u.String(), "application/json", authorization)
 }
 
 func (p *Prometheus) Get() ([]byte, error) {
 	return p.CustomGet(p.options)
 }
 
+func (p *Prometheus) WithContext(ctx context.Context) *Prometheus {
+	c := *p
+	c.client = common.HttpClientContext(p.client, ctx)
+	return &c
+}
+
 func NewPrometheus(options PrometheusOptions) *Prometheus {
 
 	return &Prometheus{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 	}
 }
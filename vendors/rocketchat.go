@@ -0,0 +1,226 @@
+package vendors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+type RocketChatOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	UserID   string
+	Token    string
+}
+
+type RocketChatMessageOptions struct {
+	Channel     string
+	Thread      string
+	Text        string
+	Alias       string
+	Emoji       string
+	Avatar      string
+	Attachments string
+}
+
+type RocketChatFileOptions struct {
+	RoomID      string
+	Thread      string
+	Text        string
+	Description string
+	Name        string
+	Content     string
+}
+
+type RocketChatChannelOptions struct {
+	Name     string
+	Members  []string
+	Private  bool
+	ReadOnly bool
+}
+
+type RocketChatMessage struct {
+	Channel     string        `json:"channel"`
+	Thread      string        `json:"tmid,omitempty"`
+	Text        string        `json:"text,omitempty"`
+	Alias       string        `json:"alias,omitempty"`
+	Emoji       string        `json:"emoji,omitempty"`
+	Avatar      string        `json:"avatar,omitempty"`
+	Attachments []interface{} `json:"attachments,omitempty"`
+}
+
+type RocketChatChannel struct {
+	Name     string   `json:"name"`
+	Members  []string `json:"members,omitempty"`
+	ReadOnly bool     `json:"readOnly,omitempty"`
+}
+
+type RocketChat struct {
+	client  *http.Client
+	options RocketChatOptions
+}
+
+func (rc *RocketChat) apiURL(opts RocketChatOptions, p string) (string, error) {
+
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, "/api/v1", p)
+	return u.String(), nil
+}
+
+// personal access token is sent with user ID it belongs to
+func (rc *RocketChat) headers(opts RocketChatOptions, contentType string) map[string]string {
+
+	headers := make(map[string]string)
+	headers["X-Auth-Token"] = opts.Token
+	headers["X-User-Id"] = opts.UserID
+	headers["Content-Type"] = contentType
+	return headers
+}
+
+func (rc *RocketChat) post(opts RocketChatOptions, p string, data []byte) ([]byte, error) {
+
+	u, err := rc.apiURL(opts, p)
+	if err != nil {
+		return nil, err
+	}
+	return common.HttpRequestRawWithHeaders(rc.client, "POST", u, rc.headers(opts, "application/json"), data)
+}
+
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/messaging/chat-endpoints/postmessage
+
+func (rc *RocketChat) CustomSendMessage(rocketChatOptions RocketChatOptions, messageOptions RocketChatMessageOptions) ([]byte, error) {
+
+	if utils.IsEmpty(messageOptions.Channel) {
+		return nil, errors.New("no channel")
+	}
+
+	m := &RocketChatMessage{
+		Channel: messageOptions.Channel,
+		Thread:  messageOptions.Thread,
+		Text:    messageOptions.Text,
+		Alias:   messageOptions.Alias,
+		Emoji:   messageOptions.Emoji,
+		Avatar:  messageOptions.Avatar,
+	}
+	if !utils.IsEmpty(messageOptions.Attachments) {
+		var attachments []interface{}
+		err := json.Unmarshal([]byte(messageOptions.Attachments), &attachments)
+		if err != nil {
+			return nil, err
+		}
+		m.Attachments = attachments
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return rc.post(rocketChatOptions, "/chat.postMessage", data)
+}
+
+func (rc *RocketChat) SendMessage(messageOptions RocketChatMessageOptions) ([]byte, error) {
+	return rc.CustomSendMessage(rc.options, messageOptions)
+}
+
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/rooms/rooms-endpoints/upload-file-to-a-room
+
+func (rc *RocketChat) CustomSendFile(rocketChatOptions RocketChatOptions, fileOptions RocketChatFileOptions) ([]byte, error) {
+
+	if utils.IsEmpty(fileOptions.RoomID) {
+		return nil, errors.New("no room ID")
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	fields := map[string]string{
+		"msg":         fileOptions.Text,
+		"description": fileOptions.Description,
+		"tmid":        fileOptions.Thread,
+	}
+	for k, v := range fields {
+		if utils.IsEmpty(v) {
+			continue
+		}
+		if err := w.WriteField(k, v); err != nil {
+			return nil, err
+		}
+	}
+
+	fw, err := w.CreateFormFile("file", fileOptions.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write([]byte(fileOptions.Content)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	u, err := rc.apiURL(rocketChatOptions, fmt.Sprintf("/rooms.upload/%s", fileOptions.RoomID))
+	if err != nil {
+		return nil, err
+	}
+	return common.HttpRequestRawWithHeaders(rc.client, "POST", u, rc.headers(rocketChatOptions, w.FormDataContentType()), body.Bytes())
+}
+
+func (rc *RocketChat) SendFile(fileOptions RocketChatFileOptions) ([]byte, error) {
+	return rc.CustomSendFile(rc.options, fileOptions)
+}
+
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/rooms/channels-endpoints/create-channel
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/rooms/groups-endpoints/create-group
+
+func (rc *RocketChat) CustomCreateChannel(rocketChatOptions RocketChatOptions, channelOptions RocketChatChannelOptions) ([]byte, error) {
+
+	if utils.IsEmpty(channelOptions.Name) {
+		return nil, errors.New("no channel name")
+	}
+
+	data, err := json.Marshal(&RocketChatChannel{
+		Name:     channelOptions.Name,
+		Members:  common.RemoveEmptyStrings(channelOptions.Members),
+		ReadOnly: channelOptions.ReadOnly,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := "/channels.create"
+	if channelOptions.Private {
+		p = "/groups.create"
+	}
+	return rc.post(rocketChatOptions, p, data)
+}
+
+func (rc *RocketChat) CreateChannel(channelOptions RocketChatChannelOptions) ([]byte, error) {
+	return rc.CustomCreateChannel(rc.options, channelOptions)
+}
+
+func (rc *RocketChat) WithContext(ctx context.Context) *RocketChat {
+	c := *rc
+	c.client = common.HttpClientContext(rc.client, ctx)
+	return &c
+}
+
+func NewRocketChat(options RocketChatOptions) *RocketChat {
+
+	return &RocketChat{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
@@ -0,0 +1,248 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const rundeckAPIVersion = 41
+
+type RundeckOptions struct {
+	Timeout    int
+	Insecure   bool
+	URL        string
+	Token      string
+	APIVersion int
+}
+
+type RundeckRunOptions struct {
+	JobID        string
+	Options      string
+	LogLevel     string
+	AsUser       string
+	Filter       string
+	Follow       bool
+	PollInterval int
+	WaitTimeout  int
+}
+
+type RundeckExecutionOptions struct {
+	ID string
+}
+
+type RundeckRun struct {
+	Options  map[string]string `json:"options,omitempty"`
+	LogLevel string            `json:"loglevel,omitempty"`
+	AsUser   string            `json:"asUser,omitempty"`
+	Filter   string            `json:"filter,omitempty"`
+}
+
+type RundeckExecution struct {
+	ID        int    `json:"id"`
+	Status    string `json:"status"`
+	Permalink string `json:"permalink"`
+}
+
+type RundeckOutputEntry struct {
+	Time  string `json:"time"`
+	Level string `json:"level"`
+	Log   string `json:"log"`
+	Node  string `json:"node"`
+}
+
+type RundeckOutput struct {
+	Offset        string                `json:"offset"`
+	Completed     bool                  `json:"completed"`
+	ExecCompleted bool                  `json:"execCompleted"`
+	ExecState     string                `json:"execState"`
+	Entries       []*RundeckOutputEntry `json:"entries"`
+}
+
+type Rundeck struct {
+	client  *http.Client
+	options RundeckOptions
+	logger  common.Logger
+}
+
+func (r *Rundeck) request(opts RundeckOptions, method, p string, params url.Values, data []byte) ([]byte, error) {
+
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	version := opts.APIVersion
+	if version == 0 {
+		version = rundeckAPIVersion
+	}
+	u.Path = path.Join(u.Path, "api", strconv.Itoa(version), p)
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+
+	headers := make(map[string]string)
+	headers["X-Rundeck-Auth-Token"] = opts.Token
+	headers["Content-Type"] = "application/json"
+	headers["Accept"] = "application/json"
+
+	return common.HttpRequestRawWithHeaders(r.client, method, u.String(), headers, data)
+}
+
+// follow prints execution output until it's completed and returns final execution
+func (r *Rundeck) follow(opts RundeckOptions, id int, runOptions RundeckRunOptions) ([]byte, error) {
+
+	interval := time.Duration(runOptions.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(runOptions.WaitTimeout) * time.Second)
+
+	offset := "0"
+	for {
+		params := make(url.Values)
+		params.Add("offset", offset)
+
+		data, err := r.request(opts, "GET", fmt.Sprintf("/execution/%d/output", id), params, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var output RundeckOutput
+		err = json.Unmarshal(data, &output)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range output.Entries {
+			if e != nil && r.logger != nil {
+				r.logger.Info("%s %s %s", e.Time, e.Node, e.Log)
+			}
+		}
+		if !utils.IsEmpty(output.Offset) {
+			offset = output.Offset
+		}
+
+		if output.ExecCompleted && output.Completed {
+			break
+		}
+		if runOptions.WaitTimeout > 0 && time.Now().After(deadline) {
+			return nil, fmt.Errorf("Rundeck execution %d is still %s after %d seconds", id, output.ExecState, runOptions.WaitTimeout)
+		}
+		time.Sleep(interval)
+	}
+
+	data, err := r.CustomGetExecution(opts, RundeckExecutionOptions{ID: strconv.Itoa(id)})
+	if err != nil {
+		return nil, err
+	}
+
+	var execution RundeckExecution
+	err = json.Unmarshal(data, &execution)
+	if err != nil {
+		return nil, err
+	}
+	if execution.Status != "succeeded" {
+		return data, fmt.Errorf("Rundeck execution %d is %s", id, execution.Status)
+	}
+	return data, nil
+}
+
+// https://docs.rundeck.com/docs/api/rundeck-api.html#running-a-job
+
+func (r *Rundeck) CustomRunJob(rundeckOptions RundeckOptions, runOptions RundeckRunOptions) ([]byte, error) {
+
+	if utils.IsEmpty(runOptions.JobID) {
+		return nil, errors.New("no job ID")
+	}
+
+	run := &RundeckRun{
+		LogLevel: runOptions.LogLevel,
+		AsUser:   runOptions.AsUser,
+		Filter:   runOptions.Filter,
+	}
+	if !utils.IsEmpty(runOptions.Options) {
+		run.Options = utils.MapGetKeyValues(runOptions.Options)
+	}
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.request(rundeckOptions, "POST", fmt.Sprintf("/job/%s/run", runOptions.JobID), nil, data)
+	if err != nil {
+		return nil, err
+	}
+	if !runOptions.Follow {
+		return resp, nil
+	}
+
+	var execution RundeckExecution
+	err = json.Unmarshal(resp, &execution)
+	if err != nil {
+		return nil, err
+	}
+	return r.follow(rundeckOptions, execution.ID, runOptions)
+}
+
+func (r *Rundeck) RunJob(runOptions RundeckRunOptions) ([]byte, error) {
+	return r.CustomRunJob(r.options, runOptions)
+}
+
+// https://docs.rundeck.com/docs/api/rundeck-api.html#execution-info
+
+func (r *Rundeck) CustomGetExecution(rundeckOptions RundeckOptions, executionOptions RundeckExecutionOptions) ([]byte, error) {
+	return r.request(rundeckOptions, "GET", fmt.Sprintf("/execution/%s", executionOptions.ID), nil, nil)
+}
+
+func (r *Rundeck) GetExecution(executionOptions RundeckExecutionOptions) ([]byte, error) {
+	return r.CustomGetExecution(r.options, executionOptions)
+}
+
+// https://docs.rundeck.com/docs/api/rundeck-api.html#execution-output
+
+func (r *Rundeck) CustomFollowExecution(rundeckOptions RundeckOptions, executionOptions RundeckExecutionOptions, runOptions RundeckRunOptions) ([]byte, error) {
+
+	id, err := strconv.Atoi(executionOptions.ID)
+	if err != nil {
+		return nil, err
+	}
+	return r.follow(rundeckOptions, id, runOptions)
+}
+
+func (r *Rundeck) FollowExecution(executionOptions RundeckExecutionOptions, runOptions RundeckRunOptions) ([]byte, error) {
+	return r.CustomFollowExecution(r.options, executionOptions, runOptions)
+}
+
+// https://docs.rundeck.com/docs/api/rundeck-api.html#aborting-executions
+
+func (r *Rundeck) CustomAbortExecution(rundeckOptions RundeckOptions, executionOptions RundeckExecutionOptions) ([]byte, error) {
+	return r.request(rundeckOptions, "POST", fmt.Sprintf("/execution/%s/abort", executionOptions.ID), nil, nil)
+}
+
+func (r *Rundeck) AbortExecution(executionOptions RundeckExecutionOptions) ([]byte, error) {
+	return r.CustomAbortExecution(r.options, executionOptions)
+}
+
+func (r *Rundeck) WithContext(ctx context.Context) *Rundeck {
+	c := *r
+	c.client = common.HttpClientContext(r.client, ctx)
+	return &c
+}
+
+func NewRundeck(options RundeckOptions, logger common.Logger) *Rundeck {
+
+	return &Rundeck{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+		logger:  logger,
+	}
+}
@@ -1 +1,152 @@
 package vendors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/devopsext/utils"
+)
+
+// SentryWebhook is issue alert of Sentry, it's normalized from payloads of legacy webhooks plugin
+// and of integration platform event_alert resource
+// https://docs.sentry.io/organization/integrations/integration-platform/webhooks/issue-alerts/
+type SentryWebhook struct {
+	IssueID     string            `json:"issueId"`
+	EventID     string            `json:"eventId"`
+	Project     string            `json:"project"`
+	Title       string            `json:"title"`
+	Message     string            `json:"message"`
+	Culprit     string            `json:"culprit"`
+	Level       string            `json:"level"`
+	Release     string            `json:"release"`
+	Environment string            `json:"environment"`
+	URL         string            `json:"url"`
+	Rule        string            `json:"rule"`
+	Tags        map[string]string `json:"tags"`
+}
+
+type sentryEvent struct {
+	EventID     string      `json:"event_id"`
+	IssueID     json.Number `json:"issue_id"`
+	GroupID     json.Number `json:"group_id"`
+	Title       string      `json:"title"`
+	Message     string      `json:"message"`
+	Culprit     string      `json:"culprit"`
+	Level       string      `json:"level"`
+	Release     string      `json:"release"`
+	Environment string      `json:"environment"`
+	WebURL      string      `json:"web_url"`
+	Project     json.Number `json:"project"`
+	Tags        [][2]string `json:"tags"`
+}
+
+type sentryPlugin struct {
+	ID              json.Number  `json:"id"`
+	Project         string       `json:"project"`
+	ProjectSlug     string       `json:"project_slug"`
+	Level           string       `json:"level"`
+	Culprit         string       `json:"culprit"`
+	Message         string       `json:"message"`
+	URL             string       `json:"url"`
+	TriggeringRules []string     `json:"triggering_rules"`
+	Event           *sentryEvent `json:"event"`
+}
+
+type sentryPlatform struct {
+	Action string `json:"action"`
+	Data   struct {
+		Event         *sentryEvent `json:"event"`
+		TriggeredRule string       `json:"triggered_rule"`
+	} `json:"data"`
+}
+
+func (w *SentryWebhook) event(e *sentryEvent) {
+
+	if e == nil {
+		return
+	}
+	w.EventID = e.EventID
+	w.Title = e.Title
+	w.Release = e.Release
+	w.Environment = e.Environment
+	if utils.IsEmpty(w.Message) {
+		w.Message = e.Message
+	}
+	if utils.IsEmpty(w.Culprit) {
+		w.Culprit = e.Culprit
+	}
+	if utils.IsEmpty(w.Level) {
+		w.Level = e.Level
+	}
+	if utils.IsEmpty(w.IssueID) {
+		w.IssueID = e.IssueID.String()
+	}
+	if utils.IsEmpty(w.IssueID) {
+		w.IssueID = e.GroupID.String()
+	}
+	if utils.IsEmpty(w.URL) {
+		w.URL = e.WebURL
+	}
+	for _, t := range e.Tags {
+		w.Tags[t[0]] = t[1]
+	}
+	if utils.IsEmpty(w.Release) {
+		w.Release = w.Tags["release"]
+	}
+	if utils.IsEmpty(w.Environment) {
+		w.Environment = w.Tags["environment"]
+	}
+}
+
+// ParseSentryWebhook parses issue alert of webhooks plugin or integration platform
+func ParseSentryWebhook(data []byte) (*SentryWebhook, error) {
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	w := &SentryWebhook{Tags: make(map[string]string)}
+
+	if _, ok := m["data"]; ok {
+		var p sentryPlatform
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, err
+		}
+		if p.Data.Event == nil {
+			return nil, fmt.Errorf("no sentry event in %s action, only issue alerts are supported", p.Action)
+		}
+		w.Rule = p.Data.TriggeredRule
+		w.event(p.Data.Event)
+		w.Project = w.Tags["project"]
+		if utils.IsEmpty(w.Project) {
+			w.Project = p.Data.Event.Project.String()
+		}
+	} else {
+		var p sentryPlugin
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, err
+		}
+		if p.Event == nil && utils.IsEmpty(p.URL) {
+			return nil, errors.New("no sentry event")
+		}
+		w.IssueID = p.ID.String()
+		w.Project = p.ProjectSlug
+		if utils.IsEmpty(w.Project) {
+			w.Project = p.Project
+		}
+		w.Level = p.Level
+		w.Culprit = p.Culprit
+		w.Message = p.Message
+		w.URL = p.URL
+		if len(p.TriggeringRules) > 0 {
+			w.Rule = p.TriggeringRules[0]
+		}
+		w.event(p.Event)
+	}
+
+	if utils.IsEmpty(w.Title) {
+		w.Title = w.Message
+	}
+	return w, nil
+}
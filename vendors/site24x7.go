@@ -2,6 +2,7 @@ package vendors
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"mime/multipart"
@@ -279,7 +280,7 @@ func (s *Site24x7) getTokenAuth(opts Site24x7Options) (*Site24x7AuthReponse, err
 		return nil, err
 	}
 
-	d, err := utils.HttpPostRaw(s.client, u.String(), w.FormDataContentType(), "", body.Bytes())
+	d, err := common.HttpPostRaw(s.client, u.String(), w.FormDataContentType(), "", body.Bytes())
 	if err != nil {
 		return nil, s.CheckError(d, err)
 	}
@@ -333,7 +334,7 @@ func (s *Site24x7) CustomGetLocationTemplate(site24x7Options Site24x7Options) ([
 	}
 	u.Path = path.Join(u.Path, Site24x7LocationTemplate)
 
-	return utils.HttpGetRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at))
+	return common.HttpGetRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at))
 }
 
 func (s *Site24x7) GetLocationTemplate() ([]byte, error) {
@@ -353,7 +354,7 @@ func (s *Site24x7) CustomGetLocationProfiles(site24x7Options Site24x7Options) ([
 	}
 	u.Path = path.Join(u.Path, Site24x7LocationProfiles)
 
-	return utils.HttpGetRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at))
+	return common.HttpGetRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at))
 }
 
 func (s *Site24x7) GetLocationProfiles() ([]byte, error) {
@@ -416,7 +417,7 @@ func (s *Site24x7) CustomCreateLocationProfile(site24x7Options Site24x7Options,
 		return nil, err
 	}
 
-	return utils.HttpPostRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at), req)
+	return common.HttpPostRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at), req)
 }
 
 func (s *Site24x7) CreateLocationProfile(options Site24x7LocationProfileOptions) ([]byte, error) {
@@ -436,7 +437,7 @@ func (s *Site24x7) CustomDeleteLocationProfile(site24x7Options Site24x7Options,
 	}
 	u.Path = path.Join(u.Path, Site24x7LocationProfiles, deleteLocationOptions.ID)
 
-	return utils.HttpDeleteRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at), nil)
+	return common.HttpDeleteRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at), nil)
 }
 
 func (s *Site24x7) DeleteLocationProfile(options Site24x7LocationProfileOptions) ([]byte, error) {
@@ -468,7 +469,7 @@ func (s *Site24x7) CustomRetrieveMonitorByName(site24x7Options Site24x7Options,
 	}
 	u.Path = path.Join(u.Path, Site24x7MonitorsName, name)
 
-	return utils.HttpGetRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at))
+	return common.HttpGetRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at))
 }
 
 func (s *Site24x7) RetrieveMonitorByName(name string) ([]byte, error) {
@@ -603,7 +604,7 @@ func (s *Site24x7) CustomCreateWebsiteMonitor(site24x7Options Site24x7Options, c
 		return nil, err
 	}
 
-	return utils.HttpPostRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at), req)
+	return common.HttpPostRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at), req)
 }
 
 func (s *Site24x7) CreateWebsiteMonitor(options Site24x7WebsiteMonitorOptions) ([]byte, error) {
@@ -623,7 +624,7 @@ func (s *Site24x7) CustomDeleteMonitor(site24x7Options Site24x7Options, monitorO
 	}
 	u.Path = path.Join(u.Path, Site24x7Monitors, monitorOptions.ID)
 
-	return utils.HttpDeleteRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at), nil)
+	return common.HttpDeleteRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at), nil)
 }
 
 func (s *Site24x7) DeleteMonitor(options Site24x7MonitorOptions) ([]byte, error) {
@@ -643,7 +644,7 @@ func (s *Site24x7) CustomActivateMonitor(site24x7Options Site24x7Options, monito
 	}
 	u.Path = path.Join(u.Path, Site24x7MonitorsActivate, monitorOptions.ID)
 
-	return utils.HttpDeleteRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at), nil)
+	return common.HttpDeleteRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at), nil)
 }
 
 func (s *Site24x7) ActivateMonitor(options Site24x7MonitorOptions) ([]byte, error) {
@@ -663,7 +664,7 @@ func (s *Site24x7) CustomSuspendMonitor(site24x7Options Site24x7Options, monitor
 	}
 	u.Path = path.Join(u.Path, Site24x7MonitorsSuspend, monitorOptions.ID)
 
-	return utils.HttpDeleteRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at), nil)
+	return common.HttpDeleteRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at), nil)
 }
 
 func (s *Site24x7) SuspendMonitor(options Site24x7MonitorOptions) ([]byte, error) {
@@ -683,7 +684,7 @@ func (s *Site24x7) CustomPollMonitor(site24x7Options Site24x7Options, pollMonito
 	}
 	u.Path = path.Join(u.Path, Site24x7MonitorPollNow, pollMonitorOptions.ID)
 
-	return utils.HttpGetRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at))
+	return common.HttpGetRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at))
 }
 
 func (s *Site24x7) PollMonitor(options Site24x7MonitorOptions) ([]byte, error) {
@@ -703,7 +704,7 @@ func (s *Site24x7) CustomGetPollingStatus(site24x7Options Site24x7Options, monit
 	}
 	u.Path = path.Join(u.Path, Site24x7MonitorStatusPollNow, monitorOptions.ID)
 
-	return utils.HttpGetRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at))
+	return common.HttpGetRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at))
 }
 
 func (s *Site24x7) GetPollingStatus(options Site24x7MonitorOptions) ([]byte, error) {
@@ -735,17 +736,23 @@ func (s *Site24x7) CustomGetLogReport(site24x7Options Site24x7Options, logReport
 
 	u.Path = path.Join(u.Path, Site24x7LogReports, logReportOptions.ID)
 	u.RawQuery = params.Encode()
-	return utils.HttpGetRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at))
+	return common.HttpGetRaw(s.client, u.String(), Site24x7ContentType, s.getAuth(at))
 }
 
 func (s *Site24x7) GetLogReport(options Site24x7LogReportOptions) ([]byte, error) {
 	return s.CustomGetLogReport(s.options, options)
 }
 
+func (s *Site24x7) WithContext(ctx context.Context) *Site24x7 {
+	c := *s
+	c.client = common.HttpClientContext(s.client, ctx)
+	return &c
+}
+
 func NewSite24x7(options Site24x7Options, logger common.Logger) *Site24x7 {
 
 	return &Site24x7{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 		logger:  logger,
 	}
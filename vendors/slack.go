@@ -2,30 +2,40 @@ package vendors
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
+	"github.com/devopsext/tools/common"
 	"github.com/devopsext/utils"
 )
 
 //go:embed slack.tmpl
 var msgTemplate string
 
-const slackBaseURL = "https://slack.com/api/"
+const slackBaseURL = "https://slack.com/api"
 
 const (
 	slackFilesUpload           = "files.upload"
 	slackChatPostMessage       = "chat.postMessage"
 	slackReactionsAdd          = "reactions.add"
+	slackReactionsGet          = "reactions.get"
 	slackUsersLookupByEmail    = "users.lookupByEmail"
 	slackUsergroupsUsersUpdate = "usergroups.users.update"
+	slackAuthTest              = "auth.test"
+	slackConversationsCreate   = "conversations.create"
+	slackConversationsList     = "conversations.list"
 )
 
 type SlackOptions struct {
+	URL      string
 	Timeout  int
 	Insecure bool
 	Token    string
@@ -104,13 +114,30 @@ type SlackUsergroupUsers struct {
 	Users     []string `json:"users"`
 }
 
+type SlackChannelOptions struct {
+	Name    string
+	Private bool
+}
+
+type SlackChannel struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	IsPrivate  bool   `json:"is_private"`
+	IsArchived bool   `json:"is_archived"`
+}
+
 type Slack struct {
 	client  *http.Client
 	options SlackOptions
 }
 
-func (s *Slack) apiURL(cmd string) string {
-	return slackBaseURL + cmd
+func (s *Slack) apiURL(opts SlackOptions, cmd string) string {
+
+	base := opts.URL
+	if utils.IsEmpty(base) {
+		base = slackBaseURL
+	}
+	return strings.TrimSuffix(base, "/") + "/" + cmd
 }
 
 func (s *Slack) getAuth(opts SlackOptions) string {
@@ -123,6 +150,49 @@ func (s *Slack) getAuth(opts SlackOptions) string {
 	return auth
 }
 
+// slackErrorStatuses are statuses of Slack errors, Slack responds 200 with ok false and error in body
+var slackErrorStatuses = map[string]int{
+	"not_authed":             http.StatusUnauthorized,
+	"invalid_auth":           http.StatusUnauthorized,
+	"account_inactive":       http.StatusUnauthorized,
+	"token_revoked":          http.StatusUnauthorized,
+	"token_expired":          http.StatusUnauthorized,
+	"missing_scope":          http.StatusForbidden,
+	"not_allowed_token_type": http.StatusForbidden,
+	"no_permission":          http.StatusForbidden,
+	"not_in_channel":         http.StatusForbidden,
+	"channel_not_found":      http.StatusNotFound,
+	"user_not_found":         http.StatusNotFound,
+	"users_not_found":        http.StatusNotFound,
+	"message_not_found":      http.StatusNotFound,
+	"thread_not_found":       http.StatusNotFound,
+	"ratelimited":            http.StatusTooManyRequests,
+	"fatal_error":            http.StatusInternalServerError,
+	"internal_error":         http.StatusInternalServerError,
+	"service_unavailable":    http.StatusServiceUnavailable,
+}
+
+// slackResult validates ok of response, error is APIError of status by Slack error, 400 by default
+func slackResult(b []byte, err error) ([]byte, error) {
+
+	options := common.GetHttpClientOptions()
+	if err != nil || options.Raw || options.DryRun {
+		return b, err
+	}
+	var r struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(b, &r) != nil || r.OK {
+		return b, nil
+	}
+	status, ok := slackErrorStatuses[r.Error]
+	if !ok {
+		status = http.StatusBadRequest
+	}
+	return nil, common.NewAPIError(status, b)
+}
+
 /*
 	func (s *Slack) Send() ([]byte, error) {
 		m := SlackMessage{
@@ -198,7 +268,7 @@ func (s *Slack) sendMessage(m SlackMessage) ([]byte, error) {
 			return nil, err
 		}
 		q := url.Values{}
-		b, err := s.post(m.Token, s.apiURL(slackChatPostMessage), q, "application/json; charset=utf-8", *jsonMsg)
+		b, err := s.post(m.Token, s.apiURL(s.options, slackChatPostMessage), q, "application/json; charset=utf-8", *jsonMsg)
 		if err != nil {
 			return nil, err
 		}
@@ -232,7 +302,7 @@ func (s *Slack) SendCustom(m SlackMessage) ([]byte, error) {
 		q := url.Values{}
 		q.Add("channels", s.options.Channel)
 
-		return s.post(m.Token, s.apiURL(slackFilesUpload), q, w.FormDataContentType(), body)
+		return s.post(m.Token, s.apiURL(s.options, slackFilesUpload), q, w.FormDataContentType(), body)
 	}
 
 func (s *Slack) SendCustomFile(m SlackMessage) ([]byte, error) {
@@ -277,7 +347,7 @@ func (s *Slack) SendCustomFile(m SlackMessage) ([]byte, error) {
 		q := url.Values{}
 		q.Add("channels", m.Channel)
 
-		return s.post(m.Token, s.apiURL(slackFilesUpload), q, w.FormDataContentType(), body)
+		return s.post(m.Token, s.apiURL(s.options, slackFilesUpload), q, w.FormDataContentType(), body)
 	}
 
 	func (s *Slack) prepareMessage(m SlackMessage) (*bytes.Buffer, error) {
@@ -327,8 +397,49 @@ func (s *Slack) post(token string, URL string, query url.Values, contentType str
 	}
 */
 
+// https://api.slack.com/methods/chat.postMessage
+// message exceeding limits is split into messages, the next ones are posted to thread of the first one,
+// or to thread of message if it's set, result is of the first one
+
 func (s *Slack) CustomSendMessage(slackOptions SlackOptions, messageOptions SlackMessageOptions) ([]byte, error) {
 
+	if err := ValidateSlackMessage(messageOptions); err != nil {
+		return nil, err
+	}
+
+	messages, _, err := SplitSlackMessage(messageOptions)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 1 {
+		return s.postMessage(slackOptions, messages[0])
+	}
+
+	var r []byte
+	thread := messageOptions.Thread
+	for i, m := range messages {
+		m.Thread = thread
+		b, err := s.postMessage(slackOptions, m)
+		if err != nil {
+			return nil, fmt.Errorf("message %d of %d: %s", i+1, len(messages), err)
+		}
+		if i > 0 {
+			continue
+		}
+		r = b
+		var resp SlackMessageResponse
+		if err := json.Unmarshal(b, &resp); err != nil || !resp.OK {
+			return r, nil
+		}
+		if utils.IsEmpty(thread) {
+			thread = resp.TS
+		}
+	}
+	return r, nil
+}
+
+func (s *Slack) postMessage(slackOptions SlackOptions, messageOptions SlackMessageOptions) ([]byte, error) {
+
 	var body bytes.Buffer
 	w := multipart.NewWriter(&body)
 	defer func() {
@@ -371,7 +482,7 @@ func (s *Slack) CustomSendMessage(slackOptions SlackOptions, messageOptions Slac
 		return nil, err
 	}
 
-	return utils.HttpPostRaw(s.client, s.apiURL(slackChatPostMessage), w.FormDataContentType(), s.getAuth(slackOptions), body.Bytes())
+	return slackResult(common.HttpPostRaw(s.client, s.apiURL(slackOptions, slackChatPostMessage), w.FormDataContentType(), s.getAuth(slackOptions), body.Bytes()))
 }
 
 func (s *Slack) SendMessage(messageOptions SlackMessageOptions) ([]byte, error) {
@@ -380,6 +491,10 @@ func (s *Slack) SendMessage(messageOptions SlackMessageOptions) ([]byte, error)
 
 func (s *Slack) CustomSendFile(slackOptions SlackOptions, fileOptions SlackFileOptions) ([]byte, error) {
 
+	if err := ValidateSlackFile(fileOptions); err != nil {
+		return nil, err
+	}
+
 	var body bytes.Buffer
 	w := multipart.NewWriter(&body)
 	defer func() {
@@ -423,7 +538,7 @@ func (s *Slack) CustomSendFile(slackOptions SlackOptions, fileOptions SlackFileO
 		return nil, err
 	}
 
-	return utils.HttpPostRaw(s.client, s.apiURL(slackFilesUpload), w.FormDataContentType(), s.getAuth(slackOptions), body.Bytes())
+	return slackResult(common.HttpPostRaw(s.client, s.apiURL(slackOptions, slackFilesUpload), w.FormDataContentType(), s.getAuth(slackOptions), body.Bytes()))
 }
 
 func (s *Slack) SendFile(fileOptions SlackFileOptions) ([]byte, error) {
@@ -432,6 +547,10 @@ func (s *Slack) SendFile(fileOptions SlackFileOptions) ([]byte, error) {
 
 func (s *Slack) CustomAddReaction(slackOptions SlackOptions, reactionOptions SlackReactionOptions) ([]byte, error) {
 
+	if err := ValidateSlackReaction(reactionOptions); err != nil {
+		return nil, err
+	}
+
 	var body bytes.Buffer
 	w := multipart.NewWriter(&body)
 	defer func() {
@@ -455,24 +574,55 @@ func (s *Slack) CustomAddReaction(slackOptions SlackOptions, reactionOptions Sla
 	if err := w.Close(); err != nil {
 		return nil, err
 	}
-	return utils.HttpPostRaw(s.client, s.apiURL(slackReactionsAdd), w.FormDataContentType(), s.getAuth(slackOptions), body.Bytes())
+	return slackResult(common.HttpPostRaw(s.client, s.apiURL(slackOptions, slackReactionsAdd), w.FormDataContentType(), s.getAuth(slackOptions), body.Bytes()))
 }
 
 func (s *Slack) AddReaction(options SlackReactionOptions) ([]byte, error) {
 	return s.CustomAddReaction(s.options, options)
 }
 
+// https://api.slack.com/methods/reactions.get
+// thread is timestamp of message, result has its reactions with users
+
+func (s *Slack) CustomGetReactions(slackOptions SlackOptions, reactionOptions SlackReactionOptions) ([]byte, error) {
+
+	if err := ValidateSlackReaction(reactionOptions); err != nil {
+		return nil, err
+	}
+
+	params := make(url.Values)
+	params.Add("channel", reactionOptions.Channel)
+	params.Add("timestamp", reactionOptions.Thread)
+	params.Add("full", "true")
+
+	u, err := url.Parse(s.apiURL(slackOptions, slackReactionsGet))
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = params.Encode()
+
+	return slackResult(common.HttpGetRaw(s.client, u.String(), "application/x-www-form-urlencoded", s.getAuth(slackOptions)))
+}
+
+func (s *Slack) GetReactions(options SlackReactionOptions) ([]byte, error) {
+	return s.CustomGetReactions(s.options, options)
+}
+
 func (s *Slack) CustomGetUser(slackOptions SlackOptions, slackUser SlackUserEmail) ([]byte, error) {
+
+	if err := ValidateSlackUserEmail(slackUser); err != nil {
+		return nil, err
+	}
 	params := make(url.Values)
 	params.Add("email", slackUser.Email)
 
-	u, err := url.Parse(s.apiURL(slackUsersLookupByEmail))
+	u, err := url.Parse(s.apiURL(slackOptions, slackUsersLookupByEmail))
 	if err != nil {
 		return nil, err
 	}
 
 	u.RawQuery = params.Encode()
-	return utils.HttpGetRaw(s.client, u.String(), "application/x-www-form-urlencoded", s.getAuth(slackOptions))
+	return slackResult(common.HttpGetRaw(s.client, u.String(), "application/x-www-form-urlencoded", s.getAuth(slackOptions)))
 }
 
 func (s *Slack) GetUser(options SlackUserEmail) ([]byte, error) {
@@ -490,17 +640,155 @@ func (s *Slack) CustomUpdateUsergroup(slackOptions SlackOptions, slackUpdateUser
 	if err != nil {
 		return nil, err
 	}
-	return utils.HttpPostRaw(s.client, s.apiURL(slackUsergroupsUsersUpdate), "application/json", s.getAuth(slackOptions), req)
+	return slackResult(common.HttpPostRaw(s.client, s.apiURL(slackOptions, slackUsergroupsUsersUpdate), "application/json", s.getAuth(slackOptions), req))
 }
 
 func (s *Slack) UpdateUsergroup(options SlackUsergroupUsers) ([]byte, error) {
 	return s.CustomUpdateUsergroup(s.options, options)
 }
 
+// https://api.slack.com/methods/auth.test
+// result is identity of token, it's error if token is invalid
+
+func (s *Slack) CustomAuthTest(slackOptions SlackOptions) ([]byte, error) {
+
+	return slackResult(common.HttpPostRaw(s.client, s.apiURL(slackOptions, slackAuthTest), "application/x-www-form-urlencoded", s.getAuth(slackOptions), nil))
+}
+
+func (s *Slack) AuthTest() ([]byte, error) {
+	return s.CustomAuthTest(s.options)
+}
+
+// https://api.slack.com/methods/conversations.create
+
+func (s *Slack) CustomCreateChannel(slackOptions SlackOptions, channelOptions SlackChannelOptions) ([]byte, error) {
+
+	if utils.IsEmpty(channelOptions.Name) {
+		return nil, errors.New("no channel name")
+	}
+	params := url.Values{}
+	params.Set("name", channelOptions.Name)
+	params.Set("is_private", strconv.FormatBool(channelOptions.Private))
+
+	b, err := slackResult(common.HttpPostRaw(s.client, s.apiURL(slackOptions, slackConversationsCreate), "application/x-www-form-urlencoded", s.getAuth(slackOptions), []byte(params.Encode())))
+	if err != nil {
+		return nil, err
+	}
+	var r struct {
+		Channel *SlackChannel `json:"channel"`
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	// raw and dry run responses are returned as is
+	if r.Channel == nil {
+		return b, nil
+	}
+	return json.Marshal(r.Channel)
+}
+
+func (s *Slack) CreateChannel(channelOptions SlackChannelOptions) ([]byte, error) {
+	return s.CustomCreateChannel(s.options, channelOptions)
+}
+
+// https://api.slack.com/methods/conversations.list
+// channels are visited through pages until visit returns false
+
+func (s *Slack) visitChannels(slackOptions SlackOptions, private bool, visit func(c *SlackChannel) bool) error {
+
+	types := "public_channel"
+	if private {
+		types = "private_channel"
+	}
+
+	cursor := ""
+	for {
+		params := url.Values{}
+		params.Set("types", types)
+		params.Set("limit", "1000")
+		if !utils.IsEmpty(cursor) {
+			params.Set("cursor", cursor)
+		}
+		// it's GET, so that pages are cached by HTTP cache in bulk workflows resolving channels
+		b, err := slackResult(common.HttpGetRaw(s.client, s.apiURL(slackOptions, slackConversationsList)+"?"+params.Encode(), "application/x-www-form-urlencoded", s.getAuth(slackOptions)))
+		if err != nil {
+			return err
+		}
+		var r struct {
+			Channels []*SlackChannel `json:"channels"`
+			Metadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		if err := json.Unmarshal(b, &r); err != nil {
+			return err
+		}
+		for _, c := range r.Channels {
+			if !visit(c) {
+				return nil
+			}
+		}
+		cursor = r.Metadata.NextCursor
+		if utils.IsEmpty(cursor) {
+			return nil
+		}
+	}
+}
+
+// channel is found by name through pages of channels, result is nil if there is no such channel
+
+func (s *Slack) CustomGetChannel(slackOptions SlackOptions, channelOptions SlackChannelOptions) ([]byte, error) {
+
+	if utils.IsEmpty(channelOptions.Name) {
+		return nil, errors.New("no channel name")
+	}
+	var channel *SlackChannel
+	err := s.visitChannels(slackOptions, channelOptions.Private, func(c *SlackChannel) bool {
+		if c.Name == channelOptions.Name {
+			channel = c
+			return false
+		}
+		return true
+	})
+	if err != nil || channel == nil {
+		return nil, err
+	}
+	return json.Marshal(channel)
+}
+
+func (s *Slack) GetChannel(channelOptions SlackChannelOptions) ([]byte, error) {
+	return s.CustomGetChannel(s.options, channelOptions)
+}
+
+// channels are all of pages, name of options isn't used
+
+func (s *Slack) CustomGetChannels(slackOptions SlackOptions, channelOptions SlackChannelOptions) ([]byte, error) {
+
+	channels := []*SlackChannel{}
+	err := s.visitChannels(slackOptions, channelOptions.Private, func(c *SlackChannel) bool {
+		channels = append(channels, c)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(channels)
+}
+
+func (s *Slack) GetChannels(channelOptions SlackChannelOptions) ([]byte, error) {
+	return s.CustomGetChannels(s.options, channelOptions)
+}
+
+func (s *Slack) WithContext(ctx context.Context) *Slack {
+	c := *s
+	c.client = common.HttpClientContext(s.client, ctx)
+	return &c
+}
+
 func NewSlack(options SlackOptions) *Slack {
 
 	slack := &Slack{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 	}
 	return slack
@@ -0,0 +1,325 @@
+package vendors
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+// sonarQubePageSize is max page size of issues search
+const sonarQubePageSize = 500
+
+type SonarQubeOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	Token    string
+}
+
+type SonarQubeProjectOptions struct {
+	Key         string
+	Name        string
+	Branch      string
+	PullRequest string
+	Visibility  string
+}
+
+type SonarQubeIssuesOptions struct {
+	Severities []string
+	Types      []string
+	Limit      int
+}
+
+type SonarQubeCondition struct {
+	Metric     string `json:"metric"`
+	Status     string `json:"status"`
+	Comparator string `json:"comparator,omitempty"`
+	Threshold  string `json:"threshold,omitempty"`
+	Actual     string `json:"actual,omitempty"`
+}
+
+// SonarQubeQualityGate is status of quality gate with its conditions, summary is text to post to chats
+type SonarQubeQualityGate struct {
+	Project    string                `json:"project"`
+	Branch     string                `json:"branch,omitempty"`
+	Status     string                `json:"status"`
+	Conditions []*SonarQubeCondition `json:"conditions"`
+	Summary    string                `json:"summary"`
+}
+
+type SonarQubeIssue struct {
+	Key       string `json:"key"`
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	Type      string `json:"type"`
+	Component string `json:"component"`
+	Line      int    `json:"line,omitempty"`
+	Message   string `json:"message"`
+}
+
+type SonarQubeIssues struct {
+	Total      int               `json:"total"`
+	BySeverity map[string]int    `json:"bySeverity"`
+	Issues     []*SonarQubeIssue `json:"issues"`
+}
+
+type sonarQubeProjectStatus struct {
+	ProjectStatus struct {
+		Status     string `json:"status"`
+		Conditions []struct {
+			Status         string `json:"status"`
+			MetricKey      string `json:"metricKey"`
+			Comparator     string `json:"comparator"`
+			ErrorThreshold string `json:"errorThreshold"`
+			ActualValue    string `json:"actualValue"`
+		} `json:"conditions"`
+	} `json:"projectStatus"`
+}
+
+type sonarQubeIssuesPage struct {
+	Paging struct {
+		Total int `json:"total"`
+	} `json:"paging"`
+	Issues []*SonarQubeIssue `json:"issues"`
+}
+
+type SonarQube struct {
+	client  *http.Client
+	options SonarQubeOptions
+}
+
+// request sends token as user without password, which is supported by all SonarQube versions
+func (s *SonarQube) request(opts SonarQubeOptions, method, p string, params url.Values) ([]byte, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return nil, errors.New("no URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, p)
+
+	headers := make(map[string]string)
+	if !utils.IsEmpty(opts.Token) {
+		headers["Authorization"] = fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(opts.Token+":")))
+	}
+
+	var data []byte
+	if method == "GET" {
+		u.RawQuery = params.Encode()
+	} else {
+		headers["Content-Type"] = "application/x-www-form-urlencoded"
+		data = []byte(params.Encode())
+	}
+
+	b, err := common.HttpRequestRawWithHeaders(s.client, method, u.String(), headers, data)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *SonarQube) projectParams(projectOptions SonarQubeProjectOptions) (url.Values, error) {
+
+	if utils.IsEmpty(projectOptions.Key) {
+		return nil, errors.New("no project key")
+	}
+	params := make(url.Values)
+	if !utils.IsEmpty(projectOptions.PullRequest) {
+		params.Set("pullRequest", projectOptions.PullRequest)
+	} else if !utils.IsEmpty(projectOptions.Branch) {
+		params.Set("branch", projectOptions.Branch)
+	}
+	return params, nil
+}
+
+// https://next.sonarqube.com/sonarqube/web_api/api/qualitygates/project_status
+// result has conditions of gate and summary of failed ones
+
+func (s *SonarQube) CustomGetQualityGateStatus(sonarQubeOptions SonarQubeOptions, projectOptions SonarQubeProjectOptions) ([]byte, error) {
+
+	params, err := s.projectParams(projectOptions)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("projectKey", projectOptions.Key)
+
+	b, err := s.request(sonarQubeOptions, "GET", "/api/qualitygates/project_status", params)
+	if err != nil {
+		return nil, err
+	}
+	var ps sonarQubeProjectStatus
+	if err := json.Unmarshal(b, &ps); err != nil {
+		return nil, err
+	}
+
+	gate := &SonarQubeQualityGate{
+		Project:    projectOptions.Key,
+		Branch:     projectOptions.Branch,
+		Status:     ps.ProjectStatus.Status,
+		Conditions: []*SonarQubeCondition{},
+	}
+	if !utils.IsEmpty(projectOptions.PullRequest) {
+		gate.Branch = fmt.Sprintf("PR %s", projectOptions.PullRequest)
+	}
+
+	name := gate.Project
+	if !utils.IsEmpty(gate.Branch) {
+		name = fmt.Sprintf("%s (%s)", gate.Project, gate.Branch)
+	}
+	lines := []string{fmt.Sprintf("Quality gate of %s is %s", name, gate.Status)}
+	for _, c := range ps.ProjectStatus.Conditions {
+		gate.Conditions = append(gate.Conditions, &SonarQubeCondition{
+			Metric:     c.MetricKey,
+			Status:     c.Status,
+			Comparator: c.Comparator,
+			Threshold:  c.ErrorThreshold,
+			Actual:     c.ActualValue,
+		})
+		if c.Status == "OK" {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- %s is %s: %s, threshold %s %s", c.MetricKey, c.Status, c.ActualValue, c.Comparator, c.ErrorThreshold))
+	}
+	gate.Summary = strings.Join(lines, "\n")
+	return json.Marshal(gate)
+}
+
+func (s *SonarQube) GetQualityGateStatus(projectOptions SonarQubeProjectOptions) ([]byte, error) {
+	return s.CustomGetQualityGateStatus(s.options, projectOptions)
+}
+
+// https://next.sonarqube.com/sonarqube/web_api/api/issues/search
+// unresolved issues are read by pages until limit, counts by severity are of all of them
+
+func (s *SonarQube) CustomGetIssues(sonarQubeOptions SonarQubeOptions, projectOptions SonarQubeProjectOptions, issuesOptions SonarQubeIssuesOptions) ([]byte, error) {
+
+	params, err := s.projectParams(projectOptions)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("componentKeys", projectOptions.Key)
+	params.Set("resolved", "false")
+	params.Set("facets", "severities")
+	if severities := common.RemoveEmptyStrings(issuesOptions.Severities); len(severities) > 0 {
+		params.Set("severities", strings.ToUpper(strings.Join(severities, ",")))
+	}
+	if types := common.RemoveEmptyStrings(issuesOptions.Types); len(types) > 0 {
+		params.Set("types", strings.ToUpper(strings.Join(types, ",")))
+	}
+
+	r := &SonarQubeIssues{
+		BySeverity: make(map[string]int),
+		Issues:     []*SonarQubeIssue{},
+	}
+	for page := 1; ; page++ {
+		size := sonarQubePageSize
+		if issuesOptions.Limit > 0 && issuesOptions.Limit-len(r.Issues) < size {
+			size = issuesOptions.Limit - len(r.Issues)
+		}
+		params.Set("p", strconv.Itoa(page))
+		params.Set("ps", strconv.Itoa(size))
+
+		b, err := s.request(sonarQubeOptions, "GET", "/api/issues/search", params)
+		if err != nil {
+			return nil, err
+		}
+		var ip sonarQubeIssuesPage
+		if err := json.Unmarshal(b, &ip); err != nil {
+			return nil, err
+		}
+		if page == 1 {
+			r.Total = ip.Paging.Total
+			var facets struct {
+				Facets []struct {
+					Property string `json:"property"`
+					Values   []struct {
+						Val   string `json:"val"`
+						Count int    `json:"count"`
+					} `json:"values"`
+				} `json:"facets"`
+			}
+			if err := json.Unmarshal(b, &facets); err != nil {
+				return nil, err
+			}
+			for _, f := range facets.Facets {
+				if f.Property != "severities" {
+					continue
+				}
+				for _, v := range f.Values {
+					if v.Count > 0 {
+						r.BySeverity[v.Val] = v.Count
+					}
+				}
+			}
+		}
+		r.Issues = append(r.Issues, ip.Issues...)
+
+		if len(ip.Issues) < size || len(r.Issues) >= r.Total {
+			break
+		}
+		if issuesOptions.Limit > 0 && len(r.Issues) >= issuesOptions.Limit {
+			break
+		}
+	}
+	return json.Marshal(r)
+}
+
+func (s *SonarQube) GetIssues(projectOptions SonarQubeProjectOptions, issuesOptions SonarQubeIssuesOptions) ([]byte, error) {
+	return s.CustomGetIssues(s.options, projectOptions, issuesOptions)
+}
+
+// https://next.sonarqube.com/sonarqube/web_api/api/projects/create
+// name is key if it's empty, branch is main branch of project
+
+func (s *SonarQube) CustomCreateProject(sonarQubeOptions SonarQubeOptions, projectOptions SonarQubeProjectOptions) ([]byte, error) {
+
+	if utils.IsEmpty(projectOptions.Key) {
+		return nil, errors.New("no project key")
+	}
+	name := projectOptions.Name
+	if utils.IsEmpty(name) {
+		name = projectOptions.Key
+	}
+
+	params := make(url.Values)
+	params.Set("project", projectOptions.Key)
+	params.Set("name", name)
+	if !utils.IsEmpty(projectOptions.Branch) {
+		params.Set("mainBranch", projectOptions.Branch)
+	}
+	if !utils.IsEmpty(projectOptions.Visibility) {
+		params.Set("visibility", projectOptions.Visibility)
+	}
+	return s.request(sonarQubeOptions, "POST", "/api/projects/create", params)
+}
+
+func (s *SonarQube) CreateProject(projectOptions SonarQubeProjectOptions) ([]byte, error) {
+	return s.CustomCreateProject(s.options, projectOptions)
+}
+
+func (s *SonarQube) WithContext(ctx context.Context) *SonarQube {
+	c := *s
+	c.client = common.HttpClientContext(s.client, ctx)
+	return &c
+}
+
+func NewSonarQube(options SonarQubeOptions) *SonarQube {
+
+	sonarQube := &SonarQube{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+	return sonarQube
+}
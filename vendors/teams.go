@@ -0,0 +1,113 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const teamsCardContentType = "application/vnd.microsoft.card.adaptive"
+
+type TeamsOptions struct {
+	Timeout    int
+	Insecure   bool
+	WebhookURL string
+}
+
+type TeamsMessageOptions struct {
+	Title string
+	Text  string
+	Card  string
+}
+
+type TeamsAttachment struct {
+	ContentType string      `json:"contentType"`
+	Content     interface{} `json:"content"`
+}
+
+type TeamsMessage struct {
+	Type        string             `json:"type"`
+	Attachments []*TeamsAttachment `json:"attachments"`
+}
+
+type Teams struct {
+	client  *http.Client
+	options TeamsOptions
+}
+
+// teamsCard is Adaptive Card of title and text, text is markdown of Teams
+func teamsCard(messageOptions TeamsMessageOptions) map[string]interface{} {
+
+	body := []map[string]interface{}{}
+	if !utils.IsEmpty(messageOptions.Title) {
+		body = append(body, map[string]interface{}{
+			"type":   "TextBlock",
+			"text":   messageOptions.Title,
+			"weight": "Bolder",
+			"size":   "Medium",
+			"wrap":   true,
+		})
+	}
+	body = append(body, map[string]interface{}{
+		"type": "TextBlock",
+		"text": messageOptions.Text,
+		"wrap": true,
+	})
+	return map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+}
+
+// https://learn.microsoft.com/en-us/microsoftteams/platform/webhooks-and-connectors/how-to/add-incoming-webhook
+// message is Adaptive Card, which is accepted by webhooks of connectors and of Workflows,
+// card is JSON of Adaptive Card replacing card of title and text
+
+func (t *Teams) CustomSendMessage(teamsOptions TeamsOptions, messageOptions TeamsMessageOptions) ([]byte, error) {
+
+	if utils.IsEmpty(teamsOptions.WebhookURL) {
+		return nil, errors.New("no webhook URL")
+	}
+	if utils.IsEmpty(messageOptions.Text) && utils.IsEmpty(messageOptions.Card) {
+		return nil, errors.New("no text or card")
+	}
+
+	var card interface{} = teamsCard(messageOptions)
+	if !utils.IsEmpty(messageOptions.Card) {
+		if err := json.Unmarshal([]byte(messageOptions.Card), &card); err != nil {
+			return nil, err
+		}
+	}
+	data, err := json.Marshal(&TeamsMessage{
+		Type:        "message",
+		Attachments: []*TeamsAttachment{{ContentType: teamsCardContentType, Content: card}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return common.HttpPostRaw(t.client, teamsOptions.WebhookURL, "application/json", "", data)
+}
+
+func (t *Teams) SendMessage(messageOptions TeamsMessageOptions) ([]byte, error) {
+	return t.CustomSendMessage(t.options, messageOptions)
+}
+
+func (t *Teams) WithContext(ctx context.Context) *Teams {
+	c := *t
+	c.client = common.HttpClientContext(t.client, ctx)
+	return &c
+}
+
+func NewTeams(options TeamsOptions) *Teams {
+
+	return &Teams{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
@@ -2,11 +2,15 @@ package vendors
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/devopsext/tools/common"
 	"github.com/devopsext/utils"
 )
 
@@ -16,10 +20,18 @@ const (
 	telegramSendMessageURL  = "https://api.telegram.org/bot%s/sendMessage?chat_id=%s"
 	telegramSendPhotoURL    = "https://api.telegram.org/bot%s/sendPhoto?chat_id=%s"
 	telegramSendDocumentURL = "https://api.telegram.org/bot%s/sendDocument?chat_id=%s"
+	telegramGetMeURL        = "https://api.telegram.org/bot%s/getMe"
+	telegramEditMessageURL  = "https://api.telegram.org/bot%s/editMessageText?chat_id=%s"
 )
 
 type TelegramMessageOptions struct {
-	Text string
+	Text    string
+	ReplyTo string
+}
+
+type TelegramEditOptions struct {
+	MessageID string
+	Text      string
 }
 
 type TelegramPhotoOptions struct {
@@ -44,6 +56,13 @@ type TelegramOptions struct {
 	DisableWebPagePreview bool
 }
 
+type telegramMessageResponse struct {
+	OK     bool `json:"ok"`
+	Result struct {
+		MessageID int64 `json:"message_id"`
+	} `json:"result"`
+}
+
 type Telegram struct {
 	client  *http.Client
 	options TelegramOptions
@@ -69,18 +88,59 @@ func (t *Telegram) getDefaultParseMode(parseMode string) string {
 	return parseMode
 }
 
+// html is true if parse mode is HTML, so tags are kept when text is split or truncated
+func (t *Telegram) html(parseMode string) bool {
+	return strings.EqualFold(t.getDefaultParseMode(parseMode), "HTML")
+}
+
+// https://core.telegram.org/bots/api#sendmessage
+// text exceeding limit is sent as reply chain, each part replies to the previous one, result is of the first one,
+// the first one replies to message if it's set
+
 func (t *Telegram) CustomSendMessage(telegramOptions TelegramOptions, messageOptions TelegramMessageOptions) ([]byte, error) {
 
+	parts := splitTelegramText(messageOptions.Text, telegramTextLimit, t.html(telegramOptions.ParseMode))
+	if len(parts) <= 1 {
+		return t.sendMessage(telegramOptions, messageOptions.Text, messageOptions.ReplyTo)
+	}
+
+	var r []byte
+	replyTo := messageOptions.ReplyTo
+	for i, part := range parts {
+		b, err := t.sendMessage(telegramOptions, part, replyTo)
+		if err != nil {
+			return nil, fmt.Errorf("part %d of %d: %s", i+1, len(parts), err)
+		}
+		if i == 0 {
+			r = b
+		}
+		var resp telegramMessageResponse
+		if err := json.Unmarshal(b, &resp); err != nil || !resp.OK {
+			return r, nil
+		}
+		replyTo = strconv.FormatInt(resp.Result.MessageID, 10)
+	}
+	return r, nil
+}
+
+func (t *Telegram) sendMessage(telegramOptions TelegramOptions, text, replyTo string) ([]byte, error) {
+
 	var body bytes.Buffer
 	w := multipart.NewWriter(&body)
 	defer func() {
 		w.Close()
 	}()
 
-	if err := w.WriteField("text", messageOptions.Text); err != nil {
+	if err := w.WriteField("text", text); err != nil {
 		return nil, err
 	}
 
+	if !utils.IsEmpty(replyTo) {
+		if err := w.WriteField("reply_to_message_id", replyTo); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := w.WriteField("parse_mode", t.getDefaultParseMode(telegramOptions.ParseMode)); err != nil {
 		return nil, err
 	}
@@ -96,7 +156,7 @@ func (t *Telegram) CustomSendMessage(telegramOptions TelegramOptions, messageOpt
 	if err := w.Close(); err != nil {
 		return nil, err
 	}
-	return utils.HttpPostRaw(t.client, t.getSendMessageURL(telegramOptions), w.FormDataContentType(), "", body.Bytes())
+	return common.HttpPostRaw(t.client, t.getSendMessageURL(telegramOptions), w.FormDataContentType(), "", body.Bytes())
 }
 
 func (t *Telegram) SendMessage(options TelegramMessageOptions) ([]byte, error) {
@@ -111,7 +171,8 @@ func (t *Telegram) CustomSendPhoto(telegramOptions TelegramOptions, photoOptions
 		w.Close()
 	}()
 
-	if err := w.WriteField("caption", photoOptions.Caption); err != nil {
+	caption := truncateTelegramText(photoOptions.Caption, telegramCaptionLimit, t.html(telegramOptions.ParseMode))
+	if err := w.WriteField("caption", caption); err != nil {
 		return nil, err
 	}
 
@@ -139,7 +200,7 @@ func (t *Telegram) CustomSendPhoto(telegramOptions TelegramOptions, photoOptions
 	if err := w.Close(); err != nil {
 		return nil, err
 	}
-	return utils.HttpPostRaw(t.client, t.getSendPhotoURL(telegramOptions), w.FormDataContentType(), "", body.Bytes())
+	return common.HttpPostRaw(t.client, t.getSendPhotoURL(telegramOptions), w.FormDataContentType(), "", body.Bytes())
 }
 
 func (t *Telegram) SendPhoto(options TelegramPhotoOptions) ([]byte, error) {
@@ -154,7 +215,8 @@ func (t *Telegram) CustomSendDocument(telegramOptions TelegramOptions, documentO
 		w.Close()
 	}()
 
-	if err := w.WriteField("caption", documentOptions.Caption); err != nil {
+	caption := truncateTelegramText(documentOptions.Caption, telegramCaptionLimit, t.html(telegramOptions.ParseMode))
+	if err := w.WriteField("caption", caption); err != nil {
 		return nil, err
 	}
 
@@ -182,17 +244,72 @@ func (t *Telegram) CustomSendDocument(telegramOptions TelegramOptions, documentO
 	if err := w.Close(); err != nil {
 		return nil, err
 	}
-	return utils.HttpPostRaw(t.client, t.getSendDocumentURL(telegramOptions), w.FormDataContentType(), "", body.Bytes())
+	return common.HttpPostRaw(t.client, t.getSendDocumentURL(telegramOptions), w.FormDataContentType(), "", body.Bytes())
 }
 
 func (t *Telegram) SendDocument(options TelegramDocumentOptions) ([]byte, error) {
 	return t.CustomSendDocument(t.options, options)
 }
 
+// https://core.telegram.org/bots/api#getme
+// result is bot of token, it's error if token is invalid
+
+func (t *Telegram) CustomGetMe(telegramOptions TelegramOptions) ([]byte, error) {
+	return common.HttpGetRaw(t.client, fmt.Sprintf(telegramGetMeURL, telegramOptions.IDToken), "application/json", "")
+}
+
+func (t *Telegram) GetMe() ([]byte, error) {
+	return t.CustomGetMe(t.options)
+}
+
+// https://core.telegram.org/bots/api#editmessagetext
+// result is edited message, it has edit_date, text exceeding limit is truncated as message can't be split
+
+func (t *Telegram) CustomEditMessage(telegramOptions TelegramOptions, editOptions TelegramEditOptions) ([]byte, error) {
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	defer func() {
+		w.Close()
+	}()
+
+	if err := w.WriteField("message_id", editOptions.MessageID); err != nil {
+		return nil, err
+	}
+
+	text := truncateTelegramText(editOptions.Text, telegramTextLimit, t.html(telegramOptions.ParseMode))
+	if err := w.WriteField("text", text); err != nil {
+		return nil, err
+	}
+
+	if err := w.WriteField("parse_mode", t.getDefaultParseMode(telegramOptions.ParseMode)); err != nil {
+		return nil, err
+	}
+
+	if err := w.WriteField("disable_web_page_preview", strconv.FormatBool(telegramOptions.DisableWebPagePreview)); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return common.HttpPostRaw(t.client, fmt.Sprintf(telegramEditMessageURL, telegramOptions.IDToken, telegramOptions.ChatID), w.FormDataContentType(), "", body.Bytes())
+}
+
+func (t *Telegram) EditMessage(options TelegramEditOptions) ([]byte, error) {
+	return t.CustomEditMessage(t.options, options)
+}
+
+func (t *Telegram) WithContext(ctx context.Context) *Telegram {
+	c := *t
+	c.client = common.HttpClientContext(t.client, ctx)
+	return &c
+}
+
 func NewTelegram(options TelegramOptions) *Telegram {
 
 	telegram := &Telegram{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 	}
 	return telegram
@@ -0,0 +1,156 @@
+package vendors
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const twilioURL = "https://api.twilio.com"
+
+type TwilioOptions struct {
+	Timeout    int
+	Insecure   bool
+	URL        string
+	AccountSID string
+	AuthToken  string
+	From       string
+}
+
+type TwilioSMSOptions struct {
+	To                  string
+	Body                string
+	MessagingServiceSID string
+}
+
+type TwilioCallOptions struct {
+	To       string
+	Text     string
+	Voice    string
+	Language string
+	Loop     int
+	TwimlURL string
+}
+
+type twilioSay struct {
+	Voice    string `xml:"voice,attr,omitempty"`
+	Language string `xml:"language,attr,omitempty"`
+	Loop     int    `xml:"loop,attr,omitempty"`
+	Text     string `xml:",chardata"`
+}
+
+type twilioResponse struct {
+	XMLName xml.Name   `xml:"Response"`
+	Say     *twilioSay `xml:"Say"`
+}
+
+type Twilio struct {
+	client  *http.Client
+	options TwilioOptions
+}
+
+func (t *Twilio) post(opts TwilioOptions, resource string, params url.Values) ([]byte, error) {
+
+	if utils.IsEmpty(opts.AccountSID) {
+		return nil, errors.New("no account SID")
+	}
+
+	base := opts.URL
+	if utils.IsEmpty(base) {
+		base = twilioURL
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "/2010-04-01/Accounts", opts.AccountSID, resource)
+
+	auth := common.FormatBasicAuth(opts.AccountSID, opts.AuthToken)
+	return common.HttpPostRaw(t.client, u.String(), "application/x-www-form-urlencoded", auth, []byte(params.Encode()))
+}
+
+// https://www.twilio.com/docs/messaging/api/message-resource#create-a-message-resource
+
+func (t *Twilio) CustomSendSMS(twilioOptions TwilioOptions, smsOptions TwilioSMSOptions) ([]byte, error) {
+
+	if utils.IsEmpty(smsOptions.To) {
+		return nil, errors.New("no phone number")
+	}
+	if utils.IsEmpty(smsOptions.Body) {
+		return nil, errors.New("no SMS body")
+	}
+
+	params := make(url.Values)
+	params.Add("To", smsOptions.To)
+	params.Add("Body", smsOptions.Body)
+	if !utils.IsEmpty(smsOptions.MessagingServiceSID) {
+		params.Add("MessagingServiceSid", smsOptions.MessagingServiceSID)
+	} else {
+		params.Add("From", twilioOptions.From)
+	}
+	return t.post(twilioOptions, "Messages.json", params)
+}
+
+func (t *Twilio) SendSMS(smsOptions TwilioSMSOptions) ([]byte, error) {
+	return t.CustomSendSMS(t.options, smsOptions)
+}
+
+// https://www.twilio.com/docs/voice/api/call-resource#create-a-call-resource
+// text is read by <Say> unless TwiML URL is set
+
+func (t *Twilio) CustomCall(twilioOptions TwilioOptions, callOptions TwilioCallOptions) ([]byte, error) {
+
+	if utils.IsEmpty(callOptions.To) {
+		return nil, errors.New("no phone number")
+	}
+
+	params := make(url.Values)
+	params.Add("To", callOptions.To)
+	params.Add("From", twilioOptions.From)
+
+	if !utils.IsEmpty(callOptions.TwimlURL) {
+		params.Add("Url", callOptions.TwimlURL)
+	} else {
+		if utils.IsEmpty(callOptions.Text) {
+			return nil, errors.New("no call text")
+		}
+		twiml, err := xml.Marshal(&twilioResponse{
+			Say: &twilioSay{
+				Voice:    callOptions.Voice,
+				Language: callOptions.Language,
+				Loop:     callOptions.Loop,
+				Text:     callOptions.Text,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		params.Add("Twiml", fmt.Sprintf("%s%s", xml.Header, twiml))
+	}
+	return t.post(twilioOptions, "Calls.json", params)
+}
+
+func (t *Twilio) Call(callOptions TwilioCallOptions) ([]byte, error) {
+	return t.CustomCall(t.options, callOptions)
+}
+
+func (t *Twilio) WithContext(ctx context.Context) *Twilio {
+	c := *t
+	c.client = common.HttpClientContext(t.client, ctx)
+	return &c
+}
+
+func NewTwilio(options TwilioOptions) *Twilio {
+
+	return &Twilio{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
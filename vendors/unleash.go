@@ -0,0 +1,133 @@
+package vendors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+type UnleashOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	Token    string
+}
+
+type UnleashFlagOptions struct {
+	Project     string
+	Key         string
+	Environment string
+	Comment     string
+}
+
+type Unleash struct {
+	client  *http.Client
+	options UnleashOptions
+	logger  common.Logger
+}
+
+func (un *Unleash) request(opts UnleashOptions, method, p string) ([]byte, error) {
+
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "/api/admin", p)
+
+	headers := make(map[string]string)
+	headers["Authorization"] = opts.Token
+	headers["Content-Type"] = "application/json"
+
+	return common.HttpRequestRawWithHeaders(un.client, method, u.String(), headers, nil)
+}
+
+func (un *Unleash) featurePath(flagOptions UnleashFlagOptions) (string, error) {
+
+	if utils.IsEmpty(flagOptions.Key) {
+		return "", errors.New("no flag key")
+	}
+	project := flagOptions.Project
+	if utils.IsEmpty(project) {
+		project = "default"
+	}
+	return fmt.Sprintf("/projects/%s/features/%s", project, flagOptions.Key), nil
+}
+
+// https://docs.getunleash.io/reference/api/unleash/get-feature
+
+func (un *Unleash) CustomGetFlag(unleashOptions UnleashOptions, flagOptions UnleashFlagOptions) ([]byte, error) {
+
+	p, err := un.featurePath(flagOptions)
+	if err != nil {
+		return nil, err
+	}
+	return un.request(unleashOptions, "GET", p)
+}
+
+func (un *Unleash) GetFlag(flagOptions UnleashFlagOptions) ([]byte, error) {
+	return un.CustomGetFlag(un.options, flagOptions)
+}
+
+// https://docs.getunleash.io/reference/api/unleash/toggle-feature-environment-on
+// https://docs.getunleash.io/reference/api/unleash/toggle-feature-environment-off
+func (un *Unleash) toggle(unleashOptions UnleashOptions, flagOptions UnleashFlagOptions, on bool) ([]byte, error) {
+
+	p, err := un.featurePath(flagOptions)
+	if err != nil {
+		return nil, err
+	}
+	if utils.IsEmpty(flagOptions.Environment) {
+		return nil, errors.New("no environment")
+	}
+
+	state := "off"
+	if on {
+		state = "on"
+	}
+
+	resp, err := un.request(unleashOptions, "POST", path.Join(p, "environments", flagOptions.Environment, state))
+	if err != nil {
+		return nil, err
+	}
+	if un.logger != nil {
+		un.logger.Info("Unleash flag %s turned %s in %s: %s", flagOptions.Key, state, flagOptions.Environment, flagOptions.Comment)
+	}
+	return resp, nil
+}
+
+func (un *Unleash) CustomTurnFlagOn(unleashOptions UnleashOptions, flagOptions UnleashFlagOptions) ([]byte, error) {
+	return un.toggle(unleashOptions, flagOptions, true)
+}
+
+func (un *Unleash) TurnFlagOn(flagOptions UnleashFlagOptions) ([]byte, error) {
+	return un.CustomTurnFlagOn(un.options, flagOptions)
+}
+
+func (un *Unleash) CustomTurnFlagOff(unleashOptions UnleashOptions, flagOptions UnleashFlagOptions) ([]byte, error) {
+	return un.toggle(unleashOptions, flagOptions, false)
+}
+
+func (un *Unleash) TurnFlagOff(flagOptions UnleashFlagOptions) ([]byte, error) {
+	return un.CustomTurnFlagOff(un.options, flagOptions)
+}
+
+func (un *Unleash) WithContext(ctx context.Context) *Unleash {
+	c := *un
+	c.client = common.HttpClientContext(un.client, ctx)
+	return &c
+}
+
+func NewUnleash(options UnleashOptions, logger common.Logger) *Unleash {
+
+	return &Unleash{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+		logger:  logger,
+	}
+}
@@ -0,0 +1,90 @@
+package vendors
+
+import (
+	"regexp"
+
+	"github.com/devopsext/tools/common"
+)
+
+// Slack IDs of channels, groups, DMs and users, which messages are posted to as well
+var slackIDRegexp = regexp.MustCompile(`^[CDGUW][A-Z0-9]+$`)
+
+// Slack channel names are lowercase without spaces and limited by 80 chars
+var slackChannelNameRegexp = regexp.MustCompile(`^#?[\p{Ll}\p{Lo}\p{Nd}_][\p{Ll}\p{Lo}\p{Nd}_.-]{0,79}$`)
+
+// Slack timestamps of messages, which are threads and targets of reactions as well
+var slackTSRegexp = regexp.MustCompile(`^[0-9]{10}\.[0-9]{6}$`)
+
+func validateSlackChannel(v *common.Validation, field, channel string) {
+
+	if slackIDRegexp.MatchString(channel) {
+		return
+	}
+	v.Match(field, channel, slackChannelNameRegexp, "C0123456789 or alerts")
+}
+
+func validateSlackTS(v *common.Validation, field, ts string) {
+	v.Match(field, ts, slackTSRegexp, "1714557600.123456")
+}
+
+// ValidateSlackMessage checks channel and thread of message before it's posted
+func ValidateSlackMessage(options SlackMessageOptions) error {
+
+	v := common.NewValidation("Slack message")
+	validateSlackChannel(v, "channel", options.Channel)
+	validateSlackTS(v, "thread", options.Thread)
+	return v.Err()
+}
+
+func ValidateSlackFile(options SlackFileOptions) error {
+
+	v := common.NewValidation("Slack file")
+	validateSlackChannel(v, "channel", options.Channel)
+	validateSlackTS(v, "thread", options.Thread)
+	return v.Err()
+}
+
+func ValidateSlackReaction(options SlackReactionOptions) error {
+
+	v := common.NewValidation("Slack reaction")
+	validateSlackChannel(v, "channel", options.Channel)
+	validateSlackTS(v, "thread", options.Thread)
+	return v.Err()
+}
+
+func ValidateSlackUserEmail(options SlackUserEmail) error {
+
+	v := common.NewValidation("Slack user")
+	v.Email("email", options.Email)
+	return v.Err()
+}
+
+// ValidateGoogleEvent checks times, time zone and attendees of event, end is after start,
+// times without offset are valid if event has time zone
+func ValidateGoogleEvent(options GoogleCalendarInsertEventOptions) error {
+
+	v := common.NewValidation("Google event")
+	local := options.TimeZone != ""
+	start, okStart := v.Time("start", options.Start, local)
+	end, okEnd := v.Time("end", options.End, local)
+	if okStart && okEnd && !end.After(start) {
+		v.Issue("end %s isn't after start %s", options.End, options.Start)
+	}
+	v.TimeZone("time zone", options.TimeZone)
+	v.Emails("attendee", options.Attendees)
+	return v.Err()
+}
+
+// ValidateGoogleEvents checks range and time zone of events, Google requires offsets of range
+func ValidateGoogleEvents(options GoogleCalendarGetEventsOptions) error {
+
+	v := common.NewValidation("Google events")
+	min, okMin := v.Time("time min", options.TimeMin, false)
+	max, okMax := v.Time("time max", options.TimeMax, false)
+	if okMin && okMax && max.Before(min) {
+		v.Issue("time max %s is before time min %s", options.TimeMax, options.TimeMin)
+	}
+	v.TimeZone("time zone", options.TimeZone)
+	v.OneOf("order by", options.OrderBy, []string{"startTime", "updated"})
+	return v.Err()
+}
@@ -0,0 +1,383 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const (
+	vaultAuthToken      = "token"
+	vaultAuthAppRole    = "approle"
+	vaultAuthKubernetes = "kubernetes"
+	vaultKubernetesJWT  = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+type VaultOptions struct {
+	Timeout    int
+	Insecure   bool
+	URL        string
+	Namespace  string
+	AuthMethod string
+	AuthMount  string
+	Token      string
+	RoleID     string
+	SecretID   string
+	Role       string
+	JWTFile    string
+}
+
+type VaultKVOptions struct {
+	Mount   string
+	Path    string
+	Version int
+	Field   string
+	Data    string
+	CAS     int
+}
+
+type VaultCredsOptions struct {
+	Mount string
+	Role  string
+}
+
+type VaultAuth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int    `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+type VaultResponse struct {
+	RequestID     string          `json:"request_id,omitempty"`
+	LeaseID       string          `json:"lease_id,omitempty"`
+	LeaseDuration int             `json:"lease_duration,omitempty"`
+	Renewable     bool            `json:"renewable,omitempty"`
+	Data          json.RawMessage `json:"data,omitempty"`
+	Auth          *VaultAuth      `json:"auth,omitempty"`
+	Errors        []string        `json:"errors,omitempty"`
+}
+
+type VaultKVData struct {
+	Data     map[string]interface{} `json:"data"`
+	Metadata json.RawMessage        `json:"metadata,omitempty"`
+}
+
+type VaultKVWrite struct {
+	Options *struct {
+		CAS int `json:"cas"`
+	} `json:"options,omitempty"`
+	Data map[string]interface{} `json:"data"`
+}
+
+type vaultToken struct {
+	token   string
+	expires time.Time
+}
+
+type Vault struct {
+	client  *http.Client
+	options VaultOptions
+	tokens  map[string]*vaultToken
+	mutex   *sync.Mutex
+}
+
+func (v *Vault) apiURL(opts VaultOptions, p string, params url.Values) (string, error) {
+
+	if utils.IsEmpty(opts.URL) {
+		return "", errors.New("no URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, "/v1", p)
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+	return u.String(), nil
+}
+
+func (v *Vault) do(opts VaultOptions, token, method, p string, params url.Values, data []byte) (*VaultResponse, error) {
+
+	u, err := v.apiURL(opts, p, params)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	if !utils.IsEmpty(token) {
+		headers["X-Vault-Token"] = token
+	}
+	if !utils.IsEmpty(opts.Namespace) {
+		headers["X-Vault-Namespace"] = opts.Namespace
+	}
+
+	b, err := common.HttpRequestRawWithHeaders(v.client, method, u, headers, data)
+	if err != nil {
+		var r VaultResponse
+		if json.Unmarshal(b, &r) == nil && len(r.Errors) > 0 {
+			return nil, common.APIErrorMessage(err, strings.Join(r.Errors, ", "))
+		}
+		return nil, err
+	}
+	// writes without result return no content
+	r := &VaultResponse{}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// login returns token of auth method, tokens of approle and kubernetes are kept until their lease expires
+func (v *Vault) login(opts VaultOptions) (string, error) {
+
+	method := strings.ToLower(opts.AuthMethod)
+	if utils.IsEmpty(method) || method == vaultAuthToken {
+		if utils.IsEmpty(opts.Token) {
+			return "", errors.New("no token")
+		}
+		return opts.Token, nil
+	}
+
+	mount := opts.AuthMount
+	if utils.IsEmpty(mount) {
+		mount = method
+	}
+	key := strings.Join([]string{opts.URL, opts.Namespace, method, mount, opts.RoleID, opts.Role}, "/")
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if t, ok := v.tokens[key]; ok && time.Now().Before(t.expires) {
+		return t.token, nil
+	}
+
+	var login map[string]string
+	switch method {
+	case vaultAuthAppRole:
+		if utils.IsEmpty(opts.RoleID) {
+			return "", errors.New("no role ID")
+		}
+		login = map[string]string{"role_id": opts.RoleID, "secret_id": opts.SecretID}
+	case vaultAuthKubernetes:
+		if utils.IsEmpty(opts.Role) {
+			return "", errors.New("no role")
+		}
+		file := opts.JWTFile
+		if utils.IsEmpty(file) {
+			file = vaultKubernetesJWT
+		}
+		jwt, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		login = map[string]string{"role": opts.Role, "jwt": strings.TrimSpace(string(jwt))}
+	default:
+		return "", fmt.Errorf("unsupported auth method %s", opts.AuthMethod)
+	}
+
+	data, err := json.Marshal(login)
+	if err != nil {
+		return "", err
+	}
+	r, err := v.do(opts, "", "POST", path.Join("/auth", mount, "login"), nil, data)
+	if err != nil {
+		return "", err
+	}
+	if r.Auth == nil || utils.IsEmpty(r.Auth.ClientToken) {
+		return "", fmt.Errorf("no token of %s login", method)
+	}
+	// token is renewed a bit earlier than it expires
+	ttl := time.Duration(r.Auth.LeaseDuration) * time.Second * 9 / 10
+	v.tokens[key] = &vaultToken{token: r.Auth.ClientToken, expires: time.Now().Add(ttl)}
+	return r.Auth.ClientToken, nil
+}
+
+func (v *Vault) request(opts VaultOptions, method, p string, params url.Values, data []byte) (*VaultResponse, error) {
+
+	token, err := v.login(opts)
+	if err != nil {
+		return nil, err
+	}
+	return v.do(opts, token, method, p, params, data)
+}
+
+func (v *Vault) kvPath(kvOptions VaultKVOptions) (string, error) {
+
+	if utils.IsEmpty(kvOptions.Path) {
+		return "", errors.New("no path")
+	}
+	mount := kvOptions.Mount
+	if utils.IsEmpty(mount) {
+		mount = "secret"
+	}
+	return path.Join("/", mount, "data", kvOptions.Path), nil
+}
+
+// kvValues accepts JSON object or key=value pairs separated by comma
+func (v *Vault) kvValues(s string) (map[string]interface{}, error) {
+
+	r := make(map[string]interface{})
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "{") {
+		if err := json.Unmarshal([]byte(s), &r); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	for k, val := range utils.MapGetKeyValues(s) {
+		r[k] = val
+	}
+	return r, nil
+}
+
+func vaultField(data map[string]interface{}, field string) ([]byte, error) {
+
+	val, ok := data[field]
+	if !ok {
+		return nil, fmt.Errorf("no field %s", field)
+	}
+	if s, ok := val.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(val)
+}
+
+// https://developer.hashicorp.com/vault/api-docs/secret/kv/kv-v2#read-secret-version
+// result is data of secret, or value of field as is
+
+func (v *Vault) CustomReadKV(vaultOptions VaultOptions, kvOptions VaultKVOptions) ([]byte, error) {
+
+	p, err := v.kvPath(kvOptions)
+	if err != nil {
+		return nil, err
+	}
+	var params url.Values
+	if kvOptions.Version > 0 {
+		params = url.Values{"version": {strconv.Itoa(kvOptions.Version)}}
+	}
+	r, err := v.request(vaultOptions, "GET", p, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	var kv VaultKVData
+	if err := json.Unmarshal(r.Data, &kv); err != nil {
+		return nil, err
+	}
+	if !utils.IsEmpty(kvOptions.Field) {
+		return vaultField(kv.Data, kvOptions.Field)
+	}
+	return json.Marshal(kv.Data)
+}
+
+func (v *Vault) ReadKV(kvOptions VaultKVOptions) ([]byte, error) {
+	return v.CustomReadKV(v.options, kvOptions)
+}
+
+// https://developer.hashicorp.com/vault/api-docs/secret/kv/kv-v2#create-update-secret
+// data replaces secret as new version, check-and-set is used if CAS is set, result is version metadata
+
+func (v *Vault) CustomWriteKV(vaultOptions VaultOptions, kvOptions VaultKVOptions) ([]byte, error) {
+
+	p, err := v.kvPath(kvOptions)
+	if err != nil {
+		return nil, err
+	}
+	values, err := v.kvValues(kvOptions.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, errors.New("no data")
+	}
+	w := &VaultKVWrite{Data: values}
+	if kvOptions.CAS > 0 {
+		w.Options = &struct {
+			CAS int `json:"cas"`
+		}{CAS: kvOptions.CAS}
+	}
+	data, err := json.Marshal(w)
+	if err != nil {
+		return nil, err
+	}
+	r, err := v.request(vaultOptions, "POST", p, nil, data)
+	if err != nil {
+		return nil, err
+	}
+	return r.Data, nil
+}
+
+func (v *Vault) WriteKV(kvOptions VaultKVOptions) ([]byte, error) {
+	return v.CustomWriteKV(v.options, kvOptions)
+}
+
+// https://developer.hashicorp.com/vault/api-docs/secret/databases#generate-credentials
+// credentials of any engine having creds endpoint, e.g. database, aws, consul, result has lease
+
+func (v *Vault) CustomIssueCredentials(vaultOptions VaultOptions, credsOptions VaultCredsOptions) ([]byte, error) {
+
+	if utils.IsEmpty(credsOptions.Mount) {
+		return nil, errors.New("no mount")
+	}
+	if utils.IsEmpty(credsOptions.Role) {
+		return nil, errors.New("no role")
+	}
+	r, err := v.request(vaultOptions, "GET", path.Join("/", credsOptions.Mount, "creds", credsOptions.Role), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.Auth = nil
+	return json.Marshal(r)
+}
+
+func (v *Vault) IssueCredentials(credsOptions VaultCredsOptions) ([]byte, error) {
+	return v.CustomIssueCredentials(v.options, credsOptions)
+}
+
+// Secret returns field of KV v2 secret, path is mount/path, so that options of other vendors can refer to it
+func (v *Vault) Secret(p, field string) (string, error) {
+
+	mount, rest, ok := strings.Cut(strings.Trim(p, "/"), "/")
+	if !ok || utils.IsEmpty(rest) {
+		return "", fmt.Errorf("invalid path %s, mount/path is expected", p)
+	}
+	if utils.IsEmpty(field) {
+		return "", fmt.Errorf("no field of %s", p)
+	}
+	b, err := v.ReadKV(VaultKVOptions{Mount: mount, Path: rest, Field: field})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (v *Vault) WithContext(ctx context.Context) *Vault {
+	c := *v
+	c.client = common.HttpClientContext(v.client, ctx)
+	return &c
+}
+
+func NewVault(options VaultOptions) *Vault {
+
+	return &Vault{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+		tokens:  make(map[string]*vaultToken),
+		mutex:   &sync.Mutex{},
+	}
+}
@@ -1,6 +1,7 @@
 package vendors
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"net/url"
 	"path"
 
+	"github.com/devopsext/tools/common"
 	"github.com/devopsext/utils"
 )
 
@@ -83,7 +85,7 @@ func (vc *VCenter) getSession(opts VCenterOptions) (string, error) {
 	}
 	u.Path = path.Join(u.Path, VCenterRestSessionPath)
 
-	res, err := utils.HttpPostRaw(vc.client, u.String(), VCenterContentType, vc.getAuth(opts), nil)
+	res, err := common.HttpPostRaw(vc.client, u.String(), VCenterContentType, vc.getAuth(opts), nil)
 	if err != nil {
 		return "", err
 	}
@@ -127,7 +129,7 @@ func (vc *VCenter) CustomGetClusters(options VCenterOptions) ([]byte, error) {
 	}
 
 	u.Path = path.Join(u.Path, VCenterRestClusterPath)
-	return utils.HttpGetRawWithHeaders(vc.client, u.String(), vc.getHeaders(session))
+	return common.HttpGetRawWithHeaders(vc.client, u.String(), vc.getHeaders(session))
 }
 
 func (vc *VCenter) GetClusters() ([]byte, error) {
@@ -153,7 +155,7 @@ func (vc *VCenter) CustomGetHosts(options VCenterOptions, hostOptions VCenterHos
 
 	u.Path = path.Join(u.Path, VCenterRestHostPath)
 
-	return utils.HttpGetRawWithHeaders(vc.client, u.String(), vc.getHeaders(session))
+	return common.HttpGetRawWithHeaders(vc.client, u.String(), vc.getHeaders(session))
 }
 
 func (vc *VCenter) GetHosts(options VCenterHostOptions) ([]byte, error) {
@@ -180,7 +182,7 @@ func (vc *VCenter) CustomGetVMs(options VCenterOptions, vmOptions VCenterVMOptio
 
 	u.Path = path.Join(u.Path, VCenterRestVMPath)
 
-	return utils.HttpGetRawWithHeaders(vc.client, u.String(), vc.getHeaders(session))
+	return common.HttpGetRawWithHeaders(vc.client, u.String(), vc.getHeaders(session))
 }
 
 func (vc *VCenter) GetVMs(options VCenterVMOptions) ([]byte, error) {
@@ -200,7 +202,7 @@ func (vc *VCenter) CustomGetVMGuestIdentity(options VCenterOptions, vmGuestident
 
 	u.Path = path.Join(u.Path, fmt.Sprintf(VCenterRestVMGuestIdentityPathFmt, vmGuestidentity.VM))
 
-	return utils.HttpGetRawWithHeaders(vc.client, u.String(), vc.getHeaders(session))
+	return common.HttpGetRawWithHeaders(vc.client, u.String(), vc.getHeaders(session))
 }
 
 func (vc *VCenter) GetVMGuestIdentity(options VCenterVMGuestIdentityOptions) ([]byte, error) {
@@ -233,7 +235,7 @@ func (vc *VCenter) CustomGetVMsByName(options VCenterOptions, vmNameOptions VCen
 
 	u.Path = path.Join(u.Path, VCenterRestVMPath)
 
-	return utils.HttpGetRawWithHeaders(vc.client, u.String(), vc.getHeaders(session))
+	return common.HttpGetRawWithHeaders(vc.client, u.String(), vc.getHeaders(session))
 }
 
 func (vc *VCenter) CustomControlVMPower(options VCenterOptions, vmID string, action string) ([]byte, error) {
@@ -250,7 +252,7 @@ func (vc *VCenter) CustomControlVMPower(options VCenterOptions, vmID string, act
 	p := fmt.Sprintf(VCenterRestVMPowerPathFmt, vmID)
 	u.Path = path.Join(u.Path, fmt.Sprintf("%s/%s", p, action))
 
-	return utils.HttpPostRawWithHeaders(vc.client, u.String(), vc.getHeaders(session), nil)
+	return common.HttpPostRawWithHeaders(vc.client, u.String(), vc.getHeaders(session), nil)
 }
 
 func (vc *VCenter) CustomGetVM(options VCenterOptions, vmID string) ([]byte, error) {
@@ -266,7 +268,7 @@ func (vc *VCenter) CustomGetVM(options VCenterOptions, vmID string) ([]byte, err
 
 	u.Path = path.Join(u.Path, fmt.Sprintf("%s/%s", VCenterRestVMPath, vmID))
 
-	return utils.HttpGetRawWithHeaders(vc.client, u.String(), vc.getHeaders(session))
+	return common.HttpGetRawWithHeaders(vc.client, u.String(), vc.getHeaders(session))
 }
 
 func (vc *VCenter) StartVM(vmID string) ([]byte, error) {
@@ -285,16 +287,22 @@ func (vc *VCenter) GetVM(vmID string) ([]byte, error) {
 	return vc.CustomGetVM(vc.options, vmID)
 }
 
+func (vc *VCenter) WithContext(ctx context.Context) *VCenter {
+	c := *vc
+	c.client = common.HttpClientContext(vc.client, ctx)
+	return &c
+}
+
 func NewVCenter(options VCenterOptions) *VCenter {
 
 	return &VCenter{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 	}
 }
 
 func InitializeVCenterSession(options VCenterOptions) (VCenterOptions, error) {
-	client := utils.NewHttpClient(options.Timeout, options.Insecure)
+	client := common.NewHttpClient(options.Timeout, options.Insecure)
 
 	tempVC := &VCenter{
 		client:  client,
@@ -0,0 +1,325 @@
+package vendors
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+	"github.com/golang/snappy"
+)
+
+const (
+	VictoriaMetricsModeImport      = "import"
+	VictoriaMetricsModeRemoteWrite = "remote-write"
+
+	victoriaMetricsImportPath      = "/api/v1/import/prometheus"
+	victoriaMetricsRemoteWritePath = "/api/v1/write"
+	victoriaMetricsNameLabel       = "__name__"
+)
+
+type VictoriaMetricsOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	Mode     string
+	User     string
+	Password string
+	Token    string
+}
+
+type VictoriaMetricsPushOptions struct {
+	Metrics string
+	Labels  string
+}
+
+// VictoriaMetricsSample is sample of metric, timestamp is in milliseconds, now if it's 0
+type VictoriaMetricsSample struct {
+	Name      string
+	Labels    map[string]string
+	Value     float64
+	Timestamp int64
+}
+
+type VictoriaMetricsPushResult struct {
+	Mode    string `json:"mode"`
+	Samples int    `json:"samples"`
+}
+
+type VictoriaMetrics struct {
+	client  *http.Client
+	options VictoriaMetricsOptions
+}
+
+// victoriaMetricsParseLabels parses labels of {name="value",...}, s starts after {, the rest after } is returned
+func victoriaMetricsParseLabels(s string, labels map[string]string) (string, error) {
+
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "}") {
+			return s[1:], nil
+		}
+		eq := strings.Index(s, "=")
+		if eq <= 0 {
+			return "", fmt.Errorf("invalid label in %s", s)
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return "", fmt.Errorf("invalid label %s value", name)
+		}
+
+		var value strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			value.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return "", fmt.Errorf("invalid label %s value, no closing quote", name)
+		}
+		labels[name] = value.String()
+
+		s = strings.TrimLeft(s[i+1:], " \t")
+		s = strings.TrimPrefix(s, ",")
+	}
+}
+
+// ParseVictoriaMetricsSamples parses lines of Prometheus text format, e.g. job_duration_seconds{job="backup"} 12.5,
+// comments and empty lines are skipped
+func ParseVictoriaMetricsSamples(text string) ([]*VictoriaMetricsSample, error) {
+
+	samples := []*VictoriaMetricsSample{}
+	for _, line := range strings.Split(text, "\n") {
+
+		line = strings.TrimSpace(line)
+		if utils.IsEmpty(line) || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		end := strings.IndexAny(line, "{ \t")
+		if end <= 0 {
+			return nil, fmt.Errorf("invalid sample %s", line)
+		}
+		sample := &VictoriaMetricsSample{
+			Name:   line[:end],
+			Labels: make(map[string]string),
+		}
+		rest := line[end:]
+		if strings.HasPrefix(rest, "{") {
+			var err error
+			rest, err = victoriaMetricsParseLabels(rest[1:], sample.Labels)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sample %s: %s", line, err)
+			}
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("invalid sample %s, value and optional timestamp are expected", line)
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sample %s: %s", line, err)
+		}
+		sample.Value = value
+		if len(fields) == 2 {
+			sample.Timestamp, err = strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sample %s: %s", line, err)
+			}
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+func victoriaMetricsLabelNames(labels map[string]string) []string {
+
+	names := []string{}
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// victoriaMetricsText formats samples in Prometheus text format for import API
+func victoriaMetricsText(samples []*VictoriaMetricsSample) []byte {
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	var sb strings.Builder
+	for _, s := range samples {
+		sb.WriteString(s.Name)
+		if len(s.Labels) > 0 {
+			pairs := []string{}
+			for _, k := range victoriaMetricsLabelNames(s.Labels) {
+				pairs = append(pairs, fmt.Sprintf(`%s="%s"`, k, replacer.Replace(s.Labels[k])))
+			}
+			sb.WriteString("{" + strings.Join(pairs, ",") + "}")
+		}
+		sb.WriteString(" " + strconv.FormatFloat(s.Value, 'g', -1, 64))
+		sb.WriteString(" " + strconv.FormatInt(s.Timestamp, 10) + "\n")
+	}
+	return []byte(sb.String())
+}
+
+func victoriaMetricsProtoBytes(b []byte, field int, data []byte) []byte {
+
+	b = binary.AppendUvarint(b, uint64(field<<3|2))
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+// victoriaMetricsWriteRequest encodes prometheus.WriteRequest protobuf, each sample is series of its own
+// https://github.com/prometheus/prometheus/blob/main/prompb/remote.proto
+func victoriaMetricsWriteRequest(samples []*VictoriaMetricsSample) []byte {
+
+	var req []byte
+	for _, s := range samples {
+
+		labels := map[string]string{victoriaMetricsNameLabel: s.Name}
+		for k, v := range s.Labels {
+			labels[k] = v
+		}
+
+		var series []byte
+		for _, k := range victoriaMetricsLabelNames(labels) {
+			var label []byte
+			label = victoriaMetricsProtoBytes(label, 1, []byte(k))
+			label = victoriaMetricsProtoBytes(label, 2, []byte(labels[k]))
+			series = victoriaMetricsProtoBytes(series, 1, label)
+		}
+
+		var sample []byte
+		sample = binary.AppendUvarint(sample, 1<<3|1)
+		sample = binary.LittleEndian.AppendUint64(sample, math.Float64bits(s.Value))
+		sample = binary.AppendUvarint(sample, 2<<3|0)
+		sample = binary.AppendUvarint(sample, uint64(s.Timestamp))
+		series = victoriaMetricsProtoBytes(series, 2, sample)
+
+		req = victoriaMetricsProtoBytes(req, 1, series)
+	}
+	return req
+}
+
+func (vm *VictoriaMetrics) headers(opts VictoriaMetricsOptions) map[string]string {
+
+	headers := make(map[string]string)
+	if !utils.IsEmpty(opts.Token) {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", opts.Token)
+	} else if !utils.IsEmpty(opts.User) {
+		headers["Authorization"] = common.FormatBasicAuth(opts.User, opts.Password)
+	}
+	return headers
+}
+
+// https://docs.victoriametrics.com/#how-to-import-data-in-prometheus-exposition-format
+// https://prometheus.io/docs/specs/remote_write_spec/
+// samples are pushed by import API or remote write, which Prometheus receiver, Mimir and vminsert accept as well
+
+func (vm *VictoriaMetrics) CustomPushSamples(victoriaMetricsOptions VictoriaMetricsOptions, samples []*VictoriaMetricsSample) ([]byte, error) {
+
+	if utils.IsEmpty(victoriaMetricsOptions.URL) {
+		return nil, errors.New("no URL")
+	}
+	if len(samples) == 0 {
+		return nil, errors.New("no samples")
+	}
+	now := time.Now().UnixMilli()
+	for _, s := range samples {
+		if s.Timestamp == 0 {
+			s.Timestamp = now
+		}
+	}
+
+	u, err := url.Parse(victoriaMetricsOptions.URL)
+	if err != nil {
+		return nil, err
+	}
+	headers := vm.headers(victoriaMetricsOptions)
+
+	mode := victoriaMetricsOptions.Mode
+	var data []byte
+	switch mode {
+	case "", VictoriaMetricsModeImport:
+		mode = VictoriaMetricsModeImport
+		u.Path = path.Join(u.Path, victoriaMetricsImportPath)
+		headers["Content-Type"] = "text/plain"
+		data = victoriaMetricsText(samples)
+	case VictoriaMetricsModeRemoteWrite:
+		u.Path = path.Join(u.Path, victoriaMetricsRemoteWritePath)
+		headers["Content-Type"] = "application/x-protobuf"
+		headers["Content-Encoding"] = "snappy"
+		headers["X-Prometheus-Remote-Write-Version"] = "0.1.0"
+		data = snappy.Encode(nil, victoriaMetricsWriteRequest(samples))
+	default:
+		return nil, fmt.Errorf("unsupported mode %s", mode)
+	}
+
+	_, err = common.HttpPostRawWithHeaders(vm.client, u.String(), headers, data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&VictoriaMetricsPushResult{Mode: mode, Samples: len(samples)})
+}
+
+func (vm *VictoriaMetrics) PushSamples(samples []*VictoriaMetricsSample) ([]byte, error) {
+	return vm.CustomPushSamples(vm.options, samples)
+}
+
+// metrics are in Prometheus text format, labels are added to each sample
+
+func (vm *VictoriaMetrics) CustomPush(victoriaMetricsOptions VictoriaMetricsOptions, pushOptions VictoriaMetricsPushOptions) ([]byte, error) {
+
+	samples, err := ParseVictoriaMetricsSamples(pushOptions.Metrics)
+	if err != nil {
+		return nil, err
+	}
+	labels := utils.MapGetKeyValues(pushOptions.Labels)
+	for _, s := range samples {
+		for k, v := range labels {
+			s.Labels[k] = v
+		}
+	}
+	return vm.CustomPushSamples(victoriaMetricsOptions, samples)
+}
+
+func (vm *VictoriaMetrics) Push(pushOptions VictoriaMetricsPushOptions) ([]byte, error) {
+	return vm.CustomPush(vm.options, pushOptions)
+}
+
+func (vm *VictoriaMetrics) WithContext(ctx context.Context) *VictoriaMetrics {
+	c := *vm
+	c.client = common.HttpClientContext(vm.client, ctx)
+	return &c
+}
+
+func NewVictoriaMetrics(options VictoriaMetricsOptions) *VictoriaMetrics {
+
+	return &VictoriaMetrics{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
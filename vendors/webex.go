@@ -0,0 +1,276 @@
+package vendors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const webexBaseURL = "https://webexapis.com/v1"
+
+type WebexOptions struct {
+	Timeout  int
+	Insecure bool
+	URL      string
+	Token    string
+}
+
+type WebexRoomOptions struct {
+	Title  string
+	TeamID string
+}
+
+type WebexMessageOptions struct {
+	Room          string
+	ToPersonEmail string
+	Parent        string
+	Text          string
+	Markdown      string
+	Attachments   string
+}
+
+type WebexFileOptions struct {
+	WebexMessageOptions
+	Name string
+	File string
+}
+
+type WebexMembershipsOptions struct {
+	Room        string
+	PersonEmail string
+	Max         int
+}
+
+type WebexRoom struct {
+	Title  string `json:"title"`
+	TeamID string `json:"teamId,omitempty"`
+}
+
+type WebexMessage struct {
+	RoomID        string        `json:"roomId,omitempty"`
+	ToPersonEmail string        `json:"toPersonEmail,omitempty"`
+	ParentID      string        `json:"parentId,omitempty"`
+	Text          string        `json:"text,omitempty"`
+	Markdown      string        `json:"markdown,omitempty"`
+	Attachments   []interface{} `json:"attachments,omitempty"`
+}
+
+type webexError struct {
+	Message string `json:"message"`
+}
+
+type Webex struct {
+	client  *http.Client
+	options WebexOptions
+}
+
+func (w *Webex) apiURL(opts WebexOptions, p string, params url.Values) (string, error) {
+
+	base := opts.URL
+	if utils.IsEmpty(base) {
+		base = webexBaseURL
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, p)
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+	return u.String(), nil
+}
+
+func (w *Webex) request(opts WebexOptions, method, p string, params url.Values, contentType string, data []byte) ([]byte, error) {
+
+	if utils.IsEmpty(opts.Token) {
+		return nil, errors.New("no token")
+	}
+	u, err := w.apiURL(opts, p, params)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", opts.Token),
+		"Content-Type":  contentType,
+	}
+	b, err := common.HttpRequestRawWithHeaders(w.client, method, u, headers, data)
+	if err != nil {
+		var e webexError
+		if json.Unmarshal(b, &e) == nil && !utils.IsEmpty(e.Message) {
+			return nil, common.APIErrorMessage(err, e.Message)
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// message goes to room, or directly to person if there is no room
+func (w *Webex) message(messageOptions WebexMessageOptions) (*WebexMessage, error) {
+
+	if utils.IsEmpty(messageOptions.Room) && utils.IsEmpty(messageOptions.ToPersonEmail) {
+		return nil, errors.New("no room or person email")
+	}
+	m := &WebexMessage{
+		RoomID:        messageOptions.Room,
+		ToPersonEmail: messageOptions.ToPersonEmail,
+		ParentID:      messageOptions.Parent,
+		Text:          messageOptions.Text,
+		Markdown:      messageOptions.Markdown,
+	}
+	if !utils.IsEmpty(messageOptions.Attachments) {
+		var attachments []interface{}
+		if err := json.Unmarshal([]byte(messageOptions.Attachments), &attachments); err != nil {
+			return nil, err
+		}
+		m.Attachments = attachments
+	}
+	return m, nil
+}
+
+// https://developer.webex.com/docs/api/v1/rooms/create-a-room
+
+func (w *Webex) CustomCreateRoom(webexOptions WebexOptions, roomOptions WebexRoomOptions) ([]byte, error) {
+
+	if utils.IsEmpty(roomOptions.Title) {
+		return nil, errors.New("no title")
+	}
+	data, err := json.Marshal(&WebexRoom{
+		Title:  roomOptions.Title,
+		TeamID: roomOptions.TeamID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w.request(webexOptions, "POST", "/rooms", nil, "application/json", data)
+}
+
+func (w *Webex) CreateRoom(roomOptions WebexRoomOptions) ([]byte, error) {
+	return w.CustomCreateRoom(w.options, roomOptions)
+}
+
+// https://developer.webex.com/docs/api/v1/messages/create-a-message
+// message is threaded if there is parent, attachments are adaptive cards
+
+func (w *Webex) CustomSendMessage(webexOptions WebexOptions, messageOptions WebexMessageOptions) ([]byte, error) {
+
+	m, err := w.message(messageOptions)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return w.request(webexOptions, "POST", "/messages", nil, "application/json", data)
+}
+
+func (w *Webex) SendMessage(messageOptions WebexMessageOptions) ([]byte, error) {
+	return w.CustomSendMessage(w.options, messageOptions)
+}
+
+// https://developer.webex.com/docs/basics#message-attachments
+// one file is allowed per message, it's sent with text or markdown
+
+func (w *Webex) CustomSendFile(webexOptions WebexOptions, fileOptions WebexFileOptions) ([]byte, error) {
+
+	m, err := w.message(fileOptions.WebexMessageOptions)
+	if err != nil {
+		return nil, err
+	}
+	if utils.IsEmpty(fileOptions.Name) {
+		return nil, errors.New("no file name")
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	fields := [][2]string{
+		{"roomId", m.RoomID},
+		{"toPersonEmail", m.ToPersonEmail},
+		{"parentId", m.ParentID},
+		{"text", m.Text},
+		{"markdown", m.Markdown},
+	}
+	for _, f := range fields {
+		if utils.IsEmpty(f[1]) {
+			continue
+		}
+		if err := mw.WriteField(f[0], f[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	fw, err := mw.CreateFormFile("files", fileOptions.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write([]byte(fileOptions.File)); err != nil {
+		return nil, err
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return w.request(webexOptions, "POST", "/messages", nil, mw.FormDataContentType(), body.Bytes())
+}
+
+func (w *Webex) SendFile(fileOptions WebexFileOptions) ([]byte, error) {
+	return w.CustomSendFile(w.options, fileOptions)
+}
+
+// https://developer.webex.com/docs/api/v1/memberships/list-memberships
+// memberships of room, or rooms of person if there is no room
+
+func (w *Webex) CustomGetMemberships(webexOptions WebexOptions, membershipsOptions WebexMembershipsOptions) ([]byte, error) {
+
+	params := url.Values{}
+	if !utils.IsEmpty(membershipsOptions.Room) {
+		params.Set("roomId", membershipsOptions.Room)
+	}
+	if !utils.IsEmpty(membershipsOptions.PersonEmail) {
+		params.Set("personEmail", membershipsOptions.PersonEmail)
+	}
+	if membershipsOptions.Max > 0 {
+		params.Set("max", strconv.Itoa(membershipsOptions.Max))
+	}
+	return w.request(webexOptions, "GET", "/memberships", params, "application/json", nil)
+}
+
+func (w *Webex) GetMemberships(membershipsOptions WebexMembershipsOptions) ([]byte, error) {
+	return w.CustomGetMemberships(w.options, membershipsOptions)
+}
+
+// https://developer.webex.com/docs/api/v1/people/get-my-own-details
+// person of token, e.g. to verify it
+
+func (w *Webex) CustomGetMe(webexOptions WebexOptions) ([]byte, error) {
+	return w.request(webexOptions, "GET", "/people/me", nil, "application/json", nil)
+}
+
+func (w *Webex) GetMe() ([]byte, error) {
+	return w.CustomGetMe(w.options)
+}
+
+func (w *Webex) WithContext(ctx context.Context) *Webex {
+	c := *w
+	c.client = common.HttpClientContext(w.client, ctx)
+	return &c
+}
+
+func NewWebex(options WebexOptions) *Webex {
+
+	return &Webex{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
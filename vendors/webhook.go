@@ -0,0 +1,256 @@
+package vendors
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devopsext/tools/common"
+	"github.com/devopsext/utils"
+)
+
+const (
+	WebhookFormatJSON = "json"
+	WebhookFormatForm = "form"
+
+	WebhookSignatureGithub = "github"
+	WebhookSignatureSlack  = "slack"
+	WebhookSignatureHmac   = "hmac"
+
+	webhookSignatureHeader = "X-Signature"
+)
+
+type WebhookOptions struct {
+	Timeout         int
+	Insecure        bool
+	URL             string
+	Method          string
+	Headers         string
+	Secret          string
+	Signature       string
+	SignatureHeader string
+	Retries         int
+	RetryDelay      int
+}
+
+type WebhookSendOptions struct {
+	Payload     string
+	Format      string
+	ExpectCodes []int
+	ExpectBody  string
+	ExpectQuery string
+}
+
+type WebhookResponse struct {
+	Code     int         `json:"code"`
+	Attempts int         `json:"attempts"`
+	Body     interface{} `json:"body,omitempty"`
+}
+
+type Webhook struct {
+	client  *http.Client
+	options WebhookOptions
+}
+
+// body returns payload as JSON or form, form payload may be JSON object or already encoded
+func (w *Webhook) body(sendOptions WebhookSendOptions) ([]byte, string, error) {
+
+	switch strings.ToLower(sendOptions.Format) {
+	case "", WebhookFormatJSON:
+		if !json.Valid([]byte(sendOptions.Payload)) {
+			return nil, "", errors.New("invalid JSON payload")
+		}
+		return []byte(sendOptions.Payload), "application/json", nil
+	case WebhookFormatForm:
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(sendOptions.Payload), &obj); err != nil {
+			return []byte(sendOptions.Payload), "application/x-www-form-urlencoded", nil
+		}
+		values := url.Values{}
+		for k, v := range obj {
+			switch vt := v.(type) {
+			case []interface{}:
+				for _, i := range vt {
+					values.Add(k, fmt.Sprintf("%v", i))
+				}
+			case map[string]interface{}:
+				b, err := json.Marshal(vt)
+				if err != nil {
+					return nil, "", err
+				}
+				values.Set(k, string(b))
+			default:
+				values.Set(k, fmt.Sprintf("%v", vt))
+			}
+		}
+		return []byte(values.Encode()), "application/x-www-form-urlencoded", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported format %s", sendOptions.Format)
+	}
+}
+
+// sign sets signature headers, GitHub and Slack styles are supported as well as plain HMAC SHA256 in header
+func (w *Webhook) sign(opts WebhookOptions, headers map[string]string, data []byte) error {
+
+	signature := strings.ToLower(opts.Signature)
+	if utils.IsEmpty(signature) {
+		return nil
+	}
+	if utils.IsEmpty(opts.Secret) {
+		return errors.New("no secret")
+	}
+	mac := hmac.New(sha256.New, []byte(opts.Secret))
+
+	switch signature {
+	case WebhookSignatureGithub:
+		mac.Write(data)
+		headers["X-Hub-Signature-256"] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	case WebhookSignatureSlack:
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		mac.Write([]byte("v0:" + ts + ":"))
+		mac.Write(data)
+		headers["X-Slack-Request-Timestamp"] = ts
+		headers["X-Slack-Signature"] = "v0=" + hex.EncodeToString(mac.Sum(nil))
+	case WebhookSignatureHmac:
+		header := opts.SignatureHeader
+		if utils.IsEmpty(header) {
+			header = webhookSignatureHeader
+		}
+		mac.Write(data)
+		headers[header] = hex.EncodeToString(mac.Sum(nil))
+	default:
+		return fmt.Errorf("unsupported signature %s", opts.Signature)
+	}
+	return nil
+}
+
+func (w *Webhook) expected(sendOptions WebhookSendOptions, code int) bool {
+
+	if len(sendOptions.ExpectCodes) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, c := range sendOptions.ExpectCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// assert checks body by regex and by JSONata query, which should be true
+func (w *Webhook) assert(sendOptions WebhookSendOptions, body []byte) error {
+
+	if !utils.IsEmpty(sendOptions.ExpectBody) {
+		re, err := regexp.Compile(sendOptions.ExpectBody)
+		if err != nil {
+			return err
+		}
+		if !re.Match(body) {
+			return fmt.Errorf("response doesn't match %s: %s", sendOptions.ExpectBody, body)
+		}
+	}
+	if !utils.IsEmpty(sendOptions.ExpectQuery) {
+		var obj interface{}
+		if err := json.Unmarshal(body, &obj); err != nil {
+			return fmt.Errorf("response isn't JSON for query %s: %s", sendOptions.ExpectQuery, err)
+		}
+		v, err := common.NewJsonata(common.JsonataOptions{}).Eval(obj, sendOptions.ExpectQuery)
+		if err != nil {
+			return err
+		}
+		if b, ok := v.(bool); !ok || !b {
+			return fmt.Errorf("response doesn't satisfy %s: %s", sendOptions.ExpectQuery, body)
+		}
+	}
+	return nil
+}
+
+// webhookRetryable are network errors, rate limits and server errors, unless they are expected
+func webhookRetryable(code int) bool {
+	return code == 0 || code == http.StatusTooManyRequests || code >= 500
+}
+
+// https://docs.github.com/en/webhooks/using-webhooks/validating-webhook-deliveries
+// https://api.slack.com/authentication/verifying-requests-from-slack
+// payload is sent to any URL, it's retried with exponential delay, response is checked by code, regex and query
+
+func (w *Webhook) CustomSend(webhookOptions WebhookOptions, sendOptions WebhookSendOptions) ([]byte, error) {
+
+	if utils.IsEmpty(webhookOptions.URL) {
+		return nil, errors.New("no URL")
+	}
+	method := strings.ToUpper(webhookOptions.Method)
+	if utils.IsEmpty(method) {
+		method = "POST"
+	}
+	data, contentType, err := w.body(sendOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	delay := time.Duration(webhookOptions.RetryDelay) * time.Second
+	r := &WebhookResponse{}
+	var body []byte
+	for {
+		r.Attempts++
+
+		headers := map[string]string{"Content-Type": contentType}
+		for k, v := range utils.MapGetKeyValues(webhookOptions.Headers) {
+			headers[k] = v
+		}
+		if err := w.sign(webhookOptions, headers, data); err != nil {
+			return nil, err
+		}
+
+		body, r.Code, err = common.HttpRequestRawWithHeadersOutCode(w.client, method, webhookOptions.URL, headers, data)
+		if w.expected(sendOptions, r.Code) {
+			break
+		}
+		if !webhookRetryable(r.Code) || r.Attempts > webhookOptions.Retries {
+			if r.Code == 0 {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%d %s: %s", r.Code, http.StatusText(r.Code), body)
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	if err := w.assert(sendOptions, body); err != nil {
+		return nil, err
+	}
+	if json.Valid(body) {
+		r.Body = json.RawMessage(body)
+	} else if len(body) > 0 {
+		r.Body = string(body)
+	}
+	return json.Marshal(r)
+}
+
+func (w *Webhook) Send(sendOptions WebhookSendOptions) ([]byte, error) {
+	return w.CustomSend(w.options, sendOptions)
+}
+
+func (w *Webhook) WithContext(ctx context.Context) *Webhook {
+	c := *w
+	c.client = common.HttpClientContext(w.client, ctx)
+	return &c
+}
+
+func NewWebhook(options WebhookOptions) *Webhook {
+
+	return &Webhook{
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
+		options: options,
+	}
+}
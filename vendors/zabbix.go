@@ -1,10 +1,14 @@
 package vendors
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"path"
+	"time"
 
 	"github.com/devopsext/tools/common"
 	"github.com/devopsext/utils"
@@ -16,6 +20,34 @@ type ZabbixHostOptions struct {
 	Interfaces []string
 }
 
+// ZabbixAcknowledgeOptions acknowledges events, severity is changed if it isn't negative
+type ZabbixAcknowledgeOptions struct {
+	EventIDs []string
+	Message  string
+	Close    bool
+	Severity int
+}
+
+// ZabbixMaintenanceOptions creates maintenance of hosts and groups, it starts now if start is empty
+type ZabbixMaintenanceOptions struct {
+	Name        string
+	Description string
+	HostIDs     []string
+	GroupIDs    []string
+	StartsAt    string
+	Duration    string
+	NoData      bool
+}
+
+// ZabbixTriggerOptions gets triggers in problem state, or all if problems is false
+type ZabbixTriggerOptions struct {
+	HostIDs     []string
+	GroupIDs    []string
+	MinSeverity int
+	Problems    bool
+	Limit       int
+}
+
 type ZabbixOptions struct {
 	Timeout  int
 	Insecure bool
@@ -60,12 +92,64 @@ type ZabbixHostGet struct {
 	ID      int                  `json:"id"`
 }
 
+type ZabbixRequest struct {
+	JsonRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+	Auth    string      `json:"auth,omitempty"`
+	ID      int         `json:"id"`
+}
+
+type ZabbixError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data"`
+}
+
+type ZabbixResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *ZabbixError    `json:"error,omitempty"`
+}
+
+type zabbixHostID struct {
+	HostID string `json:"hostid"`
+}
+
+type zabbixGroupID struct {
+	GroupID string `json:"groupid"`
+}
+
+type zabbixTimePeriod struct {
+	TimePeriodType int   `json:"timeperiod_type"`
+	StartDate      int64 `json:"start_date"`
+	Period         int64 `json:"period"`
+}
+
+type zabbixMaintenance struct {
+	Name            string              `json:"name"`
+	Description     string              `json:"description,omitempty"`
+	ActiveSince     int64               `json:"active_since"`
+	ActiveTill      int64               `json:"active_till"`
+	MaintenanceType int                 `json:"maintenance_type"`
+	Hosts           []*zabbixHostID     `json:"hosts,omitempty"`
+	Groups          []*zabbixGroupID    `json:"groups,omitempty"`
+	TimePeriods     []*zabbixTimePeriod `json:"timeperiods"`
+}
+
 const (
 	zabbixContentType    = "application/json-rpc"
 	zabbixJsonRpcPath    = "/api_jsonrpc.php"
 	zabbixJsonRpcVersion = "2.0"
 )
 
+// actions of event.acknowledge, they're combined
+const (
+	zabbixActionClose       = 1
+	zabbixActionAcknowledge = 2
+	zabbixActionMessage     = 4
+	zabbixActionSeverity    = 8
+)
+
 func (o *Zabbix) getZabbixAuth(opts ZabbixOptions) (*ZabbixUserLoginResponse, error) {
 
 	u, err := url.Parse(opts.URL)
@@ -90,7 +174,7 @@ func (o *Zabbix) getZabbixAuth(opts ZabbixOptions) (*ZabbixUserLoginResponse, er
 		return nil, err
 	}
 
-	res, err := utils.HttpPostRaw(o.client, u.String(), zabbixContentType, "", req)
+	res, err := common.HttpPostRaw(o.client, u.String(), zabbixContentType, "", req)
 	if err != nil {
 		return nil, err
 	}
@@ -103,6 +187,59 @@ func (o *Zabbix) getZabbixAuth(opts ZabbixOptions) (*ZabbixUserLoginResponse, er
 	return &zr, nil
 }
 
+func (o *Zabbix) auth(opts ZabbixOptions) (string, error) {
+
+	if !utils.IsEmpty(opts.Auth) {
+		return opts.Auth, nil
+	}
+	za, err := o.getZabbixAuth(opts)
+	if err != nil {
+		return "", err
+	}
+	if utils.IsEmpty(za.Result) {
+		return "", errors.New("no auth of user")
+	}
+	return za.Result, nil
+}
+
+// request calls method, result is result of response, error of response is error
+func (o *Zabbix) request(opts ZabbixOptions, method string, params interface{}) ([]byte, error) {
+
+	auth, err := o.auth(opts)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, zabbixJsonRpcPath)
+
+	req, err := json.Marshal(&ZabbixRequest{
+		JsonRPC: zabbixJsonRpcVersion,
+		Method:  method,
+		Params:  params,
+		Auth:    auth,
+		ID:      1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := common.HttpPostRaw(o.client, u.String(), zabbixContentType, "", req)
+	if err != nil {
+		return nil, err
+	}
+	var r ZabbixResponse
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	if r.Error != nil {
+		return nil, fmt.Errorf("%s: %s %s", method, r.Error.Message, r.Error.Data)
+	}
+	return r.Result, nil
+}
+
 func (o *Zabbix) CustomGetHosts(options ZabbixOptions, hostOptions ZabbixHostOptions) ([]byte, error) {
 
 	auth := options.Auth
@@ -139,17 +276,150 @@ func (o *Zabbix) CustomGetHosts(options ZabbixOptions, hostOptions ZabbixHostOpt
 		return nil, err
 	}
 
-	return utils.HttpPostRaw(o.client, u.String(), zabbixContentType, "", req)
+	return common.HttpPostRaw(o.client, u.String(), zabbixContentType, "", req)
 }
 
 func (o *Zabbix) GetHosts(options ZabbixHostOptions) ([]byte, error) {
 	return o.CustomGetHosts(o.options, options)
 }
 
+// https://www.zabbix.com/documentation/current/en/manual/api/reference/event/acknowledge
+// events are acknowledged with message, and closed or changed severity if it's set
+
+func (o *Zabbix) CustomAcknowledge(options ZabbixOptions, acknowledgeOptions ZabbixAcknowledgeOptions) ([]byte, error) {
+
+	ids := common.RemoveEmptyStrings(acknowledgeOptions.EventIDs)
+	if len(ids) == 0 {
+		return nil, errors.New("no event IDs")
+	}
+	action := zabbixActionAcknowledge
+	params := map[string]interface{}{"eventids": ids}
+	if !utils.IsEmpty(acknowledgeOptions.Message) {
+		action |= zabbixActionMessage
+		params["message"] = acknowledgeOptions.Message
+	}
+	if acknowledgeOptions.Close {
+		action |= zabbixActionClose
+	}
+	if acknowledgeOptions.Severity >= 0 {
+		if acknowledgeOptions.Severity > 5 {
+			return nil, fmt.Errorf("severity %d isn't 0-5", acknowledgeOptions.Severity)
+		}
+		action |= zabbixActionSeverity
+		params["severity"] = acknowledgeOptions.Severity
+	}
+	params["action"] = action
+	return o.request(options, "event.acknowledge", params)
+}
+
+func (o *Zabbix) Acknowledge(options ZabbixAcknowledgeOptions) ([]byte, error) {
+	return o.CustomAcknowledge(o.options, options)
+}
+
+// https://www.zabbix.com/documentation/current/en/manual/api/reference/maintenance/create
+// maintenance has one time period from start for duration, data is collected unless no data is set
+
+func (o *Zabbix) CustomCreateMaintenance(options ZabbixOptions, maintenanceOptions ZabbixMaintenanceOptions) ([]byte, error) {
+
+	if utils.IsEmpty(maintenanceOptions.Name) {
+		return nil, errors.New("no maintenance name")
+	}
+	hosts := common.RemoveEmptyStrings(maintenanceOptions.HostIDs)
+	groups := common.RemoveEmptyStrings(maintenanceOptions.GroupIDs)
+	if len(hosts) == 0 && len(groups) == 0 {
+		return nil, errors.New("no host IDs and no group IDs")
+	}
+
+	start := time.Now()
+	if !utils.IsEmpty(maintenanceOptions.StartsAt) {
+		t, err := time.Parse(time.RFC3339, maintenanceOptions.StartsAt)
+		if err != nil {
+			return nil, err
+		}
+		start = t
+	}
+	duration, err := time.ParseDuration(maintenanceOptions.Duration)
+	if err != nil {
+		return nil, err
+	}
+	if duration < time.Minute {
+		return nil, fmt.Errorf("duration %s is less than a minute", maintenanceOptions.Duration)
+	}
+
+	m := &zabbixMaintenance{
+		Name:        maintenanceOptions.Name,
+		Description: maintenanceOptions.Description,
+		ActiveSince: start.Unix(),
+		ActiveTill:  start.Add(duration).Unix(),
+		TimePeriods: []*zabbixTimePeriod{
+			{TimePeriodType: 0, StartDate: start.Unix(), Period: int64(duration.Seconds())},
+		},
+	}
+	if maintenanceOptions.NoData {
+		m.MaintenanceType = 1
+	}
+	for _, id := range hosts {
+		m.Hosts = append(m.Hosts, &zabbixHostID{HostID: id})
+	}
+	for _, id := range groups {
+		m.Groups = append(m.Groups, &zabbixGroupID{GroupID: id})
+	}
+	return o.request(options, "maintenance.create", m)
+}
+
+func (o *Zabbix) CreateMaintenance(options ZabbixMaintenanceOptions) ([]byte, error) {
+	return o.CustomCreateMaintenance(o.options, options)
+}
+
+// https://www.zabbix.com/documentation/current/en/manual/api/reference/trigger/get
+// triggers are of monitored hosts, the most severe first, with hosts and last event
+
+func (o *Zabbix) CustomGetTriggers(options ZabbixOptions, triggerOptions ZabbixTriggerOptions) ([]byte, error) {
+
+	params := map[string]interface{}{
+		"output":            []string{"triggerid", "description", "priority", "value", "lastchange", "comments"},
+		"selectHosts":       []string{"hostid", "host", "name"},
+		"selectLastEvent":   []string{"eventid", "clock", "acknowledged", "severity"},
+		"expandDescription": true,
+		"monitored":         true,
+		"active":            true,
+		"skipDependent":     true,
+		"sortfield":         []string{"priority", "lastchange"},
+		"sortorder":         "DESC",
+	}
+	if hosts := common.RemoveEmptyStrings(triggerOptions.HostIDs); len(hosts) > 0 {
+		params["hostids"] = hosts
+	}
+	if groups := common.RemoveEmptyStrings(triggerOptions.GroupIDs); len(groups) > 0 {
+		params["groupids"] = groups
+	}
+	if triggerOptions.MinSeverity > 0 {
+		params["min_severity"] = triggerOptions.MinSeverity
+	}
+	if triggerOptions.Problems {
+		params["only_true"] = true
+		params["filter"] = map[string]interface{}{"value": 1}
+	}
+	if triggerOptions.Limit > 0 {
+		params["limit"] = triggerOptions.Limit
+	}
+	return o.request(options, "trigger.get", params)
+}
+
+func (o *Zabbix) GetTriggers(options ZabbixTriggerOptions) ([]byte, error) {
+	return o.CustomGetTriggers(o.options, options)
+}
+
+func (o *Zabbix) WithContext(ctx context.Context) *Zabbix {
+	c := *o
+	c.client = common.HttpClientContext(o.client, ctx)
+	return &c
+}
+
 func NewZabbix(options ZabbixOptions) *Zabbix {
 
 	return &Zabbix{
-		client:  utils.NewHttpClient(options.Timeout, options.Insecure),
+		client:  common.NewHttpClient(options.Timeout, options.Insecure),
 		options: options,
 	}
 }